@@ -65,7 +65,7 @@ Use Glazed output flags for machine-readable output, for example:
 			),
 			fields.New("embeddings-type", fields.TypeString,
 				fields.WithDefault("openai"),
-				fields.WithHelp("Embedding provider type: openai or ollama"),
+				fields.WithHelp("Embedding provider type: openai, ollama, gemini, cohere, voyage, tei or llamacpp"),
 			),
 			fields.New("embeddings-engine", fields.TypeString,
 				fields.WithDefault("text-embedding-3-small"),
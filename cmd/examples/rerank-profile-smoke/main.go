@@ -103,7 +103,7 @@ Use Glazed output flags for machine-readable output, for example:
 			),
 			fields.New("rerank-type", fields.TypeString,
 				fields.WithDefault("llamacpp"),
-				fields.WithHelp("Rerank provider type (only llamacpp supported)"),
+				fields.WithHelp("Rerank provider type for a local server (llamacpp, openai-compatible or tei)"),
 			),
 			fields.New("rerank-engine", fields.TypeString,
 				fields.WithDefault("qllama/bge-reranker-v2-m3:q4_k_m"),
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	geppettobootstrap "github.com/go-go-golems/geppetto/pkg/cli/bootstrap"
+	"github.com/go-go-golems/geppetto/pkg/inference/runner"
+	geppettosections "github.com/go-go-golems/geppetto/pkg/sections"
+	aisettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/glazed/pkg/cli"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/schema"
+	"github.com/go-go-golems/glazed/pkg/cmds/sources"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	glazedconfig "github.com/go-go-golems/glazed/pkg/config"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	appName   = "geppetto"
+	envPrefix = "GEPPETTO"
+)
+
+// appBootstrapConfig is the bootstrap contract of the geppetto binary. Config
+// is read from the usual system, home and XDG locations for "geppetto", and
+// profiles default to ${XDG_CONFIG_HOME:-~/.config}/geppetto/profiles.yaml.
+func appBootstrapConfig() geppettobootstrap.AppBootstrapConfig {
+	cfg := geppettobootstrap.AppBootstrapConfig{
+		AppName:          appName,
+		EnvPrefix:        envPrefix,
+		ConfigFileMapper: geppettobootstrap.DefaultConfigFileMapper,
+		NewProfileSection: func() (schema.Section, error) {
+			return geppettosections.NewProfileSettingsSection()
+		},
+		BuildBaseSections: func() ([]schema.Section, error) {
+			return geppettosections.CreateGeppettoSections()
+		},
+	}
+	cfg.ConfigPlanBuilder = func(parsed *values.Values) (*glazedconfig.Plan, error) {
+		explicit := ""
+		if parsed != nil {
+			commandSettings := &cli.CommandSettings{}
+			if err := parsed.DecodeSectionInto(cli.CommandSettingsSlug, commandSettings); err == nil {
+				explicit = strings.TrimSpace(commandSettings.ConfigFile)
+			}
+		}
+
+		return glazedconfig.NewPlan(
+			glazedconfig.WithLayerOrder(
+				glazedconfig.LayerSystem,
+				glazedconfig.LayerUser,
+				glazedconfig.LayerExplicit,
+			),
+			glazedconfig.WithDedupePaths(),
+		).Add(
+			glazedconfig.SystemAppConfig(cfg.AppName).Named("system-app-config").Kind("app-config"),
+			glazedconfig.HomeAppConfig(cfg.AppName).Named("home-app-config").Kind("app-config"),
+			glazedconfig.XDGAppConfig(cfg.AppName).Named("xdg-app-config").Kind("app-config"),
+			glazedconfig.ExplicitFile(explicit).Named("explicit-config-file").Kind("explicit-file"),
+		), nil
+	}
+	return cfg
+}
+
+// getCobraCommandMiddlewares merges flags, arguments, environment, config files
+// and defaults, in that order of precedence.
+func getCobraCommandMiddlewares(parsed *values.Values, cmd *cobra.Command, args []string) ([]sources.Middleware, error) {
+	cfg := appBootstrapConfig()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return []sources.Middleware{
+		sources.FromCobra(cmd, fields.WithSource("cobra")),
+		sources.FromArgs(args, fields.WithSource("arguments")),
+		sources.FromEnv(cfg.EnvPrefix, fields.WithSource("env")),
+		// Config files are loaded before the cobra flags are applied, so the
+		// plan reads --config-file from the pre-parsed command settings.
+		sources.FromConfigPlanBuilder(func(context.Context, *values.Values) (*glazedconfig.Plan, error) {
+			return cfg.ConfigPlanBuilder(parsed)
+		},
+			sources.WithConfigFileMapper(cfg.ConfigFileMapper),
+			sources.WithParseOptions(fields.WithSource("config")),
+		),
+		sources.FromDefaults(fields.WithSource(fields.SourceDefaults)),
+	}, nil
+}
+
+// inferenceSections returns the shared Geppetto sections plus the
+// --print-inference-settings debug section used by engine-backed commands.
+func inferenceSections() ([]schema.Section, error) {
+	baseSections, err := geppettosections.CreateGeppettoSections()
+	if err != nil {
+		return nil, err
+	}
+	debugSection, err := geppettobootstrap.NewInferenceDebugSection()
+	if err != nil {
+		return nil, err
+	}
+	return append(baseSections, debugSection), nil
+}
+
+// resolveEngineSettings resolves the final merged inference settings. When
+// --print-inference-settings is set, the source trace is written to w and the
+// boolean result is true; callers should then return without running
+// inference. The caller owns resolved.Close.
+func resolveEngineSettings(ctx context.Context, parsed *values.Values, w io.Writer) (*geppettobootstrap.ResolvedCLIEngineSettings, bool, error) {
+	cfg := appBootstrapConfig()
+	resolved, err := resolveCLIEngineSettings(ctx, cfg, parsed)
+	if err != nil {
+		return nil, false, err
+	}
+
+	debugSettings := geppettobootstrap.InferenceDebugSettings{}
+	if err := parsed.DecodeSectionInto(geppettobootstrap.InferenceDebugSectionSlug, &debugSettings); err != nil {
+		return resolved, false, err
+	}
+	printed, err := geppettobootstrap.HandleInferenceDebugOutput(
+		w,
+		cfg,
+		parsed,
+		debugSettings,
+		&geppettobootstrap.ResolvedInferenceTrace{
+			FinalInferenceSettings: resolved.FinalInferenceSettings,
+			ResolvedEngineProfile:  resolved.ResolvedEngineProfile,
+		},
+		geppettobootstrap.InferenceDebugOutputOptions{},
+	)
+	return resolved, printed, err
+}
+
+// resolveCLIEngineSettings merges the selected profile over the settings parsed
+// for the command. Unlike bootstrap.ResolveCLIEngineSettings, whose hidden base
+// only sees environment and config files, the base here also includes the
+// inference flags the commands expose, so --ai-engine and friends apply.
+func resolveCLIEngineSettings(ctx context.Context, cfg geppettobootstrap.AppBootstrapConfig, parsed *values.Values) (*geppettobootstrap.ResolvedCLIEngineSettings, error) {
+	base, err := aisettings.NewInferenceSettingsFromParsedValues(parsed)
+	if err != nil {
+		return nil, errors.Wrap(err, "build inference settings from parsed values")
+	}
+	configFiles, err := geppettobootstrap.ResolveCLIConfigFiles(cfg, parsed)
+	if err != nil {
+		return nil, err
+	}
+	return geppettobootstrap.ResolveCLIEngineSettingsFromBase(ctx, cfg, base, parsed, configFiles)
+}
+
+// runnerRuntime builds the runner input from resolved settings, tagging turns
+// with the selected profile so persisted sessions and usage can be attributed.
+func runnerRuntime(resolved *geppettobootstrap.ResolvedCLIEngineSettings, systemPrompt string) runner.Runtime {
+	rt := runner.Runtime{
+		InferenceSettings: resolved.FinalInferenceSettings,
+		SystemPrompt:      systemPrompt,
+	}
+	if profile := resolved.ResolvedEngineProfile; profile != nil {
+		rt.RuntimeKey = profile.EngineProfileSlug.String()
+		if n := len(profile.StackLineage); n > 0 {
+			rt.ProfileVersion = profile.StackLineage[n-1].Version
+		}
+	}
+	return rt
+}
+
+func closeResolved(resolved *geppettobootstrap.ResolvedCLIEngineSettings) {
+	if resolved != nil && resolved.Close != nil {
+		resolved.Close()
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/turns/serde"
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+type chatCommand struct {
+	*cmds.CommandDescription
+}
+
+var _ cmds.WriterCommand = (*chatCommand)(nil)
+
+type chatSettings struct {
+	TurnFile      string `glazed:"turn"`
+	SystemPrompt  string `glazed:"system-prompt"`
+	HistoryFile   string `glazed:"history-file"`
+	Fresh         bool   `glazed:"fresh"`
+	HideReasoning bool   `glazed:"hide-reasoning"`
+}
+
+func newChatCommand() (*chatCommand, error) {
+	sections, err := inferenceSections()
+	if err != nil {
+		return nil, errors.Wrap(err, "create inference sections")
+	}
+
+	description := cmds.NewCommandDescription(
+		"chat",
+		cmds.WithShort("Start an interactive chat session"),
+		cmds.WithLong(`Chat with the selected profile in the terminal.
+
+Text and reasoning stream as they arrive, and tool calls and results are
+printed inline. Lines starting with "/" are commands; type /help for the list.
+Ctrl-C or /cancel stops the running inference, /exit or end of input leaves.
+
+The conversation is saved to --history-file after every reply and restored on
+the next start unless --fresh or --turn is given.`),
+		cmds.WithFlags(
+			fields.New(
+				"turn",
+				fields.TypeString,
+				fields.WithHelp("Turn YAML file used as seed conversation"),
+			),
+			fields.New(
+				"system-prompt",
+				fields.TypeString,
+				fields.WithHelp("System prompt inserted when the conversation has none"),
+			),
+			fields.New(
+				"history-file",
+				fields.TypeString,
+				fields.WithHelp("File keeping the conversation across restarts (default: <user config dir>/geppetto/chat-history.yaml, \"-\" disables)"),
+			),
+			fields.New(
+				"fresh",
+				fields.TypeBool,
+				fields.WithDefault(false),
+				fields.WithHelp("Start a new conversation instead of restoring the history file"),
+			),
+			fields.New(
+				"hide-reasoning",
+				fields.TypeBool,
+				fields.WithDefault(false),
+				fields.WithHelp("Do not stream reasoning output (toggle with /reasoning)"),
+			),
+		),
+		cmds.WithSections(sections...),
+	)
+
+	return &chatCommand{CommandDescription: description}, nil
+}
+
+func (c *chatCommand) RunIntoWriter(ctx context.Context, parsedValues *values.Values, w io.Writer) error {
+	s := &chatSettings{}
+	if err := parsedValues.DecodeSectionInto(values.DefaultSlug, s); err != nil {
+		return errors.Wrap(err, "decode chat settings")
+	}
+
+	resolved, printed, err := resolveEngineSettings(ctx, parsedValues, w)
+	defer closeResolved(resolved)
+	if err != nil || printed {
+		return err
+	}
+
+	historyFile, err := chatHistoryFile(s.HistoryFile)
+	if err != nil {
+		return err
+	}
+	r := newChatREPL(w, resolved, s.SystemPrompt, historyFile)
+	r.sink.SetShowReasoning(!s.HideReasoning)
+
+	if err := r.restore(s.TurnFile, s.Fresh); err != nil {
+		return err
+	}
+
+	return runChatLoop(ctx, r, os.Stdin)
+}
+
+// restore seeds the conversation from turnFile or, unless fresh is set, from
+// the history file of a previous chat.
+func (r *chatREPL) restore(turnFile string, fresh bool) error {
+	switch {
+	case turnFile != "":
+		seed, err := serde.LoadTurnYAML(turnFile)
+		if err != nil {
+			return errors.Wrapf(err, "load turn %s", turnFile)
+		}
+		r.seed(seed)
+	case !fresh && r.historyFile != "":
+		if _, statErr := os.Stat(r.historyFile); statErr != nil {
+			return nil
+		}
+		seed, err := serde.LoadTurnYAML(r.historyFile)
+		if err != nil {
+			return errors.Wrapf(err, "load chat history %s (use --fresh to ignore it)", r.historyFile)
+		}
+		r.seed(seed)
+		return r.printf("restored %d blocks from %s\n", len(seed.Blocks), r.historyFile)
+	}
+	return nil
+}
+
+// readChatLines scans in line by line until EOF or until stop is closed. A
+// read already blocked on in when stop closes still takes that one line, which
+// is dropped; the reader then exits instead of holding later input.
+func readChatLines(in io.Reader, stop <-chan struct{}) <-chan string {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			select {
+			case lines <- scanner.Text():
+			case <-stop:
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			log.Warn().Err(err).Msg("chat: read prompt")
+		}
+	}()
+	return lines
+}
+
+// runChatLoop reads lines concurrently with running inferences so /cancel and
+// Ctrl-C can interrupt a streaming reply.
+func runChatLoop(ctx context.Context, r *chatREPL, in io.Reader) error {
+	stop := make(chan struct{})
+	defer close(stop)
+	lines := readChatLines(in, stop)
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	// Lines typed while a reply streams are queued and handled afterwards,
+	// except for /cancel and /reasoning which act immediately.
+	var queued []string
+	eof := false
+	for {
+		if err := r.printf("> "); err != nil {
+			return err
+		}
+		var line string
+		if len(queued) > 0 {
+			line, queued = queued[0], queued[1:]
+			if err := r.printf("%s\n", line); err != nil {
+				return err
+			}
+		} else if eof {
+			return r.printf("\n")
+		} else {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-interrupts:
+				if err := r.printf("\n(use /exit to leave)\n"); err != nil {
+					return err
+				}
+				continue
+			case l, ok := <-lines:
+				if !ok {
+					return r.printf("\n")
+				}
+				line = l
+			}
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "/") {
+			quit, err := r.command(ctx, line)
+			if err != nil || quit {
+				return err
+			}
+			continue
+		}
+
+		handle, err := r.submit(ctx, line)
+		if err != nil {
+			if err := r.printf("error: %v\n", err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := handle.Wait()
+			done <- err
+		}()
+	wait:
+		for {
+			select {
+			case err := <-done:
+				switch {
+				case err == nil:
+					r.persist()
+					if err := r.printf("\n\n"); err != nil {
+						return err
+					}
+				case errors.Is(err, context.Canceled) && ctx.Err() == nil:
+					if err := r.printf("\n[cancelled]\n"); err != nil {
+						return err
+					}
+				default:
+					if err := r.printf("\nerror: %v\n", err); err != nil {
+						return err
+					}
+				}
+				break wait
+			case <-interrupts:
+				_ = r.sess.CancelActive()
+			case l, ok := <-lines:
+				if !ok {
+					eof = true
+					lines = nil
+					continue
+				}
+				name, arg := parseSlashCommand(l)
+				switch {
+				case !strings.HasPrefix(strings.TrimSpace(l), "/"):
+					queued = append(queued, l)
+				case name == "cancel":
+					_ = r.sess.CancelActive()
+				case name == "reasoning":
+					r.sink.SetShowReasoning(arg == "on" || (arg == "" && !r.sink.ShowReasoning()))
+				default:
+					queued = append(queued, l)
+				}
+			}
+		}
+	}
+}
+
+func chatHistoryFile(flag string) (string, error) {
+	switch flag {
+	case "-":
+		return "", nil
+	case "":
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			// No config dir (e.g. HOME unset): run without persistence.
+			return "", nil
+		}
+		dir = filepath.Join(dir, appName)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return "", errors.Wrap(err, "create chat history directory")
+		}
+		return filepath.Join(dir, "chat-history.yaml"), nil
+	default:
+		return flag, nil
+	}
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	geppettobootstrap "github.com/go-go-golems/geppetto/pkg/cli/bootstrap"
+	"github.com/go-go-golems/geppetto/pkg/inference/middleware"
+	aisettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/go-go-golems/geppetto/pkg/turns/serde"
+	"github.com/pkg/errors"
+)
+
+// syncBuffer collects REPL output, which the event sink writes from the
+// inference goroutine.
+type syncBuffer struct {
+	mu sync.Mutex
+	b  strings.Builder
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.String()
+}
+
+// fakeChatEngine answers in place of the provider engine. It runs as the
+// innermost middleware, records the user prompts of every call and replies
+// with an echo. With release set, each call waits for it or for cancellation.
+type fakeChatEngine struct {
+	mu      sync.Mutex
+	calls   [][]string
+	started chan struct{}
+	release chan struct{}
+}
+
+func (f *fakeChatEngine) middleware(middleware.HandlerFunc) middleware.HandlerFunc {
+	return func(ctx context.Context, t *turns.Turn) (*turns.Turn, error) {
+		var prompts []string
+		for _, b := range t.Blocks {
+			if text, ok := b.Payload[turns.PayloadKeyText].(string); ok && b.Kind == turns.BlockKindUser {
+				prompts = append(prompts, text)
+			}
+		}
+		f.mu.Lock()
+		f.calls = append(f.calls, prompts)
+		f.mu.Unlock()
+		if f.started != nil {
+			f.started <- struct{}{}
+		}
+		if f.release != nil {
+			select {
+			case <-f.release:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		turns.AppendBlock(t, turns.NewAssistantTextBlock("echo: "+prompts[len(prompts)-1]))
+		return t, nil
+	}
+}
+
+func (f *fakeChatEngine) Calls() [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][]string(nil), f.calls...)
+}
+
+func newTestChatREPL(t *testing.T, eng *fakeChatEngine, historyFile string) (*chatREPL, *syncBuffer) {
+	t.Helper()
+	ss, err := aisettings.NewInferenceSettings()
+	if err != nil {
+		t.Fatalf("NewInferenceSettings() unexpected error: %v", err)
+	}
+	// The provider engine is built but never called.
+	ss.API.APIKeys["openai-api-key"] = "test-key"
+	out := &syncBuffer{}
+	resolved := &geppettobootstrap.ResolvedCLIEngineSettings{BaseInferenceSettings: ss, FinalInferenceSettings: ss}
+	r := newChatREPL(out, resolved, "", historyFile)
+	r.runtime.Middlewares = []middleware.Middleware{eng.middleware}
+	return r, out
+}
+
+// chatSession drives runChatLoop through a pipe, like a user typing lines.
+type chatSession struct {
+	t    *testing.T
+	in   *io.PipeWriter
+	done chan error
+}
+
+func startChatLoop(t *testing.T, r *chatREPL) *chatSession {
+	t.Helper()
+	pr, pw := io.Pipe()
+	s := &chatSession{t: t, in: pw, done: make(chan error, 1)}
+	go func() { s.done <- runChatLoop(context.Background(), r, pr) }()
+	t.Cleanup(func() { _ = pw.Close() })
+	return s
+}
+
+// send writes lines. A write returns once the loop has taken the previous
+// line, so lines sent during a reply are queued in order.
+func (s *chatSession) send(lines ...string) {
+	s.t.Helper()
+	for _, l := range lines {
+		if _, err := io.WriteString(s.in, l+"\n"); err != nil {
+			s.t.Fatalf("write %q: %v", l, err)
+		}
+	}
+}
+
+// finish closes the input and waits for the loop to return.
+func (s *chatSession) finish() {
+	s.t.Helper()
+	_ = s.in.Close()
+	select {
+	case err := <-s.done:
+		if err != nil {
+			s.t.Fatalf("runChatLoop() unexpected error: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		s.t.Fatalf("runChatLoop() did not return")
+	}
+}
+
+func TestReadChatLinesStopsWhenLoopEnds(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer func() { _ = pw.Close() }()
+	stop := make(chan struct{})
+	lines := readChatLines(pr, stop)
+
+	go func() { _, _ = io.WriteString(pw, "first\n") }()
+	if l := <-lines; l != "first" {
+		t.Fatalf("line = %q, want first", l)
+	}
+	close(stop)
+	// The pending read takes this line; the reader must then exit rather than
+	// block on delivering it.
+	go func() { _, _ = io.WriteString(pw, "after\n") }()
+	select {
+	case l, ok := <-lines:
+		if ok {
+			t.Fatalf("reader delivered %q after stop", l)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("reader did not exit after stop")
+	}
+}
+
+func TestRunChatLoopReturnsOnCancel(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer func() { _ = pw.Close() }()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := &chatREPL{w: io.Discard}
+	if err := runChatLoop(ctx, r, pr); !errors.Is(err, context.Canceled) {
+		t.Fatalf("runChatLoop() error = %v, want context.Canceled", err)
+	}
+}
+
+// awaitStart waits until eng received a request.
+func awaitStart(t *testing.T, eng *fakeChatEngine, out *syncBuffer) {
+	t.Helper()
+	select {
+	case <-eng.started:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("engine was not called, output:\n%s", out.String())
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRunChatLoopQueuesLinesWhileStreaming(t *testing.T) {
+	eng := &fakeChatEngine{started: make(chan struct{}, 4), release: make(chan struct{})}
+	r, out := newTestChatREPL(t, eng, "")
+	chat := startChatLoop(t, r)
+
+	chat.send("first")
+	awaitStart(t, eng, out)
+	chat.send("second", "/history", "/reasoning off", "/help")
+	waitFor(t, "/reasoning to apply during the reply", func() bool { return !r.sink.ShowReasoning() })
+	if len(eng.Calls()) != 1 {
+		t.Fatalf("queued lines must wait for the running reply, got calls %v", eng.Calls())
+	}
+	close(eng.release)
+	chat.finish()
+
+	want := [][]string{{"first"}, {"first", "second"}}
+	if got := eng.Calls(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("calls = %v, want %v, output:\n%s", got, want, out.String())
+	}
+	output := out.String()
+	for _, s := range []string{"> second\n", "> /history\n  1 ", "  2 ", "> /help\nCommands:"} {
+		if !strings.Contains(output, s) {
+			t.Fatalf("expected output to contain %q, got:\n%s", s, output)
+		}
+	}
+}
+
+func TestRunChatLoopCancel(t *testing.T) {
+	eng := &fakeChatEngine{started: make(chan struct{}, 4), release: make(chan struct{})}
+	r, out := newTestChatREPL(t, eng, "")
+	chat := startChatLoop(t, r)
+
+	chat.send("hello")
+	awaitStart(t, eng, out)
+	chat.send("/cancel")
+	waitFor(t, "the reply to be cancelled", func() bool { return strings.Contains(out.String(), "[cancelled]") })
+
+	close(eng.release)
+	chat.send("/cancel", "again")
+	awaitStart(t, eng, out)
+	chat.finish()
+
+	if got := eng.Calls(); len(got) != 2 || got[1][len(got[1])-1] != "again" {
+		t.Fatalf("expected the chat to continue after /cancel, got calls %v", got)
+	}
+	if !strings.Contains(out.String(), "nothing to cancel") {
+		t.Fatalf("expected /cancel without a running reply to report it, got:\n%s", out.String())
+	}
+}
+
+func TestRunChatLoopCommands(t *testing.T) {
+	dir := t.TempDir()
+	historyFile := filepath.Join(dir, "history.yaml")
+	saveFile := filepath.Join(dir, "saved.yaml")
+	eng := &fakeChatEngine{}
+	r, out := newTestChatREPL(t, eng, historyFile)
+	chat := startChatLoop(t, r)
+
+	chat.send(
+		"one", "two",
+		"/fork 1", "three",
+		"/save "+saveFile,
+		"/new", "four",
+		"/load "+saveFile, "five",
+		"/fork 99", "/bogus",
+	)
+	chat.finish()
+
+	want := [][]string{
+		{"one"},
+		{"one", "two"},
+		{"one", "three"},
+		{"four"},
+		{"one", "three", "five"},
+	}
+	if got := eng.Calls(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("calls = %v, want %v, output:\n%s", got, want, out.String())
+	}
+	output := out.String()
+	for _, s := range []string{"continuing from turn 1", "saved ", "new conversation", "loaded ", "usage: /fork <n>", "unknown command /bogus"} {
+		if !strings.Contains(output, s) {
+			t.Fatalf("expected output to contain %q, got:\n%s", s, output)
+		}
+	}
+
+	persisted, err := serde.LoadTurnYAML(historyFile)
+	if err != nil {
+		t.Fatalf("load history: %v", err)
+	}
+	if got := assistantReply(persisted); got != "echo: five" {
+		t.Fatalf("expected the history file to hold the latest reply, got %q", got)
+	}
+}
+
+func TestChatREPLRestoresHistory(t *testing.T) {
+	historyFile := filepath.Join(t.TempDir(), "history.yaml")
+
+	first := &fakeChatEngine{}
+	r, _ := newTestChatREPL(t, first, historyFile)
+	chat := startChatLoop(t, r)
+	chat.send("remember me")
+	chat.finish()
+
+	cases := []struct {
+		name  string
+		fresh bool
+		want  []string
+	}{
+		{name: "restore", want: []string{"remember me", "and now?"}},
+		{name: "fresh", fresh: true, want: []string{"and now?"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			eng := &fakeChatEngine{}
+			r, out := newTestChatREPL(t, eng, historyFile)
+			if err := r.restore("", tc.fresh); err != nil {
+				t.Fatalf("restore() unexpected error: %v", err)
+			}
+			if restored := strings.Contains(out.String(), "restored "); restored == tc.fresh {
+				t.Fatalf("unexpected restore output %q", out.String())
+			}
+			chat := startChatLoop(t, r)
+			chat.send("and now?")
+			chat.finish()
+			if got := eng.Calls(); len(got) != 1 || !reflect.DeepEqual(got[0], tc.want) {
+				t.Fatalf("calls = %v, want [%v]", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLastUserText(t *testing.T) {
+	long := strings.Repeat("é", 70)
+	cases := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "short", text: "hello   world", want: "hello world"},
+		{name: "ascii truncated", text: strings.Repeat("a", 61), want: strings.Repeat("a", 57) + "..."},
+		{name: "multibyte truncated by runes", text: long, want: strings.Repeat("é", 57) + "..."},
+		{name: "multibyte at limit", text: strings.Repeat("é", 60), want: strings.Repeat("é", 60)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := lastUserText(testTurn(turns.NewUserTextBlock(tc.text), turns.NewAssistantTextBlock("reply")))
+			if got != tc.want || !utf8.ValidString(got) {
+				t.Fatalf("lastUserText() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
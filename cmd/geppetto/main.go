@@ -0,0 +1,77 @@
+// Command geppetto runs prompts and chat sessions against engine profiles,
+// inspects profile registries, counts tokens and inspects trace files.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/go-go-golems/geppetto/pkg/doc"
+	"github.com/go-go-golems/glazed/pkg/cli"
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/logging"
+	"github.com/go-go-golems/glazed/pkg/help"
+	help_cmd "github.com/go-go-golems/glazed/pkg/help/cmd"
+	"github.com/spf13/cobra"
+)
+
+var rootCmd = &cobra.Command{
+	Use:   appName,
+	Short: "Run inference, chat and inspect engine profiles from the command line",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return logging.InitLoggerFromCobra(cmd)
+	},
+}
+
+func buildCobraCommand(command cmds.Command, err error) *cobra.Command {
+	cobra.CheckErr(err)
+	cobraCommand, err := cli.BuildCobraCommand(
+		command,
+		cli.WithCobraMiddlewaresFunc(getCobraCommandMiddlewares),
+	)
+	cobra.CheckErr(err)
+	return cobraCommand
+}
+
+func main() {
+	err := logging.AddLoggingSectionToRootCommand(rootCmd, appName)
+	cobra.CheckErr(err)
+
+	helpSystem := help.NewHelpSystem()
+	err = doc.AddDocToHelpSystem(helpSystem)
+	cobra.CheckErr(err)
+	help_cmd.SetupCobraRootCommand(helpSystem, rootCmd)
+
+	rootCmd.AddCommand(buildCobraCommand(newRunCommand()))
+	rootCmd.AddCommand(buildCobraCommand(newChatCommand()))
+	rootCmd.AddCommand(buildCobraCommand(newSettingsCommand()))
+
+	profilesCmd := &cobra.Command{
+		Use:   "profiles",
+		Short: "List, resolve and validate engine profiles",
+	}
+	profilesCmd.AddCommand(buildCobraCommand(newProfilesListCommand()))
+	profilesCmd.AddCommand(buildCobraCommand(newProfilesResolveCommand()))
+	profilesCmd.AddCommand(buildCobraCommand(newProfilesValidateCommand()))
+	rootCmd.AddCommand(profilesCmd)
+
+	tokensCmd := &cobra.Command{
+		Use:   "tokens",
+		Short: "Token counting utilities",
+	}
+	tokensCmd.AddCommand(buildCobraCommand(newTokensCountCommand()))
+	rootCmd.AddCommand(tokensCmd)
+
+	traceCmd := &cobra.Command{
+		Use:   "trace",
+		Short: "Inspect JSONL trace files",
+	}
+	traceCmd.AddCommand(buildCobraCommand(newTraceInspectCommand()))
+	rootCmd.AddCommand(traceCmd)
+
+	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/go-go-golems/glazed/pkg/cli"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+)
+
+func testTurn(blocks ...turns.Block) *turns.Turn {
+	t := &turns.Turn{}
+	for _, b := range blocks {
+		turns.AppendBlock(t, b)
+	}
+	return t
+}
+
+func TestAssistantReply(t *testing.T) {
+	cases := []struct {
+		name string
+		turn *turns.Turn
+		want string
+	}{
+		{name: "nil turn", turn: nil, want: ""},
+		{name: "no assistant text", turn: testTurn(turns.NewUserTextBlock("hi")), want: ""},
+		{
+			name: "joins text after the last user block",
+			turn: testTurn(
+				turns.NewUserTextBlock("first"),
+				turns.NewAssistantTextBlock("old answer"),
+				turns.NewUserTextBlock("second"),
+				turns.NewAssistantTextBlock("part one"),
+				turns.NewToolCallBlock("call-1", "search", map[string]any{"q": "x"}),
+				turns.NewToolUseBlock("call-1", "result"),
+				turns.NewAssistantTextBlock("part two"),
+			),
+			want: "part one\npart two",
+		},
+		{
+			name: "skips empty text",
+			turn: testTurn(turns.NewUserTextBlock("q"), turns.NewAssistantTextBlock(""), turns.NewAssistantTextBlock("a")),
+			want: "a",
+		},
+		{
+			name: "no user block keeps every assistant block",
+			turn: testTurn(turns.NewSystemTextBlock("sys"), turns.NewAssistantTextBlock("a"), turns.NewAssistantTextBlock("b")),
+			want: "a\nb",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := assistantReply(tc.turn); got != tc.want {
+				t.Fatalf("assistantReply() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWriteTurnOutput(t *testing.T) {
+	turn := testTurn(turns.NewUserTextBlock("What is 2+2?"), turns.NewAssistantTextBlock("4"))
+	cases := []struct {
+		format   string
+		contains []string
+		exact    string
+	}{
+		{format: "text", exact: "4\n"},
+		{format: "", exact: "4\n"},
+		{format: "turn", contains: []string{"What is 2+2?", "4"}},
+		{format: "yaml", contains: []string{"blocks:", "kind: user", "kind: llm_text", "text: What is 2+2?"}},
+	}
+	for _, tc := range cases {
+		t.Run("format="+tc.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeTurnOutput(&buf, turn, tc.format); err != nil {
+				t.Fatalf("writeTurnOutput() unexpected error: %v", err)
+			}
+			if tc.exact != "" && buf.String() != tc.exact {
+				t.Fatalf("writeTurnOutput() = %q, want %q", buf.String(), tc.exact)
+			}
+			for _, s := range tc.contains {
+				if !strings.Contains(buf.String(), s) {
+					t.Fatalf("expected output to contain %q, got:\n%s", s, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestChatHistoryFile(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	t.Setenv("HOME", configDir)
+
+	cases := []struct {
+		flag string
+		want string
+	}{
+		{flag: "-", want: ""},
+		{flag: "", want: filepath.Join(configDir, appName, "chat-history.yaml")},
+		{flag: "custom/history.yaml", want: "custom/history.yaml"},
+	}
+	for _, tc := range cases {
+		t.Run("flag="+tc.flag, func(t *testing.T) {
+			got, err := chatHistoryFile(tc.flag)
+			if err != nil {
+				t.Fatalf("chatHistoryFile() unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("chatHistoryFile() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+	if _, err := os.Stat(filepath.Join(configDir, appName)); err != nil {
+		t.Fatalf("expected the default history directory to be created: %v", err)
+	}
+}
+
+// parseRunFlags parses args like the run command's cobra wiring does, with the
+// same sources in the same order of precedence.
+func parseRunFlags(t *testing.T, args ...string) *values.Values {
+	t.Helper()
+	c, err := newRunCommand()
+	if err != nil {
+		t.Fatalf("newRunCommand() unexpected error: %v", err)
+	}
+	parser, err := cli.NewCobraParserFromSections(c.Schema, &cli.CobraParserConfig{MiddlewaresFunc: getCobraCommandMiddlewares})
+	if err != nil {
+		t.Fatalf("NewCobraParserFromSections() unexpected error: %v", err)
+	}
+	cmd := cli.NewCobraCommandFromCommandDescription(c.CommandDescription)
+	if err := parser.AddToCobraCommand(cmd); err != nil {
+		t.Fatalf("AddToCobraCommand() unexpected error: %v", err)
+	}
+	if err := cmd.ParseFlags(args); err != nil {
+		t.Fatalf("ParseFlags() unexpected error: %v", err)
+	}
+	parsed, err := parser.Parse(cmd, cmd.Flags().Args())
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	return parsed
+}
+
+func TestResolveCLIEngineSettingsFlagPrecedence(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte("ai-chat:\n  ai-api-type: openai\n  ai-engine: config-model\n"), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	registryPath := filepath.Join(tmpDir, "profiles.yaml")
+	registryYAML := `slug: workspace
+profiles:
+  default:
+    slug: default
+    inference_settings:
+      chat:
+        engine: profile-model
+`
+	if err := os.WriteFile(registryPath, []byte(registryYAML), 0o644); err != nil {
+		t.Fatalf("write registry: %v", err)
+	}
+
+	cases := []struct {
+		name          string
+		env           string
+		args          []string
+		wantEngine    string
+		wantMaxTokens int
+	}{
+		{name: "config file", args: []string{"--config-file", configPath}, wantEngine: "config-model"},
+		{name: "env over config", env: "env-model", args: []string{"--config-file", configPath}, wantEngine: "env-model"},
+		{name: "flag over env and config", env: "env-model", args: []string{"--config-file", configPath, "--ai-engine", "flag-model"}, wantEngine: "flag-model"},
+		{
+			name:       "profile over flag",
+			args:       []string{"--config-file", configPath, "--ai-engine", "flag-model", "--profile", "default", "--profile-registries", registryPath},
+			wantEngine: "profile-model",
+		},
+		{
+			name:          "flag applies to fields the profile leaves unset",
+			args:          []string{"--config-file", configPath, "--ai-max-response-tokens", "77", "--profile", "default", "--profile-registries", registryPath},
+			wantEngine:    "profile-model",
+			wantMaxTokens: 77,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "xdg"))
+			t.Setenv("HOME", tmpDir)
+			t.Setenv(envPrefix+"_AI_ENGINE", tc.env)
+			if tc.env == "" {
+				_ = os.Unsetenv(envPrefix + "_AI_ENGINE")
+			}
+
+			resolved, err := resolveCLIEngineSettings(context.Background(), appBootstrapConfig(), parseRunFlags(t, tc.args...))
+			if err != nil {
+				t.Fatalf("resolveCLIEngineSettings() unexpected error: %v", err)
+			}
+			defer closeResolved(resolved)
+			chat := resolved.FinalInferenceSettings.Chat
+			if chat == nil || chat.Engine == nil {
+				t.Fatalf("expected a resolved chat engine")
+			}
+			if *chat.Engine != tc.wantEngine {
+				t.Fatalf("expected engine %q, got %q", tc.wantEngine, *chat.Engine)
+			}
+			if tc.wantMaxTokens != 0 && (chat.MaxResponseTokens == nil || *chat.MaxResponseTokens != tc.wantMaxTokens) {
+				t.Fatalf("expected max response tokens %d, got %v", tc.wantMaxTokens, chat.MaxResponseTokens)
+			}
+		})
+	}
+}
+
+func TestResolveCLIEngineSettingsOllamaFlags(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "xdg"))
+	t.Setenv("HOME", tmpDir)
+
+	resolved, err := resolveCLIEngineSettings(context.Background(), appBootstrapConfig(), parseRunFlags(t,
+		"--ai-api-type", "ollama",
+		"--ollama-base-url", "http://gpu-box:11434",
+		"--ollama-api-key", "proxy-token",
+		"--ollama-num-ctx", "2048",
+	))
+	if err != nil {
+		t.Fatalf("resolveCLIEngineSettings() unexpected error: %v", err)
+	}
+	defer closeResolved(resolved)
+	ss := resolved.FinalInferenceSettings
+	if got := ss.API.BaseUrls["ollama-base-url"]; got != "http://gpu-box:11434" {
+		t.Fatalf("expected ollama base URL from the flag, got %q", got)
+	}
+	if got := ss.API.APIKeys["ollama-api-key"]; got != "proxy-token" {
+		t.Fatalf("expected ollama API key from the flag, got %q", got)
+	}
+	if ss.Ollama == nil || ss.Ollama.NumCtx == nil || *ss.Ollama.NumCtx != 2048 {
+		t.Fatalf("expected num_ctx 2048, got %+v", ss.Ollama)
+	}
+	if ss.Ollama.Temperature != nil {
+		t.Fatalf("expected unset ollama temperature to stay nil, got %v", *ss.Ollama.Temperature)
+	}
+}
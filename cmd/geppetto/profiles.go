@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	geppettobootstrap "github.com/go-go-golems/geppetto/pkg/cli/bootstrap"
+	gepprofiles "github.com/go-go-golems/geppetto/pkg/engineprofiles"
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/pkg/errors"
+)
+
+type profilesSettings struct {
+	Output string `glazed:"output-format"`
+}
+
+func profileCommandDescription(name string, short string, long string, withOutput bool) (*cmds.CommandDescription, error) {
+	profileSection, err := geppettobootstrap.NewProfileSettingsSection(appBootstrapConfig())
+	if err != nil {
+		return nil, errors.Wrap(err, "create profile settings section")
+	}
+	opts := []cmds.CommandDescriptionOption{
+		cmds.WithShort(short),
+		cmds.WithLong(long),
+		cmds.WithSections(profileSection),
+	}
+	if withOutput {
+		opts = append(opts, cmds.WithFlags(
+			fields.New(
+				"output-format",
+				fields.TypeChoice,
+				fields.WithChoices("text", "json", "yaml"),
+				fields.WithDefault("text"),
+				fields.WithHelp("Report format"),
+			),
+		))
+	}
+	return cmds.NewCommandDescription(name, opts...), nil
+}
+
+type profilesListCommand struct {
+	*cmds.CommandDescription
+}
+
+var _ cmds.WriterCommand = (*profilesListCommand)(nil)
+
+func newProfilesListCommand() (*profilesListCommand, error) {
+	description, err := profileCommandDescription(
+		"list",
+		"List profile registry sources, registries and profiles",
+		"List every registry and engine profile loaded from --profile-registries (or the default geppetto profiles.yaml). The default profile is marked with * and the selected one with >.",
+		true,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &profilesListCommand{CommandDescription: description}, nil
+}
+
+func (c *profilesListCommand) RunIntoWriter(ctx context.Context, parsedValues *values.Values, w io.Writer) error {
+	return writeProfileReport(ctx, parsedValues, w, geppettobootstrap.ProfileRegistryReportOptions{})
+}
+
+type profilesResolveCommand struct {
+	*cmds.CommandDescription
+}
+
+var _ cmds.WriterCommand = (*profilesResolveCommand)(nil)
+
+func newProfilesResolveCommand() (*profilesResolveCommand, error) {
+	description, err := profileCommandDescription(
+		"resolve",
+		"Resolve the selected profile and show its stack lineage and merged settings",
+		"Resolve --profile (or the registry default) through its stack and print the lineage together with the merged inference settings. Secrets are redacted.",
+		true,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &profilesResolveCommand{CommandDescription: description}, nil
+}
+
+func (c *profilesResolveCommand) RunIntoWriter(ctx context.Context, parsedValues *values.Values, w io.Writer) error {
+	return writeProfileReport(ctx, parsedValues, w, geppettobootstrap.ProfileRegistryReportOptions{
+		IncludeResolution:     true,
+		IncludeMergedSettings: true,
+		RedactSecrets:         true,
+	})
+}
+
+func writeProfileReport(ctx context.Context, parsedValues *values.Values, w io.Writer, opts geppettobootstrap.ProfileRegistryReportOptions) error {
+	s := &profilesSettings{}
+	if err := parsedValues.DecodeSectionInto(values.DefaultSlug, s); err != nil {
+		return errors.Wrap(err, "decode profiles settings")
+	}
+	report, cleanup, err := geppettobootstrap.BuildProfileRegistryReport(ctx, appBootstrapConfig(), parsedValues, opts)
+	if cleanup != nil {
+		defer cleanup()
+	}
+	if err != nil {
+		return err
+	}
+	return geppettobootstrap.RenderProfileRegistryReport(w, report, s.Output)
+}
+
+type profilesValidateCommand struct {
+	*cmds.CommandDescription
+}
+
+var _ cmds.WriterCommand = (*profilesValidateCommand)(nil)
+
+func newProfilesValidateCommand() (*profilesValidateCommand, error) {
+	description, err := profileCommandDescription(
+		"validate",
+		"Validate profile registries and check that every profile resolves",
+		"Validate every loaded registry, check stack references for cycles and missing parents, and resolve each profile. Exits with an error when any check fails.",
+		false,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &profilesValidateCommand{CommandDescription: description}, nil
+}
+
+func (c *profilesValidateCommand) RunIntoWriter(ctx context.Context, parsedValues *values.Values, w io.Writer) error {
+	runtime, err := geppettobootstrap.ResolveCLIProfileRuntime(ctx, appBootstrapConfig(), parsedValues)
+	if err != nil {
+		return err
+	}
+	if runtime.Close != nil {
+		defer runtime.Close()
+	}
+	chain := runtime.ProfileRegistryChain
+	if chain == nil || chain.Registry == nil {
+		return errors.New("no profile registries configured")
+	}
+
+	summaries, err := chain.Registry.ListRegistries(ctx)
+	if err != nil {
+		return err
+	}
+	failures := 0
+	report := func(subject string, err error) error {
+		status := "ok"
+		if err != nil {
+			failures++
+			status = "FAIL " + err.Error()
+		}
+		_, werr := fmt.Fprintf(w, "%-40s %s\n", subject, status)
+		return werr
+	}
+
+	registries := make([]*gepprofiles.EngineProfileRegistry, 0, len(summaries))
+	for _, summary := range summaries {
+		reg, err := chain.Registry.GetRegistry(ctx, summary.Slug)
+		if err == nil {
+			err = gepprofiles.ValidateRegistry(reg)
+		}
+		if werr := report("registry "+summary.Slug.String(), err); werr != nil {
+			return werr
+		}
+		if reg != nil {
+			registries = append(registries, reg)
+		}
+	}
+	if werr := report("profile stacks", gepprofiles.ValidateProfileStackTopology(registries, gepprofiles.StackValidationOptions{})); werr != nil {
+		return werr
+	}
+
+	for _, reg := range registries {
+		profiles, err := chain.Registry.ListEngineProfiles(ctx, reg.Slug)
+		if err != nil {
+			return err
+		}
+		for _, profile := range profiles {
+			if profile == nil {
+				continue
+			}
+			_, err := chain.Registry.ResolveEngineProfile(ctx, gepprofiles.ResolveInput{
+				RegistrySlug:      reg.Slug,
+				EngineProfileSlug: profile.Slug,
+			})
+			if werr := report(fmt.Sprintf("profile %s/%s", reg.Slug, profile.Slug), err); werr != nil {
+				return werr
+			}
+		}
+	}
+
+	if failures > 0 {
+		return errors.Errorf("%d profile checks failed", failures)
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	geppettobootstrap "github.com/go-go-golems/geppetto/pkg/cli/bootstrap"
+	gepprofiles "github.com/go-go-golems/geppetto/pkg/engineprofiles"
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/inference/runner"
+	"github.com/go-go-golems/geppetto/pkg/inference/session"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/go-go-golems/geppetto/pkg/turns/serde"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const replHelp = `Commands:
+  /help                 show this help
+  /profile [slug]       show or switch the engine profile
+  /history              list the turns of this session
+  /fork <n>             continue from turn n of /history
+  /save <file>          write the current conversation as turn YAML
+  /load <file>          continue from a turn YAML file
+  /reasoning [on|off]   toggle streaming of reasoning output
+  /cancel               cancel the running inference (also Ctrl-C)
+  /new                  start a new conversation
+  /exit, /quit          leave the chat
+`
+
+// chatREPL holds the state of an interactive chat. All conversation turns live
+// in one session.Session, so /history and /fork address session turns and
+// /cancel maps to Session.CancelActive.
+type chatREPL struct {
+	w        io.Writer
+	runner   *runner.Runner
+	sink     *events.StepPrinterSink
+	resolved *geppettobootstrap.ResolvedCLIEngineSettings
+
+	systemPrompt string
+	runtime      runner.Runtime
+	sess         *session.Session
+	// builderStale is set when the session builder has to be rebuilt from
+	// runtime before the next inference: at start and after /profile.
+	builderStale bool
+
+	// historyFile receives the latest turn after every change so the
+	// conversation survives restarts. Empty disables persistence.
+	historyFile string
+}
+
+func newChatREPL(w io.Writer, resolved *geppettobootstrap.ResolvedCLIEngineSettings, systemPrompt string, historyFile string) *chatREPL {
+	return &chatREPL{
+		w:            w,
+		runner:       runner.New(),
+		sink:         events.NewStepPrinterSink("", w),
+		resolved:     resolved,
+		systemPrompt: systemPrompt,
+		runtime:      runnerRuntime(resolved, systemPrompt),
+		sess:         session.NewSession(),
+		builderStale: true,
+		historyFile:  historyFile,
+	}
+}
+
+// seed makes t the conversation the next prompt continues from.
+func (r *chatREPL) seed(t *turns.Turn) {
+	if t == nil {
+		return
+	}
+	clone := t.Clone()
+	clone.ID = ""
+	r.sess.Append(clone)
+}
+
+// submit appends prompt to the conversation and starts inference.
+func (r *chatREPL) submit(ctx context.Context, prompt string) (*session.ExecutionHandle, error) {
+	if r.builderStale {
+		prepared, err := r.runner.Prepare(ctx, runner.StartRequest{
+			SessionID:  r.sess.SessionID,
+			Prompt:     prompt,
+			SeedTurn:   r.sess.Latest(),
+			Runtime:    r.runtime,
+			EventSinks: []events.EventSink{r.sink},
+		})
+		if err != nil {
+			return nil, err
+		}
+		r.sess.Builder = prepared.Session.Builder
+		r.sess.Append(prepared.Turn)
+		r.builderStale = false
+	} else if _, err := r.sess.AppendNewTurnFromUserPrompt(prompt); err != nil {
+		return nil, err
+	}
+	return r.sess.StartInference(ctx)
+}
+
+// command executes a slash command. It returns true when the REPL should exit.
+func (r *chatREPL) command(ctx context.Context, line string) (bool, error) {
+	name, arg := parseSlashCommand(line)
+	switch name {
+	case "help", "?":
+		_, err := fmt.Fprint(r.w, replHelp)
+		return false, err
+	case "exit", "quit":
+		return true, nil
+	case "cancel":
+		if err := r.sess.CancelActive(); err != nil {
+			return false, r.printf("nothing to cancel\n")
+		}
+		return false, nil
+	case "reasoning":
+		switch arg {
+		case "on":
+			r.sink.SetShowReasoning(true)
+		case "off":
+			r.sink.SetShowReasoning(false)
+		case "":
+			r.sink.SetShowReasoning(!r.sink.ShowReasoning())
+		default:
+			return false, r.printf("usage: /reasoning [on|off]\n")
+		}
+		state := "off"
+		if r.sink.ShowReasoning() {
+			state = "on"
+		}
+		return false, r.printf("reasoning display %s\n", state)
+	case "profile":
+		if arg == "" {
+			return false, r.printf("profile: %s\n", firstNonEmptyString(r.runtime.RuntimeKey, "(base settings)"))
+		}
+		if err := r.switchProfile(ctx, arg); err != nil {
+			return false, r.printf("error: %v\n", err)
+		}
+		return false, r.printf("switched to profile %s\n", r.runtime.RuntimeKey)
+	case "history":
+		return false, r.printHistory()
+	case "fork":
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 || n > r.sess.TurnCount() {
+			return false, r.printf("usage: /fork <n> with n between 1 and %d\n", r.sess.TurnCount())
+		}
+		r.seed(r.sess.GetTurn(n - 1))
+		r.persist()
+		return false, r.printf("continuing from turn %d\n", n)
+	case "save":
+		if arg == "" {
+			return false, r.printf("usage: /save <file>\n")
+		}
+		latest := r.sess.Latest()
+		if latest == nil {
+			return false, r.printf("nothing to save\n")
+		}
+		if err := serde.SaveTurnYAML(arg, latest, serde.Options{}); err != nil {
+			return false, r.printf("error: %v\n", err)
+		}
+		return false, r.printf("saved %d blocks to %s\n", len(latest.Blocks), arg)
+	case "load":
+		if arg == "" {
+			return false, r.printf("usage: /load <file>\n")
+		}
+		t, err := serde.LoadTurnYAML(arg)
+		if err != nil {
+			return false, r.printf("error: %v\n", err)
+		}
+		r.seed(t)
+		r.persist()
+		return false, r.printf("loaded %d blocks from %s\n", len(t.Blocks), arg)
+	case "new":
+		sessionID := r.sess.SessionID
+		r.sess = session.NewSession()
+		r.builderStale = true
+		if r.historyFile != "" {
+			if err := os.Remove(r.historyFile); err != nil && !os.IsNotExist(err) {
+				log.Warn().Err(err).Str("file", r.historyFile).Msg("chat: could not remove history file")
+			}
+		}
+		log.Debug().Str("previous_session", sessionID).Str("session", r.sess.SessionID).Msg("chat: new session")
+		return false, r.printf("new conversation\n")
+	default:
+		return false, r.printf("unknown command /%s, try /help\n", name)
+	}
+}
+
+// switchProfile resolves slug from the loaded registries and merges it over
+// the base settings, the same way the CLI resolves --profile at start.
+func (r *chatREPL) switchProfile(ctx context.Context, slug string) error {
+	var registry gepprofiles.Registry
+	if r.resolved.ProfileRuntime != nil && r.resolved.ProfileRuntime.ProfileRegistryChain != nil {
+		registry = r.resolved.ProfileRuntime.ProfileRegistryChain.Registry
+	}
+	if registry == nil {
+		return errors.New("no profile registries configured")
+	}
+	profileSlug, err := gepprofiles.ParseEngineProfileSlug(slug)
+	if err != nil {
+		return err
+	}
+	profile, err := registry.ResolveEngineProfile(ctx, gepprofiles.ResolveInput{EngineProfileSlug: profileSlug})
+	if err != nil {
+		return err
+	}
+	merged, err := gepprofiles.MergeInferenceSettings(r.resolved.BaseInferenceSettings, profile.InferenceSettings)
+	if err != nil {
+		return errors.Wrap(err, "merge base inference settings with engine profile")
+	}
+
+	next := *r.resolved
+	next.FinalInferenceSettings = merged
+	next.ResolvedEngineProfile = profile
+	r.resolved = &next
+	r.runtime = runnerRuntime(r.resolved, r.systemPrompt)
+	r.builderStale = true
+	return nil
+}
+
+func (r *chatREPL) printHistory() error {
+	if r.sess.TurnCount() == 0 {
+		return r.printf("no turns yet\n")
+	}
+	for i, t := range r.sess.TurnsSnapshot() {
+		if err := r.printf("%3d  %2d blocks  %s\n", i+1, len(t.Blocks), lastUserText(t)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// persist writes the latest turn to the history file. Failures are logged but
+// do not interrupt the chat.
+func (r *chatREPL) persist() {
+	if r.historyFile == "" {
+		return
+	}
+	latest := r.sess.Latest()
+	if latest == nil {
+		return
+	}
+	if err := serde.SaveTurnYAML(r.historyFile, latest, serde.Options{}); err != nil {
+		log.Warn().Err(err).Str("file", r.historyFile).Msg("chat: could not save history")
+	}
+}
+
+func (r *chatREPL) printf(format string, args ...any) error {
+	_, err := fmt.Fprintf(r.w, format, args...)
+	return err
+}
+
+func parseSlashCommand(line string) (string, string) {
+	line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "/"))
+	name, arg, _ := strings.Cut(line, " ")
+	return strings.ToLower(name), strings.TrimSpace(arg)
+}
+
+func lastUserText(t *turns.Turn) string {
+	for i := len(t.Blocks) - 1; i >= 0; i-- {
+		b := t.Blocks[i]
+		if b.Kind != turns.BlockKindUser {
+			continue
+		}
+		text, _ := b.Payload[turns.PayloadKeyText].(string)
+		text = strings.Join(strings.Fields(text), " ")
+		if runes := []rune(text); len(runes) > 60 {
+			text = string(runes[:57]) + "..."
+		}
+		return text
+	}
+	return ""
+}
+
+func firstNonEmptyString(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/inference/engine/factory"
+	"github.com/go-go-golems/geppetto/pkg/inference/runner"
+	"github.com/go-go-golems/geppetto/pkg/observability"
+	"github.com/go-go-golems/geppetto/pkg/observability/tracefile"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/go-go-golems/geppetto/pkg/turns/serde"
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/pkg/errors"
+)
+
+type runCommand struct {
+	*cmds.CommandDescription
+}
+
+var _ cmds.WriterCommand = (*runCommand)(nil)
+
+type runSettings struct {
+	Prompt       string `glazed:"prompt"`
+	TurnFile     string `glazed:"turn"`
+	SystemPrompt string `glazed:"system-prompt"`
+	Output       string `glazed:"output-format"`
+	SaveTurn     string `glazed:"save-turn"`
+	TraceFile    string `glazed:"trace-file"`
+}
+
+func newRunCommand() (*runCommand, error) {
+	sections, err := inferenceSections()
+	if err != nil {
+		return nil, errors.Wrap(err, "create inference sections")
+	}
+
+	description := cmds.NewCommandDescription(
+		"run",
+		cmds.WithShort("Run a prompt or a turn YAML file through the runner"),
+		cmds.WithLong(`Run one inference with the selected profile and print the result.
+
+The prompt is appended as a user block. With --turn, the blocks of a turn YAML
+file (as written by --save-turn) seed the conversation and the prompt is
+optional.
+
+Examples:
+  geppetto run "Summarize the plot of Hamlet in two sentences"
+  geppetto run --profile fast --turn conversation.yaml "And now in French"
+  geppetto run --trace-file trace.jsonl "What is 2+2?"
+  geppetto run --print-inference-settings`),
+		cmds.WithArguments(
+			fields.New("prompt", fields.TypeString, fields.WithHelp("Prompt to run")),
+		),
+		cmds.WithFlags(
+			fields.New(
+				"turn",
+				fields.TypeString,
+				fields.WithHelp("Turn YAML file used as seed conversation"),
+			),
+			fields.New(
+				"system-prompt",
+				fields.TypeString,
+				fields.WithHelp("System prompt inserted when the conversation has none"),
+			),
+			fields.New(
+				"output-format",
+				fields.TypeChoice,
+				fields.WithChoices("text", "turn", "yaml"),
+				fields.WithDefault("text"),
+				fields.WithHelp("text prints the assistant reply, turn prints all blocks, yaml prints the serialized turn"),
+			),
+			fields.New(
+				"save-turn",
+				fields.TypeString,
+				fields.WithHelp("Write the resulting turn as YAML to this file"),
+			),
+			fields.New(
+				"trace-file",
+				fields.TypeString,
+				fields.WithHelp("Append the run's events and provider records to this JSONL trace file (see geppetto trace inspect)"),
+			),
+		),
+		cmds.WithSections(sections...),
+	)
+
+	return &runCommand{CommandDescription: description}, nil
+}
+
+func (c *runCommand) RunIntoWriter(ctx context.Context, parsedValues *values.Values, w io.Writer) error {
+	s := &runSettings{}
+	if err := parsedValues.DecodeSectionInto(values.DefaultSlug, s); err != nil {
+		return errors.Wrap(err, "decode run settings")
+	}
+
+	resolved, printed, err := resolveEngineSettings(ctx, parsedValues, w)
+	defer closeResolved(resolved)
+	if err != nil || printed {
+		return err
+	}
+
+	var seed *turns.Turn
+	if s.TurnFile != "" {
+		seed, err = serde.LoadTurnYAML(s.TurnFile)
+		if err != nil {
+			return errors.Wrapf(err, "load turn %s", s.TurnFile)
+		}
+	}
+	if strings.TrimSpace(s.Prompt) == "" && (seed == nil || len(seed.Blocks) == 0) {
+		return errors.New("a prompt or a non-empty --turn file is required")
+	}
+
+	var sinks []events.EventSink
+	var runnerOptions []runner.Option
+	if s.TraceFile != "" {
+		recorder, err := tracefile.NewRecorder(s.TraceFile)
+		if err != nil {
+			return errors.Wrap(err, "open trace file")
+		}
+		defer func() { _ = recorder.Close() }()
+		sinks = append(sinks, recorder)
+		// The recorder also observes the engine, so the trace holds provider
+		// records next to the canonical events.
+		engineFactory := factory.NewStandardEngineFactory(
+			factory.WithObserver(recorder, observability.Config{Level: observability.TraceProvider}),
+		)
+		runnerOptions = append(runnerOptions, runner.WithEngineFactory(engineFactory.CreateEngine))
+	}
+
+	_, out, err := runner.New(runnerOptions...).Run(ctx, runner.StartRequest{
+		Prompt:     s.Prompt,
+		SeedTurn:   seed,
+		Runtime:    runnerRuntime(resolved, s.SystemPrompt),
+		EventSinks: sinks,
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.SaveTurn != "" {
+		if err := serde.SaveTurnYAML(s.SaveTurn, out, serde.Options{}); err != nil {
+			return errors.Wrapf(err, "save turn %s", s.SaveTurn)
+		}
+	}
+	return writeTurnOutput(w, out, s.Output)
+}
+
+func writeTurnOutput(w io.Writer, t *turns.Turn, format string) error {
+	switch format {
+	case "turn":
+		turns.FprintTurn(w, t)
+		return nil
+	case "yaml":
+		b, err := serde.ToYAML(t, serde.Options{})
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	default:
+		_, err := fmt.Fprintln(w, assistantReply(t))
+		return err
+	}
+}
+
+// assistantReply joins the llm_text blocks after the last user block.
+func assistantReply(t *turns.Turn) string {
+	if t == nil {
+		return ""
+	}
+	start := 0
+	for i, b := range t.Blocks {
+		if b.Kind == turns.BlockKindUser {
+			start = i + 1
+		}
+	}
+	var parts []string
+	for _, b := range t.Blocks[start:] {
+		if b.Kind != turns.BlockKindLLMText {
+			continue
+		}
+		if text, ok := b.Payload[turns.PayloadKeyText].(string); ok && text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
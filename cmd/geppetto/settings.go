@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"io"
+
+	geppettobootstrap "github.com/go-go-golems/geppetto/pkg/cli/bootstrap"
+	geppettosections "github.com/go-go-golems/geppetto/pkg/sections"
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/pkg/errors"
+)
+
+type settingsCommand struct {
+	*cmds.CommandDescription
+}
+
+var _ cmds.WriterCommand = (*settingsCommand)(nil)
+
+func newSettingsCommand() (*settingsCommand, error) {
+	sections, err := geppettosections.CreateGeppettoSections()
+	if err != nil {
+		return nil, errors.Wrap(err, "create geppetto sections")
+	}
+
+	description := cmds.NewCommandDescription(
+		"settings",
+		cmds.WithShort("Print the resolved inference settings and where each value came from"),
+		cmds.WithLong(`Resolve flags, environment, config files and the selected profile the same
+way "run" does, then print the final settings together with the source log of
+every field. Secrets are masked. This is the same document as
+"run --print-inference-settings".`),
+		cmds.WithSections(sections...),
+	)
+
+	return &settingsCommand{CommandDescription: description}, nil
+}
+
+func (c *settingsCommand) RunIntoWriter(ctx context.Context, parsedValues *values.Values, w io.Writer) error {
+	cfg := appBootstrapConfig()
+	resolved, err := resolveCLIEngineSettings(ctx, cfg, parsedValues)
+	if err != nil {
+		return err
+	}
+	defer closeResolved(resolved)
+
+	_, err = geppettobootstrap.HandleInferenceDebugOutput(
+		w,
+		cfg,
+		parsedValues,
+		geppettobootstrap.InferenceDebugSettings{PrintInferenceSettings: true},
+		&geppettobootstrap.ResolvedInferenceTrace{
+			FinalInferenceSettings: resolved.FinalInferenceSettings,
+			ResolvedEngineProfile:  resolved.ResolvedEngineProfile,
+		},
+		geppettobootstrap.InferenceDebugOutputOptions{},
+	)
+	return err
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/tokencount"
+	tokencountfactory "github.com/go-go-golems/geppetto/pkg/inference/tokencount/factory"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/go-go-golems/geppetto/pkg/turns/serde"
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+type tokensCountCommand struct {
+	*cmds.CommandDescription
+}
+
+var _ cmds.WriterCommand = (*tokensCountCommand)(nil)
+
+type tokensCountSettings struct {
+	Prompt       string `glazed:"prompt"`
+	TurnFile     string `glazed:"turn"`
+	SystemPrompt string `glazed:"system-prompt"`
+	Estimate     bool   `glazed:"estimate"`
+	Output       string `glazed:"output-format"`
+}
+
+func newTokensCountCommand() (*tokensCountCommand, error) {
+	sections, err := inferenceSections()
+	if err != nil {
+		return nil, errors.Wrap(err, "create inference sections")
+	}
+
+	description := cmds.NewCommandDescription(
+		"count",
+		cmds.WithShort("Count the input tokens of a prompt or turn YAML file"),
+		cmds.WithLong(`Count input tokens for the selected profile without running inference.
+
+Providers with a token counting endpoint (Claude, OpenAI Responses) are asked
+directly. Other providers, or --estimate, fall back to a character-based
+estimate; the "source" field of the result says which one was used.`),
+		cmds.WithArguments(
+			fields.New("prompt", fields.TypeString, fields.WithHelp("Prompt to count")),
+		),
+		cmds.WithFlags(
+			fields.New(
+				"turn",
+				fields.TypeString,
+				fields.WithHelp("Turn YAML file to count"),
+			),
+			fields.New(
+				"system-prompt",
+				fields.TypeString,
+				fields.WithHelp("System prompt prepended before counting"),
+			),
+			fields.New(
+				"estimate",
+				fields.TypeBool,
+				fields.WithDefault(false),
+				fields.WithHelp("Use the local estimate instead of the provider endpoint"),
+			),
+			fields.New(
+				"output-format",
+				fields.TypeChoice,
+				fields.WithChoices("text", "json", "yaml"),
+				fields.WithDefault("text"),
+				fields.WithHelp("Result format"),
+			),
+		),
+		cmds.WithSections(sections...),
+	)
+
+	return &tokensCountCommand{CommandDescription: description}, nil
+}
+
+func (c *tokensCountCommand) RunIntoWriter(ctx context.Context, parsedValues *values.Values, w io.Writer) error {
+	s := &tokensCountSettings{}
+	if err := parsedValues.DecodeSectionInto(values.DefaultSlug, s); err != nil {
+		return errors.Wrap(err, "decode token count settings")
+	}
+
+	resolved, printed, err := resolveEngineSettings(ctx, parsedValues, w)
+	defer closeResolved(resolved)
+	if err != nil || printed {
+		return err
+	}
+
+	t := &turns.Turn{}
+	if s.TurnFile != "" {
+		t, err = serde.LoadTurnYAML(s.TurnFile)
+		if err != nil {
+			return errors.Wrapf(err, "load turn %s", s.TurnFile)
+		}
+	}
+	if strings.TrimSpace(s.SystemPrompt) != "" {
+		t.Blocks = append([]turns.Block{turns.NewSystemTextBlock(s.SystemPrompt)}, t.Blocks...)
+	}
+	if strings.TrimSpace(s.Prompt) != "" {
+		turns.AppendBlock(t, turns.NewUserTextBlock(s.Prompt))
+	}
+	if len(t.Blocks) == 0 {
+		return errors.New("a prompt or a non-empty --turn file is required")
+	}
+
+	res, err := newTokenCounter(resolved.FinalInferenceSettings, s.Estimate).CountTurn(ctx, t)
+	if err != nil {
+		return err
+	}
+
+	switch s.Output {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	case "yaml":
+		b, err := yaml.Marshal(res)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	default:
+		_, err := fmt.Fprintf(w, "%d input tokens (%s, %s/%s)\n", res.InputTokens, res.Source, res.Provider, res.Model)
+		return err
+	}
+}
+
+// newTokenCounter prefers the provider endpoint and falls back to the local
+// estimate for providers that have none.
+func newTokenCounter(ss *settings.InferenceSettings, estimate bool) tokencount.Counter {
+	if !estimate {
+		counter, err := tokencountfactory.NewFromSettings(ss)
+		if err == nil {
+			return counter
+		}
+		log.Debug().Err(err).Msg("token count: falling back to estimate")
+	}
+	provider, model := "", ""
+	if ss != nil && ss.Chat != nil {
+		if ss.Chat.ApiType != nil {
+			provider = string(*ss.Chat.ApiType)
+		}
+		if ss.Chat.Engine != nil {
+			model = *ss.Chat.Engine
+		}
+	}
+	return tokencount.NewEstimateCounter(provider, model)
+}
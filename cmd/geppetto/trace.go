@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/go-go-golems/geppetto/pkg/observability/tracefile"
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+type traceInspectCommand struct {
+	*cmds.CommandDescription
+}
+
+var _ cmds.WriterCommand = (*traceInspectCommand)(nil)
+
+type traceInspectSettings struct {
+	Files       []string `glazed:"files"`
+	SessionID   string   `glazed:"session-id"`
+	InferenceID string   `glazed:"inference-id"`
+	RunID       string   `glazed:"run-id"`
+	Rotated     bool     `glazed:"rotated"`
+	MaxText     int      `glazed:"max-text"`
+	Output      string   `glazed:"output-format"`
+}
+
+func newTraceInspectCommand() (*traceInspectCommand, error) {
+	description := cmds.NewCommandDescription(
+		"inspect",
+		cmds.WithShort("Reconstruct run timelines from JSONL trace files"),
+		cmds.WithLong(`Load trace files written by --trace-file or a tracefile.Recorder and print
+each run: its provider calls, text and reasoning segments, tool calls with
+arguments and results, retries, and usage per call.
+
+Examples:
+  geppetto trace inspect trace.jsonl
+  geppetto trace inspect --rotated --session-id chat-1 trace.jsonl
+  geppetto trace inspect --run-id 4f1c... --output-format json trace.jsonl`),
+		cmds.WithArguments(
+			fields.New("files", fields.TypeStringList, fields.WithRequired(true), fields.WithHelp("Trace files to load")),
+		),
+		cmds.WithFlags(
+			fields.New("session-id", fields.TypeString, fields.WithHelp("Only show runs of this session")),
+			fields.New("inference-id", fields.TypeString, fields.WithHelp("Only show runs of this inference")),
+			fields.New("run-id", fields.TypeString, fields.WithHelp("Only show this run")),
+			fields.New(
+				"rotated",
+				fields.TypeBool,
+				fields.WithDefault(false),
+				fields.WithHelp("Also load the rotated files of each trace file, oldest first"),
+			),
+			fields.New(
+				"max-text",
+				fields.TypeInteger,
+				fields.WithDefault(200),
+				fields.WithHelp("Truncate texts, tool arguments and results to this many characters in text output (0 = no limit)"),
+			),
+			fields.New(
+				"output-format",
+				fields.TypeChoice,
+				fields.WithChoices("text", "json", "yaml"),
+				fields.WithDefault("text"),
+				fields.WithHelp("Timeline format"),
+			),
+		),
+	)
+	return &traceInspectCommand{CommandDescription: description}, nil
+}
+
+func (c *traceInspectCommand) RunIntoWriter(_ context.Context, parsedValues *values.Values, w io.Writer) error {
+	s := &traceInspectSettings{}
+	if err := parsedValues.DecodeSectionInto(values.DefaultSlug, s); err != nil {
+		return errors.Wrap(err, "decode trace inspect settings")
+	}
+
+	paths := s.Files
+	if s.Rotated {
+		paths = nil
+		for _, file := range s.Files {
+			rotated, err := tracefile.Files(file)
+			if err != nil {
+				return err
+			}
+			paths = append(paths, rotated...)
+		}
+	}
+	entries, err := tracefile.LoadFiles(paths...)
+	if err != nil {
+		return err
+	}
+	runs := tracefile.BuildTimeline(entries, tracefile.Filter{
+		SessionID:   s.SessionID,
+		InferenceID: s.InferenceID,
+		RunID:       s.RunID,
+	})
+
+	switch s.Output {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(runs)
+	case "yaml":
+		b, err := yaml.Marshal(runs)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	default:
+		if len(runs) == 0 {
+			_, err := io.WriteString(w, "no matching runs\n")
+			return err
+		}
+		tracefile.Fprint(w, runs, s.MaxText)
+		return nil
+	}
+}
@@ -151,7 +151,9 @@ declare module "geppetto" {
 
     export interface RerankUsage {
         inputTokens?: number;
+        outputTokens?: number;
         totalTokens?: number;
+        searchUnits?: number;
     }
 
     export interface RerankResponse {
@@ -21,6 +21,7 @@ export type BlockKind =
 export declare const ToolConfigValueKey: "tool_config";
 export declare const ToolDefinitionsValueKey: "tool_definitions";
 export declare const StructuredOutputConfigValueKey: "structured_output_config";
+export declare const StructuredOutputResultValueKey: "structured_output_result";
 export declare const InferenceConfigValueKey: "inference_config";
 export declare const ClaudeInferenceConfigValueKey: "claude_inference_config";
 export declare const OpenAIInferenceConfigValueKey: "openai_inference_config";
@@ -42,6 +43,8 @@ export declare const BlockMetaMiddlewareValueKey: "middleware";
 export declare const BlockMetaAgentModeTagValueKey: "agentmode_tag";
 export declare const BlockMetaAgentModeValueKey: "agentmode";
 export declare const BlockMetaInferenceResultValueKey: "inference_result";
+export declare const BlockMetaCacheControlValueKey: "cache_control";
+export declare const BlockMetaCitationsValueKey: "citations";
 export declare const RunMetaKeyTraceID: "trace_id";
 export declare const PayloadKeyText: "text";
 export declare const PayloadKeyID: "id";
@@ -50,6 +53,8 @@ export declare const PayloadKeyArgs: "args";
 export declare const PayloadKeyResult: "result";
 export declare const PayloadKeyError: "error";
 export declare const PayloadKeyImages: "images";
+export declare const PayloadKeyDocuments: "documents";
+export declare const PayloadKeyFiles: "files";
 export declare const PayloadKeyEncryptedContent: "encrypted_content";
 export declare const PayloadKeySummary: "summary";
 export declare const PayloadKeyItemID: "item_id";
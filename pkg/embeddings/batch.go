@@ -22,7 +22,9 @@ func DefaultGenerateBatchEmbeddings(ctx context.Context, p Provider, texts []str
 
 // ParallelGenerateBatchEmbeddings provides a concurrent implementation of batch processing
 // by calling GenerateEmbedding for each text in parallel with a limit on concurrency.
-// This can be more efficient than sequential processing for providers with rate limiting.
+// maxConcurrency only bounds this call; provider-wide request, token and
+// concurrency limits are enforced by the shared ratelimit governor installed on
+// the provider's HTTP client.
 func ParallelGenerateBatchEmbeddings(ctx context.Context, p Provider, texts []string, maxConcurrency int) ([][]float32, error) {
 	if maxConcurrency <= 0 {
 		maxConcurrency = 4 // Default concurrency
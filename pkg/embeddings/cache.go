@@ -39,9 +39,11 @@ func NewCachedProvider(provider Provider, maxSize int) *CachedProvider {
 
 // GenerateEmbedding returns cached embeddings if available, otherwise generates new ones
 func (c *CachedProvider) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	key := cacheKey(ctx, text)
+
 	// Check cache first
 	c.mu.RLock()
-	if entry, ok := c.cache[text]; ok {
+	if entry, ok := c.cache[key]; ok {
 		// Move to front of LRU list
 		c.mu.RUnlock()
 		c.mu.Lock()
@@ -72,8 +74,8 @@ func (c *CachedProvider) GenerateEmbedding(ctx context.Context, text string) ([]
 	}
 
 	// Add new entry
-	element := c.lruList.PushFront(text)
-	c.cache[text] = cacheEntry{
+	element := c.lruList.PushFront(key)
+	c.cache[key] = cacheEntry{
 		embedding: embedding,
 		element:   element,
 	}
@@ -88,11 +90,15 @@ func (c *CachedProvider) GenerateBatchEmbeddings(ctx context.Context, texts []st
 	// Track which texts need to be fetched from the provider
 	missedIndices := []int{}
 	missedTexts := []string{}
+	keys := make([]string, len(texts))
+	for i, text := range texts {
+		keys[i] = cacheKey(ctx, text)
+	}
 
 	// Check cache first for each text
 	c.mu.RLock()
 	for i, text := range texts {
-		if entry, ok := c.cache[text]; ok {
+		if entry, ok := c.cache[keys[i]]; ok {
 			// Cache hit
 			results[i] = entry.embedding
 		} else {
@@ -106,8 +112,8 @@ func (c *CachedProvider) GenerateBatchEmbeddings(ctx context.Context, texts []st
 	if len(missedIndices) < len(texts) {
 		c.mu.RUnlock()
 		c.mu.Lock()
-		for _, text := range texts {
-			if entry, ok := c.cache[text]; ok {
+		for _, key := range keys {
+			if entry, ok := c.cache[key]; ok {
 				c.lruList.MoveToFront(entry.element)
 			}
 		}
@@ -133,7 +139,7 @@ func (c *CachedProvider) GenerateBatchEmbeddings(ctx context.Context, texts []st
 
 	for i, embedding := range missedEmbeddings {
 		originalIdx := missedIndices[i]
-		text := missedTexts[i]
+		key := keys[originalIdx]
 		results[originalIdx] = embedding
 
 		// If we're at capacity, remove the least recently used item
@@ -147,8 +153,8 @@ func (c *CachedProvider) GenerateBatchEmbeddings(ctx context.Context, texts []st
 		}
 
 		// Add to cache
-		element := c.lruList.PushFront(text)
-		c.cache[text] = cacheEntry{
+		element := c.lruList.PushFront(key)
+		c.cache[key] = cacheEntry{
 			embedding: embedding,
 			element:   element,
 		}
@@ -0,0 +1,137 @@
+package embeddings
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/security"
+)
+
+const (
+	// DefaultCohereBaseURL is the hosted Cohere API.
+	DefaultCohereBaseURL = "https://api.cohere.com"
+	// cohereMaxBatchSize is the largest number of texts /v2/embed accepts.
+	cohereMaxBatchSize = 96
+)
+
+// CohereProvider embeds texts with the Cohere /v2/embed endpoint.
+type CohereProvider struct {
+	jsonEndpoint
+	model      string
+	dimensions int
+	inputType  InputType
+}
+
+type cohereRequest struct {
+	Model           string   `json:"model"`
+	Texts           []string `json:"texts"`
+	InputType       string   `json:"input_type"`
+	EmbeddingTypes  []string `json:"embedding_types"`
+	OutputDimension int      `json:"output_dimension,omitempty"`
+}
+
+type cohereResponse struct {
+	Embeddings struct {
+		Float [][]float32 `json:"float"`
+	} `json:"embeddings"`
+}
+
+var _ Provider = &CohereProvider{}
+
+func NewCohereProvider(apiKey string, model string, dimensions int) *CohereProvider {
+	if model == "" {
+		model = "embed-v4.0"
+	}
+	// v3 models have a fixed size, so an override would misreport it.
+	if dimensions <= 0 || !supportsCohereOutputDimension(model) {
+		dimensions = cohereDefaultDimensions(model)
+	}
+
+	return &CohereProvider{
+		jsonEndpoint: jsonEndpoint{
+			name:       "cohere",
+			httpClient: http.DefaultClient,
+			baseURL:    DefaultCohereBaseURL,
+			apiKey:     apiKey,
+			outbound:   security.OutboundURLOptions{},
+		},
+		model:      model,
+		dimensions: dimensions,
+		inputType:  InputTypeDocument,
+	}
+}
+
+// SetInputType sets the input type used when the context carries none.
+func (p *CohereProvider) SetInputType(inputType InputType) {
+	if inputType != "" {
+		p.inputType = inputType
+	}
+}
+
+func (p *CohereProvider) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	batch, err := p.embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return singleEmbedding("cohere", batch)
+}
+
+func (p *CohereProvider) GenerateBatchEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	return chunkedBatchEmbeddings(ctx, "cohere", texts, cohereMaxBatchSize, p.embed)
+}
+
+func (p *CohereProvider) GetModel() EmbeddingModel {
+	return EmbeddingModel{
+		Name:       p.model,
+		Dimensions: p.dimensions,
+	}
+}
+
+func (p *CohereProvider) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var resp cohereResponse
+	if err := p.post(ctx, "v2/embed", texts, p.newRequest(ctx, texts), &resp); err != nil {
+		return nil, err
+	}
+	return resp.Embeddings.Float, nil
+}
+
+func (p *CohereProvider) newRequest(ctx context.Context, texts []string) cohereRequest {
+	req := cohereRequest{
+		Model:          p.model,
+		Texts:          texts,
+		InputType:      cohereInputType(resolveInputType(ctx, p.inputType)),
+		EmbeddingTypes: []string{"float"},
+	}
+	if supportsCohereOutputDimension(p.model) {
+		req.OutputDimension = p.dimensions
+	}
+	return req
+}
+
+func cohereInputType(inputType InputType) string {
+	if inputType == InputTypeQuery {
+		return "search_query"
+	}
+	return "search_document"
+}
+
+// cohereDefaultDimensions returns the size a model produces without an
+// output_dimension override: 384 for the v3 light models, 1024 for the other
+// v3 models and 1536 for embed-v4.0.
+func cohereDefaultDimensions(model string) int {
+	switch {
+	case strings.HasSuffix(model, "-light-v3.0"):
+		return 384
+	case strings.HasSuffix(model, "-v3.0"):
+		return 1024
+	default:
+		return 1536
+	}
+}
+
+func supportsCohereOutputDimension(model string) bool {
+	// Cohere supports `output_dimension` from embed-v4.0 on; v3 models have a
+	// fixed size.
+	return strings.HasPrefix(model, "embed-v4")
+}
@@ -0,0 +1,79 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var allowLocalTestServers = security.OutboundURLOptions{AllowHTTP: true, AllowLocalNetworks: true}
+
+func TestCohereProviderBatchesAndSendsInputType(t *testing.T) {
+	var requests []cohereRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/embed", r.URL.Path)
+		assert.Equal(t, "Bearer cohere-key", r.Header.Get("Authorization"))
+		var req cohereRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests = append(requests, req)
+		vectors := make([][]float32, len(req.Texts))
+		for i := range vectors {
+			vectors[i] = []float32{float32(len(requests)), float32(i)}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"embeddings": map[string]any{"float": vectors}})
+	}))
+	t.Cleanup(srv.Close)
+
+	p := NewCohereProvider("cohere-key", "embed-v4.0", 512)
+	p.SetBaseURL(srv.URL)
+	p.outbound = allowLocalTestServers
+
+	texts := make([]string, cohereMaxBatchSize+4)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("text %d", i)
+	}
+	got, err := p.GenerateBatchEmbeddings(WithInputType(context.Background(), InputTypeQuery), texts)
+	require.NoError(t, err)
+	require.Len(t, got, len(texts))
+	assert.Equal(t, []float32{2, 3}, got[cohereMaxBatchSize+3])
+
+	require.Len(t, requests, 2)
+	assert.Len(t, requests[0].Texts, cohereMaxBatchSize)
+	assert.Equal(t, "search_query", requests[0].InputType)
+	assert.Equal(t, []string{"float"}, requests[0].EmbeddingTypes)
+	assert.Equal(t, 512, requests[0].OutputDimension)
+
+	_, err = p.GenerateEmbedding(context.Background(), "doc")
+	require.NoError(t, err)
+	assert.Equal(t, "search_document", requests[2].InputType)
+}
+
+func TestCohereProviderNewRequestDimensions(t *testing.T) {
+	assert.Equal(t, 1024, NewCohereProvider("k", "embed-v4.0", 1024).newRequest(context.Background(), nil).OutputDimension)
+	assert.Equal(t, 0, NewCohereProvider("k", "embed-english-v3.0", 1024).newRequest(context.Background(), nil).OutputDimension)
+}
+
+func TestCohereProviderDefaultDimensionsPerModel(t *testing.T) {
+	assert.Equal(t, 1536, NewCohereProvider("k", "", 0).GetModel().Dimensions)
+	assert.Equal(t, 1536, NewCohereProvider("k", "embed-v4.0", 0).GetModel().Dimensions)
+	assert.Equal(t, 1024, NewCohereProvider("k", "embed-english-v3.0", 0).GetModel().Dimensions)
+	assert.Equal(t, 1024, NewCohereProvider("k", "embed-multilingual-v3.0", 0).GetModel().Dimensions)
+	assert.Equal(t, 384, NewCohereProvider("k", "embed-english-light-v3.0", 0).GetModel().Dimensions)
+	assert.Equal(t, 1024, NewCohereProvider("k", "embed-english-v3.0", 256).GetModel().Dimensions)
+	assert.Equal(t, 256, NewCohereProvider("k", "embed-v4.0", 256).GetModel().Dimensions)
+}
+
+func TestCohereProviderRejectsLocalURLByDefault(t *testing.T) {
+	p := NewCohereProvider("k", "embed-v4.0", 1024)
+	p.SetBaseURL("http://127.0.0.1:1")
+	_, err := p.GenerateEmbedding(context.Background(), "text")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid cohere endpoint URL")
+}
@@ -10,12 +10,19 @@ import (
 
 // EmbeddingsConfig contains the minimal configuration needed for embeddings
 type EmbeddingsConfig struct {
-	// Type specifies the provider type (e.g. "openai", "ollama")
+	// Type specifies the provider type: "openai", "ollama", "gemini",
+	// "cohere", "voyage", "tei" or "llamacpp"
 	Type string `glazed:"embeddings-type"`
 	// Engine specifies the model to use (e.g. "text-embedding-ada-002" for OpenAI)
 	Engine string `glazed:"embeddings-engine"`
-	// Dimensions specifies the embedding dimensions (defaults to 1536 for OpenAI)
+	// Dimensions specifies the embedding dimensions (defaults to 1536 for OpenAI).
+	// Models that support shortened vectors (OpenAI text-embedding-3, Gemini,
+	// Cohere embed-v4, Voyage 3.5 and later) are asked for this size.
 	Dimensions int `glazed:"embeddings-dimensions"`
+	// InputType is the default input-type hint, "query" or "document", for
+	// providers that embed queries and documents differently (Gemini, Cohere,
+	// Voyage). embeddings.WithInputType overrides it per call.
+	InputType string `yaml:"input_type,omitempty" glazed:"embeddings-input-type"`
 	// APIKeys maps provider types to their API keys
 	APIKeys map[string]string `yaml:"api_keys,omitempty" glazed:"*-api-key"`
 	// BaseURLs maps provider types to their base URLs
@@ -76,6 +83,21 @@ func NewEmbeddingsApiKeyValue() (*schema.SectionImpl, error) {
 				fields.TypeString,
 				fields.WithHelp("The API key for the OpenAI embeddings provider"),
 			),
+			fields.New(
+				"gemini-api-key",
+				fields.TypeString,
+				fields.WithHelp("The API key for the Gemini embeddings provider"),
+			),
+			fields.New(
+				"cohere-api-key",
+				fields.TypeString,
+				fields.WithHelp("The API key for the Cohere embeddings provider"),
+			),
+			fields.New(
+				"voyage-api-key",
+				fields.TypeString,
+				fields.WithHelp("The API key for the Voyage embeddings provider"),
+			),
 		),
 	)
 }
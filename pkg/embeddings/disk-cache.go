@@ -83,19 +83,19 @@ func NewDiskCacheProvider(provider Provider, opts ...Option) (*DiskCacheProvider
 	return p, nil
 }
 
-func (p *DiskCacheProvider) getCacheFilePath(text string) string {
-	hash := sha256.Sum256([]byte(text))
+func (p *DiskCacheProvider) getCacheFilePath(key string) string {
+	hash := sha256.Sum256([]byte(key))
 	return filepath.Join(p.directory, hex.EncodeToString(hash[:]))
 }
 
-func (p *DiskCacheProvider) writeEntry(text string, entry *DiskCacheEntry) error {
+func (p *DiskCacheProvider) writeEntry(key string, entry *DiskCacheEntry) error {
 	data, err := json.Marshal(entry)
 	if err != nil {
 		log.Warn().Err(err).Msg("failed to marshal entry")
 		return err
 	}
 
-	path := p.getCacheFilePath(text)
+	path := p.getCacheFilePath(key)
 	if err := os.WriteFile(path, data, 0644); err != nil {
 		log.Warn().Err(err).Msg("failed to write cache file")
 		return err
@@ -104,8 +104,8 @@ func (p *DiskCacheProvider) writeEntry(text string, entry *DiskCacheEntry) error
 	return nil
 }
 
-func (p *DiskCacheProvider) readEntry(text string) (*DiskCacheEntry, error) {
-	path := p.getCacheFilePath(text)
+func (p *DiskCacheProvider) readEntry(key string) (*DiskCacheEntry, error) {
+	path := p.getCacheFilePath(key)
 
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -178,7 +178,7 @@ func (p *DiskCacheProvider) enforceSize() error {
 
 func (p *DiskCacheProvider) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
 	p.mu.RLock()
-	entry, err := p.readEntry(text)
+	entry, err := p.readEntry(cacheKey(ctx, text))
 	p.mu.RUnlock()
 	if err != nil {
 		log.Warn().Err(err).Msg("failed to read cache entry")
@@ -205,7 +205,7 @@ func (p *DiskCacheProvider) GenerateEmbedding(ctx context.Context, text string)
 		TextPrefix: prefix,
 	}
 
-	if err := p.writeEntry(text, entry); err != nil {
+	if err := p.writeEntry(cacheKey(ctx, text), entry); err != nil {
 		log.Warn().Err(err).Msg("failed to write cache entry")
 		return embedding, nil
 	}
@@ -234,7 +234,7 @@ func (p *DiskCacheProvider) GenerateBatchEmbeddings(ctx context.Context, texts [
 	// First try to get entries from disk cache
 	for i, text := range texts {
 		p.mu.RLock()
-		entry, err := p.readEntry(text)
+		entry, err := p.readEntry(cacheKey(ctx, text))
 		p.mu.RUnlock()
 
 		if err != nil {
@@ -282,7 +282,7 @@ func (p *DiskCacheProvider) GenerateBatchEmbeddings(ctx context.Context, texts [
 			TextPrefix: prefix,
 		}
 
-		if err := p.writeEntry(text, entry); err != nil {
+		if err := p.writeEntry(cacheKey(ctx, text), entry); err != nil {
 			log.Warn().Err(err).Str("text", truncateText(text, 20)).Msg("failed to write cache entry")
 		}
 	}
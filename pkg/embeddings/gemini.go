@@ -0,0 +1,167 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/go-go-golems/geppetto/pkg/security"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/ratelimit"
+	"google.golang.org/genai"
+)
+
+// geminiMaxBatchSize is the largest number of texts batchEmbedContents
+// accepts.
+const geminiMaxBatchSize = 100
+
+// GeminiProvider embeds texts with the Gemini API through the genai SDK, using
+// batchEmbedContents for batches. The SDK client is created on first use and
+// reused until the HTTP client or base URL changes.
+type GeminiProvider struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+	outbound   security.OutboundURLOptions
+	model      string
+	dimensions int
+	inputType  InputType
+
+	mu     sync.Mutex
+	client *genai.Client
+}
+
+var _ Provider = &GeminiProvider{}
+
+func NewGeminiProvider(apiKey string, model string, dimensions int) *GeminiProvider {
+	if model == "" {
+		model = "gemini-embedding-001"
+	}
+	if dimensions <= 0 {
+		dimensions = 3072 // Default for gemini-embedding-001
+	}
+
+	return &GeminiProvider{
+		apiKey:     apiKey,
+		httpClient: http.DefaultClient,
+		outbound:   security.OutboundURLOptions{},
+		model:      model,
+		dimensions: dimensions,
+	}
+}
+
+// SetHTTPClient replaces the HTTP client used to reach the Gemini API.
+func (p *GeminiProvider) SetHTTPClient(httpClient *http.Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.httpClient = httpClient
+	p.client = nil
+}
+
+// SetBaseURL overrides the SDK's default Gemini API endpoint.
+func (p *GeminiProvider) SetBaseURL(baseURL string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.baseURL = baseURL
+	p.client = nil
+}
+
+// SetInputType sets the input type used when the context carries none.
+// Without one Gemini embeds texts for no particular task.
+func (p *GeminiProvider) SetInputType(inputType InputType) {
+	p.inputType = inputType
+}
+
+func (p *GeminiProvider) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	batch, err := p.embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return singleEmbedding("gemini", batch)
+}
+
+func (p *GeminiProvider) GenerateBatchEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	return chunkedBatchEmbeddings(ctx, "gemini", texts, geminiMaxBatchSize, p.embed)
+}
+
+func (p *GeminiProvider) GetModel() EmbeddingModel {
+	return EmbeddingModel{
+		Name:       p.model,
+		Dimensions: p.dimensions,
+	}
+}
+
+// genaiClient returns the SDK client, creating it on first use.
+func (p *GeminiProvider) genaiClient(ctx context.Context) (*genai.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client != nil {
+		return p.client, nil
+	}
+	if p.baseURL != "" {
+		if err := security.ValidateOutboundURL(p.baseURL, p.outbound); err != nil {
+			return nil, fmt.Errorf("invalid gemini endpoint URL: %w", err)
+		}
+	}
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:     p.apiKey,
+		Backend:    genai.BackendGeminiAPI,
+		HTTPClient: p.httpClient,
+		HTTPOptions: genai.HTTPOptions{
+			BaseURL: p.baseURL,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gemini client: %w", err)
+	}
+	p.client = client
+	return client, nil
+}
+
+func (p *GeminiProvider) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	client, err := p.genaiClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := make([]*genai.Content, len(texts))
+	for i, text := range texts {
+		contents[i] = genai.NewContentFromText(text, genai.RoleUser)
+	}
+
+	ctx = ratelimit.WithTokenEstimate(ctx, ratelimit.EstimateTokens(texts...))
+	resp, err := client.Models.EmbedContent(ctx, p.model, contents, p.newConfig(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("gemini embeddings request failed: %w", err)
+	}
+
+	results := make([][]float32, len(resp.Embeddings))
+	for i, embedding := range resp.Embeddings {
+		if embedding == nil {
+			return nil, fmt.Errorf("gemini returned an empty embedding at index %d", i)
+		}
+		results[i] = embedding.Values
+	}
+	return results, nil
+}
+
+func (p *GeminiProvider) newConfig(ctx context.Context) *genai.EmbedContentConfig {
+	config := &genai.EmbedContentConfig{}
+	switch resolveInputType(ctx, p.inputType) {
+	case InputTypeQuery:
+		config.TaskType = "RETRIEVAL_QUERY"
+	case InputTypeDocument:
+		config.TaskType = "RETRIEVAL_DOCUMENT"
+	}
+	if supportsGeminiOutputDimensionality(p.model) {
+		dimensions := int32(min(p.dimensions, 1<<16)) // #nosec G115 -- clamped above.
+		config.OutputDimensionality = &dimensions
+	}
+	return config
+}
+
+func supportsGeminiOutputDimensionality(model string) bool {
+	// Only the legacy embedding-001 model rejects `outputDimensionality`.
+	return strings.TrimPrefix(model, "models/") != "embedding-001"
+}
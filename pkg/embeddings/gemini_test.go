@@ -0,0 +1,74 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeminiProviderUsesBatchEmbedContents(t *testing.T) {
+	var got struct {
+		Requests []struct {
+			Model                string `json:"model"`
+			TaskType             string `json:"taskType"`
+			OutputDimensionality int    `json:"outputDimensionality"`
+		} `json:"requests"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1beta/models/gemini-embedding-001:batchEmbedContents", r.URL.Path)
+		assert.Equal(t, "gemini-key", r.Header.Get("x-goog-api-key"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`{"embeddings":[{"values":[0.1,0.2]},{"values":[0.3,0.4]}]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	p := NewGeminiProvider("gemini-key", "gemini-embedding-001", 768)
+	p.SetBaseURL(srv.URL)
+	p.outbound = allowLocalTestServers
+
+	embeddings, err := p.GenerateBatchEmbeddings(WithInputType(context.Background(), InputTypeQuery), []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0.1, 0.2}, {0.3, 0.4}}, embeddings)
+	require.Len(t, got.Requests, 2)
+	assert.Equal(t, "models/gemini-embedding-001", got.Requests[0].Model)
+	assert.Equal(t, "RETRIEVAL_QUERY", got.Requests[0].TaskType)
+	assert.Equal(t, 768, got.Requests[0].OutputDimensionality)
+}
+
+func TestGeminiProviderReusesClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"embeddings":[{"values":[0.1]}]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	p := NewGeminiProvider("gemini-key", "gemini-embedding-001", 1)
+	p.SetBaseURL(srv.URL)
+	p.outbound = allowLocalTestServers
+	_, err := p.GenerateEmbedding(context.Background(), "a")
+	require.NoError(t, err)
+	first := p.client
+	require.NotNil(t, first)
+	_, err = p.GenerateEmbedding(context.Background(), "b")
+	require.NoError(t, err)
+	assert.Same(t, first, p.client)
+
+	p.SetHTTPClient(srv.Client())
+	_, err = p.GenerateEmbedding(context.Background(), "c")
+	require.NoError(t, err)
+	assert.NotSame(t, first, p.client)
+}
+
+func TestGeminiProviderNewConfig(t *testing.T) {
+	p := NewGeminiProvider("k", "models/embedding-001", 768)
+	p.SetInputType(InputTypeDocument)
+	config := p.newConfig(context.Background())
+	assert.Equal(t, "RETRIEVAL_DOCUMENT", config.TaskType)
+	assert.Nil(t, config.OutputDimensionality)
+
+	assert.Empty(t, NewGeminiProvider("k", "", 0).newConfig(context.Background()).TaskType)
+}
@@ -0,0 +1,129 @@
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/security"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/ratelimit"
+)
+
+// maxEmbeddingResponseBytes bounds decoded response bodies. A batch of 128
+// 3072-dimension vectors is roughly 8 MiB of JSON.
+const maxEmbeddingResponseBytes = 64 << 20
+
+// jsonEndpoint is the HTTP transport shared by the JSON embedding providers
+// (Cohere, Voyage, TEI and llama.cpp).
+type jsonEndpoint struct {
+	name       string
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	outbound   security.OutboundURLOptions
+}
+
+// SetHTTPClient replaces the HTTP client used to reach the embeddings API, for
+// example with one governed by a ratelimit.Governor.
+func (e *jsonEndpoint) SetHTTPClient(httpClient *http.Client) {
+	e.httpClient = httpClient
+}
+
+// SetBaseURL overrides the provider's default endpoint, for example to route
+// through a gateway.
+func (e *jsonEndpoint) SetBaseURL(baseURL string) {
+	if baseURL != "" {
+		e.baseURL = baseURL
+	}
+}
+
+// post sends payload to baseURL+path and decodes the JSON reply into out.
+// Error bodies are not included in errors because providers may echo the
+// embedded texts.
+func (e *jsonEndpoint) post(ctx context.Context, path string, texts []string, payload any, out any) error {
+	name := e.name
+	endpointURL := strings.TrimRight(e.baseURL, "/") + "/" + strings.TrimLeft(path, "/")
+	if err := security.ValidateOutboundURL(endpointURL, e.outbound); err != nil {
+		return fmt.Errorf("invalid %s endpoint URL: %w", name, err)
+	}
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s request: %w", name, err)
+	}
+
+	ctx = ratelimit.WithTokenEstimate(ctx, ratelimit.EstimateTokens(texts...))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpointURL, bytes.NewReader(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", name, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	// #nosec G704 -- endpoint URL is validated above against the provider's outbound policy.
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send %s request: %w", name, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Warn().Err(err).Msg("failed to close response body")
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected %s status code: %d", name, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxEmbeddingResponseBytes+1))
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %w", name, err)
+	}
+	if len(body) > maxEmbeddingResponseBytes {
+		return fmt.Errorf("%s response exceeds %d bytes", name, maxEmbeddingResponseBytes)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", name, err)
+	}
+	return nil
+}
+
+// chunkedBatchEmbeddings splits texts into batches of at most batchSize,
+// embeds them in order with embed, and checks that every batch returns one
+// vector per text.
+func chunkedBatchEmbeddings(
+	ctx context.Context,
+	name string,
+	texts []string,
+	batchSize int,
+	embed func(ctx context.Context, batch []string) ([][]float32, error),
+) ([][]float32, error) {
+	results := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		end := min(start+batchSize, len(texts))
+		batch, err := embed(ctx, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("%s returned %d embeddings but expected %d", name, len(batch), end-start)
+		}
+		results = append(results, batch...)
+	}
+	return results, nil
+}
+
+// singleEmbedding returns the only vector of a one-text batch.
+func singleEmbedding(name string, batch [][]float32) ([]float32, error) {
+	if len(batch) != 1 {
+		return nil, fmt.Errorf("%s returned %d embeddings but expected 1", name, len(batch))
+	}
+	return batch[0], nil
+}
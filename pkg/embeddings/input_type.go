@@ -0,0 +1,63 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// InputType tells providers that distinguish them whether a text is a search
+// query or a document to be searched. Cohere, Voyage and Gemini embed the two
+// differently; OpenAI, Ollama, TEI and llama.cpp ignore the hint.
+type InputType string
+
+const (
+	// InputTypeQuery marks texts that are search queries.
+	InputTypeQuery InputType = "query"
+	// InputTypeDocument marks texts that are stored and searched.
+	InputTypeDocument InputType = "document"
+)
+
+type inputTypeKey struct{}
+
+// WithInputType returns a context that asks providers to embed texts as the
+// given input type. It overrides the configured embeddings-input-type.
+func WithInputType(ctx context.Context, inputType InputType) context.Context {
+	return context.WithValue(ctx, inputTypeKey{}, inputType)
+}
+
+// InputTypeFromContext returns the input type set with WithInputType.
+func InputTypeFromContext(ctx context.Context) (InputType, bool) {
+	inputType, ok := ctx.Value(inputTypeKey{}).(InputType)
+	return inputType, ok && inputType != ""
+}
+
+// ParseInputType validates a configured input type. The empty string means no
+// hint.
+func ParseInputType(s string) (InputType, error) {
+	switch inputType := InputType(strings.TrimSpace(s)); inputType {
+	case "", InputTypeQuery, InputTypeDocument:
+		return inputType, nil
+	default:
+		return "", fmt.Errorf("unsupported embeddings input type %q; supported values are query and document", s)
+	}
+}
+
+// resolveInputType returns the context input type, falling back to the
+// provider's configured default.
+func resolveInputType(ctx context.Context, fallback InputType) InputType {
+	if inputType, ok := InputTypeFromContext(ctx); ok {
+		return inputType
+	}
+	return fallback
+}
+
+// cacheKey keys cached embeddings by input type as well as text, so query and
+// document embeddings of the same text do not collide. Texts embedded without
+// a context hint keep their historical key.
+func cacheKey(ctx context.Context, text string) string {
+	if inputType, ok := InputTypeFromContext(ctx); ok {
+		return string(inputType) + "\x00" + text
+	}
+	return text
+}
@@ -0,0 +1,81 @@
+package embeddings
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// inputTypeProvider embeds every text as 1 for queries and 0 otherwise.
+type inputTypeProvider struct {
+	calls int
+}
+
+func (p *inputTypeProvider) GenerateEmbedding(ctx context.Context, _ string) ([]float32, error) {
+	p.calls++
+	if inputType, _ := InputTypeFromContext(ctx); inputType == InputTypeQuery {
+		return []float32{1}, nil
+	}
+	return []float32{0}, nil
+}
+
+func (p *inputTypeProvider) GenerateBatchEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	return DefaultGenerateBatchEmbeddings(ctx, p, texts)
+}
+
+func (p *inputTypeProvider) GetModel() EmbeddingModel {
+	return EmbeddingModel{Name: "input-type-model", Dimensions: 1}
+}
+
+func TestParseInputType(t *testing.T) {
+	inputType, err := ParseInputType(" query ")
+	require.NoError(t, err)
+	assert.Equal(t, InputTypeQuery, inputType)
+
+	inputType, err = ParseInputType("")
+	require.NoError(t, err)
+	assert.Equal(t, InputType(""), inputType)
+
+	_, err = ParseInputType("passage")
+	require.Error(t, err)
+}
+
+func TestCachesKeepQueryAndDocumentEmbeddingsApart(t *testing.T) {
+	query := WithInputType(context.Background(), InputTypeQuery)
+	document := WithInputType(context.Background(), InputTypeDocument)
+
+	t.Run("memory", func(t *testing.T) {
+		inner := &inputTypeProvider{}
+		cached := NewCachedProvider(inner, 10)
+
+		got, err := cached.GenerateEmbedding(query, "same text")
+		require.NoError(t, err)
+		assert.Equal(t, []float32{1}, got)
+		batch, err := cached.GenerateBatchEmbeddings(document, []string{"same text"})
+		require.NoError(t, err)
+		assert.Equal(t, [][]float32{{0}}, batch)
+		got, err = cached.GenerateEmbedding(query, "same text")
+		require.NoError(t, err)
+		assert.Equal(t, []float32{1}, got)
+		assert.Equal(t, 2, inner.calls)
+	})
+
+	t.Run("disk", func(t *testing.T) {
+		inner := &inputTypeProvider{}
+		cached, err := NewDiskCacheProvider(inner, WithDirectory(t.TempDir()))
+		require.NoError(t, err)
+
+		got, err := cached.GenerateEmbedding(query, "same text")
+		require.NoError(t, err)
+		assert.Equal(t, []float32{1}, got)
+		got, err = cached.GenerateEmbedding(document, "same text")
+		require.NoError(t, err)
+		assert.Equal(t, []float32{0}, got)
+		batch, err := cached.GenerateBatchEmbeddings(query, []string{"same text"})
+		require.NoError(t, err)
+		assert.Equal(t, [][]float32{{1}}, batch)
+		assert.Equal(t, 2, inner.calls)
+	})
+}
@@ -0,0 +1,116 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-go-golems/geppetto/pkg/security"
+)
+
+const (
+	// DefaultLlamaCppBaseURL is the default llama.cpp server address.
+	DefaultLlamaCppBaseURL = "http://localhost:8080"
+	// llamaCppMaxBatchSize keeps batches within a typical server --batch-size.
+	llamaCppMaxBatchSize = 32
+)
+
+// LlamaCppProvider embeds texts with a llama.cpp server started with
+// --embedding, through its native /embedding endpoint. The model is fixed by
+// the server; the configured engine only names it. Input-type hints are
+// ignored.
+type LlamaCppProvider struct {
+	jsonEndpoint
+	model      string
+	dimensions int
+}
+
+type llamaCppRequest struct {
+	Content []string `json:"content"`
+}
+
+// llamaCppItem is one result. With pooling the embedding is a single vector
+// wrapped in an array; older servers return the bare vector.
+type llamaCppItem struct {
+	Index     int             `json:"index"`
+	Embedding json.RawMessage `json:"embedding"`
+}
+
+var _ Provider = &LlamaCppProvider{}
+
+func NewLlamaCppProvider(baseURL string, apiKey string, model string, dimensions int) *LlamaCppProvider {
+	if baseURL == "" {
+		baseURL = DefaultLlamaCppBaseURL
+	}
+
+	return &LlamaCppProvider{
+		jsonEndpoint: jsonEndpoint{
+			name:       "llamacpp",
+			httpClient: http.DefaultClient,
+			baseURL:    baseURL,
+			apiKey:     apiKey,
+			// Self-hosted servers usually run on local networks, like Ollama.
+			outbound: security.OutboundURLOptions{AllowHTTP: true, AllowLocalNetworks: true},
+		},
+		model:      model,
+		dimensions: dimensions,
+	}
+}
+
+func (p *LlamaCppProvider) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	batch, err := p.embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return singleEmbedding("llamacpp", batch)
+}
+
+func (p *LlamaCppProvider) GenerateBatchEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	return chunkedBatchEmbeddings(ctx, "llamacpp", texts, llamaCppMaxBatchSize, p.embed)
+}
+
+func (p *LlamaCppProvider) GetModel() EmbeddingModel {
+	return EmbeddingModel{
+		Name:       p.model,
+		Dimensions: p.dimensions,
+	}
+}
+
+func (p *LlamaCppProvider) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var resp []llamaCppItem
+	if err := p.post(ctx, "embedding", texts, llamaCppRequest{Content: texts}, &resp); err != nil {
+		return nil, err
+	}
+
+	results := make([][]float32, len(texts))
+	for _, item := range resp {
+		if item.Index < 0 || item.Index >= len(texts) || results[item.Index] != nil {
+			return nil, fmt.Errorf("llamacpp returned an unknown or repeated embedding index %d", item.Index)
+		}
+		embedding, err := decodeLlamaCppEmbedding(item.Embedding)
+		if err != nil {
+			return nil, err
+		}
+		results[item.Index] = embedding
+	}
+	if len(resp) != len(texts) {
+		return nil, fmt.Errorf("llamacpp returned %d embeddings but expected %d", len(resp), len(texts))
+	}
+	return results, nil
+}
+
+func decodeLlamaCppEmbedding(raw json.RawMessage) ([]float32, error) {
+	var pooled [][]float32
+	if err := json.Unmarshal(raw, &pooled); err == nil {
+		if len(pooled) != 1 {
+			return nil, fmt.Errorf("llamacpp returned %d token embeddings; start the server with a pooling type other than none", len(pooled))
+		}
+		return pooled[0], nil
+	}
+	var flat []float32
+	if err := json.Unmarshal(raw, &flat); err != nil {
+		return nil, fmt.Errorf("failed to decode llamacpp embedding: %w", err)
+	}
+	return flat, nil
+}
@@ -9,9 +9,11 @@ import (
 	"strings"
 
 	"github.com/go-go-golems/geppetto/pkg/security"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/ratelimit"
 )
 
 type OllamaProvider struct {
+	httpClient *http.Client
 	baseURL    string
 	model      string
 	dimensions int
@@ -41,12 +43,18 @@ func NewOllamaProvider(baseURL string, model string, dimensions int) *OllamaProv
 	}
 
 	return &OllamaProvider{
+		httpClient: http.DefaultClient,
 		baseURL:    baseURL,
 		model:      model,
 		dimensions: dimensions,
 	}
 }
 
+// SetHTTPClient replaces the HTTP client used to reach the Ollama server.
+func (p *OllamaProvider) SetHTTPClient(httpClient *http.Client) {
+	p.httpClient = httpClient
+}
+
 func (p *OllamaProvider) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
 	endpointURL := strings.TrimRight(p.baseURL, "/") + "/api/embeddings"
 	if err := security.ValidateOutboundURL(endpointURL, security.OutboundURLOptions{
@@ -66,6 +74,7 @@ func (p *OllamaProvider) GenerateEmbedding(ctx context.Context, text string) ([]
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
+	ctx = ratelimit.WithTokenEstimate(ctx, ratelimit.EstimateTokens(text))
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpointURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -73,9 +82,8 @@ func (p *OllamaProvider) GenerateEmbedding(ctx context.Context, text string) ([]
 
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
 	// #nosec G704 -- endpoint URL is validated above and local Ollama is intentionally allowed.
-	resp, err := client.Do(req)
+	resp, err := p.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
@@ -3,12 +3,15 @@ package embeddings
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strings"
 
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/ratelimit"
 	"github.com/sashabaranov/go-openai"
 )
 
 type OpenAIProvider struct {
+	apiKey     string
 	client     *openai.Client
 	model      openai.EmbeddingModel
 	dimensions int
@@ -25,13 +28,22 @@ func NewOpenAIProvider(apiKey string, model openai.EmbeddingModel, dimensions in
 	}
 
 	return &OpenAIProvider{
+		apiKey:     apiKey,
 		client:     openai.NewClient(apiKey),
 		model:      model,
 		dimensions: dimensions,
 	}
 }
 
+// SetHTTPClient replaces the HTTP client used to reach the OpenAI API.
+func (p *OpenAIProvider) SetHTTPClient(httpClient *http.Client) {
+	config := openai.DefaultConfig(p.apiKey)
+	config.HTTPClient = httpClient
+	p.client = openai.NewClientWithConfig(config)
+}
+
 func (p *OpenAIProvider) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	ctx = ratelimit.WithTokenEstimate(ctx, ratelimit.EstimateTokens(text))
 	resp, err := p.client.CreateEmbeddings(ctx, p.newRequest([]string{text}))
 	if err != nil {
 		return nil, err
@@ -51,6 +63,7 @@ func (p *OpenAIProvider) GenerateBatchEmbeddings(ctx context.Context, texts []st
 	}
 
 	// OpenAI API has native batch support
+	ctx = ratelimit.WithTokenEstimate(ctx, ratelimit.EstimateTokens(texts...))
 	resp, err := p.client.CreateEmbeddings(ctx, p.newRequest(texts))
 	if err != nil {
 		return nil, err
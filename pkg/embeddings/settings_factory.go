@@ -4,12 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"strconv"
 	"text/template"
 
 	_ "embed"
 
 	"github.com/go-go-golems/geppetto/pkg/embeddings/config"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/providerhttp"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/ratelimit"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
 	geppetto_openai "github.com/go-go-golems/geppetto/pkg/steps/ai/settings/openai"
 	"github.com/go-go-golems/glazed/pkg/cmds/values"
@@ -33,6 +36,7 @@ type providerOptions struct {
 	baseURL      string
 	apiKey       string
 	dimensions   int
+	httpClient   *http.Client
 }
 
 // WithType sets the provider type
@@ -70,9 +74,20 @@ func WithDimensions(d int) ProviderOption {
 	}
 }
 
+// WithHTTPClient sets the HTTP client of the provider, bypassing the
+// rate-limited client derived from the factory's client settings.
+func WithHTTPClient(c *http.Client) ProviderOption {
+	return func(o *providerOptions) {
+		o.httpClient = c
+	}
+}
+
 // SettingsFactory creates embedding providers based on configuration
 type SettingsFactory struct {
 	config *config.EmbeddingsConfig
+	// client carries the HTTP client and rate-limit settings when the factory
+	// is built from inference settings.
+	client *settings.ClientSettings
 }
 
 var _ ProviderFactory = &SettingsFactory{}
@@ -120,6 +135,11 @@ func (f *SettingsFactory) NewProvider(opts ...ProviderOption) (Provider, error)
 		}
 	}
 
+	inputType, err := ParseInputType(f.config.InputType)
+	if err != nil {
+		return nil, err
+	}
+
 	var provider Provider
 
 	switch options.providerType {
@@ -132,7 +152,15 @@ func (f *SettingsFactory) NewProvider(opts ...ProviderOption) (Provider, error)
 				baseURL = url
 			}
 		}
-		provider = NewOllamaProvider(baseURL, options.engine, options.dimensions)
+		ollamaProvider := NewOllamaProvider(baseURL, options.engine, options.dimensions)
+		httpClient, err := f.httpClient(options, "")
+		if err != nil {
+			return nil, err
+		}
+		if httpClient != nil {
+			ollamaProvider.SetHTTPClient(httpClient)
+		}
+		provider = ollamaProvider
 
 	case "openai":
 		apiKey := options.apiKey
@@ -145,7 +173,43 @@ func (f *SettingsFactory) NewProvider(opts ...ProviderOption) (Provider, error)
 			return nil, fmt.Errorf("no API key provided for OpenAI")
 		}
 
-		provider = NewOpenAIProvider(apiKey, openai.EmbeddingModel(options.engine), options.dimensions)
+		openaiProvider := NewOpenAIProvider(apiKey, openai.EmbeddingModel(options.engine), options.dimensions)
+		httpClient, err := f.httpClient(options, apiKey)
+		if err != nil {
+			return nil, err
+		}
+		if httpClient != nil {
+			openaiProvider.SetHTTPClient(httpClient)
+		}
+		provider = openaiProvider
+
+	case geminiEmbeddingProvider, cohereEmbeddingProvider, voyageEmbeddingProvider:
+		hosted, err := f.newHostedProvider(options, inputType)
+		if err != nil {
+			return nil, err
+		}
+		provider = hosted
+
+	case teiEmbeddingProvider, llamaCppEmbeddingProvider:
+		apiKey := f.apiKey(options)
+		baseURL := f.baseURL(options)
+		var selfHosted interface {
+			Provider
+			SetHTTPClient(*http.Client)
+		}
+		if options.providerType == teiEmbeddingProvider {
+			selfHosted = NewTEIProvider(baseURL, apiKey, options.engine, options.dimensions)
+		} else {
+			selfHosted = NewLlamaCppProvider(baseURL, apiKey, options.engine, options.dimensions)
+		}
+		httpClient, err := f.httpClient(options, apiKey)
+		if err != nil {
+			return nil, err
+		}
+		if httpClient != nil {
+			selfHosted.SetHTTPClient(httpClient)
+		}
+		provider = selfHosted
 
 	default:
 		return nil, fmt.Errorf("unsupported provider type for embeddings: %s", options.providerType)
@@ -171,6 +235,75 @@ func (f *SettingsFactory) NewProvider(opts ...ProviderOption) (Provider, error)
 	}
 }
 
+// newHostedProvider builds the Gemini, Cohere and Voyage providers, which
+// need an API key and accept a base URL override and an input-type default.
+func (f *SettingsFactory) newHostedProvider(options *providerOptions, inputType InputType) (Provider, error) {
+	apiKey := f.apiKey(options)
+	if apiKey == "" {
+		return nil, fmt.Errorf("no API key provided for %s embeddings", options.providerType)
+	}
+
+	var hosted interface {
+		Provider
+		SetHTTPClient(*http.Client)
+		SetBaseURL(string)
+		SetInputType(InputType)
+	}
+	switch options.providerType {
+	case geminiEmbeddingProvider:
+		hosted = NewGeminiProvider(apiKey, options.engine, options.dimensions)
+	case cohereEmbeddingProvider:
+		hosted = NewCohereProvider(apiKey, options.engine, options.dimensions)
+	default:
+		hosted = NewVoyageProvider(apiKey, options.engine, options.dimensions)
+	}
+	hosted.SetBaseURL(f.baseURL(options))
+	hosted.SetInputType(inputType)
+
+	httpClient, err := f.httpClient(options, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	if httpClient != nil {
+		hosted.SetHTTPClient(httpClient)
+	}
+	return hosted, nil
+}
+
+// apiKey returns the explicit WithAPIKey key or the "<type>-api-key" entry of
+// the configuration.
+func (f *SettingsFactory) apiKey(options *providerOptions) string {
+	if options.apiKey != "" {
+		return options.apiKey
+	}
+	return f.config.APIKeys[options.providerType+"-api-key"]
+}
+
+// baseURL returns the explicit WithBaseURL URL or the "<type>-base-url" entry
+// of the configuration. An empty result keeps the provider default.
+func (f *SettingsFactory) baseURL(options *providerOptions) string {
+	if options.baseURL != "" {
+		return options.baseURL
+	}
+	return f.config.BaseURLs[options.providerType+"-base-url"]
+}
+
+// httpClient returns the explicit WithHTTPClient client, the rate-limited
+// client of the factory's client settings, or nil to keep the provider default.
+func (f *SettingsFactory) httpClient(options *providerOptions, apiKey string) (*http.Client, error) {
+	if options.httpClient != nil {
+		return options.httpClient, nil
+	}
+	if f.client == nil {
+		return nil, nil
+	}
+	httpClient, err := providerhttp.Client(f.client, ratelimit.NewKey(options.providerType, options.engine, apiKey))
+	if err != nil {
+		return nil, fmt.Errorf("embeddings http client: %w", err)
+	}
+	return httpClient, nil
+}
+
 func NewSettingsFactoryFromParsedValues(parsedValues *values.Values) (ProviderFactory, error) {
 	embeddingsSettings := &config.EmbeddingsConfig{}
 	// try loading openai API keys
@@ -211,6 +344,9 @@ func NewSettingsFactoryFromInferenceSettings(s *settings.InferenceSettings) *Set
 		if s.Embeddings.Dimensions != 0 {
 			config.Dimensions = s.Embeddings.Dimensions
 		}
+		if s.Embeddings.InputType != "" {
+			config.InputType = s.Embeddings.InputType
+		}
 
 		if s.Embeddings.CacheType != "" {
 			config.CacheType = s.Embeddings.CacheType
@@ -247,7 +383,9 @@ func NewSettingsFactoryFromInferenceSettings(s *settings.InferenceSettings) *Set
 		}
 	}
 
-	return NewSettingsFactory(config)
+	factory := NewSettingsFactory(config)
+	factory.client = s.Client
+	return factory
 }
 
 func (f *SettingsFactory) GetEmbeddingFuncMap() template.FuncMap {
@@ -6,6 +6,7 @@ import (
 
 	"github.com/go-go-golems/geppetto/pkg/embeddings/config"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
 	"gopkg.in/yaml.v3"
 )
 
@@ -148,3 +149,135 @@ inference_settings:
 		t.Fatalf("cache directory = %q, want %q", diskCache.directory, cacheDirectory)
 	}
 }
+
+func TestNewSettingsFactoryFromInferenceSettingsHostedProvidersFromYAML(t *testing.T) {
+	profile := `
+inference_settings:
+  api:
+    api_keys:
+      voyage-api-key: top-level-key
+    base_urls:
+      voyage-base-url: https://gateway.example.com/voyage
+  embeddings:
+    type: voyage
+    engine: voyage-3.5
+    dimensions: 512
+    input_type: query
+`
+	var decoded struct {
+		InferenceSettings *settings.InferenceSettings `yaml:"inference_settings"`
+	}
+	if err := yaml.Unmarshal([]byte(profile), &decoded); err != nil {
+		t.Fatalf("unmarshal profile YAML: %v", err)
+	}
+
+	provider, err := NewSettingsFactoryFromInferenceSettings(decoded.InferenceSettings).NewProvider()
+	if err != nil {
+		t.Fatalf("NewProvider returned error: %v", err)
+	}
+	voyageProvider, ok := provider.(*VoyageProvider)
+	if !ok {
+		t.Fatalf("provider = %T, want *VoyageProvider", provider)
+	}
+	if voyageProvider.apiKey != "top-level-key" || voyageProvider.baseURL != "https://gateway.example.com/voyage" {
+		t.Fatalf("voyage provider key/url = %q/%q, want profile values", voyageProvider.apiKey, voyageProvider.baseURL)
+	}
+	if voyageProvider.inputType != InputTypeQuery || voyageProvider.dimensions != 512 {
+		t.Fatalf("voyage provider input type/dimensions = %q/%d, want query/512", voyageProvider.inputType, voyageProvider.dimensions)
+	}
+}
+
+func TestSettingsFactoryConstructsAdditionalProviders(t *testing.T) {
+	cfg := &config.EmbeddingsConfig{
+		Engine:     "test-model",
+		Dimensions: 384,
+		APIKeys: map[string]string{
+			"gemini-api-key": "g",
+			"cohere-api-key": "c",
+		},
+		BaseURLs: map[string]string{"tei-base-url": "http://tei.internal:8080"},
+	}
+	for providerType, want := range map[string]string{
+		"gemini":   "*embeddings.GeminiProvider",
+		"cohere":   "*embeddings.CohereProvider",
+		"tei":      "*embeddings.TEIProvider",
+		"llamacpp": "*embeddings.LlamaCppProvider",
+	} {
+		provider, err := NewSettingsFactory(cfg).NewProvider(WithType(providerType))
+		if err != nil {
+			t.Fatalf("%s: NewProvider returned error: %v", providerType, err)
+		}
+		if got := fmt.Sprintf("%T", provider); got != want {
+			t.Fatalf("%s: provider = %s, want %s", providerType, got, want)
+		}
+	}
+
+	provider, err := NewSettingsFactory(cfg).NewProvider(WithType("tei"))
+	if err != nil {
+		t.Fatalf("NewProvider returned error: %v", err)
+	}
+	if got := provider.(*TEIProvider).baseURL; got != "http://tei.internal:8080" {
+		t.Fatalf("tei base url = %q, want configured URL", got)
+	}
+
+	if _, err := NewSettingsFactory(cfg).NewProvider(WithType("voyage")); err == nil {
+		t.Fatal("voyage without API key: expected error")
+	}
+	cfg.InputType = "passage"
+	if _, err := NewSettingsFactory(cfg).NewProvider(WithType("cohere")); err == nil {
+		t.Fatal("invalid input type: expected error")
+	}
+}
+
+func TestNewSettingsFactoryFromParsedValuesReadsProviderAPIKeys(t *testing.T) {
+	embeddingsSection, err := config.NewEmbeddingsValueSection()
+	if err != nil {
+		t.Fatalf("embeddings section: %v", err)
+	}
+	apiKeySection, err := config.NewEmbeddingsApiKeyValue()
+	if err != nil {
+		t.Fatalf("api key section: %v", err)
+	}
+	parsed := values.New()
+	embeddingsValues, err := values.NewSectionValues(embeddingsSection,
+		values.WithFieldValue("embeddings-type", "gemini"),
+		values.WithFieldValue("embeddings-engine", "gemini-embedding-001"),
+		values.WithFieldValue("embeddings-dimensions", 768),
+	)
+	if err != nil {
+		t.Fatalf("embeddings values: %v", err)
+	}
+	parsed.Set(config.EmbeddingsSlug, embeddingsValues)
+	apiKeyValues, err := values.NewSectionValues(apiKeySection,
+		values.WithFieldValue("gemini-api-key", "gemini-key"),
+		values.WithFieldValue("voyage-api-key", "voyage-key"),
+	)
+	if err != nil {
+		t.Fatalf("api key values: %v", err)
+	}
+	parsed.Set(config.EmbeddingsApiKeySlug, apiKeyValues)
+
+	factory, err := NewSettingsFactoryFromParsedValues(parsed)
+	if err != nil {
+		t.Fatalf("NewSettingsFactoryFromParsedValues returned error: %v", err)
+	}
+	for providerType, want := range map[string]string{
+		"gemini": "*embeddings.GeminiProvider",
+		"voyage": "*embeddings.VoyageProvider",
+	} {
+		provider, err := factory.NewProvider(WithType(providerType))
+		if err != nil {
+			t.Fatalf("%s: NewProvider returned error: %v", providerType, err)
+		}
+		if got := fmt.Sprintf("%T", provider); got != want {
+			t.Fatalf("%s: provider = %s, want %s", providerType, got, want)
+		}
+	}
+	provider, err := factory.NewProvider()
+	if err != nil {
+		t.Fatalf("NewProvider returned error: %v", err)
+	}
+	if gemini, ok := provider.(*GeminiProvider); !ok || gemini.apiKey != "gemini-key" {
+		t.Fatalf("default provider = %#v, want the configured Gemini provider", provider)
+	}
+}
@@ -8,8 +8,13 @@ import (
 )
 
 const (
-	openAIEmbeddingProvider = "openai"
-	ollamaEmbeddingProvider = "ollama"
+	openAIEmbeddingProvider   = "openai"
+	ollamaEmbeddingProvider   = "ollama"
+	geminiEmbeddingProvider   = "gemini"
+	cohereEmbeddingProvider   = "cohere"
+	voyageEmbeddingProvider   = "voyage"
+	teiEmbeddingProvider      = "tei"
+	llamaCppEmbeddingProvider = "llamacpp"
 )
 
 // ValidateInferenceSettingsForEmbeddings verifies that final, already-merged
@@ -44,24 +49,46 @@ func ValidateInferenceSettingsForEmbeddings(s *settings.InferenceSettings) error
 		if s.Embeddings.Dimensions == 0 {
 			return fmt.Errorf("selected Ollama embedding profile must set inference_settings.embeddings.dimensions")
 		}
+	case geminiEmbeddingProvider, cohereEmbeddingProvider, voyageEmbeddingProvider:
+		keyName := providerType + "-api-key"
+		if strings.TrimSpace(embeddingAPIKey(s, keyName)) == "" {
+			return fmt.Errorf("selected %s embedding profile has no %s; set inference_settings.api.api_keys.%s or inference_settings.embeddings.api_keys.%s", providerType, keyName, keyName, keyName)
+		}
+		if s.Embeddings.Dimensions == 0 {
+			return fmt.Errorf("selected %s embedding profile must set inference_settings.embeddings.dimensions", providerType)
+		}
+	case teiEmbeddingProvider, llamaCppEmbeddingProvider:
+		if s.Embeddings.Dimensions == 0 {
+			return fmt.Errorf("selected %s embedding profile must set inference_settings.embeddings.dimensions", providerType)
+		}
 	default:
-		return fmt.Errorf("unsupported embeddings provider type %q; supported values are openai and ollama", providerType)
+		return fmt.Errorf("unsupported embeddings provider type %q; supported values are openai, ollama, gemini, cohere, voyage, tei and llamacpp", providerType)
+	}
+
+	if _, err := ParseInputType(s.Embeddings.InputType); err != nil {
+		return fmt.Errorf("selected embedding profile has an invalid inference_settings.embeddings.input_type: %w", err)
 	}
 
 	return nil
 }
 
 func openAIEmbeddingAPIKey(s *settings.InferenceSettings) string {
+	return embeddingAPIKey(s, "openai-api-key")
+}
+
+// embeddingAPIKey returns the embedding-local key, falling back to the
+// top-level API key of the same name.
+func embeddingAPIKey(s *settings.InferenceSettings, keyName string) string {
 	if s == nil {
 		return ""
 	}
 	if s.Embeddings != nil {
-		if key := s.Embeddings.APIKeys["openai-api-key"]; strings.TrimSpace(key) != "" {
+		if key := s.Embeddings.APIKeys[keyName]; strings.TrimSpace(key) != "" {
 			return key
 		}
 	}
 	if s.API != nil {
-		return s.API.APIKeys["openai-api-key"]
+		return s.API.APIKeys[keyName]
 	}
 	return ""
 }
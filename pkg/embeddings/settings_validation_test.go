@@ -49,10 +49,24 @@ func TestValidateInferenceSettingsForEmbeddings(t *testing.T) {
 		{
 			name: "unsupported provider",
 			in: &settings.InferenceSettings{
-				Embeddings: &config.EmbeddingsConfig{Type: "cohere", Engine: "embed-english-v3", Dimensions: 1024},
+				Embeddings: &config.EmbeddingsConfig{Type: "unknown-embeddings", Engine: "embed-english-v3", Dimensions: 1024},
 			},
 			wantErr: "unsupported embeddings provider type",
 		},
+		{
+			name: "cohere missing key",
+			in: &settings.InferenceSettings{
+				Embeddings: &config.EmbeddingsConfig{Type: "cohere", Engine: "embed-v4.0", Dimensions: 1024},
+			},
+			wantErr: "has no cohere-api-key",
+		},
+		{
+			name: "invalid input type",
+			in: &settings.InferenceSettings{
+				Embeddings: &config.EmbeddingsConfig{Type: "tei", Engine: "bge-small", Dimensions: 384, InputType: "passage"},
+			},
+			wantErr: "unsupported embeddings input type",
+		},
 		{
 			name: "ollama missing dimensions",
 			in: &settings.InferenceSettings{
@@ -78,6 +92,13 @@ func TestValidateInferenceSettingsForEmbeddings(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "gemini complete with shared chat key",
+			in: &settings.InferenceSettings{
+				API:        &settings.APISettings{APIKeys: map[string]string{"gemini-api-key": "test-key"}},
+				Embeddings: &config.EmbeddingsConfig{Type: "gemini", Engine: "gemini-embedding-001", Dimensions: 768, InputType: "document"},
+			},
+		},
 		{
 			name: "ollama complete",
 			in: &settings.InferenceSettings{
@@ -0,0 +1,77 @@
+package embeddings
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-go-golems/geppetto/pkg/security"
+)
+
+const (
+	// DefaultTEIBaseURL is the default text-embeddings-inference router address.
+	DefaultTEIBaseURL = "http://localhost:8080"
+	// teiMaxBatchSize matches the router's default --max-client-batch-size.
+	teiMaxBatchSize = 32
+)
+
+// TEIProvider embeds texts with a Hugging Face text-embeddings-inference
+// server's /embed endpoint. The model is fixed by the server; the configured
+// engine only names it. Input-type hints are ignored.
+type TEIProvider struct {
+	jsonEndpoint
+	model      string
+	dimensions int
+}
+
+type teiRequest struct {
+	Inputs   []string `json:"inputs"`
+	Truncate bool     `json:"truncate"`
+}
+
+var _ Provider = &TEIProvider{}
+
+func NewTEIProvider(baseURL string, apiKey string, model string, dimensions int) *TEIProvider {
+	if baseURL == "" {
+		baseURL = DefaultTEIBaseURL
+	}
+
+	return &TEIProvider{
+		jsonEndpoint: jsonEndpoint{
+			name:       "tei",
+			httpClient: http.DefaultClient,
+			baseURL:    baseURL,
+			apiKey:     apiKey,
+			// Self-hosted servers usually run on local networks, like Ollama.
+			outbound: security.OutboundURLOptions{AllowHTTP: true, AllowLocalNetworks: true},
+		},
+		model:      model,
+		dimensions: dimensions,
+	}
+}
+
+func (p *TEIProvider) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	batch, err := p.embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return singleEmbedding("tei", batch)
+}
+
+func (p *TEIProvider) GenerateBatchEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	return chunkedBatchEmbeddings(ctx, "tei", texts, teiMaxBatchSize, p.embed)
+}
+
+func (p *TEIProvider) GetModel() EmbeddingModel {
+	return EmbeddingModel{
+		Name:       p.model,
+		Dimensions: p.dimensions,
+	}
+}
+
+func (p *TEIProvider) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var resp [][]float32
+	if err := p.post(ctx, "embed", texts, teiRequest{Inputs: texts, Truncate: true}, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,56 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTEIProviderEmbedsBatch(t *testing.T) {
+	var got teiRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/embed", r.URL.Path)
+		assert.Empty(t, r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`[[0.1,0.2],[0.3,0.4]]`))
+	}))
+	t.Cleanup(srv.Close)
+
+	p := NewTEIProvider(srv.URL, "", "BAAI/bge-small-en-v1.5", 2)
+	embeddings, err := p.GenerateBatchEmbeddings(WithInputType(context.Background(), InputTypeQuery), []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0.1, 0.2}, {0.3, 0.4}}, embeddings)
+	assert.Equal(t, teiRequest{Inputs: []string{"a", "b"}, Truncate: true}, got)
+
+	_, err = p.GenerateEmbedding(context.Background(), "a")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tei returned 2 embeddings but expected 1")
+}
+
+func TestLlamaCppProviderDecodesPooledAndFlatEmbeddings(t *testing.T) {
+	var got llamaCppRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/embedding", r.URL.Path)
+		assert.Equal(t, "Bearer llama-key", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`[{"index":1,"embedding":[0.3,0.4]},{"index":0,"embedding":[[0.1,0.2]]}]`))
+	}))
+	t.Cleanup(srv.Close)
+
+	p := NewLlamaCppProvider(srv.URL, "llama-key", "nomic-embed-text-v1.5", 2)
+	embeddings, err := p.GenerateBatchEmbeddings(context.Background(), []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0.1, 0.2}, {0.3, 0.4}}, embeddings)
+	assert.Equal(t, []string{"a", "b"}, got.Content)
+}
+
+func TestDecodeLlamaCppEmbeddingRejectsTokenEmbeddings(t *testing.T) {
+	_, err := decodeLlamaCppEmbedding(json.RawMessage(`[[0.1],[0.2]]`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pooling")
+}
@@ -0,0 +1,146 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/security"
+)
+
+const (
+	// DefaultVoyageBaseURL is the hosted Voyage AI API.
+	DefaultVoyageBaseURL = "https://api.voyageai.com"
+	// voyageMaxBatchSize stays well below the /v1/embeddings limit of 1000
+	// texts so batches also fit the per-request token limit.
+	voyageMaxBatchSize = 128
+)
+
+// VoyageProvider embeds texts with the Voyage AI /v1/embeddings endpoint.
+type VoyageProvider struct {
+	jsonEndpoint
+	model      string
+	dimensions int
+	inputType  InputType
+}
+
+type voyageRequest struct {
+	Model           string   `json:"model"`
+	Input           []string `json:"input"`
+	InputType       string   `json:"input_type,omitempty"`
+	OutputDimension int      `json:"output_dimension,omitempty"`
+}
+
+type voyageResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+var _ Provider = &VoyageProvider{}
+
+func NewVoyageProvider(apiKey string, model string, dimensions int) *VoyageProvider {
+	if model == "" {
+		model = "voyage-3.5"
+	}
+	// Only the Matryoshka models accept output_dimension; the others always
+	// return their fixed size.
+	if dimensions <= 0 || !supportsVoyageOutputDimension(model) {
+		dimensions = voyageDefaultDimensions(model)
+	}
+
+	return &VoyageProvider{
+		jsonEndpoint: jsonEndpoint{
+			name:       "voyage",
+			httpClient: http.DefaultClient,
+			baseURL:    DefaultVoyageBaseURL,
+			apiKey:     apiKey,
+			outbound:   security.OutboundURLOptions{},
+		},
+		model:      model,
+		dimensions: dimensions,
+	}
+}
+
+// SetInputType sets the input type used when the context carries none. Voyage
+// embeds texts without an input type as neither query nor document.
+func (p *VoyageProvider) SetInputType(inputType InputType) {
+	p.inputType = inputType
+}
+
+func (p *VoyageProvider) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	batch, err := p.embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return singleEmbedding("voyage", batch)
+}
+
+func (p *VoyageProvider) GenerateBatchEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	return chunkedBatchEmbeddings(ctx, "voyage", texts, voyageMaxBatchSize, p.embed)
+}
+
+func (p *VoyageProvider) GetModel() EmbeddingModel {
+	return EmbeddingModel{
+		Name:       p.model,
+		Dimensions: p.dimensions,
+	}
+}
+
+func (p *VoyageProvider) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var resp voyageResponse
+	if err := p.post(ctx, "v1/embeddings", texts, p.newRequest(ctx, texts), &resp); err != nil {
+		return nil, err
+	}
+
+	// Results carry their input index; place them rather than trusting order.
+	results := make([][]float32, len(texts))
+	for _, item := range resp.Data {
+		if item.Index < 0 || item.Index >= len(texts) || results[item.Index] != nil {
+			return nil, fmt.Errorf("voyage returned an unknown or repeated embedding index %d", item.Index)
+		}
+		results[item.Index] = item.Embedding
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("voyage returned %d embeddings but expected %d", len(resp.Data), len(texts))
+	}
+	return results, nil
+}
+
+func (p *VoyageProvider) newRequest(ctx context.Context, texts []string) voyageRequest {
+	req := voyageRequest{
+		Model:     p.model,
+		Input:     texts,
+		InputType: string(resolveInputType(ctx, p.inputType)),
+	}
+	if supportsVoyageOutputDimension(p.model) {
+		req.OutputDimension = p.dimensions
+	}
+	return req
+}
+
+// voyageDefaultDimensions returns the size a model produces without an
+// output_dimension override: 512 for voyage-3-lite, 1536 for the
+// voyage-code-2 and voyage-large-2 families and 1024 otherwise.
+func voyageDefaultDimensions(model string) int {
+	switch {
+	case model == "voyage-3-lite":
+		return 512
+	case strings.HasPrefix(model, "voyage-code-2"), strings.HasPrefix(model, "voyage-large-2"):
+		return 1536
+	default:
+		return 1024
+	}
+}
+
+func supportsVoyageOutputDimension(model string) bool {
+	// Voyage supports `output_dimension` on its Matryoshka models only.
+	for _, prefix := range []string{"voyage-3-large", "voyage-3.5", "voyage-code-3", "voyage-context-3", "voyage-4"} {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
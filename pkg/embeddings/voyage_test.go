@@ -0,0 +1,73 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newVoyageTestProvider(t *testing.T, model string, handler http.HandlerFunc) *VoyageProvider {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	p := NewVoyageProvider("voyage-key", model, 256)
+	p.SetBaseURL(srv.URL)
+	p.outbound = allowLocalTestServers
+	return p
+}
+
+func TestVoyageProviderPlacesResultsByIndex(t *testing.T) {
+	var got voyageRequest
+	p := newVoyageTestProvider(t, "voyage-3.5", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/embeddings", r.URL.Path)
+		got = voyageRequest{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		if len(got.Input) == 1 {
+			_, _ = w.Write([]byte(`{"data":[{"index":0,"embedding":[0.5]}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"object":"list","data":[
+			{"object":"embedding","index":1,"embedding":[0.2]},
+			{"object":"embedding","index":0,"embedding":[0.1]}
+		],"model":"voyage-3.5","usage":{"total_tokens":4}}`))
+	})
+	p.SetInputType(InputTypeDocument)
+
+	embeddings, err := p.GenerateBatchEmbeddings(context.Background(), []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0.1}, {0.2}}, embeddings)
+	assert.Equal(t, voyageRequest{Model: "voyage-3.5", Input: []string{"a", "b"}, InputType: "document", OutputDimension: 256}, got)
+
+	embedding, err := p.GenerateEmbedding(WithInputType(context.Background(), InputTypeQuery), "q")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0.5}, embedding)
+	assert.Equal(t, "query", got.InputType)
+}
+
+func TestVoyageProviderOmitsUnsupportedFields(t *testing.T) {
+	req := NewVoyageProvider("k", "voyage-3", 1024).newRequest(context.Background(), []string{"a"})
+	assert.Equal(t, 0, req.OutputDimension)
+	assert.Equal(t, "", req.InputType)
+}
+
+func TestVoyageProviderDefaultDimensionsPerModel(t *testing.T) {
+	assert.Equal(t, 1024, NewVoyageProvider("k", "", 0).GetModel().Dimensions)
+	assert.Equal(t, 512, NewVoyageProvider("k", "voyage-3-lite", 0).GetModel().Dimensions)
+	assert.Equal(t, 512, NewVoyageProvider("k", "voyage-3-lite", 256).GetModel().Dimensions)
+	assert.Equal(t, 1536, NewVoyageProvider("k", "voyage-code-2", 0).GetModel().Dimensions)
+	assert.Equal(t, 256, NewVoyageProvider("k", "voyage-3.5", 256).GetModel().Dimensions)
+}
+
+func TestVoyageProviderRejectsRepeatedIndex(t *testing.T) {
+	p := newVoyageTestProvider(t, "voyage-3.5", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"data":[{"index":0,"embedding":[0.1]},{"index":0,"embedding":[0.2]}]}`))
+	})
+	_, err := p.GenerateBatchEmbeddings(context.Background(), []string{"a", "b"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown or repeated embedding index")
+}
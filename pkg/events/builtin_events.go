@@ -50,6 +50,10 @@ type EventCitation struct {
 	OutputIndex     *int   `json:"output_index,omitempty"`
 	ContentIndex    *int   `json:"content_index,omitempty"`
 	AnnotationIndex *int   `json:"annotation_index,omitempty"`
+	// CitedText and DocumentIndex are set for citations into request
+	// documents (Claude); Title is then the document title.
+	CitedText     string `json:"cited_text,omitempty"`
+	DocumentIndex *int   `json:"document_index,omitempty"`
 }
 
 func NewCitation(metadata EventMetadata, title, url string, start, end, outputIdx, contentIdx, annIdx *int) *EventCitation {
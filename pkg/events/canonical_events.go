@@ -109,6 +109,51 @@ func (e *EventProviderCallFinished) Correlation() Correlation { return e.Correla
 
 var _ CorrelatedEvent = &EventProviderCallFinished{}
 
+// EventProviderFallback records that a composite engine abandoned one provider
+// candidate and is retrying the same run on the next one.
+type EventProviderFallback struct {
+	EventImpl
+	Correlation_  Correlation `json:"correlation"`
+	FromCandidate string      `json:"from_candidate"`
+	ToCandidate   string      `json:"to_candidate"`
+	Attempt       int         `json:"attempt"`
+	ErrorClass    string      `json:"error_class,omitempty"`
+	ErrorString   string      `json:"error_string,omitempty"`
+}
+
+func NewProviderFallbackEvent(metadata EventMetadata, corr Correlation, fromCandidate, toCandidate string, attempt int, errorClass string, err error) *EventProviderFallback {
+	errorString := ""
+	if err != nil {
+		errorString = err.Error()
+	}
+	return &EventProviderFallback{EventImpl: EventImpl{Type_: EventTypeProviderFallback, Metadata_: metadata}, Correlation_: corr, FromCandidate: fromCandidate, ToCandidate: toCandidate, Attempt: attempt, ErrorClass: errorClass, ErrorString: errorString}
+}
+
+func (e *EventProviderFallback) Correlation() Correlation { return e.Correlation_ }
+
+var _ CorrelatedEvent = &EventProviderFallback{}
+
+// EventProviderCallRetry records that a provider request failed with a
+// transient error before its stream started and is about to be replayed.
+// Attempt is the 1-based number of the attempt that is about to run.
+type EventProviderCallRetry struct {
+	EventImpl
+	Correlation_ Correlation `json:"correlation"`
+	Attempt      int         `json:"attempt"`
+	MaxAttempts  int         `json:"max_attempts"`
+	StatusCode   int         `json:"status_code,omitempty"`
+	ErrorClass   string      `json:"error_class,omitempty"`
+	DelayMs      int64       `json:"delay_ms"`
+}
+
+func NewProviderCallRetryEvent(metadata EventMetadata, corr Correlation, attempt, maxAttempts, statusCode int, errorClass string, delayMs int64) *EventProviderCallRetry {
+	return &EventProviderCallRetry{EventImpl: EventImpl{Type_: EventTypeProviderCallRetry, Metadata_: metadata}, Correlation_: corr, Attempt: attempt, MaxAttempts: maxAttempts, StatusCode: statusCode, ErrorClass: errorClass, DelayMs: delayMs}
+}
+
+func (e *EventProviderCallRetry) Correlation() Correlation { return e.Correlation_ }
+
+var _ CorrelatedEvent = &EventProviderCallRetry{}
+
 type EventTextSegmentStarted struct {
 	EventImpl
 	Correlation_ Correlation `json:"correlation"`
@@ -22,6 +22,12 @@ const (
 	EventTypeProviderCallStarted         EventType = "provider-call-started"
 	EventTypeProviderCallMetadataUpdated EventType = "provider-call-metadata-updated"
 	EventTypeProviderCallFinished        EventType = "provider-call-finished"
+	// EventTypeProviderFallback is emitted by composite engines before retrying
+	// a run on the next provider candidate.
+	EventTypeProviderFallback EventType = "provider-fallback"
+	// EventTypeProviderCallRetry is emitted before an engine replays a provider
+	// request that failed with a transient error before streaming started.
+	EventTypeProviderCallRetry EventType = "provider-call-retry"
 
 	// Canonical transcript segment events.
 	EventTypeTextSegmentStarted  EventType = "text-segment-started"
@@ -51,6 +57,14 @@ const (
 	// Debugger pause event (step-mode)
 	EventTypeDebuggerPause EventType = "debugger.pause"
 
+	// Human-in-the-loop tool approval events
+	EventTypeToolApprovalRequested EventType = "tool-approval-requested"
+	EventTypeToolApprovalResolved  EventType = "tool-approval-resolved"
+
+	// Usage accounting and budget enforcement events
+	EventTypeUsageUpdated   EventType = "usage-updated"
+	EventTypeBudgetExceeded EventType = "budget-exceeded"
+
 	// Agent-mode custom event (exported so UIs can act upon it)
 	EventTypeAgentModeSwitch EventType = "agent-mode-switch"
 
@@ -290,6 +304,18 @@ func NewEventFromJson(b []byte) (Event, error) {
 			return nil, fmt.Errorf("could not cast event to EventProviderCallFinished")
 		}
 		return ret, nil
+	case EventTypeProviderFallback:
+		ret, ok := ToTypedEvent[EventProviderFallback](e)
+		if !ok {
+			return nil, fmt.Errorf("could not cast event to EventProviderFallback")
+		}
+		return ret, nil
+	case EventTypeProviderCallRetry:
+		ret, ok := ToTypedEvent[EventProviderCallRetry](e)
+		if !ok {
+			return nil, fmt.Errorf("could not cast event to EventProviderCallRetry")
+		}
+		return ret, nil
 	case EventTypeTextSegmentStarted:
 		ret, ok := ToTypedEvent[EventTextSegmentStarted](e)
 		if !ok {
@@ -392,6 +418,30 @@ func NewEventFromJson(b []byte) (Event, error) {
 			return nil, fmt.Errorf("could not cast event to EventDebuggerPause")
 		}
 		return ret, nil
+	case EventTypeToolApprovalRequested:
+		ret, ok := ToTypedEvent[EventToolApprovalRequested](e)
+		if !ok {
+			return nil, fmt.Errorf("could not cast event to EventToolApprovalRequested")
+		}
+		return ret, nil
+	case EventTypeToolApprovalResolved:
+		ret, ok := ToTypedEvent[EventToolApprovalResolved](e)
+		if !ok {
+			return nil, fmt.Errorf("could not cast event to EventToolApprovalResolved")
+		}
+		return ret, nil
+	case EventTypeUsageUpdated:
+		ret, ok := ToTypedEvent[EventUsageUpdated](e)
+		if !ok {
+			return nil, fmt.Errorf("could not cast event to EventUsageUpdated")
+		}
+		return ret, nil
+	case EventTypeBudgetExceeded:
+		ret, ok := ToTypedEvent[EventBudgetExceeded](e)
+		if !ok {
+			return nil, fmt.Errorf("could not cast event to EventBudgetExceeded")
+		}
+		return ret, nil
 	case EventTypeAgentModeSwitch:
 		ret, ok := ToTypedEvent[EventAgentModeSwitch](e)
 		if !ok {
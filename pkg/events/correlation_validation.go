@@ -16,7 +16,7 @@ func ValidateCanonicalEvent(event Event) error {
 
 	//nolint:exhaustive // Validation groups canonical scopes and future events must be added deliberately.
 	switch event.Type() {
-	case EventTypeRunStarted, EventTypeRunFinished, EventTypeRunStopped, EventTypeRunFailed:
+	case EventTypeRunStarted, EventTypeRunFinished, EventTypeRunStopped, EventTypeRunFailed, EventTypeProviderFallback, EventTypeProviderCallRetry:
 		if corr.RunID == "" {
 			return fmt.Errorf("event %s missing run_id", event.Type())
 		}
@@ -0,0 +1,53 @@
+package events
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// StepPrinterSink is an EventSink that renders events the same way as
+// StepPrinterFunc, without going through a watermill router. It is meant for
+// interactive terminals: text and reasoning deltas are written as they arrive
+// and tool calls and results are printed inline.
+//
+// Reasoning output can be switched on and off while a run is streaming with
+// SetShowReasoning.
+type StepPrinterSink struct {
+	mu            sync.Mutex
+	printer       *stepPrinter
+	showReasoning atomic.Bool
+}
+
+var _ EventSink = (*StepPrinterSink)(nil)
+
+// NewStepPrinterSink returns a sink writing to w. name, when set, is printed
+// once before the first text delta. Reasoning is shown by default.
+func NewStepPrinterSink(name string, w io.Writer) *StepPrinterSink {
+	s := &StepPrinterSink{printer: newStepPrinter(name, w)}
+	s.showReasoning.Store(true)
+	s.printer.showReasoning = s.showReasoning.Load
+	return s
+}
+
+// SetShowReasoning controls whether reasoning deltas and thinking markers are
+// printed.
+func (s *StepPrinterSink) SetShowReasoning(show bool) {
+	s.showReasoning.Store(show)
+}
+
+// ShowReasoning reports whether reasoning output is currently printed.
+func (s *StepPrinterSink) ShowReasoning() bool {
+	return s.showReasoning.Load()
+}
+
+// PublishEvent writes the event to the underlying writer. Writes are
+// serialized so events from concurrent tool executions do not interleave.
+func (s *StepPrinterSink) PublishEvent(event Event) error {
+	if event == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.printer.printEvent(event)
+}
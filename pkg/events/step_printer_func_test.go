@@ -47,3 +47,34 @@ func TestStepPrinterStillPrintsReasoningSummaryBoundaries(t *testing.T) {
 		t.Fatalf("expected summary boundary markers to remain visible, got %q", got)
 	}
 }
+
+func TestStepPrinterSinkTogglesReasoning(t *testing.T) {
+	metadata := EventMetadata{}
+	corr := Correlation{}
+	var out bytes.Buffer
+	sink := NewStepPrinterSink("", &out)
+
+	publish := func(e Event) {
+		t.Helper()
+		if err := sink.PublishEvent(e); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+	}
+	publish(NewReasoningDeltaEvent(metadata, corr, "pondering ", "pondering ", 1))
+	sink.SetShowReasoning(false)
+	publish(NewInfoEvent(metadata, "thinking-ended", nil))
+	publish(NewReasoningDeltaEvent(metadata, corr, "hidden", "pondering hidden", 2))
+	publish(NewTextDeltaEvent(metadata, corr, "answer", "answer", 3))
+	publish(NewToolCallRequestedEvent(metadata, corr, "call-1", "lookup", `{"q":"x"}`))
+
+	got := out.String()
+	if !strings.HasPrefix(got, "pondering answer") {
+		t.Fatalf("unexpected output %q", got)
+	}
+	if strings.Contains(got, "hidden") || strings.Contains(got, "Thinking ended") {
+		t.Fatalf("reasoning should be hidden after toggle, got %q", got)
+	}
+	if !strings.Contains(got, "name: lookup") {
+		t.Fatalf("expected tool call to be rendered inline, got %q", got)
+	}
+}
@@ -0,0 +1,58 @@
+package events
+
+// EventToolApprovalRequested is emitted when the tool loop holds a pending tool
+// call until an external approve/deny/edit decision arrives.
+type EventToolApprovalRequested struct {
+	EventImpl
+	ApprovalID string         `json:"approval_id"`
+	ToolCallID string         `json:"tool_call_id"`
+	ToolName   string         `json:"tool_name"`
+	Input      string         `json:"input"`
+	DeadlineMs int64          `json:"deadline_ms"`
+	Extra      map[string]any `json:"extra,omitempty"`
+}
+
+func NewToolApprovalRequestedEvent(metadata EventMetadata, approvalID, toolCallID, toolName, input string, deadlineMs int64, extra map[string]any) *EventToolApprovalRequested {
+	if extra == nil {
+		extra = map[string]any{}
+	}
+	return &EventToolApprovalRequested{
+		EventImpl:  EventImpl{Type_: EventTypeToolApprovalRequested, Metadata_: metadata},
+		ApprovalID: approvalID,
+		ToolCallID: toolCallID,
+		ToolName:   toolName,
+		Input:      input,
+		DeadlineMs: deadlineMs,
+		Extra:      extra,
+	}
+}
+
+var _ Event = &EventToolApprovalRequested{}
+
+// EventToolApprovalResolved is emitted once a pending approval is decided,
+// including decisions made by timeout or cancellation.
+type EventToolApprovalResolved struct {
+	EventImpl
+	ApprovalID string `json:"approval_id"`
+	ToolCallID string `json:"tool_call_id"`
+	ToolName   string `json:"tool_name"`
+	// Decision is one of "approved", "edited" or "denied".
+	Decision string `json:"decision"`
+	Reason   string `json:"reason,omitempty"`
+	// Input holds the rewritten arguments when Decision is "edited".
+	Input string `json:"input,omitempty"`
+}
+
+func NewToolApprovalResolvedEvent(metadata EventMetadata, approvalID, toolCallID, toolName, decision, reason, input string) *EventToolApprovalResolved {
+	return &EventToolApprovalResolved{
+		EventImpl:  EventImpl{Type_: EventTypeToolApprovalResolved, Metadata_: metadata},
+		ApprovalID: approvalID,
+		ToolCallID: toolCallID,
+		ToolName:   toolName,
+		Decision:   decision,
+		Reason:     reason,
+		Input:      input,
+	}
+}
+
+var _ Event = &EventToolApprovalResolved{}
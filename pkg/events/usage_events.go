@@ -0,0 +1,74 @@
+package events
+
+// UsageTotals aggregates token usage and cost over one or more provider calls.
+type UsageTotals struct {
+	Calls            int     `json:"calls"`
+	InputTokens      int     `json:"input_tokens"`
+	OutputTokens     int     `json:"output_tokens"`
+	CacheReadTokens  int     `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int     `json:"cache_write_tokens,omitempty"`
+	CostUSD          float64 `json:"cost_usd"`
+	// UnpricedCalls counts calls without a computed cost (no ModelInfo.Cost),
+	// so CostUSD is a lower bound when it is non-zero.
+	UnpricedCalls int `json:"unpriced_calls,omitempty"`
+}
+
+// TotalTokens returns input plus output tokens.
+func (u UsageTotals) TotalTokens() int { return u.InputTokens + u.OutputTokens }
+
+// EventUsageUpdated is emitted after each provider call with the call's usage
+// and the accumulated totals for its run and session.
+type EventUsageUpdated struct {
+	EventImpl
+	Correlation_ Correlation `json:"correlation"`
+	Provider     string      `json:"provider,omitempty"`
+	Model        string      `json:"model,omitempty"`
+	Profile      string      `json:"profile,omitempty"`
+	Call         UsageTotals `json:"call"`
+	Run          UsageTotals `json:"run"`
+	Session      UsageTotals `json:"session"`
+}
+
+func NewUsageUpdatedEvent(metadata EventMetadata, corr Correlation, provider, model, profile string, call, run, session UsageTotals) *EventUsageUpdated {
+	return &EventUsageUpdated{
+		EventImpl:    EventImpl{Type_: EventTypeUsageUpdated, Metadata_: metadata},
+		Correlation_: corr,
+		Provider:     provider,
+		Model:        model,
+		Profile:      profile,
+		Call:         call,
+		Run:          run,
+		Session:      session,
+	}
+}
+
+func (e *EventUsageUpdated) Correlation() Correlation { return e.Correlation_ }
+
+var _ CorrelatedEvent = &EventUsageUpdated{}
+
+// EventBudgetExceeded is emitted when a budget guard refuses a provider call.
+type EventBudgetExceeded struct {
+	EventImpl
+	Correlation_ Correlation `json:"correlation"`
+	// Scope is "run", "session" or "profile".
+	Scope string `json:"scope"`
+	// Limit is "cost_usd" or "tokens".
+	Limit string  `json:"limit"`
+	Max   float64 `json:"max"`
+	Used  float64 `json:"used"`
+}
+
+func NewBudgetExceededEvent(metadata EventMetadata, corr Correlation, scope, limit string, maxValue, used float64) *EventBudgetExceeded {
+	return &EventBudgetExceeded{
+		EventImpl:    EventImpl{Type_: EventTypeBudgetExceeded, Metadata_: metadata},
+		Correlation_: corr,
+		Scope:        scope,
+		Limit:        limit,
+		Max:          maxValue,
+		Used:         used,
+	}
+}
+
+func (e *EventBudgetExceeded) Correlation() Correlation { return e.Correlation_ }
+
+var _ CorrelatedEvent = &EventBudgetExceeded{}
@@ -0,0 +1,151 @@
+// Package batch runs many independent Turns as one offline job.
+//
+// Providers with a native batch API (OpenAI Batch, Anthropic Message Batches)
+// process such jobs asynchronously at a reduced price. Client abstracts over
+// those APIs; LocalExecutor implements the same contract by running an
+// engine.Engine concurrently, for providers without batch support.
+//
+// A job goes through Submit, polling with Get (or Wait) until its status is
+// done, and Results, which maps the provider output back onto clones of the
+// submitted Turns using the same block conversion as the streaming engines.
+// Run chains the three steps.
+package batch
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/pkg/errors"
+)
+
+// Status is the provider-neutral state of a batch job.
+type Status string
+
+const (
+	StatusInProgress Status = "in_progress"
+	StatusCompleted  Status = "completed"
+	StatusFailed     Status = "failed"
+	StatusCancelled  Status = "cancelled"
+	StatusExpired    Status = "expired"
+)
+
+// Done reports whether the job reached a final state. Results are available
+// for completed, cancelled and expired jobs; failed jobs have none.
+func (s Status) Done() bool {
+	switch s {
+	case StatusInProgress:
+		return false
+	case StatusCompleted, StatusFailed, StatusCancelled, StatusExpired:
+		return true
+	}
+	return false
+}
+
+var (
+	// ErrNotFinished is returned by Results while the job is still running.
+	ErrNotFinished = errors.New("batch job is not finished")
+	// ErrMissingResult is set on results of requests the provider returned
+	// nothing for, e.g. because the job was cancelled before reaching them.
+	ErrMissingResult = errors.New("no result for request")
+)
+
+// Request is one independent inference of a batch job.
+type Request struct {
+	// CustomID identifies the request in provider output. It must be unique
+	// within a job and match ^[a-zA-Z0-9_-]{1,64}$, the strictest provider rule.
+	CustomID string
+	Turn     *turns.Turn
+}
+
+// Counts tracks the progress of a job's requests.
+type Counts struct {
+	Total     int `json:"total" yaml:"total"`
+	Succeeded int `json:"succeeded" yaml:"succeeded"`
+	Failed    int `json:"failed" yaml:"failed"`
+}
+
+// Job is a snapshot of a submitted batch.
+type Job struct {
+	ID       string `json:"id" yaml:"id"`
+	Provider string `json:"provider" yaml:"provider"`
+	Status   Status `json:"status" yaml:"status"`
+	// ProviderStatus is the status as reported by the provider, e.g.
+	// "finalizing" or "canceling".
+	ProviderStatus string `json:"provider_status,omitempty" yaml:"provider_status,omitempty"`
+	Counts         Counts `json:"counts" yaml:"counts"`
+	// Error describes why a failed job failed.
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Result is the outcome of one Request.
+type Result struct {
+	CustomID string
+	// Turn is a clone of the request Turn with the generated blocks appended
+	// and the inference result persisted. It is nil when Err is set.
+	Turn *turns.Turn
+	Err  error
+}
+
+// Client submits batch jobs and retrieves their results.
+type Client interface {
+	// Submit validates reqs and starts a job.
+	Submit(ctx context.Context, reqs []Request) (*Job, error)
+	// Get returns the current state of a job.
+	Get(ctx context.Context, id string) (*Job, error)
+	// Cancel asks the provider to stop a job. Requests already finished keep
+	// their results.
+	Cancel(ctx context.Context, id string) (*Job, error)
+	// Results returns one Result per request of reqs, in the same order. reqs
+	// must be the requests the job was submitted with. It returns
+	// ErrNotFinished while the job is running.
+	Results(ctx context.Context, id string, reqs []Request) ([]Result, error)
+}
+
+var customIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// ValidateRequests checks that reqs is non-empty, every request has a Turn
+// and custom IDs are valid and unique.
+func ValidateRequests(reqs []Request) error {
+	if len(reqs) == 0 {
+		return errors.New("batch: no requests")
+	}
+	seen := make(map[string]struct{}, len(reqs))
+	for i, r := range reqs {
+		if !customIDPattern.MatchString(r.CustomID) {
+			return errors.Errorf("batch: request %d: invalid custom id %q (want 1-64 characters of [a-zA-Z0-9_-])", i, r.CustomID)
+		}
+		if _, ok := seen[r.CustomID]; ok {
+			return errors.Errorf("batch: request %d: duplicate custom id %q", i, r.CustomID)
+		}
+		seen[r.CustomID] = struct{}{}
+		if r.Turn == nil {
+			return errors.Errorf("batch: request %q: turn is nil", r.CustomID)
+		}
+	}
+	return nil
+}
+
+// Assemble orders results by reqs. Requests missing from byID get a Result
+// with ErrMissingResult.
+func Assemble(reqs []Request, byID map[string]Result) []Result {
+	ret := make([]Result, len(reqs))
+	for i, r := range reqs {
+		res, ok := byID[r.CustomID]
+		if !ok {
+			res = Result{Err: ErrMissingResult}
+		}
+		res.CustomID = r.CustomID
+		ret[i] = res
+	}
+	return ret
+}
+
+// RequestTurns indexes the request Turns by custom ID.
+func RequestTurns(reqs []Request) map[string]*turns.Turn {
+	ret := make(map[string]*turns.Turn, len(reqs))
+	for _, r := range reqs {
+		ret[r.CustomID] = r.Turn
+	}
+	return ret
+}
@@ -0,0 +1,45 @@
+package factory
+
+import (
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/batch"
+	enginefactory "github.com/go-go-golems/geppetto/pkg/inference/engine/factory"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/claude"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/openai"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/types"
+	"github.com/pkg/errors"
+)
+
+// NewFromSettings returns the native batch client of the configured provider
+// (OpenAI chat completions, Claude) or, for every other provider, a
+// LocalExecutor running the provider's engine. opts only apply to the local
+// executor.
+func NewFromSettings(ss *settings.InferenceSettings, opts ...batch.LocalOption) (batch.Client, error) {
+	if ss == nil {
+		return nil, errors.New("batch: settings cannot be nil")
+	}
+	if ss.Chat == nil {
+		return nil, errors.New("batch: chat settings cannot be nil")
+	}
+
+	provider := string(types.ApiTypeOpenAI)
+	if ss.Chat.ApiType != nil && strings.TrimSpace(string(*ss.Chat.ApiType)) != "" {
+		provider = strings.ToLower(strings.TrimSpace(string(*ss.Chat.ApiType)))
+	}
+
+	switch provider {
+	case string(types.ApiTypeOpenAI):
+		return openai.NewBatchClient(ss)
+	case string(types.ApiTypeClaude), "anthropic":
+		return claude.NewBatchClient(ss)
+	default:
+		eng, err := enginefactory.NewEngineFromSettings(ss)
+		if err != nil {
+			return nil, errors.Wrap(err, "batch: create engine for local executor")
+		}
+		log.Debug().Str("provider", provider).Msg("provider has no batch API, using local executor")
+		return batch.NewLocalExecutor(eng, append([]batch.LocalOption{batch.WithProvider(provider)}, opts...)...), nil
+	}
+}
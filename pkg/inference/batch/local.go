@@ -0,0 +1,240 @@
+package batch
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// DefaultLocalConcurrency is the number of inferences LocalExecutor runs at
+// the same time unless configured otherwise.
+const DefaultLocalConcurrency = 4
+
+// DefaultLocalRetention is how long LocalExecutor keeps a finished job and its
+// results unless configured otherwise.
+const DefaultLocalRetention = time.Hour
+
+// LocalExecutor implements Client by running each request through an engine
+// in-process. It is the fallback for providers without a batch API: there is
+// no discount, but callers keep a single code path. Jobs live in memory and
+// are lost when the process exits. Finished jobs are dropped by Forget or once
+// the retention period has passed.
+type LocalExecutor struct {
+	engine      engine.Engine
+	provider    string
+	concurrency int
+	retention   time.Duration
+
+	mu   sync.Mutex
+	jobs map[string]*localJob
+}
+
+type localJob struct {
+	job        Job
+	results    map[string]Result
+	cancel     context.CancelFunc
+	finishedAt time.Time
+}
+
+var _ Client = (*LocalExecutor)(nil)
+
+// LocalOption configures a LocalExecutor.
+type LocalOption func(*LocalExecutor)
+
+// WithConcurrency limits how many requests run at once.
+func WithConcurrency(n int) LocalOption {
+	return func(l *LocalExecutor) {
+		if n > 0 {
+			l.concurrency = n
+		}
+	}
+}
+
+// WithRetention sets how long finished jobs are kept (default
+// DefaultLocalRetention).
+func WithRetention(d time.Duration) LocalOption {
+	return func(l *LocalExecutor) {
+		if d > 0 {
+			l.retention = d
+		}
+	}
+}
+
+// WithProvider sets the provider name reported on jobs (default "local").
+func WithProvider(name string) LocalOption {
+	return func(l *LocalExecutor) { l.provider = name }
+}
+
+// NewLocalExecutor returns a LocalExecutor running requests through e.
+func NewLocalExecutor(e engine.Engine, opts ...LocalOption) *LocalExecutor {
+	l := &LocalExecutor{
+		engine:      e,
+		provider:    "local",
+		concurrency: DefaultLocalConcurrency,
+		retention:   DefaultLocalRetention,
+		jobs:        map[string]*localJob{},
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(l)
+		}
+	}
+	return l
+}
+
+// Submit starts running reqs in the background and returns immediately. The
+// inferences keep the values of ctx (event sinks, tool registry) but not its
+// cancellation; use Cancel to stop them.
+func (l *LocalExecutor) Submit(ctx context.Context, reqs []Request) (*Job, error) {
+	if l.engine == nil {
+		return nil, errors.New("batch: local executor has no engine")
+	}
+	if err := ValidateRequests(reqs); err != nil {
+		return nil, err
+	}
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	lj := &localJob{
+		job: Job{
+			ID:             "local_" + uuid.NewString(),
+			Provider:       l.provider,
+			Status:         StatusInProgress,
+			ProviderStatus: string(StatusInProgress),
+			Counts:         Counts{Total: len(reqs)},
+		},
+		results: make(map[string]Result, len(reqs)),
+		cancel:  cancel,
+	}
+	l.mu.Lock()
+	l.evictExpired(time.Now())
+	l.jobs[lj.job.ID] = lj
+	job := lj.job
+	l.mu.Unlock()
+
+	go l.run(runCtx, lj, reqs)
+	return &job, nil
+}
+
+func (l *LocalExecutor) run(ctx context.Context, lj *localJob, reqs []Request) {
+	sem := make(chan struct{}, l.concurrency)
+	var wg sync.WaitGroup
+	for _, r := range reqs {
+		wg.Add(1)
+		go func(r Request) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+			if ctx.Err() != nil {
+				return
+			}
+
+			out, err := l.engine.RunInference(ctx, r.Turn.Clone())
+			res := Result{CustomID: r.CustomID, Turn: out, Err: err}
+			if err != nil {
+				res.Turn = nil
+			}
+			l.mu.Lock()
+			lj.results[r.CustomID] = res
+			if err != nil {
+				lj.job.Counts.Failed++
+			} else {
+				lj.job.Counts.Succeeded++
+			}
+			l.mu.Unlock()
+		}(r)
+	}
+	wg.Wait()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lj.job.Status = StatusCompleted
+	if ctx.Err() != nil {
+		lj.job.Status = StatusCancelled
+	}
+	lj.job.ProviderStatus = string(lj.job.Status)
+	lj.finishedAt = time.Now()
+	lj.cancel()
+}
+
+// evictExpired drops jobs that finished more than the retention period before
+// now. The caller holds l.mu.
+func (l *LocalExecutor) evictExpired(now time.Time) {
+	for id, lj := range l.jobs {
+		if !lj.finishedAt.IsZero() && now.Sub(lj.finishedAt) > l.retention {
+			delete(l.jobs, id)
+		}
+	}
+}
+
+func (l *LocalExecutor) lookup(id string) (*localJob, error) {
+	l.evictExpired(time.Now())
+	lj, ok := l.jobs[id]
+	if !ok {
+		return nil, errors.Errorf("batch: unknown local job %q", id)
+	}
+	return lj, nil
+}
+
+// Get returns a snapshot of the job.
+func (l *LocalExecutor) Get(_ context.Context, id string) (*Job, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lj, err := l.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+	job := lj.job
+	return &job, nil
+}
+
+// Cancel stops the job's running inferences and skips pending ones. The job
+// reports StatusCancelled once the running inferences returned.
+func (l *LocalExecutor) Cancel(_ context.Context, id string) (*Job, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lj, err := l.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+	lj.cancel()
+	if !lj.job.Status.Done() {
+		lj.job.ProviderStatus = "cancelling"
+	}
+	job := lj.job
+	return &job, nil
+}
+
+// Results returns the results of a finished job in request order.
+func (l *LocalExecutor) Results(_ context.Context, id string, reqs []Request) ([]Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lj, err := l.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+	if !lj.job.Status.Done() {
+		return nil, ErrNotFinished
+	}
+	return Assemble(reqs, lj.results), nil
+}
+
+// Forget drops the job and its results, cancelling it first if it is still
+// running. Run forgets local jobs once it fetched their results.
+func (l *LocalExecutor) Forget(_ context.Context, id string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lj, err := l.lookup(id)
+	if err != nil {
+		return err
+	}
+	lj.cancel()
+	delete(l.jobs, id)
+	return nil
+}
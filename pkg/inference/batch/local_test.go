@@ -0,0 +1,194 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/turns"
+)
+
+type echoEngine struct {
+	running atomic.Int32
+	peak    atomic.Int32
+	block   chan struct{}
+}
+
+func (e *echoEngine) RunInference(ctx context.Context, t *turns.Turn) (*turns.Turn, error) {
+	n := e.running.Add(1)
+	defer e.running.Add(-1)
+	for {
+		p := e.peak.Load()
+		if n <= p || e.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	if e.block != nil {
+		select {
+		case <-e.block:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	text, _ := t.Blocks[0].Payload[turns.PayloadKeyText].(string)
+	if text == "fail" {
+		return nil, errors.New("boom")
+	}
+	turns.AppendBlock(t, turns.NewAssistantTextBlock(strings.ToUpper(text)))
+	return t, nil
+}
+
+func userTurn(text string) *turns.Turn {
+	return &turns.Turn{Blocks: []turns.Block{turns.NewUserTextBlock(text)}}
+}
+
+func TestLocalExecutorRunMapsResultsInRequestOrder(t *testing.T) {
+	eng := &echoEngine{}
+	l := NewLocalExecutor(eng, WithConcurrency(2))
+	reqs := []Request{
+		{CustomID: "a", Turn: userTurn("one")},
+		{CustomID: "b", Turn: userTurn("fail")},
+		{CustomID: "c", Turn: userTurn("three")},
+	}
+	var polls int
+	results, job, err := Run(context.Background(), l, reqs, WithPollInterval(time.Millisecond), WithProgress(func(*Job) { polls++ }))
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if job.Status != StatusCompleted || job.Counts != (Counts{Total: 3, Succeeded: 2, Failed: 1}) {
+		t.Fatalf("job = %#v", job)
+	}
+	if polls == 0 {
+		t.Fatalf("progress callback was not called")
+	}
+	if len(results) != 3 || results[0].CustomID != "a" || results[1].CustomID != "b" || results[2].CustomID != "c" {
+		t.Fatalf("results = %#v", results)
+	}
+	if results[1].Err == nil || results[1].Turn != nil {
+		t.Fatalf("failing request: %#v", results[1])
+	}
+	out := results[2].Turn
+	if out == nil || len(out.Blocks) != 2 || out.Blocks[1].Payload[turns.PayloadKeyText] != "THREE" {
+		t.Fatalf("result turn = %#v", out)
+	}
+	if len(reqs[2].Turn.Blocks) != 1 {
+		t.Fatalf("request turn was mutated")
+	}
+	if eng.peak.Load() > 2 {
+		t.Fatalf("concurrency limit exceeded: %d", eng.peak.Load())
+	}
+}
+
+func TestLocalExecutorCancel(t *testing.T) {
+	eng := &echoEngine{block: make(chan struct{})}
+	l := NewLocalExecutor(eng, WithConcurrency(1))
+	reqs := []Request{{CustomID: "a", Turn: userTurn("one")}, {CustomID: "b", Turn: userTurn("two")}}
+	job, err := l.Submit(context.Background(), reqs)
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if _, err := l.Results(context.Background(), job.ID, reqs); !errors.Is(err, ErrNotFinished) {
+		t.Fatalf("expected ErrNotFinished, got %v", err)
+	}
+	if _, err := l.Cancel(context.Background(), job.ID); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	job, err = Wait(context.Background(), l, job.ID, WithPollInterval(time.Millisecond))
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if job.Status != StatusCancelled {
+		t.Fatalf("status = %s", job.Status)
+	}
+	results, err := l.Results(context.Background(), job.ID, reqs)
+	if err != nil {
+		t.Fatalf("Results: %v", err)
+	}
+	for _, r := range results {
+		if r.Err == nil {
+			t.Fatalf("expected error for cancelled request %q", r.CustomID)
+		}
+	}
+}
+
+func TestLocalExecutorForgetsJobs(t *testing.T) {
+	reqs := []Request{{CustomID: "a", Turn: userTurn("one")}}
+
+	l := NewLocalExecutor(&echoEngine{})
+	job, err := l.Submit(context.Background(), reqs)
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if _, err := Wait(context.Background(), l, job.ID, WithPollInterval(time.Millisecond)); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if err := l.Forget(context.Background(), job.ID); err != nil {
+		t.Fatalf("Forget: %v", err)
+	}
+	if _, err := l.Get(context.Background(), job.ID); err == nil {
+		t.Fatalf("expected a forgotten job to be unknown")
+	}
+
+	if _, job, err = Run(context.Background(), l, reqs, WithPollInterval(time.Millisecond)); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if _, err := l.Get(context.Background(), job.ID); err == nil {
+		t.Fatalf("expected Run to forget the job after fetching its results")
+	}
+	if len(l.jobs) != 0 {
+		t.Fatalf("expected no retained jobs, got %d", len(l.jobs))
+	}
+}
+
+func TestLocalExecutorEvictsFinishedJobsAfterRetention(t *testing.T) {
+	eng := &echoEngine{block: make(chan struct{})}
+	l := NewLocalExecutor(eng, WithRetention(time.Minute))
+	reqs := []Request{{CustomID: "a", Turn: userTurn("one")}}
+	job, err := l.Submit(context.Background(), reqs)
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	l.mu.Lock()
+	l.evictExpired(time.Now().Add(time.Hour))
+	l.mu.Unlock()
+	if _, err := l.Get(context.Background(), job.ID); err != nil {
+		t.Fatalf("running jobs must not be evicted: %v", err)
+	}
+
+	close(eng.block)
+	if _, err := Wait(context.Background(), l, job.ID, WithPollInterval(time.Millisecond)); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	l.mu.Lock()
+	l.jobs[job.ID].finishedAt = time.Now().Add(-30 * time.Second)
+	l.mu.Unlock()
+	if _, err := l.Results(context.Background(), job.ID, reqs); err != nil {
+		t.Fatalf("jobs within the retention period must be kept: %v", err)
+	}
+	l.mu.Lock()
+	l.jobs[job.ID].finishedAt = time.Now().Add(-2 * time.Minute)
+	l.mu.Unlock()
+	if _, err := l.Get(context.Background(), job.ID); err == nil {
+		t.Fatalf("expected the finished job to be evicted after the retention period")
+	}
+}
+
+func TestValidateRequests(t *testing.T) {
+	cases := map[string][]Request{
+		"empty":     nil,
+		"bad id":    {{CustomID: "has space", Turn: userTurn("x")}},
+		"duplicate": {{CustomID: "a", Turn: userTurn("x")}, {CustomID: "a", Turn: userTurn("y")}},
+		"nil turn":  {{CustomID: "a"}},
+	}
+	for name, reqs := range cases {
+		if err := ValidateRequests(reqs); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if err := ValidateRequests([]Request{{CustomID: "req_1-a", Turn: userTurn("x")}}); err != nil {
+		t.Fatalf("valid request rejected: %v", err)
+	}
+}
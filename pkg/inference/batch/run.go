@@ -0,0 +1,93 @@
+package batch
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultPollInterval is how often Wait polls a job. Provider batches take
+// minutes to hours, so polling more often only burns rate limit.
+const DefaultPollInterval = 30 * time.Second
+
+type waitOptions struct {
+	pollInterval time.Duration
+	onProgress   func(*Job)
+}
+
+// WaitOption configures Wait and Run.
+type WaitOption func(*waitOptions)
+
+// WithPollInterval sets the delay between status polls.
+func WithPollInterval(d time.Duration) WaitOption {
+	return func(o *waitOptions) {
+		if d > 0 {
+			o.pollInterval = d
+		}
+	}
+}
+
+// WithProgress registers a callback invoked with every polled job snapshot.
+func WithProgress(fn func(*Job)) WaitOption {
+	return func(o *waitOptions) { o.onProgress = fn }
+}
+
+// Wait polls the job until it is done or ctx ends. Cancelling ctx stops
+// polling only; use Client.Cancel to stop the job itself.
+func Wait(ctx context.Context, c Client, id string, opts ...WaitOption) (*Job, error) {
+	o := waitOptions{pollInterval: DefaultPollInterval}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	ticker := time.NewTicker(o.pollInterval)
+	defer ticker.Stop()
+	for {
+		job, err := c.Get(ctx, id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "batch: get job %s", id)
+		}
+		if o.onProgress != nil {
+			o.onProgress(job)
+		}
+		if job.Status.Done() {
+			return job, nil
+		}
+		select {
+		case <-ctx.Done():
+			return job, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run submits reqs, waits for the job and returns its results in request
+// order. A failed job is returned together with an error.
+func Run(ctx context.Context, c Client, reqs []Request, opts ...WaitOption) ([]Result, *Job, error) {
+	job, err := c.Submit(ctx, reqs)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "batch: submit")
+	}
+	log.Debug().Str("job_id", job.ID).Str("provider", job.Provider).Int("requests", len(reqs)).Msg("batch job submitted")
+	job, err = Wait(ctx, c, job.ID, opts...)
+	if err != nil {
+		return nil, job, err
+	}
+	if job.Status == StatusFailed {
+		return nil, job, errors.Errorf("batch: job %s failed: %s", job.ID, job.Error)
+	}
+	results, err := c.Results(ctx, job.ID, reqs)
+	if err != nil {
+		return nil, job, errors.Wrapf(err, "batch: results of job %s", job.ID)
+	}
+	if f, ok := c.(interface {
+		Forget(ctx context.Context, id string) error
+	}); ok {
+		if err := f.Forget(ctx, job.ID); err != nil {
+			log.Debug().Err(err).Str("job_id", job.ID).Msg("batch: forget job")
+		}
+	}
+	return results, job, nil
+}
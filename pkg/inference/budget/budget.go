@@ -0,0 +1,66 @@
+package budget
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Budget scopes and limits reported in ExceededError and EventBudgetExceeded.
+const (
+	ScopeRun     = "run"
+	ScopeSession = "session"
+	ScopeProfile = "profile"
+
+	LimitCostUSD = "cost_usd"
+	LimitTokens  = "tokens"
+)
+
+// ErrBudgetExceeded matches every *ExceededError with errors.Is.
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// Limits caps the usage of one scope. Zero values mean unlimited. Tokens are
+// counted as input plus output tokens.
+type Limits struct {
+	MaxCostUSD float64
+	MaxTokens  int
+}
+
+// IsZero reports whether no limit is set.
+func (l Limits) IsZero() bool { return l.MaxCostUSD <= 0 && l.MaxTokens <= 0 }
+
+// check returns an *ExceededError when totals have reached a limit.
+func (l Limits) check(scope, id string, used usage) *ExceededError {
+	if l.MaxCostUSD > 0 && used.cost >= l.MaxCostUSD {
+		return &ExceededError{Scope: scope, ScopeID: id, Limit: LimitCostUSD, Max: l.MaxCostUSD, Used: used.cost}
+	}
+	if l.MaxTokens > 0 && used.tokens >= l.MaxTokens {
+		return &ExceededError{Scope: scope, ScopeID: id, Limit: LimitTokens, Max: float64(l.MaxTokens), Used: float64(used.tokens)}
+	}
+	return nil
+}
+
+type usage struct {
+	cost   float64
+	tokens int
+}
+
+// ExceededError is returned by the budget middleware when a provider call is
+// refused because a run, session or profile budget is used up.
+type ExceededError struct {
+	Scope   string
+	ScopeID string
+	Limit   string
+	Max     float64
+	Used    float64
+}
+
+func (e *ExceededError) Error() string {
+	if e.Limit == LimitCostUSD {
+		return fmt.Sprintf("%s budget exceeded for %s %q: $%.4f used of $%.4f", e.Limit, e.Scope, e.ScopeID, e.Used, e.Max)
+	}
+	return fmt.Sprintf("%s budget exceeded for %s %q: %.0f used of %.0f", e.Limit, e.Scope, e.ScopeID, e.Used, e.Max)
+}
+
+// Is makes errors.Is(err, ErrBudgetExceeded) succeed.
+func (e *ExceededError) Is(target error) bool { return target == ErrBudgetExceeded }
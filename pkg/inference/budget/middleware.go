@@ -0,0 +1,111 @@
+package budget
+
+import (
+	"context"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/inference/middleware"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/google/uuid"
+)
+
+// MiddlewareName is the name used for profile-driven composition.
+const MiddlewareName = "budget"
+
+// Config configures NewMiddleware.
+type Config struct {
+	// Tracker aggregates usage. A private tracker is created when nil, which
+	// limits session and profile budgets to the lifetime of the middleware.
+	Tracker *Tracker
+	Run     Limits
+	Session Limits
+	Profile Limits
+	// ProfileName overrides the profile read from the turn's runtime
+	// attribution.
+	ProfileName string
+}
+
+// NewMiddleware returns a middleware that records the usage and cost of every
+// provider call in the tracker and publishes EventUsageUpdated. Before each
+// call it checks the run, session and profile budgets; once one is used up it
+// publishes EventBudgetExceeded and returns an *ExceededError without calling
+// the provider, which ends the tool loop.
+//
+// Costs come from InferenceResult.Cost, which engines fill from
+// ModelInfo.Cost. Calls without pricing still count against token budgets.
+func NewMiddleware(cfg Config) middleware.Middleware {
+	tracker := cfg.Tracker
+	if tracker == nil {
+		tracker = NewTracker()
+	}
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, t *turns.Turn) (*turns.Turn, error) {
+			scope := ScopeFromTurn(t)
+			if cfg.ProfileName != "" {
+				scope.Profile = cfg.ProfileName
+			}
+			if exceeded := checkLimits(cfg, tracker, scope); exceeded != nil {
+				log.Warn().
+					Str("scope", exceeded.Scope).
+					Str("scope_id", exceeded.ScopeID).
+					Str("limit", exceeded.Limit).
+					Float64("max", exceeded.Max).
+					Float64("used", exceeded.Used).
+					Msg("budget: refusing provider call")
+				meta, corr := eventIdentity(t, scope)
+				events.PublishEventToContext(ctx, events.NewBudgetExceededEvent(meta, corr, exceeded.Scope, exceeded.Limit, exceeded.Max, exceeded.Used))
+				return t, exceeded
+			}
+
+			out, err := next(ctx, t)
+			if out == nil {
+				return out, err
+			}
+			res, ok, rerr := turns.KeyTurnMetaInferenceResult.Get(out.Metadata)
+			if rerr != nil || !ok {
+				return out, err
+			}
+			snap := tracker.Record(scope, res)
+			meta, corr := eventIdentity(out, scope)
+			events.PublishEventToContext(ctx, events.NewUsageUpdatedEvent(meta, corr, res.Provider, res.Model, scope.Profile, snap.Call, snap.Run, snap.Session))
+			return out, err
+		}
+	}
+}
+
+func checkLimits(cfg Config, tracker *Tracker, scope Scope) *ExceededError {
+	if !cfg.Run.IsZero() && scope.RunID != "" {
+		if e := cfg.Run.check(ScopeRun, scope.RunID, usageOf(tracker.Run(scope.RunID))); e != nil {
+			return e
+		}
+	}
+	if !cfg.Session.IsZero() && scope.SessionID != "" {
+		if e := cfg.Session.check(ScopeSession, scope.SessionID, usageOf(tracker.Session(scope.SessionID))); e != nil {
+			return e
+		}
+	}
+	if !cfg.Profile.IsZero() && scope.Profile != "" {
+		if e := cfg.Profile.check(ScopeProfile, scope.Profile, usageOf(tracker.Profile(scope.Profile))); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+func usageOf(u events.UsageTotals) usage {
+	return usage{cost: u.CostUSD, tokens: u.TotalTokens()}
+}
+
+func eventIdentity(t *turns.Turn, scope Scope) (events.EventMetadata, events.Correlation) {
+	turnID := ""
+	if t != nil {
+		turnID = t.ID
+	}
+	meta := events.EventMetadata{
+		ID:          uuid.New(),
+		SessionID:   scope.SessionID,
+		InferenceID: scope.RunID,
+		TurnID:      turnID,
+	}
+	return meta, events.BuildRunCorrelation(scope.SessionID, scope.RunID, turnID)
+}
@@ -0,0 +1,136 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+)
+
+type captureSink struct {
+	mu  sync.Mutex
+	evs []events.Event
+}
+
+func (s *captureSink) PublishEvent(e events.Event) error {
+	s.mu.Lock()
+	s.evs = append(s.evs, e)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *captureSink) count(typ events.EventType) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, e := range s.evs {
+		if e.Type() == typ {
+			n++
+		}
+	}
+	return n
+}
+
+func pricedHandler(calls *int, cost float64) func(context.Context, *turns.Turn) (*turns.Turn, error) {
+	return func(_ context.Context, t *turns.Turn) (*turns.Turn, error) {
+		*calls++
+		c := cost
+		res := turns.InferenceResult{
+			Provider: "openai",
+			Model:    "gpt-test",
+			Usage:    &turns.InferenceUsage{InputTokens: 100, OutputTokens: 20, CachedTokens: 40},
+			Cost:     &c,
+		}
+		if err := turns.KeyTurnMetaInferenceResult.Set(&t.Metadata, res); err != nil {
+			return nil, err
+		}
+		return t, nil
+	}
+}
+
+func testTurn(runID string) *turns.Turn {
+	t := &turns.Turn{ID: "turn-1"}
+	_ = turns.KeyTurnMetaSessionID.Set(&t.Metadata, "session-1")
+	_ = turns.KeyTurnMetaInferenceID.Set(&t.Metadata, runID)
+	_ = turns.KeyTurnMetaRuntime.Set(&t.Metadata, map[string]any{"runtime_key": "default", "profile.slug": "cheap"})
+	return t
+}
+
+func TestTrackerAggregatesPerScope(t *testing.T) {
+	tr := NewTracker()
+	cost := 0.5
+	res := turns.InferenceResult{Usage: &turns.InferenceUsage{InputTokens: 10, OutputTokens: 5, CacheReadInputTokens: 3, CacheCreationInputTokens: 2}, Cost: &cost}
+	tr.Record(Scope{SessionID: "s", RunID: "r1", Profile: "p"}, res)
+	snap := tr.Record(Scope{SessionID: "s", RunID: "r2", Profile: "p"}, turns.InferenceResult{Usage: &turns.InferenceUsage{InputTokens: 1}})
+
+	if snap.Run.Calls != 1 || snap.Run.UnpricedCalls != 1 {
+		t.Fatalf("unexpected run totals %+v", snap.Run)
+	}
+	s := tr.Session("s")
+	if s.Calls != 2 || s.InputTokens != 11 || s.OutputTokens != 5 || s.CacheReadTokens != 3 || s.CacheWriteTokens != 2 || s.CostUSD != 0.5 || s.UnpricedCalls != 1 {
+		t.Fatalf("unexpected session totals %+v", s)
+	}
+	if tr.Profile("p") != s || tr.Total() != s {
+		t.Fatalf("profile and total should match the single session")
+	}
+	tr.ForgetRun("r1")
+	if tr.Run("r1").Calls != 0 || tr.Session("s").Calls != 2 {
+		t.Fatalf("ForgetRun must only drop the run bucket")
+	}
+}
+
+func TestMiddlewareStopsRunOnceCostBudgetIsUsed(t *testing.T) {
+	sink := &captureSink{}
+	ctx := events.WithEventSinks(context.Background(), sink)
+	tracker := NewTracker()
+	calls := 0
+	h := NewMiddleware(Config{Tracker: tracker, Run: Limits{MaxCostUSD: 0.25}})(pricedHandler(&calls, 0.1))
+
+	var err error
+	for i := 0; i < 5 && err == nil; i++ {
+		_, err = h(ctx, testTurn("run-1"))
+	}
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected ErrBudgetExceeded, got %v", err)
+	}
+	var exceeded *ExceededError
+	if !errors.As(err, &exceeded) || exceeded.Scope != ScopeRun || exceeded.Limit != LimitCostUSD {
+		t.Fatalf("unexpected error %#v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 provider calls before the guard tripped, got %d", calls)
+	}
+	if got := sink.count(events.EventTypeUsageUpdated); got != 3 {
+		t.Fatalf("expected 3 usage events, got %d", got)
+	}
+	if got := sink.count(events.EventTypeBudgetExceeded); got != 1 {
+		t.Fatalf("expected 1 budget event, got %d", got)
+	}
+	if p := tracker.Profile("cheap"); p.Calls != 3 || p.CacheReadTokens != 120 {
+		t.Fatalf("unexpected profile totals %+v", p)
+	}
+
+	// A new run in the same session starts with a fresh run budget.
+	if _, err := h(ctx, testTurn("run-2")); err != nil {
+		t.Fatalf("expected new run to proceed, got %v", err)
+	}
+}
+
+func TestMiddlewareSessionTokenBudget(t *testing.T) {
+	calls := 0
+	h := NewMiddleware(Config{Session: Limits{MaxTokens: 200}})(pricedHandler(&calls, 0))
+	if _, err := h(context.Background(), testTurn("run-1")); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	if _, err := h(context.Background(), testTurn("run-2")); err != nil {
+		t.Fatalf("second call: %v", err)
+	}
+	_, err := h(context.Background(), testTurn("run-3"))
+	var exceeded *ExceededError
+	if !errors.As(err, &exceeded) || exceeded.Scope != ScopeSession || exceeded.Limit != LimitTokens || exceeded.Used != 240 {
+		t.Fatalf("expected session token budget error, got %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package budget
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+)
+
+// Scope identifies the aggregation buckets a provider call is counted in.
+// Empty fields are not aggregated.
+type Scope struct {
+	SessionID string
+	RunID     string
+	Profile   string
+}
+
+// ScopeFromTurn reads the session id, run (inference) id and profile from the
+// turn metadata. The profile is the profile slug from the runtime attribution,
+// falling back to the runtime key.
+func ScopeFromTurn(t *turns.Turn) Scope {
+	var s Scope
+	if t == nil {
+		return s
+	}
+	if v, ok, err := turns.KeyTurnMetaSessionID.Get(t.Metadata); err == nil && ok {
+		s.SessionID = v
+	}
+	if v, ok, err := turns.KeyTurnMetaInferenceID.Get(t.Metadata); err == nil && ok {
+		s.RunID = v
+	}
+	if v, ok, err := turns.KeyTurnMetaRuntime.Get(t.Metadata); err == nil && ok {
+		if rt, ok := v.(map[string]any); ok {
+			for _, k := range []string{"profile.slug", "runtime_key"} {
+				if p, ok := rt[k].(string); ok && strings.TrimSpace(p) != "" {
+					s.Profile = strings.TrimSpace(p)
+					break
+				}
+			}
+		}
+	}
+	return s
+}
+
+// Snapshot is the state of all buckets right after a call was recorded.
+type Snapshot struct {
+	Call    events.UsageTotals
+	Run     events.UsageTotals
+	Session events.UsageTotals
+	Profile events.UsageTotals
+	Total   events.UsageTotals
+}
+
+// Tracker aggregates usage and cost per session, run and profile. It is safe
+// for concurrent use and is typically shared by every middleware instance of
+// an application.
+type Tracker struct {
+	mu       sync.Mutex
+	sessions map[string]events.UsageTotals
+	runs     map[string]events.UsageTotals
+	profiles map[string]events.UsageTotals
+	total    events.UsageTotals
+}
+
+// NewTracker creates an empty tracker.
+func NewTracker() *Tracker {
+	return &Tracker{
+		sessions: map[string]events.UsageTotals{},
+		runs:     map[string]events.UsageTotals{},
+		profiles: map[string]events.UsageTotals{},
+	}
+}
+
+// UsageFromResult converts an inference result into the totals of one call.
+// Cache reads come from CacheReadInputTokens, falling back to CachedTokens for
+// providers that only report the latter.
+func UsageFromResult(res turns.InferenceResult) events.UsageTotals {
+	u := events.UsageTotals{Calls: 1}
+	if res.Usage != nil {
+		u.InputTokens = res.Usage.InputTokens
+		u.OutputTokens = res.Usage.OutputTokens
+		u.CacheReadTokens = res.Usage.CacheReadInputTokens
+		if u.CacheReadTokens == 0 {
+			u.CacheReadTokens = res.Usage.CachedTokens
+		}
+		u.CacheWriteTokens = res.Usage.CacheCreationInputTokens
+	}
+	if res.Cost != nil {
+		u.CostUSD = *res.Cost
+	} else {
+		u.UnpricedCalls = 1
+	}
+	return u
+}
+
+// Record adds one provider call to every bucket of scope and returns the
+// updated totals.
+func (tr *Tracker) Record(scope Scope, res turns.InferenceResult) Snapshot {
+	call := UsageFromResult(res)
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	snap := Snapshot{Call: call}
+	if scope.RunID != "" {
+		snap.Run = add(tr.runs[scope.RunID], call)
+		tr.runs[scope.RunID] = snap.Run
+	}
+	if scope.SessionID != "" {
+		snap.Session = add(tr.sessions[scope.SessionID], call)
+		tr.sessions[scope.SessionID] = snap.Session
+	}
+	if scope.Profile != "" {
+		snap.Profile = add(tr.profiles[scope.Profile], call)
+		tr.profiles[scope.Profile] = snap.Profile
+	}
+	tr.total = add(tr.total, call)
+	snap.Total = tr.total
+	return snap
+}
+
+// Run returns the totals of a run.
+func (tr *Tracker) Run(id string) events.UsageTotals {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return tr.runs[id]
+}
+
+// Session returns the totals of a session.
+func (tr *Tracker) Session(id string) events.UsageTotals {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return tr.sessions[id]
+}
+
+// Profile returns the totals of a profile.
+func (tr *Tracker) Profile(name string) events.UsageTotals {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return tr.profiles[name]
+}
+
+// Total returns the totals over all recorded calls.
+func (tr *Tracker) Total() events.UsageTotals {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return tr.total
+}
+
+// ForgetRun drops the run bucket once a run has finished. Session and profile
+// totals are kept.
+func (tr *Tracker) ForgetRun(id string) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	delete(tr.runs, id)
+}
+
+func add(a, b events.UsageTotals) events.UsageTotals {
+	a.Calls += b.Calls
+	a.InputTokens += b.InputTokens
+	a.OutputTokens += b.OutputTokens
+	a.CacheReadTokens += b.CacheReadTokens
+	a.CacheWriteTokens += b.CacheWriteTokens
+	a.CostUSD += b.CostUSD
+	a.UnpricedCalls += b.UnpricedCalls
+	return a
+}
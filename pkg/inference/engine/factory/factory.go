@@ -4,9 +4,12 @@ import (
 	"strings"
 
 	"github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/inference/recordreplay"
+	geppettoobs "github.com/go-go-golems/geppetto/pkg/observability"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/claude"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/credentials"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/gemini"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/ollama"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/openai"
 	openai_responses "github.com/go-go-golems/geppetto/pkg/steps/ai/openai_responses"
 
@@ -41,7 +44,9 @@ type StandardEngineFactory struct {
 	openAIOptions          []openai.EngineOption
 	claudeOptions          []claude.EngineOption
 	geminiOptions          []gemini.EngineOption
+	ollamaOptions          []ollama.EngineOption
 	bearerTokenSource      credentials.BearerTokenSource
+	recorder               *recordreplay.Recorder
 }
 
 // StandardEngineFactoryOption configures StandardEngineFactory.
@@ -79,6 +84,25 @@ func WithGeminiOptions(opts ...gemini.EngineOption) StandardEngineFactoryOption
 	}
 }
 
+// WithOllamaOptions passes options to Ollama engines created by the factory.
+func WithOllamaOptions(opts ...ollama.EngineOption) StandardEngineFactoryOption {
+	return func(f *StandardEngineFactory) {
+		f.ollamaOptions = append(f.ollamaOptions, opts...)
+	}
+}
+
+// WithObserver attaches obs to every engine created by the factory, emitting
+// the records that cfg enables.
+func WithObserver(obs geppettoobs.Observer, cfg geppettoobs.Config) StandardEngineFactoryOption {
+	return func(f *StandardEngineFactory) {
+		f.openAIResponsesOptions = append(f.openAIResponsesOptions, openai_responses.WithObserver(obs), openai_responses.WithObservabilityConfig(cfg))
+		f.openAIOptions = append(f.openAIOptions, openai.WithObserver(obs), openai.WithObservabilityConfig(cfg))
+		f.claudeOptions = append(f.claudeOptions, claude.WithObserver(obs), claude.WithObservabilityConfig(cfg))
+		f.geminiOptions = append(f.geminiOptions, gemini.WithObserver(obs), gemini.WithObservabilityConfig(cfg))
+		f.ollamaOptions = append(f.ollamaOptions, ollama.WithObserver(obs), ollama.WithObservabilityConfig(cfg))
+	}
+}
+
 // WithBearerTokenSource supplies OpenAI-compatible engines with a request-time
 // bearer credential source. It is authoritative over static API-key settings.
 func WithBearerTokenSource(source credentials.BearerTokenSource) StandardEngineFactoryOption {
@@ -87,6 +111,15 @@ func WithBearerTokenSource(source credentials.BearerTokenSource) StandardEngineF
 	}
 }
 
+// WithRecorder routes every engine created by the factory through a
+// record/replay recorder: provider HTTP traffic goes through the recorder's
+// transport and emitted events are captured or verified per run.
+func WithRecorder(rec *recordreplay.Recorder) StandardEngineFactoryOption {
+	return func(f *StandardEngineFactory) {
+		f.recorder = rec
+	}
+}
+
 func isResponsesProvider(provider string) bool {
 	return provider == string(types.ApiTypeOpenResponses) || provider == string(types.ApiTypeOpenAIResponses)
 }
@@ -104,7 +137,7 @@ func NewStandardEngineFactory(opts ...StandardEngineFactoryOption) *StandardEngi
 
 // CreateEngine creates an Engine instance based on the provider specified in settings.Chat.ApiType.
 // If no ApiType is specified, defaults to OpenAI.
-// Supported providers: openai, anyscale, fireworks, claude, anthropic, gemini.
+// Supported providers: openai, anyscale, fireworks, claude, anthropic, gemini, ollama.
 func (f *StandardEngineFactory) CreateEngine(settings *settings.InferenceSettings) (engine.Engine, error) {
 	if settings == nil {
 		return nil, errors.New("settings cannot be nil")
@@ -126,12 +159,30 @@ func (f *StandardEngineFactory) CreateEngine(settings *settings.InferenceSetting
 		}
 	}
 
+	if f.recorder != nil {
+		recorded, err := f.recorder.ApplySettings(settings)
+		if err != nil {
+			return nil, errors.Wrap(err, "apply record/replay settings")
+		}
+		settings = recorded
+	}
+
 	// Validate that we have the required settings
 	if err := f.validateSettings(settings, provider); err != nil {
 		return nil, errors.Wrapf(err, "invalid settings for provider %s", provider)
 	}
 
-	// Create engine based on provider
+	eng, err := f.createProviderEngine(settings, provider)
+	if err != nil {
+		return nil, err
+	}
+	if f.recorder != nil {
+		return f.recorder.WrapEngine(eng), nil
+	}
+	return eng, nil
+}
+
+func (f *StandardEngineFactory) createProviderEngine(settings *settings.InferenceSettings, provider string) (engine.Engine, error) {
 	switch provider {
 	case string(types.ApiTypeOpenAI), string(types.ApiTypeAnyScale), string(types.ApiTypeFireworks):
 		opts := append([]openai.EngineOption(nil), f.openAIOptions...)
@@ -157,6 +208,9 @@ func (f *StandardEngineFactory) CreateEngine(settings *settings.InferenceSetting
 	case string(types.ApiTypeGemini):
 		return gemini.NewGeminiEngine(settings, f.geminiOptions...)
 
+	case string(types.ApiTypeOllama):
+		return ollama.NewOllamaEngine(settings, f.ollamaOptions...)
+
 	default:
 		supported := strings.Join(f.SupportedProviders(), ", ")
 		return nil, errors.Errorf("unsupported provider %s. Supported providers: %s", provider, supported)
@@ -188,6 +242,7 @@ func (f *StandardEngineFactory) SupportedProviders() []string {
 		string(types.ApiTypeClaude),
 		"anthropic", // alias for claude
 		string(types.ApiTypeGemini),
+		string(types.ApiTypeOllama),
 	}
 }
 
@@ -217,6 +272,9 @@ func (f *StandardEngineFactory) validateSettings(settings *settings.InferenceSet
 	case string(types.ApiTypeGemini):
 		return f.validateGeminiSettings(settings, provider)
 
+	case string(types.ApiTypeOllama):
+		return f.validateOllamaSettings(settings, provider)
+
 	default:
 		return errors.Errorf("unknown provider %s", provider)
 	}
@@ -307,5 +365,17 @@ func (f *StandardEngineFactory) validateGeminiSettings(settings *settings.Infere
 	return nil
 }
 
+// validateOllamaSettings validates settings required for the native Ollama provider.
+func (f *StandardEngineFactory) validateOllamaSettings(settings *settings.InferenceSettings, provider string) error {
+	// API key and base URL are both optional: a local daemon needs no key and
+	// the engine falls back to http://localhost:11434. Ollama has no default
+	// model, so the engine name must be set.
+	if settings.Chat.Engine == nil || strings.TrimSpace(*settings.Chat.Engine) == "" {
+		return errors.Errorf("missing engine (model name) for provider %s", provider)
+	}
+
+	return nil
+}
+
 // Compile-time check that StandardEngineFactory implements EngineFactory
 var _ EngineFactory = (*StandardEngineFactory)(nil)
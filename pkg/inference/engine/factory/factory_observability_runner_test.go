@@ -0,0 +1,82 @@
+package factory_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/engine/factory"
+	"github.com/go-go-golems/geppetto/pkg/inference/runner"
+	geppettoobs "github.com/go-go-golems/geppetto/pkg/observability"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/types"
+)
+
+type runnerObserver struct {
+	mu      sync.Mutex
+	records []geppettoobs.Record
+}
+
+func (o *runnerObserver) OnGeppettoRecord(_ context.Context, rec geppettoobs.Record) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.records = append(o.records, rec)
+}
+
+func (o *runnerObserver) count() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.records)
+}
+
+// The runner imports this package, so the runner-level check lives in an
+// external test package.
+func TestRunnerWithEngineFactoryUsesObservedStandardFactory(t *testing.T) {
+	obs := &runnerObserver{}
+	engineFactory := factory.NewStandardEngineFactory(factory.WithObserver(obs, geppettoobs.Config{Level: geppettoobs.TraceProvider}))
+
+	ss, err := settings.NewInferenceSettings()
+	if err != nil {
+		t.Fatalf("NewInferenceSettings: %v", err)
+	}
+	apiType := types.ApiTypeOpenAI
+	engineName := "gpt-test"
+	ss.Chat.ApiType = &apiType
+	ss.Chat.Engine = &engineName
+	ss.API.APIKeys["openai-api-key"] = "test-api-key"
+	ss.API.BaseUrls["openai-base-url"] = "https://api.openai.com/v1"
+	ss.Client.HTTPClient = &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		body := strings.Join([]string{
+			`data: {"id":"chatcmpl-runner","object":"chat.completion.chunk","model":"gpt-test","choices":[{"delta":{"content":"ok"},"finish_reason":"stop"}]}`,
+			``,
+			`data: [DONE]`,
+			``,
+		}, "\n")
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    r,
+		}, nil
+	})}
+
+	r := runner.New(runner.WithEngineFactory(engineFactory.CreateEngine))
+	if _, _, err := r.Run(context.Background(), runner.StartRequest{
+		Prompt:  "say ok",
+		Runtime: runner.Runtime{InferenceSettings: ss},
+	}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if obs.count() == 0 {
+		t.Fatalf("expected the runner's engine to report to the factory observer")
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
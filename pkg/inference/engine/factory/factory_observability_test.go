@@ -203,3 +203,40 @@ func TestStandardEngineFactory_WithClaudeOptionsPassesObservabilityOptions(t *te
 		t.Fatalf("factory-created Claude engine did not emit provider-call-finished publish-started record: %#v", records)
 	}
 }
+
+func TestStandardEngineFactory_WithObserverAttachesToEveryProvider(t *testing.T) {
+	obs := &captureFactoryObserver{}
+	factory := NewStandardEngineFactory(WithObserver(obs, geppettoobs.Config{Level: geppettoobs.TraceEvents}))
+	if len(factory.openAIResponsesOptions) != 2 || len(factory.openAIOptions) != 2 || len(factory.claudeOptions) != 2 ||
+		len(factory.geminiOptions) != 2 || len(factory.ollamaOptions) != 2 {
+		t.Fatalf("expected observer and config options for every provider")
+	}
+
+	settings := createValidOpenAISettings()
+	engineName := "gpt-test"
+	settings.Chat.Engine = &engineName
+	settings.Client.HTTPClient = &http.Client{Transport: factoryRoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		body := strings.Join([]string{
+			`data: {"id":"chatcmpl-factory","object":"chat.completion.chunk","model":"gpt-test","choices":[{"delta":{"content":"ok"},"finish_reason":"stop"}]}`,
+			``,
+			`data: [DONE]`,
+			``,
+		}, "\n")
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    r,
+		}, nil
+	})}
+	eng, err := factory.CreateEngine(settings)
+	if err != nil {
+		t.Fatalf("CreateEngine: %v", err)
+	}
+	if _, err := eng.RunInference(context.Background(), &turns.Turn{Blocks: []turns.Block{turns.NewUserTextBlock("say ok")}}); err != nil {
+		t.Fatalf("RunInference: %v", err)
+	}
+	if len(obs.snapshot()) == 0 {
+		t.Fatalf("expected the factory observer to receive records")
+	}
+}
@@ -0,0 +1,94 @@
+package factory
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/recordreplay"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/types"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordReplayRoundTripper func(*http.Request) (*http.Response, error)
+
+func (f recordReplayRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func createOllamaSettings(t *testing.T) *settings.InferenceSettings {
+	t.Helper()
+	st, err := settings.NewInferenceSettings()
+	require.NoError(t, err)
+	ollamaType := types.ApiTypeOllama
+	model := "llama3.2"
+	st.Chat.ApiType = &ollamaType
+	st.Chat.Engine = &model
+	return st
+}
+
+func runOllamaThroughFactory(t *testing.T, rec *recordreplay.Recorder) *turns.Turn {
+	t.Helper()
+	eng, err := NewStandardEngineFactory(WithRecorder(rec)).CreateEngine(createOllamaSettings(t))
+	require.NoError(t, err)
+	turn := &turns.Turn{}
+	turns.AppendBlock(turn, turns.NewUserTextBlock("Say hi"))
+	out, err := eng.RunInference(context.Background(), turn)
+	require.NoError(t, err)
+	return out
+}
+
+func TestStandardEngineFactory_RecordReplay(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "ollama.json")
+	calls := 0
+	provider := recordReplayRoundTripper(func(r *http.Request) (*http.Response, error) {
+		calls++
+		body := `{"model":"llama3.2","message":{"role":"assistant","content":"Hi"},"done":false}` + "\n" +
+			`{"model":"llama3.2","message":{"role":"assistant","content":"!"},"done":true,"done_reason":"stop","prompt_eval_count":3,"eval_count":2}` + "\n"
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/x-ndjson"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    r,
+		}, nil
+	})
+
+	rec, err := recordreplay.New(cassette, recordreplay.ModeRecord, recordreplay.WithTransport(provider))
+	require.NoError(t, err)
+	recorded := runOllamaThroughFactory(t, rec)
+	require.NoError(t, rec.Close())
+	require.Equal(t, 1, calls)
+
+	replayer, err := recordreplay.New(cassette, recordreplay.ModeReplay)
+	require.NoError(t, err)
+	replayed := runOllamaThroughFactory(t, replayer)
+	require.NoError(t, replayer.Close())
+	require.Equal(t, 1, calls, "replay must not reach the provider")
+
+	require.Len(t, replayed.Blocks, len(recorded.Blocks))
+	last := replayed.Blocks[len(replayed.Blocks)-1]
+	assert.Equal(t, "Hi!", last.Payload[turns.PayloadKeyText])
+}
+
+func TestStandardEngineFactory_ReplayDoesNotRequireAPIKeys(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "empty.json")
+	require.NoError(t, (&recordreplay.Cassette{}).Save(cassette))
+	rec, err := recordreplay.New(cassette, recordreplay.ModeReplay)
+	require.NoError(t, err)
+
+	st := createValidOpenAISettings()
+	delete(st.API.APIKeys, "openai-api-key")
+
+	_, err = NewStandardEngineFactory().CreateEngine(st)
+	require.Error(t, err)
+
+	eng, err := NewStandardEngineFactory(WithRecorder(rec)).CreateEngine(st)
+	require.NoError(t, err)
+	assert.NotNil(t, eng)
+	_, stillMissing := st.API.APIKeys["openai-api-key"]
+	assert.False(t, stillMissing, "caller settings must not be mutated")
+}
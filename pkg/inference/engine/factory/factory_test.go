@@ -2,6 +2,7 @@ package factory
 
 import (
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/claude"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/ollama"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/openai"
 	openai_responses "github.com/go-go-golems/geppetto/pkg/steps/ai/openai_responses"
 	"testing"
@@ -22,6 +23,7 @@ func TestStandardEngineFactory_SupportedProviders(t *testing.T) {
 	assert.Contains(t, providers, string(types.ApiTypeOpenAIResponses))
 	assert.Contains(t, providers, string(types.ApiTypeClaude))
 	assert.Contains(t, providers, "anthropic")
+	assert.Contains(t, providers, string(types.ApiTypeOllama))
 	assert.NotEmpty(t, providers)
 }
 
@@ -98,6 +100,41 @@ func TestStandardEngineFactory_CreateEngine_LegacyOpenAIResponsesAlias_Success(t
 	assert.IsType(t, &openai_responses.Engine{}, engine)
 }
 
+func TestStandardEngineFactory_CreateEngine_Ollama_Success(t *testing.T) {
+	factory := NewStandardEngineFactory()
+
+	settings, err := settings.NewInferenceSettings()
+	require.NoError(t, err)
+
+	ollamaType := types.ApiTypeOllama
+	model := "llama3.2"
+	settings.Chat.ApiType = &ollamaType
+	settings.Chat.Engine = &model
+
+	engine, err := factory.CreateEngine(settings)
+
+	require.NoError(t, err)
+	assert.NotNil(t, engine)
+	assert.IsType(t, &ollama.OllamaEngine{}, engine)
+}
+
+func TestStandardEngineFactory_CreateEngine_Ollama_MissingModel(t *testing.T) {
+	factory := NewStandardEngineFactory()
+
+	settings, err := settings.NewInferenceSettings()
+	require.NoError(t, err)
+
+	ollamaType := types.ApiTypeOllama
+	settings.Chat.ApiType = &ollamaType
+	settings.Chat.Engine = nil
+
+	engine, err := factory.CreateEngine(settings)
+
+	assert.Nil(t, engine)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "missing engine")
+}
+
 func TestStandardEngineFactory_CreateEngine_UnsupportedProvider(t *testing.T) {
 	factory := NewStandardEngineFactory()
 
@@ -1,6 +1,11 @@
 package engine
 
-import "github.com/go-go-golems/geppetto/pkg/turns"
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-go-golems/geppetto/pkg/turns"
+)
 
 // InferenceConfig provides per-turn overrides for inference parameters.
 // When set on Turn.Data via KeyInferenceConfig, these take precedence
@@ -51,6 +56,91 @@ type ClaudeInferenceConfig struct {
 
 	// TopK sampling parameter specific to Claude.
 	TopK *int `json:"top_k,omitempty"`
+
+	// PromptCache controls where cache_control breakpoints are placed.
+	// Nil honours only block-level turns.KeyBlockMetaCacheControl markers.
+	PromptCache *ClaudePromptCacheConfig `json:"prompt_cache,omitempty"`
+}
+
+// ClaudePromptCacheMode selects how Claude prompt-cache breakpoints are placed.
+type ClaudePromptCacheMode string
+
+const (
+	// ClaudePromptCacheModeExplicit honours block markers plus the System,
+	// Tools and MinUserBlockChars switches. It is the default.
+	ClaudePromptCacheModeExplicit ClaudePromptCacheMode = "explicit"
+	// ClaudePromptCacheModeAuto additionally caches the system prompt, the tool
+	// definitions and the stable conversation prefix, which is what tool-loop
+	// agents resending the same history on every iteration want.
+	ClaudePromptCacheModeAuto ClaudePromptCacheMode = "auto"
+	// ClaudePromptCacheModeOff sends no breakpoints, ignoring block markers.
+	ClaudePromptCacheModeOff ClaudePromptCacheMode = "off"
+)
+
+// ClaudePromptCacheConfig is the per-turn Anthropic prompt caching policy.
+// Anthropic accepts at most four breakpoints per request; tools and system are
+// placed first and the latest message breakpoints fill the remaining slots.
+type ClaudePromptCacheConfig struct {
+	// Mode defaults to ClaudePromptCacheModeExplicit.
+	Mode ClaudePromptCacheMode `json:"mode,omitempty"`
+
+	// TTL for policy-placed breakpoints: "5m" (default when empty) or "1h".
+	// Block markers carry their own TTL.
+	TTL string `json:"ttl,omitempty"`
+
+	// System marks the system prompt as a breakpoint.
+	System bool `json:"system,omitempty"`
+
+	// Tools marks the tool definitions as a breakpoint.
+	Tools bool `json:"tools,omitempty"`
+
+	// MinUserBlockChars marks user blocks whose text is at least this long.
+	// Zero disables the rule.
+	MinUserBlockChars int `json:"min_user_block_chars,omitempty"`
+}
+
+// EffectiveMode returns Mode, defaulting to explicit.
+func (c *ClaudePromptCacheConfig) EffectiveMode() ClaudePromptCacheMode {
+	if c == nil || c.Mode == "" {
+		return ClaudePromptCacheModeExplicit
+	}
+	return c.Mode
+}
+
+// Validate rejects unknown modes and TTLs, so a typo such as "atuo" fails
+// instead of silently behaving like explicit mode.
+func (c *ClaudePromptCacheConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	switch c.Mode {
+	case "", ClaudePromptCacheModeExplicit, ClaudePromptCacheModeAuto, ClaudePromptCacheModeOff:
+	default:
+		return fmt.Errorf("unknown prompt cache mode %q (want explicit, auto or off)", c.Mode)
+	}
+	switch c.TTL {
+	case "", "5m", "1h":
+	default:
+		return fmt.Errorf("unknown prompt cache ttl %q (want 5m or 1h)", c.TTL)
+	}
+	if c.MinUserBlockChars < 0 {
+		return fmt.Errorf("prompt cache min_user_block_chars must be >= 0, got %d", c.MinUserBlockChars)
+	}
+	return nil
+}
+
+func (c *ClaudePromptCacheConfig) UnmarshalJSON(b []byte) error {
+	type promptCacheConfigJSON ClaudePromptCacheConfig
+	var tmp promptCacheConfigJSON
+	if err := json.Unmarshal(b, &tmp); err != nil {
+		return err
+	}
+	cfg := ClaudePromptCacheConfig(tmp)
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	*c = cfg
+	return nil
 }
 
 // OpenAIInferenceConfig holds OpenAI-specific per-turn overrides.
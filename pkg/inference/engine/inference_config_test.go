@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"encoding/json"
 	"testing"
 )
 
@@ -246,3 +247,16 @@ func TestSanitizeOpenAIForReasoningModel_ClearsFields(t *testing.T) {
 }
 
 func boolPtr(v bool) *bool { return &v }
+
+func TestClaudePromptCacheConfig_RejectsUnknownModeAndTTL(t *testing.T) {
+	valid := &ClaudePromptCacheConfig{Mode: ClaudePromptCacheModeAuto, TTL: "1h"}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, raw := range []string{`{"mode":"atuo"}`, `{"ttl":"10m"}`} {
+		var cfg ClaudePromptCacheConfig
+		if err := json.Unmarshal([]byte(raw), &cfg); err == nil {
+			t.Errorf("expected %s to be rejected", raw)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package engine
+
+// StatusError is returned by engines when a provider API answers with a
+// non-2xx HTTP status. Message is the engine's error text; callers that need
+// the status use errors.As instead of parsing it.
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string { return e.Message }
@@ -0,0 +1,381 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidateJSONSchema checks a decoded JSON value (as produced by
+// encoding/json into any) against schema and returns one message per
+// violation, or nil when the value is valid.
+//
+// The supported subset covers what providers accept for structured output:
+// type (including type arrays), enum, const, properties, required,
+// additionalProperties, items, prefixItems, min/max items, length and
+// numeric bounds, pattern, allOf/anyOf/oneOf/not and local $ref pointers into
+// $defs or definitions. Unknown keywords are ignored.
+func ValidateJSONSchema(schema map[string]any, value any) []string {
+	// Schemas built in Go may use []string, ints or nested structs; decode them
+	// through JSON so the validator only deals with JSON shapes.
+	normalized, ok := normalizeJSONValue(schema).(map[string]any)
+	if !ok {
+		normalized = schema
+	}
+	v := &schemaValidator{root: normalized}
+	v.validate(normalized, normalizeJSONValue(value), "$", 0)
+	return v.errs
+}
+
+// maxSchemaDepth bounds $ref recursion on self-referencing schemas.
+const maxSchemaDepth = 64
+
+type schemaValidator struct {
+	root map[string]any
+	errs []string
+}
+
+func (v *schemaValidator) fail(path, format string, args ...any) {
+	v.errs = append(v.errs, path+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *schemaValidator) validate(schema map[string]any, value any, path string, depth int) {
+	if schema == nil {
+		return
+	}
+	if depth > maxSchemaDepth {
+		v.fail(path, "schema nesting too deep")
+		return
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := v.resolveRef(ref)
+		if err != nil {
+			v.fail(path, "%v", err)
+			return
+		}
+		v.validate(target, value, path, depth+1)
+	}
+
+	if t, ok := schema["type"]; ok && !matchesSchemaType(t, value) {
+		v.fail(path, "expected %s, got %s", describeSchemaType(t), jsonTypeName(value))
+		return
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "value %s is not one of %s", compactJSON(value), compactJSON(enum))
+		}
+	}
+	if c, ok := schema["const"]; ok && !jsonEqual(c, value) {
+		v.fail(path, "value must be %s", compactJSON(c))
+	}
+
+	switch x := value.(type) {
+	case map[string]any:
+		v.validateObject(schema, x, path, depth)
+	case []any:
+		v.validateArray(schema, x, path, depth)
+	case string:
+		n := utf8.RuneCountInString(x)
+		if m, ok := schemaNumber(schema["minLength"]); ok && float64(n) < m {
+			v.fail(path, "string shorter than %v", m)
+		}
+		if m, ok := schemaNumber(schema["maxLength"]); ok && float64(n) > m {
+			v.fail(path, "string longer than %v", m)
+		}
+		if p, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(p); err == nil && !re.MatchString(x) {
+				v.fail(path, "string does not match pattern %q", p)
+			}
+		}
+	case float64:
+		if m, ok := schemaNumber(schema["minimum"]); ok && x < m {
+			v.fail(path, "%v is less than minimum %v", x, m)
+		}
+		if m, ok := schemaNumber(schema["maximum"]); ok && x > m {
+			v.fail(path, "%v is greater than maximum %v", x, m)
+		}
+		if m, ok := schemaNumber(schema["exclusiveMinimum"]); ok && x <= m {
+			v.fail(path, "%v must be greater than %v", x, m)
+		}
+		if m, ok := schemaNumber(schema["exclusiveMaximum"]); ok && x >= m {
+			v.fail(path, "%v must be less than %v", x, m)
+		}
+	}
+
+	v.validateCombinators(schema, value, path, depth)
+}
+
+func (v *schemaValidator) validateObject(schema map[string]any, obj map[string]any, path string, depth int) {
+	props, _ := schema["properties"].(map[string]any)
+	if req, ok := schema["required"].([]any); ok {
+		for _, r := range req {
+			name, _ := r.(string)
+			if _, present := obj[name]; name != "" && !present {
+				v.fail(path, "missing required property %q", name)
+			}
+		}
+	}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		childPath := path + "." + k
+		if ps, ok := props[k].(map[string]any); ok {
+			v.validate(ps, obj[k], childPath, depth+1)
+			continue
+		}
+		if _, ok := props[k]; ok {
+			continue
+		}
+		switch ap := schema["additionalProperties"].(type) {
+		case bool:
+			if !ap {
+				v.fail(path, "unexpected property %q", k)
+			}
+		case map[string]any:
+			v.validate(ap, obj[k], childPath, depth+1)
+		}
+	}
+	if m, ok := schemaNumber(schema["minProperties"]); ok && float64(len(obj)) < m {
+		v.fail(path, "object has fewer than %v properties", m)
+	}
+	if m, ok := schemaNumber(schema["maxProperties"]); ok && float64(len(obj)) > m {
+		v.fail(path, "object has more than %v properties", m)
+	}
+}
+
+func (v *schemaValidator) validateArray(schema map[string]any, arr []any, path string, depth int) {
+	if m, ok := schemaNumber(schema["minItems"]); ok && float64(len(arr)) < m {
+		v.fail(path, "array has fewer than %v items", m)
+	}
+	if m, ok := schemaNumber(schema["maxItems"]); ok && float64(len(arr)) > m {
+		v.fail(path, "array has more than %v items", m)
+	}
+	prefix, _ := schema["prefixItems"].([]any)
+	for i, item := range arr {
+		childPath := fmt.Sprintf("%s[%d]", path, i)
+		if i < len(prefix) {
+			if ps, ok := prefix[i].(map[string]any); ok {
+				v.validate(ps, item, childPath, depth+1)
+			}
+			continue
+		}
+		if is, ok := schema["items"].(map[string]any); ok {
+			v.validate(is, item, childPath, depth+1)
+		}
+	}
+	if unique, _ := schema["uniqueItems"].(bool); unique {
+		for i := range arr {
+			for j := i + 1; j < len(arr); j++ {
+				if jsonEqual(arr[i], arr[j]) {
+					v.fail(path, "items %d and %d are equal", i, j)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (v *schemaValidator) validateCombinators(schema map[string]any, value any, path string, depth int) {
+	if all, ok := schema["allOf"].([]any); ok {
+		for _, s := range all {
+			if sub, ok := s.(map[string]any); ok {
+				v.validate(sub, value, path, depth+1)
+			}
+		}
+	}
+	count := func(list []any) (int, []string) {
+		matched := 0
+		var firstErrs []string
+		for _, s := range list {
+			sub, ok := s.(map[string]any)
+			if !ok {
+				continue
+			}
+			inner := &schemaValidator{root: v.root}
+			inner.validate(sub, value, path, depth+1)
+			if len(inner.errs) == 0 {
+				matched++
+			} else if firstErrs == nil {
+				firstErrs = inner.errs
+			}
+		}
+		return matched, firstErrs
+	}
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		if matched, errs := count(anyOf); matched == 0 {
+			v.fail(path, "value matches none of anyOf (%s)", strings.Join(errs, "; "))
+		}
+	}
+	if oneOf, ok := schema["oneOf"].([]any); ok {
+		if matched, errs := count(oneOf); matched != 1 {
+			if matched == 0 {
+				v.fail(path, "value matches none of oneOf (%s)", strings.Join(errs, "; "))
+			} else {
+				v.fail(path, "value matches %d schemas of oneOf, expected exactly one", matched)
+			}
+		}
+	}
+	if not, ok := schema["not"].(map[string]any); ok {
+		inner := &schemaValidator{root: v.root}
+		inner.validate(not, value, path, depth+1)
+		if len(inner.errs) == 0 {
+			v.fail(path, "value must not match the \"not\" schema")
+		}
+	}
+}
+
+func (v *schemaValidator) resolveRef(ref string) (map[string]any, error) {
+	if ref == "#" {
+		return v.root, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q", ref)
+	}
+	var cur any = v.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+		if cur, ok = m[part]; !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+	}
+	target, ok := cur.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("$ref %q does not point to a schema", ref)
+	}
+	return target, nil
+}
+
+func matchesSchemaType(t any, value any) bool {
+	switch tt := t.(type) {
+	case string:
+		return matchesSingleType(tt, value)
+	case []any:
+		for _, x := range tt {
+			if s, ok := x.(string); ok && matchesSingleType(s, value) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+func matchesSingleType(t string, value any) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f) && !math.IsInf(f, 0)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	default:
+		return true
+	}
+}
+
+func describeSchemaType(t any) string {
+	switch tt := t.(type) {
+	case string:
+		return tt
+	case []any:
+		parts := make([]string, 0, len(tt))
+		for _, x := range tt {
+			parts = append(parts, fmt.Sprint(x))
+		}
+		return strings.Join(parts, " or ")
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+func jsonTypeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func schemaNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+// jsonEqual compares two values after normalizing them through JSON, so that
+// schema literals written as Go ints compare equal to decoded float64 values.
+func jsonEqual(a, b any) bool {
+	return reflect.DeepEqual(normalizeJSONValue(a), normalizeJSONValue(b))
+}
+
+func normalizeJSONValue(v any) any {
+	switch v.(type) {
+	case nil, bool, string, float64:
+		return v
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+func compactJSON(v any) string {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(raw)
+}
@@ -1,6 +1,14 @@
 package engine
 
-import "testing"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/turns"
+)
 
 func TestStructuredOutputConfigValidate(t *testing.T) {
 	cfg := StructuredOutputConfig{
@@ -33,3 +41,137 @@ func TestResolveStructuredOutputConfigPrefersTurnOverride(t *testing.T) {
 		t.Fatalf("expected turn override config to win")
 	}
 }
+
+func personSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{"type": "string", "minLength": 1},
+			"age":  map[string]any{"type": "integer", "minimum": 0},
+			"tags": map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/tag"}},
+		},
+		"required":             []string{"name", "age"},
+		"additionalProperties": false,
+		"$defs": map[string]any{
+			"tag": map[string]any{"type": "string", "enum": []string{"a", "b"}},
+		},
+	}
+}
+
+func TestValidateJSONSchema(t *testing.T) {
+	var valid any
+	if err := json.Unmarshal([]byte(`{"name":"Ada","age":36,"tags":["a"]}`), &valid); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ValidateJSONSchema(personSchema(), valid); len(errs) != 0 {
+		t.Fatalf("expected valid, got %v", errs)
+	}
+
+	var invalid any
+	if err := json.Unmarshal([]byte(`{"name":"","age":1.5,"tags":["c"],"extra":true}`), &invalid); err != nil {
+		t.Fatal(err)
+	}
+	errs := strings.Join(ValidateJSONSchema(personSchema(), invalid), "\n")
+	for _, want := range []string{"$.name: string shorter", "$.age: expected integer", "$.tags[0]: value \"c\"", `unexpected property "extra"`} {
+		if !strings.Contains(errs, want) {
+			t.Fatalf("expected %q in violations:\n%s", want, errs)
+		}
+	}
+	if errs := ValidateJSONSchema(personSchema(), map[string]any{}); len(errs) != 2 {
+		t.Fatalf("expected two missing required properties, got %v", errs)
+	}
+}
+
+func TestParseStructuredOutputTextToleratesFencesAndProse(t *testing.T) {
+	for _, in := range []string{
+		"```json\n{\"a\":1}\n```",
+		"Here you go: {\"a\":1} Let me know.",
+		" {\"a\":1} ",
+	} {
+		v, err := ParseStructuredOutputText(in)
+		if err != nil {
+			t.Fatalf("parse %q: %v", in, err)
+		}
+		if m, ok := v.(map[string]any); !ok || m["a"] != float64(1) {
+			t.Fatalf("unexpected value %#v for %q", v, in)
+		}
+	}
+	if _, err := ParseStructuredOutputText("no json here"); err == nil {
+		t.Fatalf("expected parse error")
+	}
+}
+
+func scriptedRun(replies ...string) (func(context.Context, *turns.Turn) (*turns.Turn, error), *[]*turns.Turn) {
+	var seen []*turns.Turn
+	i := 0
+	return func(_ context.Context, t *turns.Turn) (*turns.Turn, error) {
+		seen = append(seen, t.Clone())
+		turns.AppendBlock(t, turns.NewAssistantTextBlock(replies[i]))
+		i++
+		return t, nil
+	}, &seen
+}
+
+func TestProcessStructuredOutputRepairsInvalidOutput(t *testing.T) {
+	cfg := StructuredOutputConfig{Mode: StructuredOutputModeJSONSchema, Name: "person", Schema: personSchema()}
+	run, seen := scriptedRun(`{"name":"Ada"}`, `{"name":"Ada","age":36}`)
+	in := &turns.Turn{}
+	turns.AppendBlock(in, turns.NewUserTextBlock("who?"))
+
+	out, err := ProcessStructuredOutput(context.Background(), run, in, cfg, StructuredOutputRepairOptions{MaxRepairAttempts: 2})
+	if err != nil {
+		t.Fatalf("ProcessStructuredOutput: %v", err)
+	}
+	if len(*seen) != 2 {
+		t.Fatalf("expected one repair call, got %d calls", len(*seen))
+	}
+	repair := (*seen)[1].Blocks[len((*seen)[1].Blocks)-1]
+	text, _ := repair.Payload[turns.PayloadKeyText].(string)
+	if repair.Kind != turns.BlockKindUser || !strings.Contains(text, `missing required property "age"`) {
+		t.Fatalf("unexpected repair prompt %+v", repair)
+	}
+	if tag, ok, _ := turns.KeyBlockMetaMiddleware.Get(repair.Metadata); !ok || tag != StructuredOutputRepairTag {
+		t.Fatalf("expected repair prompt to be tagged, got %q", tag)
+	}
+	res, ok, err := KeyStructuredOutputResult.Get(out.Data)
+	if err != nil || !ok {
+		t.Fatalf("expected structured output result, ok=%v err=%v", ok, err)
+	}
+	if !res.Valid || res.RepairAttempts != 1 || res.Value.(map[string]any)["age"] != float64(36) {
+		t.Fatalf("unexpected result %+v", res)
+	}
+}
+
+func TestProcessStructuredOutputRequireValid(t *testing.T) {
+	cfg := StructuredOutputConfig{Mode: StructuredOutputModeJSONSchema, Name: "person", Schema: personSchema(), RequireValid: true}
+	run, _ := scriptedRun("not json", `{"name":1}`)
+	out, err := ProcessStructuredOutput(context.Background(), run, &turns.Turn{}, cfg, StructuredOutputRepairOptions{MaxRepairAttempts: 1})
+	var soErr *StructuredOutputError
+	if !errors.Is(err, ErrStructuredOutputInvalid) || !errors.As(err, &soErr) || soErr.RepairAttempts != 1 {
+		t.Fatalf("expected structured output error, got %v", err)
+	}
+	if res, ok, _ := KeyStructuredOutputResult.Get(out.Data); !ok || res.Valid || len(res.Errors) == 0 {
+		t.Fatalf("expected invalid result to be recorded, got %+v", res)
+	}
+
+	cfg.RequireValid = false
+	run, _ = scriptedRun("not json")
+	if _, err := ProcessStructuredOutput(context.Background(), run, &turns.Turn{}, cfg, StructuredOutputRepairOptions{}); err != nil {
+		t.Fatalf("expected no error without RequireValid, got %v", err)
+	}
+}
+
+func TestProcessStructuredOutputSkipsToolCalls(t *testing.T) {
+	cfg := StructuredOutputConfig{Mode: StructuredOutputModeJSONSchema, Name: "person", Schema: personSchema(), RequireValid: true}
+	run := func(_ context.Context, t *turns.Turn) (*turns.Turn, error) {
+		turns.AppendBlock(t, turns.NewToolCallBlock("c1", "lookup", map[string]any{}))
+		return t, nil
+	}
+	out, err := ProcessStructuredOutput(context.Background(), run, &turns.Turn{}, cfg, StructuredOutputRepairOptions{MaxRepairAttempts: 3})
+	if err != nil {
+		t.Fatalf("expected tool call response to pass through, got %v", err)
+	}
+	if _, ok, _ := KeyStructuredOutputResult.Get(out.Data); ok {
+		t.Fatalf("expected no result for tool call response")
+	}
+}
@@ -0,0 +1,255 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/pkg/errors"
+)
+
+// StructuredOutputRepairTag is the turns.KeyBlockMetaMiddleware value of repair
+// prompts appended by ProcessStructuredOutput.
+const StructuredOutputRepairTag = "structuredoutput"
+
+// DefaultStructuredOutputRepairPrompt introduces the validation errors sent
+// back to the model on a repair attempt.
+const DefaultStructuredOutputRepairPrompt = "Your previous response did not match the required JSON schema. Fix the following problems and respond again with only the corrected JSON value, without code fences or commentary:"
+
+// ErrStructuredOutputInvalid matches every *StructuredOutputError with errors.Is.
+var ErrStructuredOutputInvalid = errors.New("structured output does not match schema")
+
+// ErrStructuredOutputUnsupported is wrapped by engines that reject a
+// structured output config when RequireValid is set, for example a schema the
+// provider cannot express.
+var ErrStructuredOutputUnsupported = errors.New("structured output config is not supported")
+
+// StructuredOutputResult is stored under KeyStructuredOutputResult after the
+// final llm_text block was checked against the structured output schema.
+type StructuredOutputResult struct {
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Value is the decoded JSON value. It is set even when Valid is false, as
+	// long as the text parsed as JSON.
+	Value any  `json:"value,omitempty" yaml:"value,omitempty"`
+	Valid bool `json:"valid" yaml:"valid"`
+	// Errors lists parse or schema violations of the last attempt.
+	Errors []string `json:"errors,omitempty" yaml:"errors,omitempty"`
+	// RepairAttempts is the number of re-prompts that were needed.
+	RepairAttempts int `json:"repair_attempts,omitempty" yaml:"repair_attempts,omitempty"`
+}
+
+// StructuredOutputError is returned when the output is still invalid after all
+// repair attempts and the config sets RequireValid.
+type StructuredOutputError struct {
+	Name           string
+	Violations     []string
+	RepairAttempts int
+}
+
+func (e *StructuredOutputError) Error() string {
+	return fmt.Sprintf("structured output %q invalid after %d repair attempts: %s", e.Name, e.RepairAttempts, strings.Join(e.Violations, "; "))
+}
+
+// Is makes errors.Is(err, ErrStructuredOutputInvalid) succeed.
+func (e *StructuredOutputError) Is(target error) bool { return target == ErrStructuredOutputInvalid }
+
+// StructuredOutputRepairOptions controls ProcessStructuredOutput.
+type StructuredOutputRepairOptions struct {
+	// MaxRepairAttempts is the number of times the model is re-prompted with
+	// the validation errors. Zero only validates.
+	MaxRepairAttempts int
+	// RepairPrompt replaces DefaultStructuredOutputRepairPrompt.
+	RepairPrompt string
+}
+
+// ParseStructuredOutputText decodes a JSON value from model text. Markdown code
+// fences and prose around a single top-level object or array are tolerated.
+func ParseStructuredOutputText(text string) (any, error) {
+	s := strings.TrimSpace(text)
+	if strings.HasPrefix(s, "```") {
+		s = strings.TrimPrefix(s, "```")
+		if nl := strings.IndexByte(s, '\n'); nl >= 0 {
+			s = s[nl+1:]
+		}
+		s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "```"))
+	}
+	if s == "" {
+		return nil, errors.New("response is empty")
+	}
+	var v any
+	err := json.Unmarshal([]byte(s), &v)
+	if err == nil {
+		return v, nil
+	}
+	start := strings.IndexAny(s, "{[")
+	if start >= 0 {
+		closer := "}"
+		if s[start] == '[' {
+			closer = "]"
+		}
+		if end := strings.LastIndex(s, closer); end > start {
+			if json.Unmarshal([]byte(s[start:end+1]), &v) == nil {
+				return v, nil
+			}
+		}
+	}
+	return nil, errors.Wrap(err, "response is not valid JSON")
+}
+
+// CheckStructuredOutput parses the last llm_text block of t and validates it
+// against cfg.Schema.
+func CheckStructuredOutput(t *turns.Turn, cfg StructuredOutputConfig) StructuredOutputResult {
+	res := StructuredOutputResult{Name: cfg.Name}
+	text, ok := lastAssistantText(t)
+	if !ok {
+		res.Errors = []string{"no assistant text in response"}
+		return res
+	}
+	v, err := ParseStructuredOutputText(text)
+	if err != nil {
+		res.Errors = []string{err.Error()}
+		return res
+	}
+	res.Value = v
+	res.Errors = ValidateJSONSchema(cfg.Schema, v)
+	res.Valid = len(res.Errors) == 0
+	return res
+}
+
+// ProcessStructuredOutput runs one inference through run and checks the final
+// llm_text block against the structured output schema. Invalid output is sent
+// back to the model together with the validation errors, up to
+// opts.MaxRepairAttempts times. The outcome is stored under
+// KeyStructuredOutputResult on the returned turn.
+//
+// Responses that end in pending tool calls are returned unchanged, so the
+// function can wrap every provider call of a tool loop. When the output stays
+// invalid, a *StructuredOutputError is returned if cfg.RequireValid is set.
+func ProcessStructuredOutput(
+	ctx context.Context,
+	run func(context.Context, *turns.Turn) (*turns.Turn, error),
+	t *turns.Turn,
+	cfg StructuredOutputConfig,
+	opts StructuredOutputRepairOptions,
+) (*turns.Turn, error) {
+	if run == nil {
+		return t, ErrEngineNil
+	}
+	if !cfg.IsEnabled() {
+		return run(ctx, t)
+	}
+	if err := cfg.Validate(); err != nil {
+		return t, errors.Wrap(err, "structured output")
+	}
+	prompt := opts.RepairPrompt
+	if strings.TrimSpace(prompt) == "" {
+		prompt = DefaultStructuredOutputRepairPrompt
+	}
+
+	cur := t
+	for attempt := 0; ; attempt++ {
+		out, err := run(ctx, cur)
+		if err != nil {
+			return out, err
+		}
+		if out == nil {
+			out = cur
+		}
+		if hasPendingToolCalls(out) {
+			return out, nil
+		}
+		res := CheckStructuredOutput(out, cfg)
+		res.RepairAttempts = attempt
+		if res.Valid || attempt >= opts.MaxRepairAttempts {
+			if setErr := KeyStructuredOutputResult.Set(&out.Data, res); setErr != nil {
+				return out, errors.Wrap(setErr, "set structured output result")
+			}
+			if !res.Valid {
+				log.Warn().Str("schema", cfg.Name).Int("repair_attempts", attempt).Strs("errors", res.Errors).Msg("structured output: response does not match schema")
+				if cfg.RequireValid {
+					return out, &StructuredOutputError{Name: cfg.Name, Violations: res.Errors, RepairAttempts: attempt}
+				}
+			}
+			return out, nil
+		}
+
+		log.Debug().Str("schema", cfg.Name).Int("attempt", attempt+1).Strs("errors", res.Errors).Msg("structured output: requesting repair")
+		repair := turns.NewUserTextBlock(prompt + "\n- " + strings.Join(res.Errors, "\n- "))
+		if setErr := turns.KeyBlockMetaMiddleware.Set(&repair.Metadata, StructuredOutputRepairTag); setErr != nil {
+			return out, errors.Wrap(setErr, "tag repair prompt")
+		}
+		turns.AppendBlock(out, repair)
+		cur = out
+	}
+}
+
+// RunInferenceWithStructuredOutput is RunInferenceWithResult with structured
+// output validation and repair. The config is read from
+// KeyStructuredOutputConfig on the turn, falling back to defaultCfg.
+func RunInferenceWithStructuredOutput(
+	ctx context.Context,
+	eng Engine,
+	t *turns.Turn,
+	defaultCfg *StructuredOutputConfig,
+	opts StructuredOutputRepairOptions,
+) (*turns.Turn, *InferenceResult, error) {
+	if eng == nil {
+		return nil, nil, ErrEngineNil
+	}
+	cfg := defaultCfg
+	if t != nil {
+		if override, ok, err := KeyStructuredOutputConfig.Get(t.Data); err == nil && ok {
+			cfg = ResolveStructuredOutputConfig(defaultCfg, &override)
+		}
+	}
+	if cfg == nil || !cfg.IsEnabled() {
+		return RunInferenceWithResult(ctx, eng, t)
+	}
+
+	var last *InferenceResult
+	run := func(ctx context.Context, t *turns.Turn) (*turns.Turn, error) {
+		out, res, err := RunInferenceWithResult(ctx, eng, t)
+		last = res
+		return out, err
+	}
+	out, err := ProcessStructuredOutput(ctx, run, t, *cfg, opts)
+	return out, last, err
+}
+
+func lastAssistantText(t *turns.Turn) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+	for i := len(t.Blocks) - 1; i >= 0; i-- {
+		b := t.Blocks[i]
+		if b.Kind == turns.BlockKindUser {
+			return "", false
+		}
+		if b.Kind != turns.BlockKindLLMText {
+			continue
+		}
+		if text, ok := b.Payload[turns.PayloadKeyText].(string); ok {
+			return text, true
+		}
+	}
+	return "", false
+}
+
+func hasPendingToolCalls(t *turns.Turn) bool {
+	if t == nil {
+		return false
+	}
+	for i := len(t.Blocks) - 1; i >= 0; i-- {
+		switch t.Blocks[i].Kind {
+		case turns.BlockKindToolCall:
+			return true
+		case turns.BlockKindLLMText, turns.BlockKindReasoning, turns.BlockKindOther:
+			continue
+		case turns.BlockKindUser, turns.BlockKindToolUse, turns.BlockKindSystem:
+			return false
+		}
+	}
+	return false
+}
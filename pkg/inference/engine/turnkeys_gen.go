@@ -9,6 +9,7 @@ var (
 	KeyToolConfig             = turns.DataK[ToolConfig](turns.GeppettoNamespaceKey, turns.ToolConfigValueKey, 1)
 	KeyToolDefinitions        = turns.DataK[ToolDefinitions](turns.GeppettoNamespaceKey, turns.ToolDefinitionsValueKey, 1)
 	KeyStructuredOutputConfig = turns.DataK[StructuredOutputConfig](turns.GeppettoNamespaceKey, turns.StructuredOutputConfigValueKey, 1)
+	KeyStructuredOutputResult = turns.DataK[StructuredOutputResult](turns.GeppettoNamespaceKey, turns.StructuredOutputResultValueKey, 1)
 	KeyInferenceConfig        = turns.DataK[InferenceConfig](turns.GeppettoNamespaceKey, turns.InferenceConfigValueKey, 1)
 	KeyClaudeInferenceConfig  = turns.DataK[ClaudeInferenceConfig](turns.GeppettoNamespaceKey, turns.ClaudeInferenceConfigValueKey, 1)
 	KeyOpenAIInferenceConfig  = turns.DataK[OpenAIInferenceConfig](turns.GeppettoNamespaceKey, turns.OpenAIInferenceConfigValueKey, 1)
@@ -0,0 +1,309 @@
+// Package extract runs inference that returns a typed Go value. The JSON
+// schema is derived from the Go type with the same reflector that
+// tools.NewToolFromFunc uses, and enforced either through provider-native
+// structured output or through a forced tool call.
+package extract
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/inference/tools"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/go-go-golems/geppetto/pkg/turns/toolblocks"
+	"github.com/pkg/errors"
+)
+
+// Mode selects how the schema is enforced.
+type Mode string
+
+const (
+	// ModeNative uses the provider's JSON-schema output mode
+	// (engine.KeyStructuredOutputConfig) with validation and repair.
+	ModeNative Mode = "native"
+	// ModeToolCall advertises a single tool whose parameters are the schema and
+	// requires the model to call it. Use it for providers without a native
+	// JSON-schema mode.
+	ModeToolCall Mode = "tool_call"
+	// ModeAuto tries ModeNative and falls back to ModeToolCall when the engine
+	// rejects the schema or the output still does not match it after the
+	// repair attempts.
+	ModeAuto Mode = "auto"
+)
+
+// BlockMetaTag is the turns.KeyBlockMetaMiddleware value of blocks added by
+// the tool-call mode.
+const BlockMetaTag = "extract"
+
+// DefaultToolInstruction is appended as a user block in tool-call mode.
+const DefaultToolInstruction = "Respond by calling the %s tool exactly once with the complete result as its arguments."
+
+// ErrNoResult is returned when the model produced neither valid JSON nor a
+// call to the extraction tool.
+var ErrNoResult = errors.New("extract: model returned no structured result")
+
+type options struct {
+	name              string
+	description       string
+	mode              Mode
+	maxRepairAttempts int
+	strict            bool
+	toolName          string
+}
+
+// Option configures Extract.
+type Option func(*options)
+
+// WithName sets the schema name sent to the provider. Defaults to "result".
+func WithName(name string) Option {
+	return func(o *options) { o.name = name }
+}
+
+// WithDescription sets the schema (and tool) description.
+func WithDescription(description string) Option {
+	return func(o *options) { o.description = description }
+}
+
+// WithMode selects native, tool-call or auto mode. Defaults to ModeAuto.
+func WithMode(mode Mode) Option {
+	return func(o *options) { o.mode = mode }
+}
+
+// WithMaxRepairAttempts sets how often invalid output is sent back to the
+// model with the validation errors. Defaults to 1.
+func WithMaxRepairAttempts(n int) Option {
+	return func(o *options) { o.maxRepairAttempts = n }
+}
+
+// WithStrict requests provider-side strict schema adherence in native mode.
+// It is off by default because strict modes reject schemas with optional
+// fields; responses are validated locally either way.
+func WithStrict(strict bool) Option {
+	return func(o *options) { o.strict = strict }
+}
+
+// WithToolName overrides the tool name used in tool-call mode. Defaults to
+// "submit_<name>".
+func WithToolName(name string) Option {
+	return func(o *options) { o.toolName = name }
+}
+
+// SchemaFor returns the JSON schema of T as used by Extract. T must reflect to
+// a JSON object (a struct or a map).
+func SchemaFor[T any]() (map[string]any, error) {
+	def, err := toolDefinitionFor[T]("schema", "")
+	if err != nil {
+		return nil, err
+	}
+	return schemaMap(def)
+}
+
+// Prompt runs Extract on a new turn containing a single user prompt.
+func Prompt[T any](ctx context.Context, eng engine.Engine, prompt string, opts ...Option) (T, *engine.InferenceResult, error) {
+	t := &turns.Turn{}
+	turns.AppendBlock(t, turns.NewUserTextBlock(prompt))
+	return Extract[T](ctx, eng, t, opts...)
+}
+
+// Extract runs inference on a copy of t and decodes the model's answer into a
+// T. The input turn is not modified. The returned InferenceResult is the one
+// of the last provider call.
+func Extract[T any](ctx context.Context, eng engine.Engine, t *turns.Turn, opts ...Option) (T, *engine.InferenceResult, error) {
+	var zero T
+	if eng == nil {
+		return zero, nil, engine.ErrEngineNil
+	}
+	o := options{name: "result", mode: ModeAuto, maxRepairAttempts: 1}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	if strings.TrimSpace(o.toolName) == "" {
+		o.toolName = "submit_" + o.name
+	}
+	def, err := toolDefinitionFor[T](o.toolName, o.description)
+	if err != nil {
+		return zero, nil, err
+	}
+	schema, err := schemaMap(def)
+	if err != nil {
+		return zero, nil, err
+	}
+	if t == nil {
+		t = &turns.Turn{}
+	}
+
+	switch o.mode {
+	case ModeNative:
+		return extractNative[T](ctx, eng, t, schema, o)
+	case ModeToolCall:
+		return extractToolCall[T](ctx, eng, t, def, schema, o)
+	case ModeAuto, "":
+		v, res, err := extractNative[T](ctx, eng, t, schema, o)
+		if err == nil || (!errors.Is(err, engine.ErrStructuredOutputInvalid) && !errors.Is(err, engine.ErrStructuredOutputUnsupported)) {
+			return v, res, err
+		}
+		log.Debug().Err(err).Str("schema", o.name).Msg("extract: native structured output failed, retrying with a forced tool call")
+		return extractToolCall[T](ctx, eng, t, def, schema, o)
+	default:
+		return zero, nil, errors.Errorf("extract: unknown mode %q", o.mode)
+	}
+}
+
+func extractNative[T any](ctx context.Context, eng engine.Engine, t *turns.Turn, schema map[string]any, o options) (T, *engine.InferenceResult, error) {
+	var zero T
+	work := t.Clone()
+	strict := o.strict
+	cfg := engine.StructuredOutputConfig{
+		Mode:         engine.StructuredOutputModeJSONSchema,
+		Name:         o.name,
+		Description:  o.description,
+		Schema:       schema,
+		Strict:       &strict,
+		RequireValid: true,
+	}
+	if err := engine.KeyStructuredOutputConfig.Set(&work.Data, cfg); err != nil {
+		return zero, nil, errors.Wrap(err, "extract: set structured output config")
+	}
+	out, res, err := engine.RunInferenceWithStructuredOutput(ctx, eng, work, nil, engine.StructuredOutputRepairOptions{MaxRepairAttempts: o.maxRepairAttempts})
+	if err != nil {
+		return zero, res, err
+	}
+	so, ok, err := engine.KeyStructuredOutputResult.Get(out.Data)
+	if err != nil {
+		return zero, res, errors.Wrap(err, "extract: get structured output result")
+	}
+	if !ok {
+		// The response ended in a call to a tool from the context registry.
+		return zero, res, ErrNoResult
+	}
+	v, err := decode[T](so.Value)
+	return v, res, err
+}
+
+func extractToolCall[T any](ctx context.Context, eng engine.Engine, t *turns.Turn, def *tools.ToolDefinition, schema map[string]any, o options) (T, *engine.InferenceResult, error) {
+	var zero T
+	reg := tools.NewInMemoryToolRegistry()
+	if err := reg.RegisterTool(def.Name, *def); err != nil {
+		return zero, nil, errors.Wrap(err, "extract: register tool")
+	}
+	ctx = tools.WithRegistry(ctx, reg)
+
+	work := t.Clone()
+	if err := engine.KeyToolConfig.Set(&work.Data, engine.ToolConfig{
+		Enabled:          true,
+		ToolChoice:       engine.ToolChoiceRequired,
+		MaxIterations:    1,
+		MaxParallelTools: 1,
+	}); err != nil {
+		return zero, nil, errors.Wrap(err, "extract: set tool config")
+	}
+	if err := appendTagged(work, turns.NewUserTextBlock(fmt.Sprintf(DefaultToolInstruction, def.Name))); err != nil {
+		return zero, nil, err
+	}
+
+	var (
+		res     *engine.InferenceResult
+		lastErr error
+	)
+	for attempt := 0; attempt <= o.maxRepairAttempts; attempt++ {
+		out, r, err := engine.RunInferenceWithResult(ctx, eng, work)
+		res = r
+		if err != nil {
+			return zero, res, err
+		}
+		work = out
+
+		pending := toolblocks.ExtractPendingToolCalls(out)
+		var call *toolblocks.ToolCall
+		for i := range pending {
+			if pending[i].Name == def.Name {
+				call = &pending[i]
+				break
+			}
+		}
+		var value any
+		var violations []string
+		switch {
+		case call != nil:
+			value = map[string]any(call.Arguments)
+			violations = engine.ValidateJSONSchema(schema, value)
+		default:
+			// Some providers answer in text despite the tool choice.
+			so := engine.CheckStructuredOutput(out, engine.StructuredOutputConfig{Name: o.name, Schema: schema})
+			value, violations = so.Value, so.Errors
+		}
+		if len(violations) == 0 {
+			v, err := decode[T](value)
+			return v, res, err
+		}
+
+		lastErr = &engine.StructuredOutputError{Name: o.name, Violations: violations, RepairAttempts: attempt}
+		feedback := engine.DefaultStructuredOutputRepairPrompt + "\n- " + strings.Join(violations, "\n- ")
+		if call != nil {
+			// Answer every pending call so the transcript stays valid.
+			for _, c := range pending {
+				msg := "not executed"
+				if c.ID == call.ID {
+					msg = feedback
+				}
+				if err := appendTagged(work, turns.NewToolUseBlockWithError(c.ID, nil, msg)); err != nil {
+					return zero, res, err
+				}
+			}
+		} else if err := appendTagged(work, turns.NewUserTextBlock(feedback)); err != nil {
+			return zero, res, err
+		}
+	}
+	return zero, res, lastErr
+}
+
+func toolDefinitionFor[T any](name, description string) (*tools.ToolDefinition, error) {
+	def, err := tools.NewToolFromFunc(name, description, func(in T) (T, error) { return in, nil })
+	if err != nil {
+		return nil, errors.Wrap(err, "extract: derive schema")
+	}
+	if def.Parameters == nil || (def.Parameters.Type != "object" && def.Parameters.Ref == "") {
+		return nil, errors.Errorf("extract: %T must reflect to a JSON object schema", *new(T))
+	}
+	return def, nil
+}
+
+func schemaMap(def *tools.ToolDefinition) (map[string]any, error) {
+	raw, err := json.Marshal(def.Parameters)
+	if err != nil {
+		return nil, errors.Wrap(err, "extract: encode schema")
+	}
+	var m map[string]any
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, errors.Wrap(err, "extract: decode schema")
+	}
+	// Providers do not need the meta-schema and some reject it.
+	delete(m, "$schema")
+	delete(m, "$id")
+	return m, nil
+}
+
+func decode[T any](value any) (T, error) {
+	var out T
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return out, errors.Wrap(err, "extract: encode result")
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return out, errors.Wrapf(err, "extract: decode result into %T", out)
+	}
+	return out, nil
+}
+
+func appendTagged(t *turns.Turn, b turns.Block) error {
+	if err := turns.KeyBlockMetaMiddleware.Set(&b.Metadata, BlockMetaTag); err != nil {
+		return errors.Wrap(err, "extract: tag block")
+	}
+	turns.AppendBlock(t, b)
+	return nil
+}
@@ -0,0 +1,143 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/inference/tools"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+)
+
+type person struct {
+	Name string   `json:"name" jsonschema:"required"`
+	Age  int      `json:"age" jsonschema:"required"`
+	Tags []string `json:"tags,omitempty"`
+}
+
+// scriptedEngine answers with text replies, or with a tool call carrying
+// toolArgs when a tool registry is present in the context.
+type scriptedEngine struct {
+	texts    []string
+	toolArgs []map[string]any
+	calls    int
+	sawTools bool
+	sawCfg   *engine.StructuredOutputConfig
+}
+
+func (e *scriptedEngine) RunInference(ctx context.Context, t *turns.Turn) (*turns.Turn, error) {
+	e.calls++
+	if cfg, ok, _ := engine.KeyStructuredOutputConfig.Get(t.Data); ok {
+		e.sawCfg = &cfg
+	}
+	if reg, ok := tools.RegistryFrom(ctx); ok && len(e.toolArgs) > 0 {
+		e.sawTools = true
+		name := reg.ListTools()[0].Name
+		args := e.toolArgs[0]
+		e.toolArgs = e.toolArgs[1:]
+		turns.AppendBlock(t, turns.NewToolCallBlock(fmt.Sprintf("call-%d", e.calls), name, args))
+		return t, nil
+	}
+	text := e.texts[0]
+	e.texts = e.texts[1:]
+	turns.AppendBlock(t, turns.NewAssistantTextBlock(text))
+	return t, nil
+}
+
+func TestSchemaFor(t *testing.T) {
+	schema, err := SchemaFor[person]()
+	if err != nil {
+		t.Fatalf("SchemaFor: %v", err)
+	}
+	if schema["type"] != "object" || schema["$schema"] != nil {
+		t.Fatalf("unexpected schema %v", schema)
+	}
+	props, _ := schema["properties"].(map[string]any)
+	if _, ok := props["age"]; !ok {
+		t.Fatalf("expected age property, got %v", props)
+	}
+	if _, err := SchemaFor[[]string](); err == nil {
+		t.Fatalf("expected non-object type to be rejected")
+	}
+}
+
+func TestExtractNative(t *testing.T) {
+	eng := &scriptedEngine{texts: []string{`{"name":"Ada"}`, "```json\n{\"name\":\"Ada\",\"age\":36}\n```"}}
+	in := &turns.Turn{}
+	turns.AppendBlock(in, turns.NewUserTextBlock("Ada is 36"))
+
+	got, res, err := Extract[person](context.Background(), eng, in, WithName("person"), WithMode(ModeNative))
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if got.Name != "Ada" || got.Age != 36 || res == nil {
+		t.Fatalf("unexpected result %+v %+v", got, res)
+	}
+	if eng.calls != 2 || eng.sawCfg == nil || eng.sawCfg.Name != "person" || eng.sawCfg.StrictOrDefault() {
+		t.Fatalf("expected a repaired native call with non-strict config, calls=%d cfg=%+v", eng.calls, eng.sawCfg)
+	}
+	if len(in.Blocks) != 1 {
+		t.Fatalf("input turn must not be modified")
+	}
+}
+
+func TestExtractAutoFallsBackToToolCall(t *testing.T) {
+	eng := &scriptedEngine{
+		texts:    []string{"Ada, 36", "still prose"},
+		toolArgs: []map[string]any{{"name": "Ada"}, {"name": "Ada", "age": float64(36)}},
+	}
+	got, _, err := Prompt[person](context.Background(), eng, "Ada is 36", WithName("person"))
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if !eng.sawTools || got.Age != 36 {
+		t.Fatalf("expected tool call fallback, got %+v sawTools=%v", got, eng.sawTools)
+	}
+	if eng.calls != 4 {
+		t.Fatalf("expected 2 native and 2 tool-call attempts, got %d", eng.calls)
+	}
+}
+
+// rejectingEngine fails every native structured output request like an engine
+// whose request builder cannot express the schema, and answers tool-call
+// requests through scriptedEngine.
+type rejectingEngine struct {
+	scriptedEngine
+}
+
+func (e *rejectingEngine) RunInference(ctx context.Context, t *turns.Turn) (*turns.Turn, error) {
+	if _, ok := tools.RegistryFrom(ctx); !ok {
+		e.calls++
+		return nil, fmt.Errorf("gemini structured output: %w: $.tags: unsupported schema keywords: oneOf", engine.ErrStructuredOutputUnsupported)
+	}
+	return e.scriptedEngine.RunInference(ctx, t)
+}
+
+func TestExtractAutoFallsBackWhenSchemaIsRejected(t *testing.T) {
+	eng := &rejectingEngine{scriptedEngine{toolArgs: []map[string]any{{"name": "Ada", "age": float64(36)}}}}
+	got, _, err := Prompt[person](context.Background(), eng, "Ada is 36", WithName("person"))
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if !eng.sawTools || got.Name != "Ada" || got.Age != 36 {
+		t.Fatalf("expected tool call fallback, got %+v sawTools=%v", got, eng.sawTools)
+	}
+	if eng.calls != 2 {
+		t.Fatalf("expected 1 rejected native and 1 tool-call attempt, got %d", eng.calls)
+	}
+
+	native := &rejectingEngine{}
+	if _, _, err := Prompt[person](context.Background(), native, "?", WithMode(ModeNative)); !errors.Is(err, engine.ErrStructuredOutputUnsupported) {
+		t.Fatalf("native mode must surface the rejection, got %v", err)
+	}
+}
+
+func TestExtractToolCallGivesUp(t *testing.T) {
+	eng := &scriptedEngine{toolArgs: []map[string]any{{"name": 1}}}
+	_, _, err := Prompt[person](context.Background(), eng, "?", WithMode(ModeToolCall), WithMaxRepairAttempts(0))
+	if !errors.Is(err, engine.ErrStructuredOutputInvalid) {
+		t.Fatalf("expected structured output error, got %v", err)
+	}
+}
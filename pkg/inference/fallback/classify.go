@@ -0,0 +1,82 @@
+package fallback
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrorClass names why a provider attempt failed, for fallback decisions and audit.
+type ErrorClass string
+
+const (
+	ErrorClassNone          ErrorClass = ""
+	ErrorClassRateLimited   ErrorClass = "rate_limited"
+	ErrorClassOverloaded    ErrorClass = "overloaded"
+	ErrorClassServerError   ErrorClass = "server_error"
+	ErrorClassContextLength ErrorClass = "context_length"
+	ErrorClassTimeout       ErrorClass = "timeout"
+	ErrorClassFatal         ErrorClass = "fatal"
+)
+
+// Classifier maps a provider error to an ErrorClass and whether the next
+// candidate should be tried.
+type Classifier func(err error) (ErrorClass, bool)
+
+var statusPattern = regexp.MustCompile(`(?i)status(?:\s*code)?[=:\s]+(\d{3})`)
+
+// ClassifyError is the default Classifier. Provider engines report HTTP
+// failures as "status=NNN ..." strings, so classification combines the status
+// code with well-known provider error phrases.
+func ClassifyError(err error) (ErrorClass, bool) {
+	if err == nil {
+		return ErrorClassNone, false
+	}
+	if errors.Is(err, context.Canceled) {
+		return ErrorClassFatal, false
+	}
+	msg := strings.ToLower(err.Error())
+
+	switch {
+	case containsAny(msg, "context_length_exceeded", "context length", "context window", "maximum context", "prompt is too long", "too many tokens", "input is too long"):
+		return ErrorClassContextLength, true
+	case containsAny(msg, "overloaded"):
+		return ErrorClassOverloaded, true
+	case containsAny(msg, "rate limit", "rate_limit", "too many requests", "resource_exhausted", "quota"):
+		return ErrorClassRateLimited, true
+	}
+
+	if m := statusPattern.FindStringSubmatch(msg); m != nil {
+		code, _ := strconv.Atoi(m[1])
+		switch {
+		case code == 429:
+			return ErrorClassRateLimited, true
+		case code == 529:
+			return ErrorClassOverloaded, true
+		case code == 408:
+			return ErrorClassTimeout, true
+		case code >= 500:
+			return ErrorClassServerError, true
+		}
+		return ErrorClassFatal, false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || containsAny(msg, "timeout", "deadline exceeded") {
+		return ErrorClassTimeout, true
+	}
+	if containsAny(msg, "internal server error", "bad gateway", "service unavailable", "gateway timeout", "connection reset", "connection refused", "unexpected eof") {
+		return ErrorClassServerError, true
+	}
+	return ErrorClassFatal, false
+}
+
+func containsAny(s string, needles ...string) bool {
+	for _, n := range needles {
+		if strings.Contains(s, n) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,265 @@
+package fallback
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// InferenceResultExtraKey is the InferenceResult.Extra key holding the
+// fallback audit trail (see Audit).
+const InferenceResultExtraKey = "fallback"
+
+var (
+	// ErrNoCandidates is returned when no candidate is configured or all of them
+	// were excluded by routes.
+	ErrNoCandidates = errors.New("fallback: no eligible engine candidates")
+)
+
+// Candidate is one provider engine the fallback engine may use, in priority order.
+type Candidate struct {
+	// Name identifies the candidate in events and audit records, typically the
+	// engine profile slug.
+	Name   string
+	Engine engine.Engine
+	// Settings are the resolved inference settings the engine was built from.
+	// They are optional and only used for routing (ModelInfo) and labeling.
+	Settings *settings.InferenceSettings
+}
+
+// Provider returns the candidate's API type, if known.
+func (c Candidate) Provider() string {
+	if c.Settings == nil || c.Settings.Chat == nil || c.Settings.Chat.ApiType == nil {
+		return ""
+	}
+	return string(*c.Settings.Chat.ApiType)
+}
+
+// Model returns the candidate's model name, if known.
+func (c Candidate) Model() string {
+	if c.Settings == nil || c.Settings.Chat == nil || c.Settings.Chat.Engine == nil {
+		return ""
+	}
+	return *c.Settings.Chat.Engine
+}
+
+func (c Candidate) modelInfo() *settings.ModelInfo {
+	if c.Settings == nil {
+		return nil
+	}
+	return c.Settings.ModelInfo
+}
+
+// Engine runs a turn on the first eligible candidate and falls back to the next
+// one when the classifier reports a retryable failure.
+//
+// Each attempt receives a fresh clone of the input turn. Events streamed by a
+// failed attempt have already been published when the fallback happens; UIs
+// should use the provider-fallback event to discard partial output.
+type Engine struct {
+	candidates []Candidate
+	routes     []Route
+	classifier Classifier
+}
+
+var _ engine.Engine = (*Engine)(nil)
+
+type Option func(*Engine)
+
+// WithRoutes filters candidates per turn; a candidate must pass every route.
+func WithRoutes(routes ...Route) Option {
+	return func(e *Engine) { e.routes = append(e.routes, routes...) }
+}
+
+// WithClassifier replaces ClassifyError.
+func WithClassifier(c Classifier) Option {
+	return func(e *Engine) { e.classifier = c }
+}
+
+// New creates a fallback engine over candidates in priority order.
+func New(candidates []Candidate, opts ...Option) (*Engine, error) {
+	if len(candidates) == 0 {
+		return nil, ErrNoCandidates
+	}
+	candidates = append([]Candidate(nil), candidates...)
+	for i, c := range candidates {
+		if c.Engine == nil {
+			return nil, errors.Errorf("fallback: candidate %d (%s) has no engine", i, c.Name)
+		}
+		if strings.TrimSpace(c.Name) == "" {
+			candidates[i].Name = firstNonEmpty(c.Model(), c.Provider(), "candidate-"+strconv.Itoa(i))
+		}
+	}
+	e := &Engine{candidates: candidates, classifier: ClassifyError}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(e)
+		}
+	}
+	if e.classifier == nil {
+		e.classifier = ClassifyError
+	}
+	return e, nil
+}
+
+// Candidates returns the configured candidates in priority order.
+func (e *Engine) Candidates() []Candidate {
+	return append([]Candidate(nil), e.candidates...)
+}
+
+// Attempt is one failed candidate in the audit trail.
+type Attempt struct {
+	Candidate  string
+	Provider   string
+	Model      string
+	ErrorClass ErrorClass
+	Error      string
+}
+
+// Skip is one candidate excluded by routing.
+type Skip struct {
+	Candidate string
+	Reason    string
+}
+
+// Audit describes how the fallback engine served a turn. It is stored in
+// InferenceResult.Extra[InferenceResultExtraKey] as a plain map so it survives
+// turn serialization.
+type Audit struct {
+	Selected string
+	Attempts []Attempt
+	Skipped  []Skip
+}
+
+func (a Audit) toMap() map[string]any {
+	attempts := make([]any, 0, len(a.Attempts))
+	for _, at := range a.Attempts {
+		attempts = append(attempts, map[string]any{
+			"candidate":   at.Candidate,
+			"provider":    at.Provider,
+			"model":       at.Model,
+			"error_class": string(at.ErrorClass),
+			"error":       at.Error,
+		})
+	}
+	skipped := make([]any, 0, len(a.Skipped))
+	for _, s := range a.Skipped {
+		skipped = append(skipped, map[string]any{"candidate": s.Candidate, "reason": s.Reason})
+	}
+	return map[string]any{
+		"selected": a.Selected,
+		"attempts": attempts,
+		"skipped":  skipped,
+	}
+}
+
+// RunInference implements engine.Engine.
+func (e *Engine) RunInference(ctx context.Context, t *turns.Turn) (*turns.Turn, error) {
+	if t == nil {
+		t = &turns.Turn{}
+	}
+
+	var audit Audit
+	eligible := make([]Candidate, 0, len(e.candidates))
+	for _, c := range e.candidates {
+		if ok, reason := e.route(ctx, t, c); !ok {
+			audit.Skipped = append(audit.Skipped, Skip{Candidate: c.Name, Reason: reason})
+			log.Debug().Str("candidate", c.Name).Str("reason", reason).Msg("fallback: candidate skipped by route")
+			continue
+		}
+		eligible = append(eligible, c)
+	}
+	if len(eligible) == 0 {
+		return nil, errors.Wrapf(ErrNoCandidates, "%d candidates excluded by routes", len(audit.Skipped))
+	}
+
+	var lastErr error
+	for i, c := range eligible {
+		if i > 0 {
+			prev := audit.Attempts[len(audit.Attempts)-1]
+			e.publishFallback(ctx, t, prev, c, i, lastErr)
+		}
+		out, err := c.Engine.RunInference(ctx, t.Clone())
+		if err == nil {
+			audit.Selected = c.Name
+			if out != nil {
+				recordAudit(out, c, audit)
+			}
+			return out, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		class, retry := e.classifier(err)
+		audit.Attempts = append(audit.Attempts, Attempt{
+			Candidate:  c.Name,
+			Provider:   c.Provider(),
+			Model:      c.Model(),
+			ErrorClass: class,
+			Error:      err.Error(),
+		})
+		if !retry {
+			return nil, err
+		}
+		log.Warn().Err(err).Str("candidate", c.Name).Str("error_class", string(class)).Msg("fallback: candidate failed")
+	}
+	return nil, errors.Wrapf(lastErr, "fallback: all %d eligible candidates failed", len(eligible))
+}
+
+func (e *Engine) route(ctx context.Context, t *turns.Turn, c Candidate) (bool, string) {
+	for _, r := range e.routes {
+		if r == nil {
+			continue
+		}
+		if ok, reason := r(ctx, t, c); !ok {
+			return false, reason
+		}
+	}
+	return true, ""
+}
+
+func (e *Engine) publishFallback(ctx context.Context, t *turns.Turn, from Attempt, to Candidate, attempt int, err error) {
+	sessionID, _, _ := turns.KeyTurnMetaSessionID.Get(t.Metadata)
+	inferenceID, _, _ := turns.KeyTurnMetaInferenceID.Get(t.Metadata)
+	meta := events.EventMetadata{
+		ID:          uuid.New(),
+		SessionID:   sessionID,
+		InferenceID: inferenceID,
+		TurnID:      t.ID,
+	}
+	corr := events.BuildRunCorrelation(sessionID, inferenceID, t.ID)
+	events.PublishEventToContext(ctx, events.NewProviderFallbackEvent(meta, corr, from.Candidate, to.Name, attempt, string(from.ErrorClass), err))
+}
+
+func recordAudit(out *turns.Turn, c Candidate, audit Audit) {
+	result, ok, err := turns.KeyTurnMetaInferenceResult.Get(out.Metadata)
+	if err != nil || !ok {
+		result = turns.InferenceResult{Provider: c.Provider(), Model: c.Model()}
+	}
+	extra := make(map[string]any, len(result.Extra)+1)
+	for k, v := range result.Extra {
+		extra[k] = v
+	}
+	extra[InferenceResultExtraKey] = audit.toMap()
+	result.Extra = extra
+	if err := turns.KeyTurnMetaInferenceResult.Set(&out.Metadata, result); err != nil {
+		log.Warn().Err(err).Msg("fallback: failed to record audit in inference result")
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,253 @@
+package fallback
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+)
+
+type fakeEngine struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (f *fakeEngine) RunInference(_ context.Context, t *turns.Turn) (*turns.Turn, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	turns.AppendBlock(t, turns.NewAssistantTextBlock("from "+f.name))
+	if err := turns.KeyTurnMetaInferenceResult.Set(&t.Metadata, turns.InferenceResult{Provider: f.name, Model: f.name + "-model"}); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+type captureSink struct {
+	mu  sync.Mutex
+	evs []*events.EventProviderFallback
+}
+
+func (s *captureSink) PublishEvent(e events.Event) error {
+	if ev, ok := e.(*events.EventProviderFallback); ok {
+		s.mu.Lock()
+		s.evs = append(s.evs, ev)
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+func testTurn() *turns.Turn {
+	t := &turns.Turn{ID: "turn-1"}
+	_ = turns.KeyTurnMetaSessionID.Set(&t.Metadata, "session-1")
+	_ = turns.KeyTurnMetaInferenceID.Set(&t.Metadata, "inf-1")
+	turns.AppendBlock(t, turns.NewUserTextBlock("hello"))
+	return t
+}
+
+func withModelInfo(mi *settings.ModelInfo) *settings.InferenceSettings {
+	s, _ := settings.NewInferenceSettings()
+	s.ModelInfo = mi
+	return s
+}
+
+func TestEngineFallsBackOnRateLimit(t *testing.T) {
+	primary := &fakeEngine{name: "primary", err: errors.New("openai: status=429 rate limit exceeded")}
+	secondary := &fakeEngine{name: "secondary"}
+	e, err := New([]Candidate{{Name: "primary", Engine: primary}, {Name: "secondary", Engine: secondary}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	sink := &captureSink{}
+	in := testTurn()
+	out, err := e.RunInference(events.WithEventSinks(context.Background(), sink), in)
+	if err != nil {
+		t.Fatalf("RunInference: %v", err)
+	}
+	if primary.calls != 1 || secondary.calls != 1 {
+		t.Fatalf("expected one call per candidate, got %d/%d", primary.calls, secondary.calls)
+	}
+	if len(in.Blocks) != 1 {
+		t.Fatalf("input turn must not be mutated, got %d blocks", len(in.Blocks))
+	}
+	if len(sink.evs) != 1 {
+		t.Fatalf("expected one fallback event, got %d", len(sink.evs))
+	}
+	ev := sink.evs[0]
+	if ev.FromCandidate != "primary" || ev.ToCandidate != "secondary" || ev.ErrorClass != string(ErrorClassRateLimited) {
+		t.Fatalf("unexpected fallback event: %+v", ev)
+	}
+	if ev.Correlation().RunID != "inf-1" || ev.Correlation().SessionID != "session-1" {
+		t.Fatalf("unexpected correlation: %+v", ev.Correlation())
+	}
+
+	res, ok, err := turns.KeyTurnMetaInferenceResult.Get(out.Metadata)
+	if err != nil || !ok {
+		t.Fatalf("missing inference result: ok=%v err=%v", ok, err)
+	}
+	if res.Provider != "secondary" {
+		t.Fatalf("expected provider from selected engine, got %q", res.Provider)
+	}
+	audit, ok := res.Extra[InferenceResultExtraKey].(map[string]any)
+	if !ok {
+		t.Fatalf("missing fallback audit in %+v", res.Extra)
+	}
+	if audit["selected"] != "secondary" {
+		t.Fatalf("unexpected selected candidate: %v", audit["selected"])
+	}
+	attempts, _ := audit["attempts"].([]any)
+	if len(attempts) != 1 || attempts[0].(map[string]any)["error_class"] != string(ErrorClassRateLimited) {
+		t.Fatalf("unexpected attempts: %v", attempts)
+	}
+}
+
+func TestEngineStopsOnFatalError(t *testing.T) {
+	primary := &fakeEngine{name: "primary", err: errors.New("status=401 invalid api key")}
+	secondary := &fakeEngine{name: "secondary"}
+	e, err := New([]Candidate{{Name: "primary", Engine: primary}, {Name: "secondary", Engine: secondary}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := e.RunInference(context.Background(), testTurn()); err == nil {
+		t.Fatalf("expected error")
+	}
+	if secondary.calls != 0 {
+		t.Fatalf("fatal errors must not fall back")
+	}
+}
+
+func TestEngineReportsAllFailures(t *testing.T) {
+	a := &fakeEngine{name: "a", err: errors.New("status=503 service unavailable")}
+	b := &fakeEngine{name: "b", err: errors.New("anthropic: overloaded_error")}
+	e, err := New([]Candidate{{Name: "a", Engine: a}, {Name: "b", Engine: b}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	_, err = e.RunInference(context.Background(), testTurn())
+	if err == nil || !strings.Contains(err.Error(), "all 2 eligible candidates failed") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestNewDoesNotMutateCallerCandidates(t *testing.T) {
+	candidates := []Candidate{{Engine: &fakeEngine{name: "a"}}, {Engine: &fakeEngine{name: "b"}}}
+	e, err := New(candidates)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for i, c := range candidates {
+		if c.Name != "" {
+			t.Fatalf("candidate %d name was rewritten to %q", i, c.Name)
+		}
+	}
+	if e.candidates[0].Name != "candidate-0" || e.candidates[1].Name != "candidate-1" {
+		t.Fatalf("unexpected default names: %q, %q", e.candidates[0].Name, e.candidates[1].Name)
+	}
+}
+
+func TestEngineRoutesImagesAwayFromTextOnlyModels(t *testing.T) {
+	textOnly := &fakeEngine{name: "text"}
+	vision := &fakeEngine{name: "vision"}
+	e, err := New([]Candidate{
+		{Name: "text", Engine: textOnly, Settings: withModelInfo(&settings.ModelInfo{Input: []settings.InputModality{settings.InputModalityText}})},
+		{Name: "vision", Engine: vision, Settings: withModelInfo(&settings.ModelInfo{Input: []settings.InputModality{settings.InputModalityText, settings.InputModalityImage}})},
+	}, WithRoutes(RequireImageInput()))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	in := testTurn()
+	turns.AppendBlock(in, turns.NewUserMultimodalBlock("what is this?", []map[string]any{{"media_type": "image/png", "url": "https://example.com/a.png"}}))
+	out, err := e.RunInference(context.Background(), in)
+	if err != nil {
+		t.Fatalf("RunInference: %v", err)
+	}
+	if textOnly.calls != 0 || vision.calls != 1 {
+		t.Fatalf("expected vision model only, got text=%d vision=%d", textOnly.calls, vision.calls)
+	}
+	res, _, _ := turns.KeyTurnMetaInferenceResult.Get(out.Metadata)
+	skipped, _ := res.Extra[InferenceResultExtraKey].(map[string]any)["skipped"].([]any)
+	if len(skipped) != 1 || skipped[0].(map[string]any)["candidate"] != "text" {
+		t.Fatalf("unexpected skipped list: %v", skipped)
+	}
+
+	// Text-only turns still go to the first candidate.
+	if _, err := e.RunInference(context.Background(), testTurn()); err != nil {
+		t.Fatalf("RunInference: %v", err)
+	}
+	if textOnly.calls != 1 {
+		t.Fatalf("expected text model for text-only turn")
+	}
+}
+
+func TestFitsContextWindowRoute(t *testing.T) {
+	small := 5
+	large := 100000
+	smallEng := &fakeEngine{name: "small"}
+	largeEng := &fakeEngine{name: "large"}
+	e, err := New([]Candidate{
+		{Name: "small", Engine: smallEng, Settings: withModelInfo(&settings.ModelInfo{ContextWindow: &small})},
+		{Name: "large", Engine: largeEng, Settings: withModelInfo(&settings.ModelInfo{ContextWindow: &large})},
+	}, WithRoutes(FitsContextWindow(nil, 0)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	in := testTurn()
+	turns.AppendBlock(in, turns.NewUserTextBlock(strings.Repeat("long context ", 200)))
+	if _, err := e.RunInference(context.Background(), in); err != nil {
+		t.Fatalf("RunInference: %v", err)
+	}
+	if smallEng.calls != 0 || largeEng.calls != 1 {
+		t.Fatalf("expected large model, got small=%d large=%d", smallEng.calls, largeEng.calls)
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		err   error
+		class ErrorClass
+		retry bool
+	}{
+		{errors.New("status=429 too many requests"), ErrorClassRateLimited, true},
+		{errors.New("status=500 internal"), ErrorClassServerError, true},
+		{errors.New("overloaded_error: Overloaded"), ErrorClassOverloaded, true},
+		{errors.New("This model's maximum context length is 8192 tokens"), ErrorClassContextLength, true},
+		{context.DeadlineExceeded, ErrorClassTimeout, true},
+		{errors.New("status=400 invalid request"), ErrorClassFatal, false},
+		{context.Canceled, ErrorClassFatal, false},
+	}
+	for _, c := range cases {
+		class, retry := ClassifyError(c.err)
+		if class != c.class || retry != c.retry {
+			t.Errorf("ClassifyError(%q) = %s/%v, want %s/%v", c.err, class, retry, c.class, c.retry)
+		}
+	}
+}
+
+func TestEngineRoutesPDFsToModelsAcceptingThem(t *testing.T) {
+	textOnly := &fakeEngine{name: "text"}
+	docs := &fakeEngine{name: "docs"}
+	e, err := New([]Candidate{
+		{Name: "text", Engine: textOnly, Settings: withModelInfo(&settings.ModelInfo{Input: []settings.InputModality{settings.InputModalityText, settings.InputModalityImage}})},
+		{Name: "docs", Engine: docs, Settings: withModelInfo(&settings.ModelInfo{Input: []settings.InputModality{settings.InputModalityText, settings.InputModalityPDF}})},
+	}, WithRoutes(RequireMediaInput()))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	in := testTurn()
+	turns.AppendBlock(in, turns.NewUserFileBlock("summarize", []map[string]any{{"url": "https://example.com/report.pdf"}}))
+	if _, err := e.RunInference(context.Background(), in); err != nil {
+		t.Fatalf("RunInference: %v", err)
+	}
+	if textOnly.calls != 0 || docs.calls != 1 {
+		t.Fatalf("expected pdf model only, got text=%d docs=%d", textOnly.calls, docs.calls)
+	}
+}
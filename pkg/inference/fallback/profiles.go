@@ -0,0 +1,51 @@
+package fallback
+
+import (
+	"context"
+
+	"github.com/go-go-golems/geppetto/pkg/engineprofiles"
+	"github.com/go-go-golems/geppetto/pkg/inference/engine/factory"
+	"github.com/pkg/errors"
+)
+
+// NewFromProfiles resolves profileSlugs in order from registry, builds one
+// engine per profile with f and returns a fallback engine over them. Candidate
+// names are the profile slugs. An empty registrySlug uses the registry default.
+func NewFromProfiles(
+	ctx context.Context,
+	registry engineprofiles.Registry,
+	f factory.EngineFactory,
+	registrySlug engineprofiles.RegistrySlug,
+	profileSlugs []engineprofiles.EngineProfileSlug,
+	opts ...Option,
+) (*Engine, error) {
+	if registry == nil {
+		return nil, errors.New("fallback: profile registry is nil")
+	}
+	if f == nil {
+		f = factory.NewStandardEngineFactory()
+	}
+	candidates := make([]Candidate, 0, len(profileSlugs))
+	for _, slug := range profileSlugs {
+		resolved, err := registry.ResolveEngineProfile(ctx, engineprofiles.ResolveInput{
+			RegistrySlug:      registrySlug,
+			EngineProfileSlug: slug,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "fallback: resolve engine profile %q", slug)
+		}
+		if resolved == nil || resolved.InferenceSettings == nil {
+			return nil, errors.Errorf("fallback: engine profile %q has no inference settings", slug)
+		}
+		eng, err := f.CreateEngine(resolved.InferenceSettings)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fallback: create engine for profile %q", slug)
+		}
+		candidates = append(candidates, Candidate{
+			Name:     string(resolved.EngineProfileSlug),
+			Engine:   eng,
+			Settings: resolved.InferenceSettings,
+		})
+	}
+	return New(candidates, opts...)
+}
@@ -0,0 +1,116 @@
+package fallback
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/tokencount"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/mediaparts"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+)
+
+// Route decides whether a candidate may serve a turn. It returns a short
+// reason when the candidate is skipped. Routes only use static ModelInfo, so a
+// candidate without ModelInfo is never excluded by the built-in routes.
+type Route func(ctx context.Context, t *turns.Turn, c Candidate) (ok bool, reason string)
+
+// RequireImageInput skips candidates whose ModelInfo.Input is declared and lacks
+// image support when the turn contains images.
+func RequireImageInput() Route {
+	return func(_ context.Context, t *turns.Turn, c Candidate) (bool, string) {
+		if !TurnHasImages(t) {
+			return true, ""
+		}
+		mi := c.modelInfo()
+		if mi == nil || len(mi.Input) == 0 {
+			return true, ""
+		}
+		for _, in := range mi.Input {
+			if in == settings.InputModalityImage {
+				return true, ""
+			}
+		}
+		return false, "model does not accept image input"
+	}
+}
+
+// RequireMediaInput skips candidates whose ModelInfo.Input is declared and
+// lacks a modality needed by the images or file parts (PDF, audio, video, ...)
+// of the turn's user blocks.
+func RequireMediaInput() Route {
+	return func(_ context.Context, t *turns.Turn, c Candidate) (bool, string) {
+		if err := mediaparts.CheckTurnInput(c.modelInfo(), t); err != nil {
+			return false, err.Error()
+		}
+		return true, ""
+	}
+}
+
+// RequireReasoning skips candidates with ModelInfo.Reasoning=false when
+// needsReasoning reports that the turn calls for a reasoning model.
+func RequireReasoning(needsReasoning func(t *turns.Turn) bool) Route {
+	return func(_ context.Context, t *turns.Turn, c Candidate) (bool, string) {
+		if needsReasoning == nil || !needsReasoning(t) {
+			return true, ""
+		}
+		mi := c.modelInfo()
+		if mi == nil || mi.Reasoning == nil || *mi.Reasoning {
+			return true, ""
+		}
+		return false, "model is not a reasoning model"
+	}
+}
+
+// FitsContextWindow skips candidates whose context window (or quality high
+// watermark, when set) is smaller than the estimated turn size plus
+// reserveTokens. A nil counter uses tokencount.EstimateCounter.
+func FitsContextWindow(counter tokencount.Counter, reserveTokens int) Route {
+	return func(ctx context.Context, t *turns.Turn, c Candidate) (bool, string) {
+		mi := c.modelInfo()
+		if mi == nil {
+			return true, ""
+		}
+		limit := 0
+		switch {
+		case mi.QualityHighWatermark != nil && *mi.QualityHighWatermark > 0:
+			limit = *mi.QualityHighWatermark
+		case mi.ContextWindow != nil && *mi.ContextWindow > 0:
+			limit = *mi.ContextWindow
+		default:
+			return true, ""
+		}
+		cnt := counter
+		if cnt == nil {
+			cnt = tokencount.NewEstimateCounter(c.Provider(), c.Model())
+		}
+		res, err := cnt.CountTurn(ctx, t)
+		if err != nil || res == nil {
+			return true, ""
+		}
+		if res.InputTokens+reserveTokens > limit {
+			return false, fmt.Sprintf("estimated %d tokens exceed limit %d", res.InputTokens+reserveTokens, limit)
+		}
+		return true, ""
+	}
+}
+
+// TurnHasImages reports whether any block carries image payloads.
+func TurnHasImages(t *turns.Turn) bool {
+	if t == nil {
+		return false
+	}
+	for _, b := range t.Blocks {
+		switch imgs := b.Payload[turns.PayloadKeyImages].(type) {
+		case []any:
+			if len(imgs) > 0 {
+				return true
+			}
+		case []map[string]any:
+			if len(imgs) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/runner"
+	"github.com/go-go-golems/geppetto/pkg/inference/tools"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+)
+
+// AgentToolInput is the input schema of the tool created by AgentTool.
+type AgentToolInput struct {
+	Prompt    string `json:"prompt" jsonschema:"required,description=Task or question for the agent"`
+	SessionID string `json:"session_id,omitempty" jsonschema:"description=Optional session id to group related runs"`
+}
+
+// AgentToolOutput is returned by the tool created by AgentTool.
+type AgentToolOutput struct {
+	Answer    string `json:"answer"`
+	SessionID string `json:"session_id,omitempty"`
+}
+
+// AgentTool returns a registrar for a single tool that runs a whole agent: it
+// starts r with runtime and the given prompt, runs the tool loop to completion
+// and returns the final assistant text. Registering it in the registry passed
+// to NewServer exposes the agent to MCP clients.
+func AgentTool(name, description string, r *runner.Runner, runtime runner.Runtime) runner.ToolRegistrar {
+	return func(_ context.Context, reg tools.ToolRegistry) error {
+		if reg == nil {
+			return fmt.Errorf("tool registry is nil")
+		}
+		if r == nil {
+			return fmt.Errorf("agent tool %s: runner is nil", name)
+		}
+		def, err := tools.NewToolFromFunc(name, description, func(ctx context.Context, in AgentToolInput) (AgentToolOutput, error) {
+			if strings.TrimSpace(in.Prompt) == "" {
+				return AgentToolOutput{}, fmt.Errorf("prompt is required")
+			}
+			prepared, out, err := r.Run(ctx, runner.StartRequest{
+				SessionID: in.SessionID,
+				Prompt:    in.Prompt,
+				Runtime:   runtime,
+			})
+			if err != nil {
+				return AgentToolOutput{}, err
+			}
+			res := AgentToolOutput{Answer: lastAssistantText(out), SessionID: in.SessionID}
+			if prepared != nil && prepared.Session != nil {
+				res.SessionID = prepared.Session.SessionID
+			}
+			return res, nil
+		})
+		if err != nil {
+			return fmt.Errorf("create agent tool %s: %w", name, err)
+		}
+		return reg.RegisterTool(def.Name, *def)
+	}
+}
+
+func lastAssistantText(t *turns.Turn) string {
+	if t == nil {
+		return ""
+	}
+	for i := len(t.Blocks) - 1; i >= 0; i-- {
+		b := t.Blocks[i]
+		if b.Kind != turns.BlockKindLLMText {
+			continue
+		}
+		if text, ok := b.Payload[turns.PayloadKeyText].(string); ok && strings.TrimSpace(text) != "" {
+			return text
+		}
+	}
+	return ""
+}
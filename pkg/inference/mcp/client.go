@@ -0,0 +1,276 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Client is a Model Context Protocol client bound to one server connection.
+// It is safe for concurrent use.
+type Client struct {
+	transport      Transport
+	info           Implementation
+	onToolsChanged func()
+
+	nextID  atomic.Int64
+	mu      sync.Mutex
+	pending map[string]chan *Message
+	closed  bool
+	init    *InitializeResult
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+type ClientOption func(*Client)
+
+// WithClientInfo sets the implementation info sent during initialize.
+func WithClientInfo(info Implementation) ClientOption {
+	return func(c *Client) { c.info = info }
+}
+
+// WithToolsChangedHandler registers a callback for the server's
+// notifications/tools/list_changed notification. It runs on the dispatch
+// goroutine and must not block on client calls.
+func WithToolsChangedHandler(fn func()) ClientOption {
+	return func(c *Client) { c.onToolsChanged = fn }
+}
+
+// NewClient wraps an open transport. Call Initialize before any other method,
+// or use Connect.
+func NewClient(t Transport, opts ...ClientOption) *Client {
+	c := &Client{
+		transport: t,
+		info:      Implementation{Name: "geppetto", Version: "dev"},
+		pending:   map[string]chan *Message{},
+		done:      make(chan struct{}),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(c)
+		}
+	}
+	go c.dispatch()
+	return c
+}
+
+// Connect creates a client on t and performs the initialize handshake.
+func Connect(ctx context.Context, t Transport, opts ...ClientOption) (*Client, error) {
+	c := NewClient(t, opts...)
+	if _, err := c.Initialize(ctx); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// ConnectCommand launches an MCP server subprocess and connects to it over stdio.
+func ConnectCommand(ctx context.Context, name string, args []string, opts ...ClientOption) (*Client, error) {
+	t, err := NewCommandTransport(context.WithoutCancel(ctx), name, args...)
+	if err != nil {
+		return nil, err
+	}
+	return Connect(ctx, t, opts...)
+}
+
+// ConnectHTTP connects to a streamable HTTP MCP endpoint.
+func ConnectHTTP(ctx context.Context, endpoint string, httpOpts []HTTPOption, opts ...ClientOption) (*Client, error) {
+	t, err := NewHTTPTransport(endpoint, httpOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return Connect(ctx, t, opts...)
+}
+
+// Initialize performs the initialize handshake and sends notifications/initialized.
+func (c *Client) Initialize(ctx context.Context) (*InitializeResult, error) {
+	var res InitializeResult
+	err := c.call(ctx, MethodInitialize, InitializeParams{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    map[string]any{},
+		ClientInfo:      c.info,
+	}, &res)
+	if err != nil {
+		return nil, fmt.Errorf("mcp: initialize: %w", err)
+	}
+	if !supportedProtocolVersions[res.ProtocolVersion] {
+		return nil, fmt.Errorf("mcp: server protocol version %q is not supported", res.ProtocolVersion)
+	}
+	if s, ok := c.transport.(interface{ setProtocolVersion(string) }); ok {
+		s.setProtocolVersion(res.ProtocolVersion)
+	}
+	if err := c.notify(ctx, NotificationInitialized, nil); err != nil {
+		return nil, fmt.Errorf("mcp: initialized notification: %w", err)
+	}
+	c.mu.Lock()
+	c.init = &res
+	c.mu.Unlock()
+	return &res, nil
+}
+
+// ServerInfo returns the server's initialize result, or nil before Initialize.
+func (c *Client) ServerInfo() *InitializeResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.init
+}
+
+// Ping checks that the server is responsive.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.call(ctx, MethodPing, nil, nil)
+}
+
+// ListTools returns all tools offered by the server, following pagination.
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	var out []Tool
+	cursor := ""
+	for {
+		var res ListToolsResult
+		if err := c.call(ctx, MethodToolsList, ListToolsParams{Cursor: cursor}, &res); err != nil {
+			return nil, fmt.Errorf("mcp: list tools: %w", err)
+		}
+		out = append(out, res.Tools...)
+		if res.NextCursor == "" || res.NextCursor == cursor {
+			return out, nil
+		}
+		cursor = res.NextCursor
+	}
+}
+
+// CallTool invokes a tool. Tool-level failures are reported through
+// CallToolResult.IsError; the returned error covers protocol failures only.
+func (c *Client) CallTool(ctx context.Context, name string, args json.RawMessage) (*CallToolResult, error) {
+	if len(args) == 0 {
+		args = json.RawMessage("{}")
+	}
+	var res CallToolResult
+	if err := c.call(ctx, MethodToolsCall, CallToolParams{Name: name, Arguments: args}, &res); err != nil {
+		return nil, fmt.Errorf("mcp: call tool %s: %w", name, err)
+	}
+	return &res, nil
+}
+
+// Close closes the transport and fails pending calls.
+func (c *Client) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.done)
+		err = c.transport.Close()
+	})
+	return err
+}
+
+func (c *Client) call(ctx context.Context, method string, params any, result any) error {
+	id := c.nextID.Add(1)
+	req, err := newRequest(id, method, params)
+	if err != nil {
+		return err
+	}
+	key := string(req.ID)
+	ch := make(chan *Message, 1)
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	c.pending[key] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, key)
+		c.mu.Unlock()
+	}()
+
+	if err := c.transport.Send(ctx, req); err != nil {
+		return err
+	}
+	select {
+	case resp, ok := <-ch:
+		if !ok || resp == nil {
+			return ErrClosed
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil || len(resp.Result) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("mcp: decode %s result: %w", method, err)
+		}
+		return nil
+	case <-ctx.Done():
+		if n, nerr := newNotification(NotificationCancelled, map[string]any{"requestId": id, "reason": ctx.Err().Error()}); nerr == nil {
+			go func() { _ = c.transport.Send(context.WithoutCancel(ctx), n) }()
+		}
+		return ctx.Err()
+	case <-c.done:
+		return ErrClosed
+	}
+}
+
+func (c *Client) notify(ctx context.Context, method string, params any) error {
+	n, err := newNotification(method, params)
+	if err != nil {
+		return err
+	}
+	return c.transport.Send(ctx, n)
+}
+
+// dispatch routes incoming messages until the transport closes.
+func (c *Client) dispatch() {
+	defer c.failPending()
+	for msg := range c.transport.Messages() {
+		switch {
+		case msg.IsResponse():
+			c.mu.Lock()
+			ch := c.pending[string(msg.ID)]
+			c.mu.Unlock()
+			if ch == nil {
+				log.Debug().RawJSON("id", msg.ID).Msg("mcp: response for unknown request")
+				continue
+			}
+			select {
+			case ch <- msg:
+			default:
+			}
+		case msg.IsRequest():
+			go c.answerServerRequest(msg)
+		case msg.IsNotification():
+			if msg.Method == NotificationToolsListChanged && c.onToolsChanged != nil {
+				c.onToolsChanged()
+			}
+		}
+	}
+}
+
+// answerServerRequest replies to server-initiated requests. Only ping is
+// supported; sampling, roots and elicitation are not advertised.
+func (c *Client) answerServerRequest(msg *Message) {
+	var resp *Message
+	if msg.Method == MethodPing {
+		r, err := newResult(msg.ID, struct{}{})
+		if err != nil {
+			return
+		}
+		resp = r
+	} else {
+		resp = newErrorResponse(msg.ID, CodeMethodNotFound, "method not supported by client: "+msg.Method)
+	}
+	if err := c.transport.Send(context.Background(), resp); err != nil {
+		log.Debug().Err(err).Str("method", msg.Method).Msg("mcp: failed to answer server request")
+	}
+}
+
+func (c *Client) failPending() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	for key, ch := range c.pending {
+		close(ch)
+		delete(c.pending, key)
+	}
+}
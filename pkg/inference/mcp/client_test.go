@@ -0,0 +1,314 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/tools"
+	"github.com/go-go-golems/geppetto/pkg/security"
+)
+
+// stubServer answers MCP requests for the client tests. It offers an "echo"
+// tool on the first tools/list page and a "fail.tool" on the second.
+type stubServer struct {
+	calls []CallToolParams
+}
+
+func (s *stubServer) handle(msg *Message) *Message {
+	if !msg.IsRequest() {
+		return nil
+	}
+	var result any
+	switch msg.Method {
+	case MethodInitialize:
+		result = InitializeResult{
+			ProtocolVersion: ProtocolVersion,
+			Capabilities:    ServerCapabilities{Tools: &ToolsCapability{}},
+			ServerInfo:      Implementation{Name: "stub", Version: "1"},
+		}
+	case MethodToolsList:
+		var p ListToolsParams
+		_ = json.Unmarshal(msg.Params, &p)
+		if p.Cursor == "" {
+			result = ListToolsResult{
+				Tools: []Tool{{
+					Name:        "echo",
+					Description: "Echo text back",
+					InputSchema: json.RawMessage(`{"type":"object","properties":{"text":{"type":"string"}},"required":["text"]}`),
+				}},
+				NextCursor: "page-2",
+			}
+		} else {
+			result = ListToolsResult{Tools: []Tool{{Name: "fail.tool", InputSchema: json.RawMessage(`{"type":"object"}`)}}}
+		}
+	case MethodToolsCall:
+		var p CallToolParams
+		_ = json.Unmarshal(msg.Params, &p)
+		s.calls = append(s.calls, p)
+		if p.Name == "fail.tool" {
+			result = CallToolResult{Content: []Content{TextContent("boom")}, IsError: true}
+			break
+		}
+		var args struct {
+			Text string `json:"text"`
+		}
+		_ = json.Unmarshal(p.Arguments, &args)
+		result = CallToolResult{Content: []Content{TextContent("echo: " + args.Text)}}
+	case MethodPing:
+		result = struct{}{}
+	default:
+		return newErrorResponse(msg.ID, CodeMethodNotFound, msg.Method)
+	}
+	resp, err := newResult(msg.ID, result)
+	if err != nil {
+		return newErrorResponse(msg.ID, CodeInternalError, err.Error())
+	}
+	return resp
+}
+
+func (s *stubServer) httpHandler(sse bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		var msg Message
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if msg.Method == MethodInitialize {
+			w.Header().Set(headerSessionID, "session-1")
+		} else if r.Header.Get(headerSessionID) != "session-1" {
+			http.Error(w, "missing session", http.StatusBadRequest)
+			return
+		}
+		resp := s.handle(&msg)
+		if resp == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		b, _ := json.Marshal(resp)
+		if sse {
+			w.Header().Set("Content-Type", contentTypeEventStream)
+			_, _ = fmt.Fprintf(w, "event: message\ndata: %s\n\n", b)
+			return
+		}
+		w.Header().Set("Content-Type", contentTypeJSON)
+		_, _ = w.Write(b)
+	})
+}
+
+func (s *stubServer) serveStdio(r io.Reader, w io.Writer) {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		var msg Message
+		if err := json.Unmarshal(sc.Bytes(), &msg); err != nil {
+			continue
+		}
+		if resp := s.handle(&msg); resp != nil {
+			b, _ := json.Marshal(resp)
+			_, _ = w.Write(append(b, '\n'))
+		}
+	}
+}
+
+func stdioPair(t *testing.T, s *stubServer) Transport {
+	t.Helper()
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	go s.serveStdio(serverR, serverW)
+	return NewStdioTransport(clientR, clientW, func() error {
+		_ = clientW.Close()
+		return clientR.Close()
+	})
+}
+
+// localHTTPTransport connects to an httptest server, which listens on
+// plain HTTP on loopback.
+func localHTTPTransport(t *testing.T, endpoint string) Transport {
+	t.Helper()
+	tr, err := NewHTTPTransport(endpoint, WithOutboundURLOptions(security.OutboundURLOptions{AllowHTTP: true, AllowLocalNetworks: true}))
+	if err != nil {
+		t.Fatalf("NewHTTPTransport: %v", err)
+	}
+	return tr
+}
+
+func TestClientRegistersAndExecutesRemoteTools(t *testing.T) {
+	transports := map[string]func(t *testing.T, s *stubServer) Transport{
+		"stdio": stdioPair,
+		"http-json": func(t *testing.T, s *stubServer) Transport {
+			srv := httptest.NewServer(s.httpHandler(false))
+			t.Cleanup(srv.Close)
+			return localHTTPTransport(t, srv.URL)
+		},
+		"http-sse": func(t *testing.T, s *stubServer) Transport {
+			srv := httptest.NewServer(s.httpHandler(true))
+			t.Cleanup(srv.Close)
+			return localHTTPTransport(t, srv.URL)
+		},
+	}
+	for name, mk := range transports {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			stub := &stubServer{}
+			client, err := Connect(ctx, mk(t, stub))
+			if err != nil {
+				t.Fatalf("Connect: %v", err)
+			}
+			defer func() { _ = client.Close() }()
+			if info := client.ServerInfo(); info == nil || info.ServerInfo.Name != "stub" {
+				t.Fatalf("unexpected server info: %+v", info)
+			}
+
+			reg := tools.NewInMemoryToolRegistry()
+			if err := ToolRegistrar(client, WithToolPrefix("stub_"))(ctx, reg); err != nil {
+				t.Fatalf("register: %v", err)
+			}
+			if !reg.HasTool("stub_echo") || !reg.HasTool("stub_fail_tool") {
+				t.Fatalf("unexpected tools: %+v", reg.ListTools())
+			}
+			def, _ := reg.GetTool("stub_echo")
+			if def.Parameters == nil || def.Parameters.Properties == nil {
+				t.Fatalf("expected converted input schema, got %+v", def.Parameters)
+			}
+			if _, ok := def.Parameters.Properties.Get("text"); !ok {
+				t.Fatalf("expected text property in schema")
+			}
+
+			exec := tools.NewBaseToolExecutor(tools.DefaultToolConfig())
+			res, err := exec.ExecuteToolCall(ctx, tools.ToolCall{ID: "call-1", Name: "stub_echo", Arguments: json.RawMessage(`{"text":"hi"}`)}, reg)
+			if err != nil {
+				t.Fatalf("ExecuteToolCall: %v", err)
+			}
+			if res.Error != "" || res.Result != "echo: hi" {
+				t.Fatalf("unexpected result: %+v", res)
+			}
+
+			res, err = exec.ExecuteToolCall(ctx, tools.ToolCall{ID: "call-2", Name: "stub_fail_tool", Arguments: json.RawMessage(`{}`)}, reg)
+			if err != nil {
+				t.Fatalf("ExecuteToolCall: %v", err)
+			}
+			if !strings.Contains(res.Error, "boom") {
+				t.Fatalf("expected tool error, got %+v", res)
+			}
+			if len(stub.calls) != 2 || stub.calls[1].Name != "fail.tool" {
+				t.Fatalf("expected remote names to be preserved, got %+v", stub.calls)
+			}
+		})
+	}
+}
+
+func TestClientReturnsRPCErrors(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, err := Connect(ctx, stdioPair(t, &stubServer{}))
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+	err = client.call(ctx, "resources/list", nil, nil)
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != CodeMethodNotFound {
+		t.Fatalf("expected method-not-found, got %v", err)
+	}
+	if err := client.Ping(ctx); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+}
+
+func TestHTTPTransportValidatesEndpoint(t *testing.T) {
+	for _, endpoint := range []string{"http://127.0.0.1:8080/mcp", "https://localhost/mcp", "file:///tmp/mcp"} {
+		if _, err := NewHTTPTransport(endpoint); err == nil {
+			t.Fatalf("expected %q to be rejected", endpoint)
+		}
+	}
+	if _, err := NewHTTPTransport("https://mcp.example.com/mcp"); err != nil {
+		t.Fatalf("NewHTTPTransport: %v", err)
+	}
+}
+
+func TestHTTPTransportRejectsOversizedResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"`))
+		_, _ = w.Write([]byte(strings.Repeat("x", maxMessageBytes)))
+		_, _ = w.Write([]byte(`"}`))
+	}))
+	t.Cleanup(srv.Close)
+	tr := localHTTPTransport(t, srv.URL)
+	defer func() { _ = tr.Close() }()
+	err := tr.Send(context.Background(), &Message{JSONRPC: "2.0", ID: json.RawMessage("1"), Method: "ping"})
+	if err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Fatalf("expected size error, got %v", err)
+	}
+}
+
+func TestHTTPTransportBoundsEventStream(t *testing.T) {
+	half := strings.Repeat("x", maxMessageBytes/2+1)
+	cases := []struct {
+		name   string
+		stream string
+		want   string
+	}{
+		{name: "line", stream: "data: " + strings.Repeat("x", maxMessageBytes) + "\n\n", want: "sse line exceeds"},
+		{name: "event", stream: "data: " + half + "\ndata: " + half + "\n\n", want: "sse event exceeds"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := &HTTPTransport{incoming: make(chan *Message, 1), done: make(chan struct{})}
+			err := tr.readEventStream(strings.NewReader(tc.stream))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected %q error, got %v", tc.want, err)
+			}
+			if len(tr.incoming) != 0 {
+				t.Fatalf("oversized event must not be delivered")
+			}
+		})
+	}
+
+	tr := &HTTPTransport{incoming: make(chan *Message, 1), done: make(chan struct{})}
+	if err := tr.readEventStream(strings.NewReader("event: message\ndata: {\"jsonrpc\":\"2.0\",\"id\":1,\"result\":{}}\n\n")); err != nil {
+		t.Fatalf("readEventStream: %v", err)
+	}
+	if len(tr.incoming) != 1 {
+		t.Fatalf("expected the event to be delivered")
+	}
+}
+
+func TestStdioTransportClosesOnOversizedLine(t *testing.T) {
+	in := strings.Repeat("x", maxMessageBytes+1) + "\n" + `{"jsonrpc":"2.0","id":1,"result":{}}` + "\n"
+	closed := make(chan struct{})
+	tr := NewStdioTransport(strings.NewReader(in), io.Discard, func() error {
+		close(closed)
+		return nil
+	})
+	for msg := range tr.Messages() {
+		t.Fatalf("unexpected message after an oversized line: %+v", msg)
+	}
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the transport to be closed")
+	}
+}
+
+func TestSanitizeToolName(t *testing.T) {
+	if got := SanitizeToolName("fs/read.file"); got != "fs_read_file" {
+		t.Fatalf("unexpected name %q", got)
+	}
+	if got := SanitizeToolName(strings.Repeat("a", 80)); len(got) != 64 {
+		t.Fatalf("expected truncation to 64, got %d", len(got))
+	}
+}
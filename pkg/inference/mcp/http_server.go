@@ -0,0 +1,189 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxHTTPRequestBytes bounds one POSTed JSON-RPC payload.
+const maxHTTPRequestBytes = 4 << 20
+
+var _ http.Handler = (*Server)(nil)
+
+// ServeHTTP implements the MCP streamable HTTP transport with plain JSON
+// responses. initialize assigns an Mcp-Session-Id that later requests must
+// send; DELETE ends the session. Sessions also expire after the idle timeout,
+// and initialize evicts the least recently used session once the maximum is
+// reached (see WithSessionIdleTimeout and WithMaxSessions). The optional GET
+// event stream is not offered.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.originAllowed(r.Header.Get("Origin")) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		s.servePost(w, r)
+	case http.MethodDelete:
+		sid := r.Header.Get(headerSessionID)
+		if sid == "" || !s.endSession(sid) {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) servePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxHTTPRequestBytes+1))
+	if err != nil {
+		http.Error(w, "read body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxHTTPRequestBytes {
+		http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	body = bytes.TrimSpace(body)
+
+	var (
+		msgs  []*Message
+		batch = len(body) > 0 && body[0] == '['
+	)
+	if batch {
+		err = json.Unmarshal(body, &msgs)
+	} else {
+		var m Message
+		err = json.Unmarshal(body, &m)
+		msgs = append(msgs, &m)
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, newErrorResponse(nil, CodeParseError, "parse error: "+err.Error()))
+		return
+	}
+
+	isInit := len(msgs) == 1 && msgs[0].Method == MethodInitialize
+	if isInit {
+		w.Header().Set(headerSessionID, s.newSession())
+	} else {
+		sid := r.Header.Get(headerSessionID)
+		if sid == "" {
+			http.Error(w, "missing "+headerSessionID+" header", http.StatusBadRequest)
+			return
+		}
+		if !s.hasSession(sid) {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+	}
+
+	var responses []*Message
+	for _, m := range msgs {
+		if resp := s.HandleMessage(r.Context(), m); resp != nil {
+			responses = append(responses, resp)
+		}
+	}
+	switch {
+	case len(responses) == 0:
+		w.WriteHeader(http.StatusAccepted)
+	case batch:
+		writeJSON(w, http.StatusOK, responses)
+	default:
+		writeJSON(w, http.StatusOK, responses[0])
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", contentTypeJSON)
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Debug().Err(err).Msg("mcp: write http response")
+	}
+}
+
+func (s *Server) newSession() string {
+	sid := uuid.NewString()
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	now := s.now()
+	s.expireSessionsLocked(now)
+	for s.maxSessions > 0 && len(s.sessions) >= s.maxSessions {
+		oldest, oldestAt := "", time.Time{}
+		for id, at := range s.sessions {
+			if oldest == "" || at.Before(oldestAt) {
+				oldest, oldestAt = id, at
+			}
+		}
+		delete(s.sessions, oldest)
+		log.Debug().Str("session", oldest).Msg("mcp: evicted least recently used session")
+	}
+	s.sessions[sid] = now
+	return sid
+}
+
+// hasSession reports whether sid is live and marks it as used.
+func (s *Server) hasSession(sid string) bool {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	now := s.now()
+	s.expireSessionsLocked(now)
+	if _, ok := s.sessions[sid]; !ok {
+		return false
+	}
+	s.sessions[sid] = now
+	return true
+}
+
+func (s *Server) endSession(sid string) bool {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	s.expireSessionsLocked(s.now())
+	if _, ok := s.sessions[sid]; !ok {
+		return false
+	}
+	delete(s.sessions, sid)
+	return true
+}
+
+// expireSessionsLocked drops sessions idle longer than idleTimeout. The
+// caller holds sessionsMu.
+func (s *Server) expireSessionsLocked(now time.Time) {
+	if s.idleTimeout <= 0 {
+		return
+	}
+	for id, at := range s.sessions {
+		if now.Sub(at) > s.idleTimeout {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+func (s *Server) originAllowed(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	if s.allowedOrigins[strings.TrimRight(origin, "/")] {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,293 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/go-go-golems/geppetto/pkg/security"
+)
+
+// HTTPTransport implements the MCP streamable HTTP transport: every message is
+// POSTed to one endpoint and the server answers with JSON or a short SSE
+// stream. The server-initiated GET stream is not used.
+type HTTPTransport struct {
+	endpoint string
+	client   *http.Client
+	headers  http.Header
+	outbound security.OutboundURLOptions
+	incoming chan *Message
+
+	mu              sync.Mutex
+	sessionID       string
+	protocolVersion string
+	closed          bool
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+var _ Transport = (*HTTPTransport)(nil)
+
+type HTTPOption func(*HTTPTransport)
+
+// WithHTTPClient sets the HTTP client (default http.DefaultClient).
+func WithHTTPClient(c *http.Client) HTTPOption {
+	return func(t *HTTPTransport) { t.client = c }
+}
+
+// WithHTTPHeader adds a header to every request, e.g. Authorization.
+func WithHTTPHeader(key, value string) HTTPOption {
+	return func(t *HTTPTransport) { t.headers.Add(key, value) }
+}
+
+// WithOutboundURLOptions relaxes endpoint validation, e.g. to reach an MCP
+// server on localhost. By default only public HTTPS endpoints are accepted.
+func WithOutboundURLOptions(o security.OutboundURLOptions) HTTPOption {
+	return func(t *HTTPTransport) { t.outbound = o }
+}
+
+// NewHTTPTransport creates a streamable HTTP transport for endpoint. The
+// endpoint is checked with security.ValidateOutboundURL.
+func NewHTTPTransport(endpoint string, opts ...HTTPOption) (*HTTPTransport, error) {
+	t := &HTTPTransport{
+		endpoint: endpoint,
+		client:   http.DefaultClient,
+		headers:  http.Header{},
+		outbound: security.OutboundURLOptions{},
+		incoming: make(chan *Message, 16),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(t)
+		}
+	}
+	if t.client == nil {
+		t.client = http.DefaultClient
+	}
+	if err := security.ValidateOutboundURL(endpoint, t.outbound); err != nil {
+		return nil, fmt.Errorf("mcp: invalid endpoint URL: %w", err)
+	}
+	return t, nil
+}
+
+// SessionID returns the session id assigned by the server, if any.
+func (t *HTTPTransport) SessionID() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sessionID
+}
+
+func (t *HTTPTransport) setProtocolVersion(v string) {
+	t.mu.Lock()
+	t.protocolVersion = v
+	t.mu.Unlock()
+}
+
+func (t *HTTPTransport) newRequest(ctx context.Context, method string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, t.endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("mcp: build http request: %w", err)
+	}
+	for k, vs := range t.headers {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	t.mu.Lock()
+	if t.sessionID != "" {
+		req.Header.Set(headerSessionID, t.sessionID)
+	}
+	if t.protocolVersion != "" {
+		req.Header.Set(headerProtocolVersion, t.protocolVersion)
+	}
+	t.mu.Unlock()
+	return req, nil
+}
+
+// begin registers an in-flight send so Close can wait for it before closing
+// the incoming channel.
+func (t *HTTPTransport) begin() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return false
+	}
+	t.wg.Add(1)
+	return true
+}
+
+func (t *HTTPTransport) Send(ctx context.Context, msg *Message) error {
+	if !t.begin() {
+		return ErrClosed
+	}
+	defer t.wg.Done()
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("mcp: marshal message: %w", err)
+	}
+	req, err := t.newRequest(ctx, http.MethodPost, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentTypeJSON)
+	req.Header.Set("Accept", contentTypeJSON+", "+contentTypeEventStream)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("mcp: http post: %w", err)
+	}
+	if sid := resp.Header.Get(headerSessionID); sid != "" {
+		t.mu.Lock()
+		t.sessionID = sid
+		t.mu.Unlock()
+	}
+	if resp.StatusCode == http.StatusAccepted || resp.StatusCode == http.StatusNoContent {
+		_ = resp.Body.Close()
+		return nil
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("mcp: http status=%d %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case contentTypeEventStream:
+		// Read the stream in the background; the response arrives through
+		// Messages like every other incoming message.
+		t.wg.Add(1)
+		go func() {
+			defer t.wg.Done()
+			stop := make(chan struct{})
+			defer close(stop)
+			go func() {
+				// Unblock the reader when the transport closes mid-stream.
+				select {
+				case <-t.done:
+					_ = resp.Body.Close()
+				case <-stop:
+				}
+			}()
+			defer resp.Body.Close()
+			if err := t.readEventStream(resp.Body); err != nil {
+				select {
+				case <-t.done:
+				default:
+					log.Warn().Err(err).Msg("mcp: sse stream stopped")
+				}
+			}
+		}()
+		return nil
+	default:
+		defer resp.Body.Close()
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxMessageBytes+1))
+		if err != nil {
+			return fmt.Errorf("mcp: read http response: %w", err)
+		}
+		if len(body) > maxMessageBytes {
+			return fmt.Errorf("mcp: http response exceeds %d bytes", maxMessageBytes)
+		}
+		return t.deliverPayload(body)
+	}
+}
+
+// readEventStream delivers the events of an SSE response. It stops with an
+// error when a line or an event exceeds maxMessageBytes.
+func (t *HTTPTransport) readEventStream(r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), maxMessageBytes)
+	var data bytes.Buffer
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case line == "":
+			if data.Len() > 0 {
+				if derr := t.deliverPayload(data.Bytes()); derr != nil {
+					log.Warn().Err(derr).Msg("mcp: dropping malformed sse event")
+				}
+				data.Reset()
+			}
+		case strings.HasPrefix(line, "data:"):
+			value := strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " ")
+			if data.Len()+1+len(value) > maxMessageBytes {
+				return fmt.Errorf("mcp: sse event exceeds %d bytes", maxMessageBytes)
+			}
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return fmt.Errorf("mcp: sse line exceeds %d bytes", maxMessageBytes)
+		}
+		return fmt.Errorf("mcp: read sse stream: %w", err)
+	}
+	if data.Len() > 0 {
+		return t.deliverPayload(data.Bytes())
+	}
+	return nil
+}
+
+// deliverPayload decodes one message or a batch and queues it.
+func (t *HTTPTransport) deliverPayload(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return nil
+	}
+	var msgs []*Message
+	if b[0] == '[' {
+		if err := json.Unmarshal(b, &msgs); err != nil {
+			return fmt.Errorf("mcp: decode message batch: %w", err)
+		}
+	} else {
+		var m Message
+		if err := json.Unmarshal(b, &m); err != nil {
+			return fmt.Errorf("mcp: decode message: %w", err)
+		}
+		msgs = append(msgs, &m)
+	}
+	for _, m := range msgs {
+		select {
+		case t.incoming <- m:
+		case <-t.done:
+			return ErrClosed
+		}
+	}
+	return nil
+}
+
+func (t *HTTPTransport) Messages() <-chan *Message { return t.incoming }
+
+// Close ends the session on the server (best effort) and stops delivery.
+func (t *HTTPTransport) Close() error {
+	t.closeOnce.Do(func() {
+		t.mu.Lock()
+		t.closed = true
+		t.mu.Unlock()
+		if t.SessionID() != "" {
+			if req, err := t.newRequest(context.Background(), http.MethodDelete, nil); err == nil {
+				if resp, err := t.client.Do(req); err == nil {
+					_ = resp.Body.Close()
+				}
+			}
+		}
+		close(t.done)
+		t.wg.Wait()
+		close(t.incoming)
+	})
+	return nil
+}
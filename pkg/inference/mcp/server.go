@@ -0,0 +1,310 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/inference/tools"
+	"github.com/google/uuid"
+)
+
+// Server publishes a tools.ToolRegistry as an MCP server. Calls run through a
+// tools.ToolExecutor, so tool lifecycle events reach the configured event
+// sinks exactly as they do inside the tool loop.
+type Server struct {
+	registry       tools.ToolRegistry
+	executor       tools.ToolExecutor
+	info           Implementation
+	instructions   string
+	sinks          []events.EventSink
+	allowedOrigins map[string]bool
+
+	sessionsMu  sync.Mutex
+	sessions    map[string]time.Time // session ID -> last use
+	idleTimeout time.Duration
+	maxSessions int
+	now         func() time.Time
+}
+
+const (
+	// DefaultSessionIdleTimeout expires HTTP sessions that send no request.
+	DefaultSessionIdleTimeout = 30 * time.Minute
+	// DefaultMaxSessions caps concurrent HTTP sessions; initialize evicts the
+	// least recently used session beyond it.
+	DefaultMaxSessions = 1024
+)
+
+type ServerOption func(*Server)
+
+// WithServerInfo sets the implementation info returned from initialize.
+func WithServerInfo(info Implementation) ServerOption {
+	return func(s *Server) { s.info = info }
+}
+
+// WithInstructions sets the instructions returned from initialize.
+func WithInstructions(instructions string) ServerOption {
+	return func(s *Server) { s.instructions = instructions }
+}
+
+// WithServerToolExecutor replaces the default tools.DefaultToolExecutor.
+func WithServerToolExecutor(exec tools.ToolExecutor) ServerOption {
+	return func(s *Server) { s.executor = exec }
+}
+
+// WithServerEventSinks attaches sinks that receive tool lifecycle events.
+func WithServerEventSinks(sinks ...events.EventSink) ServerOption {
+	return func(s *Server) { s.sinks = append(s.sinks, sinks...) }
+}
+
+// WithAllowedOrigins lists browser origins accepted by the HTTP handler. By
+// default only loopback origins are accepted when an Origin header is present,
+// which protects local servers against DNS rebinding.
+func WithAllowedOrigins(origins ...string) ServerOption {
+	return func(s *Server) {
+		for _, o := range origins {
+			s.allowedOrigins[strings.TrimRight(o, "/")] = true
+		}
+	}
+}
+
+// WithSessionIdleTimeout expires HTTP sessions unused for d. Zero or less
+// keeps sessions until DELETE or eviction.
+func WithSessionIdleTimeout(d time.Duration) ServerOption {
+	return func(s *Server) { s.idleTimeout = d }
+}
+
+// WithMaxSessions caps concurrent HTTP sessions. Zero or less removes the cap.
+func WithMaxSessions(n int) ServerOption {
+	return func(s *Server) { s.maxSessions = n }
+}
+
+// NewServer creates a server exposing reg.
+func NewServer(reg tools.ToolRegistry, opts ...ServerOption) *Server {
+	s := &Server{
+		registry:       reg,
+		info:           Implementation{Name: "geppetto", Version: "dev"},
+		allowedOrigins: map[string]bool{},
+		sessions:       map[string]time.Time{},
+		idleTimeout:    DefaultSessionIdleTimeout,
+		maxSessions:    DefaultMaxSessions,
+		now:            time.Now,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(s)
+		}
+	}
+	if s.registry == nil {
+		s.registry = tools.NewInMemoryToolRegistry()
+	}
+	if s.executor == nil {
+		s.executor = tools.NewDefaultToolExecutor(tools.DefaultToolConfig())
+	}
+	return s
+}
+
+// HandleMessage processes one incoming message and returns the response, or
+// nil for notifications and responses.
+func (s *Server) HandleMessage(ctx context.Context, msg *Message) *Message {
+	if msg == nil {
+		return nil
+	}
+	if !msg.IsRequest() {
+		return nil
+	}
+	if msg.JSONRPC != jsonRPCVersion {
+		return newErrorResponse(msg.ID, CodeInvalidRequest, "jsonrpc must be \"2.0\"")
+	}
+	var (
+		result any
+		rpcErr *RPCError
+	)
+	switch msg.Method {
+	case MethodInitialize:
+		result, rpcErr = s.initialize(msg.Params)
+	case MethodPing:
+		result = struct{}{}
+	case MethodToolsList:
+		result, rpcErr = s.listTools()
+	case MethodToolsCall:
+		result, rpcErr = s.callTool(ctx, msg.Params)
+	default:
+		rpcErr = &RPCError{Code: CodeMethodNotFound, Message: "method not found: " + msg.Method}
+	}
+	if rpcErr != nil {
+		return newErrorResponse(msg.ID, rpcErr.Code, rpcErr.Message)
+	}
+	resp, err := newResult(msg.ID, result)
+	if err != nil {
+		return newErrorResponse(msg.ID, CodeInternalError, err.Error())
+	}
+	return resp
+}
+
+func (s *Server) initialize(params json.RawMessage) (*InitializeResult, *RPCError) {
+	var p InitializeParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: "invalid initialize params: " + err.Error()}
+		}
+	}
+	version := ProtocolVersion
+	if supportedProtocolVersions[p.ProtocolVersion] {
+		version = p.ProtocolVersion
+	}
+	return &InitializeResult{
+		ProtocolVersion: version,
+		Capabilities:    ServerCapabilities{Tools: &ToolsCapability{}},
+		ServerInfo:      s.info,
+		Instructions:    s.instructions,
+	}, nil
+}
+
+func (s *Server) listTools() (*ListToolsResult, *RPCError) {
+	defs := s.registry.ListTools()
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	out := &ListToolsResult{Tools: make([]Tool, 0, len(defs))}
+	for _, def := range defs {
+		schema := json.RawMessage(`{"type":"object"}`)
+		if def.Parameters != nil {
+			b, err := json.Marshal(def.Parameters)
+			if err != nil {
+				return nil, &RPCError{Code: CodeInternalError, Message: fmt.Sprintf("marshal schema for %s: %v", def.Name, err)}
+			}
+			schema = b
+		}
+		out.Tools = append(out.Tools, Tool{Name: def.Name, Description: def.Description, InputSchema: schema})
+	}
+	return out, nil
+}
+
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (*CallToolResult, *RPCError) {
+	var p CallToolParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &RPCError{Code: CodeInvalidParams, Message: "invalid tools/call params: " + err.Error()}
+	}
+	if _, err := s.registry.GetTool(p.Name); err != nil {
+		return nil, &RPCError{Code: CodeInvalidParams, Message: "unknown tool: " + p.Name}
+	}
+	args := p.Arguments
+	if len(args) == 0 || string(args) == "null" {
+		args = json.RawMessage("{}")
+	}
+	if len(s.sinks) > 0 {
+		ctx = events.WithEventSinks(ctx, s.sinks...)
+	}
+	call := tools.ToolCall{ID: uuid.NewString(), Name: p.Name, Arguments: args}
+	res, err := s.executor.ExecuteToolCall(ctx, call, s.registry)
+	if err != nil {
+		return &CallToolResult{Content: []Content{TextContent(err.Error())}, IsError: true}, nil
+	}
+	return toolResultToCallResult(res), nil
+}
+
+// toolResultToCallResult maps an executor result to MCP content. Strings are
+// returned as text; other values as JSON text, with JSON objects also set as
+// structuredContent.
+func toolResultToCallResult(res *tools.ToolResult) *CallToolResult {
+	if res == nil {
+		return &CallToolResult{Content: []Content{}}
+	}
+	if res.Error != "" {
+		return &CallToolResult{Content: []Content{TextContent(res.Error)}, IsError: true}
+	}
+	switch v := res.Result.(type) {
+	case nil:
+		return &CallToolResult{Content: []Content{}}
+	case string:
+		return &CallToolResult{Content: []Content{TextContent(v)}}
+	}
+	b, err := json.Marshal(res.Result)
+	if err != nil {
+		return &CallToolResult{Content: []Content{TextContent(fmt.Sprintf("marshal tool result: %v", err))}, IsError: true}
+	}
+	out := &CallToolResult{Content: []Content{TextContent(string(b))}}
+	if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 && trimmed[0] == '{' {
+		out.StructuredContent = json.RawMessage(trimmed)
+	}
+	return out
+}
+
+// ServeStdio serves newline-delimited JSON-RPC on r and w until r is exhausted
+// or ctx is canceled. Requests are handled concurrently; a
+// notifications/cancelled message cancels the matching in-flight call.
+func (s *Server) ServeStdio(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wmu      sync.Mutex
+		wg       sync.WaitGroup
+		inflight sync.Map // request id -> context.CancelFunc
+	)
+	write := func(m *Message) {
+		b, err := json.Marshal(m)
+		if err != nil {
+			log.Warn().Err(err).Msg("mcp: marshal response")
+			return
+		}
+		wmu.Lock()
+		defer wmu.Unlock()
+		if _, err := w.Write(append(b, '\n')); err != nil {
+			log.Debug().Err(err).Msg("mcp: write response")
+		}
+	}
+
+	br := bufio.NewReader(r)
+	var readErr error
+	for ctx.Err() == nil {
+		line, err := br.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var msg Message
+			if uerr := json.Unmarshal(line, &msg); uerr != nil {
+				write(newErrorResponse(nil, CodeParseError, "parse error: "+uerr.Error()))
+			} else if msg.Method == NotificationCancelled {
+				var p struct {
+					RequestID json.RawMessage `json:"requestId"`
+				}
+				if json.Unmarshal(msg.Params, &p) == nil {
+					if c, ok := inflight.Load(string(p.RequestID)); ok {
+						c.(context.CancelFunc)()
+					}
+				}
+			} else if msg.IsRequest() {
+				reqCtx, reqCancel := context.WithCancel(ctx)
+				key := string(msg.ID)
+				inflight.Store(key, reqCancel)
+				wg.Add(1)
+				go func(m Message) {
+					defer wg.Done()
+					defer inflight.Delete(key)
+					defer reqCancel()
+					if resp := s.HandleMessage(reqCtx, &m); resp != nil && reqCtx.Err() == nil {
+						write(resp)
+					}
+				}(msg)
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				readErr = err
+			}
+			break
+		}
+	}
+	wg.Wait()
+	if readErr != nil {
+		return fmt.Errorf("mcp: read stdio: %w", readErr)
+	}
+	return ctx.Err()
+}
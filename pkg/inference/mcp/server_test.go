@@ -0,0 +1,208 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/inference/tools"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+)
+
+type weatherInput struct {
+	City string `json:"city" jsonschema:"required"`
+}
+
+type weatherOutput struct {
+	City  string  `json:"city"`
+	TempC float64 `json:"temp_c"`
+}
+
+type eventCapture struct {
+	mu    sync.Mutex
+	types []events.EventType
+}
+
+func (c *eventCapture) PublishEvent(e events.Event) error {
+	c.mu.Lock()
+	c.types = append(c.types, e.Type())
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *eventCapture) has(t events.EventType) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, got := range c.types {
+		if got == t {
+			return true
+		}
+	}
+	return false
+}
+
+func newTestRegistry(t *testing.T) tools.ToolRegistry {
+	t.Helper()
+	reg := tools.NewInMemoryToolRegistry()
+	def, err := tools.NewToolFromFunc("get_weather", "Current weather for a city", func(in weatherInput) (weatherOutput, error) {
+		return weatherOutput{City: in.City, TempC: 21.5}, nil
+	})
+	if err != nil {
+		t.Fatalf("NewToolFromFunc: %v", err)
+	}
+	if err := reg.RegisterTool(def.Name, *def); err != nil {
+		t.Fatalf("RegisterTool: %v", err)
+	}
+	return reg
+}
+
+func TestServerRoundTripWithClient(t *testing.T) {
+	transports := map[string]func(t *testing.T, s *Server) Transport{
+		"stdio": func(t *testing.T, s *Server) Transport {
+			clientR, serverW := io.Pipe()
+			serverR, clientW := io.Pipe()
+			go func() {
+				_ = s.ServeStdio(context.Background(), serverR, serverW)
+				_ = serverW.Close()
+			}()
+			return NewStdioTransport(clientR, clientW, clientW.Close)
+		},
+		"http": func(t *testing.T, s *Server) Transport {
+			srv := httptest.NewServer(s)
+			t.Cleanup(srv.Close)
+			return localHTTPTransport(t, srv.URL)
+		},
+	}
+	for name, mk := range transports {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			sink := &eventCapture{}
+			server := NewServer(newTestRegistry(t), WithServerEventSinks(sink), WithServerInfo(Implementation{Name: "weather", Version: "1.0"}))
+
+			client, err := Connect(ctx, mk(t, server))
+			if err != nil {
+				t.Fatalf("Connect: %v", err)
+			}
+			defer func() { _ = client.Close() }()
+			if client.ServerInfo().ServerInfo.Name != "weather" {
+				t.Fatalf("unexpected server info %+v", client.ServerInfo())
+			}
+
+			list, err := client.ListTools(ctx)
+			if err != nil {
+				t.Fatalf("ListTools: %v", err)
+			}
+			if len(list) != 1 || list[0].Name != "get_weather" || !strings.Contains(string(list[0].InputSchema), `"city"`) {
+				t.Fatalf("unexpected tools: %+v", list)
+			}
+
+			res, err := client.CallTool(ctx, "get_weather", json.RawMessage(`{"city":"Paris"}`))
+			if err != nil {
+				t.Fatalf("CallTool: %v", err)
+			}
+			if res.IsError || len(res.Content) != 1 || !strings.Contains(res.Content[0].Text, "Paris") {
+				t.Fatalf("unexpected result: %+v", res)
+			}
+			structured, ok := res.StructuredContent.(map[string]any)
+			if !ok || structured["temp_c"] != 21.5 {
+				t.Fatalf("unexpected structured content: %#v", res.StructuredContent)
+			}
+			if !sink.has(events.EventTypeToolResultReady) {
+				t.Fatalf("expected tool events on the server sink, got %v", sink.types)
+			}
+
+			if _, err := client.CallTool(ctx, "missing", nil); err == nil {
+				t.Fatalf("expected unknown tool error")
+			}
+		})
+	}
+}
+
+func TestServerHTTPRejectsUnknownSessionAndForeignOrigin(t *testing.T) {
+	server := NewServer(newTestRegistry(t))
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	post := func(body, session, origin string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentTypeJSON)
+		if session != "" {
+			req.Header.Set(headerSessionID, session)
+		}
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("post: %v", err)
+		}
+		_ = resp.Body.Close()
+		return resp
+	}
+
+	ping := `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+	if resp := post(ping, "", ""); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 without session, got %d", resp.StatusCode)
+	}
+	if resp := post(ping, "nope", ""); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown session, got %d", resp.StatusCode)
+	}
+	initBody := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"t","version":"1"}}}`
+	if resp := post(initBody, "", "https://evil.example"); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for foreign origin, got %d", resp.StatusCode)
+	}
+	resp := post(initBody, "", "http://localhost:3000")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get(headerSessionID) == "" {
+		t.Fatalf("expected initialize to succeed with a session, got %d", resp.StatusCode)
+	}
+}
+
+func TestServerHTTPSessionsExpireAndEvict(t *testing.T) {
+	now := time.Unix(0, 0)
+	server := NewServer(newTestRegistry(t), WithSessionIdleTimeout(time.Minute), WithMaxSessions(2))
+	server.now = func() time.Time { return now }
+
+	idle := server.newSession()
+	now = now.Add(2 * time.Minute)
+	if server.hasSession(idle) {
+		t.Fatalf("expected idle session to expire")
+	}
+
+	first := server.newSession()
+	now = now.Add(time.Second)
+	second := server.newSession()
+	now = now.Add(time.Second)
+	if !server.hasSession(first) {
+		t.Fatalf("expected first session to be live")
+	}
+	now = now.Add(time.Second)
+	third := server.newSession()
+	if server.hasSession(second) {
+		t.Fatalf("expected least recently used session to be evicted")
+	}
+	if !server.hasSession(first) || !server.hasSession(third) {
+		t.Fatalf("expected recently used sessions to survive eviction")
+	}
+	if n := len(server.sessions); n != 2 {
+		t.Fatalf("expected 2 sessions, got %d", n)
+	}
+}
+
+func TestLastAssistantText(t *testing.T) {
+	tt := &turns.Turn{}
+	turns.AppendBlock(tt, turns.NewUserTextBlock("question"))
+	turns.AppendBlock(tt, turns.NewAssistantTextBlock("first"))
+	turns.AppendBlock(tt, turns.NewToolCallBlock("c1", "lookup", map[string]any{}))
+	turns.AppendBlock(tt, turns.NewAssistantTextBlock("final answer"))
+	if got := lastAssistantText(tt); got != "final answer" {
+		t.Fatalf("unexpected text %q", got)
+	}
+}
@@ -0,0 +1,188 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/runner"
+	"github.com/go-go-golems/geppetto/pkg/inference/tools"
+	"github.com/invopop/jsonschema"
+)
+
+// maxToolNameLength is the strictest provider limit on tool names (OpenAI).
+const maxToolNameLength = 64
+
+var invalidToolNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+type registerConfig struct {
+	prefix string
+	filter func(Tool) bool
+	tags   []string
+}
+
+type RegisterOption func(*registerConfig)
+
+// WithToolPrefix prepends prefix to every registered tool name, e.g. "github_",
+// to avoid collisions between servers.
+func WithToolPrefix(prefix string) RegisterOption {
+	return func(c *registerConfig) { c.prefix = prefix }
+}
+
+// WithToolFilter registers only the remote tools for which keep returns true.
+func WithToolFilter(keep func(Tool) bool) RegisterOption {
+	return func(c *registerConfig) { c.filter = keep }
+}
+
+// WithToolNames registers only the named remote tools.
+func WithToolNames(names ...string) RegisterOption {
+	allowed := map[string]bool{}
+	for _, n := range names {
+		allowed[n] = true
+	}
+	return WithToolFilter(func(t Tool) bool { return allowed[t.Name] })
+}
+
+// WithToolTags adds tags to every registered tool definition. Tools are always
+// tagged "mcp".
+func WithToolTags(tags ...string) RegisterOption {
+	return func(c *registerConfig) { c.tags = append(c.tags, tags...) }
+}
+
+// RegisterTools lists the server's tools and registers each one into reg. The
+// registered tool functions forward calls to the server, so they run through any
+// tools.ToolExecutor like local tools.
+func (c *Client) RegisterTools(ctx context.Context, reg tools.ToolRegistry, opts ...RegisterOption) error {
+	if reg == nil {
+		return fmt.Errorf("tool registry is nil")
+	}
+	cfg := &registerConfig{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(cfg)
+		}
+	}
+	remote, err := c.ListTools(ctx)
+	if err != nil {
+		return err
+	}
+	for _, t := range remote {
+		if cfg.filter != nil && !cfg.filter(t) {
+			continue
+		}
+		def, err := c.ToolDefinition(t, cfg.prefix+t.Name)
+		if err != nil {
+			return err
+		}
+		def.Tags = append(append([]string{"mcp"}, def.Tags...), cfg.tags...)
+		if err := reg.RegisterTool(def.Name, *def); err != nil {
+			return fmt.Errorf("register mcp tool %s: %w", def.Name, err)
+		}
+	}
+	return nil
+}
+
+// ToolRegistrar returns a runner.ToolRegistrar that registers the server's
+// tools each time a runner builds its registry.
+func ToolRegistrar(c *Client, opts ...RegisterOption) runner.ToolRegistrar {
+	return func(ctx context.Context, reg tools.ToolRegistry) error {
+		if c == nil {
+			return fmt.Errorf("mcp client is nil")
+		}
+		return c.RegisterTools(ctx, reg, opts...)
+	}
+}
+
+// ToolDefinition converts a remote tool into a Geppetto tool definition named
+// name (sanitized to provider-safe characters) that calls the tool through c.
+func (c *Client) ToolDefinition(t Tool, name string) (*tools.ToolDefinition, error) {
+	remoteName := t.Name
+	def, err := tools.NewToolFromFunc(SanitizeToolName(name), toolDescription(t),
+		func(ctx context.Context, args map[string]any) (any, error) {
+			raw, err := json.Marshal(args)
+			if err != nil {
+				return nil, fmt.Errorf("marshal arguments: %w", err)
+			}
+			res, err := c.CallTool(ctx, remoteName, raw)
+			if err != nil {
+				return nil, err
+			}
+			return callResultValue(res)
+		})
+	if err != nil {
+		return nil, fmt.Errorf("create mcp tool %s: %w", t.Name, err)
+	}
+	schema, err := convertInputSchema(t.InputSchema)
+	if err != nil {
+		return nil, fmt.Errorf("mcp tool %s: %w", t.Name, err)
+	}
+	def.Parameters = schema
+	return def, nil
+}
+
+// SanitizeToolName replaces characters providers reject in tool names and
+// truncates to 64 characters.
+func SanitizeToolName(name string) string {
+	name = invalidToolNameChars.ReplaceAllString(name, "_")
+	if len(name) > maxToolNameLength {
+		name = name[:maxToolNameLength]
+	}
+	return name
+}
+
+func toolDescription(t Tool) string {
+	desc := strings.TrimSpace(t.Description)
+	if desc == "" {
+		desc = strings.TrimSpace(t.Title)
+	}
+	return desc
+}
+
+func convertInputSchema(raw json.RawMessage) (*jsonschema.Schema, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return &jsonschema.Schema{Type: "object"}, nil
+	}
+	var schema jsonschema.Schema
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return nil, fmt.Errorf("decode input schema: %w", err)
+	}
+	if schema.Type == "" && schema.Ref == "" {
+		schema.Type = "object"
+	}
+	return &schema, nil
+}
+
+// callResultValue maps a tool result to the value returned to the model:
+// structured content when present, plain text when all content is text, and
+// the raw content list otherwise. IsError results become errors.
+func callResultValue(res *CallToolResult) (any, error) {
+	text, allText := joinText(res.Content)
+	if res.IsError {
+		if text == "" {
+			text = "tool reported an error"
+		}
+		return nil, fmt.Errorf("%s", text)
+	}
+	if res.StructuredContent != nil {
+		return res.StructuredContent, nil
+	}
+	if allText {
+		return text, nil
+	}
+	return res.Content, nil
+}
+
+func joinText(content []Content) (string, bool) {
+	parts := make([]string, 0, len(content))
+	allText := true
+	for _, c := range content {
+		if c.Type != "text" {
+			allText = false
+			continue
+		}
+		parts = append(parts, c.Text)
+	}
+	return strings.Join(parts, "\n"), allText
+}
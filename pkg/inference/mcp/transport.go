@@ -0,0 +1,178 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// maxMessageBytes caps one incoming JSON-RPC message: a stdio line, an SSE line
+// or event, or a JSON response body.
+const maxMessageBytes = 16 << 20
+
+// ErrClosed is returned when a transport or client has been closed.
+var ErrClosed = errors.New("mcp: connection closed")
+
+// Transport carries JSON-RPC messages to and from one MCP peer.
+type Transport interface {
+	// Send delivers one message to the peer.
+	Send(ctx context.Context, msg *Message) error
+	// Messages returns the channel of incoming messages. It is closed when the
+	// connection ends.
+	Messages() <-chan *Message
+	// Close releases the connection.
+	Close() error
+}
+
+// StdioTransport exchanges newline-delimited JSON-RPC messages over a reader
+// and writer, as used by MCP servers launched as subprocesses.
+type StdioTransport struct {
+	w        io.Writer
+	closer   func() error
+	incoming chan *Message
+
+	wmu       sync.Mutex
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+var _ Transport = (*StdioTransport)(nil)
+
+// NewStdioTransport reads messages from r and writes them to w. closer, when
+// not nil, is called by Close after reading stops.
+func NewStdioTransport(r io.Reader, w io.Writer, closer func() error) *StdioTransport {
+	t := &StdioTransport{
+		w:        w,
+		closer:   closer,
+		incoming: make(chan *Message, 16),
+		done:     make(chan struct{}),
+	}
+	go t.readLoop(r)
+	return t
+}
+
+// NewCommandTransport starts name with args and speaks MCP over its stdin and
+// stdout. Stderr output is logged at debug level. Close closes stdin and waits
+// briefly for the process to exit before killing it.
+func NewCommandTransport(ctx context.Context, name string, args ...string) (*StdioTransport, error) {
+	return newCommandTransport(exec.CommandContext(ctx, name, args...))
+}
+
+// NewCmdTransport is like NewCommandTransport for a prepared command, e.g.
+// one with a custom environment or working directory.
+func NewCmdTransport(cmd *exec.Cmd) (*StdioTransport, error) {
+	return newCommandTransport(cmd)
+}
+
+func newCommandTransport(cmd *exec.Cmd) (*StdioTransport, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("mcp: stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("mcp: stdout pipe: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("mcp: stderr pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("mcp: start %s: %w", cmd.Path, err)
+	}
+	go func() {
+		sc := bufio.NewScanner(stderr)
+		for sc.Scan() {
+			log.Debug().Str("command", cmd.Path).Str("stderr", sc.Text()).Msg("mcp server output")
+		}
+	}()
+
+	closer := func() error {
+		_ = stdin.Close()
+		exited := make(chan error, 1)
+		go func() { exited <- cmd.Wait() }()
+		select {
+		case <-exited:
+			return nil
+		case <-time.After(2 * time.Second):
+			_ = cmd.Process.Kill()
+			<-exited
+			return nil
+		}
+	}
+	return NewStdioTransport(stdout, stdin, closer), nil
+}
+
+// readLoop delivers one message per line. A line over maxMessageBytes closes
+// the transport.
+func (t *StdioTransport) readLoop(r io.Reader) {
+	defer close(t.incoming)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), maxMessageBytes)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var msg Message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			log.Warn().Err(err).Msg("mcp: dropping malformed stdio message")
+			continue
+		}
+		select {
+		case t.incoming <- &msg:
+		case <-t.done:
+			return
+		}
+	}
+	if err := sc.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			log.Warn().Int("limit", maxMessageBytes).Msg("mcp: stdio message too large, closing transport")
+			_ = t.Close()
+			return
+		}
+		log.Debug().Err(err).Msg("mcp: stdio read stopped")
+	}
+}
+
+func (t *StdioTransport) Send(ctx context.Context, msg *Message) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("mcp: marshal message: %w", err)
+	}
+	b = append(b, '\n')
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case <-t.done:
+		return ErrClosed
+	default:
+	}
+	t.wmu.Lock()
+	defer t.wmu.Unlock()
+	if _, err := t.w.Write(b); err != nil {
+		return fmt.Errorf("mcp: write message: %w", err)
+	}
+	return nil
+}
+
+func (t *StdioTransport) Messages() <-chan *Message { return t.incoming }
+
+func (t *StdioTransport) Close() error {
+	t.closeOnce.Do(func() {
+		close(t.done)
+		if t.closer != nil {
+			t.closeErr = t.closer()
+		}
+	})
+	return t.closeErr
+}
@@ -0,0 +1,405 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/inference/tokencount"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/pkg/errors"
+)
+
+// ContextWindowStrategy names one way of shrinking a Turn that exceeds its token budget.
+type ContextWindowStrategy string
+
+const (
+	// ContextWindowStrategyTruncateToolResults shortens large tool results, oldest first.
+	ContextWindowStrategyTruncateToolResults ContextWindowStrategy = "truncate_tool_results"
+	// ContextWindowStrategyDropOldest removes the oldest exchanges (a user block and
+	// everything that answered it).
+	ContextWindowStrategyDropOldest ContextWindowStrategy = "drop_oldest"
+	// ContextWindowStrategySummarize replaces the oldest exchanges with a summary
+	// produced by ContextWindowConfig.Summarizer.
+	ContextWindowStrategySummarize ContextWindowStrategy = "summarize"
+)
+
+const (
+	// ContextWindowMiddlewareName is the value written to turns.KeyBlockMetaMiddleware
+	// on blocks produced or rewritten by the context window middleware.
+	ContextWindowMiddlewareName = "contextwindow"
+
+	// DefaultContextWindowMaxToolResultChars is the size tool results are cut to
+	// by the truncate_tool_results strategy.
+	DefaultContextWindowMaxToolResultChars = 4000
+
+	// DefaultContextWindowSummaryPrompt instructs the summarizer engine.
+	DefaultContextWindowSummaryPrompt = "Summarize the following conversation excerpt for an assistant that will continue the conversation. " +
+		"Keep decisions, facts, open questions, and tool results that are still relevant. Be concise."
+
+	contextWindowMaxRounds     = 4
+	contextWindowSummaryPrefix = "Summary of earlier conversation:\n"
+)
+
+// DefaultContextWindowStrategies is used when ContextWindowConfig.Strategies is empty.
+var DefaultContextWindowStrategies = []ContextWindowStrategy{
+	ContextWindowStrategyTruncateToolResults,
+	ContextWindowStrategyDropOldest,
+}
+
+// ContextWindowConfig configures NewContextWindowMiddleware.
+type ContextWindowConfig struct {
+	// Counter counts the outgoing Turn. Nil falls back to tokencount.EstimateCounter.
+	Counter tokencount.Counter
+	// ModelInfo supplies the budget: QualityHighWatermark when set, else ContextWindow.
+	ModelInfo *settings.ModelInfo
+	// MaxTokens overrides the budget derived from ModelInfo.
+	MaxTokens int
+	// ReserveTokens is subtracted from the budget to leave room for the response.
+	ReserveTokens int
+	// Strategies are applied in order until the Turn fits.
+	Strategies []ContextWindowStrategy
+	// MaxToolResultChars bounds tool results for the truncate_tool_results strategy.
+	MaxToolResultChars int
+	// Summarizer runs the summarize strategy; the strategy is skipped when nil.
+	Summarizer engine.Engine
+	// SummaryPrompt overrides DefaultContextWindowSummaryPrompt.
+	SummaryPrompt string
+}
+
+// TokenLimit returns the input token budget, or 0 when no budget is configured.
+func (c ContextWindowConfig) TokenLimit() int {
+	limit := c.MaxTokens
+	if limit <= 0 && c.ModelInfo != nil {
+		switch {
+		case c.ModelInfo.QualityHighWatermark != nil && *c.ModelInfo.QualityHighWatermark > 0:
+			limit = *c.ModelInfo.QualityHighWatermark
+		case c.ModelInfo.ContextWindow != nil && *c.ModelInfo.ContextWindow > 0:
+			limit = *c.ModelInfo.ContextWindow
+		}
+	}
+	if limit <= 0 {
+		return 0
+	}
+	limit -= c.ReserveTokens
+	if limit < 1 {
+		limit = 1
+	}
+	return limit
+}
+
+// NewContextWindowMiddleware returns a middleware that counts the outgoing Turn
+// before each inference and, when it exceeds the budget, applies the configured
+// strategies until it fits. System blocks and the latest exchange are never
+// removed, and a tool_call is never separated from its tool_use.
+//
+// Reductions are applied to the Turn in place, so a session persisting the
+// Turn keeps the compacted history.
+func NewContextWindowMiddleware(cfg ContextWindowConfig) Middleware {
+	if cfg.Counter == nil {
+		cfg.Counter = tokencount.NewEstimateCounter("", "")
+	}
+	if len(cfg.Strategies) == 0 {
+		cfg.Strategies = DefaultContextWindowStrategies
+	}
+	if cfg.MaxToolResultChars <= 0 {
+		cfg.MaxToolResultChars = DefaultContextWindowMaxToolResultChars
+	}
+	if strings.TrimSpace(cfg.SummaryPrompt) == "" {
+		cfg.SummaryPrompt = DefaultContextWindowSummaryPrompt
+	}
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, t *turns.Turn) (*turns.Turn, error) {
+			limit := cfg.TokenLimit()
+			if t == nil || len(t.Blocks) == 0 || limit <= 0 {
+				return next(ctx, t)
+			}
+			if err := fitContextWindow(ctx, cfg, t, limit); err != nil {
+				return nil, err
+			}
+			return next(ctx, t)
+		}
+	}
+}
+
+func fitContextWindow(ctx context.Context, cfg ContextWindowConfig, t *turns.Turn, limit int) error {
+	count, err := countContextWindowTurn(ctx, cfg.Counter, t)
+	if err != nil {
+		return err
+	}
+	if count <= limit {
+		return nil
+	}
+	initial := count
+	for _, strategy := range cfg.Strategies {
+		for round := 0; count > limit && round < contextWindowMaxRounds; round++ {
+			changed, err := applyContextWindowStrategy(ctx, cfg, strategy, t, contextWindowExcessChars(t, count, limit))
+			if err != nil {
+				return err
+			}
+			if !changed {
+				break
+			}
+			if count, err = countContextWindowTurn(ctx, cfg.Counter, t); err != nil {
+				return err
+			}
+		}
+		if count <= limit {
+			break
+		}
+	}
+	ev := log.Debug()
+	if count > limit {
+		ev = log.Warn()
+	}
+	ev.Str("turn_id", t.ID).
+		Int("limit", limit).
+		Int("tokens_before", initial).
+		Int("tokens_after", count).
+		Int("block_count", len(t.Blocks)).
+		Msg("contextwindow: turn reduced")
+	return nil
+}
+
+func countContextWindowTurn(ctx context.Context, counter tokencount.Counter, t *turns.Turn) (int, error) {
+	res, err := counter.CountTurn(ctx, t)
+	if err != nil {
+		return 0, errors.Wrap(err, "contextwindow: count turn tokens")
+	}
+	if res == nil {
+		return 0, errors.New("contextwindow: token counter returned no result")
+	}
+	return res.InputTokens, nil
+}
+
+// contextWindowExcessChars converts the token overshoot into an approximate
+// number of payload characters using the Turn's observed chars-per-token ratio.
+func contextWindowExcessChars(t *turns.Turn, count, limit int) int {
+	chars := 0
+	for _, b := range t.Blocks {
+		chars += tokencount.BlockChars(b)
+	}
+	if count <= 0 || chars <= 0 {
+		return 0
+	}
+	return int(math.Ceil(float64(count-limit) * float64(chars) / float64(count)))
+}
+
+func applyContextWindowStrategy(ctx context.Context, cfg ContextWindowConfig, strategy ContextWindowStrategy, t *turns.Turn, excessChars int) (bool, error) {
+	switch strategy {
+	case ContextWindowStrategyTruncateToolResults:
+		return truncateContextWindowToolResults(t, cfg.MaxToolResultChars, excessChars)
+	case ContextWindowStrategyDropOldest:
+		return dropOldestContextWindowExchanges(t, excessChars), nil
+	case ContextWindowStrategySummarize:
+		return summarizeOldestContextWindowExchanges(ctx, cfg, t, excessChars)
+	default:
+		return false, errors.Errorf("contextwindow: unknown strategy %q", strategy)
+	}
+}
+
+// contextWindowExchanges splits the non-system blocks of a Turn into exchanges.
+// An exchange starts at a user block, unless a tool_call/tool_use pair spans
+// that boundary, in which case both sides stay in the same exchange.
+func contextWindowExchanges(blocks []turns.Block) [][]int {
+	first := map[string]int{}
+	last := map[string]int{}
+	for i, b := range blocks {
+		if b.Kind != turns.BlockKindToolCall && b.Kind != turns.BlockKindToolUse {
+			continue
+		}
+		id, _ := b.Payload[turns.PayloadKeyID].(string)
+		if id == "" {
+			continue
+		}
+		if _, ok := first[id]; !ok {
+			first[id] = i
+		}
+		last[id] = i
+	}
+	spanned := func(k int) bool {
+		for id, lo := range first {
+			if lo < k && k <= last[id] {
+				return true
+			}
+		}
+		return false
+	}
+
+	var groups [][]int
+	var current []int
+	for i, b := range blocks {
+		if b.Kind == turns.BlockKindSystem {
+			continue
+		}
+		if b.Kind == turns.BlockKindUser && len(current) > 0 && !spanned(i) {
+			groups = append(groups, current)
+			current = nil
+		}
+		current = append(current, i)
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+	return groups
+}
+
+// oldestContextWindowExchanges selects exchanges from the oldest onwards until
+// at least excessChars are covered. The latest exchange is never selected.
+func oldestContextWindowExchanges(blocks []turns.Block, excessChars int) []int {
+	groups := contextWindowExchanges(blocks)
+	var selected []int
+	covered := 0
+	for _, g := range groups[:max(len(groups)-1, 0)] {
+		selected = append(selected, g...)
+		for _, idx := range g {
+			covered += tokencount.BlockChars(blocks[idx])
+		}
+		if covered >= excessChars {
+			break
+		}
+	}
+	return selected
+}
+
+func dropOldestContextWindowExchanges(t *turns.Turn, excessChars int) bool {
+	selected := oldestContextWindowExchanges(t.Blocks, excessChars)
+	if len(selected) == 0 {
+		return false
+	}
+	t.Blocks = replaceContextWindowBlocks(t.Blocks, selected, nil)
+	return true
+}
+
+func summarizeOldestContextWindowExchanges(ctx context.Context, cfg ContextWindowConfig, t *turns.Turn, excessChars int) (bool, error) {
+	if cfg.Summarizer == nil {
+		log.Warn().Str("turn_id", t.ID).Msg("contextwindow: summarize strategy configured without a summarizer engine; skipping")
+		return false, nil
+	}
+	selected := oldestContextWindowExchanges(t.Blocks, excessChars)
+	if len(selected) == 0 {
+		return false, nil
+	}
+
+	excerpt := &turns.Turn{}
+	for _, idx := range selected {
+		excerpt.Blocks = append(excerpt.Blocks, t.Blocks[idx])
+	}
+	var transcript strings.Builder
+	turns.FprintTurn(&transcript, excerpt)
+
+	req := &turns.Turn{Blocks: []turns.Block{
+		turns.NewSystemTextBlock(cfg.SummaryPrompt),
+		turns.NewUserTextBlock(transcript.String()),
+	}}
+	out, err := cfg.Summarizer.RunInference(ctx, req)
+	if err != nil {
+		return false, errors.Wrap(err, "contextwindow: summarize older blocks")
+	}
+	summary := ""
+	if out != nil {
+		for i := len(out.Blocks) - 1; i >= 0; i-- {
+			if out.Blocks[i].Kind == turns.BlockKindLLMText {
+				summary, _ = out.Blocks[i].Payload[turns.PayloadKeyText].(string)
+				break
+			}
+		}
+	}
+	if strings.TrimSpace(summary) == "" {
+		return false, errors.New("contextwindow: summarizer returned no text")
+	}
+
+	summaryBlock := turns.NewUserTextBlock(contextWindowSummaryPrefix + strings.TrimSpace(summary))
+	if err := turns.KeyBlockMetaMiddleware.Set(&summaryBlock.Metadata, ContextWindowMiddlewareName); err != nil {
+		return false, errors.Wrap(err, "set block middleware metadata (summary block)")
+	}
+	t.Blocks = replaceContextWindowBlocks(t.Blocks, selected, &summaryBlock)
+	return true, nil
+}
+
+// replaceContextWindowBlocks removes the selected indices and, when replacement
+// is set, inserts it where the first removed block was.
+func replaceContextWindowBlocks(blocks []turns.Block, selected []int, replacement *turns.Block) []turns.Block {
+	drop := make(map[int]bool, len(selected))
+	for _, idx := range selected {
+		drop[idx] = true
+	}
+	out := make([]turns.Block, 0, len(blocks)-len(selected)+1)
+	inserted := false
+	for i, b := range blocks {
+		if drop[i] {
+			if replacement != nil && !inserted {
+				out = append(out, *replacement)
+				inserted = true
+			}
+			continue
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+func truncateContextWindowToolResults(t *turns.Turn, maxChars int, excessChars int) (bool, error) {
+	changed := false
+	saved := 0
+	for i := range t.Blocks {
+		if saved >= excessChars {
+			break
+		}
+		b := &t.Blocks[i]
+		if b.Kind != turns.BlockKindToolUse {
+			continue
+		}
+		// A result truncated in an earlier round keeps its original count;
+		// truncating the marker again would save nothing and lose that count.
+		if mw, ok, _ := turns.KeyBlockMetaMiddleware.Get(b.Metadata); ok && mw == ContextWindowMiddlewareName {
+			continue
+		}
+		text, ok := contextWindowToolResultText(b.Payload[turns.PayloadKeyResult])
+		if !ok || len(text) <= maxChars {
+			continue
+		}
+		cut := maxChars
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		truncated := fmt.Sprintf("%s\n...[truncated %d characters]", text[:cut], len(text)-cut)
+		if len(truncated) >= len(text) {
+			continue
+		}
+
+		payload := make(map[string]any, len(b.Payload))
+		for k, v := range b.Payload {
+			payload[k] = v
+		}
+		payload[turns.PayloadKeyResult] = truncated
+		b.Payload = payload
+		b.Metadata = b.Metadata.Clone()
+		if err := turns.KeyBlockMetaMiddleware.Set(&b.Metadata, ContextWindowMiddlewareName); err != nil {
+			return changed, errors.Wrap(err, "set block middleware metadata (truncated tool result)")
+		}
+		saved += len(text) - len(truncated)
+		changed = true
+	}
+	return changed, nil
+}
+
+func contextWindowToolResultText(result any) (string, bool) {
+	switch v := result.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	default:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return "", false
+		}
+		return string(raw), true
+	}
+}
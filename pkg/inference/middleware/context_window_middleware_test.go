@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/tokencount"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/stretchr/testify/require"
+)
+
+// charCounter counts one token per payload character.
+type charCounter struct{ calls int }
+
+func (c *charCounter) CountTurn(_ context.Context, t *turns.Turn) (*tokencount.Result, error) {
+	c.calls++
+	n := 0
+	for _, b := range t.Blocks {
+		n += tokencount.BlockChars(b)
+	}
+	return &tokencount.Result{InputTokens: n, Source: tokencount.SourceEstimate}, nil
+}
+
+func runContextWindow(t *testing.T, cfg ContextWindowConfig, in *turns.Turn) *turns.Turn {
+	t.Helper()
+	var seen *turns.Turn
+	h := Chain(func(ctx context.Context, t *turns.Turn) (*turns.Turn, error) {
+		seen = t
+		return t, nil
+	}, NewContextWindowMiddleware(cfg))
+	_, err := h(context.Background(), in)
+	require.NoError(t, err)
+	return seen
+}
+
+func blockTexts(t *turns.Turn) []string {
+	var out []string
+	for _, b := range t.Blocks {
+		if s, ok := b.Payload[turns.PayloadKeyText].(string); ok {
+			out = append(out, s)
+			continue
+		}
+		id, _ := b.Payload[turns.PayloadKeyID].(string)
+		out = append(out, b.Kind.String()+":"+id)
+	}
+	return out
+}
+
+func TestContextWindow_UnderBudgetPassesThrough(t *testing.T) {
+	counter := &charCounter{}
+	in := &turns.Turn{Blocks: []turns.Block{turns.NewUserTextBlock("hello")}}
+	out := runContextWindow(t, ContextWindowConfig{Counter: counter, MaxTokens: 100}, in)
+	require.Len(t, out.Blocks, 1)
+	require.Equal(t, 1, counter.calls)
+}
+
+func TestContextWindow_NoBudgetSkipsCounting(t *testing.T) {
+	counter := &charCounter{}
+	in := &turns.Turn{Blocks: []turns.Block{turns.NewUserTextBlock(strings.Repeat("x", 1000))}}
+	out := runContextWindow(t, ContextWindowConfig{Counter: counter}, in)
+	require.Len(t, out.Blocks, 1)
+	require.Equal(t, 0, counter.calls)
+}
+
+func TestContextWindow_DropOldestKeepsSystemAndLatestExchange(t *testing.T) {
+	in := &turns.Turn{Blocks: []turns.Block{
+		turns.NewSystemTextBlock("sys"),
+		turns.NewUserTextBlock(strings.Repeat("a", 50)),
+		turns.NewAssistantTextBlock(strings.Repeat("b", 50)),
+		turns.NewUserTextBlock(strings.Repeat("c", 50)),
+		turns.NewAssistantTextBlock(strings.Repeat("d", 50)),
+		turns.NewUserTextBlock("latest"),
+	}}
+	out := runContextWindow(t, ContextWindowConfig{
+		Counter:    &charCounter{},
+		MaxTokens:  150,
+		Strategies: []ContextWindowStrategy{ContextWindowStrategyDropOldest},
+	}, in)
+	require.Equal(t, []string{"sys", strings.Repeat("c", 50), strings.Repeat("d", 50), "latest"}, blockTexts(out))
+}
+
+func TestContextWindow_DropOldestNeverSplitsToolPairs(t *testing.T) {
+	in := &turns.Turn{Blocks: []turns.Block{
+		turns.NewUserTextBlock("first"),
+		turns.NewToolCallBlock("call-1", "search", map[string]any{"q": "x"}),
+		// A user block between call and result must not become an exchange boundary.
+		turns.NewUserTextBlock(strings.Repeat("u", 40)),
+		turns.NewToolUseBlock("call-1", strings.Repeat("r", 40)),
+		turns.NewUserTextBlock("second"),
+		turns.NewAssistantTextBlock("answer"),
+		turns.NewUserTextBlock("latest"),
+	}}
+	out := runContextWindow(t, ContextWindowConfig{
+		Counter:    &charCounter{},
+		MaxTokens:  40,
+		Strategies: []ContextWindowStrategy{ContextWindowStrategyDropOldest},
+	}, in)
+	for _, b := range out.Blocks {
+		require.NotEqual(t, turns.BlockKindToolCall, b.Kind)
+		require.NotEqual(t, turns.BlockKindToolUse, b.Kind)
+	}
+	require.Equal(t, "latest", blockTexts(out)[len(out.Blocks)-1])
+}
+
+func TestContextWindow_TruncateToolResultsOldestFirst(t *testing.T) {
+	in := &turns.Turn{Blocks: []turns.Block{
+		turns.NewUserTextBlock("q"),
+		turns.NewToolCallBlock("call-1", "read", nil),
+		turns.NewToolUseBlock("call-1", strings.Repeat("1", 500)),
+		turns.NewToolCallBlock("call-2", "read", nil),
+		turns.NewToolUseBlock("call-2", map[string]any{"body": strings.Repeat("2", 500)}),
+	}}
+	original := in.Blocks[2].Payload
+	out := runContextWindow(t, ContextWindowConfig{
+		Counter:            &charCounter{},
+		MaxTokens:          700,
+		Strategies:         []ContextWindowStrategy{ContextWindowStrategyTruncateToolResults},
+		MaxToolResultChars: 100,
+	}, in)
+
+	first, _ := out.Blocks[2].Payload[turns.PayloadKeyResult].(string)
+	require.True(t, strings.HasPrefix(first, strings.Repeat("1", 100)))
+	require.Contains(t, first, "[truncated 400 characters]")
+	mw, ok, err := turns.KeyBlockMetaMiddleware.Get(out.Blocks[2].Metadata)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, ContextWindowMiddlewareName, mw)
+	// The second result was not needed to fit and stays untouched.
+	require.IsType(t, map[string]any{}, out.Blocks[4].Payload[turns.PayloadKeyResult])
+	// The original payload map is not mutated.
+	require.Equal(t, strings.Repeat("1", 500), original[turns.PayloadKeyResult])
+}
+
+func TestContextWindow_TruncateToolResultsIsIdempotentAcrossRounds(t *testing.T) {
+	in := &turns.Turn{Blocks: []turns.Block{
+		turns.NewUserTextBlock("q"),
+		turns.NewToolCallBlock("call-1", "read", nil),
+		turns.NewToolUseBlock("call-1", strings.Repeat("x", 50000)),
+	}}
+	counter := &charCounter{}
+	// The budget stays exceeded after truncation, so later rounds run but
+	// must leave the already truncated result alone.
+	out := runContextWindow(t, ContextWindowConfig{
+		Counter:            counter,
+		MaxTokens:          100,
+		Strategies:         []ContextWindowStrategy{ContextWindowStrategyTruncateToolResults},
+		MaxToolResultChars: 20000,
+	}, in)
+
+	result, _ := out.Blocks[2].Payload[turns.PayloadKeyResult].(string)
+	require.True(t, strings.HasPrefix(result, strings.Repeat("x", 20000)))
+	require.True(t, strings.HasSuffix(result, "...[truncated 30000 characters]"))
+	// One count before and one after the only effective round.
+	require.Equal(t, 2, counter.calls)
+}
+
+func TestContextWindow_SummarizeReplacesOldestExchanges(t *testing.T) {
+	summaryResponse := turns.NewAssistantTextBlock("they discussed apples")
+	summarizer := &MockEngine{response: &summaryResponse}
+	in := &turns.Turn{Blocks: []turns.Block{
+		turns.NewSystemTextBlock("sys"),
+		turns.NewUserTextBlock(strings.Repeat("apple ", 30)),
+		turns.NewAssistantTextBlock(strings.Repeat("pear ", 30)),
+		turns.NewUserTextBlock("latest"),
+	}}
+	out := runContextWindow(t, ContextWindowConfig{
+		Counter:    &charCounter{},
+		MaxTokens:  100,
+		Strategies: []ContextWindowStrategy{ContextWindowStrategySummarize},
+		Summarizer: summarizer,
+	}, in)
+	require.Equal(t, []string{"sys", contextWindowSummaryPrefix + "they discussed apples", "latest"}, blockTexts(out))
+	mw, ok, err := turns.KeyBlockMetaMiddleware.Get(out.Blocks[1].Metadata)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, ContextWindowMiddlewareName, mw)
+}
+
+func TestContextWindowConfig_TokenLimitFromModelInfo(t *testing.T) {
+	window, watermark := 200000, 150000
+	cfg := ContextWindowConfig{
+		ModelInfo:     &settings.ModelInfo{ContextWindow: &window},
+		ReserveTokens: 8000,
+	}
+	require.Equal(t, 192000, cfg.TokenLimit())
+	cfg.ModelInfo.QualityHighWatermark = &watermark
+	require.Equal(t, 142000, cfg.TokenLimit())
+	cfg.MaxTokens = 100000
+	require.Equal(t, 92000, cfg.TokenLimit())
+	require.Equal(t, 0, ContextWindowConfig{}.TokenLimit())
+	require.Equal(t, 1, ContextWindowConfig{MaxTokens: 10, ReserveTokens: 20}.TokenLimit())
+}
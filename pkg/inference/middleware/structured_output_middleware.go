@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+)
+
+// StructuredOutputMiddlewareName is the value written to
+// turns.KeyBlockMetaMiddleware on repair prompts added by the structured output
+// middleware.
+const StructuredOutputMiddlewareName = engine.StructuredOutputRepairTag
+
+// StructuredOutputConfig configures NewStructuredOutputMiddleware.
+type StructuredOutputConfig struct {
+	// Default is used when the Turn carries no engine.KeyStructuredOutputConfig,
+	// typically the result of settings.ChatSettings.StructuredOutputConfig().
+	Default *engine.StructuredOutputConfig
+	// MaxRepairAttempts is the number of times invalid output is sent back to
+	// the model together with the validation errors.
+	MaxRepairAttempts int
+	// RepairPrompt overrides engine.DefaultStructuredOutputRepairPrompt.
+	RepairPrompt string
+}
+
+// NewStructuredOutputMiddleware returns a middleware that validates the final
+// llm_text block of each response against the structured output schema and
+// re-prompts the model on violations. The decoded value is stored under
+// engine.KeyStructuredOutputResult. Responses ending in tool calls pass
+// through, so the middleware can sit inside a tool loop.
+func NewStructuredOutputMiddleware(cfg StructuredOutputConfig) Middleware {
+	opts := engine.StructuredOutputRepairOptions{
+		MaxRepairAttempts: cfg.MaxRepairAttempts,
+		RepairPrompt:      cfg.RepairPrompt,
+	}
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, t *turns.Turn) (*turns.Turn, error) {
+			soCfg := cfg.Default
+			if t != nil {
+				if override, ok, err := engine.KeyStructuredOutputConfig.Get(t.Data); err == nil && ok {
+					soCfg = engine.ResolveStructuredOutputConfig(cfg.Default, &override)
+				}
+			}
+			if soCfg == nil || !soCfg.IsEnabled() {
+				return next(ctx, t)
+			}
+			return engine.ProcessStructuredOutput(ctx, next, t, *soCfg, opts)
+		}
+	}
+}
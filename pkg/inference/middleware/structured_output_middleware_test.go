@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+)
+
+func TestStructuredOutputMiddlewareUsesTurnConfig(t *testing.T) {
+	replies := []string{"sure!", `{"answer":"42"}`}
+	calls := 0
+	next := func(_ context.Context, t *turns.Turn) (*turns.Turn, error) {
+		turns.AppendBlock(t, turns.NewAssistantTextBlock(replies[calls]))
+		calls++
+		return t, nil
+	}
+	mw := NewStructuredOutputMiddleware(StructuredOutputConfig{MaxRepairAttempts: 1})
+
+	plain := &turns.Turn{}
+	if _, err := mw(next)(context.Background(), plain); err != nil || calls != 1 {
+		t.Fatalf("expected passthrough without config, calls=%d err=%v", calls, err)
+	}
+
+	calls = 0
+	in := &turns.Turn{}
+	if err := engine.KeyStructuredOutputConfig.Set(&in.Data, engine.StructuredOutputConfig{
+		Mode:   engine.StructuredOutputModeJSONSchema,
+		Name:   "answer",
+		Schema: map[string]any{"type": "object", "required": []string{"answer"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	out, err := mw(next)(context.Background(), in)
+	if err != nil {
+		t.Fatalf("middleware: %v", err)
+	}
+	res, ok, _ := engine.KeyStructuredOutputResult.Get(out.Data)
+	if calls != 2 || !ok || !res.Valid || res.Value.(map[string]any)["answer"] != "42" {
+		t.Fatalf("expected repaired result after 2 calls, calls=%d res=%+v", calls, res)
+	}
+}
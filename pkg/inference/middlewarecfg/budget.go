@@ -0,0 +1,94 @@
+package middlewarecfg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/budget"
+	gepmiddleware "github.com/go-go-golems/geppetto/pkg/inference/middleware"
+)
+
+// BuildDepUsageTracker holds the *budget.Tracker shared by budget middleware
+// instances. It is required for session and profile limits: without it each
+// built middleware would aggregate on its own and those limits would only
+// span one chain build.
+const BuildDepUsageTracker = "usage_tracker"
+
+// BudgetDefinition exposes budget.NewMiddleware to profile-driven composition
+// under the name "budget".
+type BudgetDefinition struct{}
+
+var _ Definition = BudgetDefinition{}
+
+// budgetUseConfig is the resolved config payload shape.
+type budgetUseConfig struct {
+	MaxCostUSD        float64 `json:"max_cost_usd,omitempty"`
+	MaxTokens         int     `json:"max_tokens,omitempty"`
+	SessionMaxCostUSD float64 `json:"session_max_cost_usd,omitempty"`
+	SessionMaxTokens  int     `json:"session_max_tokens,omitempty"`
+	ProfileMaxCostUSD float64 `json:"profile_max_cost_usd,omitempty"`
+	ProfileMaxTokens  int     `json:"profile_max_tokens,omitempty"`
+	Profile           string  `json:"profile,omitempty"`
+}
+
+// Name returns the middleware name used in profile middleware uses.
+func (BudgetDefinition) Name() string {
+	return budget.MiddlewareName
+}
+
+// ConfigJSONSchema returns the config schema for profile validation.
+func (BudgetDefinition) ConfigJSONSchema() map[string]any {
+	limit := func(typ, description string) map[string]any {
+		return map[string]any{"type": typ, "minimum": 0, "description": description}
+	}
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"max_cost_usd":         limit("number", "Dollar budget of one run (tool loop)."),
+			"max_tokens":           limit("integer", "Input plus output token budget of one run."),
+			"session_max_cost_usd": limit("number", "Dollar budget of a session across runs."),
+			"session_max_tokens":   limit("integer", "Token budget of a session across runs."),
+			"profile_max_cost_usd": limit("number", "Dollar budget of the profile across sessions."),
+			"profile_max_tokens":   limit("integer", "Token budget of the profile across sessions."),
+			"profile": map[string]any{
+				"type":        "string",
+				"description": "Profile bucket name; defaults to the runtime attribution of the turn.",
+			},
+		},
+		"additionalProperties": false,
+	}
+}
+
+// Build constructs the middleware from the resolved config and build deps.
+func (BudgetDefinition) Build(_ context.Context, deps BuildDeps, cfg any) (gepmiddleware.Middleware, error) {
+	var use budgetUseConfig
+	if cfg != nil {
+		raw, err := json.Marshal(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("budget: encode config: %w", err)
+		}
+		if err := json.Unmarshal(raw, &use); err != nil {
+			return nil, fmt.Errorf("budget: decode config: %w", err)
+		}
+	}
+
+	mwCfg := budget.Config{
+		Run:         budget.Limits{MaxCostUSD: use.MaxCostUSD, MaxTokens: use.MaxTokens},
+		Session:     budget.Limits{MaxCostUSD: use.SessionMaxCostUSD, MaxTokens: use.SessionMaxTokens},
+		Profile:     budget.Limits{MaxCostUSD: use.ProfileMaxCostUSD, MaxTokens: use.ProfileMaxTokens},
+		ProfileName: use.Profile,
+	}
+	if v, ok := deps.Get(BuildDepUsageTracker); ok && v != nil {
+		tracker, ok := v.(*budget.Tracker)
+		if !ok {
+			return nil, fmt.Errorf("budget: build dep %q must be *budget.Tracker, got %T", BuildDepUsageTracker, v)
+		}
+		mwCfg.Tracker = tracker
+	}
+	if mwCfg.Tracker == nil && (!mwCfg.Session.IsZero() || !mwCfg.Profile.IsZero()) {
+		return nil, fmt.Errorf("budget: session and profile limits need the %q build dep", BuildDepUsageTracker)
+	}
+
+	return budget.NewMiddleware(mwCfg), nil
+}
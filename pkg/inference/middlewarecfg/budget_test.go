@@ -0,0 +1,49 @@
+package middlewarecfg
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/budget"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+)
+
+func TestBudgetDefinition_BuildWithSharedTracker(t *testing.T) {
+	tracker := budget.NewTracker()
+	deps := BuildDeps{Values: map[string]any{BuildDepUsageTracker: tracker}}
+	mw, err := BudgetDefinition{}.Build(context.Background(), deps, map[string]any{"max_tokens": 10})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	handler := mw(func(_ context.Context, t *turns.Turn) (*turns.Turn, error) {
+		err := turns.KeyTurnMetaInferenceResult.Set(&t.Metadata, turns.InferenceResult{Usage: &turns.InferenceUsage{InputTokens: 8, OutputTokens: 4}})
+		return t, err
+	})
+	in := &turns.Turn{}
+	_ = turns.KeyTurnMetaInferenceID.Set(&in.Metadata, "run-1")
+	if _, err := handler(context.Background(), in); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	if _, err := handler(context.Background(), in); !errors.Is(err, budget.ErrBudgetExceeded) {
+		t.Fatalf("expected budget error, got %v", err)
+	}
+	if got := tracker.Run("run-1").TotalTokens(); got != 12 {
+		t.Fatalf("expected shared tracker to hold the run usage, got %d", got)
+	}
+
+	if _, err := (BudgetDefinition{}).Build(context.Background(), BuildDeps{Values: map[string]any{BuildDepUsageTracker: "nope"}}, nil); err == nil {
+		t.Fatalf("expected wrong dep type error")
+	}
+}
+
+func TestBudgetDefinition_SessionLimitsNeedTracker(t *testing.T) {
+	for _, cfg := range []map[string]any{{"session_max_tokens": 10}, {"profile_max_cost_usd": 1.5}} {
+		if _, err := (BudgetDefinition{}).Build(context.Background(), BuildDeps{}, cfg); err == nil {
+			t.Fatalf("expected missing tracker error for %v", cfg)
+		}
+	}
+	if _, err := (BudgetDefinition{}).Build(context.Background(), BuildDeps{}, map[string]any{"max_tokens": 10}); err != nil {
+		t.Fatalf("run limits must not need a tracker: %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package middlewarecfg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/engine"
+	gepmiddleware "github.com/go-go-golems/geppetto/pkg/inference/middleware"
+	"github.com/go-go-golems/geppetto/pkg/inference/tokencount"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+)
+
+// Well-known BuildDeps keys consumed by built-in middleware definitions.
+const (
+	// BuildDepInferenceSettings holds the runtime *settings.InferenceSettings.
+	// The runner injects it when the application has not provided one.
+	BuildDepInferenceSettings = "inference_settings"
+	// BuildDepTokenCounter holds a tokencount.Counter for the runtime provider.
+	BuildDepTokenCounter = "token_counter"
+	// BuildDepSummarizerEngine holds the engine.Engine used for summarization.
+	BuildDepSummarizerEngine = "summarizer_engine"
+)
+
+// ContextWindowDefinition exposes middleware.NewContextWindowMiddleware to
+// profile-driven composition under the name "contextwindow".
+//
+// The budget comes from the model_info of BuildDepInferenceSettings unless
+// max_tokens is set. BuildDepTokenCounter and BuildDepSummarizerEngine are
+// optional; without a counter tokens are estimated from payload size.
+type ContextWindowDefinition struct{}
+
+var _ Definition = ContextWindowDefinition{}
+
+// contextWindowUseConfig is the resolved config payload shape.
+type contextWindowUseConfig struct {
+	MaxTokens          int      `json:"max_tokens,omitempty"`
+	ReserveTokens      int      `json:"reserve_tokens,omitempty"`
+	Strategies         []string `json:"strategies,omitempty"`
+	MaxToolResultChars int      `json:"max_tool_result_chars,omitempty"`
+	SummaryPrompt      string   `json:"summary_prompt,omitempty"`
+}
+
+// Name returns the middleware name used in profile middleware uses.
+func (ContextWindowDefinition) Name() string {
+	return gepmiddleware.ContextWindowMiddlewareName
+}
+
+// ConfigJSONSchema returns the config schema for profile validation.
+func (ContextWindowDefinition) ConfigJSONSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"max_tokens": map[string]any{
+				"type":        "integer",
+				"description": "Input token budget; overrides model_info.quality_high_watermark and model_info.context_window.",
+			},
+			"reserve_tokens": map[string]any{
+				"type":        "integer",
+				"description": "Tokens subtracted from the budget to leave room for the response.",
+			},
+			"strategies": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "string",
+					"enum": []any{
+						string(gepmiddleware.ContextWindowStrategyTruncateToolResults),
+						string(gepmiddleware.ContextWindowStrategyDropOldest),
+						string(gepmiddleware.ContextWindowStrategySummarize),
+					},
+				},
+				"default": []any{
+					string(gepmiddleware.ContextWindowStrategyTruncateToolResults),
+					string(gepmiddleware.ContextWindowStrategyDropOldest),
+				},
+				"description": "Reduction strategies, applied in order until the turn fits.",
+			},
+			"max_tool_result_chars": map[string]any{
+				"type":        "integer",
+				"default":     gepmiddleware.DefaultContextWindowMaxToolResultChars,
+				"description": "Size tool results are cut to by truncate_tool_results.",
+			},
+			"summary_prompt": map[string]any{
+				"type":        "string",
+				"description": "Instruction for the summarizer engine used by the summarize strategy.",
+			},
+		},
+		"additionalProperties": false,
+	}
+}
+
+// Build constructs the middleware from the resolved config and build deps.
+func (ContextWindowDefinition) Build(_ context.Context, deps BuildDeps, cfg any) (gepmiddleware.Middleware, error) {
+	var use contextWindowUseConfig
+	if cfg != nil {
+		raw, err := json.Marshal(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("contextwindow: encode config: %w", err)
+		}
+		if err := json.Unmarshal(raw, &use); err != nil {
+			return nil, fmt.Errorf("contextwindow: decode config: %w", err)
+		}
+	}
+
+	mwCfg := gepmiddleware.ContextWindowConfig{
+		MaxTokens:          use.MaxTokens,
+		ReserveTokens:      use.ReserveTokens,
+		MaxToolResultChars: use.MaxToolResultChars,
+		SummaryPrompt:      use.SummaryPrompt,
+	}
+	for _, s := range use.Strategies {
+		mwCfg.Strategies = append(mwCfg.Strategies, gepmiddleware.ContextWindowStrategy(s))
+	}
+
+	if v, ok := deps.Get(BuildDepInferenceSettings); ok && v != nil {
+		ss, ok := v.(*settings.InferenceSettings)
+		if !ok {
+			return nil, fmt.Errorf("contextwindow: build dep %q must be *settings.InferenceSettings, got %T", BuildDepInferenceSettings, v)
+		}
+		if ss != nil {
+			mwCfg.ModelInfo = ss.ModelInfo
+		}
+	}
+	if v, ok := deps.Get(BuildDepTokenCounter); ok && v != nil {
+		counter, ok := v.(tokencount.Counter)
+		if !ok {
+			return nil, fmt.Errorf("contextwindow: build dep %q must be tokencount.Counter, got %T", BuildDepTokenCounter, v)
+		}
+		mwCfg.Counter = counter
+	}
+	if v, ok := deps.Get(BuildDepSummarizerEngine); ok && v != nil {
+		summarizer, ok := v.(engine.Engine)
+		if !ok {
+			return nil, fmt.Errorf("contextwindow: build dep %q must be engine.Engine, got %T", BuildDepSummarizerEngine, v)
+		}
+		mwCfg.Summarizer = summarizer
+	}
+
+	return gepmiddleware.NewContextWindowMiddleware(mwCfg), nil
+}
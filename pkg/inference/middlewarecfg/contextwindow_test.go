@@ -0,0 +1,69 @@
+package middlewarecfg
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	gepmiddleware "github.com/go-go-golems/geppetto/pkg/inference/middleware"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+)
+
+func TestContextWindowDefinition_ResolveDefaultsAndBuild(t *testing.T) {
+	def := ContextWindowDefinition{}
+	resolved, err := NewResolver(staticSource{
+		name:    "profile",
+		layer:   SourceLayerProfile,
+		payload: map[string]any{"strategies": []any{"drop_oldest"}},
+	}).Resolve(def, Use{Name: def.Name()})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if got := resolved.Config["max_tool_result_chars"]; got != int64(gepmiddleware.DefaultContextWindowMaxToolResultChars) {
+		t.Fatalf("expected schema default for max_tool_result_chars, got %#v", got)
+	}
+
+	window := 20
+	deps := BuildDeps{Values: map[string]any{
+		BuildDepInferenceSettings: &settings.InferenceSettings{ModelInfo: &settings.ModelInfo{ContextWindow: &window}},
+	}}
+	mw, err := def.Build(context.Background(), deps, resolved.Config)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	in := &turns.Turn{Blocks: []turns.Block{
+		turns.NewUserTextBlock(strings.Repeat("old ", 100)),
+		turns.NewAssistantTextBlock(strings.Repeat("reply ", 100)),
+		turns.NewUserTextBlock("latest"),
+	}}
+	out, err := mw(func(_ context.Context, t *turns.Turn) (*turns.Turn, error) { return t, nil })(context.Background(), in)
+	if err != nil {
+		t.Fatalf("run middleware: %v", err)
+	}
+	if len(out.Blocks) != 1 {
+		t.Fatalf("expected only the latest exchange to remain, got %d blocks", len(out.Blocks))
+	}
+}
+
+func TestContextWindowDefinition_RejectsUnknownStrategy(t *testing.T) {
+	def := ContextWindowDefinition{}
+	_, err := NewResolver(staticSource{
+		name:    "profile",
+		layer:   SourceLayerProfile,
+		payload: map[string]any{"strategies": []any{"compress"}},
+	}).Resolve(def, Use{Name: def.Name()})
+	if err == nil {
+		t.Fatalf("expected validation error for unknown strategy")
+	}
+}
+
+func TestContextWindowDefinition_RejectsWrongDepType(t *testing.T) {
+	_, err := ContextWindowDefinition{}.Build(context.Background(), BuildDeps{Values: map[string]any{
+		BuildDepTokenCounter: "not a counter",
+	}}, nil)
+	if err == nil {
+		t.Fatalf("expected error for wrong token counter dep type")
+	}
+}
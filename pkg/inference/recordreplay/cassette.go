@@ -0,0 +1,130 @@
+package recordreplay
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// CassetteVersion is the on-disk format version written by Save.
+const CassetteVersion = 1
+
+// Cassette holds the provider HTTP exchanges and engine events captured
+// during a recording session.
+type Cassette struct {
+	Version      int             `json:"version"`
+	Interactions []Interaction   `json:"interactions"`
+	Events       []RecordedEvent `json:"events,omitempty"`
+}
+
+// Interaction is one recorded HTTP request/response pair.
+type Interaction struct {
+	// Key is the normalized request hash used for matching (see RequestKey).
+	Key      string           `json:"key"`
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the redacted request that produced an interaction.
+// Request headers are never stored so credentials stay out of cassettes.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   Body   `json:"body"`
+}
+
+// RecordedResponse is replayed verbatim, including streaming bodies.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       Body        `json:"body"`
+}
+
+// RecordedEvent is an event emitted by the wrapped engine during run Run
+// (0-based count of RunInference calls on the recorder).
+type RecordedEvent struct {
+	Run   int             `json:"run"`
+	Type  string          `json:"type"`
+	Event json.RawMessage `json:"event"`
+}
+
+// Body stores payload bytes as a readable string when they are valid UTF-8
+// and as base64 otherwise, so replay is byte-for-byte.
+type Body []byte
+
+type bodyJSON struct {
+	Text   *string `json:"text,omitempty"`
+	Base64 *string `json:"base64,omitempty"`
+}
+
+func (b Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		s := string(b)
+		return json.Marshal(bodyJSON{Text: &s})
+	}
+	s := base64.StdEncoding.EncodeToString(b)
+	return json.Marshal(bodyJSON{Base64: &s})
+}
+
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var raw bodyJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	switch {
+	case raw.Base64 != nil:
+		decoded, err := base64.StdEncoding.DecodeString(*raw.Base64)
+		if err != nil {
+			return errors.Wrap(err, "decode base64 body")
+		}
+		*b = decoded
+	case raw.Text != nil:
+		*b = []byte(*raw.Text)
+	default:
+		*b = nil
+	}
+	return nil
+}
+
+// LoadCassette reads a cassette written by Save.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read cassette %s", path)
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, errors.Wrapf(err, "parse cassette %s", path)
+	}
+	if c.Version > CassetteVersion {
+		return nil, errors.Errorf("cassette %s has unsupported version %d", path, c.Version)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette as indented JSON, creating parent directories.
+func (c *Cassette) Save(path string) error {
+	if c == nil {
+		return errors.New("cassette is nil")
+	}
+	c.Version = CassetteVersion
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshal cassette")
+	}
+	if dir := filepath.Dir(path); dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return errors.Wrap(err, "create cassette dir")
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return errors.Wrap(err, "write cassette")
+	}
+	return errors.Wrap(os.Rename(tmp, path), "write cassette")
+}
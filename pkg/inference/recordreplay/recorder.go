@@ -0,0 +1,377 @@
+package recordreplay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/types"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// Mode selects whether a Recorder talks to the provider or serves a cassette.
+type Mode string
+
+const (
+	// ModeRecord forwards requests to the provider and captures them.
+	ModeRecord Mode = "record"
+	// ModeReplay serves responses from the cassette and never touches the network.
+	ModeReplay Mode = "replay"
+	// ModeAuto replays when the cassette exists and records otherwise.
+	ModeAuto Mode = "auto"
+)
+
+// MatchMode controls how replayed requests are matched to recorded ones.
+type MatchMode string
+
+const (
+	// MatchStrict requires an exact request key, fails Close when recorded
+	// exchanges were not replayed, and fails a run whose emitted events differ
+	// from the recording (see ErrEventMismatch).
+	MatchStrict MatchMode = "strict"
+	// MatchLenient falls back to the next unused exchange on the same endpoint
+	// when no exact key matches, and skips the event and leftover checks.
+	MatchLenient MatchMode = "lenient"
+)
+
+// ReplayAPIKey is the placeholder credential injected on replay so settings
+// validation passes without real API keys.
+const ReplayAPIKey = "recordreplay-placeholder"
+
+var (
+	// ErrUnusedInteractions is returned by Close in strict replay when the
+	// cassette contains exchanges the run never requested.
+	ErrUnusedInteractions = errors.New("recordreplay: recorded interactions were not replayed")
+	// ErrEventMismatch is returned in strict replay when a run emits events
+	// that differ from the recording once IDs, timestamps and durations are
+	// normalized.
+	ErrEventMismatch = errors.New("recordreplay: replayed events differ from recording")
+)
+
+// Recorder records provider HTTP exchanges and engine events to a cassette
+// file, or replays them for deterministic offline runs.
+type Recorder struct {
+	path         string
+	mode         Mode
+	matchMode    MatchMode
+	ignoreFields []string
+	next         http.RoundTripper
+
+	mu       sync.Mutex
+	cassette *Cassette
+	idx      interactionIndex
+	runs     int
+}
+
+type Option func(*Recorder)
+
+// WithMatchMode sets the replay matching mode (default MatchStrict).
+func WithMatchMode(m MatchMode) Option {
+	return func(r *Recorder) { r.matchMode = m }
+}
+
+// WithIgnoredBodyFields drops top-level JSON request fields from the request
+// key, e.g. per-request user ids or metadata that vary between runs.
+func WithIgnoredBodyFields(fields ...string) Option {
+	return func(r *Recorder) { r.ignoreFields = append(r.ignoreFields, fields...) }
+}
+
+// WithTransport sets the transport used to reach the provider while recording
+// when the provider HTTP client does not define one.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(r *Recorder) { r.next = rt }
+}
+
+// New creates a Recorder for the cassette at path. In ModeReplay the cassette
+// must exist; ModeAuto resolves to replay or record based on its existence.
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, errors.New("recordreplay: cassette path is empty")
+	}
+	r := &Recorder{path: path, mode: mode, matchMode: MatchStrict}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(r)
+		}
+	}
+	if r.mode == ModeAuto || r.mode == "" {
+		r.mode = ModeRecord
+		if _, err := os.Stat(path); err == nil {
+			r.mode = ModeReplay
+		}
+	}
+	switch r.mode {
+	case ModeReplay:
+		c, err := LoadCassette(path)
+		if err != nil {
+			return nil, err
+		}
+		r.cassette = c
+		r.idx.used = make([]bool, len(c.Interactions))
+	case ModeRecord:
+		r.cassette = &Cassette{Version: CassetteVersion}
+	default:
+		return nil, errors.Errorf("recordreplay: unknown mode %q", r.mode)
+	}
+	switch r.matchMode {
+	case MatchStrict, MatchLenient:
+	default:
+		return nil, errors.Errorf("recordreplay: unknown match mode %q", r.matchMode)
+	}
+	return r, nil
+}
+
+// Mode returns the resolved mode (never ModeAuto).
+func (r *Recorder) Mode() Mode { return r.mode }
+
+// Cassette returns the cassette being recorded or replayed.
+func (r *Recorder) Cassette() *Cassette { return r.cassette }
+
+// HTTPClient returns a copy of base whose transport records or replays.
+// A nil base uses http.DefaultClient.
+func (r *Recorder) HTTPClient(base *http.Client) *http.Client {
+	if base == nil {
+		base = http.DefaultClient
+	}
+	next := base.Transport
+	if next == nil {
+		next = r.next
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	c := *base
+	c.Transport = &transport{r: r, next: next}
+	return &c
+}
+
+// ApplySettings returns a copy of s whose client settings route provider
+// traffic through the recorder. On replay it also fills a placeholder API key
+// for the selected provider so runs work without credentials.
+func (r *Recorder) ApplySettings(s *settings.InferenceSettings) (*settings.InferenceSettings, error) {
+	if s == nil {
+		return nil, errors.New("recordreplay: settings are nil")
+	}
+	out := s.Clone()
+	if out.Client == nil {
+		out.Client = settings.NewClientSettings()
+	}
+	base, err := settings.EnsureHTTPClient(out.Client)
+	if err != nil {
+		return nil, err
+	}
+	out.Client.HTTPClient = r.HTTPClient(base)
+
+	if r.mode == ModeReplay && out.API != nil && out.Chat != nil && out.Chat.ApiType != nil {
+		provider := strings.ToLower(string(*out.Chat.ApiType))
+		if provider == "anthropic" {
+			provider = string(types.ApiTypeClaude)
+		}
+		keyName := provider + "-api-key"
+		if out.API.APIKeys == nil {
+			out.API.APIKeys = map[string]string{}
+		}
+		if strings.TrimSpace(out.API.APIKeys[keyName]) == "" {
+			out.API.APIKeys[keyName] = ReplayAPIKey
+		}
+	}
+	return out, nil
+}
+
+// WrapEngine returns an engine that captures the events emitted by eng on
+// record and checks them against the recording on strict replay.
+func (r *Recorder) WrapEngine(eng engine.Engine) engine.Engine {
+	return &recordingEngine{r: r, inner: eng}
+}
+
+// Close saves the cassette when recording. In strict replay it reports
+// recorded exchanges that were never requested.
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	if r.mode == ModeRecord {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.cassette.Save(r.path)
+	}
+	if r.matchMode == MatchStrict {
+		if unused := r.unusedInteractions(); len(unused) > 0 {
+			return errors.Wrapf(ErrUnusedInteractions, "%d left, first %s %s", len(unused), unused[0].Request.Method, unused[0].Request.URL)
+		}
+	}
+	return nil
+}
+
+func (r *Recorder) record(it Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, it)
+}
+
+func (r *Recorder) nextRun() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run := r.runs
+	r.runs++
+	return run
+}
+
+func (r *Recorder) recordEvents(run int, evs []events.Event) error {
+	recorded := make([]RecordedEvent, 0, len(evs))
+	for _, e := range evs {
+		b, err := json.Marshal(e)
+		if err != nil {
+			return errors.Wrapf(err, "recordreplay: marshal %s event", e.Type())
+		}
+		recorded = append(recorded, RecordedEvent{Run: run, Type: string(e.Type()), Event: b})
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Events = append(r.cassette.Events, recorded...)
+	return nil
+}
+
+func (r *Recorder) checkEvents(run int, evs []events.Event) error {
+	var want []RecordedEvent
+	for _, e := range r.cassette.Events {
+		if e.Run == run {
+			want = append(want, e)
+		}
+	}
+	if len(want) != len(evs) {
+		got := make([]string, 0, len(evs))
+		for _, e := range evs {
+			got = append(got, string(e.Type()))
+		}
+		wantTypes := make([]string, 0, len(want))
+		for _, e := range want {
+			wantTypes = append(wantTypes, e.Type)
+		}
+		return errors.Wrapf(ErrEventMismatch, "run %d: recorded [%s], replayed [%s]", run, strings.Join(wantTypes, " "), strings.Join(got, " "))
+	}
+	for i, e := range evs {
+		raw, err := json.Marshal(e)
+		if err != nil {
+			return errors.Wrapf(err, "recordreplay: marshal %s event", e.Type())
+		}
+		gotNorm, err := normalizeEventJSON(raw)
+		if err != nil {
+			return err
+		}
+		wantNorm, err := normalizeEventJSON(want[i].Event)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(gotNorm, wantNorm) {
+			return errors.Wrapf(ErrEventMismatch, "run %d event %d: recorded %s %s, replayed %s %s", run, i, want[i].Type, wantNorm, e.Type(), gotNorm)
+		}
+	}
+	return nil
+}
+
+// normalizeEventJSON re-marshals an event with volatile values replaced:
+// IDs (keys named id or ending in _id, and UUID strings), timestamps (keys
+// ending in _at or named timestamp, and RFC 3339 strings) and durations. Map
+// keys marshal sorted, so the result is canonical.
+func normalizeEventJSON(raw []byte) ([]byte, error) {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, errors.Wrap(err, "recordreplay: decode recorded event")
+	}
+	out, err := json.Marshal(normalizeEventValue("", v))
+	if err != nil {
+		return nil, errors.Wrap(err, "recordreplay: encode normalized event")
+	}
+	return out, nil
+}
+
+func normalizeEventValue(key string, v any) any {
+	switch {
+	case key == "id" || strings.HasSuffix(key, "_id"):
+		return "<id>"
+	case key == "timestamp" || strings.HasSuffix(key, "_at"):
+		return "<time>"
+	case key == "duration_ms" || strings.HasSuffix(key, "_duration_ms"):
+		return "<duration>"
+	}
+	switch val := v.(type) {
+	case map[string]any:
+		for k, child := range val {
+			val[k] = normalizeEventValue(k, child)
+		}
+		return val
+	case []any:
+		for i, child := range val {
+			val[i] = normalizeEventValue("", child)
+		}
+		return val
+	case string:
+		if _, err := uuid.Parse(val); err == nil && len(val) == 36 {
+			return "<id>"
+		}
+		if _, err := time.Parse(time.RFC3339Nano, val); err == nil {
+			return "<time>"
+		}
+		return val
+	default:
+		return val
+	}
+}
+
+type recordingEngine struct {
+	r     *Recorder
+	inner engine.Engine
+}
+
+var _ engine.Engine = (*recordingEngine)(nil)
+
+func (e *recordingEngine) RunInference(ctx context.Context, t *turns.Turn) (*turns.Turn, error) {
+	run := e.r.nextRun()
+	collector := &eventCollector{}
+	out, err := e.inner.RunInference(events.WithEventSinks(ctx, collector), t)
+	if err != nil {
+		return out, err
+	}
+	evs := collector.snapshot()
+	switch {
+	case e.r.mode == ModeRecord:
+		if err := e.r.recordEvents(run, evs); err != nil {
+			return nil, err
+		}
+	case e.r.matchMode == MatchStrict:
+		if err := e.r.checkEvents(run, evs); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+type eventCollector struct {
+	mu  sync.Mutex
+	evs []events.Event
+}
+
+func (c *eventCollector) PublishEvent(e events.Event) error {
+	c.mu.Lock()
+	c.evs = append(c.evs, e)
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *eventCollector) snapshot() []events.Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]events.Event(nil), c.evs...)
+}
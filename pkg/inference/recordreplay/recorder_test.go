@@ -0,0 +1,201 @@
+package recordreplay
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// echoProvider answers with the request prompt upper-cased and counts calls.
+func echoProvider(calls *atomic.Int64) http.RoundTripper {
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		var req struct {
+			Prompt string `json:"prompt"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+			Body:       io.NopCloser(strings.NewReader("data: " + strings.ToUpper(req.Prompt) + "\n\n")),
+			Request:    r,
+		}, nil
+	})
+}
+
+// httpEngine is a minimal provider engine: it posts the last user text and
+// appends the response body as assistant text, emitting one info event.
+type httpEngine struct {
+	client *http.Client
+}
+
+func (e *httpEngine) RunInference(ctx context.Context, t *turns.Turn) (*turns.Turn, error) {
+	prompt := ""
+	for _, b := range t.Blocks {
+		if s, ok := b.Payload[turns.PayloadKeyText].(string); ok {
+			prompt = s
+		}
+	}
+	body, _ := json.Marshal(map[string]any{"prompt": prompt, "model": "m"})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.example.com/v1/chat?key=secret", strings.NewReader(string(body)))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	out, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	events.PublishEventToContext(ctx, events.NewInfoEvent(events.EventMetadata{ID: uuid.New()}, "response", map[string]any{"bytes": len(out)}))
+	next := t.Clone()
+	turns.AppendBlock(next, turns.NewAssistantTextBlock(string(out)))
+	return next, nil
+}
+
+func userTurn(text string) *turns.Turn {
+	t := &turns.Turn{}
+	turns.AppendBlock(t, turns.NewUserTextBlock(text))
+	return t
+}
+
+func recordCassette(t *testing.T, path string, prompts ...string) int64 {
+	t.Helper()
+	var calls atomic.Int64
+	rec, err := New(path, ModeRecord, WithTransport(echoProvider(&calls)))
+	require.NoError(t, err)
+	eng := rec.WrapEngine(&httpEngine{client: rec.HTTPClient(nil)})
+	for _, p := range prompts {
+		_, err := eng.RunInference(context.Background(), userTurn(p))
+		require.NoError(t, err)
+	}
+	require.NoError(t, rec.Close())
+	return calls.Load()
+}
+
+func TestRecorder_RecordThenReplayStrict(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassettes", "chat.json")
+	require.Equal(t, int64(2), recordCassette(t, path, "hello", "again"))
+
+	c, err := LoadCassette(path)
+	require.NoError(t, err)
+	require.Len(t, c.Interactions, 2)
+	require.NotContains(t, c.Interactions[0].Request.URL, "secret")
+	require.Len(t, c.Events, 2)
+	require.Equal(t, 1, c.Events[1].Run)
+
+	rec, err := New(path, ModeAuto)
+	require.NoError(t, err)
+	require.Equal(t, ModeReplay, rec.Mode())
+	offline := &http.Client{Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		t.Fatalf("replay must not reach the network")
+		return nil, nil
+	})}
+	eng := rec.WrapEngine(&httpEngine{client: rec.HTTPClient(offline)})
+	out, err := eng.RunInference(context.Background(), userTurn("hello"))
+	require.NoError(t, err)
+	require.Equal(t, "data: HELLO\n\n", out.Blocks[1].Payload[turns.PayloadKeyText])
+
+	require.ErrorIs(t, rec.Close(), ErrUnusedInteractions)
+	_, err = eng.RunInference(context.Background(), userTurn("again"))
+	require.NoError(t, err)
+	require.NoError(t, rec.Close())
+}
+
+func TestRecorder_StrictRejectsUnknownRequestLenientFallsBack(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chat.json")
+	recordCassette(t, path, "hello")
+
+	strict, err := New(path, ModeReplay)
+	require.NoError(t, err)
+	_, err = strict.WrapEngine(&httpEngine{client: strict.HTTPClient(nil)}).RunInference(context.Background(), userTurn("drifted"))
+	require.ErrorIs(t, err, ErrNoInteraction)
+
+	lenient, err := New(path, ModeReplay, WithMatchMode(MatchLenient))
+	require.NoError(t, err)
+	out, err := lenient.WrapEngine(&httpEngine{client: lenient.HTTPClient(nil)}).RunInference(context.Background(), userTurn("drifted"))
+	require.NoError(t, err)
+	require.Equal(t, "data: HELLO\n\n", out.Blocks[1].Payload[turns.PayloadKeyText])
+}
+
+func TestRecorder_StrictReplayDetectsEventDrift(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chat.json")
+	recordCassette(t, path, "hello")
+
+	c, err := LoadCassette(path)
+	require.NoError(t, err)
+	c.Events = nil
+	require.NoError(t, c.Save(path))
+
+	rec, err := New(path, ModeReplay)
+	require.NoError(t, err)
+	_, err = rec.WrapEngine(&httpEngine{client: rec.HTTPClient(nil)}).RunInference(context.Background(), userTurn("hello"))
+	require.ErrorIs(t, err, ErrEventMismatch)
+}
+
+func TestRequestKey_CanonicalizesJSONAndRedactsCredentials(t *testing.T) {
+	u1, _ := url.Parse("https://api.example.com/v1/chat?b=2&a=1&key=one")
+	u2, _ := url.Parse("https://api.example.com/v1/chat?a=1&b=2&key=two")
+	k1 := RequestKey("POST", u1, []byte(`{"model":"m","prompt":"hi","user":"u1"}`), []string{"user"})
+	k2 := RequestKey("post", u2, []byte("{\n  \"prompt\": \"hi\", \"model\": \"m\", \"user\": \"u2\"}"), []string{"user"})
+	require.Equal(t, k1, k2)
+	require.NotEqual(t, k1, RequestKey("POST", u1, []byte(`{"model":"m","prompt":"bye"}`), nil))
+}
+
+func TestBody_RoundTripsBinaryAndText(t *testing.T) {
+	for _, b := range []Body{Body("data: {\"x\":1}\n\n"), Body{0xff, 0x00, 0xfe}} {
+		raw, err := json.Marshal(b)
+		require.NoError(t, err)
+		var got Body
+		require.NoError(t, json.Unmarshal(raw, &got))
+		require.Equal(t, []byte(b), []byte(got))
+	}
+}
+
+func TestRecorder_StrictReplayComparesEventPayloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chat.json")
+	recordCassette(t, path, "hello")
+
+	c, err := LoadCassette(path)
+	require.NoError(t, err)
+	require.Len(t, c.Events, 1)
+	var recorded map[string]any
+	require.NoError(t, json.Unmarshal(c.Events[0].Event, &recorded))
+	recorded["data"] = map[string]any{"bytes": 1}
+	c.Events[0].Event, err = json.Marshal(recorded)
+	require.NoError(t, err)
+	require.NoError(t, c.Save(path))
+
+	rec, err := New(path, ModeReplay)
+	require.NoError(t, err)
+	_, err = rec.WrapEngine(&httpEngine{client: rec.HTTPClient(nil)}).RunInference(context.Background(), userTurn("hello"))
+	require.ErrorIs(t, err, ErrEventMismatch)
+	require.Contains(t, err.Error(), "event 0")
+}
+
+func TestNormalizeEventJSON_IgnoresIDsAndTimestamps(t *testing.T) {
+	a, err := normalizeEventJSON([]byte(`{"type":"info","meta":{"message_id":"` + uuid.NewString() + `","duration_ms":12},"at":"2026-01-02T03:04:05Z","n":1}`))
+	require.NoError(t, err)
+	b, err := normalizeEventJSON([]byte(`{"n":1,"at":"2027-05-06T07:08:09.5Z","meta":{"duration_ms":40,"message_id":"` + uuid.NewString() + `"},"type":"info"}`))
+	require.NoError(t, err)
+	require.JSONEq(t, string(a), string(b))
+	require.Equal(t, string(a), string(b))
+}
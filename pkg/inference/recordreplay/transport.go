@@ -0,0 +1,201 @@
+package recordreplay
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNoInteraction is returned on replay when no recorded interaction matches a request.
+var ErrNoInteraction = errors.New("recordreplay: no recorded interaction matches request")
+
+// redactedQueryParams are dropped from recorded URLs and request keys because
+// some providers accept credentials as query parameters.
+var redactedQueryParams = map[string]bool{
+	"key":          true,
+	"api_key":      true,
+	"apikey":       true,
+	"access_token": true,
+}
+
+// RequestKey returns the normalized hash of a provider request: method, URL
+// (redacted, with sorted query) and body. JSON bodies are canonicalized so key
+// order and whitespace do not matter; top-level fields listed in ignoreFields are
+// dropped before hashing.
+func RequestKey(method string, u *url.URL, body []byte, ignoreFields []string) string {
+	h := sha256.New()
+	_, _ = io.WriteString(h, strings.ToUpper(method))
+	_, _ = io.WriteString(h, "\n")
+	_, _ = io.WriteString(h, redactURL(u))
+	_, _ = io.WriteString(h, "\n")
+	_, _ = h.Write(normalizeBody(body, ignoreFields))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func normalizeBody(body []byte, ignoreFields []string) []byte {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(trimmed, &v); err != nil {
+		return trimmed
+	}
+	if m, ok := v.(map[string]any); ok {
+		for _, f := range ignoreFields {
+			delete(m, f)
+		}
+	}
+	// encoding/json sorts map keys, which makes the output canonical.
+	out, err := json.Marshal(v)
+	if err != nil {
+		return trimmed
+	}
+	return out
+}
+
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	c := *u
+	c.User = nil
+	c.Fragment = ""
+	q := c.Query()
+	for k := range q {
+		if redactedQueryParams[strings.ToLower(k)] {
+			q.Del(k)
+		}
+	}
+	c.RawQuery = q.Encode() // Encode sorts by key
+	return c.String()
+}
+
+// transport is the http.RoundTripper installed into provider HTTP clients.
+type transport struct {
+	r    *Recorder
+	next http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, "recordreplay: read request body")
+		}
+		body = b
+	}
+	key := RequestKey(req.Method, req.URL, body, t.r.ignoreFields)
+
+	if t.r.mode == ModeReplay {
+		it, err := t.r.match(req, key)
+		if err != nil {
+			return nil, err
+		}
+		return replayResponse(req, it), nil
+	}
+
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	out.ContentLength = int64(len(body))
+	resp, err := t.next.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "recordreplay: read response body")
+	}
+	header := resp.Header.Clone()
+	header.Del("Set-Cookie")
+	t.r.record(Interaction{
+		Key:      key,
+		Request:  RecordedRequest{Method: req.Method, URL: redactURL(req.URL), Body: body},
+		Response: RecordedResponse{StatusCode: resp.StatusCode, Header: header, Body: respBody},
+	})
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	resp.ContentLength = int64(len(respBody))
+	return resp, nil
+}
+
+func replayResponse(req *http.Request, it Interaction) *http.Response {
+	header := it.Response.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", it.Response.StatusCode, http.StatusText(it.Response.StatusCode)),
+		StatusCode:    it.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(it.Response.Body)),
+		ContentLength: int64(len(it.Response.Body)),
+		Request:       req,
+	}
+}
+
+// interactionIndex tracks which recorded interactions were consumed on replay.
+type interactionIndex struct {
+	mu   sync.Mutex
+	used []bool
+}
+
+func (r *Recorder) match(req *http.Request, key string) (Interaction, error) {
+	r.idx.mu.Lock()
+	defer r.idx.mu.Unlock()
+	its := r.cassette.Interactions
+	for i, it := range its {
+		if !r.idx.used[i] && it.Key == key {
+			r.idx.used[i] = true
+			return it, nil
+		}
+	}
+	if r.matchMode == MatchLenient {
+		// Fall back to the next unused exchange on the same endpoint, in
+		// recording order. This tolerates prompt or parameter drift.
+		want := endpointOf(req.Method, redactURL(req.URL))
+		for i, it := range its {
+			if !r.idx.used[i] && endpointOf(it.Request.Method, it.Request.URL) == want {
+				r.idx.used[i] = true
+				log.Debug().Str("url", it.Request.URL).Msg("recordreplay: lenient match on endpoint")
+				return it, nil
+			}
+		}
+	}
+	return Interaction{}, errors.Wrapf(ErrNoInteraction, "%s %s (key %s)", req.Method, redactURL(req.URL), key)
+}
+
+func endpointOf(method, rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return strings.ToUpper(method) + " " + rawURL
+	}
+	return strings.ToUpper(method) + " " + u.Scheme + "://" + u.Host + u.Path
+}
+
+// unusedInteractions returns recorded exchanges that were never replayed.
+func (r *Recorder) unusedInteractions() []Interaction {
+	r.idx.mu.Lock()
+	defer r.idx.mu.Unlock()
+	var out []Interaction
+	for i, it := range r.cassette.Interactions {
+		if !r.idx.used[i] {
+			out = append(out, it)
+		}
+	}
+	return out
+}
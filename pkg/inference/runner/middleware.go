@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/go-go-golems/geppetto/pkg/inference/budget"
 	"github.com/go-go-golems/geppetto/pkg/inference/engine"
 	gepmiddleware "github.com/go-go-golems/geppetto/pkg/inference/middleware"
 	"github.com/go-go-golems/geppetto/pkg/inference/middlewarecfg"
@@ -112,7 +113,28 @@ func (r *Runner) resolveMiddlewares(ctx context.Context, runtime Runtime) ([]gep
 		})
 	}
 
-	return middlewarecfg.BuildChain(ctx, r.middlewareBuildDeps, resolved)
+	return middlewarecfg.BuildChain(ctx, runtimeMiddlewareBuildDeps(r.middlewareBuildDeps, runtime, r.usageTracker), resolved)
+}
+
+// runtimeMiddlewareBuildDeps adds runtime-scoped dependencies and the runner's
+// usage tracker to the application-provided build deps without overriding
+// explicit values.
+func runtimeMiddlewareBuildDeps(base middlewarecfg.BuildDeps, runtime Runtime, tracker *budget.Tracker) middlewarecfg.BuildDeps {
+	deps := base.Clone()
+	if deps.Values == nil {
+		deps.Values = map[string]any{}
+	}
+	if _, ok := deps.Get(middlewarecfg.BuildDepUsageTracker); !ok && tracker != nil {
+		deps.Values[middlewarecfg.BuildDepUsageTracker] = tracker
+	}
+	if runtime.InferenceSettings == nil {
+		return deps
+	}
+	if _, ok := deps.Get(middlewarecfg.BuildDepInferenceSettings); ok {
+		return deps
+	}
+	deps.Values[middlewarecfg.BuildDepInferenceSettings] = runtime.InferenceSettings
+	return deps
 }
 
 type fixedPayloadSource struct {
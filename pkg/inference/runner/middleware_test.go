@@ -3,6 +3,7 @@ package runner
 import (
 	"context"
 	"errors"
+	"github.com/go-go-golems/geppetto/pkg/inference/budget"
 	"github.com/go-go-golems/geppetto/pkg/inference/engine"
 	gepmiddleware "github.com/go-go-golems/geppetto/pkg/inference/middleware"
 	"github.com/go-go-golems/geppetto/pkg/inference/middlewarecfg"
@@ -160,3 +161,31 @@ func TestBuildEngineRequiresInferenceSettings(t *testing.T) {
 		t.Fatalf("expected ErrRuntimeInferenceSettingsNil, got %v", err)
 	}
 }
+
+func TestResolveMiddlewaresSharesUsageTrackerAcrossBuilds(t *testing.T) {
+	registry := middlewarecfg.NewInMemoryDefinitionRegistry()
+	if err := registry.RegisterDefinition(middlewarecfg.BudgetDefinition{}); err != nil {
+		t.Fatalf("RegisterDefinition: %v", err)
+	}
+	tracker := budget.NewTracker()
+	r := New(WithMiddlewareDefinitions(registry), WithUsageTracker(tracker))
+	runtime := Runtime{MiddlewareUses: []middlewarecfg.Use{{Name: "budget", Config: map[string]any{"session_max_tokens": 3}}}}
+
+	for i := 0; i < 2; i++ {
+		base := &captureEngine{}
+		eng, _, err := r.buildEngineFromBase(context.Background(), base, runtime)
+		if err != nil {
+			t.Fatalf("buildEngineFromBase: %v", err)
+		}
+		turn := &turns.Turn{}
+		_ = turns.KeyTurnMetaSessionID.Set(&turn.Metadata, "sess-1")
+		_ = turns.KeyTurnMetaInferenceResult.Set(&turn.Metadata, turns.InferenceResult{Usage: &turns.InferenceUsage{InputTokens: 3}})
+		_, err = eng.RunInference(context.Background(), turn)
+		if i == 1 && !errors.Is(err, budget.ErrBudgetExceeded) {
+			t.Fatalf("expected the second build to see the session usage, got %v", err)
+		}
+	}
+	if got := tracker.Session("sess-1").TotalTokens(); got != 3 {
+		t.Fatalf("expected session usage in the runner tracker, got %d", got)
+	}
+}
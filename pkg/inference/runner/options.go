@@ -4,11 +4,14 @@ import (
 	"time"
 
 	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/inference/budget"
+	"github.com/go-go-golems/geppetto/pkg/inference/engine"
 	"github.com/go-go-golems/geppetto/pkg/inference/engine/factory"
 	"github.com/go-go-golems/geppetto/pkg/inference/middlewarecfg"
 	"github.com/go-go-golems/geppetto/pkg/inference/toolloop"
 	"github.com/go-go-golems/geppetto/pkg/inference/toolloop/enginebuilder"
 	geptools "github.com/go-go-golems/geppetto/pkg/inference/tools"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
 )
 
 // Option mutates a runner during construction.
@@ -26,9 +29,41 @@ func New(opts ...Option) *Runner {
 			opt(r)
 		}
 	}
+	if r.usageTracker == nil {
+		r.usageTracker = defaultUsageTracker(r.middlewareBuildDeps)
+	}
 	return r
 }
 
+// processUsageTracker is shared by every runner without its own tracker, so
+// session and profile budgets span runners and chain builds.
+var processUsageTracker = budget.NewTracker()
+
+func defaultUsageTracker(deps middlewarecfg.BuildDeps) *budget.Tracker {
+	if v, ok := deps.Get(middlewarecfg.BuildDepUsageTracker); ok {
+		if tracker, ok := v.(*budget.Tracker); ok && tracker != nil {
+			return tracker
+		}
+	}
+	return processUsageTracker
+}
+
+// WithEngineFactory sets how the runner builds the provider engine from the
+// runtime's inference settings (default factory.NewEngineFromSettings).
+func WithEngineFactory(f func(*settings.InferenceSettings) (engine.Engine, error)) Option {
+	return func(r *Runner) {
+		r.engineFactory = f
+	}
+}
+
+// WithUsageTracker sets the tracker that profile-declared budget middleware
+// aggregates in (default: one tracker shared by the process).
+func WithUsageTracker(tracker *budget.Tracker) Option {
+	return func(r *Runner) {
+		r.usageTracker = tracker
+	}
+}
+
 func WithMiddlewareDefinitions(defs middlewarecfg.DefinitionRegistry) Option {
 	return func(r *Runner) {
 		r.middlewareDefinitions = defs
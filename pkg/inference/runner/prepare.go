@@ -50,6 +50,7 @@ func (r *Runner) Prepare(ctx context.Context, req StartRequest) (*PreparedRun, e
 		StepController:   r.stepController,
 		StepPauseTimeout: r.stepPauseTimeout,
 		Persister:        choosePersister(r.persister, req.Persister),
+		UsageTracker:     r.usageTracker,
 	}
 
 	turn, err := appendSeedTurn(sess, req)
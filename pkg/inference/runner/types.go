@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/inference/budget"
 	"github.com/go-go-golems/geppetto/pkg/inference/engine"
 	gepmiddleware "github.com/go-go-golems/geppetto/pkg/inference/middleware"
 	"github.com/go-go-golems/geppetto/pkg/inference/middlewarecfg"
@@ -75,4 +76,5 @@ type Runner struct {
 	persister        enginebuilder.TurnPersister
 	stepController   *toolloop.StepController
 	stepPauseTimeout time.Duration
+	usageTracker     *budget.Tracker
 }
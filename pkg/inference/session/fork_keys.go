@@ -0,0 +1,20 @@
+package session
+
+import "github.com/go-go-golems/geppetto/pkg/turns"
+
+// Fork lineage keys written on the first Turn of a forked session. The IDs match
+// the ones the JavaScript session API has always written (session.fork()), so
+// Go and JS forks are read back the same way.
+const (
+	TurnMetaForkedFromSourceValueKey    = "forkedFromSource"
+	TurnMetaForkedFromSessionIDValueKey = "forkedFromSessionID"
+	TurnMetaForkedFromTurnIDValueKey    = "forkedFromTurnID"
+	TurnMetaForkedAtMsValueKey          = "forkedAtMs"
+)
+
+var (
+	KeyTurnMetaForkedFromSource    = turns.TurnMetaK[string](turns.GeppettoNamespaceKey, TurnMetaForkedFromSourceValueKey, 1)
+	KeyTurnMetaForkedFromSessionID = turns.TurnMetaK[string](turns.GeppettoNamespaceKey, TurnMetaForkedFromSessionIDValueKey, 1)
+	KeyTurnMetaForkedFromTurnID    = turns.TurnMetaK[string](turns.GeppettoNamespaceKey, TurnMetaForkedFromTurnIDValueKey, 1)
+	KeyTurnMetaForkedAtMs          = turns.TurnMetaK[int64](turns.GeppettoNamespaceKey, TurnMetaForkedAtMsValueKey, 1)
+)
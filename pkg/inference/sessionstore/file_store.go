@@ -0,0 +1,377 @@
+package sessionstore
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/go-go-golems/geppetto/pkg/turns/serde"
+	"gopkg.in/yaml.v3"
+)
+
+// FileStore is a Store that keeps one directory per session under a root
+// directory:
+//
+//	<root>/<session>/session.yaml
+//	<root>/<session>/turns/<turn>.<phase>.yaml
+//
+// It suits single-process tools and tests; use SQLiteStore when several
+// processes share the same history.
+type FileStore struct {
+	mu     sync.RWMutex
+	root   string
+	closed bool
+}
+
+var _ Store = (*FileStore)(nil)
+
+type fileTurnRecord struct {
+	ConvID      string `yaml:"conv_id"`
+	SessionID   string `yaml:"session_id"`
+	TurnID      string `yaml:"turn_id"`
+	Phase       string `yaml:"phase"`
+	RuntimeKey  string `yaml:"runtime_key,omitempty"`
+	InferenceID string `yaml:"inference_id,omitempty"`
+	CreatedAtMs int64  `yaml:"created_at_ms"`
+	// Seq orders records written within the same millisecond.
+	Seq  int64  `yaml:"seq"`
+	Turn string `yaml:"turn"`
+}
+
+type fileSessionRecord struct {
+	SessionID       string `yaml:"session_id"`
+	ParentSessionID string `yaml:"parent_session_id,omitempty"`
+	ParentTurnID    string `yaml:"parent_turn_id,omitempty"`
+	ForkSource      string `yaml:"fork_source,omitempty"`
+	CreatedAtMs     int64  `yaml:"created_at_ms"`
+}
+
+// NewFileStore returns a FileStore rooted at root, creating the directory.
+func NewFileStore(root string) (*FileStore, error) {
+	root = strings.TrimSpace(root)
+	if root == "" {
+		return nil, fmt.Errorf("file session store: root directory is required")
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("file session store: create root: %w", err)
+	}
+	return &FileStore{root: root}, nil
+}
+
+func (s *FileStore) ensureOpen() error {
+	if s == nil {
+		return ErrStoreNil
+	}
+	if s.closed {
+		return ErrStoreClosed
+	}
+	return nil
+}
+
+// PersistTurn stores t under PhaseFinal.
+func (s *FileStore) PersistTurn(ctx context.Context, t *turns.Turn) error {
+	return s.SaveTurn(ctx, PhaseFinal, t)
+}
+
+// SaveTurn stores a snapshot of t under phase and records the session if new.
+func (s *FileStore) SaveTurn(_ context.Context, phase string, t *turns.Turn) error {
+	if s == nil {
+		return ErrStoreNil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.ensureOpen(); err != nil {
+		return err
+	}
+	if t == nil {
+		return nil
+	}
+	sessionID := turnSessionID(t)
+	if sessionID == "" {
+		return ErrSessionIDEmpty
+	}
+	payload, err := serde.ToYAML(t, serde.Options{})
+	if err != nil {
+		return fmt.Errorf("file session store: serialize turn: %w", err)
+	}
+	now := time.Now()
+
+	sessionPath := s.sessionPath(sessionID)
+	if _, err := os.Stat(sessionPath); errors.Is(err, os.ErrNotExist) {
+		if err := writeYAMLFile(sessionPath, sessionRecordToFile(sessionRecordFromTurn(sessionID, t, now.UnixMilli()))); err != nil {
+			return fmt.Errorf("file session store: record session: %w", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("file session store: stat session: %w", err)
+	}
+
+	phase = normalizePhase(phase)
+	turnID := turnRecordID(t)
+	path := filepath.Join(s.sessionDir(sessionID), "turns", fileSafeName(turnID)+"."+fileSafeName(phase)+".yaml")
+	rec := fileTurnRecord{
+		ConvID:      sessionID,
+		SessionID:   sessionID,
+		TurnID:      turnID,
+		Phase:       phase,
+		RuntimeKey:  turnRuntimeKey(t),
+		InferenceID: turnInferenceID(t),
+		CreatedAtMs: now.UnixMilli(),
+		Seq:         now.UnixNano(),
+		Turn:        string(payload),
+	}
+	if existing, err := readFileTurnRecord(path); err == nil {
+		rec.Seq = existing.Seq
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("file session store: read existing turn: %w", err)
+	}
+	if err := writeYAMLFile(path, rec); err != nil {
+		return fmt.Errorf("file session store: persist turn: %w", err)
+	}
+	return nil
+}
+
+// ListTurns returns matching records newest first.
+func (s *FileStore) ListTurns(_ context.Context, q Query) ([]TurnRecord, error) {
+	if s == nil {
+		return nil, ErrStoreNil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if err := s.ensureOpen(); err != nil {
+		return nil, err
+	}
+	raw, err := s.listFileTurnRecords(strings.TrimSpace(q.SessionID))
+	if err != nil {
+		return nil, err
+	}
+	convID := strings.TrimSpace(q.ConvID)
+	phase := strings.TrimSpace(q.Phase)
+	filtered := raw[:0]
+	for _, rec := range raw {
+		if convID != "" && rec.ConvID != convID {
+			continue
+		}
+		if phase != "" && rec.Phase != phase {
+			continue
+		}
+		if q.SinceMs > 0 && rec.CreatedAtMs < q.SinceMs {
+			continue
+		}
+		filtered = append(filtered, rec)
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		if filtered[i].CreatedAtMs != filtered[j].CreatedAtMs {
+			return filtered[i].CreatedAtMs > filtered[j].CreatedAtMs
+		}
+		return filtered[i].Seq > filtered[j].Seq
+	})
+	if q.Limit > 0 && len(filtered) > q.Limit {
+		filtered = filtered[:q.Limit]
+	}
+	out := make([]TurnRecord, 0, len(filtered))
+	for _, rec := range filtered {
+		tr := TurnRecord{
+			ConvID:      rec.ConvID,
+			SessionID:   rec.SessionID,
+			TurnID:      rec.TurnID,
+			Phase:       rec.Phase,
+			RuntimeKey:  rec.RuntimeKey,
+			InferenceID: rec.InferenceID,
+			CreatedAtMs: rec.CreatedAtMs,
+		}
+		if strings.TrimSpace(rec.Turn) != "" {
+			decoded, err := serde.FromYAML([]byte(rec.Turn))
+			if err != nil {
+				return nil, fmt.Errorf("file session store: decode turn %s/%s: %w", rec.SessionID, rec.TurnID, err)
+			}
+			tr.Turn = decoded
+		}
+		out = append(out, tr)
+	}
+	return out, nil
+}
+
+// LoadLatestTurn returns the newest matching record, defaulting to PhaseFinal.
+func (s *FileStore) LoadLatestTurn(ctx context.Context, q Query) (*TurnRecord, error) {
+	if strings.TrimSpace(q.ConvID) == "" && strings.TrimSpace(q.SessionID) == "" {
+		return nil, fmt.Errorf("file session store: conv id or session id required")
+	}
+	q.Phase = normalizePhase(q.Phase)
+	q.Limit = 1
+	recs, err := s.ListTurns(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if len(recs) == 0 {
+		return nil, nil
+	}
+	return &recs[0], nil
+}
+
+// SaveSession creates or replaces a session record.
+func (s *FileStore) SaveSession(_ context.Context, rec SessionRecord) error {
+	if s == nil {
+		return ErrStoreNil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.ensureOpen(); err != nil {
+		return err
+	}
+	rec.SessionID = strings.TrimSpace(rec.SessionID)
+	if rec.SessionID == "" {
+		return ErrSessionIDEmpty
+	}
+	if rec.CreatedAtMs == 0 {
+		rec.CreatedAtMs = time.Now().UnixMilli()
+	}
+	if err := writeYAMLFile(s.sessionPath(rec.SessionID), sessionRecordToFile(rec)); err != nil {
+		return fmt.Errorf("file session store: save session: %w", err)
+	}
+	return nil
+}
+
+// GetSession returns the session record, or nil when it does not exist.
+func (s *FileStore) GetSession(_ context.Context, sessionID string) (*SessionRecord, error) {
+	if s == nil {
+		return nil, ErrStoreNil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if err := s.ensureOpen(); err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(s.sessionPath(strings.TrimSpace(sessionID)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("file session store: get session: %w", err)
+	}
+	var rec fileSessionRecord
+	if err := yaml.Unmarshal(b, &rec); err != nil {
+		return nil, fmt.Errorf("file session store: decode session: %w", err)
+	}
+	return &SessionRecord{
+		SessionID:       rec.SessionID,
+		ParentSessionID: rec.ParentSessionID,
+		ParentTurnID:    rec.ParentTurnID,
+		ForkSource:      rec.ForkSource,
+		CreatedAtMs:     rec.CreatedAtMs,
+	}, nil
+}
+
+// Close marks the store closed; later calls fail with ErrStoreClosed.
+func (s *FileStore) Close() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *FileStore) sessionDir(sessionID string) string {
+	return filepath.Join(s.root, fileSafeName(sessionID))
+}
+
+func (s *FileStore) sessionPath(sessionID string) string {
+	return filepath.Join(s.sessionDir(sessionID), "session.yaml")
+}
+
+func (s *FileStore) listFileTurnRecords(sessionID string) ([]fileTurnRecord, error) {
+	var dirs []string
+	if sessionID != "" {
+		dirs = []string{s.sessionDir(sessionID)}
+	} else {
+		entries, err := os.ReadDir(s.root)
+		if err != nil {
+			return nil, fmt.Errorf("file session store: list sessions: %w", err)
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				dirs = append(dirs, filepath.Join(s.root, e.Name()))
+			}
+		}
+	}
+	var out []fileTurnRecord
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(filepath.Join(dir, "turns"))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("file session store: list turns: %w", err)
+		}
+		for _, e := range entries {
+			if e.IsDir() || !strings.HasSuffix(e.Name(), ".yaml") {
+				continue
+			}
+			rec, err := readFileTurnRecord(filepath.Join(dir, "turns", e.Name()))
+			if err != nil {
+				return nil, fmt.Errorf("file session store: read turn %s: %w", e.Name(), err)
+			}
+			out = append(out, rec)
+		}
+	}
+	return out, nil
+}
+
+func readFileTurnRecord(path string) (fileTurnRecord, error) {
+	var rec fileTurnRecord
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return rec, err
+	}
+	if err := yaml.Unmarshal(b, &rec); err != nil {
+		return rec, err
+	}
+	return rec, nil
+}
+
+func sessionRecordToFile(rec SessionRecord) fileSessionRecord {
+	return fileSessionRecord{
+		SessionID:       rec.SessionID,
+		ParentSessionID: rec.ParentSessionID,
+		ParentTurnID:    rec.ParentTurnID,
+		ForkSource:      rec.ForkSource,
+		CreatedAtMs:     rec.CreatedAtMs,
+	}
+}
+
+// writeYAMLFile writes v atomically via a temp file and rename.
+func writeYAMLFile(path string, v any) error {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+var fileSafeNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// fileSafeName returns s when it is a plain identifier, or a "~"-prefixed
+// base64url encoding otherwise, so IDs can never escape the store directory.
+func fileSafeName(s string) string {
+	if fileSafeNameRe.MatchString(s) {
+		return s
+	}
+	return "~" + base64.RawURLEncoding.EncodeToString([]byte(s))
+}
@@ -0,0 +1,164 @@
+package sessionstore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/session"
+	"github.com/go-go-golems/geppetto/pkg/inference/toolloop"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/google/uuid"
+)
+
+// ForkSourceFork is the fork source recorded by Fork, matching JS session.fork().
+const ForkSourceFork = "fork"
+
+// LoadSession rehydrates a Session from its stored PhaseFinal turns, oldest
+// first. The returned Session has no Builder; set one before starting inference.
+func LoadSession(ctx context.Context, st Store, sessionID string) (*session.Session, error) {
+	if st == nil {
+		return nil, ErrStoreNil
+	}
+	sessionID = strings.TrimSpace(sessionID)
+	if sessionID == "" {
+		return nil, ErrSessionIDEmpty
+	}
+	recs, err := st.ListTurns(ctx, Query{SessionID: sessionID, Phase: PhaseFinal})
+	if err != nil {
+		return nil, err
+	}
+	if len(recs) == 0 {
+		info, err := st.GetSession(ctx, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		if info == nil {
+			return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+		}
+	}
+	sess := session.NewSessionWithID(sessionID)
+	for i := len(recs) - 1; i >= 0; i-- {
+		if recs[i].Turn == nil {
+			continue
+		}
+		sess.Append(recs[i].Turn)
+	}
+	return sess, nil
+}
+
+// Fork starts a new session from a stored turn of sourceSessionID and records
+// the lineage. An empty turnID forks from the latest final turn; an empty
+// newSessionID generates one. The seed turn is persisted under the new session
+// so the fork can be rehydrated before its first inference.
+func Fork(ctx context.Context, st Store, sourceSessionID, turnID, newSessionID string) (*session.Session, error) {
+	if st == nil {
+		return nil, ErrStoreNil
+	}
+	sourceSessionID = strings.TrimSpace(sourceSessionID)
+	if sourceSessionID == "" {
+		return nil, ErrSessionIDEmpty
+	}
+	base, err := loadForkBase(ctx, st, sourceSessionID, strings.TrimSpace(turnID))
+	if err != nil {
+		return nil, err
+	}
+	if newSessionID = strings.TrimSpace(newSessionID); newSessionID == "" {
+		newSessionID = uuid.NewString()
+	}
+
+	seed := base.Clone()
+	now := time.Now().UnixMilli()
+	if err := turns.KeyTurnMetaSessionID.Set(&seed.Metadata, newSessionID); err != nil {
+		return nil, err
+	}
+	if err := session.KeyTurnMetaForkedFromSource.Set(&seed.Metadata, ForkSourceFork); err != nil {
+		return nil, err
+	}
+	if err := session.KeyTurnMetaForkedFromSessionID.Set(&seed.Metadata, sourceSessionID); err != nil {
+		return nil, err
+	}
+	if err := session.KeyTurnMetaForkedFromTurnID.Set(&seed.Metadata, base.ID); err != nil {
+		return nil, err
+	}
+	if err := session.KeyTurnMetaForkedAtMs.Set(&seed.Metadata, now); err != nil {
+		return nil, err
+	}
+
+	if err := st.SaveSession(ctx, SessionRecord{
+		SessionID:       newSessionID,
+		ParentSessionID: sourceSessionID,
+		ParentTurnID:    base.ID,
+		ForkSource:      ForkSourceFork,
+		CreatedAtMs:     now,
+	}); err != nil {
+		return nil, err
+	}
+	if err := st.PersistTurn(ctx, seed); err != nil {
+		return nil, err
+	}
+
+	sess := session.NewSessionWithID(newSessionID)
+	sess.Append(seed)
+	return sess, nil
+}
+
+func loadForkBase(ctx context.Context, st Store, sessionID, turnID string) (*turns.Turn, error) {
+	if turnID == "" {
+		rec, err := st.LoadLatestTurn(ctx, Query{SessionID: sessionID, Phase: PhaseFinal})
+		if err != nil {
+			return nil, err
+		}
+		if rec == nil || rec.Turn == nil {
+			return nil, fmt.Errorf("%w: session %s has no final turn", ErrTurnNotFound, sessionID)
+		}
+		return rec.Turn, nil
+	}
+	recs, err := st.ListTurns(ctx, Query{SessionID: sessionID, Phase: PhaseFinal})
+	if err != nil {
+		return nil, err
+	}
+	for _, rec := range recs {
+		if rec.TurnID == turnID && rec.Turn != nil {
+			return rec.Turn, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s/%s", ErrTurnNotFound, sessionID, turnID)
+}
+
+// Lineage returns the session record of sessionID followed by its ancestors,
+// nearest first. Ancestors missing from the store end the chain.
+func Lineage(ctx context.Context, st Store, sessionID string) ([]SessionRecord, error) {
+	if st == nil {
+		return nil, ErrStoreNil
+	}
+	var out []SessionRecord
+	seen := map[string]bool{}
+	for id := strings.TrimSpace(sessionID); id != "" && !seen[id]; {
+		seen[id] = true
+		rec, err := st.GetSession(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if rec == nil {
+			break
+		}
+		out = append(out, *rec)
+		id = rec.ParentSessionID
+	}
+	return out, nil
+}
+
+// SnapshotHook returns a tool loop snapshot hook that saves every phase snapshot
+// to st. Failures are logged and never interrupt the loop.
+func SnapshotHook(st Store) toolloop.SnapshotHook {
+	return func(ctx context.Context, t *turns.Turn, phase string) {
+		if st == nil || t == nil {
+			return
+		}
+		if err := st.SaveTurn(ctx, phase, t); err != nil {
+			log.Warn().Err(err).Str("turn_id", t.ID).Str("phase", phase).Msg("session store: snapshot failed")
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package sessionstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/go-go-golems/geppetto/pkg/turns/serde"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// The geppetto_turns table keeps the layout of the original JS provider turn
+// store so existing databases open unchanged.
+const sqliteStoreSchema = `
+CREATE TABLE IF NOT EXISTS geppetto_turns (
+	conv_id TEXT NOT NULL,
+	session_id TEXT NOT NULL,
+	turn_id TEXT NOT NULL,
+	phase TEXT NOT NULL,
+	runtime_key TEXT NOT NULL DEFAULT '',
+	inference_id TEXT NOT NULL DEFAULT '',
+	created_at_ms INTEGER NOT NULL,
+	payload TEXT NOT NULL,
+	PRIMARY KEY (conv_id, session_id, turn_id, phase)
+);
+CREATE INDEX IF NOT EXISTS geppetto_turns_by_session ON geppetto_turns(session_id, phase, created_at_ms DESC);
+CREATE INDEX IF NOT EXISTS geppetto_turns_by_conv ON geppetto_turns(conv_id, phase, created_at_ms DESC);
+CREATE TABLE IF NOT EXISTS geppetto_sessions (
+	session_id TEXT PRIMARY KEY,
+	parent_session_id TEXT NOT NULL DEFAULT '',
+	parent_turn_id TEXT NOT NULL DEFAULT '',
+	fork_source TEXT NOT NULL DEFAULT '',
+	created_at_ms INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS geppetto_sessions_by_parent ON geppetto_sessions(parent_session_id);
+`
+
+// SQLiteStore is a Store backed by a SQLite database.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+var _ Store = (*SQLiteStore)(nil)
+
+// OpenSQLite opens (and migrates) a SQLite store for the given DSN.
+func OpenSQLite(dsn string) (*SQLiteStore, error) {
+	dsn = strings.TrimSpace(dsn)
+	if dsn == "" {
+		return nil, fmt.Errorf("sqlite session store: empty dsn")
+	}
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite session store: open: %w", err)
+	}
+	s := &SQLiteStore{db: db}
+	if err := s.migrate(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// OpenSQLiteFile opens a SQLite store at path, creating parent directories.
+func OpenSQLiteFile(path string) (*SQLiteStore, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, fmt.Errorf("sqlite session store: empty path")
+	}
+	if dir := filepath.Dir(path); dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("sqlite session store: create db dir: %w", err)
+		}
+	}
+	return OpenSQLite(SQLiteDSNForFile(path))
+}
+
+// SQLiteDSNForFile returns the DSN used for file-backed stores (WAL mode with a
+// busy timeout so concurrent readers do not fail immediately).
+func SQLiteDSNForFile(path string) string {
+	return fmt.Sprintf("file:%s?_busy_timeout=5000&_journal_mode=WAL", path)
+}
+
+func (s *SQLiteStore) migrate() error {
+	if _, err := s.db.Exec(sqliteStoreSchema); err != nil {
+		return fmt.Errorf("sqlite session store: migrate: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) ensureOpen() error {
+	if s == nil {
+		return ErrStoreNil
+	}
+	if s.db == nil {
+		return ErrStoreClosed
+	}
+	return nil
+}
+
+// PersistTurn stores t under PhaseFinal.
+func (s *SQLiteStore) PersistTurn(ctx context.Context, t *turns.Turn) error {
+	return s.SaveTurn(ctx, PhaseFinal, t)
+}
+
+// SaveTurn stores a snapshot of t under phase and records the session if new.
+func (s *SQLiteStore) SaveTurn(ctx context.Context, phase string, t *turns.Turn) error {
+	if err := s.ensureOpen(); err != nil {
+		return err
+	}
+	if t == nil {
+		return nil
+	}
+	sessionID := turnSessionID(t)
+	if sessionID == "" {
+		return ErrSessionIDEmpty
+	}
+	payload, err := serde.ToYAML(t, serde.Options{})
+	if err != nil {
+		return fmt.Errorf("sqlite session store: serialize turn: %w", err)
+	}
+	nowMs := time.Now().UnixMilli()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sqlite session store: begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rec := sessionRecordFromTurn(sessionID, t, nowMs)
+	if _, err := tx.ExecContext(ctx, `
+INSERT INTO geppetto_sessions (session_id, parent_session_id, parent_turn_id, fork_source, created_at_ms)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT(session_id) DO NOTHING`, rec.SessionID, rec.ParentSessionID, rec.ParentTurnID, rec.ForkSource, rec.CreatedAtMs); err != nil {
+		return fmt.Errorf("sqlite session store: record session: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+INSERT INTO geppetto_turns (conv_id, session_id, turn_id, phase, runtime_key, inference_id, created_at_ms, payload)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(conv_id, session_id, turn_id, phase) DO UPDATE SET
+	runtime_key=excluded.runtime_key,
+	inference_id=excluded.inference_id,
+	created_at_ms=excluded.created_at_ms,
+	payload=excluded.payload
+`, sessionID, sessionID, turnRecordID(t), normalizePhase(phase), turnRuntimeKey(t), turnInferenceID(t), nowMs, string(payload)); err != nil {
+		return fmt.Errorf("sqlite session store: persist turn: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("sqlite session store: commit: %w", err)
+	}
+	return nil
+}
+
+// ListTurns returns matching records newest first.
+func (s *SQLiteStore) ListTurns(ctx context.Context, q Query) ([]TurnRecord, error) {
+	if err := s.ensureOpen(); err != nil {
+		return nil, err
+	}
+	convID := strings.TrimSpace(q.ConvID)
+	sessionID := strings.TrimSpace(q.SessionID)
+	phase := strings.TrimSpace(q.Phase)
+	limit := q.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT conv_id, session_id, turn_id, phase, runtime_key, inference_id, created_at_ms, payload
+FROM geppetto_turns
+WHERE (? = '' OR conv_id = ?)
+  AND (? = '' OR session_id = ?)
+  AND (? = '' OR phase = ?)
+  AND (? <= 0 OR created_at_ms >= ?)
+ORDER BY created_at_ms DESC, rowid DESC
+LIMIT ?`, convID, convID, sessionID, sessionID, phase, phase, q.SinceMs, q.SinceMs, limit)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite session store: list turns: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	out := []TurnRecord{}
+	for rows.Next() {
+		rec, err := scanTurnRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite session store: list turns: %w", err)
+	}
+	return out, nil
+}
+
+// LoadLatestTurn returns the newest matching record, defaulting to PhaseFinal.
+func (s *SQLiteStore) LoadLatestTurn(ctx context.Context, q Query) (*TurnRecord, error) {
+	if err := s.ensureOpen(); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(q.ConvID) == "" && strings.TrimSpace(q.SessionID) == "" {
+		return nil, fmt.Errorf("sqlite session store: conv id or session id required")
+	}
+	q.Phase = normalizePhase(q.Phase)
+	q.Limit = 1
+	recs, err := s.ListTurns(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if len(recs) == 0 {
+		return nil, nil
+	}
+	return &recs[0], nil
+}
+
+// SaveSession creates or replaces a session record.
+func (s *SQLiteStore) SaveSession(ctx context.Context, rec SessionRecord) error {
+	if err := s.ensureOpen(); err != nil {
+		return err
+	}
+	rec.SessionID = strings.TrimSpace(rec.SessionID)
+	if rec.SessionID == "" {
+		return ErrSessionIDEmpty
+	}
+	if rec.CreatedAtMs == 0 {
+		rec.CreatedAtMs = time.Now().UnixMilli()
+	}
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO geppetto_sessions (session_id, parent_session_id, parent_turn_id, fork_source, created_at_ms)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT(session_id) DO UPDATE SET
+	parent_session_id=excluded.parent_session_id,
+	parent_turn_id=excluded.parent_turn_id,
+	fork_source=excluded.fork_source,
+	created_at_ms=excluded.created_at_ms`, rec.SessionID, rec.ParentSessionID, rec.ParentTurnID, rec.ForkSource, rec.CreatedAtMs)
+	if err != nil {
+		return fmt.Errorf("sqlite session store: save session: %w", err)
+	}
+	return nil
+}
+
+// GetSession returns the session record, or nil when it does not exist.
+func (s *SQLiteStore) GetSession(ctx context.Context, sessionID string) (*SessionRecord, error) {
+	if err := s.ensureOpen(); err != nil {
+		return nil, err
+	}
+	var rec SessionRecord
+	err := s.db.QueryRowContext(ctx, `
+SELECT session_id, parent_session_id, parent_turn_id, fork_source, created_at_ms
+FROM geppetto_sessions WHERE session_id = ?`, strings.TrimSpace(sessionID)).
+		Scan(&rec.SessionID, &rec.ParentSessionID, &rec.ParentTurnID, &rec.ForkSource, &rec.CreatedAtMs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sqlite session store: get session: %w", err)
+	}
+	return &rec, nil
+}
+
+// Close closes the underlying database. It is safe to call more than once.
+func (s *SQLiteStore) Close() error {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	err := s.db.Close()
+	s.db = nil
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTurnRecord(row rowScanner) (TurnRecord, error) {
+	var rec TurnRecord
+	var payload string
+	if err := row.Scan(&rec.ConvID, &rec.SessionID, &rec.TurnID, &rec.Phase, &rec.RuntimeKey, &rec.InferenceID, &rec.CreatedAtMs, &payload); err != nil {
+		return TurnRecord{}, fmt.Errorf("sqlite session store: scan turn: %w", err)
+	}
+	if strings.TrimSpace(payload) != "" {
+		decoded, err := serde.FromYAML([]byte(payload))
+		if err != nil {
+			return TurnRecord{}, fmt.Errorf("sqlite session store: decode turn: %w", err)
+		}
+		rec.Turn = decoded
+	}
+	return rec, nil
+}
@@ -0,0 +1,150 @@
+package sessionstore
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/session"
+	"github.com/go-go-golems/geppetto/pkg/inference/toolloop/enginebuilder"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+)
+
+// Snapshot phases. PhaseFinal is written by PersistTurn; the others mirror the
+// phases emitted by the tool loop's snapshot hook.
+const (
+	PhaseFinal         = "final"
+	PhasePreInference  = "pre_inference"
+	PhasePostInference = "post_inference"
+	PhasePostTools     = "post_tools"
+)
+
+var (
+	ErrStoreNil        = errors.New("session store is nil")
+	ErrStoreClosed     = errors.New("session store is closed")
+	ErrSessionIDEmpty  = errors.New("session store: session id is empty")
+	ErrSessionNotFound = errors.New("session store: session not found")
+	ErrTurnNotFound    = errors.New("session store: turn not found")
+)
+
+// TurnRecord is one stored Turn snapshot.
+//
+// ConvID groups sessions of one conversation; Go callers that do not use it
+// get ConvID == SessionID, matching the JS turn store.
+type TurnRecord struct {
+	ConvID      string
+	SessionID   string
+	TurnID      string
+	Phase       string
+	RuntimeKey  string
+	InferenceID string
+	CreatedAtMs int64
+	Turn        *turns.Turn
+}
+
+// SessionRecord describes a stored session and where it was forked from.
+type SessionRecord struct {
+	SessionID       string
+	ParentSessionID string
+	ParentTurnID    string
+	ForkSource      string
+	CreatedAtMs     int64
+}
+
+// Query filters turn records. Empty fields match everything; Limit <= 0 means
+// no limit. Results are ordered newest first.
+type Query struct {
+	ConvID    string
+	SessionID string
+	Phase     string
+	SinceMs   int64
+	Limit     int
+}
+
+// Store persists session turns per session and phase, plus session lineage.
+//
+// Every Store is an enginebuilder.TurnPersister: PersistTurn stores the Turn
+// under PhaseFinal using the session ID from turns.KeyTurnMetaSessionID.
+type Store interface {
+	enginebuilder.TurnPersister
+
+	// SaveTurn stores a snapshot of t under phase, replacing any previous
+	// snapshot of the same session, turn ID and phase.
+	SaveTurn(ctx context.Context, phase string, t *turns.Turn) error
+	ListTurns(ctx context.Context, q Query) ([]TurnRecord, error)
+	// LoadLatestTurn returns the newest matching record, or nil when none match.
+	LoadLatestTurn(ctx context.Context, q Query) (*TurnRecord, error)
+
+	// SaveSession creates or replaces a session record.
+	SaveSession(ctx context.Context, rec SessionRecord) error
+	// GetSession returns the session record, or nil when it does not exist.
+	GetSession(ctx context.Context, sessionID string) (*SessionRecord, error)
+
+	Close() error
+}
+
+// sessionRecordFromTurn derives the session record implied by a persisted turn,
+// including fork lineage written by Fork or the JS session.fork() API.
+func sessionRecordFromTurn(sessionID string, t *turns.Turn, nowMs int64) SessionRecord {
+	rec := SessionRecord{SessionID: sessionID, CreatedAtMs: nowMs}
+	if t == nil {
+		return rec
+	}
+	if v, ok, err := session.KeyTurnMetaForkedFromSessionID.Get(t.Metadata); err == nil && ok {
+		rec.ParentSessionID = strings.TrimSpace(v)
+	}
+	if v, ok, err := session.KeyTurnMetaForkedFromTurnID.Get(t.Metadata); err == nil && ok {
+		rec.ParentTurnID = strings.TrimSpace(v)
+	}
+	if v, ok, err := session.KeyTurnMetaForkedFromSource.Get(t.Metadata); err == nil && ok {
+		rec.ForkSource = strings.TrimSpace(v)
+	}
+	return rec
+}
+
+func turnSessionID(t *turns.Turn) string {
+	if t == nil {
+		return ""
+	}
+	if v, ok, err := turns.KeyTurnMetaSessionID.Get(t.Metadata); err == nil && ok {
+		return strings.TrimSpace(v)
+	}
+	return ""
+}
+
+func turnRuntimeKey(t *turns.Turn) string {
+	if t == nil {
+		return ""
+	}
+	if v, ok, err := turns.KeyTurnMetaRuntime.Get(t.Metadata); err == nil && ok {
+		if s, ok := v.(string); ok {
+			return strings.TrimSpace(s)
+		}
+	}
+	return ""
+}
+
+func turnInferenceID(t *turns.Turn) string {
+	if t == nil {
+		return ""
+	}
+	if v, ok, err := turns.KeyTurnMetaInferenceID.Get(t.Metadata); err == nil && ok {
+		return strings.TrimSpace(v)
+	}
+	return ""
+}
+
+func turnRecordID(t *turns.Turn) string {
+	if id := strings.TrimSpace(t.ID); id != "" {
+		return id
+	}
+	return "turn"
+}
+
+func normalizePhase(phase string) string {
+	phase = strings.TrimSpace(phase)
+	if phase == "" {
+		return PhaseFinal
+	}
+	return phase
+}
@@ -0,0 +1,201 @@
+package sessionstore
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/session"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/stretchr/testify/require"
+)
+
+func forEachStore(t *testing.T, fn func(t *testing.T, st Store)) {
+	t.Helper()
+	t.Run("sqlite", func(t *testing.T) {
+		st, err := OpenSQLiteFile(filepath.Join(t.TempDir(), "sessions.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = st.Close() })
+		fn(t, st)
+	})
+	t.Run("file", func(t *testing.T) {
+		st, err := NewFileStore(filepath.Join(t.TempDir(), "sessions"))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = st.Close() })
+		fn(t, st)
+	})
+}
+
+func newSessionTurn(t *testing.T, sessionID, turnID string, texts ...string) *turns.Turn {
+	t.Helper()
+	turn := &turns.Turn{ID: turnID}
+	require.NoError(t, turns.KeyTurnMetaSessionID.Set(&turn.Metadata, sessionID))
+	for _, text := range texts {
+		turns.AppendBlock(turn, turns.NewUserTextBlock(text))
+	}
+	return turn
+}
+
+func TestStore_PersistListAndLoadLatest(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st Store) {
+		ctx := context.Background()
+		require.NoError(t, st.PersistTurn(ctx, newSessionTurn(t, "s1", "t1", "hello")))
+		require.NoError(t, st.PersistTurn(ctx, newSessionTurn(t, "s1", "t2", "hello", "again")))
+		require.NoError(t, st.SaveTurn(ctx, PhasePreInference, newSessionTurn(t, "s1", "t2", "hello")))
+		require.NoError(t, st.PersistTurn(ctx, newSessionTurn(t, "s2", "t9", "other")))
+
+		finals, err := st.ListTurns(ctx, Query{SessionID: "s1", Phase: PhaseFinal})
+		require.NoError(t, err)
+		require.Len(t, finals, 2)
+		require.Equal(t, "t2", finals[0].TurnID, "newest first")
+		require.Equal(t, "s1", finals[0].ConvID)
+		require.Len(t, finals[0].Turn.Blocks, 2)
+
+		all, err := st.ListTurns(ctx, Query{SessionID: "s1"})
+		require.NoError(t, err)
+		require.Len(t, all, 3)
+
+		latest, err := st.LoadLatestTurn(ctx, Query{SessionID: "s1"})
+		require.NoError(t, err)
+		require.NotNil(t, latest)
+		require.Equal(t, "t2", latest.TurnID)
+		require.Equal(t, PhaseFinal, latest.Phase)
+
+		missing, err := st.LoadLatestTurn(ctx, Query{SessionID: "nope"})
+		require.NoError(t, err)
+		require.Nil(t, missing)
+
+		limited, err := st.ListTurns(ctx, Query{Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, limited, 1)
+	})
+}
+
+func TestStore_PersistRequiresSessionID(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st Store) {
+		err := st.PersistTurn(context.Background(), &turns.Turn{ID: "t1"})
+		require.ErrorIs(t, err, ErrSessionIDEmpty)
+	})
+}
+
+func TestStore_PersistUpsertsSameTurnAndPhase(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st Store) {
+		ctx := context.Background()
+		require.NoError(t, st.PersistTurn(ctx, newSessionTurn(t, "s1", "t1", "draft")))
+		require.NoError(t, st.PersistTurn(ctx, newSessionTurn(t, "s1", "t1", "draft", "final")))
+		recs, err := st.ListTurns(ctx, Query{SessionID: "s1"})
+		require.NoError(t, err)
+		require.Len(t, recs, 1)
+		require.Len(t, recs[0].Turn.Blocks, 2)
+	})
+}
+
+func TestLoadSession_RehydratesTurnsInOrder(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st Store) {
+		ctx := context.Background()
+		sess := session.NewSessionWithID("s1")
+		for _, prompt := range []string{"one", "two", "three"} {
+			turn, err := sess.AppendNewTurnFromUserPrompt(prompt)
+			require.NoError(t, err)
+			require.NoError(t, st.PersistTurn(ctx, turn))
+		}
+
+		loaded, err := LoadSession(ctx, st, "s1")
+		require.NoError(t, err)
+		require.Equal(t, "s1", loaded.SessionID)
+		require.Equal(t, 3, loaded.TurnCount())
+		for i, want := range sess.TurnsSnapshot() {
+			require.Equal(t, want.ID, loaded.GetTurn(i).ID)
+		}
+		require.Len(t, loaded.Latest().Blocks, 3)
+
+		_, err = LoadSession(ctx, st, "missing")
+		require.True(t, errors.Is(err, ErrSessionNotFound), "got %v", err)
+	})
+}
+
+func TestFork_RecordsLineageAndRehydrates(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st Store) {
+		ctx := context.Background()
+		require.NoError(t, st.PersistTurn(ctx, newSessionTurn(t, "root", "t1", "a")))
+		require.NoError(t, st.PersistTurn(ctx, newSessionTurn(t, "root", "t2", "a", "b")))
+
+		child, err := Fork(ctx, st, "root", "t1", "child")
+		require.NoError(t, err)
+		require.Equal(t, "child", child.SessionID)
+		seed := child.Latest()
+		require.Len(t, seed.Blocks, 1)
+		from, ok, err := session.KeyTurnMetaForkedFromSessionID.Get(seed.Metadata)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, "root", from)
+
+		grandchild, err := Fork(ctx, st, "child", "", "grandchild")
+		require.NoError(t, err)
+		require.Equal(t, 1, grandchild.TurnCount())
+
+		lineage, err := Lineage(ctx, st, "grandchild")
+		require.NoError(t, err)
+		require.Len(t, lineage, 3)
+		require.Equal(t, "grandchild", lineage[0].SessionID)
+		require.Equal(t, "child", lineage[0].ParentSessionID)
+		require.Equal(t, "root", lineage[1].ParentSessionID)
+		require.Equal(t, "t1", lineage[1].ParentTurnID)
+		require.Equal(t, ForkSourceFork, lineage[1].ForkSource)
+		require.Equal(t, "", lineage[2].ParentSessionID)
+
+		reloaded, err := LoadSession(ctx, st, "child")
+		require.NoError(t, err)
+		require.Equal(t, 1, reloaded.TurnCount())
+
+		_, err = Fork(ctx, st, "root", "missing-turn", "")
+		require.ErrorIs(t, err, ErrTurnNotFound)
+	})
+}
+
+func TestStore_RecordsLineageFromTurnMetadata(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st Store) {
+		ctx := context.Background()
+		turn := newSessionTurn(t, "js-fork", "t1", "hi")
+		require.NoError(t, session.KeyTurnMetaForkedFromSessionID.Set(&turn.Metadata, "js-root"))
+		require.NoError(t, session.KeyTurnMetaForkedFromTurnID.Set(&turn.Metadata, "root-turn"))
+		require.NoError(t, session.KeyTurnMetaForkedFromSource.Set(&turn.Metadata, "fork"))
+		require.NoError(t, st.PersistTurn(ctx, turn))
+
+		rec, err := st.GetSession(ctx, "js-fork")
+		require.NoError(t, err)
+		require.NotNil(t, rec)
+		require.Equal(t, "js-root", rec.ParentSessionID)
+		require.Equal(t, "root-turn", rec.ParentTurnID)
+	})
+}
+
+func TestSnapshotHook_SavesPhases(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st Store) {
+		ctx := context.Background()
+		hook := SnapshotHook(st)
+		turn := newSessionTurn(t, "s1", "t1", "hi")
+		hook(ctx, turn, PhasePreInference)
+		hook(ctx, turn, PhasePostInference)
+
+		recs, err := st.ListTurns(ctx, Query{SessionID: "s1", Phase: PhasePostInference})
+		require.NoError(t, err)
+		require.Len(t, recs, 1)
+	})
+}
+
+func TestFileStore_EscapesIdentifiers(t *testing.T) {
+	root := t.TempDir()
+	st, err := NewFileStore(root)
+	require.NoError(t, err)
+	require.NoError(t, st.PersistTurn(context.Background(), newSessionTurn(t, "../escape", "a/b", "x")))
+
+	matches, err := filepath.Glob(filepath.Join(root, "*", "turns", "*.yaml"))
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	recs, err := st.ListTurns(context.Background(), Query{SessionID: "../escape"})
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	require.Equal(t, "a/b", recs[0].TurnID)
+}
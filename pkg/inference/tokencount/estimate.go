@@ -0,0 +1,76 @@
+package tokencount
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-go-golems/geppetto/pkg/turns"
+)
+
+// DefaultCharsPerToken is the rough characters-per-token ratio used by
+// EstimateCounter for English text and JSON.
+const DefaultCharsPerToken = 4.0
+
+// EstimateCounter approximates input tokens from the size of block payloads.
+// It needs no provider round-trip and is meant as a fallback for providers
+// without a token counting endpoint.
+type EstimateCounter struct {
+	Provider      string
+	Model         string
+	CharsPerToken float64
+}
+
+var _ Counter = (*EstimateCounter)(nil)
+
+// NewEstimateCounter returns an EstimateCounter using DefaultCharsPerToken.
+func NewEstimateCounter(provider, model string) *EstimateCounter {
+	return &EstimateCounter{Provider: provider, Model: model, CharsPerToken: DefaultCharsPerToken}
+}
+
+// CountTurn returns the estimated input tokens for all blocks of t.
+func (c *EstimateCounter) CountTurn(_ context.Context, t *turns.Turn) (*Result, error) {
+	ratio := DefaultCharsPerToken
+	if c != nil && c.CharsPerToken > 0 {
+		ratio = c.CharsPerToken
+	}
+	chars := 0
+	if t != nil {
+		for _, b := range t.Blocks {
+			chars += BlockChars(b)
+		}
+	}
+	res := &Result{
+		InputTokens: int(float64(chars)/ratio + 0.5),
+		Source:      SourceEstimate,
+	}
+	if c != nil {
+		res.Provider = c.Provider
+		res.Model = c.Model
+	}
+	return res, nil
+}
+
+// BlockChars returns the approximate serialized size of a block payload in
+// characters. Text payloads count their text; other payloads count their JSON.
+func BlockChars(b turns.Block) int {
+	if len(b.Payload) == 0 {
+		return 0
+	}
+	total := 0
+	for _, value := range b.Payload {
+		switch v := value.(type) {
+		case nil:
+		case string:
+			total += len(v)
+		default:
+			raw, err := json.Marshal(v)
+			if err != nil {
+				total += len(fmt.Sprint(v))
+				continue
+			}
+			total += len(raw)
+		}
+	}
+	return total
+}
@@ -0,0 +1,430 @@
+package toolloop
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/go-go-golems/geppetto/pkg/turns/toolblocks"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// DefaultApprovalTimeout bounds how long the loop waits for a human decision
+// before denying a pending tool call.
+const DefaultApprovalTimeout = 5 * time.Minute
+
+// ApprovalOutcome names how a pending tool call was resolved.
+type ApprovalOutcome string
+
+const (
+	ApprovalOutcomeApproved ApprovalOutcome = "approved"
+	ApprovalOutcomeEdited   ApprovalOutcome = "edited"
+	ApprovalOutcomeDenied   ApprovalOutcome = "denied"
+)
+
+// ApprovalDecision is the external verdict on a pending tool call.
+type ApprovalDecision struct {
+	Approved bool
+	// Reason is fed back to the model as the tool error when the call is denied.
+	Reason string
+	// Arguments, when non-nil on an approved decision, replace the call arguments
+	// both for execution and in the persisted tool_call block.
+	Arguments map[string]any
+}
+
+// Outcome classifies the decision for events and logs.
+func (d ApprovalDecision) Outcome() ApprovalOutcome {
+	switch {
+	case !d.Approved:
+		return ApprovalOutcomeDenied
+	case d.Arguments != nil:
+		return ApprovalOutcomeEdited
+	default:
+		return ApprovalOutcomeApproved
+	}
+}
+
+// ApproveDecision approves the call as proposed by the model.
+func ApproveDecision() ApprovalDecision {
+	return ApprovalDecision{Approved: true}
+}
+
+// EditDecision approves the call with rewritten arguments.
+func EditDecision(args map[string]any) ApprovalDecision {
+	if args == nil {
+		args = map[string]any{}
+	}
+	return ApprovalDecision{Approved: true, Arguments: args}
+}
+
+// DenyDecision rejects the call; reason is returned to the model as a tool error.
+func DenyDecision(reason string) ApprovalDecision {
+	return ApprovalDecision{Approved: false, Reason: reason}
+}
+
+// ApprovalPolicy reports whether a pending tool call must be approved before it runs.
+type ApprovalPolicy func(call toolblocks.ToolCall) bool
+
+// RequireApprovalForTools requires approval for every call to one of the named tools.
+func RequireApprovalForTools(names ...string) ApprovalPolicy {
+	set := make(map[string]struct{}, len(names))
+	for _, n := range names {
+		set[n] = struct{}{}
+	}
+	return func(call toolblocks.ToolCall) bool {
+		_, ok := set[call.Name]
+		return ok
+	}
+}
+
+// RequireApprovalWhen requires approval for calls to toolName whose arguments
+// match pred. An empty toolName matches every tool.
+func RequireApprovalWhen(toolName string, pred func(args map[string]any) bool) ApprovalPolicy {
+	return func(call toolblocks.ToolCall) bool {
+		if toolName != "" && call.Name != toolName {
+			return false
+		}
+		return pred == nil || pred(call.Arguments)
+	}
+}
+
+// AnyApprovalPolicy requires approval when any of the policies does.
+func AnyApprovalPolicy(policies ...ApprovalPolicy) ApprovalPolicy {
+	return func(call toolblocks.ToolCall) bool {
+		for _, p := range policies {
+			if p != nil && p(call) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ApprovalRequest describes a single pending tool call awaiting a decision.
+type ApprovalRequest struct {
+	ApprovalID string
+	DeadlineMs int64
+
+	SessionID   string
+	InferenceID string
+	TurnID      string
+
+	ToolCallID string
+	ToolName   string
+	Arguments  map[string]any
+}
+
+type approvalWaiter struct {
+	req ApprovalRequest
+	ch  chan ApprovalDecision
+}
+
+// ApprovalController coordinates approve/deny/edit decisions for pending tool calls
+// across in-flight executions.
+//
+// Like StepController it is owned by the application layer, so HTTP handlers or UIs
+// can resolve an approval by approval_id after receiving a tool-approval-requested event.
+type ApprovalController struct {
+	mu      sync.Mutex
+	waiters map[string]*approvalWaiter // keyed by ApprovalID
+}
+
+func NewApprovalController() *ApprovalController {
+	return &ApprovalController{
+		waiters: make(map[string]*approvalWaiter),
+	}
+}
+
+// Request registers a pending approval and returns the stored request.
+func (c *ApprovalController) Request(req ApprovalRequest) ApprovalRequest {
+	if c == nil {
+		return req
+	}
+	return c.register(req).req
+}
+
+func (c *ApprovalController) register(req ApprovalRequest) *approvalWaiter {
+	if req.ApprovalID == "" {
+		req.ApprovalID = uuid.NewString()
+	}
+	w := &approvalWaiter{req: req, ch: make(chan ApprovalDecision, 1)}
+	c.mu.Lock()
+	c.waiters[req.ApprovalID] = w
+	c.mu.Unlock()
+	return w
+}
+
+// Lookup returns a pending request without resolving it.
+func (c *ApprovalController) Lookup(approvalID string) (ApprovalRequest, bool) {
+	if c == nil || approvalID == "" {
+		return ApprovalRequest{}, false
+	}
+	c.mu.Lock()
+	w := c.waiters[approvalID]
+	c.mu.Unlock()
+	if w == nil {
+		return ApprovalRequest{}, false
+	}
+	return w.req, true
+}
+
+// Pending lists pending requests for sessionID (all sessions when empty), oldest deadline first.
+func (c *ApprovalController) Pending(sessionID string) []ApprovalRequest {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	out := make([]ApprovalRequest, 0, len(c.waiters))
+	for _, w := range c.waiters {
+		if sessionID == "" || w.req.SessionID == sessionID {
+			out = append(out, w.req)
+		}
+	}
+	c.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].DeadlineMs != out[j].DeadlineMs {
+			return out[i].DeadlineMs < out[j].DeadlineMs
+		}
+		return out[i].ApprovalID < out[j].ApprovalID
+	})
+	return out
+}
+
+// Resolve delivers a decision and returns the request if it was still pending.
+func (c *ApprovalController) Resolve(approvalID string, decision ApprovalDecision) (ApprovalRequest, bool) {
+	if c == nil || approvalID == "" {
+		return ApprovalRequest{}, false
+	}
+	c.mu.Lock()
+	w := c.waiters[approvalID]
+	delete(c.waiters, approvalID)
+	c.mu.Unlock()
+	if w == nil {
+		return ApprovalRequest{}, false
+	}
+	w.ch <- decision
+	return w.req, true
+}
+
+// Approve resolves a pending approval as approved.
+func (c *ApprovalController) Approve(approvalID string) (ApprovalRequest, bool) {
+	return c.Resolve(approvalID, ApproveDecision())
+}
+
+// Deny resolves a pending approval as denied with reason.
+func (c *ApprovalController) Deny(approvalID string, reason string) (ApprovalRequest, bool) {
+	return c.Resolve(approvalID, DenyDecision(reason))
+}
+
+// Edit resolves a pending approval as approved with rewritten arguments.
+func (c *ApprovalController) Edit(approvalID string, args map[string]any) (ApprovalRequest, bool) {
+	return c.Resolve(approvalID, EditDecision(args))
+}
+
+// Wait blocks until the approval is resolved, timeout elapses (deny), or ctx is canceled (deny).
+// A timeout <= 0 waits until ctx is done.
+func (c *ApprovalController) Wait(ctx context.Context, approvalID string, timeout time.Duration) (ApprovalDecision, error) {
+	if c == nil || approvalID == "" {
+		return DenyDecision("no approval controller"), nil
+	}
+	c.mu.Lock()
+	w := c.waiters[approvalID]
+	c.mu.Unlock()
+	if w == nil {
+		return DenyDecision("approval request not found"), nil
+	}
+	return c.wait(ctx, w, timeout)
+}
+
+// wait holds on to the waiter directly so decisions delivered before waiting
+// (e.g. by a synchronous event sink) are not lost.
+func (c *ApprovalController) wait(ctx context.Context, w *approvalWaiter, timeout time.Duration) (ApprovalDecision, error) {
+	expired, stop := approvalDeadline(timeout)
+	defer stop()
+	return c.waitUntil(ctx, w, expired)
+}
+
+// waitUntil is wait with a deadline channel that several waiters can share.
+func (c *ApprovalController) waitUntil(ctx context.Context, w *approvalWaiter, expired <-chan struct{}) (ApprovalDecision, error) {
+	select {
+	case d := <-w.ch:
+		return d, nil
+	case <-expired:
+		return c.resolveOrTake(w, DenyDecision("approval timed out")), nil
+	case <-ctx.Done():
+		return c.resolveOrTake(w, DenyDecision("approval canceled")), ctx.Err()
+	}
+}
+
+// approvalDeadline returns a channel closed once timeout elapses, or a nil
+// channel (never closed) for a timeout <= 0, and a func releasing the timer.
+func approvalDeadline(timeout time.Duration) (<-chan struct{}, func()) {
+	if timeout <= 0 {
+		return nil, func() {}
+	}
+	expired := make(chan struct{})
+	timer := time.AfterFunc(timeout, func() { close(expired) })
+	return expired, func() { timer.Stop() }
+}
+
+// resolveOrTake applies fallback unless a concurrent Resolve won the race, in
+// which case that decision is already buffered on the waiter channel.
+func (c *ApprovalController) resolveOrTake(w *approvalWaiter, fallback ApprovalDecision) ApprovalDecision {
+	_, _ = c.Resolve(w.req.ApprovalID, fallback)
+	return <-w.ch
+}
+
+// reviewToolCalls splits pending calls into those allowed to run and those denied
+// by a reviewer. Denied calls map tool call ID to the error reported to the model.
+// Edited arguments are written back to the tool_call blocks of t.
+func (l *Loop) reviewToolCalls(ctx context.Context, t *turns.Turn, calls []toolblocks.ToolCall) ([]toolblocks.ToolCall, map[string]error, error) {
+	if l.approvalCtrl == nil || len(calls) == 0 {
+		return calls, nil, nil
+	}
+
+	var meta events.EventMetadata
+	if t != nil {
+		sessionID, _, _ := turns.KeyTurnMetaSessionID.Get(t.Metadata)
+		inferenceID, _, _ := turns.KeyTurnMetaInferenceID.Get(t.Metadata)
+		meta = events.EventMetadata{SessionID: sessionID, InferenceID: inferenceID, TurnID: t.ID}
+	}
+
+	// Publish every request before waiting so reviewers see all pending calls
+	// at once; they share one deadline.
+	var deadlineMs int64
+	if l.approvalTimeout > 0 {
+		deadlineMs = time.Now().Add(l.approvalTimeout).UnixMilli()
+	}
+	waiters := make([]*approvalWaiter, len(calls))
+	for i, call := range calls {
+		if l.approvalPolicy != nil && !l.approvalPolicy(call) {
+			continue
+		}
+		w := l.approvalCtrl.register(ApprovalRequest{
+			DeadlineMs:  deadlineMs,
+			SessionID:   meta.SessionID,
+			InferenceID: meta.InferenceID,
+			TurnID:      meta.TurnID,
+			ToolCallID:  call.ID,
+			ToolName:    call.Name,
+			Arguments:   call.Arguments,
+		})
+		waiters[i] = w
+		evMeta := meta
+		evMeta.ID = uuid.New()
+		events.PublishEventToContext(ctx, events.NewToolApprovalRequestedEvent(evMeta, w.req.ApprovalID, call.ID, call.Name, marshalToolArguments(call.Arguments), deadlineMs, nil))
+	}
+
+	expired, stop := approvalDeadline(l.approvalTimeout)
+	defer stop()
+
+	approved := make([]toolblocks.ToolCall, 0, len(calls))
+	denied := map[string]error{}
+	var firstErr error
+	for i, call := range calls {
+		w := waiters[i]
+		if w == nil {
+			approved = append(approved, call)
+			continue
+		}
+
+		// After a cancellation the remaining waits return at once, which
+		// resolves and reports every outstanding request.
+		decision, waitErr := l.approvalCtrl.waitUntil(ctx, w, expired)
+
+		input := ""
+		if decision.Outcome() == ApprovalOutcomeEdited {
+			input = marshalToolArguments(decision.Arguments)
+		}
+		evMeta := meta
+		evMeta.ID = uuid.New()
+		events.PublishEventToContext(ctx, events.NewToolApprovalResolvedEvent(evMeta, w.req.ApprovalID, call.ID, call.Name, string(decision.Outcome()), decision.Reason, input))
+		log.Debug().Str("tool", call.Name).Str("tool_call_id", call.ID).Str("decision", string(decision.Outcome())).Msg("toolloop: tool approval resolved")
+
+		if waitErr != nil {
+			if firstErr == nil {
+				firstErr = waitErr
+			}
+			continue
+		}
+		if !decision.Approved {
+			denied[call.ID] = deniedToolCallError(decision.Reason)
+			continue
+		}
+		if decision.Arguments != nil {
+			call.Arguments = decision.Arguments
+			rewriteToolCallArguments(t, call.ID, decision.Arguments)
+		}
+		approved = append(approved, call)
+	}
+	if firstErr != nil {
+		return nil, nil, firstErr
+	}
+	return approved, denied, nil
+}
+
+func deniedToolCallError(reason string) error {
+	if reason == "" {
+		return errors.New("tool call denied by reviewer")
+	}
+	return errors.Errorf("tool call denied by reviewer: %s", reason)
+}
+
+func marshalToolArguments(args map[string]any) string {
+	if args == nil {
+		return "{}"
+	}
+	b, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Sprintf("%v", args)
+	}
+	return string(b)
+}
+
+func rewriteToolCallArguments(t *turns.Turn, toolCallID string, args map[string]any) {
+	if t == nil {
+		return
+	}
+	for i := range t.Blocks {
+		b := &t.Blocks[i]
+		if b.Kind != turns.BlockKindToolCall {
+			continue
+		}
+		if id, _ := b.Payload[turns.PayloadKeyID].(string); id != toolCallID {
+			continue
+		}
+		payload := make(map[string]any, len(b.Payload))
+		for k, v := range b.Payload {
+			payload[k] = v
+		}
+		payload[turns.PayloadKeyArgs] = args
+		b.Payload = payload
+	}
+}
+
+// mergeToolResults restores the original call order, interleaving executed
+// results with denials.
+func mergeToolResults(calls []toolblocks.ToolCall, executed []toolResult, denied map[string]error) []toolResult {
+	if len(denied) == 0 {
+		return executed
+	}
+	out := make([]toolResult, 0, len(calls))
+	next := 0
+	for _, c := range calls {
+		if err, ok := denied[c.ID]; ok {
+			out = append(out, toolResult{ToolCallID: c.ID, Error: err})
+			continue
+		}
+		if next < len(executed) {
+			out = append(out, executed[next])
+			next++
+		}
+	}
+	return out
+}
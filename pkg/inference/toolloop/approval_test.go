@@ -0,0 +1,264 @@
+package toolloop
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/inference/tools"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/go-go-golems/geppetto/pkg/turns/toolblocks"
+)
+
+type approvalSink struct {
+	mu        sync.Mutex
+	requested []*events.EventToolApprovalRequested
+	resolved  []*events.EventToolApprovalResolved
+	onRequest func(e *events.EventToolApprovalRequested)
+}
+
+func (s *approvalSink) PublishEvent(e events.Event) error {
+	switch ev := e.(type) {
+	case *events.EventToolApprovalRequested:
+		s.mu.Lock()
+		s.requested = append(s.requested, ev)
+		onRequest := s.onRequest
+		s.mu.Unlock()
+		if onRequest != nil {
+			onRequest(ev)
+		}
+	case *events.EventToolApprovalResolved:
+		s.mu.Lock()
+		s.resolved = append(s.resolved, ev)
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+func newCountingEchoRegistry(t *testing.T, executed *atomic.Int64) tools.ToolRegistry {
+	t.Helper()
+	reg := tools.NewInMemoryToolRegistry()
+	type echoIn struct {
+		Text string `json:"text"`
+	}
+	echoTool, err := tools.NewToolFromFunc("echo", "Echo back the provided text", func(in echoIn) (map[string]any, error) {
+		executed.Add(1)
+		return map[string]any{"echo": in.Text}, nil
+	})
+	if err != nil {
+		t.Fatalf("NewToolFromFunc: %v", err)
+	}
+	if err := reg.RegisterTool("echo", *echoTool); err != nil {
+		t.Fatalf("RegisterTool: %v", err)
+	}
+	return reg
+}
+
+func runApprovalLoop(t *testing.T, sink *approvalSink, opts ...Option) (*turns.Turn, int64) {
+	t.Helper()
+	var executed atomic.Int64
+	reg := newCountingEchoRegistry(t, &executed)
+	initial := &turns.Turn{ID: "turn-1"}
+	_ = turns.KeyTurnMetaSessionID.Set(&initial.Metadata, "run-1")
+	turns.AppendBlock(initial, turns.NewUserTextBlock("please echo"))
+
+	base := []Option{
+		WithEngine(&toolCallingFakeEngine{}),
+		WithRegistry(reg),
+		WithLoopConfig(NewLoopConfig().WithMaxIterations(3)),
+	}
+	loop := New(append(base, opts...)...)
+	out, err := loop.RunLoop(events.WithEventSinks(context.Background(), sink), initial)
+	if err != nil {
+		t.Fatalf("RunLoop: %v", err)
+	}
+	return out, executed.Load()
+}
+
+func toolUseBlock(t *testing.T, turn *turns.Turn, id string) turns.Block {
+	t.Helper()
+	for _, b := range turn.Blocks {
+		if b.Kind == turns.BlockKindToolUse {
+			if got, _ := b.Payload[turns.PayloadKeyID].(string); got == id {
+				return b
+			}
+		}
+	}
+	t.Fatalf("expected tool_use block for %s", id)
+	return turns.Block{}
+}
+
+func TestLoop_ToolApprovalDenyFeedsReasonBackAsToolError(t *testing.T) {
+	t.Parallel()
+
+	ctrl := NewApprovalController()
+	sink := &approvalSink{onRequest: func(e *events.EventToolApprovalRequested) {
+		if _, ok := ctrl.Deny(e.ApprovalID, "shell access not allowed"); !ok {
+			t.Errorf("expected pending approval %s", e.ApprovalID)
+		}
+	}}
+
+	out, executed := runApprovalLoop(t, sink, WithToolApproval(ctrl, RequireApprovalForTools("echo")))
+	if executed != 0 {
+		t.Fatalf("expected denied tool not to run, ran %d times", executed)
+	}
+	use := toolUseBlock(t, out, "call-1")
+	if errStr, _ := use.Payload[turns.PayloadKeyError].(string); !strings.Contains(errStr, "shell access not allowed") {
+		t.Fatalf("expected denial reason in tool error, got %q", errStr)
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if len(sink.requested) != 1 || len(sink.resolved) != 1 {
+		t.Fatalf("expected one requested and one resolved event, got %d/%d", len(sink.requested), len(sink.resolved))
+	}
+	req := sink.requested[0]
+	if req.ToolName != "echo" || req.ToolCallID != "call-1" || !strings.Contains(req.Input, "hello") {
+		t.Fatalf("unexpected approval request event: %+v", req)
+	}
+	if req.Metadata().SessionID != "run-1" {
+		t.Fatalf("expected session id on approval event, got %q", req.Metadata().SessionID)
+	}
+	if got := sink.resolved[0].Decision; got != string(ApprovalOutcomeDenied) {
+		t.Fatalf("expected denied decision, got %q", got)
+	}
+	if len(ctrl.Pending("")) != 0 {
+		t.Fatalf("expected no pending approvals after run")
+	}
+}
+
+func TestLoop_ToolApprovalEditRewritesArguments(t *testing.T) {
+	t.Parallel()
+
+	ctrl := NewApprovalController()
+	sink := &approvalSink{onRequest: func(e *events.EventToolApprovalRequested) {
+		go func() { _, _ = ctrl.Edit(e.ApprovalID, map[string]any{"text": "edited"}) }()
+	}}
+
+	out, executed := runApprovalLoop(t, sink, WithToolApproval(ctrl, nil))
+	if executed != 1 {
+		t.Fatalf("expected edited tool to run once, ran %d times", executed)
+	}
+	use := toolUseBlock(t, out, "call-1")
+	if res, _ := use.Payload[turns.PayloadKeyResult].(string); !strings.Contains(res, "edited") {
+		t.Fatalf("expected result from edited arguments, got %q", res)
+	}
+	for _, b := range out.Blocks {
+		if b.Kind != turns.BlockKindToolCall {
+			continue
+		}
+		args, _ := b.Payload[turns.PayloadKeyArgs].(map[string]any)
+		if args["text"] != "edited" {
+			t.Fatalf("expected tool_call block arguments to be rewritten, got %v", b.Payload[turns.PayloadKeyArgs])
+		}
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if len(sink.resolved) != 1 || sink.resolved[0].Decision != string(ApprovalOutcomeEdited) {
+		t.Fatalf("expected one edited resolution, got %+v", sink.resolved)
+	}
+}
+
+func TestLoop_ToolApprovalSkipsCallsOutsidePolicy(t *testing.T) {
+	t.Parallel()
+
+	ctrl := NewApprovalController()
+	sink := &approvalSink{}
+	policy := RequireApprovalWhen("echo", func(args map[string]any) bool {
+		return args["text"] == "rm -rf /"
+	})
+
+	_, executed := runApprovalLoop(t, sink, WithToolApproval(ctrl, policy))
+	if executed != 1 {
+		t.Fatalf("expected tool to run without approval, ran %d times", executed)
+	}
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if len(sink.requested) != 0 {
+		t.Fatalf("expected no approval requests, got %d", len(sink.requested))
+	}
+}
+
+func TestLoop_ToolApprovalTimeoutDenies(t *testing.T) {
+	t.Parallel()
+
+	ctrl := NewApprovalController()
+	sink := &approvalSink{}
+
+	out, executed := runApprovalLoop(t, sink, WithToolApproval(ctrl, nil), WithApprovalTimeout(10*time.Millisecond))
+	if executed != 0 {
+		t.Fatalf("expected timed out tool not to run, ran %d times", executed)
+	}
+	use := toolUseBlock(t, out, "call-1")
+	if errStr, _ := use.Payload[turns.PayloadKeyError].(string); !strings.Contains(errStr, "approval timed out") {
+		t.Fatalf("expected timeout denial, got %q", errStr)
+	}
+}
+
+func TestLoop_ToolApprovalCanceledContextStopsLoop(t *testing.T) {
+	t.Parallel()
+
+	var executed atomic.Int64
+	ctrl := NewApprovalController()
+	ctx, cancel := context.WithCancel(context.Background())
+	sink := &approvalSink{onRequest: func(*events.EventToolApprovalRequested) { cancel() }}
+
+	initial := &turns.Turn{}
+	turns.AppendBlock(initial, turns.NewUserTextBlock("please echo"))
+	loop := New(
+		WithEngine(&toolCallingFakeEngine{}),
+		WithRegistry(newCountingEchoRegistry(t, &executed)),
+		WithToolApproval(ctrl, nil),
+	)
+	if _, err := loop.RunLoop(events.WithEventSinks(ctx, sink), initial); err == nil {
+		t.Fatalf("expected context cancellation error")
+	}
+	if executed.Load() != 0 {
+		t.Fatalf("expected no tool execution after cancellation")
+	}
+}
+
+func TestLoop_ToolApprovalPublishesAllRequestsBeforeWaiting(t *testing.T) {
+	t.Parallel()
+
+	const timeout = 200 * time.Millisecond
+	ctrl := NewApprovalController()
+	var resolvedAtRequest []int
+	sink := &approvalSink{}
+	sink.onRequest = func(*events.EventToolApprovalRequested) {
+		sink.mu.Lock()
+		resolvedAtRequest = append(resolvedAtRequest, len(sink.resolved))
+		sink.mu.Unlock()
+	}
+	l := New(WithToolApproval(ctrl, nil), WithApprovalTimeout(timeout))
+	calls := []toolblocks.ToolCall{
+		{ID: "call-1", Name: "echo", Arguments: map[string]any{"text": "a"}},
+		{ID: "call-2", Name: "echo", Arguments: map[string]any{"text": "b"}},
+	}
+
+	started := time.Now()
+	approved, denied, err := l.reviewToolCalls(events.WithEventSinks(context.Background(), sink), &turns.Turn{}, calls)
+	elapsed := time.Since(started)
+	if err != nil {
+		t.Fatalf("reviewToolCalls: %v", err)
+	}
+	if len(approved) != 0 || len(denied) != 2 {
+		t.Fatalf("expected both calls to time out, got %d approved and %d denied", len(approved), len(denied))
+	}
+	if len(resolvedAtRequest) != 2 || resolvedAtRequest[1] != 0 {
+		t.Fatalf("expected both requests before any resolution, got %v", resolvedAtRequest)
+	}
+	if elapsed >= 2*timeout {
+		t.Fatalf("expected one shared deadline, waited %s", elapsed)
+	}
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if sink.requested[0].DeadlineMs != sink.requested[1].DeadlineMs {
+		t.Fatalf("expected a shared deadline, got %d and %d", sink.requested[0].DeadlineMs, sink.requested[1].DeadlineMs)
+	}
+}
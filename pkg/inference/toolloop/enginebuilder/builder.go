@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/inference/budget"
 	"github.com/go-go-golems/geppetto/pkg/inference/engine"
 	"github.com/go-go-golems/geppetto/pkg/inference/middleware"
 	"github.com/go-go-golems/geppetto/pkg/inference/session"
@@ -69,8 +70,23 @@ type Builder struct {
 	// If zero, the loop default is used.
 	StepPauseTimeout time.Duration
 
+	// ApprovalController holds pending tool calls for human approval when non-nil.
+	// Like StepController, it is owned by the application/web layer.
+	ApprovalController *toolloop.ApprovalController
+
+	// ApprovalPolicy selects which tool calls need approval. Nil means all of them.
+	ApprovalPolicy toolloop.ApprovalPolicy
+
+	// ApprovalTimeout is how long to wait for a decision before denying the call.
+	// If nil, the loop default is used; a value <= 0 waits until ctx is done.
+	ApprovalTimeout *time.Duration
+
 	// Persister is invoked on successful completion (when err == nil and an updated turn exists).
 	Persister TurnPersister
+
+	// UsageTracker, when set, drops the run's budget bucket once RunInference
+	// returns. Session and profile totals are kept.
+	UsageTracker *budget.Tracker
 }
 
 var _ session.EngineBuilder = (*Builder)(nil)
@@ -107,7 +123,11 @@ func (b *Builder) Build(ctx context.Context, sessionID string) (session.Inferenc
 		snapshotHook:     b.SnapshotHook,
 		stepController:   b.StepController,
 		stepPauseTimeout: b.StepPauseTimeout,
+		approvalCtrl:     b.ApprovalController,
+		approvalPolicy:   b.ApprovalPolicy,
+		approvalTimeout:  b.ApprovalTimeout,
 		persister:        b.Persister,
+		usageTracker:     b.UsageTracker,
 	}, nil
 }
 
@@ -128,7 +148,13 @@ type runner struct {
 	stepController   *toolloop.StepController
 	stepPauseTimeout time.Duration
 
+	approvalCtrl    *toolloop.ApprovalController
+	approvalPolicy  toolloop.ApprovalPolicy
+	approvalTimeout *time.Duration
+
 	persister TurnPersister
+
+	usageTracker *budget.Tracker
 }
 
 var _ session.InferenceRunner = (*runner)(nil)
@@ -182,6 +208,11 @@ func (r *runner) RunInference(ctx context.Context, t *turns.Turn) (*turns.Turn,
 	if _, ok, err := turns.KeyTurnMetaInferenceID.Get(t.Metadata); err != nil || !ok {
 		_ = turns.KeyTurnMetaInferenceID.Set(&t.Metadata, uuid.NewString())
 	}
+	if r.usageTracker != nil {
+		if runID, ok, err := turns.KeyTurnMetaInferenceID.Get(t.Metadata); err == nil && ok {
+			defer r.usageTracker.ForgetRun(runID)
+		}
+	}
 
 	var (
 		updated *turns.Turn
@@ -204,6 +235,12 @@ func (r *runner) RunInference(ctx context.Context, t *turns.Turn) (*turns.Turn,
 		if r.stepPauseTimeout > 0 {
 			opts = append(opts, toolloop.WithPauseTimeout(r.stepPauseTimeout))
 		}
+		if r.approvalCtrl != nil {
+			opts = append(opts, toolloop.WithToolApproval(r.approvalCtrl, r.approvalPolicy))
+		}
+		if r.approvalTimeout != nil {
+			opts = append(opts, toolloop.WithApprovalTimeout(*r.approvalTimeout))
+		}
 		loop := toolloop.New(opts...)
 		updated, err = loop.RunLoop(runCtx, t)
 		// The tool loop calls eng.RunInference per iteration but does not
@@ -4,7 +4,9 @@ import (
 	"context"
 	"testing"
 
+	"github.com/go-go-golems/geppetto/pkg/inference/budget"
 	"github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/inference/middleware"
 	"github.com/go-go-golems/geppetto/pkg/inference/tools"
 	"github.com/go-go-golems/geppetto/pkg/turns"
 	"github.com/stretchr/testify/require"
@@ -61,3 +63,37 @@ func TestBuilder_RunsToolLoopAndPersists(t *testing.T) {
 	// Tool loop calls snapshot hook at least pre/post inference on the first iteration.
 	require.GreaterOrEqual(t, snapshotCalls, 2)
 }
+
+func TestBuilder_ForgetsRunUsageWhenRunFinishes(t *testing.T) {
+	tracker := budget.NewTracker()
+	b := &Builder{
+		Base:         engine.Engine(passthroughEngine{}),
+		Middlewares:  []middleware.Middleware{budget.NewMiddleware(budget.Config{Tracker: tracker})},
+		UsageTracker: tracker,
+	}
+	runner, err := b.Build(context.Background(), "sess-budget")
+	require.NoError(t, err)
+
+	in := &turns.Turn{}
+	require.NoError(t, turns.KeyTurnMetaInferenceID.Set(&in.Metadata, "run-1"))
+	require.NoError(t, turns.KeyTurnMetaInferenceResult.Set(&in.Metadata, turns.InferenceResult{Usage: &turns.InferenceUsage{InputTokens: 3}}))
+	_, err = runner.RunInference(context.Background(), in)
+	require.NoError(t, err)
+
+	require.Zero(t, tracker.Run("run-1").Calls)
+	require.Equal(t, 1, tracker.Session("sess-budget").Calls)
+}
+
+func TestBuilder_ForwardsNonPositiveApprovalTimeout(t *testing.T) {
+	b := New(WithBase(passthroughEngine{}), WithApprovalTimeout(0))
+	built, err := b.Build(context.Background(), "sess-approval")
+	require.NoError(t, err)
+	r, ok := built.(*runner)
+	require.True(t, ok)
+	require.NotNil(t, r.approvalTimeout)
+	require.Zero(t, *r.approvalTimeout)
+
+	built, err = New(WithBase(passthroughEngine{})).Build(context.Background(), "sess-default")
+	require.NoError(t, err)
+	require.Nil(t, built.(*runner).approvalTimeout)
+}
@@ -82,6 +82,19 @@ func WithStepPauseTimeout(d time.Duration) Option {
 	}
 }
 
+func WithToolApproval(ctrl *toolloop.ApprovalController, policy toolloop.ApprovalPolicy) Option {
+	return func(b *Builder) {
+		b.ApprovalController = ctrl
+		b.ApprovalPolicy = policy
+	}
+}
+
+func WithApprovalTimeout(d time.Duration) Option {
+	return func(b *Builder) {
+		b.ApprovalTimeout = &d
+	}
+}
+
 func WithPersister(persister TurnPersister) Option {
 	return func(b *Builder) {
 		b.Persister = persister
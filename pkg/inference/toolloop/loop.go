@@ -29,15 +29,20 @@ type Loop struct {
 	pauseTimeout time.Duration
 
 	snapshotHook SnapshotHook
+
+	approvalCtrl    *ApprovalController
+	approvalPolicy  ApprovalPolicy
+	approvalTimeout time.Duration
 }
 
 type Option func(*Loop)
 
 func New(opts ...Option) *Loop {
 	l := &Loop{
-		loopCfg:      DefaultLoopConfig(),
-		toolCfg:      tools.DefaultToolConfig(),
-		pauseTimeout: 30 * time.Second,
+		loopCfg:         DefaultLoopConfig(),
+		toolCfg:         tools.DefaultToolConfig(),
+		pauseTimeout:    30 * time.Second,
+		approvalTimeout: DefaultApprovalTimeout,
 	}
 	for _, opt := range opts {
 		if opt != nil {
@@ -79,6 +84,21 @@ func WithSnapshotHook(h SnapshotHook) Option {
 	return func(l *Loop) { l.snapshotHook = h }
 }
 
+// WithToolApproval holds pending tool calls matched by policy until ctrl
+// resolves them. A nil policy requires approval for every tool call.
+func WithToolApproval(ctrl *ApprovalController, policy ApprovalPolicy) Option {
+	return func(l *Loop) {
+		l.approvalCtrl = ctrl
+		l.approvalPolicy = policy
+	}
+}
+
+// WithApprovalTimeout sets how long to wait for an approval before denying the call.
+// A value <= 0 waits until the context is canceled.
+func WithApprovalTimeout(d time.Duration) Option {
+	return func(l *Loop) { l.approvalTimeout = d }
+}
+
 func (l *Loop) snapshot(ctx context.Context, t *turns.Turn, phase string) {
 	if l.snapshotHook != nil {
 		l.snapshotHook(ctx, t, phase)
@@ -145,7 +165,11 @@ func (l *Loop) RunLoop(ctx context.Context, initialTurn *turns.Turn) (*turns.Tur
 			return updated, nil
 		}
 
-		results := l.executeTools(ctx, calls)
+		approved, denied, err := l.reviewToolCalls(ctx, updated, calls)
+		if err != nil {
+			return nil, err
+		}
+		results := mergeToolResults(calls, l.executeTools(ctx, approved), denied)
 
 		var appended []toolblocks.ToolResult
 		for _, r := range results {
@@ -10,6 +10,7 @@ import (
 	aistepssettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
 	claudesettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings/claude"
 	geminisettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings/gemini"
+	ollamasettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings/ollama"
 	openaisettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings/openai"
 	aitypes "github.com/go-go-golems/geppetto/pkg/steps/ai/types"
 )
@@ -105,8 +106,16 @@ func ensureInferenceSettingsProviderDefaults(ss *aistepssettings.InferenceSettin
 			}
 			ss.Gemini = settings
 		}
+	case aitypes.ApiTypeOllama:
+		if ss.Ollama == nil {
+			settings, err := ollamasettings.NewSettings()
+			if err != nil {
+				return fmt.Errorf("initialize Ollama provider settings: %w", err)
+			}
+			ss.Ollama = settings
+		}
 	case aitypes.ApiTypeOpenResponses, aitypes.ApiTypeOpenAIResponses,
-		aitypes.ApiTypeOllama, aitypes.ApiTypeMistral, aitypes.ApiTypePerplexity, aitypes.ApiTypeCohere:
+		aitypes.ApiTypeMistral, aitypes.ApiTypePerplexity, aitypes.ApiTypeCohere:
 		// These API types do not have a provider-specific settings object that
 		// needs materialization here. Unsupported types remain unsupported in
 		// the engine factory; normalization must not imply provider support.
@@ -217,10 +217,17 @@ func rerankResponseToJS(resp rerank.Response) map[string]any {
 	}
 	out["results"] = results
 	if resp.Usage != nil {
-		out["usage"] = map[string]any{
+		usage := map[string]any{
 			"inputTokens": resp.Usage.InputTokens,
 			"totalTokens": resp.Usage.TotalTokens,
 		}
+		if resp.Usage.OutputTokens > 0 {
+			usage["outputTokens"] = resp.Usage.OutputTokens
+		}
+		if resp.Usage.SearchUnits > 0 {
+			usage["searchUnits"] = resp.Usage.SearchUnits
+		}
+		out["usage"] = usage
 	}
 	if resp.Cost != nil {
 		out["cost"] = *resp.Cost
@@ -337,14 +337,14 @@ func (s *agentSessionRef) importBaseTurn(base *turns.Turn, source string) {
 	originTurnID := clone.ID
 	_ = turns.KeyTurnMetaSessionID.Set(&clone.Metadata, s.sess.SessionID)
 	if source != "" && source != "resume" {
-		_ = turns.TurnMetaKeyFromID[any](canonicalTurnMetaKey("forkedFromSource")).Set(&clone.Metadata, source)
+		_ = gosession.KeyTurnMetaForkedFromSource.Set(&clone.Metadata, source)
 		if originSessionID != "" {
-			_ = turns.TurnMetaKeyFromID[any](canonicalTurnMetaKey("forkedFromSessionID")).Set(&clone.Metadata, originSessionID)
+			_ = gosession.KeyTurnMetaForkedFromSessionID.Set(&clone.Metadata, originSessionID)
 		}
 		if originTurnID != "" {
-			_ = turns.TurnMetaKeyFromID[any](canonicalTurnMetaKey("forkedFromTurnID")).Set(&clone.Metadata, originTurnID)
+			_ = gosession.KeyTurnMetaForkedFromTurnID.Set(&clone.Metadata, originTurnID)
 		}
-		_ = turns.TurnMetaKeyFromID[any](canonicalTurnMetaKey("forkedAtMs")).Set(&clone.Metadata, time.Now().UnixMilli())
+		_ = gosession.KeyTurnMetaForkedAtMs.Set(&clone.Metadata, time.Now().UnixMilli())
 	}
 	s.sess.Append(clone)
 }
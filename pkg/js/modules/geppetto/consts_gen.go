@@ -27,6 +27,7 @@ func (m *moduleRuntime) installConsts(exports *goja.Object) {
 		m.mustSet(o, "TOOL_CONFIG", "tool_config")
 		m.mustSet(o, "TOOL_DEFINITIONS", "tool_definitions")
 		m.mustSet(o, "STRUCTURED_OUTPUT_CONFIG", "structured_output_config")
+		m.mustSet(o, "STRUCTURED_OUTPUT_RESULT", "structured_output_result")
 		m.mustSet(o, "INFERENCE_CONFIG", "inference_config")
 		m.mustSet(o, "CLAUDE_INFERENCE_CONFIG", "claude_inference_config")
 		m.mustSet(o, "OPENAI_INFERENCE_CONFIG", "openai_inference_config")
@@ -60,6 +61,8 @@ func (m *moduleRuntime) installConsts(exports *goja.Object) {
 		m.mustSet(o, "AGENTMODE_TAG", "agentmode_tag")
 		m.mustSet(o, "AGENTMODE", "agentmode")
 		m.mustSet(o, "INFERENCE_RESULT", "inference_result")
+		m.mustSet(o, "CACHE_CONTROL", "cache_control")
+		m.mustSet(o, "CITATIONS", "citations")
 		m.mustSet(constsObj, "BlockMetadataKeys", o)
 	}
 
@@ -80,6 +83,8 @@ func (m *moduleRuntime) installConsts(exports *goja.Object) {
 		m.mustSet(o, "RESULT", "result")
 		m.mustSet(o, "ERROR", "error")
 		m.mustSet(o, "IMAGES", "images")
+		m.mustSet(o, "DOCUMENTS", "documents")
+		m.mustSet(o, "FILES", "files")
 		m.mustSet(o, "ENCRYPTED_CONTENT", "encrypted_content")
 		m.mustSet(o, "SUMMARY", "summary")
 		m.mustSet(o, "ITEM_ID", "item_id")
@@ -2,6 +2,7 @@ package provider
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -14,6 +15,7 @@ import (
 	"github.com/dop251/goja_nodejs/require"
 	"github.com/go-go-golems/geppetto/pkg/events"
 	inferenceengine "github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/inference/sessionstore"
 	geppettomodule "github.com/go-go-golems/geppetto/pkg/js/modules/geppetto"
 	"github.com/go-go-golems/geppetto/pkg/turns"
 	"github.com/go-go-golems/glazed/pkg/cmds/values"
@@ -263,13 +265,19 @@ func TestSQLiteTurnStorePersistsAndReadsTurns(t *testing.T) {
 }
 
 func TestSQLiteTurnStoreLoadLatestKeepsConvAndSessionPredicatesSeparate(t *testing.T) {
-	store, err := openSQLiteTurnStore("", filepath.Join(t.TempDir(), "turns.db"))
+	dbPath := filepath.Join(t.TempDir(), "turns.db")
+	store, err := openSQLiteTurnStore("", dbPath)
 	if err != nil {
 		t.Fatalf("openSQLiteTurnStore failed: %v", err)
 	}
 	t.Cleanup(func() { _ = store.Close() })
 
-	_, err = store.db.Exec(`
+	db, err := sql.Open("sqlite3", sessionstore.SQLiteDSNForFile(dbPath))
+	if err != nil {
+		t.Fatalf("open raw sqlite: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	_, err = db.Exec(`
 INSERT INTO geppetto_turns (conv_id, session_id, turn_id, phase, runtime_key, inference_id, created_at_ms, payload)
 VALUES
 	('conv-a', 'session-a', 'turn-a', 'final', '', '', 100, ''),
@@ -44,6 +44,13 @@ func EnrichRecordFromEvent(rec *Record, event events.Event) {
 		rec.Usage = e.Usage
 		rec.DurationMs = e.DurationMs
 		rec.HasToolCalls = e.HasToolCalls
+	case *events.EventProviderCallRetry:
+		attempt, delayMs := e.Attempt, e.DelayMs
+		rec.Attempt = &attempt
+		rec.MaxAttempts = e.MaxAttempts
+		rec.StatusCode = e.StatusCode
+		rec.ErrorClass = e.ErrorClass
+		rec.RetryDelayMs = &delayMs
 	case *events.EventTextSegmentStarted:
 		rec.SegmentType = events.SegmentTypeText
 		rec.StreamKind = events.StreamKindContent
@@ -100,7 +107,7 @@ func EnrichRecordFromEvent(rec *Record, event events.Event) {
 	}
 }
 
-// DerivedRecordsFromEvent emits provider-call result and segment lifecycle rows
+// DerivedRecordsFromEvent emits provider-call result, retry attempt and segment lifecycle rows
 // in addition to compact canonical event rows. Callers provide a base record
 // with provider/model/session/message context; the derived records fill typed
 // correlation and lifecycle-specific fields.
@@ -108,8 +115,15 @@ func DerivedRecordsFromEvent(base Record, event events.Event) []Record {
 	if event == nil {
 		return nil
 	}
-	//nolint:exhaustive // Only canonical provider-call result and segment events derive extra observability rows.
+	//nolint:exhaustive // Only canonical provider-call result, retry and segment events derive extra observability rows.
 	switch event.Type() {
+	case events.EventTypeProviderCallRetry:
+		rec := base
+		rec.Stage = StageProviderCallRetry
+		rec.Kind = RecordKindProviderCallAttempt
+		rec.EventType = string(event.Type())
+		EnrichRecordFromEvent(&rec, event)
+		return []Record{rec}
 	case events.EventTypeProviderCallFinished:
 		rec := base
 		rec.Stage = StageProviderCallResultFinalized
@@ -54,3 +54,23 @@ func TestDerivedRecordsFromSegmentEvents(t *testing.T) {
 		t.Fatalf("segment text/status not copied: %#v", rec)
 	}
 }
+
+func TestDerivedRecordsFromProviderCallRetry(t *testing.T) {
+	corr := events.BuildRunCorrelation("session-1", "inf-1", "turn-1")
+	event := events.NewProviderCallRetryEvent(events.EventMetadata{InferenceID: "inf-1"}, corr, 2, 3, 529, "overloaded", 750)
+
+	records := DerivedRecordsFromEvent(Record{Provider: "claude"}, event)
+	if len(records) != 1 {
+		t.Fatalf("expected one retry attempt record, got %d", len(records))
+	}
+	rec := records[0]
+	if rec.Stage != StageProviderCallRetry || rec.Kind != RecordKindProviderCallAttempt {
+		t.Fatalf("unexpected retry stage/kind: %#v", rec)
+	}
+	if rec.Attempt == nil || *rec.Attempt != 2 || rec.MaxAttempts != 3 || rec.StatusCode != 529 || rec.ErrorClass != "overloaded" {
+		t.Fatalf("retry fields not copied: %#v", rec)
+	}
+	if rec.RetryDelayMs == nil || *rec.RetryDelayMs != 750 || rec.RunID != "inf-1" {
+		t.Fatalf("retry delay/correlation not copied: %#v", rec)
+	}
+}
@@ -25,13 +25,15 @@ const (
 	StageSegmentStarted              Stage = "segment_started"
 	StageSegmentUpdated              Stage = "segment_updated"
 	StageSegmentFinished             Stage = "segment_finished"
+	StageProviderCallRetry           Stage = "provider_call_retry"
 )
 
 const (
-	RecordKindProviderEvent      RecordKind = "provider_event"
-	RecordKindCanonicalEvent     RecordKind = "canonical_event"
-	RecordKindProviderCallResult RecordKind = "provider_call_result"
-	RecordKindSegment            RecordKind = "segment"
+	RecordKindProviderEvent       RecordKind = "provider_event"
+	RecordKindCanonicalEvent      RecordKind = "canonical_event"
+	RecordKindProviderCallResult  RecordKind = "provider_call_result"
+	RecordKindSegment             RecordKind = "segment"
+	RecordKindProviderCallAttempt RecordKind = "provider_call_attempt"
 )
 
 // Record is the neutral evidence object emitted by Geppetto provider engines.
@@ -83,6 +85,12 @@ type Record struct {
 	DurationMs   *int64        `json:"durationMs,omitempty"`
 	HasToolCalls bool          `json:"hasToolCalls,omitempty"`
 
+	Attempt      *int   `json:"attempt,omitempty"`
+	MaxAttempts  int    `json:"maxAttempts,omitempty"`
+	StatusCode   int    `json:"statusCode,omitempty"`
+	ErrorClass   string `json:"errorClass,omitempty"`
+	RetryDelayMs *int64 `json:"retryDelayMs,omitempty"`
+
 	ObjectJSON   json.RawMessage `json:"objectJson,omitempty"`
 	EventJSON    json.RawMessage `json:"eventJson,omitempty"`
 	MetadataJSON json.RawMessage `json:"metadataJson,omitempty"`
@@ -0,0 +1,667 @@
+// Package otelobs exports Geppetto inference to OpenTelemetry. An Exporter is
+// both an events.EventSink and an observability.Observer:
+//
+//   - as an event sink it turns canonical events into a span tree of
+//     run → provider call → tool execution, keyed by the events' Correlation,
+//     and records latency, token and cost metrics;
+//   - as an engine observer it adds provider-level evidence (routed provider
+//     events, publish errors) to the provider-call spans as span events.
+//
+// Spans and metrics follow the OpenTelemetry GenAI semantic conventions
+// (gen_ai.operation.name, gen_ai.provider.name, gen_ai.request.model,
+// gen_ai.usage.*, gen_ai.client.operation.duration, gen_ai.client.token.usage).
+// Geppetto identities are added as geppetto.* attributes.
+//
+// Prompt and completion text are never exported.
+package otelobs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/observability"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/semconv/v1.40.0/genaiconv"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the tracer and meter name used by the Exporter.
+const InstrumentationName = "github.com/go-go-golems/geppetto/pkg/observability/otelobs"
+
+// DefaultIdleRunTimeout is how long an implicit run may wait for its next
+// provider call after its tools finished before it is ended.
+const DefaultIdleRunTimeout = 30 * time.Second
+
+// Geppetto-specific attribute keys.
+const (
+	AttrRunID          = attribute.Key("geppetto.run_id")
+	AttrTurnID         = attribute.Key("geppetto.turn_id")
+	AttrProviderCallID = attribute.Key("geppetto.provider_call_id")
+	AttrFinishClass    = attribute.Key("geppetto.finish_class")
+	AttrHasToolCalls   = attribute.Key("geppetto.has_tool_calls")
+	AttrToolStatus     = attribute.Key("geppetto.tool.status")
+	AttrRunStatus      = attribute.Key("geppetto.run.status")
+)
+
+// Metric names that are not covered by the GenAI semantic conventions.
+const (
+	MetricToolDuration = "geppetto.tool.duration"
+	MetricCost         = "geppetto.client.cost"
+	MetricRetries      = "geppetto.client.retries"
+)
+
+type Option func(*Exporter)
+
+// WithTracerProvider sets the tracer provider (default otel.GetTracerProvider).
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(x *Exporter) { x.tracerProvider = tp }
+}
+
+// WithIdleRunTimeout sets how long an implicit run with no open provider call
+// or tool is kept open for a follow-up call (default DefaultIdleRunTimeout).
+func WithIdleRunTimeout(d time.Duration) Option {
+	return func(x *Exporter) {
+		if d > 0 {
+			x.idleRunTimeout = d
+		}
+	}
+}
+
+// WithMeterProvider sets the meter provider (default otel.GetMeterProvider).
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(x *Exporter) { x.meterProvider = mp }
+}
+
+// Exporter maps canonical events and observability records to OpenTelemetry
+// spans and metrics. It is safe for concurrent use.
+type Exporter struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	tracer         trace.Tracer
+	now            func() time.Time
+	idleRunTimeout time.Duration
+
+	operationDuration genaiconv.ClientOperationDuration
+	tokenUsage        genaiconv.ClientTokenUsage
+	toolDuration      metric.Float64Histogram
+	cost              metric.Float64Counter
+	retries           metric.Int64Counter
+
+	mu    sync.Mutex
+	runs  map[string]*runSpan
+	calls map[string]*callSpan
+	tools map[string]*toolSpan
+}
+
+var (
+	_ events.EventSink       = (*Exporter)(nil)
+	_ observability.Observer = (*Exporter)(nil)
+)
+
+// runSpan is an open run. implicit runs were opened by a provider call or tool
+// instead of a run-started event and end when the last provider call finishes
+// without tool calls. When their tools finish with no provider call open they
+// become idle, and are ended at idleSince unless another provider call starts
+// within the idle run timeout. finished keeps the span contexts of ended
+// provider calls so the tools they requested, which start afterwards, become
+// their children.
+type runSpan struct {
+	span      trace.Span
+	implicit  bool
+	calls     int
+	tools     int
+	idleSince time.Time
+	finished  map[string]trace.SpanContext
+}
+
+type callSpan struct {
+	span     trace.Span
+	runID    string
+	provider string
+	model    string
+	started  time.Time
+	usage    *events.Usage
+}
+
+type toolSpan struct {
+	span    trace.Span
+	runID   string
+	name    string
+	started time.Time
+}
+
+// New creates an Exporter with its instruments registered on the meter
+// provider.
+func New(options ...Option) (*Exporter, error) {
+	x := &Exporter{
+		now:            time.Now,
+		idleRunTimeout: DefaultIdleRunTimeout,
+		runs:           map[string]*runSpan{},
+		calls:          map[string]*callSpan{},
+		tools:          map[string]*toolSpan{},
+	}
+	for _, option := range options {
+		if option != nil {
+			option(x)
+		}
+	}
+	if x.tracerProvider == nil {
+		x.tracerProvider = otel.GetTracerProvider()
+	}
+	if x.meterProvider == nil {
+		x.meterProvider = otel.GetMeterProvider()
+	}
+	x.tracer = x.tracerProvider.Tracer(InstrumentationName, trace.WithSchemaURL(semconv.SchemaURL))
+	meter := x.meterProvider.Meter(InstrumentationName, metric.WithSchemaURL(semconv.SchemaURL))
+
+	var err error
+	if x.operationDuration, err = genaiconv.NewClientOperationDuration(meter); err != nil {
+		return nil, fmt.Errorf("create operation duration histogram: %w", err)
+	}
+	if x.tokenUsage, err = genaiconv.NewClientTokenUsage(meter); err != nil {
+		return nil, fmt.Errorf("create token usage histogram: %w", err)
+	}
+	if x.toolDuration, err = meter.Float64Histogram(MetricToolDuration, metric.WithUnit("s"), metric.WithDescription("Tool execution duration.")); err != nil {
+		return nil, fmt.Errorf("create tool duration histogram: %w", err)
+	}
+	if x.cost, err = meter.Float64Counter(MetricCost, metric.WithUnit("USD"), metric.WithDescription("Estimated provider cost from model pricing.")); err != nil {
+		return nil, fmt.Errorf("create cost counter: %w", err)
+	}
+	if x.retries, err = meter.Int64Counter(MetricRetries, metric.WithUnit("{retry}"), metric.WithDescription("Provider request retries.")); err != nil {
+		return nil, fmt.Errorf("create retry counter: %w", err)
+	}
+	return x, nil
+}
+
+// PublishEvent implements events.EventSink.
+func (x *Exporter) PublishEvent(event events.Event) error {
+	if event == nil {
+		return nil
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.endIdleRunsLocked()
+
+	switch e := event.(type) {
+	case *events.EventRunStarted:
+		x.startRunLocked(e.Correlation(), false)
+	case *events.EventRunFinished:
+		x.endRunLocked(e.Correlation().RunID, e.Status, "")
+	case *events.EventRunStopped:
+		x.endRunLocked(e.Correlation().RunID, "stopped", "")
+	case *events.EventRunFailed:
+		x.endRunLocked(e.Correlation().RunID, "failed", e.ErrorString)
+	case *events.EventProviderCallStarted:
+		x.startCallLocked(e.Correlation(), e.Metadata())
+	case *events.EventProviderCallMetadataUpdated:
+		if call := x.calls[e.Correlation().ProviderCallID]; call != nil && e.Usage != nil {
+			usage := *e.Usage
+			call.usage = &usage
+		}
+	case *events.EventProviderCallFinished:
+		x.finishCallLocked(e)
+	case *events.EventProviderCallRetry:
+		x.retryLocked(e)
+	case *events.EventProviderFallback:
+		if run := x.runs[e.Correlation().RunID]; run != nil {
+			run.span.AddEvent("provider_fallback", trace.WithAttributes(
+				attribute.String("geppetto.fallback.from", e.FromCandidate),
+				attribute.String("geppetto.fallback.to", e.ToCandidate),
+				attribute.Int("geppetto.fallback.attempt", e.Attempt),
+				semconv.ErrorTypeKey.String(errorType(e.ErrorClass)),
+			))
+		}
+	case *events.EventToolExecutionStarted:
+		x.startToolLocked(e.Correlation(), e.ToolCallID, e.ToolName)
+	case *events.EventToolCallFinished:
+		x.finishToolLocked(e.Correlation(), e.ToolCallID, e.Status)
+	case *events.EventUsageUpdated:
+		if e.Call.CostUSD > 0 {
+			x.cost.Add(context.Background(), e.Call.CostUSD, metric.WithAttributes(
+				semconv.GenAIProviderNameKey.String(providerName(e.Provider)),
+				semconv.GenAIRequestModel(e.Model),
+			))
+		}
+	case *events.EventError:
+		x.failRunLocked(eventRunID(e), e.ErrorString)
+	}
+	return nil
+}
+
+// OnGeppettoRecord implements observability.Observer. Records that mirror
+// canonical events are skipped because the event sink already handles them;
+// provider-level records become span events on their provider call.
+func (x *Exporter) OnGeppettoRecord(_ context.Context, rec observability.Record) {
+	//nolint:exhaustive // Only provider-level stages carry evidence the event sink does not see.
+	switch rec.Stage {
+	case observability.StageProviderRoutedEvent, observability.StageProviderNormalizeDelta, observability.StageGeppettoPublishError:
+	default:
+		return
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	call := x.callForRecordLocked(rec)
+	if call == nil {
+		return
+	}
+	attrs := []attribute.KeyValue{attribute.String("geppetto.record.stage", string(rec.Stage))}
+	if rec.EventType != "" {
+		attrs = append(attrs, attribute.String("geppetto.record.event_type", rec.EventType))
+	}
+	if rec.StreamKind != "" {
+		attrs = append(attrs, attribute.String("geppetto.record.stream_kind", rec.StreamKind))
+	}
+	if rec.DeltaLen > 0 {
+		attrs = append(attrs, attribute.Int("geppetto.record.delta_len", rec.DeltaLen))
+	}
+	if rec.Error != "" {
+		attrs = append(attrs, attribute.String("geppetto.record.error", rec.Error))
+	}
+	call.span.AddEvent("geppetto.provider_record", trace.WithAttributes(attrs...))
+	if rec.Provider != "" && call.provider == "" {
+		call.provider = rec.Provider
+		call.span.SetAttributes(semconv.GenAIProviderNameKey.String(providerName(rec.Provider)))
+	}
+}
+
+// callForRecordLocked returns the provider call of rec. Engines that do not set
+// ProviderCallID on provider records are matched by their run when it has
+// exactly one open call.
+func (x *Exporter) callForRecordLocked(rec observability.Record) *callSpan {
+	if rec.ProviderCallID != "" {
+		return x.calls[rec.ProviderCallID]
+	}
+	runID := rec.RunID
+	if runID == "" {
+		runID = rec.InferenceID
+	}
+	if runID == "" {
+		return nil
+	}
+	var found *callSpan
+	for _, call := range x.calls {
+		if call.runID != runID {
+			continue
+		}
+		if found != nil {
+			return nil
+		}
+		found = call
+	}
+	return found
+}
+
+// Close ends every span that is still open, for example on shutdown. It does
+// not shut down the tracer or meter providers.
+func (x *Exporter) Close() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for id, tool := range x.tools {
+		tool.span.End()
+		delete(x.tools, id)
+	}
+	for id, call := range x.calls {
+		call.span.End()
+		delete(x.calls, id)
+	}
+	for id, run := range x.runs {
+		run.span.End()
+		delete(x.runs, id)
+	}
+	return nil
+}
+
+func (x *Exporter) startRunLocked(corr events.Correlation, implicit bool) *runSpan {
+	if corr.RunID == "" {
+		return nil
+	}
+	if run := x.runs[corr.RunID]; run != nil {
+		if !implicit {
+			run.implicit = false
+		}
+		return run
+	}
+	attrs := []attribute.KeyValue{
+		semconv.GenAIOperationNameInvokeAgent,
+		AttrRunID.String(corr.RunID),
+	}
+	if corr.SessionID != "" {
+		attrs = append(attrs, semconv.GenAIConversationID(corr.SessionID))
+	}
+	if corr.TurnID != "" {
+		attrs = append(attrs, AttrTurnID.String(corr.TurnID))
+	}
+	_, span := x.tracer.Start(context.Background(), "invoke_agent geppetto",
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithTimestamp(x.now()),
+		trace.WithAttributes(attrs...),
+	)
+	run := &runSpan{span: span, implicit: implicit, finished: map[string]trace.SpanContext{}}
+	x.runs[corr.RunID] = run
+	return run
+}
+
+// endIdleRunsLocked ends the implicit runs that have been idle for longer than
+// the idle run timeout, at the time they became idle.
+func (x *Exporter) endIdleRunsLocked() {
+	now := x.now()
+	for id, run := range x.runs {
+		if run.idleSince.IsZero() || now.Sub(run.idleSince) < x.idleRunTimeout {
+			continue
+		}
+		run.span.SetAttributes(AttrRunStatus.String("completed"))
+		run.span.End(trace.WithTimestamp(run.idleSince))
+		delete(x.runs, id)
+	}
+}
+
+// markIdle marks an implicit run without open provider calls or tools
+// as idle.
+func (run *runSpan) markIdle(at time.Time) {
+	if run.implicit && run.calls <= 0 && run.tools <= 0 {
+		run.idleSince = at
+	}
+}
+
+func (x *Exporter) endRunLocked(runID, status, errorString string) {
+	run := x.runs[runID]
+	if run == nil {
+		return
+	}
+	if status != "" {
+		run.span.SetAttributes(AttrRunStatus.String(status))
+	}
+	if errorString != "" {
+		run.span.SetStatus(codes.Error, errorString)
+	}
+	run.span.End(trace.WithTimestamp(x.now()))
+	delete(x.runs, runID)
+}
+
+// failRunLocked ends the open provider calls of runID with an error, and the
+// run itself when it was opened implicitly.
+func (x *Exporter) failRunLocked(runID, errorString string) {
+	if runID == "" {
+		return
+	}
+	for id, call := range x.calls {
+		if call.runID != runID {
+			continue
+		}
+		call.span.SetStatus(codes.Error, errorString)
+		call.span.SetAttributes(semconv.ErrorTypeOther)
+		x.operationDuration.Record(context.Background(), x.now().Sub(call.started).Seconds(),
+			genaiconv.OperationNameChat, genaiconv.ProviderNameAttr(providerName(call.provider)),
+			semconv.GenAIRequestModel(call.model), semconv.ErrorTypeOther)
+		call.span.End(trace.WithTimestamp(x.now()))
+		delete(x.calls, id)
+	}
+	if run := x.runs[runID]; run != nil && run.implicit {
+		x.endRunLocked(runID, "failed", errorString)
+	}
+}
+
+func (x *Exporter) startCallLocked(corr events.Correlation, metadata events.EventMetadata) {
+	if corr.ProviderCallID == "" {
+		return
+	}
+	if existing := x.calls[corr.ProviderCallID]; existing != nil {
+		return
+	}
+	parent := context.Background()
+	run := x.startRunLocked(corr, true)
+	if run != nil {
+		parent = trace.ContextWithSpan(parent, run.span)
+		run.calls++
+		run.idleSince = time.Time{}
+	}
+	provider := providerFromCallID(corr.ProviderCallID)
+	attrs := []attribute.KeyValue{
+		semconv.GenAIOperationNameChat,
+		semconv.GenAIProviderNameKey.String(providerName(provider)),
+		AttrProviderCallID.String(corr.ProviderCallID),
+	}
+	if metadata.Model != "" {
+		attrs = append(attrs, semconv.GenAIRequestModel(metadata.Model))
+	}
+	if metadata.MaxTokens != nil {
+		attrs = append(attrs, semconv.GenAIRequestMaxTokens(*metadata.MaxTokens))
+	}
+	if metadata.Temperature != nil {
+		attrs = append(attrs, semconv.GenAIRequestTemperature(*metadata.Temperature))
+	}
+	if metadata.TopP != nil {
+		attrs = append(attrs, semconv.GenAIRequestTopP(*metadata.TopP))
+	}
+	if corr.RunID != "" {
+		attrs = append(attrs, AttrRunID.String(corr.RunID))
+	}
+	if corr.SessionID != "" {
+		attrs = append(attrs, semconv.GenAIConversationID(corr.SessionID))
+	}
+	started := x.now()
+	_, span := x.tracer.Start(parent, strings.TrimSpace("chat "+metadata.Model),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(started),
+		trace.WithAttributes(attrs...),
+	)
+	x.calls[corr.ProviderCallID] = &callSpan{span: span, runID: corr.RunID, provider: provider, model: metadata.Model, started: started}
+}
+
+func (x *Exporter) finishCallLocked(e *events.EventProviderCallFinished) {
+	corr := e.Correlation()
+	call := x.calls[corr.ProviderCallID]
+	if call == nil {
+		return
+	}
+	delete(x.calls, corr.ProviderCallID)
+
+	usage := call.usage
+	if e.Usage != nil {
+		usage = e.Usage
+	}
+	attrs := []attribute.KeyValue{AttrHasToolCalls.Bool(e.HasToolCalls)}
+	if e.StopReason != "" {
+		attrs = append(attrs, semconv.GenAIResponseFinishReasons(e.StopReason))
+	}
+	if e.FinishClass != "" {
+		attrs = append(attrs, AttrFinishClass.String(e.FinishClass))
+	}
+	if usage != nil {
+		attrs = append(attrs,
+			semconv.GenAIUsageInputTokens(usage.InputTokens),
+			semconv.GenAIUsageOutputTokens(usage.OutputTokens),
+		)
+		if cached := max(usage.CacheReadInputTokens, usage.CachedTokens); cached > 0 {
+			attrs = append(attrs, semconv.GenAIUsageCacheReadInputTokens(cached))
+		}
+		if usage.CacheCreationInputTokens > 0 {
+			attrs = append(attrs, semconv.GenAIUsageCacheCreationInputTokens(usage.CacheCreationInputTokens))
+		}
+	}
+	call.span.SetAttributes(attrs...)
+
+	end := x.now()
+	duration := end.Sub(call.started)
+	if e.DurationMs != nil && *e.DurationMs > 0 {
+		duration = time.Duration(*e.DurationMs) * time.Millisecond
+	}
+	ctx := context.Background()
+	operation, provider := genaiconv.OperationNameChat, genaiconv.ProviderNameAttr(providerName(call.provider))
+	model := semconv.GenAIRequestModel(call.model)
+	x.operationDuration.Record(ctx, duration.Seconds(), operation, provider, model)
+	if usage != nil {
+		x.tokenUsage.Record(ctx, int64(usage.InputTokens), operation, provider, genaiconv.TokenTypeInput, model)
+		x.tokenUsage.Record(ctx, int64(usage.OutputTokens), operation, provider, genaiconv.TokenTypeOutput, model)
+	}
+	call.span.End(trace.WithTimestamp(end))
+
+	if run := x.runs[call.runID]; run != nil {
+		run.finished[corr.ProviderCallID] = call.span.SpanContext()
+		run.calls--
+		if run.implicit && !e.HasToolCalls && run.calls <= 0 && run.tools <= 0 {
+			x.endRunLocked(call.runID, "completed", "")
+		} else {
+			run.markIdle(end)
+		}
+	}
+}
+
+func (x *Exporter) retryLocked(e *events.EventProviderCallRetry) {
+	corr := e.Correlation()
+	attrs := []attribute.KeyValue{
+		attribute.Int("geppetto.retry.attempt", e.Attempt),
+		attribute.Int("geppetto.retry.max_attempts", e.MaxAttempts),
+		attribute.Int64("geppetto.retry.delay_ms", e.DelayMs),
+		semconv.ErrorTypeKey.String(errorType(e.ErrorClass)),
+	}
+	if e.StatusCode != 0 {
+		attrs = append(attrs, semconv.HTTPResponseStatusCode(e.StatusCode))
+	}
+	var span trace.Span
+	provider := providerFromCallID(corr.ProviderCallID)
+	if call := x.calls[corr.ProviderCallID]; call != nil {
+		span, provider = call.span, call.provider
+	} else if run := x.runs[corr.RunID]; run != nil {
+		span = run.span
+	}
+	if span != nil {
+		span.AddEvent("provider_call_retry", trace.WithAttributes(attrs...))
+	}
+	x.retries.Add(context.Background(), 1, metric.WithAttributes(
+		semconv.GenAIProviderNameKey.String(providerName(provider)),
+		semconv.ErrorTypeKey.String(errorType(e.ErrorClass)),
+	))
+}
+
+func (x *Exporter) startToolLocked(corr events.Correlation, toolCallID, name string) {
+	if toolCallID == "" {
+		toolCallID = corr.ToolCallID
+	}
+	if toolCallID == "" || x.tools[toolCallID] != nil {
+		return
+	}
+	parent := context.Background()
+	run := x.startRunLocked(corr, true)
+	if run != nil {
+		run.tools++
+		run.idleSince = time.Time{}
+		parent = trace.ContextWithSpan(parent, run.span)
+	}
+	if call := x.calls[corr.ProviderCallID]; call != nil {
+		parent = trace.ContextWithSpan(parent, call.span)
+	} else if run != nil && run.finished[corr.ProviderCallID].IsValid() {
+		parent = trace.ContextWithSpanContext(parent, run.finished[corr.ProviderCallID])
+	}
+	attrs := []attribute.KeyValue{
+		semconv.GenAIOperationNameExecuteTool,
+		semconv.GenAIToolName(name),
+		semconv.GenAIToolCallID(toolCallID),
+	}
+	if corr.RunID != "" {
+		attrs = append(attrs, AttrRunID.String(corr.RunID))
+	}
+	if corr.ProviderCallID != "" {
+		attrs = append(attrs, AttrProviderCallID.String(corr.ProviderCallID))
+	}
+	started := x.now()
+	_, span := x.tracer.Start(parent, strings.TrimSpace("execute_tool "+name),
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithTimestamp(started),
+		trace.WithAttributes(attrs...),
+	)
+	x.tools[toolCallID] = &toolSpan{span: span, runID: corr.RunID, name: name, started: started}
+}
+
+func (x *Exporter) finishToolLocked(corr events.Correlation, toolCallID, status string) {
+	if toolCallID == "" {
+		toolCallID = corr.ToolCallID
+	}
+	tool := x.tools[toolCallID]
+	if tool == nil {
+		return
+	}
+	delete(x.tools, toolCallID)
+	attrs := []attribute.KeyValue{semconv.GenAIToolName(tool.name)}
+	if status != "" {
+		tool.span.SetAttributes(AttrToolStatus.String(status))
+		attrs = append(attrs, AttrToolStatus.String(status))
+	}
+	if status == "error" {
+		tool.span.SetStatus(codes.Error, "tool execution failed")
+		tool.span.SetAttributes(semconv.ErrorTypeOther)
+	}
+	end := x.now()
+	x.toolDuration.Record(context.Background(), end.Sub(tool.started).Seconds(), metric.WithAttributes(attrs...))
+	tool.span.End(trace.WithTimestamp(end))
+	if run := x.runs[tool.runID]; run != nil {
+		run.tools--
+		run.markIdle(end)
+	}
+}
+
+// eventRunID returns the run an event belongs to. Legacy events such as
+// EventError carry no typed correlation; for them it is the run ID engines
+// put in their provider-call correlation: the inference ID, or the event ID
+// when there is none.
+func eventRunID(event events.Event) string {
+	if correlated, ok := event.(events.CorrelatedEvent); ok {
+		return correlated.Correlation().RunID
+	}
+	metadata := event.Metadata()
+	if metadata.InferenceID != "" {
+		return metadata.InferenceID
+	}
+	if metadata.ID == uuid.Nil {
+		return ""
+	}
+	return metadata.ID.String()
+}
+
+// providerFromCallID returns the provider prefix of a canonical provider-call
+// ID ("openai:<run>:provider-call:0", "claude:msg_…", "claude-provider-call-0").
+func providerFromCallID(id string) string {
+	if i := strings.Index(id, ":"); i > 0 {
+		return id[:i]
+	}
+	if i := strings.Index(id, "-provider-call-"); i > 0 {
+		return id[:i]
+	}
+	return ""
+}
+
+// providerName maps Geppetto provider and API type names to the
+// gen_ai.provider.name well-known values; unknown names pass through.
+func providerName(provider string) string {
+	switch p := strings.ToLower(strings.TrimSpace(provider)); p {
+	case "claude", "anthropic":
+		return string(genaiconv.ProviderNameAnthropic)
+	case "gemini", "google":
+		return string(genaiconv.ProviderNameGCPGemini)
+	case "openai", "openai_responses", "openai-responses", "open-responses", "openai-codex":
+		return string(genaiconv.ProviderNameOpenAI)
+	case "mistral":
+		return string(genaiconv.ProviderNameMistralAI)
+	case "":
+		return "unknown"
+	default:
+		return p
+	}
+}
+
+// errorType returns the error.type value for a retry or fallback class.
+func errorType(class string) string {
+	if class == "" {
+		return semconv.ErrorTypeOther.Value.AsString()
+	}
+	return class
+}
@@ -0,0 +1,310 @@
+package otelobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/observability"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTestExporter(t *testing.T) (*Exporter, *tracetest.InMemoryExporter, *sdkmetric.ManualReader) {
+	t.Helper()
+	spans := tracetest.NewInMemoryExporter()
+	reader := sdkmetric.NewManualReader()
+	x, err := New(
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans))),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return x, spans, reader
+}
+
+func publish(t *testing.T, x *Exporter, evs ...events.Event) {
+	t.Helper()
+	for _, ev := range evs {
+		if err := x.PublishEvent(ev); err != nil {
+			t.Fatalf("PublishEvent(%s): %v", ev.Type(), err)
+		}
+	}
+}
+
+func spanByName(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, s := range spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("span %q not found in %d spans", name, len(spans))
+	return tracetest.SpanStub{}
+}
+
+func attr(s tracetest.SpanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range s.Attributes {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestExporterBuildsRunProviderCallToolTree(t *testing.T) {
+	x, spans, reader := newTestExporter(t)
+	metadata := events.EventMetadata{SessionID: "session-1", InferenceID: "run-1"}
+	metadata.Model = "gpt-4o-mini"
+	run := events.Correlation{SessionID: "session-1", RunID: "run-1", TurnID: "turn-1"}
+	call0 := run
+	call0.ProviderCallID = "openai:run-1:provider-call:0"
+	tool := call0
+	tool.ToolCallID = "call_1"
+	call1 := run
+	call1.ProviderCallID = "openai:run-1:provider-call:1"
+	duration := int64(1500)
+
+	publish(t, x,
+		events.NewProviderCallStartedEvent(metadata, call0),
+		events.NewProviderCallFinishedEvent(metadata, call0, "tool_calls", "tool_calls", &events.Usage{InputTokens: 12, OutputTokens: 4}, &duration, true),
+		events.NewToolExecutionStartedEvent(events.EventMetadata{}, tool, "call_1", "get_weather", `{"city":"Paris"}`),
+		events.NewToolCallFinishedEvent(events.EventMetadata{}, tool, "call_1", "get_weather", "error"),
+		events.NewProviderCallStartedEvent(metadata, call1),
+		events.NewProviderCallMetadataUpdatedEvent(metadata, call1, "stop", "", &events.Usage{InputTokens: 30, OutputTokens: 8, CachedTokens: 10}),
+		events.NewProviderCallFinishedEvent(metadata, call1, "stop", "completed", nil, nil, false),
+	)
+
+	got := spans.GetSpans()
+	if len(got) != 4 {
+		t.Fatalf("got %d spans, want 4 (run, two calls, tool)", len(got))
+	}
+	root := spanByName(t, got, "invoke_agent geppetto")
+	if root.Parent.IsValid() {
+		t.Fatalf("run span should be a root span")
+	}
+	if v, _ := attr(root, "gen_ai.conversation.id"); v.AsString() != "session-1" {
+		t.Fatalf("conversation id = %q", v.AsString())
+	}
+
+	chats := 0
+	for _, s := range got {
+		if s.Name != "chat gpt-4o-mini" {
+			continue
+		}
+		chats++
+		if s.Parent.SpanID() != root.SpanContext.SpanID() {
+			t.Fatalf("provider call span is not a child of the run")
+		}
+		if v, _ := attr(s, "gen_ai.provider.name"); v.AsString() != "openai" {
+			t.Fatalf("provider name = %q", v.AsString())
+		}
+		if v, _ := attr(s, "gen_ai.request.model"); v.AsString() != "gpt-4o-mini" {
+			t.Fatalf("request model = %q", v.AsString())
+		}
+		id, _ := attr(s, AttrProviderCallID)
+		if id.AsString() == call1.ProviderCallID {
+			if v, _ := attr(s, "gen_ai.usage.input_tokens"); v.AsInt64() != 30 {
+				t.Fatalf("second call input tokens = %d, want metadata usage 30", v.AsInt64())
+			}
+			if v, _ := attr(s, "gen_ai.usage.cache_read.input_tokens"); v.AsInt64() != 10 {
+				t.Fatalf("cache read tokens = %d", v.AsInt64())
+			}
+		}
+	}
+	if chats != 2 {
+		t.Fatalf("got %d chat spans, want 2", chats)
+	}
+
+	toolSpan := spanByName(t, got, "execute_tool get_weather")
+	firstCall := spanByName(t, got, "chat gpt-4o-mini")
+	for _, s := range got {
+		if id, _ := attr(s, AttrProviderCallID); s.Name == "chat gpt-4o-mini" && id.AsString() == call0.ProviderCallID {
+			firstCall = s
+		}
+	}
+	if toolSpan.Parent.SpanID() != firstCall.SpanContext.SpanID() {
+		t.Fatalf("tool span is not a child of the provider call that requested it")
+	}
+	if toolSpan.Status.Code != codes.Error {
+		t.Fatalf("failed tool status = %v", toolSpan.Status.Code)
+	}
+	if v, _ := attr(toolSpan, "gen_ai.tool.call.id"); v.AsString() != "call_1" {
+		t.Fatalf("tool call id = %q", v.AsString())
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	var tokens, operations, tools uint64
+	var inputSum int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch m.Name {
+			case "gen_ai.client.token.usage":
+				for _, dp := range m.Data.(metricdata.Histogram[int64]).DataPoints {
+					tokens += dp.Count
+					if v, _ := dp.Attributes.Value("gen_ai.token.type"); v.AsString() == "input" {
+						inputSum += dp.Sum
+					}
+				}
+			case "gen_ai.client.operation.duration":
+				for _, dp := range m.Data.(metricdata.Histogram[float64]).DataPoints {
+					operations += dp.Count
+				}
+			case MetricToolDuration:
+				for _, dp := range m.Data.(metricdata.Histogram[float64]).DataPoints {
+					tools += dp.Count
+				}
+			}
+		}
+	}
+	if tokens != 4 || inputSum != 42 || operations != 2 || tools != 1 {
+		t.Fatalf("metrics: token points=%d input sum=%d operations=%d tools=%d", tokens, inputSum, operations, tools)
+	}
+}
+
+func TestExporterExplicitRunWaitsForRunFinished(t *testing.T) {
+	x, spans, _ := newTestExporter(t)
+	corr := events.Correlation{SessionID: "s", RunID: "run-2"}
+	call := corr
+	call.ProviderCallID = "claude:msg_1"
+	metadata := events.EventMetadata{InferenceID: "run-2"}
+	metadata.Model = "claude-sonnet"
+
+	publish(t, x,
+		events.NewRunStartedEvent(metadata, corr, "hello"),
+		events.NewProviderCallStartedEvent(metadata, call),
+		events.NewProviderCallRetryEvent(metadata, call, 2, 3, 529, "overloaded", 500),
+		events.NewProviderCallFinishedEvent(metadata, call, "end_turn", "completed", &events.Usage{InputTokens: 1, OutputTokens: 1}, nil, false),
+	)
+	if n := len(spans.GetSpans()); n != 1 {
+		t.Fatalf("explicit run ended before run-finished: %d spans", n)
+	}
+	chat := spanByName(t, spans.GetSpans(), "chat claude-sonnet")
+	if v, _ := attr(chat, "gen_ai.provider.name"); v.AsString() != "anthropic" {
+		t.Fatalf("provider name = %q", v.AsString())
+	}
+	if len(chat.Events) != 1 || chat.Events[0].Name != "provider_call_retry" {
+		t.Fatalf("retry span events = %+v", chat.Events)
+	}
+
+	publish(t, x, events.NewRunFinishedEvent(metadata, corr, "completed"))
+	root := spanByName(t, spans.GetSpans(), "invoke_agent geppetto")
+	if v, _ := attr(root, AttrRunStatus); v.AsString() != "completed" {
+		t.Fatalf("run status = %q", v.AsString())
+	}
+}
+
+func TestExporterErrorEventFailsOpenSpans(t *testing.T) {
+	x, spans, _ := newTestExporter(t)
+	call := events.Correlation{RunID: "run-3", ProviderCallID: "gemini:run-3:provider-call:0"}
+	metadata := events.EventMetadata{InferenceID: "run-3"}
+	metadata.Model = "gemini-2.5-flash"
+
+	publish(t, x, events.NewProviderCallStartedEvent(metadata, call))
+	x.OnGeppettoRecord(context.Background(), observability.Record{
+		Stage:          observability.StageProviderRoutedEvent,
+		ProviderCallID: call.ProviderCallID,
+		EventType:      "partial-completion",
+	})
+	x.OnGeppettoRecord(context.Background(), observability.Record{Stage: observability.StageGeppettoPublishStarted, ProviderCallID: call.ProviderCallID})
+	publish(t, x, events.NewErrorEvent(metadata, errors.New("stream reset")))
+
+	got := spans.GetSpans()
+	if len(got) != 2 {
+		t.Fatalf("got %d spans, want failed call and implicit run", len(got))
+	}
+	for _, s := range got {
+		if s.Status.Code != codes.Error || s.Status.Description != "stream reset" {
+			t.Fatalf("span %q status = %+v", s.Name, s.Status)
+		}
+	}
+	chat := spanByName(t, got, "chat gemini-2.5-flash")
+	if v, _ := attr(chat, "gen_ai.provider.name"); v.AsString() != "gcp.gemini" {
+		t.Fatalf("provider name = %q", v.AsString())
+	}
+	if len(chat.Events) != 1 {
+		t.Fatalf("only provider-level records should become span events, got %+v", chat.Events)
+	}
+}
+
+func TestExporterErrorEventUsesEngineRunID(t *testing.T) {
+	x, spans, _ := newTestExporter(t)
+	// Without an inference ID engines scope their provider calls by the event ID.
+	metadata := events.EventMetadata{ID: uuid.New()}
+	runID := metadata.ID.String()
+	call := events.Correlation{RunID: runID, ProviderCallID: "ollama:" + runID + ":provider-call:0"}
+	other := events.Correlation{RunID: "other", ProviderCallID: "ollama:other:provider-call:0"}
+
+	publish(t, x,
+		events.NewProviderCallStartedEvent(metadata, call),
+		events.NewProviderCallStartedEvent(events.EventMetadata{InferenceID: "other"}, other),
+		events.NewErrorEvent(metadata, errors.New("connection refused")),
+	)
+	if n := len(spans.GetSpans()); n != 2 {
+		t.Fatalf("got %d ended spans, want the failed call and its implicit run", n)
+	}
+	for _, s := range spans.GetSpans() {
+		if v, _ := attr(s, AttrRunID); v.AsString() != runID {
+			t.Fatalf("error ended span %q of run %q", s.Name, v.AsString())
+		}
+	}
+}
+
+func TestExporterEndsIdleImplicitRuns(t *testing.T) {
+	x, spans, _ := newTestExporter(t)
+	now := time.Unix(1000, 0)
+	x.now = func() time.Time { return now }
+	call := events.Correlation{RunID: "run-4", ProviderCallID: "openai:run-4:provider-call:0"}
+	tool := call
+	tool.ToolCallID = "call_1"
+
+	publish(t, x,
+		events.NewProviderCallStartedEvent(events.EventMetadata{}, call),
+		events.NewProviderCallFinishedEvent(events.EventMetadata{}, call, "tool_calls", "tool_calls", nil, nil, true),
+		events.NewToolExecutionStartedEvent(events.EventMetadata{}, tool, "call_1", "search", "{}"),
+	)
+	now = now.Add(time.Second)
+	toolEnd := now
+	publish(t, x, events.NewToolCallFinishedEvent(events.EventMetadata{}, tool, "call_1", "search", "success"))
+
+	now = now.Add(DefaultIdleRunTimeout / 2)
+	publish(t, x, events.NewRunFinishedEvent(events.EventMetadata{}, events.Correlation{RunID: "unrelated"}, "completed"))
+	if _, ok := x.runs["run-4"]; !ok {
+		t.Fatalf("idle run ended before the idle run timeout")
+	}
+
+	now = now.Add(DefaultIdleRunTimeout)
+	publish(t, x, events.NewRunFinishedEvent(events.EventMetadata{}, events.Correlation{RunID: "unrelated"}, "completed"))
+	if len(x.runs) != 0 {
+		t.Fatalf("expected the idle run to be ended, got %d open runs", len(x.runs))
+	}
+	root := spanByName(t, spans.GetSpans(), "invoke_agent geppetto")
+	if !root.EndTime.Equal(toolEnd) {
+		t.Fatalf("run ended at %v, want when its last tool finished (%v)", root.EndTime, toolEnd)
+	}
+	if v, _ := attr(root, AttrRunStatus); v.AsString() != "completed" {
+		t.Fatalf("run status = %q", v.AsString())
+	}
+}
+
+func TestExporterCloseEndsOpenSpans(t *testing.T) {
+	x, spans, _ := newTestExporter(t)
+	publish(t, x, events.NewProviderCallStartedEvent(events.EventMetadata{}, events.Correlation{RunID: "r", ProviderCallID: "ollama:r:provider-call:0"}))
+	if err := x.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if n := len(spans.GetSpans()); n != 2 {
+		t.Fatalf("Close ended %d spans, want 2", n)
+	}
+}
@@ -0,0 +1,112 @@
+package tracefile
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxLineSize bounds one trace line; provider payloads in records can be
+// large, but a line is never expected to exceed this.
+const maxLineSize = 64 << 20
+
+// Load reads JSONL entries from r. Blank lines are skipped; a malformed line
+// is an error naming its line number.
+func Load(r io.Reader) ([]Entry, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	var entries []Entry
+	for line := 1; scanner.Scan(); line++ {
+		b := scanner.Bytes()
+		if len(strings.TrimSpace(string(b))) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(b, &entry); err != nil {
+			return nil, errors.Wrapf(err, "line %d", line)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "read trace entries")
+	}
+	return entries, nil
+}
+
+// LoadFiles reads the entries of every path in order. Files ending in .gz,
+// as written by WithCompress, are decompressed.
+func LoadFiles(paths ...string) ([]Entry, error) {
+	var entries []Entry
+	for _, path := range paths {
+		loaded, err := loadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "load trace file %s", path)
+		}
+		entries = append(entries, loaded...)
+	}
+	return entries, nil
+}
+
+func loadFile(path string) ([]Entry, error) {
+	// #nosec G304 -- trace files are chosen by the caller.
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = gz.Close() }()
+		r = gz
+	}
+	return Load(r)
+}
+
+// backupTimeFormat is the timestamp lumberjack puts between the base name and
+// the extension of a rotated file.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// Files returns the rotated files of the trace file at path, oldest first,
+// followed by path itself when it exists. Rotated files are named
+// "<name>-<backupTimeFormat><ext>", optionally with a ".gz" suffix; other files
+// that share the prefix are ignored.
+func Files(path string) ([]string, error) {
+	ext := filepath.Ext(path)
+	prefix := strings.TrimSuffix(path, ext) + "-"
+	var files []string
+	for _, suffix := range []string{ext, ext + ".gz"} {
+		matches, err := filepath.Glob(prefix + "*" + suffix)
+		if err != nil {
+			return nil, errors.Wrapf(err, "list rotated files of %s", path)
+		}
+		for _, m := range matches {
+			stamp := strings.TrimSuffix(strings.TrimPrefix(m, prefix), suffix)
+			if _, err := time.Parse(backupTimeFormat, stamp); err == nil {
+				files = append(files, m)
+			}
+		}
+	}
+	// Rotation timestamps sort lexically; compare without the .gz suffix so a
+	// compressed file sorts with its siblings.
+	sort.Slice(files, func(i, j int) bool {
+		return strings.TrimSuffix(files[i], ".gz") < strings.TrimSuffix(files[j], ".gz")
+	})
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return files, nil
+}
@@ -0,0 +1,121 @@
+package tracefile
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+)
+
+// Fprint writes a human-readable timeline of runs. Texts, tool arguments and
+// results longer than maxText runes are truncated; 0 prints them in full.
+func Fprint(w io.Writer, runs []*Run, maxText int) {
+	for i, run := range runs {
+		if i > 0 {
+			_, _ = fmt.Fprintln(w)
+		}
+		fprintRun(w, run, maxText)
+	}
+}
+
+func fprintRun(w io.Writer, run *Run, maxText int) {
+	_, _ = fmt.Fprintf(w, "run %s", run.RunID)
+	if run.SessionID != "" {
+		_, _ = fmt.Fprintf(w, " session=%s", run.SessionID)
+	}
+	if run.InferenceID != "" && run.InferenceID != run.RunID {
+		_, _ = fmt.Fprintf(w, " inference=%s", run.InferenceID)
+	}
+	if run.Status != "" {
+		_, _ = fmt.Fprintf(w, " status=%s", run.Status)
+	}
+	_, _ = fmt.Fprintf(w, " %s (%s, %d events, %d records)\n",
+		run.Started.Format(time.RFC3339Nano), run.Finished.Sub(run.Started).Round(time.Millisecond), run.Events, run.Records)
+	if run.Prompt != "" {
+		_, _ = fmt.Fprintf(w, "  prompt: %s\n", clip(run.Prompt, maxText))
+	}
+	for _, call := range run.ProviderCalls {
+		fprintCall(w, run, call, maxText)
+	}
+	for _, tool := range run.ToolCalls {
+		fprintTool(w, "  ", tool, maxText)
+	}
+	if run.Error != "" {
+		_, _ = fmt.Fprintf(w, "  error: %s\n", run.Error)
+	}
+	_, _ = fmt.Fprintf(w, "  usage: %s\n", formatUsage(&run.Usage))
+}
+
+func fprintCall(w io.Writer, run *Run, call *ProviderCall, maxText int) {
+	_, _ = fmt.Fprintf(w, "  provider call %s", call.ID)
+	if call.Provider != "" || call.Model != "" {
+		_, _ = fmt.Fprintf(w, " %s/%s", call.Provider, call.Model)
+	}
+	_, _ = fmt.Fprintf(w, " +%s", call.Started.Sub(run.Started).Round(time.Millisecond))
+	if call.DurationMs != nil {
+		_, _ = fmt.Fprintf(w, " took %s", (time.Duration(*call.DurationMs) * time.Millisecond).String())
+	} else if !call.Finished.IsZero() {
+		_, _ = fmt.Fprintf(w, " took %s", call.Finished.Sub(call.Started).Round(time.Millisecond))
+	}
+	if call.StopReason != "" {
+		_, _ = fmt.Fprintf(w, " stop=%s", call.StopReason)
+	}
+	if call.FinishClass != "" && call.FinishClass != call.StopReason {
+		_, _ = fmt.Fprintf(w, " finish=%s", call.FinishClass)
+	}
+	_, _ = fmt.Fprintln(w)
+	for _, retry := range call.Retries {
+		_, _ = fmt.Fprintf(w, "    retry %d/%d after %d (%s), waited %dms\n", retry.Attempt, retry.MaxAttempts, retry.StatusCode, retry.ErrorClass, retry.DelayMs)
+	}
+	for _, seg := range call.Segments {
+		_, _ = fmt.Fprintf(w, "    %s: %s\n", seg.Kind, clip(seg.Text, maxText))
+	}
+	for _, tool := range call.ToolCalls {
+		fprintTool(w, "    ", tool, maxText)
+	}
+	if call.Usage != nil || call.CostUSD > 0 {
+		_, _ = fmt.Fprintf(w, "    usage: %s", formatUsage(call.Usage))
+		if call.CostUSD > 0 {
+			_, _ = fmt.Fprintf(w, " cost=$%s", strconv.FormatFloat(call.CostUSD, 'f', -1, 64))
+		}
+		_, _ = fmt.Fprintln(w)
+	}
+}
+
+func fprintTool(w io.Writer, indent string, tool *ToolCall, maxText int) {
+	_, _ = fmt.Fprintf(w, "%stool %s %s", indent, tool.Name, tool.ID)
+	if tool.Status != "" {
+		_, _ = fmt.Fprintf(w, " [%s]", tool.Status)
+	}
+	_, _ = fmt.Fprintln(w)
+	if tool.Arguments != "" {
+		_, _ = fmt.Fprintf(w, "%s  args: %s\n", indent, clip(tool.Arguments, maxText))
+	}
+	if tool.Result != "" {
+		_, _ = fmt.Fprintf(w, "%s  result: %s\n", indent, clip(tool.Result, maxText))
+	}
+}
+
+func formatUsage(u *events.Usage) string {
+	if u == nil {
+		return "-"
+	}
+	s := fmt.Sprintf("in=%d out=%d", u.InputTokens, u.OutputTokens)
+	if cached := max(u.CachedTokens, u.CacheReadInputTokens); cached > 0 {
+		s += fmt.Sprintf(" cached=%d", cached)
+	}
+	if u.CacheCreationInputTokens > 0 {
+		s += fmt.Sprintf(" cache_write=%d", u.CacheCreationInputTokens)
+	}
+	return s
+}
+
+// clip quotes s on one line and truncates it to maxText runes.
+func clip(s string, maxText int) string {
+	if r := []rune(s); maxText > 0 && len(r) > maxText {
+		return strconv.Quote(string(r[:maxText])) + "…"
+	}
+	return strconv.Quote(s)
+}
@@ -0,0 +1,168 @@
+// Package tracefile records Geppetto inference evidence as JSON lines and
+// reconstructs run timelines from such files.
+//
+// A Recorder is both an events.EventSink and an observability.Observer: it
+// appends every canonical event and every Record it receives as one Entry per
+// line. Files rotate by size; rotated files keep the base name with a
+// timestamp suffix and are found again by Files.
+//
+// Load, LoadFiles and BuildTimeline read the entries back and group them by
+// the events' Correlation keys into runs, provider calls, text and reasoning
+// segments and tool calls, without a tracing backend.
+package tracefile
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/observability"
+	"github.com/pkg/errors"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// EntryKind distinguishes the two kinds of lines in a trace file.
+type EntryKind string
+
+const (
+	EntryKindEvent  EntryKind = "event"
+	EntryKindRecord EntryKind = "record"
+)
+
+// Entry is one line of a trace file. Event holds the event as serialized by
+// encoding/json, which events.NewEventFromJson reads back.
+type Entry struct {
+	Time      time.Time             `json:"time"`
+	Kind      EntryKind             `json:"kind"`
+	EventType string                `json:"eventType,omitempty"`
+	Event     json.RawMessage       `json:"event,omitempty"`
+	Record    *observability.Record `json:"record,omitempty"`
+}
+
+const (
+	DefaultMaxSizeMB  = 100
+	DefaultMaxBackups = 10
+)
+
+type Option func(*rotation)
+
+type rotation struct {
+	maxSizeMB  int
+	maxBackups int
+	maxAgeDays int
+	compress   bool
+}
+
+// WithMaxSizeMB rotates the file once it would exceed n megabytes.
+func WithMaxSizeMB(n int) Option {
+	return func(r *rotation) { r.maxSizeMB = n }
+}
+
+// WithMaxBackups keeps at most n rotated files; 0 keeps all of them.
+func WithMaxBackups(n int) Option {
+	return func(r *rotation) { r.maxBackups = n }
+}
+
+// WithMaxAgeDays deletes rotated files older than n days; 0 keeps them.
+func WithMaxAgeDays(n int) Option {
+	return func(r *rotation) { r.maxAgeDays = n }
+}
+
+// WithCompress gzips rotated files.
+func WithCompress(compress bool) Option {
+	return func(r *rotation) { r.compress = compress }
+}
+
+// Recorder appends events and records to a JSONL writer. Writes are
+// serialized, so one Recorder can be shared by engines, tool executors and
+// middleware of concurrent runs.
+type Recorder struct {
+	mu  sync.Mutex
+	w   io.Writer
+	now func() time.Time
+}
+
+var (
+	_ events.EventSink       = (*Recorder)(nil)
+	_ observability.Observer = (*Recorder)(nil)
+)
+
+// NewRecorder appends to the file at path, creating it and its directory when
+// needed, and rotates it according to options (by default at 100 MB, keeping
+// 10 rotated files).
+func NewRecorder(path string, options ...Option) (*Recorder, error) {
+	if path == "" {
+		return nil, errors.New("trace file path is empty")
+	}
+	r := rotation{maxSizeMB: DefaultMaxSizeMB, maxBackups: DefaultMaxBackups}
+	for _, option := range options {
+		if option != nil {
+			option(&r)
+		}
+	}
+	return NewWriterRecorder(&lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    r.maxSizeMB,
+		MaxBackups: r.maxBackups,
+		MaxAge:     r.maxAgeDays,
+		Compress:   r.compress,
+	}), nil
+}
+
+// NewWriterRecorder writes entries to w without rotation. Close closes w when
+// it is an io.Closer.
+func NewWriterRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w, now: time.Now}
+}
+
+// PublishEvent implements events.EventSink.
+func (r *Recorder) PublishEvent(event events.Event) error {
+	if event == nil {
+		return nil
+	}
+	b, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrapf(err, "marshal %s event", event.Type())
+	}
+	return r.write(Entry{Time: r.now().UTC(), Kind: EntryKindEvent, EventType: string(event.Type()), Event: b})
+}
+
+// OnGeppettoRecord implements observability.Observer. Write failures are
+// logged and dropped, like any observer failure.
+func (r *Recorder) OnGeppettoRecord(_ context.Context, rec observability.Record) {
+	ts := rec.Timestamp
+	if ts.IsZero() {
+		ts = r.now().UTC()
+	}
+	if err := r.write(Entry{Time: ts, Kind: EntryKindRecord, EventType: rec.EventType, Record: &rec}); err != nil {
+		log.Warn().Err(err).Str("stage", string(rec.Stage)).Msg("tracefile: dropping observability record")
+	}
+}
+
+// Close closes the underlying file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (r *Recorder) write(entry Entry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "marshal trace entry")
+	}
+	b = append(b, '\n')
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// One Write per line: the rotating writer never splits a line across files.
+	if _, err := r.w.Write(b); err != nil {
+		return errors.Wrap(err, "write trace entry")
+	}
+	return nil
+}
@@ -0,0 +1,404 @@
+package tracefile
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/observability"
+)
+
+// Filter selects runs by identity. Empty fields match everything.
+type Filter struct {
+	SessionID   string
+	InferenceID string
+	RunID       string
+}
+
+func (f Filter) matches(run *Run) bool {
+	return (f.SessionID == "" || f.SessionID == run.SessionID) &&
+		(f.InferenceID == "" || f.InferenceID == run.InferenceID) &&
+		(f.RunID == "" || f.RunID == run.RunID)
+}
+
+// Run is the reconstructed timeline of one run, keyed by Correlation.RunID or,
+// for events without canonical correlation, by the inference ID.
+type Run struct {
+	RunID       string    `json:"run_id" yaml:"run_id"`
+	SessionID   string    `json:"session_id,omitempty" yaml:"session_id,omitempty"`
+	InferenceID string    `json:"inference_id,omitempty" yaml:"inference_id,omitempty"`
+	TurnID      string    `json:"turn_id,omitempty" yaml:"turn_id,omitempty"`
+	Prompt      string    `json:"prompt,omitempty" yaml:"prompt,omitempty"`
+	Status      string    `json:"status,omitempty" yaml:"status,omitempty"`
+	Error       string    `json:"error,omitempty" yaml:"error,omitempty"`
+	Started     time.Time `json:"started" yaml:"started"`
+	Finished    time.Time `json:"finished" yaml:"finished"`
+
+	// Usage sums the usage of the provider calls.
+	Usage         events.Usage    `json:"usage" yaml:"usage"`
+	ProviderCalls []*ProviderCall `json:"provider_calls,omitempty" yaml:"provider_calls,omitempty"`
+	// ToolCalls holds tool calls whose provider call is unknown.
+	ToolCalls []*ToolCall `json:"tool_calls,omitempty" yaml:"tool_calls,omitempty"`
+
+	Events  int `json:"events" yaml:"events"`
+	Records int `json:"records" yaml:"records"`
+}
+
+// ProviderCall is one request to a provider within a run.
+type ProviderCall struct {
+	ID           string        `json:"id" yaml:"id"`
+	Provider     string        `json:"provider,omitempty" yaml:"provider,omitempty"`
+	Model        string        `json:"model,omitempty" yaml:"model,omitempty"`
+	Started      time.Time     `json:"started" yaml:"started"`
+	Finished     time.Time     `json:"finished,omitempty" yaml:"finished,omitempty"`
+	StopReason   string        `json:"stop_reason,omitempty" yaml:"stop_reason,omitempty"`
+	FinishClass  string        `json:"finish_class,omitempty" yaml:"finish_class,omitempty"`
+	Usage        *events.Usage `json:"usage,omitempty" yaml:"usage,omitempty"`
+	DurationMs   *int64        `json:"duration_ms,omitempty" yaml:"duration_ms,omitempty"`
+	HasToolCalls bool          `json:"has_tool_calls,omitempty" yaml:"has_tool_calls,omitempty"`
+	CostUSD      float64       `json:"cost_usd,omitempty" yaml:"cost_usd,omitempty"`
+	Retries      []Retry       `json:"retries,omitempty" yaml:"retries,omitempty"`
+	Segments     []*Segment    `json:"segments,omitempty" yaml:"segments,omitempty"`
+	ToolCalls    []*ToolCall   `json:"tool_calls,omitempty" yaml:"tool_calls,omitempty"`
+	Records      int           `json:"records" yaml:"records"`
+}
+
+type SegmentKind string
+
+const (
+	SegmentKindText      SegmentKind = "text"
+	SegmentKindReasoning SegmentKind = "reasoning"
+)
+
+// Segment is a streamed text or reasoning segment of a provider call.
+type Segment struct {
+	ID           string      `json:"id" yaml:"id"`
+	Kind         SegmentKind `json:"kind" yaml:"kind"`
+	Text         string      `json:"text" yaml:"text"`
+	FinishReason string      `json:"finish_reason,omitempty" yaml:"finish_reason,omitempty"`
+	Started      time.Time   `json:"started" yaml:"started"`
+	Finished     time.Time   `json:"finished,omitempty" yaml:"finished,omitempty"`
+}
+
+// ToolCall is a tool call from its request by the model to its result.
+type ToolCall struct {
+	ID        string    `json:"id" yaml:"id"`
+	Name      string    `json:"name,omitempty" yaml:"name,omitempty"`
+	Arguments string    `json:"arguments,omitempty" yaml:"arguments,omitempty"`
+	Result    string    `json:"result,omitempty" yaml:"result,omitempty"`
+	Status    string    `json:"status,omitempty" yaml:"status,omitempty"`
+	Requested time.Time `json:"requested" yaml:"requested"`
+	Finished  time.Time `json:"finished,omitempty" yaml:"finished,omitempty"`
+}
+
+// Retry is a provider-call-retry event.
+type Retry struct {
+	Time        time.Time `json:"time" yaml:"time"`
+	Attempt     int       `json:"attempt" yaml:"attempt"`
+	MaxAttempts int       `json:"max_attempts" yaml:"max_attempts"`
+	StatusCode  int       `json:"status_code,omitempty" yaml:"status_code,omitempty"`
+	ErrorClass  string    `json:"error_class,omitempty" yaml:"error_class,omitempty"`
+	DelayMs     int64     `json:"delay_ms" yaml:"delay_ms"`
+}
+
+// BuildTimeline groups entries into the runs matching filter, ordered by
+// start time. Entries are processed in time order; events that cannot be
+// decoded or carry no run identity are skipped.
+func BuildTimeline(entries []Entry, filter Filter) []*Run {
+	sorted := append([]Entry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	b := &builder{runs: map[string]*Run{}, calls: map[string]*ProviderCall{}, segments: map[string]*Segment{}, tools: map[string]*ToolCall{}}
+	for _, entry := range sorted {
+		switch entry.Kind {
+		case EntryKindEvent:
+			event, err := events.NewEventFromJson(entry.Event)
+			if err != nil || event == nil {
+				continue
+			}
+			b.event(entry.Time, event)
+		case EntryKindRecord:
+			if entry.Record != nil {
+				b.record(entry.Time, entry.Record)
+			}
+		}
+	}
+
+	var runs []*Run
+	for _, run := range b.runs {
+		if filter.matches(run) {
+			runs = append(runs, run)
+		}
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].Started.Before(runs[j].Started) })
+	return runs
+}
+
+// builder keys calls, segments and tool calls by run and ID, since provider
+// and tool call IDs are only unique within their run.
+type builder struct {
+	runs     map[string]*Run
+	calls    map[string]*ProviderCall
+	segments map[string]*Segment
+	tools    map[string]*ToolCall
+}
+
+func key(runID, id string) string { return runID + "\x00" + id }
+
+func (b *builder) run(ts time.Time, runID, sessionID, inferenceID, turnID string) *Run {
+	if runID == "" {
+		runID = inferenceID
+	}
+	if runID == "" {
+		return nil
+	}
+	run := b.runs[runID]
+	if run == nil {
+		run = &Run{RunID: runID, Started: ts}
+		b.runs[runID] = run
+	}
+	if run.SessionID == "" {
+		run.SessionID = sessionID
+	}
+	if run.InferenceID == "" {
+		run.InferenceID = inferenceID
+	}
+	if turnID != "" {
+		run.TurnID = turnID
+	}
+	if ts.After(run.Finished) {
+		run.Finished = ts
+	}
+	return run
+}
+
+func (b *builder) call(ts time.Time, run *Run, id string) *ProviderCall {
+	if id == "" {
+		return nil
+	}
+	call := b.calls[key(run.RunID, id)]
+	if call == nil {
+		call = &ProviderCall{ID: id, Provider: providerFromCallID(id), Started: ts}
+		b.calls[key(run.RunID, id)] = call
+		run.ProviderCalls = append(run.ProviderCalls, call)
+	}
+	return call
+}
+
+func (b *builder) segment(ts time.Time, run *Run, corr events.Correlation, kind SegmentKind) *Segment {
+	if corr.SegmentID == "" {
+		return nil
+	}
+	seg := b.segments[key(run.RunID, corr.SegmentID)]
+	if seg == nil {
+		seg = &Segment{ID: corr.SegmentID, Kind: kind, Started: ts}
+		b.segments[key(run.RunID, corr.SegmentID)] = seg
+		if call := b.call(ts, run, corr.ProviderCallID); call != nil {
+			call.Segments = append(call.Segments, seg)
+		}
+	}
+	return seg
+}
+
+func (b *builder) tool(ts time.Time, run *Run, corr events.Correlation, id string) *ToolCall {
+	if id == "" {
+		id = corr.ToolCallID
+	}
+	if id == "" {
+		return nil
+	}
+	tool := b.tools[key(run.RunID, id)]
+	if tool == nil {
+		tool = &ToolCall{ID: id, Requested: ts}
+		b.tools[key(run.RunID, id)] = tool
+		if call := b.call(ts, run, corr.ProviderCallID); call != nil {
+			call.ToolCalls = append(call.ToolCalls, tool)
+		} else {
+			run.ToolCalls = append(run.ToolCalls, tool)
+		}
+	}
+	return tool
+}
+
+func (b *builder) event(ts time.Time, event events.Event) {
+	metadata := event.Metadata()
+	var corr events.Correlation
+	if correlated, ok := event.(events.CorrelatedEvent); ok {
+		corr = correlated.Correlation()
+	}
+	sessionID, turnID := corr.SessionID, corr.TurnID
+	if sessionID == "" {
+		sessionID = metadata.SessionID
+	}
+	if turnID == "" {
+		turnID = metadata.TurnID
+	}
+	run := b.run(ts, corr.RunID, sessionID, metadata.InferenceID, turnID)
+	if run == nil {
+		return
+	}
+	run.Events++
+	if call := b.calls[key(run.RunID, corr.ProviderCallID)]; call != nil && call.Model == "" {
+		call.Model = metadata.Model
+	}
+
+	switch e := event.(type) {
+	case *events.EventRunStarted:
+		run.Prompt = e.Prompt
+	case *events.EventRunFinished:
+		run.Status = e.Status
+	case *events.EventRunStopped:
+		run.Status = "stopped"
+	case *events.EventRunFailed:
+		run.Status, run.Error = "failed", e.ErrorString
+	case *events.EventError:
+		run.Error = e.ErrorString
+	case *events.EventProviderCallStarted:
+		if call := b.call(ts, run, corr.ProviderCallID); call != nil {
+			call.Model = metadata.Model
+		}
+	case *events.EventProviderCallMetadataUpdated:
+		if call := b.call(ts, run, corr.ProviderCallID); call != nil && e.Usage != nil {
+			usage := *e.Usage
+			call.Usage = &usage
+		}
+	case *events.EventProviderCallFinished:
+		if call := b.call(ts, run, corr.ProviderCallID); call != nil {
+			call.Finished = ts
+			call.StopReason, call.FinishClass = e.StopReason, e.FinishClass
+			call.DurationMs, call.HasToolCalls = e.DurationMs, e.HasToolCalls
+			if e.Usage != nil {
+				usage := *e.Usage
+				call.Usage = &usage
+			}
+			if call.Usage != nil {
+				run.Usage.InputTokens += call.Usage.InputTokens
+				run.Usage.OutputTokens += call.Usage.OutputTokens
+				run.Usage.CachedTokens += call.Usage.CachedTokens
+				run.Usage.CacheReadInputTokens += call.Usage.CacheReadInputTokens
+				run.Usage.CacheCreationInputTokens += call.Usage.CacheCreationInputTokens
+			}
+		}
+	case *events.EventProviderCallRetry:
+		if call := b.call(ts, run, corr.ProviderCallID); call != nil {
+			call.Retries = append(call.Retries, Retry{Time: ts, Attempt: e.Attempt, MaxAttempts: e.MaxAttempts, StatusCode: e.StatusCode, ErrorClass: e.ErrorClass, DelayMs: e.DelayMs})
+		}
+	case *events.EventUsageUpdated:
+		// The budget middleware reports after the call; without a provider-call
+		// ID the cost belongs to the run's latest call.
+		call := b.calls[key(run.RunID, corr.ProviderCallID)]
+		if call == nil && len(run.ProviderCalls) > 0 {
+			call = run.ProviderCalls[len(run.ProviderCalls)-1]
+		}
+		if call != nil {
+			call.CostUSD = e.Call.CostUSD
+		}
+	case *events.EventTextSegmentStarted:
+		b.segment(ts, run, corr, SegmentKindText)
+	case *events.EventTextDelta:
+		if seg := b.segment(ts, run, corr, SegmentKindText); seg != nil {
+			seg.Text = accumulate(seg.Text, e.Text, e.Delta)
+		}
+	case *events.EventTextSegmentFinished:
+		if seg := b.segment(ts, run, corr, SegmentKindText); seg != nil {
+			seg.Finished, seg.FinishReason = ts, e.FinishReason
+			if e.Text != "" {
+				seg.Text = e.Text
+			}
+		}
+	case *events.EventReasoningSegmentStarted:
+		b.segment(ts, run, corr, SegmentKindReasoning)
+	case *events.EventReasoningDelta:
+		if seg := b.segment(ts, run, corr, SegmentKindReasoning); seg != nil {
+			seg.Text = accumulate(seg.Text, e.Text, e.Delta)
+		}
+	case *events.EventReasoningSegmentFinished:
+		if seg := b.segment(ts, run, corr, SegmentKindReasoning); seg != nil {
+			seg.Finished, seg.FinishReason = ts, e.FinishReason
+			if e.Text != "" {
+				seg.Text = e.Text
+			}
+		}
+	case *events.EventToolCallStarted:
+		if tool := b.tool(ts, run, corr, e.ToolCallID); tool != nil && e.ToolName != "" {
+			tool.Name = e.ToolName
+		}
+	case *events.EventToolCallArgumentsDelta:
+		if tool := b.tool(ts, run, corr, e.ToolCallID); tool != nil {
+			tool.Arguments = accumulate(tool.Arguments, e.Arguments, e.Delta)
+		}
+	case *events.EventToolCallRequested:
+		if tool := b.tool(ts, run, corr, e.ToolCallID); tool != nil {
+			tool.Name, tool.Arguments = firstNonEmpty(e.ToolName, tool.Name), firstNonEmpty(e.Input, tool.Arguments)
+		}
+	case *events.EventToolExecutionStarted:
+		if tool := b.tool(ts, run, corr, e.ToolCallID); tool != nil {
+			tool.Name, tool.Arguments = firstNonEmpty(tool.Name, e.ToolName), firstNonEmpty(tool.Arguments, e.Input)
+		}
+	case *events.EventToolResultReady:
+		if tool := b.tool(ts, run, corr, e.ToolCallID); tool != nil {
+			tool.Result, tool.Status = e.Result, firstNonEmpty(e.Status, tool.Status)
+			tool.Name = firstNonEmpty(tool.Name, e.ToolName)
+		}
+	case *events.EventToolCallFinished:
+		if tool := b.tool(ts, run, corr, e.ToolCallID); tool != nil {
+			tool.Finished, tool.Status = ts, firstNonEmpty(e.Status, tool.Status)
+			tool.Name = firstNonEmpty(tool.Name, e.ToolName)
+		}
+	}
+}
+
+// record counts a record against its run and provider call and fills in the
+// provider and model, which canonical events do not carry.
+func (b *builder) record(ts time.Time, rec *observability.Record) {
+	run := b.run(ts, rec.RunID, rec.SessionID, rec.InferenceID, rec.TurnID)
+	if run == nil {
+		return
+	}
+	run.Records++
+	if rec.Stage == observability.StageGeppettoPublishError && rec.Error != "" && run.Error == "" {
+		run.Error = rec.Error
+	}
+	call := b.calls[key(run.RunID, rec.ProviderCallID)]
+	if call == nil {
+		return
+	}
+	call.Records++
+	if rec.Provider != "" {
+		call.Provider = rec.Provider
+	}
+	if call.Model == "" {
+		call.Model = rec.Model
+	}
+}
+
+// accumulate prefers the cumulative text of a delta event and falls back to
+// appending the delta.
+func accumulate(current, cumulative, delta string) string {
+	if cumulative != "" {
+		return cumulative
+	}
+	return current + delta
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// providerFromCallID returns the provider prefix of a canonical provider-call
+// ID ("openai:<run>:provider-call:0", "claude:msg_…", "claude-provider-call-0").
+func providerFromCallID(id string) string {
+	if i := strings.Index(id, ":"); i > 0 {
+		return id[:i]
+	}
+	if i := strings.Index(id, "-provider-call-"); i > 0 {
+		return id[:i]
+	}
+	return ""
+}
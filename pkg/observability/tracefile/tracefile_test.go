@@ -0,0 +1,198 @@
+package tracefile
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/observability"
+)
+
+// summary renders the provider calls, segments and tool calls of run compactly.
+func summary(run *Run) string {
+	var parts []string
+	for _, call := range run.ProviderCalls {
+		part := call.ID
+		for _, seg := range call.Segments {
+			part += "|" + string(seg.Kind) + ":" + seg.Text
+		}
+		for _, tool := range call.ToolCalls {
+			part += "|tool:" + tool.Name + "(" + tool.Arguments + ")=" + tool.Result + "[" + tool.Status + "]"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ; ")
+}
+
+// recordRun publishes a tool-loop run with two provider calls to r.
+func recordRun(t *testing.T, r *Recorder, sessionID, runID string) {
+	t.Helper()
+	clock := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	r.now = func() time.Time {
+		clock = clock.Add(10 * time.Millisecond)
+		return clock
+	}
+	metadata := events.EventMetadata{SessionID: sessionID, InferenceID: runID}
+	metadata.Model = "gpt-4o-mini"
+	run := events.Correlation{SessionID: sessionID, RunID: runID}
+	call0, call1 := run, run
+	call0.ProviderCallID = "openai:" + runID + ":provider-call:0"
+	call1.ProviderCallID = "openai:" + runID + ":provider-call:1"
+	reasoning, text := call0, call1
+	reasoning.SegmentID, text.SegmentID = "reasoning-0", "text-0"
+	tool := call0
+	tool.ToolCallID = "call_1"
+
+	evs := []events.Event{
+		events.NewRunStartedEvent(metadata, run, "weather in Paris?"),
+		events.NewProviderCallStartedEvent(metadata, call0),
+		events.NewReasoningSegmentStartedEvent(metadata, reasoning, "summary"),
+		events.NewReasoningDeltaEvent(metadata, reasoning, "Need ", "Need ", 1),
+		events.NewReasoningDeltaEvent(metadata, reasoning, "weather", "Need weather", 2),
+		events.NewReasoningSegmentFinishedEvent(metadata, reasoning, "Need weather", ""),
+		events.NewToolCallStartedEvent(metadata, tool, "call_1", "get_weather"),
+		events.NewToolCallArgumentsDeltaEvent(metadata, tool, "call_1", `{"city":`, `{"city":`, 1),
+		events.NewToolCallRequestedEvent(metadata, tool, "call_1", "get_weather", `{"city":"Paris"}`),
+		events.NewProviderCallFinishedEvent(metadata, call0, "tool_calls", "tool_calls", &events.Usage{InputTokens: 20, OutputTokens: 5}, nil, true),
+		events.NewToolExecutionStartedEvent(events.EventMetadata{}, tool, "call_1", "get_weather", `{"city":"Paris"}`),
+		events.NewToolResultReadyEvent(events.EventMetadata{}, tool, "call_1", "get_weather", "sunny", "success"),
+		events.NewToolCallFinishedEvent(events.EventMetadata{}, tool, "call_1", "get_weather", "success"),
+		events.NewProviderCallStartedEvent(metadata, call1),
+		events.NewProviderCallRetryEvent(metadata, call1, 2, 3, 429, "rate_limited", 500),
+		events.NewTextSegmentStartedEvent(metadata, text, "assistant"),
+		events.NewTextDeltaEvent(metadata, text, "It is ", "It is ", 1),
+		events.NewTextDeltaEvent(metadata, text, "sunny.", "It is sunny.", 2),
+		events.NewTextSegmentFinishedEvent(metadata, text, "It is sunny.", "stop"),
+		events.NewProviderCallFinishedEvent(metadata, call1, "stop", "completed", &events.Usage{InputTokens: 40, OutputTokens: 6}, nil, false),
+		events.NewUsageUpdatedEvent(metadata, run, "openai", "gpt-4o-mini", "", events.UsageTotals{CostUSD: 0.25}, events.UsageTotals{}, events.UsageTotals{}),
+		events.NewRunFinishedEvent(metadata, run, "completed"),
+	}
+	for _, ev := range evs {
+		if err := r.PublishEvent(ev); err != nil {
+			t.Fatalf("PublishEvent(%s): %v", ev.Type(), err)
+		}
+	}
+	r.OnGeppettoRecord(context.Background(), observability.Record{
+		Timestamp:      clock,
+		Provider:       "openai",
+		InferenceID:    runID,
+		RunID:          runID,
+		ProviderCallID: call1.ProviderCallID,
+		Stage:          observability.StageProviderRoutedEvent,
+	})
+}
+
+func TestRecorderRoundTripBuildsTimeline(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewWriterRecorder(&buf)
+	recordRun(t, r, "session-1", "run-1")
+	recordRun(t, r, "session-2", "run-2")
+
+	entries, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(entries) != 2*23 {
+		t.Fatalf("got %d entries, want %d", len(entries), 2*23)
+	}
+
+	runs := BuildTimeline(entries, Filter{SessionID: "session-2"})
+	if len(runs) != 1 || runs[0].RunID != "run-2" {
+		t.Fatalf("session filter returned %d runs", len(runs))
+	}
+	run := runs[0]
+	want := `openai:run-2:provider-call:0|reasoning:Need weather|tool:get_weather({"city":"Paris"})=sunny[success] ; openai:run-2:provider-call:1|text:It is sunny.`
+	if got := summary(run); got != want {
+		t.Fatalf("timeline:\n got  %s\n want %s", got, want)
+	}
+	if run.Status != "completed" || run.Prompt != "weather in Paris?" {
+		t.Fatalf("run status=%q prompt=%q", run.Status, run.Prompt)
+	}
+	if run.Usage.InputTokens != 60 || run.Usage.OutputTokens != 11 {
+		t.Fatalf("run usage = %+v", run.Usage)
+	}
+	last := run.ProviderCalls[1]
+	if last.CostUSD != 0.25 || len(last.Retries) != 1 || last.Records != 1 || last.Model != "gpt-4o-mini" {
+		t.Fatalf("last call = %+v", last)
+	}
+	if run.Records != 1 || run.Events != 22 {
+		t.Fatalf("run counted %d events, %d records", run.Events, run.Records)
+	}
+
+	if got := BuildTimeline(entries, Filter{RunID: "run-1", InferenceID: "run-1"}); len(got) != 1 {
+		t.Fatalf("run filter returned %d runs", len(got))
+	}
+	if got := BuildTimeline(entries, Filter{RunID: "missing"}); len(got) != 0 {
+		t.Fatalf("unknown run matched %d runs", len(got))
+	}
+
+	var out bytes.Buffer
+	Fprint(&out, runs, 8)
+	for _, fragment := range []string{
+		"run run-2 session=session-2 status=completed",
+		"provider call openai:run-2:provider-call:1 openai/gpt-4o-mini",
+		"retry 2/3 after 429 (rate_limited), waited 500ms",
+		`text: "It is su"…`,
+		"tool get_weather call_1 [success]",
+		"usage: in=40 out=6 cost=$0.25",
+		"usage: in=60 out=11",
+	} {
+		if !strings.Contains(out.String(), fragment) {
+			t.Fatalf("output lacks %q:\n%s", fragment, out.String())
+		}
+	}
+}
+
+func TestFilesListsRotatedFilesOldestFirst(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "trace.jsonl")
+	for _, name := range []string{
+		"trace-2026-01-02T10-00-00.000.jsonl.gz",
+		"trace-2026-01-01T10-00-00.000.jsonl",
+		"trace.jsonl",
+		"other.jsonl",
+		"trace-old.jsonl",
+		"trace-2026-01-03.jsonl",
+		"trace-errors-2026-01-01T10-00-00.000.jsonl",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := Files(path)
+	if err != nil {
+		t.Fatalf("Files: %v", err)
+	}
+	want := []string{
+		filepath.Join(dir, "trace-2026-01-01T10-00-00.000.jsonl"),
+		filepath.Join(dir, "trace-2026-01-02T10-00-00.000.jsonl.gz"),
+		path,
+	}
+	if strings.Join(files, ",") != strings.Join(want, ",") {
+		t.Fatalf("Files = %v, want %v", files, want)
+	}
+}
+
+func TestNewRecorderAppendsToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces", "run.jsonl")
+	r, err := NewRecorder(path, WithMaxSizeMB(1))
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	recordRun(t, r, "s", "run-file")
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	entries, err := LoadFiles(path)
+	if err != nil {
+		t.Fatalf("LoadFiles: %v", err)
+	}
+	if runs := BuildTimeline(entries, Filter{}); len(runs) != 1 || len(runs[0].ProviderCalls) != 2 {
+		t.Fatalf("file timeline = %+v", runs)
+	}
+}
@@ -0,0 +1,49 @@
+package cohere
+
+import (
+	"encoding/json"
+
+	"github.com/go-go-golems/geppetto/pkg/rerank/rerankhttp"
+)
+
+// request is the Cohere v2 /rerank request wire DTO.
+type request struct {
+	Model     string   `json:"model"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n"`
+}
+
+// response is the Cohere v2 /rerank response wire DTO.
+type response struct {
+	ID      string            `json:"id,omitempty"`
+	Results []rerankhttp.Item `json:"results"`
+	Meta    *meta             `json:"meta,omitempty"`
+}
+
+// meta carries API version, billing, and token accounting. APIVersion is kept
+// raw: it is informational and its shape is not part of the adapter contract.
+type meta struct {
+	APIVersion   json.RawMessage `json:"api_version,omitempty"`
+	BilledUnits  *billedUnits    `json:"billed_units,omitempty"`
+	Tokens       *tokens         `json:"tokens,omitempty"`
+	CachedTokens float64         `json:"cached_tokens,omitempty"`
+	Warnings     []string        `json:"warnings,omitempty"`
+}
+
+// billedUnits mirrors meta.billed_units. Cohere documents these as numbers
+// that may be fractional.
+type billedUnits struct {
+	Images          float64 `json:"images,omitempty"`
+	InputTokens     float64 `json:"input_tokens,omitempty"`
+	ImageTokens     float64 `json:"image_tokens,omitempty"`
+	OutputTokens    float64 `json:"output_tokens,omitempty"`
+	SearchUnits     float64 `json:"search_units,omitempty"`
+	Classifications float64 `json:"classifications,omitempty"`
+}
+
+// tokens mirrors meta.tokens.
+type tokens struct {
+	InputTokens  float64 `json:"input_tokens,omitempty"`
+	OutputTokens float64 `json:"output_tokens,omitempty"`
+}
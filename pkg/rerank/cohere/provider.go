@@ -0,0 +1,63 @@
+// Package cohere implements a strict Cohere v2 /rerank adapter for the
+// transport-neutral rerank.Provider interface.
+package cohere
+
+import (
+	"github.com/go-go-golems/geppetto/pkg/rerank"
+	"github.com/go-go-golems/geppetto/pkg/rerank/rerankhttp"
+)
+
+const (
+	// ProviderName is the provider identity reported in rerank.Response.
+	ProviderName = "cohere"
+
+	// DefaultBaseURL is the hosted Cohere API.
+	DefaultBaseURL = "https://api.cohere.com"
+
+	// rerankPath is the Cohere v2 reranking route appended to the base URL.
+	rerankPath = "v2/rerank"
+)
+
+// Options configures the Cohere rerank provider. Cohere usually bills rerank
+// by search unit and reports no tokens, in which case Cost is nil (unknown).
+type Options = rerankhttp.HostedOptions
+
+// Provider is the Cohere rerank provider.
+type Provider = rerankhttp.HostedProvider[response]
+
+var _ rerank.Provider = (*Provider)(nil)
+
+var api = rerankhttp.HostedAPI[response]{
+	Name:           ProviderName,
+	DefaultBaseURL: DefaultBaseURL,
+	Path:           rerankPath,
+	BuildRequest: func(model string, in rerank.Request, documents []string) any {
+		return request{Model: model, Query: in.Query, Documents: documents, TopN: in.TopN}
+	},
+	MapResponse: func(resp *response) rerankhttp.Reply {
+		return rerankhttp.Reply{Items: resp.Results, Usage: mapUsage(resp.Meta), RequestID: resp.ID}
+	},
+}
+
+// New constructs a Cohere rerank provider.
+func New(options Options) (*Provider, error) {
+	return rerankhttp.NewHosted(api, options)
+}
+
+// mapUsage prefers meta.tokens and falls back to meta.billed_units. Returns
+// nil when Cohere reported neither.
+func mapUsage(m *meta) *rerank.Usage {
+	if m == nil || (m.Tokens == nil && m.BilledUnits == nil) {
+		return nil
+	}
+	u := &rerank.Usage{}
+	if m.BilledUnits != nil {
+		u.InputTokens = int(m.BilledUnits.InputTokens)
+		u.SearchUnits = int(m.BilledUnits.SearchUnits)
+	}
+	if m.Tokens != nil && m.Tokens.InputTokens > 0 {
+		u.InputTokens = int(m.Tokens.InputTokens)
+	}
+	u.TotalTokens = u.InputTokens
+	return u
+}
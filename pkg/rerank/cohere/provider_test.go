@@ -0,0 +1,118 @@
+package cohere
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/rerank"
+	"github.com/go-go-golems/geppetto/pkg/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testModel = "rerank-v3.5"
+
+var testDocs = []rerank.Document{
+	{ID: "chunk-001", Text: "A payroll adjustment corrects wages or deductions."},
+	{ID: "chunk-002", Text: "Cypress trees tolerate dry conditions."},
+}
+
+func newTestServer(t *testing.T, cost *float64, handler http.HandlerFunc) *Provider {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	p, err := New(Options{
+		BaseURL:        srv.URL,
+		APIKey:         "COHERESECRET",
+		Model:          testModel,
+		OutboundURL:    security.OutboundURLOptions{AllowHTTP: true, AllowLocalNetworks: true},
+		CostPerMTokens: cost,
+	})
+	require.NoError(t, err)
+	return p
+}
+
+func TestNew_RequiresAPIKeyAndModel(t *testing.T) {
+	_, err := New(Options{Model: testModel})
+	require.ErrorIs(t, err, rerank.ErrInvalidRequest)
+	assert.Contains(t, err.Error(), "API key is required")
+
+	_, err = New(Options{APIKey: "k"})
+	require.ErrorIs(t, err, rerank.ErrInvalidRequest)
+	assert.Contains(t, err.Error(), "model is required")
+
+	p, err := New(Options{APIKey: "k", Model: testModel})
+	require.NoError(t, err)
+	assert.Equal(t, rerank.Model{Provider: "cohere", Name: testModel}, p.Model())
+}
+
+func TestRerank_RequestShapeUsageAndCost(t *testing.T) {
+	var got request
+	cost := 2.0
+	p := newTestServer(t, &cost, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/rerank", r.URL.Path)
+		assert.Equal(t, "Bearer COHERESECRET", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`{
+			"id": "cohere-req-1",
+			"results": [
+				{"index": 1, "relevance_score": 0.02},
+				{"index": 0, "relevance_score": 0.91}
+			],
+			"meta": {
+				"api_version": {"version": "2", "is_experimental": false},
+				"billed_units": {"search_units": 1},
+				"tokens": {"input_tokens": 500000}
+			}
+		}`))
+	})
+
+	resp, err := p.Rerank(context.Background(), rerank.Request{Query: "payroll adjustment", Documents: testDocs, TopN: 2})
+	require.NoError(t, err)
+	assert.Equal(t, request{Model: testModel, Query: "payroll adjustment", Documents: []string{testDocs[0].Text, testDocs[1].Text}, TopN: 2}, got)
+
+	assert.Equal(t, "cohere", resp.Provider)
+	assert.Equal(t, "cohere-req-1", resp.RequestID)
+	require.Len(t, resp.Results, 2)
+	assert.Equal(t, "chunk-001", resp.Results[0].DocumentID)
+	assert.Equal(t, 1, resp.Results[0].Rank)
+	require.NotNil(t, resp.Usage)
+	assert.Equal(t, rerank.Usage{InputTokens: 500000, TotalTokens: 500000, SearchUnits: 1}, *resp.Usage)
+	require.NotNil(t, resp.Cost)
+	assert.InDelta(t, 1.0, *resp.Cost, 1e-9)
+}
+
+func TestRerank_SearchUnitsOnlyLeaveCostUnknown(t *testing.T) {
+	cost := 2.0
+	p := newTestServer(t, &cost, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"results":[{"index":0,"relevance_score":0.5}],"meta":{"billed_units":{"search_units":1}}}`))
+	})
+	resp, err := p.Rerank(context.Background(), rerank.Request{Query: "q", Documents: testDocs, TopN: 1})
+	require.NoError(t, err)
+	require.NotNil(t, resp.Usage)
+	assert.Equal(t, 1, resp.Usage.SearchUnits)
+	assert.Nil(t, resp.Cost)
+}
+
+func TestRerank_Non2xxDoesNotLeakKeyOrBody(t *testing.T) {
+	p := newTestServer(t, nil, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"message":"invalid api token BODYSECRET"}`))
+	})
+	_, err := p.Rerank(context.Background(), rerank.Request{Query: "q", Documents: testDocs, TopN: 1})
+	require.ErrorIs(t, err, rerank.ErrUnavailable)
+	assert.Contains(t, err.Error(), "status 401")
+	assert.NotContains(t, err.Error(), "BODYSECRET")
+	assert.NotContains(t, err.Error(), "COHERESECRET")
+}
+
+func TestRerank_RejectsUnknownFields(t *testing.T) {
+	p := newTestServer(t, nil, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"results":[{"index":0,"relevance_score":0.5}],"surprise":1}`))
+	})
+	_, err := p.Rerank(context.Background(), rerank.Request{Query: "q", Documents: testDocs, TopN: 1})
+	require.ErrorIs(t, err, rerank.ErrInvalidResponse)
+}
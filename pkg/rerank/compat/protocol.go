@@ -0,0 +1,44 @@
+package compat
+
+import "github.com/go-go-golems/geppetto/pkg/rerank/rerankhttp"
+
+// openAIRequest is the OpenAI-style /rerank request wire DTO.
+type openAIRequest struct {
+	Model     string   `json:"model"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n"`
+}
+
+// openAIResponse is the OpenAI-style /rerank response wire DTO. The optional
+// fields cover what vLLM, Infinity, and LocalAI add around results.
+type openAIResponse struct {
+	ID      string            `json:"id,omitempty"`
+	Object  string            `json:"object,omitempty"`
+	Created int64             `json:"created,omitempty"`
+	Model   string            `json:"model,omitempty"`
+	Usage   *openAIUsage      `json:"usage,omitempty"`
+	Results []rerankhttp.Item `json:"results"`
+}
+
+// openAIUsage mirrors the prompt/total token usage object.
+type openAIUsage struct {
+	PromptTokens int `json:"prompt_tokens,omitempty"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// teiRequest is the text-embeddings-inference /rerank request wire DTO.
+type teiRequest struct {
+	Query      string   `json:"query"`
+	Texts      []string `json:"texts"`
+	RawScores  bool     `json:"raw_scores"`
+	ReturnText bool     `json:"return_text"`
+}
+
+// teiItem is one element of the TEI /rerank response array. Pointer fields
+// distinguish missing from zero.
+type teiItem struct {
+	Index *int     `json:"index"`
+	Score *float64 `json:"score"`
+	Text  *string  `json:"text,omitempty"`
+}
@@ -0,0 +1,224 @@
+// Package compat implements strict adapters for self-hosted /rerank servers
+// that speak one of two de-facto schemas:
+//
+//   - SchemaOpenAI: {model, query, documents, top_n} -> {results: [{index,
+//     relevance_score}]}, as served by vLLM, Infinity, LocalAI and similar
+//     OpenAI-style gateways;
+//   - SchemaTEI: {query, texts} -> [{index, score}], as served by Hugging Face
+//     text-embeddings-inference.
+package compat
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/rerank"
+	"github.com/go-go-golems/geppetto/pkg/rerank/rerankhttp"
+	"github.com/go-go-golems/geppetto/pkg/security"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/ratelimit"
+)
+
+// Schema selects the wire format of a compatible /rerank endpoint.
+type Schema string
+
+const (
+	// SchemaOpenAI is the OpenAI-style {model, query, documents, top_n} schema.
+	SchemaOpenAI Schema = "openai"
+	// SchemaTEI is the text-embeddings-inference {query, texts} schema.
+	SchemaTEI Schema = "tei"
+)
+
+const (
+	// ProviderNameOpenAI is the provider identity reported for SchemaOpenAI.
+	ProviderNameOpenAI = "openai-compatible"
+	// ProviderNameTEI is the provider identity reported for SchemaTEI.
+	ProviderNameTEI = "tei"
+
+	openAIPath = "v1/rerank"
+	teiPath    = "rerank"
+)
+
+// Options configures a compatible rerank provider.
+//
+// BaseURL and Model are required; SchemaTEI servers host a single model, so
+// Model only identifies it in responses. Schema defaults to SchemaOpenAI.
+// Path overrides the schema's default route ("v1/rerank" or "rerank").
+// APIKey is optional and sent as a bearer token when set. CostPerMTokens is
+// applied to reported tokens; when nil, Cost is nil (unknown).
+type Options struct {
+	Schema           Schema
+	BaseURL          string
+	Path             string
+	APIKey           string
+	Model            string
+	HTTPClient       *http.Client
+	OutboundURL      security.OutboundURLOptions
+	MaxRequestBytes  int64
+	MaxResponseBytes int64
+	CostPerMTokens   *float64
+}
+
+// Provider is a compatible rerank provider.
+type Provider struct {
+	http           *rerankhttp.Client
+	schema         Schema
+	name           string
+	apiKey         string
+	model          string
+	costPerMTokens *float64
+}
+
+var _ rerank.Provider = (*Provider)(nil)
+
+// New constructs a compatible rerank provider.
+func New(options Options) (*Provider, error) {
+	schema := options.Schema
+	if schema == "" {
+		schema = SchemaOpenAI
+	}
+	var name, path string
+	switch schema {
+	case SchemaOpenAI:
+		name, path = ProviderNameOpenAI, openAIPath
+	case SchemaTEI:
+		name, path = ProviderNameTEI, teiPath
+	default:
+		return nil, fmt.Errorf("compat rerank schema %q is not supported: %w", schema, rerank.ErrInvalidRequest)
+	}
+	if options.Path != "" {
+		path = options.Path
+	}
+
+	if strings.TrimSpace(options.BaseURL) == "" {
+		return nil, fmt.Errorf("%s base URL is required: %w", name, rerank.ErrInvalidRequest)
+	}
+	model := strings.TrimSpace(options.Model)
+	if model == "" {
+		return nil, fmt.Errorf("%s model is required: %w", name, rerank.ErrInvalidRequest)
+	}
+
+	client, err := rerankhttp.New(rerankhttp.Options{
+		Name:             name,
+		BaseURL:          options.BaseURL,
+		Path:             path,
+		HTTPClient:       options.HTTPClient,
+		OutboundURL:      options.OutboundURL,
+		MaxRequestBytes:  options.MaxRequestBytes,
+		MaxResponseBytes: options.MaxResponseBytes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Provider{
+		http:           client,
+		schema:         schema,
+		name:           name,
+		apiKey:         strings.TrimSpace(options.APIKey),
+		model:          model,
+		costPerMTokens: options.CostPerMTokens,
+	}, nil
+}
+
+// Model returns the provider's configured provider/model identity.
+func (p *Provider) Model() rerank.Model {
+	return rerank.Model{Provider: p.name, Name: p.model}
+}
+
+// Rerank scores and reorders documents via the configured /rerank endpoint.
+func (p *Provider) Rerank(ctx context.Context, in rerank.Request) (rerank.Response, error) {
+	started := time.Now()
+
+	providerModel := p.Model()
+	if err := rerank.ValidateRequest(in, providerModel); err != nil {
+		return rerank.Response{}, err
+	}
+
+	effectiveModel := rerank.ResolveModel(in, providerModel)
+	documents := rerankhttp.DocumentTexts(in)
+	ctx = ratelimit.WithTokenEstimate(ctx, ratelimit.EstimateTokens(in.Query)+ratelimit.EstimateTokens(documents...))
+
+	var (
+		results   []rerank.Result
+		usage     *rerank.Usage
+		requestID string
+		err       error
+	)
+	switch p.schema {
+	case SchemaTEI:
+		results, requestID, err = p.rerankTEI(ctx, in, documents)
+	case SchemaOpenAI:
+		results, usage, requestID, err = p.rerankOpenAI(ctx, in, effectiveModel, documents)
+	}
+	if err != nil {
+		return rerank.Response{}, err
+	}
+	durationMs := time.Since(started).Milliseconds()
+
+	return rerank.Response{
+		Provider:   p.name,
+		Model:      effectiveModel,
+		Results:    results,
+		Usage:      usage,
+		Cost:       rerankhttp.InputCost(usage, p.costPerMTokens),
+		RequestID:  requestID,
+		DurationMs: &durationMs,
+	}, nil
+}
+
+func (p *Provider) rerankOpenAI(ctx context.Context, in rerank.Request, model string, documents []string) ([]rerank.Result, *rerank.Usage, string, error) {
+	var wire openAIResponse
+	header, err := p.http.PostJSON(ctx, openAIRequest{
+		Model:     model,
+		Query:     in.Query,
+		Documents: documents,
+		TopN:      in.TopN,
+	}, rerankhttp.BearerHeader(p.apiKey), &wire)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	results, err := rerank.ValidateAndMapResults(in.Documents, in.TopN, rerankhttp.ToRawResults(wire.Results))
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if wire.Model != "" && wire.Model != model {
+		return nil, nil, "", fmt.Errorf("%s response model %q does not match effective model %q: %w",
+			p.name, wire.Model, model, rerank.ErrInvalidResponse)
+	}
+	var usage *rerank.Usage
+	if wire.Usage != nil {
+		usage = &rerank.Usage{InputTokens: wire.Usage.PromptTokens, TotalTokens: wire.Usage.TotalTokens}
+	}
+	requestID := rerankhttp.RequestID(header)
+	if requestID == "" {
+		requestID = wire.ID
+	}
+	return results, usage, requestID, nil
+}
+
+// rerankTEI scores every document (TEI has no top_n) and keeps the TopN best
+// after validation, so cardinality is still checked against the submission.
+func (p *Provider) rerankTEI(ctx context.Context, in rerank.Request, documents []string) ([]rerank.Result, string, error) {
+	var wire []teiItem
+	header, err := p.http.PostJSON(ctx, teiRequest{
+		Query:      in.Query,
+		Texts:      documents,
+		RawScores:  false,
+		ReturnText: false,
+	}, rerankhttp.BearerHeader(p.apiKey), &wire)
+	if err != nil {
+		return nil, "", err
+	}
+	raw := make([]rerank.RawResult, 0, len(wire))
+	for _, it := range wire {
+		raw = append(raw, rerankhttp.RawResult(it.Index, it.Score))
+	}
+	results, err := rerank.ValidateAndMapResults(in.Documents, len(in.Documents), raw)
+	if err != nil {
+		return nil, "", err
+	}
+	return results[:in.TopN], rerankhttp.RequestID(header), nil
+}
@@ -0,0 +1,107 @@
+package compat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/rerank"
+	"github.com/go-go-golems/geppetto/pkg/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testModel = "BAAI/bge-reranker-base"
+
+var testDocs = []rerank.Document{
+	{ID: "a", Text: "Deep learning is a subset of machine learning."},
+	{ID: "b", Text: "Paris is the capital of France."},
+	{ID: "c", Text: "Neural networks learn representations."},
+}
+
+func newTestServer(t *testing.T, options Options, handler http.HandlerFunc) *Provider {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	options.BaseURL = srv.URL
+	options.Model = testModel
+	options.OutboundURL = security.OutboundURLOptions{AllowHTTP: true, AllowLocalNetworks: true}
+	p, err := New(options)
+	require.NoError(t, err)
+	return p
+}
+
+func TestNew_Validation(t *testing.T) {
+	_, err := New(Options{Schema: "bogus", BaseURL: "https://rerank.example.com", Model: testModel})
+	require.ErrorIs(t, err, rerank.ErrInvalidRequest)
+
+	_, err = New(Options{Schema: SchemaTEI, Model: testModel})
+	require.ErrorIs(t, err, rerank.ErrInvalidRequest)
+	assert.Contains(t, err.Error(), "tei base URL is required")
+
+	_, err = New(Options{BaseURL: "http://127.0.0.1:8080", Model: testModel})
+	require.ErrorIs(t, err, rerank.ErrInvalidRequest)
+	assert.Contains(t, err.Error(), "outbound URL policy")
+}
+
+func TestRerank_OpenAISchema(t *testing.T) {
+	var got openAIRequest
+	cost := 1.0
+	p := newTestServer(t, Options{APIKey: "k", CostPerMTokens: &cost}, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/rerank", r.URL.Path)
+		assert.Equal(t, "Bearer k", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`{
+			"id": "rerank-42",
+			"model": "` + testModel + `",
+			"usage": {"total_tokens": 1000},
+			"results": [
+				{"index": 0, "document": {"text": "Deep learning"}, "relevance_score": 0.99},
+				{"index": 2, "document": {"text": "Neural networks"}, "relevance_score": 0.5}
+			]
+		}`))
+	})
+
+	resp, err := p.Rerank(context.Background(), rerank.Request{Query: "what is deep learning?", Documents: testDocs, TopN: 2})
+	require.NoError(t, err)
+	assert.Equal(t, testModel, got.Model)
+	assert.Equal(t, 2, got.TopN)
+	assert.Equal(t, "openai-compatible", resp.Provider)
+	assert.Equal(t, "rerank-42", resp.RequestID)
+	require.Len(t, resp.Results, 2)
+	assert.Equal(t, "a", resp.Results[0].DocumentID)
+	assert.Equal(t, "c", resp.Results[1].DocumentID)
+	require.NotNil(t, resp.Cost)
+	assert.InDelta(t, 0.001, *resp.Cost, 1e-12)
+}
+
+func TestRerank_TEISchemaKeepsTopN(t *testing.T) {
+	var got teiRequest
+	p := newTestServer(t, Options{Schema: SchemaTEI}, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rerank", r.URL.Path)
+		assert.Empty(t, r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`[{"index":0,"score":0.98},{"index":2,"score":0.61},{"index":1,"score":0.0001}]`))
+	})
+
+	resp, err := p.Rerank(context.Background(), rerank.Request{Query: "what is deep learning?", Documents: testDocs, TopN: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{testDocs[0].Text, testDocs[1].Text, testDocs[2].Text}, got.Texts)
+	assert.Equal(t, "tei", resp.Provider)
+	require.Len(t, resp.Results, 2)
+	assert.Equal(t, "a", resp.Results[0].DocumentID)
+	assert.Equal(t, "c", resp.Results[1].DocumentID)
+	assert.Equal(t, 2, resp.Results[1].Rank)
+	assert.Nil(t, resp.Usage)
+	assert.Nil(t, resp.Cost)
+}
+
+func TestRerank_TEIRejectsPartialScores(t *testing.T) {
+	p := newTestServer(t, Options{Schema: SchemaTEI}, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[{"index":0,"score":0.98}]`))
+	})
+	_, err := p.Rerank(context.Background(), rerank.Request{Query: "q", Documents: testDocs, TopN: 1})
+	require.ErrorIs(t, err, rerank.ErrInvalidResponse)
+}
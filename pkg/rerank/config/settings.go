@@ -17,15 +17,27 @@ import (
 // like embeddings. This keeps profile composition consistent across all
 // model-service primitives.
 type RerankConfig struct {
-	// Type specifies the provider type (e.g. "llamacpp"). Currently only
-	// "llamacpp" is supported.
+	// Type specifies the provider type: "llamacpp", "cohere", "jina",
+	// "voyage", "openai-compatible", "tei", or "llm".
 	Type string `yaml:"type,omitempty" glazed:"rerank-type"`
-	// Engine specifies the model to use for reranking.
+	// Engine specifies the model to use for reranking. For the "llm" type it
+	// is optional and overrides the chat engine.
 	Engine string `yaml:"engine,omitempty" glazed:"rerank-engine"`
 	// MaxRequestBytes bounds the encoded request body. Defaults to 2 MiB.
 	MaxRequestBytes int64 `yaml:"max_request_bytes,omitempty" glazed:"rerank-max-request-bytes"`
 	// MaxResponseBytes bounds the response body. Defaults to 1 MiB.
 	MaxResponseBytes int64 `yaml:"max_response_bytes,omitempty" glazed:"rerank-max-response-bytes"`
+
+	// LLMMode selects "pointwise" (default) or "listwise" scoring for the
+	// "llm" type, which reranks with the profile's chat engine.
+	LLMMode string `yaml:"llm_mode,omitempty" glazed:"rerank-llm-mode"`
+	// LLMBatchSize is the documents per pointwise call (default 10) or the
+	// listwise window (default 20).
+	LLMBatchSize int `yaml:"llm_batch_size,omitempty" glazed:"rerank-llm-batch-size"`
+	// LLMMaxConcurrency bounds concurrent pointwise calls. Defaults to 4.
+	LLMMaxConcurrency int `yaml:"llm_max_concurrency,omitempty" glazed:"rerank-llm-max-concurrency"`
+	// LLMNormalization is "scale" (default), "minmax", or "none".
+	LLMNormalization string `yaml:"llm_normalization,omitempty" glazed:"rerank-llm-normalization"`
 }
 
 func NewRerankConfig() (*RerankConfig, error) {
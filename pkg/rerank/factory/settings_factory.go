@@ -1,23 +1,52 @@
 // Package factory constructs rerank providers from RerankConfig or
 // InferenceSettings, breaking the import cycle between pkg/rerank (the core
-// types) and pkg/rerank/llamacpp (the adapter).
+// types) and the adapters (llamacpp, cohere, jina, voyage, compat, llm).
 package factory
 
 import (
 	"fmt"
 	"strings"
 
+	enginefactory "github.com/go-go-golems/geppetto/pkg/inference/engine/factory"
 	"github.com/go-go-golems/geppetto/pkg/rerank"
+	"github.com/go-go-golems/geppetto/pkg/rerank/cohere"
+	"github.com/go-go-golems/geppetto/pkg/rerank/compat"
 	"github.com/go-go-golems/geppetto/pkg/rerank/config"
+	"github.com/go-go-golems/geppetto/pkg/rerank/jina"
 	"github.com/go-go-golems/geppetto/pkg/rerank/llamacpp"
+	"github.com/go-go-golems/geppetto/pkg/rerank/llm"
+	"github.com/go-go-golems/geppetto/pkg/rerank/voyage"
 	"github.com/go-go-golems/geppetto/pkg/security"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/providerhttp"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/ratelimit"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
 )
 
 const (
-	rerankProviderLlamaCpp = "llamacpp"
+	rerankProviderLlamaCpp         = "llamacpp"
+	rerankProviderCohere           = "cohere"
+	rerankProviderJina             = "jina"
+	rerankProviderVoyage           = "voyage"
+	rerankProviderOpenAICompatible = "openai-compatible"
+	rerankProviderTEI              = "tei"
+	rerankProviderLLM              = "llm"
+
+	// rerankAPIKey is the generic credential key. Hosted providers prefer
+	// their own "<type>-api-key" and fall back to it; compatible servers use
+	// it when they require a bearer token.
+	rerankAPIKey = "rerank-api-key"
 )
 
+var supportedRerankProviders = []string{
+	rerankProviderLlamaCpp,
+	rerankProviderCohere,
+	rerankProviderJina,
+	rerankProviderVoyage,
+	rerankProviderOpenAICompatible,
+	rerankProviderTEI,
+	rerankProviderLLM,
+}
+
 // ProviderFactory constructs rerank providers from configuration.
 type ProviderFactory interface {
 	// NewProvider constructs the provider described by the resolved settings.
@@ -34,6 +63,9 @@ type SettingsFactory struct {
 	api    *settings.APISettings
 	client *settings.ClientSettings
 	model  *settings.ModelInfo
+	// inference is the full profile, kept for the "llm" type, which builds a
+	// chat engine from it. Only NewSettingsFactoryFromInferenceSettings sets it.
+	inference *settings.InferenceSettings
 }
 
 var _ ProviderFactory = &SettingsFactory{}
@@ -58,12 +90,14 @@ func NewSettingsFactoryFromInferenceSettings(s *settings.InferenceSettings) (*Se
 	if err := ValidateInferenceSettingsForRerank(s); err != nil {
 		return nil, err
 	}
-	return NewSettingsFactory(s.Rerank, s.API, s.Client, s.ModelInfo), nil
+	f := NewSettingsFactory(s.Rerank, s.API, s.Client, s.ModelInfo)
+	f.inference = s
+	return f, nil
 }
 
 // SupportedProviders returns the provider types this factory can construct.
 func (f *SettingsFactory) SupportedProviders() []string {
-	return []string{rerankProviderLlamaCpp}
+	return append([]string(nil), supportedRerankProviders...)
 }
 
 // NewProvider creates a rerank provider from the resolved configuration.
@@ -77,29 +111,87 @@ func (f *SettingsFactory) NewProvider() (rerank.Provider, error) {
 	if providerType == "" {
 		return nil, fmt.Errorf("no rerank type specified: %w", rerank.ErrInvalidRequest)
 	}
+	if providerType == rerankProviderLLM {
+		return f.newLLMProvider(engine)
+	}
 	if engine == "" {
 		return nil, fmt.Errorf("no rerank model specified: %w", rerank.ErrInvalidRequest)
 	}
 
+	httpClient, err := providerhttp.Client(f.client, ratelimit.NewKey(providerType, engine, f.resolveAPIKey(providerType)))
+	if err != nil {
+		return nil, fmt.Errorf("rerank http client: %w", err)
+	}
+	outbound := f.resolveOutboundURLOptions()
+	cost := f.resolveInputCostPerMTokens()
+
 	switch providerType {
 	case rerankProviderLlamaCpp:
 		baseURL, err := f.resolveBaseURL()
 		if err != nil {
 			return nil, err
 		}
-		outbound := f.resolveOutboundURLOptions()
-		httpClient, err := settings.EnsureHTTPClient(f.client)
+		return llamacpp.New(llamacpp.Options{
+			BaseURL:          baseURL,
+			Model:            engine,
+			HTTPClient:       httpClient,
+			OutboundURL:      outbound,
+			MaxRequestBytes:  f.config.MaxRequestBytes,
+			MaxResponseBytes: f.config.MaxResponseBytes,
+			CostPerMTokens:   cost,
+		})
+	case rerankProviderCohere:
+		return cohere.New(cohere.Options{
+			BaseURL:          f.optionalBaseURL(),
+			APIKey:           f.resolveAPIKey(providerType),
+			Model:            engine,
+			HTTPClient:       httpClient,
+			OutboundURL:      outbound,
+			MaxRequestBytes:  f.config.MaxRequestBytes,
+			MaxResponseBytes: f.config.MaxResponseBytes,
+			CostPerMTokens:   cost,
+		})
+	case rerankProviderJina:
+		return jina.New(jina.Options{
+			BaseURL:          f.optionalBaseURL(),
+			APIKey:           f.resolveAPIKey(providerType),
+			Model:            engine,
+			HTTPClient:       httpClient,
+			OutboundURL:      outbound,
+			MaxRequestBytes:  f.config.MaxRequestBytes,
+			MaxResponseBytes: f.config.MaxResponseBytes,
+			CostPerMTokens:   cost,
+		})
+	case rerankProviderVoyage:
+		return voyage.New(voyage.Options{
+			BaseURL:          f.optionalBaseURL(),
+			APIKey:           f.resolveAPIKey(providerType),
+			Model:            engine,
+			HTTPClient:       httpClient,
+			OutboundURL:      outbound,
+			MaxRequestBytes:  f.config.MaxRequestBytes,
+			MaxResponseBytes: f.config.MaxResponseBytes,
+			CostPerMTokens:   cost,
+		})
+	case rerankProviderOpenAICompatible, rerankProviderTEI:
+		baseURL, err := f.resolveBaseURL()
 		if err != nil {
-			return nil, fmt.Errorf("rerank http client: %w", err)
+			return nil, err
 		}
-		return llamacpp.New(llamacpp.Options{
+		schema := compat.SchemaOpenAI
+		if providerType == rerankProviderTEI {
+			schema = compat.SchemaTEI
+		}
+		return compat.New(compat.Options{
+			Schema:           schema,
 			BaseURL:          baseURL,
+			APIKey:           f.resolveAPIKey(providerType),
 			Model:            engine,
 			HTTPClient:       httpClient,
 			OutboundURL:      outbound,
 			MaxRequestBytes:  f.config.MaxRequestBytes,
 			MaxResponseBytes: f.config.MaxResponseBytes,
-			CostPerMTokens:   f.resolveInputCostPerMTokens(),
+			CostPerMTokens:   cost,
 		})
 	default:
 		return nil, fmt.Errorf("unsupported rerank provider type %q; supported values are %v: %w",
@@ -120,6 +212,62 @@ func (f *SettingsFactory) resolveBaseURL() (string, error) {
 	return baseURL, nil
 }
 
+// newLLMProvider builds the profile's chat engine, with engineOverride
+// replacing chat.engine when set, and wraps it in an LLM reranker.
+func (f *SettingsFactory) newLLMProvider(engineOverride string) (rerank.Provider, error) {
+	if f.inference == nil {
+		return nil, fmt.Errorf("llm rerank needs the profile's chat settings; construct the factory with NewSettingsFactoryFromInferenceSettings: %w", rerank.ErrInvalidRequest)
+	}
+	chat := f.inference.Clone()
+	if chat.Chat == nil {
+		return nil, fmt.Errorf("llm rerank needs inference_settings.chat: %w", rerank.ErrInvalidRequest)
+	}
+	if engineOverride != "" {
+		chat.Chat.Engine = &engineOverride
+	}
+	if chat.Chat.Engine == nil || strings.TrimSpace(*chat.Chat.Engine) == "" {
+		return nil, fmt.Errorf("llm rerank needs inference_settings.chat.engine or inference_settings.rerank.engine: %w", rerank.ErrInvalidRequest)
+	}
+	eng, err := enginefactory.NewEngineFromSettings(chat)
+	if err != nil {
+		return nil, fmt.Errorf("llm rerank chat engine: %w", err)
+	}
+	return llm.New(llm.Options{
+		Engine:         eng,
+		Model:          *chat.Chat.Engine,
+		Mode:           llm.Mode(strings.TrimSpace(f.config.LLMMode)),
+		BatchSize:      f.config.LLMBatchSize,
+		MaxConcurrency: f.config.LLMMaxConcurrency,
+		Normalization:  llm.Normalization(strings.TrimSpace(f.config.LLMNormalization)),
+		ModelInfo:      chat.ModelInfo,
+	})
+}
+
+// optionalBaseURL returns the "rerank-base-url" override for hosted providers,
+// or "" so the adapter uses its default hosted endpoint.
+func (f *SettingsFactory) optionalBaseURL() string {
+	if f.api == nil {
+		return ""
+	}
+	return strings.TrimSpace(f.api.BaseUrls["rerank-base-url"])
+}
+
+// resolveAPIKey returns the "<type>-api-key" credential, falling back to
+// "rerank-api-key". Missing keys are reported by the adapter.
+func (f *SettingsFactory) resolveAPIKey(providerType string) string {
+	return apiKeyFor(f.api, providerType)
+}
+
+func apiKeyFor(api *settings.APISettings, providerType string) string {
+	if api == nil {
+		return ""
+	}
+	if key := strings.TrimSpace(api.APIKeys[providerType+"-api-key"]); key != "" {
+		return key
+	}
+	return strings.TrimSpace(api.APIKeys[rerankAPIKey])
+}
+
 // resolveOutboundURLOptions builds the outbound URL policy from the API
 // AllowHTTP and AllowLocalNetworks maps under the "rerank" key.
 func (f *SettingsFactory) resolveOutboundURLOptions() security.OutboundURLOptions {
@@ -162,15 +310,27 @@ func ValidateInferenceSettingsForRerank(s *settings.InferenceSettings) error {
 	if providerType == "" {
 		return fmt.Errorf("selected profile is not rerank-capable: missing inference_settings.rerank.type: %w", rerank.ErrInvalidRequest)
 	}
-	if strings.TrimSpace(s.Rerank.Engine) == "" {
+	if strings.TrimSpace(s.Rerank.Engine) == "" && providerType != rerankProviderLLM {
 		return fmt.Errorf("selected profile is not rerank-capable: missing inference_settings.rerank.engine: %w", rerank.ErrInvalidRequest)
 	}
-	if providerType != rerankProviderLlamaCpp {
+	switch providerType {
+	case rerankProviderLlamaCpp, rerankProviderOpenAICompatible, rerankProviderTEI:
+		if s.API == nil || strings.TrimSpace(s.API.BaseUrls["rerank-base-url"]) == "" {
+			return fmt.Errorf("selected rerank profile has no rerank-base-url; set inference_settings.api.base_urls.rerank-base-url: %w", rerank.ErrInvalidRequest)
+		}
+	case rerankProviderCohere, rerankProviderJina, rerankProviderVoyage:
+		if apiKeyFor(s.API, providerType) == "" {
+			return fmt.Errorf("selected %s rerank profile has no API key; set inference_settings.api.api_keys.%s-api-key or %s: %w",
+				providerType, providerType, rerankAPIKey, rerank.ErrInvalidRequest)
+		}
+	case rerankProviderLLM:
+		hasChatEngine := s.Chat != nil && s.Chat.Engine != nil && strings.TrimSpace(*s.Chat.Engine) != ""
+		if !hasChatEngine && strings.TrimSpace(s.Rerank.Engine) == "" {
+			return fmt.Errorf("selected llm rerank profile has no chat engine; set inference_settings.chat.engine or inference_settings.rerank.engine: %w", rerank.ErrInvalidRequest)
+		}
+	default:
 		return fmt.Errorf("unsupported rerank provider type %q; supported values are %v: %w",
-			providerType, []string{rerankProviderLlamaCpp}, rerank.ErrInvalidRequest)
-	}
-	if s.API == nil || strings.TrimSpace(s.API.BaseUrls["rerank-base-url"]) == "" {
-		return fmt.Errorf("selected rerank profile has no rerank-base-url; set inference_settings.api.base_urls.rerank-base-url: %w", rerank.ErrInvalidRequest)
+			providerType, supportedRerankProviders, rerank.ErrInvalidRequest)
 	}
 	return nil
 }
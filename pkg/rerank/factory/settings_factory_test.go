@@ -4,10 +4,13 @@ import (
 	"testing"
 
 	"github.com/go-go-golems/geppetto/pkg/rerank"
+	"github.com/go-go-golems/geppetto/pkg/rerank/compat"
 	rerankconfig "github.com/go-go-golems/geppetto/pkg/rerank/config"
 	"github.com/go-go-golems/geppetto/pkg/rerank/llamacpp"
+	"github.com/go-go-golems/geppetto/pkg/rerank/llm"
 	"github.com/go-go-golems/geppetto/pkg/security"
 	aistepssettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
@@ -15,7 +18,7 @@ import (
 
 func TestSupportedProviders(t *testing.T) {
 	f := NewSettingsFactory(&rerankconfig.RerankConfig{}, nil, nil, nil)
-	assert.Equal(t, []string{"llamacpp"}, f.SupportedProviders())
+	assert.Equal(t, []string{"llamacpp", "cohere", "jina", "voyage", "openai-compatible", "tei", "llm"}, f.SupportedProviders())
 }
 
 func TestNewProvider_RejectsMissingConfig(t *testing.T) {
@@ -40,12 +43,66 @@ func TestNewProvider_RejectsMissingEngine(t *testing.T) {
 }
 
 func TestNewProvider_RejectsUnsupportedType(t *testing.T) {
-	f := NewSettingsFactory(&rerankconfig.RerankConfig{Type: "cohere", Engine: "m"}, nil, nil, nil)
+	f := NewSettingsFactory(&rerankconfig.RerankConfig{Type: "unknown-rerank", Engine: "m"}, nil, nil, nil)
 	_, err := f.NewProvider()
 	require.ErrorIs(t, err, rerank.ErrInvalidRequest)
 	assert.Contains(t, err.Error(), "unsupported rerank provider type")
 }
 
+func TestNewProvider_ConstructsHostedProviders(t *testing.T) {
+	for _, tc := range []struct {
+		providerType string
+		wantProvider string
+	}{
+		{"cohere", "cohere"},
+		{"jina", "jina"},
+		{"voyage", "voyage"},
+	} {
+		t.Run(tc.providerType, func(t *testing.T) {
+			api := aistepssettings.NewAPISettings()
+			cfg := &rerankconfig.RerankConfig{Type: tc.providerType, Engine: "rerank-model"}
+
+			_, err := NewSettingsFactory(cfg, api, nil, nil).NewProvider()
+			require.ErrorIs(t, err, rerank.ErrInvalidRequest)
+			assert.Contains(t, err.Error(), "API key is required")
+
+			api.APIKeys[tc.providerType+"-api-key"] = "secret"
+			provider, err := NewSettingsFactory(cfg, api, aistepssettings.NewClientSettings(), nil).NewProvider()
+			require.NoError(t, err)
+			assert.Equal(t, rerank.Model{Provider: tc.wantProvider, Name: "rerank-model"}, provider.Model())
+		})
+	}
+}
+
+func TestNewProvider_HostedProviderFallsBackToRerankAPIKey(t *testing.T) {
+	api := aistepssettings.NewAPISettings()
+	api.APIKeys["rerank-api-key"] = "secret"
+	f := NewSettingsFactory(&rerankconfig.RerankConfig{Type: "voyage", Engine: "rerank-2"}, api, nil, nil)
+	_, err := f.NewProvider()
+	require.NoError(t, err)
+}
+
+func TestNewProvider_ConstructsCompatibleProviders(t *testing.T) {
+	api := aistepssettings.NewAPISettings()
+	api.BaseUrls["rerank-base-url"] = "http://127.0.0.1:8080"
+	api.AllowHTTP["rerank"] = true
+	api.AllowLocalNetworks["rerank"] = true
+
+	for _, providerType := range []string{"openai-compatible", "tei"} {
+		f := NewSettingsFactory(&rerankconfig.RerankConfig{Type: providerType, Engine: "BAAI/bge-reranker-base"}, api, nil, nil)
+		provider, err := f.NewProvider()
+		require.NoError(t, err)
+		_, ok := provider.(*compat.Provider)
+		require.True(t, ok)
+		assert.Equal(t, providerType, provider.Model().Provider)
+	}
+
+	f := NewSettingsFactory(&rerankconfig.RerankConfig{Type: "tei", Engine: "m"}, aistepssettings.NewAPISettings(), nil, nil)
+	_, err := f.NewProvider()
+	require.ErrorIs(t, err, rerank.ErrInvalidRequest)
+	assert.Contains(t, err.Error(), "rerank-base-url")
+}
+
 func TestNewProvider_RejectsMissingBaseURL(t *testing.T) {
 	f := NewSettingsFactory(&rerankconfig.RerankConfig{Type: "llamacpp", Engine: "m"}, aistepssettings.NewAPISettings(), nil, nil)
 	_, err := f.NewProvider()
@@ -121,9 +178,21 @@ func TestValidateInferenceSettingsForRerank_Errors(t *testing.T) {
 		Rerank: &rerankconfig.RerankConfig{Type: "llamacpp"},
 	}), rerank.ErrInvalidRequest)
 
+	require.ErrorIs(t, ValidateInferenceSettingsForRerank(&aistepssettings.InferenceSettings{
+		Rerank: &rerankconfig.RerankConfig{Type: "unknown-rerank", Engine: "m"},
+	}), rerank.ErrInvalidRequest)
+
+	// Hosted providers need an API key instead of a base URL.
 	require.ErrorIs(t, ValidateInferenceSettingsForRerank(&aistepssettings.InferenceSettings{
 		Rerank: &rerankconfig.RerankConfig{Type: "cohere", Engine: "m"},
+		API:    aistepssettings.NewAPISettings(),
 	}), rerank.ErrInvalidRequest)
+	withKey := aistepssettings.NewAPISettings()
+	withKey.APIKeys["cohere-api-key"] = "secret"
+	require.NoError(t, ValidateInferenceSettingsForRerank(&aistepssettings.InferenceSettings{
+		Rerank: &rerankconfig.RerankConfig{Type: "cohere", Engine: "m"},
+		API:    withKey,
+	}))
 
 	api := aistepssettings.NewAPISettings()
 	// No rerank-base-url set.
@@ -156,6 +225,64 @@ func TestNewSettingsFactoryFromInferenceSettings_ConstructsProvider(t *testing.T
 	require.True(t, ok)
 }
 
+func newLLMRerankSettings(t *testing.T) *aistepssettings.InferenceSettings {
+	t.Helper()
+	in, err := aistepssettings.NewInferenceSettings()
+	require.NoError(t, err)
+	apiType := types.ApiTypeOpenAI
+	chatEngine := "gpt-4o-mini"
+	in.Chat.ApiType = &apiType
+	in.Chat.Engine = &chatEngine
+	in.API.APIKeys["openai-api-key"] = "test-api-key"
+	in.Rerank = &rerankconfig.RerankConfig{Type: "llm", LLMMode: "listwise", LLMBatchSize: 8}
+	return in
+}
+
+func TestNewSettingsFactoryFromInferenceSettings_ConstructsLLMProvider(t *testing.T) {
+	in := newLLMRerankSettings(t)
+	f, err := NewSettingsFactoryFromInferenceSettings(in)
+	require.NoError(t, err)
+	provider, err := f.NewProvider()
+	require.NoError(t, err)
+	_, ok := provider.(*llm.Provider)
+	require.True(t, ok)
+	assert.Equal(t, rerank.Model{Provider: "llm", Name: "gpt-4o-mini"}, provider.Model())
+
+	// rerank.engine overrides the chat model without touching the profile.
+	in.Rerank.Engine = "gpt-4.1-nano"
+	f, err = NewSettingsFactoryFromInferenceSettings(in)
+	require.NoError(t, err)
+	provider, err = f.NewProvider()
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-4.1-nano", provider.Model().Name)
+	assert.Equal(t, "gpt-4o-mini", *in.Chat.Engine)
+}
+
+func TestNewProvider_LLMRequiresChatSettings(t *testing.T) {
+	f := NewSettingsFactory(&rerankconfig.RerankConfig{Type: "llm"}, nil, nil, nil)
+	_, err := f.NewProvider()
+	require.ErrorIs(t, err, rerank.ErrInvalidRequest)
+	assert.Contains(t, err.Error(), "NewSettingsFactoryFromInferenceSettings")
+
+	in := newLLMRerankSettings(t)
+	in.Rerank.LLMMode = "pairwise"
+	f, err = NewSettingsFactoryFromInferenceSettings(in)
+	require.NoError(t, err)
+	_, err = f.NewProvider()
+	require.ErrorIs(t, err, rerank.ErrInvalidRequest)
+}
+
+func TestValidateInferenceSettingsForRerank_LLM(t *testing.T) {
+	in := newLLMRerankSettings(t)
+	require.NoError(t, ValidateInferenceSettingsForRerank(in))
+
+	in.Chat.Engine = nil
+	require.ErrorIs(t, ValidateInferenceSettingsForRerank(in), rerank.ErrInvalidRequest)
+
+	in.Rerank.Engine = "gpt-4o-mini"
+	require.NoError(t, ValidateInferenceSettingsForRerank(in))
+}
+
 func TestNewSettingsFactoryFromInferenceSettings_RejectsMissingRerank(t *testing.T) {
 	in := &aistepssettings.InferenceSettings{}
 	_, err := NewSettingsFactoryFromInferenceSettings(in)
@@ -0,0 +1,27 @@
+package jina
+
+import "github.com/go-go-golems/geppetto/pkg/rerank/rerankhttp"
+
+// request is the Jina /v1/rerank request wire DTO. ReturnDocuments is always
+// false so document text is not echoed back.
+type request struct {
+	Model           string   `json:"model"`
+	Query           string   `json:"query"`
+	Documents       []string `json:"documents"`
+	TopN            int      `json:"top_n"`
+	ReturnDocuments bool     `json:"return_documents"`
+}
+
+// response is the Jina /v1/rerank response wire DTO.
+type response struct {
+	Model   string            `json:"model,omitempty"`
+	Object  string            `json:"object,omitempty"`
+	Usage   *usage            `json:"usage,omitempty"`
+	Results []rerankhttp.Item `json:"results"`
+}
+
+// usage mirrors the Jina token usage object.
+type usage struct {
+	PromptTokens int `json:"prompt_tokens,omitempty"`
+	TotalTokens  int `json:"total_tokens"`
+}
@@ -0,0 +1,48 @@
+// Package jina implements a strict Jina /v1/rerank adapter for the
+// transport-neutral rerank.Provider interface.
+package jina
+
+import (
+	"github.com/go-go-golems/geppetto/pkg/rerank"
+	"github.com/go-go-golems/geppetto/pkg/rerank/rerankhttp"
+)
+
+const (
+	// ProviderName is the provider identity reported in rerank.Response.
+	ProviderName = "jina"
+
+	// DefaultBaseURL is the hosted Jina API.
+	DefaultBaseURL = "https://api.jina.ai"
+
+	// rerankPath is the Jina reranking route appended to the base URL.
+	rerankPath = "v1/rerank"
+)
+
+// Options configures the Jina rerank provider.
+type Options = rerankhttp.HostedOptions
+
+// Provider is the Jina rerank provider.
+type Provider = rerankhttp.HostedProvider[response]
+
+var _ rerank.Provider = (*Provider)(nil)
+
+var api = rerankhttp.HostedAPI[response]{
+	Name:           ProviderName,
+	DefaultBaseURL: DefaultBaseURL,
+	Path:           rerankPath,
+	BuildRequest: func(model string, in rerank.Request, documents []string) any {
+		return request{Model: model, Query: in.Query, Documents: documents, TopN: in.TopN, ReturnDocuments: false}
+	},
+	MapResponse: func(resp *response) rerankhttp.Reply {
+		reply := rerankhttp.Reply{Items: resp.Results, Model: resp.Model}
+		if resp.Usage != nil {
+			reply.Usage = &rerank.Usage{InputTokens: resp.Usage.PromptTokens, TotalTokens: resp.Usage.TotalTokens}
+		}
+		return reply
+	},
+}
+
+// New constructs a Jina rerank provider.
+func New(options Options) (*Provider, error) {
+	return rerankhttp.NewHosted(api, options)
+}
@@ -0,0 +1,97 @@
+package jina
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/rerank"
+	"github.com/go-go-golems/geppetto/pkg/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testModel = "jina-reranker-v2-base-multilingual"
+
+var testDocs = []rerank.Document{
+	{ID: "a", Text: "Organic skincare for sensitive skin."},
+	{ID: "b", Text: "New makeup trends focus on bold colors."},
+	{ID: "c", Text: "Bio-Hautpflege für empfindliche Haut."},
+}
+
+func newTestServer(t *testing.T, cost *float64, handler http.HandlerFunc) *Provider {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	p, err := New(Options{
+		BaseURL:        srv.URL,
+		APIKey:         "JINASECRET",
+		Model:          testModel,
+		OutboundURL:    security.OutboundURLOptions{AllowHTTP: true, AllowLocalNetworks: true},
+		CostPerMTokens: cost,
+	})
+	require.NoError(t, err)
+	return p
+}
+
+func TestNew_RequiresAPIKey(t *testing.T) {
+	_, err := New(Options{Model: testModel})
+	require.ErrorIs(t, err, rerank.ErrInvalidRequest)
+	assert.Contains(t, err.Error(), "jina API key is required")
+}
+
+func TestRerank_RequestShapeUsageAndCost(t *testing.T) {
+	var got map[string]any
+	cost := 0.02
+	p := newTestServer(t, &cost, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/rerank", r.URL.Path)
+		assert.Equal(t, "Bearer JINASECRET", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Header().Set("X-Request-Id", "jina-req")
+		_, _ = w.Write([]byte(`{
+			"model": "` + testModel + `",
+			"usage": {"total_tokens": 1000000},
+			"results": [
+				{"index": 2, "relevance_score": 0.83},
+				{"index": 0, "relevance_score": 0.91}
+			]
+		}`))
+	})
+
+	resp, err := p.Rerank(context.Background(), rerank.Request{Query: "organic skincare", Documents: testDocs, TopN: 2})
+	require.NoError(t, err)
+	assert.Equal(t, testModel, got["model"])
+	assert.Equal(t, float64(2), got["top_n"])
+	assert.Equal(t, false, got["return_documents"])
+
+	assert.Equal(t, "jina", resp.Provider)
+	assert.Equal(t, "jina-req", resp.RequestID)
+	require.Len(t, resp.Results, 2)
+	assert.Equal(t, "a", resp.Results[0].DocumentID)
+	assert.Equal(t, "c", resp.Results[1].DocumentID)
+	require.NotNil(t, resp.Usage)
+	assert.Equal(t, 1000000, resp.Usage.TotalTokens)
+	require.NotNil(t, resp.Cost)
+	assert.InDelta(t, 0.02, *resp.Cost, 1e-9)
+}
+
+func TestRerank_AcceptsEchoedDocuments(t *testing.T) {
+	p := newTestServer(t, nil, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"results":[{"index":1,"document":{"text":"x"},"relevance_score":0.4}]}`))
+	})
+	resp, err := p.Rerank(context.Background(), rerank.Request{Query: "q", Documents: testDocs, TopN: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "b", resp.Results[0].DocumentID)
+	assert.Nil(t, resp.Cost)
+}
+
+func TestRerank_RejectsResponseModelMismatch(t *testing.T) {
+	p := newTestServer(t, nil, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"model":"other","results":[{"index":0,"relevance_score":0.5}]}`))
+	})
+	_, err := p.Rerank(context.Background(), rerank.Request{Query: "q", Documents: testDocs, TopN: 1})
+	require.ErrorIs(t, err, rerank.ErrInvalidResponse)
+	assert.Contains(t, err.Error(), "does not match effective model")
+}
@@ -1,5 +1,7 @@
 package llamacpp
 
+import "github.com/go-go-golems/geppetto/pkg/rerank/rerankhttp"
+
 // request is the llama.cpp /v1/rerank request wire DTO.
 //
 // Caller document IDs never enter the provider payload. The adapter retains a
@@ -16,10 +18,10 @@ type request struct {
 // Pointer fields distinguish a missing zero from a valid zero. The adapter
 // rejects responses where any result is missing index or relevance_score.
 type response struct {
-	Model   string `json:"model,omitempty"`
-	Object  string `json:"object,omitempty"`
-	Usage   *usage `json:"usage,omitempty"`
-	Results []item `json:"results"`
+	Model   string            `json:"model,omitempty"`
+	Object  string            `json:"object,omitempty"`
+	Usage   *usage            `json:"usage,omitempty"`
+	Results []rerankhttp.Item `json:"results"`
 }
 
 // usage mirrors the llama.cpp prompt/total token usage object.
@@ -27,9 +29,3 @@ type usage struct {
 	PromptTokens int `json:"prompt_tokens"`
 	TotalTokens  int `json:"total_tokens"`
 }
-
-// item is one scored result. Pointer fields distinguish missing from zero.
-type item struct {
-	Index          *int     `json:"index"`
-	RelevanceScore *float64 `json:"relevance_score"`
-}
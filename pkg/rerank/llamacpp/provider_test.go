@@ -273,14 +273,14 @@ func TestRerank_Non2xxDoesNotLeakBody(t *testing.T) {
 }
 
 func TestRerank_RejectsOversizedResponse(t *testing.T) {
-	p, _ := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+	_, srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		_, _ = w.Write([]byte(`{"results":[{"index":0,"relevance_score":1.0}]`))
 		// Pad well past the small limit.
 		_, _ = w.Write(padBytes(2000))
 	})
 	p2 := mustNewProvider(t, Options{
-		BaseURL:          p.baseURL,
+		BaseURL:          srv.URL,
 		Model:            testModel,
 		OutboundURL:      security.OutboundURLOptions{AllowHTTP: true, AllowLocalNetworks: true},
 		MaxResponseBytes: 100,
@@ -291,11 +291,11 @@ func TestRerank_RejectsOversizedResponse(t *testing.T) {
 }
 
 func TestRerank_RejectsOversizedRequest(t *testing.T) {
-	p, _ := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+	_, srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
 		_, _ = w.Write([]byte(`{"results":[{"index":0,"relevance_score":1.0}]}`))
 	})
 	p2 := mustNewProvider(t, Options{
-		BaseURL:         p.baseURL,
+		BaseURL:         srv.URL,
 		Model:           testModel,
 		OutboundURL:     security.OutboundURLOptions{AllowHTTP: true, AllowLocalNetworks: true},
 		MaxRequestBytes: 16,
@@ -387,11 +387,11 @@ func TestRerank_CostIsNilWithoutPricing(t *testing.T) {
 
 func TestRerank_CostIsComputedWithPricing(t *testing.T) {
 	rate := 0.0 // explicitly free/local
-	p, _ := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+	_, srv := newTestServer(t, func(w http.ResponseWriter, _ *http.Request) {
 		_, _ = w.Write([]byte(`{"usage":{"prompt_tokens":96,"total_tokens":96},"results":[{"index":0,"relevance_score":1.0}]}`))
 	})
 	p2 := mustNewProvider(t, Options{
-		BaseURL:        p.baseURL,
+		BaseURL:        srv.URL,
 		Model:          testModel,
 		OutboundURL:    security.OutboundURLOptions{AllowHTTP: true, AllowLocalNetworks: true},
 		CostPerMTokens: &rate,
@@ -419,13 +419,6 @@ func TestRerank_InjectedClientIsNotMutated(t *testing.T) {
 	assert.Nil(t, injected.CheckRedirect, "injected client CheckRedirect was mutated in place")
 }
 
-func TestReadAtMost_Limits(t *testing.T) {
-	out, tooLarge, err := readAtMost(strings.NewReader("hello"), 3)
-	require.NoError(t, err)
-	assert.True(t, tooLarge)
-	assert.Len(t, out, 4) // limit+1
-}
-
 type failingReader struct{}
 
 func (failingReader) Read([]byte) (int, error) {
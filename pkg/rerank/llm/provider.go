@@ -0,0 +1,308 @@
+// Package llm implements rerank.Provider on top of any chat engine.Engine, for
+// deployments without a dedicated rerank model.
+//
+// Two strategies are available:
+//   - ModePointwise asks the model to grade each document's relevance on a
+//     0-10 scale via structured output. Documents are sent in batches of
+//     BatchSize that run with at most MaxConcurrency calls in flight.
+//   - ModeListwise asks the model to order a window of documents by relevance
+//     and slides that window from the bottom of the list to the top (as in
+//     RankGPT), so the best documents bubble up across windows. Windows are
+//     sequential by construction.
+//
+// Both strategies produce finite raw scores that Normalization maps to a
+// common range, so rerank.ValidateAndMapResults, SortResults, and AssignRanks
+// apply unchanged. Caller document IDs never enter prompts; documents are
+// identified by batch-local positions.
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/inference/extract"
+	"github.com/go-go-golems/geppetto/pkg/rerank"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+)
+
+const (
+	// ProviderName is the provider identity reported in rerank.Response.
+	ProviderName = "llm"
+
+	// MaxPointwiseScore is the top of the pointwise grading scale.
+	MaxPointwiseScore = 10
+
+	// DefaultPointwiseBatchSize is the number of documents graded per call.
+	DefaultPointwiseBatchSize = 10
+	// DefaultListwiseWindowSize is the number of documents ordered per call.
+	DefaultListwiseWindowSize = 20
+	// DefaultMaxConcurrency bounds concurrent pointwise calls.
+	DefaultMaxConcurrency = 4
+	// DefaultMaxDocumentChars truncates each document in prompts.
+	DefaultMaxDocumentChars = 2000
+)
+
+// Mode selects the scoring strategy.
+type Mode string
+
+const (
+	// ModePointwise grades every document independently on a 0-10 scale.
+	ModePointwise Mode = "pointwise"
+	// ModeListwise orders documents by relevance with a sliding window.
+	ModeListwise Mode = "listwise"
+)
+
+// Normalization maps raw scores before ranking.
+type Normalization string
+
+const (
+	// NormalizationScale divides by the largest possible raw score (10 for
+	// pointwise, the document count for listwise), giving [0, 1].
+	NormalizationScale Normalization = "scale"
+	// NormalizationMinMax maps the lowest observed score to 0 and the highest
+	// to 1. When all scores are equal they all become 1.
+	NormalizationMinMax Normalization = "minmax"
+	// NormalizationNone keeps raw scores: 0-10 for pointwise, and n for the
+	// best down to 1 for the worst of n listwise documents.
+	NormalizationNone Normalization = "none"
+)
+
+// Options configures the LLM rerank provider.
+//
+// Engine and Model are required; Model is the chat model identity reported by
+// the provider and checked against rerank.Request.Model. BatchSize is the
+// pointwise batch or listwise window size. ModelInfo prices calls whose
+// engine did not already report a cost; Response.Cost is nil unless every
+// call was priced. ExtractMode selects how structured output is enforced
+// (see extract.Mode) and defaults to extract.ModeAuto.
+type Options struct {
+	Engine           engine.Engine
+	Model            string
+	Mode             Mode
+	BatchSize        int
+	MaxConcurrency   int
+	MaxDocumentChars int
+	Normalization    Normalization
+	ExtractMode      extract.Mode
+	ModelInfo        *settings.ModelInfo
+}
+
+// Provider is the LLM rerank provider.
+type Provider struct {
+	engine           engine.Engine
+	model            string
+	mode             Mode
+	batchSize        int
+	maxConcurrency   int
+	maxDocumentChars int
+	normalization    Normalization
+	extractMode      extract.Mode
+	modelInfo        *settings.ModelInfo
+}
+
+var _ rerank.Provider = (*Provider)(nil)
+
+// New constructs an LLM rerank provider, applying defaults to zero options.
+func New(options Options) (*Provider, error) {
+	if options.Engine == nil {
+		return nil, fmt.Errorf("llm rerank engine is required: %w", rerank.ErrInvalidRequest)
+	}
+	model := strings.TrimSpace(options.Model)
+	if model == "" {
+		return nil, fmt.Errorf("llm rerank model is required: %w", rerank.ErrInvalidRequest)
+	}
+
+	p := &Provider{
+		engine:           options.Engine,
+		model:            model,
+		mode:             options.Mode,
+		batchSize:        options.BatchSize,
+		maxConcurrency:   options.MaxConcurrency,
+		maxDocumentChars: options.MaxDocumentChars,
+		normalization:    options.Normalization,
+		extractMode:      options.ExtractMode,
+		modelInfo:        options.ModelInfo,
+	}
+	switch p.mode {
+	case "":
+		p.mode = ModePointwise
+	case ModePointwise, ModeListwise:
+	default:
+		return nil, fmt.Errorf("llm rerank mode %q is not supported: %w", p.mode, rerank.ErrInvalidRequest)
+	}
+	switch p.normalization {
+	case "":
+		p.normalization = NormalizationScale
+	case NormalizationScale, NormalizationMinMax, NormalizationNone:
+	default:
+		return nil, fmt.Errorf("llm rerank normalization %q is not supported: %w", p.normalization, rerank.ErrInvalidRequest)
+	}
+	if p.batchSize < 0 || p.maxConcurrency < 0 || p.maxDocumentChars < 0 {
+		return nil, fmt.Errorf("llm rerank batch size, concurrency and document chars must not be negative: %w", rerank.ErrInvalidRequest)
+	}
+	if p.batchSize == 0 {
+		p.batchSize = DefaultPointwiseBatchSize
+		if p.mode == ModeListwise {
+			p.batchSize = DefaultListwiseWindowSize
+		}
+	}
+	if p.mode == ModeListwise && p.batchSize < 2 {
+		return nil, fmt.Errorf("llm rerank listwise window must hold at least 2 documents: %w", rerank.ErrInvalidRequest)
+	}
+	if p.maxConcurrency == 0 {
+		p.maxConcurrency = DefaultMaxConcurrency
+	}
+	if p.maxDocumentChars == 0 {
+		p.maxDocumentChars = DefaultMaxDocumentChars
+	}
+	if p.extractMode == "" {
+		p.extractMode = extract.ModeAuto
+	}
+	return p, nil
+}
+
+// Model returns the provider's configured provider/model identity.
+func (p *Provider) Model() rerank.Model {
+	return rerank.Model{Provider: ProviderName, Name: p.model}
+}
+
+// Rerank scores every document with the chat engine, normalizes the scores,
+// and returns the TopN best.
+func (p *Provider) Rerank(ctx context.Context, in rerank.Request) (rerank.Response, error) {
+	started := time.Now()
+
+	providerModel := p.Model()
+	if err := rerank.ValidateRequest(in, providerModel); err != nil {
+		return rerank.Response{}, err
+	}
+	effectiveModel := rerank.ResolveModel(in, providerModel)
+
+	acc := &accounting{}
+	var (
+		scores   []float64
+		maxScore float64
+		err      error
+	)
+	switch p.mode {
+	case ModeListwise:
+		scores, err = p.scoreListwise(ctx, in, acc)
+		maxScore = float64(len(in.Documents))
+	case ModePointwise:
+		scores, err = p.scorePointwise(ctx, in, acc)
+		maxScore = MaxPointwiseScore
+	}
+	if err != nil {
+		return rerank.Response{}, err
+	}
+
+	normalized := normalize(scores, p.normalization, maxScore)
+	raw := make([]rerank.RawResult, len(normalized))
+	for i, score := range normalized {
+		raw[i] = rerank.RawResult{Index: i, HasIndex: true, Score: score, HasScore: true}
+	}
+	results, err := rerank.ValidateAndMapResults(in.Documents, len(in.Documents), raw)
+	if err != nil {
+		return rerank.Response{}, err
+	}
+	durationMs := time.Since(started).Milliseconds()
+
+	return rerank.Response{
+		Provider:   ProviderName,
+		Model:      effectiveModel,
+		Results:    results[:in.TopN],
+		Usage:      acc.usage(),
+		Cost:       acc.cost(),
+		RequestID:  acc.requestID,
+		DurationMs: &durationMs,
+	}, nil
+}
+
+// accounting sums usage and cost over the calls of one Rerank.
+type accounting struct {
+	mu           sync.Mutex
+	calls        int
+	reported     bool
+	inputTokens  int
+	outputTokens int
+	priced       int
+	costUSD      float64
+	requestID    string
+}
+
+func (a *accounting) add(res *engine.InferenceResult, modelInfo *settings.ModelInfo) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.calls++
+	if res == nil {
+		return
+	}
+	if res.Cost == nil && modelInfo != nil {
+		priced := *res
+		settings.ApplyModelInfoCost(&priced, modelInfo)
+		res = &priced
+	}
+	if res.Usage != nil {
+		a.reported = true
+		a.inputTokens += res.Usage.InputTokens
+		a.outputTokens += res.Usage.OutputTokens
+	}
+	if res.Cost != nil {
+		a.priced++
+		a.costUSD += *res.Cost
+	}
+	if a.requestID == "" {
+		a.requestID = res.RequestID
+	}
+}
+
+func (a *accounting) usage() *rerank.Usage {
+	if !a.reported {
+		return nil
+	}
+	return &rerank.Usage{
+		InputTokens:  a.inputTokens,
+		OutputTokens: a.outputTokens,
+		TotalTokens:  a.inputTokens + a.outputTokens,
+	}
+}
+
+// cost is nil unless every call was priced, so a partial sum is never
+// mistaken for the full cost.
+func (a *accounting) cost() *float64 {
+	if a.calls == 0 || a.priced != a.calls {
+		return nil
+	}
+	cost := a.costUSD
+	return &cost
+}
+
+// normalize maps raw scores according to mode; maxScore is the largest
+// possible raw score.
+func normalize(scores []float64, mode Normalization, maxScore float64) []float64 {
+	out := make([]float64, len(scores))
+	switch mode {
+	case NormalizationNone:
+		copy(out, scores)
+	case NormalizationScale:
+		for i, s := range scores {
+			out[i] = s / maxScore
+		}
+	case NormalizationMinMax:
+		lo, hi := scores[0], scores[0]
+		for _, s := range scores[1:] {
+			lo, hi = min(lo, s), max(hi, s)
+		}
+		for i, s := range scores {
+			if hi == lo {
+				out[i] = 1
+				continue
+			}
+			out[i] = (s - lo) / (hi - lo)
+		}
+	}
+	return out
+}
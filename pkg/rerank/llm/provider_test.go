@@ -0,0 +1,289 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/budget"
+	"github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/inference/extract"
+	"github.com/go-go-golems/geppetto/pkg/rerank"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testModel = "gpt-4o-mini"
+
+// documentLine matches one prompt line; test documents carry their true
+// relevance as "rel=N".
+var documentLine = regexp.MustCompile(`(?m)^\[(\d+)\] .*rel=(\d+)`)
+
+// judgeEngine answers pointwise and listwise prompts from the relevance
+// embedded in each document, and records call and concurrency counts.
+type judgeEngine struct {
+	usage *turns.InferenceUsage
+	delay time.Duration
+	reply func(system, user string) string
+	err   error
+
+	mu       sync.Mutex
+	prompts  []string
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (e *judgeEngine) RunInference(ctx context.Context, t *turns.Turn) (*turns.Turn, error) {
+	out, _, err := e.RunInferenceWithResult(ctx, t)
+	return out, err
+}
+
+func (e *judgeEngine) RunInferenceWithResult(_ context.Context, t *turns.Turn) (*turns.Turn, *engine.InferenceResult, error) {
+	n := e.inFlight.Add(1)
+	defer e.inFlight.Add(-1)
+	for {
+		peak := e.peak.Load()
+		if n <= peak || e.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(e.delay)
+
+	system, _ := t.Blocks[0].Payload[turns.PayloadKeyText].(string)
+	user, _ := t.Blocks[1].Payload[turns.PayloadKeyText].(string)
+	e.mu.Lock()
+	e.prompts = append(e.prompts, user)
+	e.mu.Unlock()
+	if e.err != nil {
+		return nil, nil, e.err
+	}
+
+	reply := judge(system, user)
+	if e.reply != nil {
+		reply = e.reply(system, user)
+	}
+	turns.AppendBlock(t, turns.NewAssistantTextBlock(reply))
+	return t, &engine.InferenceResult{Provider: "openai", Model: testModel, Usage: e.usage, RequestID: "req-1"}, nil
+}
+
+func judge(system, user string) string {
+	type doc struct{ id, rel int }
+	var docs []doc
+	for _, m := range documentLine.FindAllStringSubmatch(user, -1) {
+		id, _ := strconv.Atoi(m[1])
+		rel, _ := strconv.Atoi(m[2])
+		docs = append(docs, doc{id, rel})
+	}
+	if system == ListwiseSystemPrompt {
+		sort.SliceStable(docs, func(i, j int) bool { return docs[i].rel > docs[j].rel })
+		ranking := make([]int, len(docs))
+		for i, d := range docs {
+			ranking[i] = d.id
+		}
+		raw, _ := json.Marshal(listwiseAnswer{Ranking: ranking})
+		return string(raw)
+	}
+	answer := pointwiseAnswer{}
+	for _, d := range docs {
+		answer.Scores = append(answer.Scores, pointwiseScore{ID: d.id, Score: float64(d.rel)})
+	}
+	raw, _ := json.Marshal(answer)
+	return string(raw)
+}
+
+func testDocs(rels ...int) []rerank.Document {
+	docs := make([]rerank.Document, len(rels))
+	for i, rel := range rels {
+		docs[i] = rerank.Document{ID: fmt.Sprintf("doc-%d", i), Text: fmt.Sprintf("document %d rel=%d", i, rel)}
+	}
+	return docs
+}
+
+func newProvider(t *testing.T, eng engine.Engine, options Options) *Provider {
+	t.Helper()
+	options.Engine = eng
+	options.Model = testModel
+	options.ExtractMode = extract.ModeNative
+	p, err := New(options)
+	require.NoError(t, err)
+	return p
+}
+
+func TestNew_Validation(t *testing.T) {
+	_, err := New(Options{Model: testModel})
+	require.ErrorIs(t, err, rerank.ErrInvalidRequest)
+
+	_, err = New(Options{Engine: &judgeEngine{}})
+	require.ErrorIs(t, err, rerank.ErrInvalidRequest)
+
+	_, err = New(Options{Engine: &judgeEngine{}, Model: testModel, Mode: "pairwise"})
+	require.ErrorIs(t, err, rerank.ErrInvalidRequest)
+
+	_, err = New(Options{Engine: &judgeEngine{}, Model: testModel, Mode: ModeListwise, BatchSize: 1})
+	require.ErrorIs(t, err, rerank.ErrInvalidRequest)
+
+	p, err := New(Options{Engine: &judgeEngine{}, Model: testModel, Mode: ModeListwise})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultListwiseWindowSize, p.batchSize)
+	assert.Equal(t, NormalizationScale, p.normalization)
+	assert.Equal(t, rerank.Model{Provider: "llm", Name: testModel}, p.Model())
+}
+
+func TestRerank_PointwiseBatchesConcurrently(t *testing.T) {
+	eng := &judgeEngine{delay: 20 * time.Millisecond}
+	p := newProvider(t, eng, Options{BatchSize: 2, MaxConcurrency: 2})
+
+	docs := testDocs(3, 9, 0, 7, 5, 10, 1)
+	resp, err := p.Rerank(context.Background(), rerank.Request{Query: "q", Documents: docs, TopN: 3})
+	require.NoError(t, err)
+
+	assert.Len(t, eng.prompts, 4)
+	assert.Equal(t, int32(2), eng.peak.Load())
+	for _, prompt := range eng.prompts {
+		assert.NotContains(t, prompt, "doc-")
+	}
+
+	assert.Equal(t, "llm", resp.Provider)
+	assert.Equal(t, testModel, resp.Model)
+	require.Len(t, resp.Results, 3)
+	assert.Equal(t, "doc-5", resp.Results[0].DocumentID)
+	assert.Equal(t, "doc-1", resp.Results[1].DocumentID)
+	assert.Equal(t, "doc-3", resp.Results[2].DocumentID)
+	assert.InDelta(t, 1.0, resp.Results[0].Score, 1e-9)
+	assert.InDelta(t, 0.9, resp.Results[1].Score, 1e-9)
+	assert.Equal(t, 3, resp.Results[2].Rank)
+	assert.Nil(t, resp.Usage)
+	assert.Nil(t, resp.Cost)
+}
+
+func TestRerank_PointwiseRejectsIncompleteOrUnknownScores(t *testing.T) {
+	for name, reply := range map[string]string{
+		"missing":  `{"scores":[{"id":0,"score":5}]}`,
+		"unknown":  `{"scores":[{"id":0,"score":5},{"id":7,"score":1}]}`,
+		"repeated": `{"scores":[{"id":0,"score":5},{"id":0,"score":1}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			eng := &judgeEngine{reply: func(string, string) string { return reply }}
+			p := newProvider(t, eng, Options{})
+			_, err := p.Rerank(context.Background(), rerank.Request{Query: "q", Documents: testDocs(1, 2), TopN: 2})
+			require.ErrorIs(t, err, rerank.ErrInvalidResponse)
+		})
+	}
+}
+
+func TestRerank_ListwiseSlidingWindowBubblesUpBest(t *testing.T) {
+	eng := &judgeEngine{}
+	p := newProvider(t, eng, Options{Mode: ModeListwise, BatchSize: 4})
+
+	docs := testDocs(1, 2, 3, 4, 9, 8)
+	resp, err := p.Rerank(context.Background(), rerank.Request{Query: "q", Documents: docs, TopN: 2})
+	require.NoError(t, err)
+
+	assert.Len(t, eng.prompts, 2)
+	require.Len(t, resp.Results, 2)
+	assert.Equal(t, "doc-4", resp.Results[0].DocumentID)
+	assert.Equal(t, "doc-5", resp.Results[1].DocumentID)
+	assert.InDelta(t, 1.0, resp.Results[0].Score, 1e-9)
+	assert.InDelta(t, 5.0/6.0, resp.Results[1].Score, 1e-9)
+}
+
+func TestRerank_ListwiseRepairsPartialPermutation(t *testing.T) {
+	eng := &judgeEngine{reply: func(string, string) string { return `{"ranking":[2,2,9]}` }}
+	p := newProvider(t, eng, Options{Mode: ModeListwise, Normalization: NormalizationNone})
+
+	resp, err := p.Rerank(context.Background(), rerank.Request{Query: "q", Documents: testDocs(0, 0, 0), TopN: 3})
+	require.NoError(t, err)
+	ids := []string{resp.Results[0].DocumentID, resp.Results[1].DocumentID, resp.Results[2].DocumentID}
+	assert.Equal(t, []string{"doc-2", "doc-0", "doc-1"}, ids)
+	assert.InDelta(t, 3.0, resp.Results[0].Score, 1e-9)
+
+	eng.reply = func(string, string) string { return `{"ranking":[7]}` }
+	_, err = p.Rerank(context.Background(), rerank.Request{Query: "q", Documents: testDocs(0, 0, 0), TopN: 3})
+	require.ErrorIs(t, err, rerank.ErrInvalidResponse)
+}
+
+func TestRerank_SumsUsageAndPricesCalls(t *testing.T) {
+	eng := &judgeEngine{usage: &turns.InferenceUsage{InputTokens: 100_000, OutputTokens: 1_000}}
+	info := &settings.ModelInfo{Cost: &settings.ModelCost{Input: 1, Output: 4}}
+	p := newProvider(t, eng, Options{BatchSize: 2, ModelInfo: info})
+
+	resp, err := p.Rerank(context.Background(), rerank.Request{Query: "q", Documents: testDocs(1, 2, 3), TopN: 1})
+	require.NoError(t, err)
+	require.NotNil(t, resp.Usage)
+	assert.Equal(t, 200_000, resp.Usage.InputTokens)
+	assert.Equal(t, 2_000, resp.Usage.OutputTokens)
+	assert.Equal(t, 202_000, resp.Usage.TotalTokens)
+	require.NotNil(t, resp.Cost)
+	assert.InDelta(t, 0.208, *resp.Cost, 1e-9)
+	assert.Equal(t, "req-1", resp.RequestID)
+}
+
+func TestRerank_EngineErrorsDoNotLeakText(t *testing.T) {
+	eng := &judgeEngine{err: errors.New("upstream echoed: SECRET QUERY")}
+	p := newProvider(t, eng, Options{})
+	_, err := p.Rerank(context.Background(), rerank.Request{Query: "SECRET QUERY", Documents: testDocs(1), TopN: 1})
+	require.ErrorIs(t, err, rerank.ErrUnavailable)
+	assert.NotContains(t, err.Error(), "SECRET")
+}
+
+func TestRerank_CallerFaultsAreInvalidRequests(t *testing.T) {
+	cases := map[string]error{
+		"unsupported schema": fmt.Errorf("gemini structured output: %w: $ref", engine.ErrStructuredOutputUnsupported),
+		"budget stop":        fmt.Errorf("run stopped: %w", budget.ErrBudgetExceeded),
+		"rejected key":       &engine.StatusError{StatusCode: 401, Message: "responses api error: status=401"},
+		"bad request":        fmt.Errorf("run: %w", &engine.StatusError{StatusCode: 400, Message: "invalid parameter"}),
+		"unknown model":      &engine.StatusError{StatusCode: 404, Message: "model not found"},
+	}
+	for name, engineErr := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := newProvider(t, &judgeEngine{err: engineErr}, Options{})
+			_, err := p.Rerank(context.Background(), rerank.Request{Query: "q", Documents: testDocs(1), TopN: 1})
+			require.ErrorIs(t, err, rerank.ErrInvalidRequest)
+			assert.NotErrorIs(t, err, rerank.ErrUnavailable)
+		})
+	}
+}
+
+func TestRerank_RetryableEngineErrorsAreUnavailable(t *testing.T) {
+	cases := map[string]error{
+		"rate limited":  &engine.StatusError{StatusCode: 429, Message: "rate limited"},
+		"server error":  &engine.StatusError{StatusCode: 502, Message: "bad gateway"},
+		"overloaded":    &engine.StatusError{StatusCode: 529, Message: "overloaded_error"},
+		"untyped 400":   errors.New("status=400 context_length_exceeded"),
+		"dns failure":   errors.New("dial tcp: lookup x: no such host"),
+		"tls failure":   errors.New("tls: failed to verify certificate: x509: certificate signed by unknown authority"),
+		"stream decode": errors.New("error decoding stream event: invalid character 'x'"),
+	}
+	for name, engineErr := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := newProvider(t, &judgeEngine{err: engineErr}, Options{})
+			_, err := p.Rerank(context.Background(), rerank.Request{Query: "q", Documents: testDocs(1), TopN: 1})
+			require.ErrorIs(t, err, rerank.ErrUnavailable)
+		})
+	}
+}
+
+func TestRedactCause(t *testing.T) {
+	docs := []rerank.Document{{ID: "a", Text: " SECRET DOC "}}
+	err := errors.New("status=500 echoed SECRET QUERY and SECRET DOC body=map[prompt:SECRET]")
+	got := redactCause(err, "SECRET QUERY", docs)
+	assert.Equal(t, "status=500 echoed [redacted] and [redacted] body=[redacted]", got)
+}
+
+func TestNormalize(t *testing.T) {
+	scores := []float64{2, 6, 4}
+	assert.Equal(t, []float64{0.2, 0.6, 0.4}, normalize(scores, NormalizationScale, 10))
+	assert.Equal(t, []float64{0, 1, 0.5}, normalize(scores, NormalizationMinMax, 10))
+	assert.Equal(t, []float64{1, 1}, normalize([]float64{3, 3}, NormalizationMinMax, 10))
+	assert.Equal(t, scores, normalize(scores, NormalizationNone, 10))
+}
@@ -0,0 +1,233 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/budget"
+	"github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/inference/extract"
+	"github.com/go-go-golems/geppetto/pkg/rerank"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"golang.org/x/sync/errgroup"
+)
+
+// PointwiseSystemPrompt instructs the model in ModePointwise.
+const PointwiseSystemPrompt = `You are a relevance judge for a search system. You receive a query and numbered documents. Grade how well each document answers the query on a scale from 0 (irrelevant) to 10 (fully answers the query). Judge every document independently and return exactly one score per document number.`
+
+// ListwiseSystemPrompt instructs the model in ModeListwise.
+const ListwiseSystemPrompt = `You are a relevance judge for a search system. You receive a query and numbered documents. Order the document numbers from most to least relevant to the query. Return every document number exactly once.`
+
+// pointwiseAnswer is the structured output of a pointwise call.
+type pointwiseAnswer struct {
+	Scores []pointwiseScore `json:"scores" jsonschema:"required,description=One entry per document"`
+}
+
+type pointwiseScore struct {
+	ID    int     `json:"id" jsonschema:"required,description=The document number shown in square brackets"`
+	Score float64 `json:"score" jsonschema:"required,minimum=0,maximum=10,description=Relevance from 0 (irrelevant) to 10 (fully answers the query)"`
+}
+
+// listwiseAnswer is the structured output of a listwise call.
+type listwiseAnswer struct {
+	Ranking []int `json:"ranking" jsonschema:"required,description=Document numbers ordered from most to least relevant"`
+}
+
+// scorePointwise grades documents in concurrent batches and returns one raw
+// score in [0, MaxPointwiseScore] per document.
+func (p *Provider) scorePointwise(ctx context.Context, in rerank.Request, acc *accounting) ([]float64, error) {
+	scores := make([]float64, len(in.Documents))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(p.maxConcurrency)
+	for start := 0; start < len(in.Documents); start += p.batchSize {
+		end := min(start+p.batchSize, len(in.Documents))
+		g.Go(func() error {
+			return p.gradeBatch(gctx, in.Query, in.Documents[start:end], scores[start:end], acc)
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return scores, nil
+}
+
+func (p *Provider) gradeBatch(ctx context.Context, query string, docs []rerank.Document, out []float64, acc *accounting) error {
+	indices := make([]int, len(docs))
+	for i := range indices {
+		indices[i] = i
+	}
+	t := p.prompt(PointwiseSystemPrompt, query, docs, indices)
+	answer, res, err := extract.Extract[pointwiseAnswer](ctx, p.engine, t,
+		extract.WithName("relevance_scores"),
+		extract.WithMode(p.extractMode))
+	acc.add(res, p.modelInfo)
+	if err != nil {
+		return p.callError(ctx, err, query, docs)
+	}
+
+	seen := make([]bool, len(docs))
+	for _, s := range answer.Scores {
+		if s.ID < 0 || s.ID >= len(docs) || seen[s.ID] {
+			return fmt.Errorf("llm rerank returned an unknown or repeated document number %d: %w", s.ID, rerank.ErrInvalidResponse)
+		}
+		if math.IsNaN(s.Score) || math.IsInf(s.Score, 0) {
+			return fmt.Errorf("llm rerank returned a non-finite score for document number %d: %w", s.ID, rerank.ErrInvalidResponse)
+		}
+		seen[s.ID] = true
+		out[s.ID] = min(max(s.Score, 0), MaxPointwiseScore)
+	}
+	if len(answer.Scores) != len(docs) {
+		return fmt.Errorf("llm rerank returned %d scores for %d documents: %w", len(answer.Scores), len(docs), rerank.ErrInvalidResponse)
+	}
+	return nil
+}
+
+// scoreListwise orders documents with a window of batchSize that slides by
+// half a window from the bottom of the list to the top, then scores each
+// document by its final position: n for the first down to 1 for the last.
+func (p *Provider) scoreListwise(ctx context.Context, in rerank.Request, acc *accounting) ([]float64, error) {
+	n := len(in.Documents)
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	step := max(1, p.batchSize/2)
+	for end := n; ; end -= step {
+		start := max(0, end-p.batchSize)
+		if err := p.orderWindow(ctx, in.Query, in.Documents, order[start:end], acc); err != nil {
+			return nil, err
+		}
+		if start == 0 {
+			break
+		}
+	}
+
+	scores := make([]float64, n)
+	for pos, idx := range order {
+		scores[idx] = float64(n - pos)
+	}
+	return scores, nil
+}
+
+// orderWindow reorders window (indices into docs) in place. Models sometimes
+// repeat or drop numbers; repeats and unknown numbers are ignored and omitted
+// documents keep their relative order after the ranked ones.
+func (p *Provider) orderWindow(ctx context.Context, query string, docs []rerank.Document, window []int, acc *accounting) error {
+	if len(window) < 2 {
+		return nil
+	}
+	windowDocs := make([]rerank.Document, len(window))
+	for i, idx := range window {
+		windowDocs[i] = docs[idx]
+	}
+	local := make([]int, len(window))
+	for i := range local {
+		local[i] = i
+	}
+	t := p.prompt(ListwiseSystemPrompt, query, windowDocs, local)
+	answer, res, err := extract.Extract[listwiseAnswer](ctx, p.engine, t,
+		extract.WithName("relevance_ranking"),
+		extract.WithMode(p.extractMode))
+	acc.add(res, p.modelInfo)
+	if err != nil {
+		return p.callError(ctx, err, query, windowDocs)
+	}
+
+	seen := make([]bool, len(window))
+	ranked := make([]int, 0, len(window))
+	for _, id := range answer.Ranking {
+		if id < 0 || id >= len(window) || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ranked = append(ranked, window[id])
+	}
+	if len(ranked) == 0 {
+		return fmt.Errorf("llm rerank returned no known document numbers: %w", rerank.ErrInvalidResponse)
+	}
+	for id, idx := range window {
+		if !seen[id] {
+			ranked = append(ranked, idx)
+		}
+	}
+	copy(window, ranked)
+	return nil
+}
+
+// prompt builds a turn listing docs under batch-local numbers. Caller
+// document IDs never enter the prompt.
+func (p *Provider) prompt(system, query string, docs []rerank.Document, numbers []int) *turns.Turn {
+	var b strings.Builder
+	b.WriteString("Query:\n")
+	b.WriteString(query)
+	b.WriteString("\n\nDocuments:\n")
+	for i, doc := range docs {
+		text := doc.Text
+		if r := []rune(text); len(r) > p.maxDocumentChars {
+			text = string(r[:p.maxDocumentChars]) + "…"
+		}
+		fmt.Fprintf(&b, "[%d] %s\n", numbers[i], strings.TrimSpace(text))
+	}
+	t := &turns.Turn{}
+	turns.AppendBlock(t, turns.NewSystemTextBlock(system))
+	turns.AppendBlock(t, turns.NewUserTextBlock(b.String()))
+	return t
+}
+
+// callError classifies an engine or extraction failure. Engine errors can
+// echo provider bodies, and with them query or document text, so they are
+// reduced to a sentinel like the HTTP adapters' transport errors; the cause is
+// logged at debug level with those texts redacted.
+func (p *Provider) callError(ctx context.Context, err error, query string, docs []rerank.Document) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("llm rerank call interrupted: %w: %w", ctx.Err(), rerank.ErrUnavailable)
+	}
+	log.Debug().Str("cause", redactCause(err, query, docs)).Msg("llm rerank engine call failed")
+	switch {
+	case errors.Is(err, engine.ErrStructuredOutputInvalid), errors.Is(err, extract.ErrNoResult):
+		return fmt.Errorf("llm rerank model returned no valid structured answer: %w", rerank.ErrInvalidResponse)
+	case isCallerFault(err):
+		return fmt.Errorf("llm rerank engine rejected the request: %w", rerank.ErrInvalidRequest)
+	default:
+		return fmt.Errorf("llm rerank engine call failed: %w", rerank.ErrUnavailable)
+	}
+}
+
+// isCallerFault reports whether err is caused by the request or the
+// provider's configuration rather than by the rerank backend: a budget stop, a
+// schema the engine cannot enforce, or an engine.StatusError with a 4xx status
+// other than 408 and 429. Anything else, including dial, TLS and stream decode
+// failures, is treated as the backend being unavailable.
+func isCallerFault(err error) bool {
+	if errors.Is(err, engine.ErrStructuredOutputUnsupported) || errors.Is(err, budget.ErrBudgetExceeded) {
+		return true
+	}
+	var statusErr *engine.StatusError
+	if errors.As(err, &statusErr) {
+		code := statusErr.StatusCode
+		return code >= 400 && code < 500 && code != 408 && code != 429
+	}
+	return false
+}
+
+// redactCause returns err's message with the query, document texts and
+// provider response bodies removed.
+func redactCause(err error, query string, docs []rerank.Document) string {
+	msg := err.Error()
+	if i := strings.Index(msg, "body="); i >= 0 {
+		msg = msg[:i] + "body=[redacted]"
+	}
+	texts := []string{query}
+	for _, doc := range docs {
+		texts = append(texts, doc.Text)
+	}
+	for _, text := range texts {
+		if text = strings.TrimSpace(text); text != "" {
+			msg = strings.ReplaceAll(msg, text, "[redacted]")
+		}
+	}
+	return msg
+}
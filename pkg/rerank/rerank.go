@@ -49,12 +49,16 @@ type Result struct {
 // Usage reports provider-reported token consumption for a rerank call.
 //
 // Reranking usually consumes input tokens but produces no generated output
-// tokens. Provider responses may report prompt or total tokens. A zero
-// Usage value means the provider reported zero tokens; a nil *Usage means the
-// provider did not report usage at all.
+// tokens; only chat-model reranking (pkg/rerank/llm) reports OutputTokens.
+// Provider responses may report prompt or total tokens; providers that bill
+// per search (Cohere) report SearchUnits instead. A zero Usage value means the
+// provider reported zero tokens; a nil *Usage means the provider did not
+// report usage at all.
 type Usage struct {
-	InputTokens int `json:"input_tokens,omitempty" yaml:"input_tokens,omitempty"`
-	TotalTokens int `json:"total_tokens,omitempty" yaml:"total_tokens,omitempty"`
+	InputTokens  int `json:"input_tokens,omitempty" yaml:"input_tokens,omitempty"`
+	OutputTokens int `json:"output_tokens,omitempty" yaml:"output_tokens,omitempty"`
+	TotalTokens  int `json:"total_tokens,omitempty" yaml:"total_tokens,omitempty"`
+	SearchUnits  int `json:"search_units,omitempty" yaml:"search_units,omitempty"`
 }
 
 // Response is the rich rerank response carrying scores plus provider
@@ -0,0 +1,254 @@
+// Package rerankhttp is the hardened JSON-over-HTTP transport shared by the
+// rerank adapters (llama.cpp, Cohere, Jina, Voyage, OpenAI/TEI-compatible).
+//
+// A Client enforces:
+//   - base URL validation (scheme, host, no userinfo, no query/fragment,
+//     unambiguous path) and security.ValidateOutboundURL on the endpoint;
+//   - bounded request encoding (MaxRequestBytes) before sending;
+//   - bounded response reading (MaxResponseBytes) before decoding;
+//   - strict JSON decoding that rejects unknown fields and trailing data;
+//   - redirect rejection on a clone of the injected client;
+//   - safe errors that never include query/document text, credentials, or
+//     response bodies.
+//
+// Adapters own their wire DTOs and result mapping, and keep caller document
+// IDs out of provider payloads. The hosted API-key adapters (Cohere, Jina,
+// Voyage) share the HostedProvider request flow and supply only that mapping.
+package rerankhttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/rerank"
+	"github.com/go-go-golems/geppetto/pkg/security"
+)
+
+const (
+	// DefaultMaxRequestBytes is the conservative default request body bound.
+	DefaultMaxRequestBytes int64 = 2 << 20 // 2 MiB
+	// DefaultMaxResponseBytes is the conservative default response body bound.
+	DefaultMaxResponseBytes int64 = 1 << 20 // 1 MiB
+)
+
+// Options configures a Client.
+//
+// Name prefixes every error (for example "cohere") so failures identify the
+// adapter without echoing the endpoint. Path is joined to BaseURL with
+// url.JoinPath. Zero byte limits select the defaults; negative limits are
+// rejected.
+type Options struct {
+	Name             string
+	BaseURL          string
+	Path             string
+	HTTPClient       *http.Client
+	OutboundURL      security.OutboundURLOptions
+	MaxRequestBytes  int64
+	MaxResponseBytes int64
+}
+
+// Client posts JSON to one validated rerank endpoint.
+type Client struct {
+	name             string
+	endpoint         string
+	client           *http.Client
+	maxRequestBytes  int64
+	maxResponseBytes int64
+}
+
+// New validates options and constructs a Client. An injected HTTPClient is
+// cloned so the caller's redirect policy is replaced without mutating it.
+func New(options Options) (*Client, error) {
+	name := options.Name
+	baseURL := strings.TrimSpace(options.BaseURL)
+	if baseURL == "" {
+		return nil, fmt.Errorf("%s base URL is required: %w", name, rerank.ErrInvalidRequest)
+	}
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		// url.Parse errors include the original URL. Never wrap them: a malformed
+		// URL can contain endpoint credentials or private topology.
+		return nil, fmt.Errorf("%s base URL is malformed: %w", name, rerank.ErrInvalidRequest)
+	}
+	if err := ValidateBaseURL(name, parsed); err != nil {
+		return nil, err
+	}
+
+	maxRequestBytes := options.MaxRequestBytes
+	if maxRequestBytes == 0 {
+		maxRequestBytes = DefaultMaxRequestBytes
+	}
+	if maxRequestBytes < 1 {
+		return nil, fmt.Errorf("%s max_request_bytes must be positive: %w", name, rerank.ErrInvalidRequest)
+	}
+	maxResponseBytes := options.MaxResponseBytes
+	if maxResponseBytes == 0 {
+		maxResponseBytes = DefaultMaxResponseBytes
+	}
+	if maxResponseBytes < 1 {
+		return nil, fmt.Errorf("%s max_response_bytes must be positive: %w", name, rerank.ErrInvalidRequest)
+	}
+
+	endpoint, err := url.JoinPath(baseURL, options.Path)
+	if err != nil {
+		return nil, fmt.Errorf("%s endpoint construction failed: %w: %w", name, err, rerank.ErrInvalidRequest)
+	}
+	if err := security.ValidateOutboundURL(endpoint, options.OutboundURL); err != nil {
+		return nil, fmt.Errorf("%s endpoint rejected by outbound URL policy: %w: %w", name, err, rerank.ErrInvalidRequest)
+	}
+
+	return &Client{
+		name:             name,
+		endpoint:         endpoint,
+		client:           CloneClientWithRedirectRejection(options.HTTPClient),
+		maxRequestBytes:  maxRequestBytes,
+		maxResponseBytes: maxResponseBytes,
+	}, nil
+}
+
+// ValidateBaseURL permits an optional unambiguous path prefix, but rejects
+// encoded paths, repeated separators, and dot segments. url.JoinPath would
+// otherwise normalize those forms after validation, making the configured
+// target ambiguous to reviewers and security policy.
+func ValidateBaseURL(name string, parsed *url.URL) error {
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("%s base URL scheme must be http or https: %w", name, rerank.ErrInvalidRequest)
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("%s base URL host is required: %w", name, rerank.ErrInvalidRequest)
+	}
+	if parsed.User != nil {
+		return fmt.Errorf("%s base URL must not contain userinfo: %w", name, rerank.ErrInvalidRequest)
+	}
+	if parsed.RawQuery != "" || parsed.Fragment != "" {
+		return fmt.Errorf("%s base URL must not contain query or fragment: %w", name, rerank.ErrInvalidRequest)
+	}
+	if parsed.RawPath != "" || strings.Contains(parsed.Path, "//") {
+		return fmt.Errorf("%s base URL path must be unambiguous: %w", name, rerank.ErrInvalidRequest)
+	}
+	for _, segment := range strings.Split(parsed.Path, "/") {
+		if segment == "." || segment == ".." {
+			return fmt.Errorf("%s base URL path must not contain dot segments: %w", name, rerank.ErrInvalidRequest)
+		}
+	}
+	return nil
+}
+
+// PostJSON encodes payload, posts it with header added to the request, and
+// strictly decodes a 2xx response into out. It returns the response headers
+// so adapters can surface request IDs.
+func (c *Client) PostJSON(ctx context.Context, payload any, header http.Header, out any) (http.Header, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("%s encode request: %w", c.name, err)
+	}
+	if int64(len(body)) > c.maxRequestBytes {
+		return nil, fmt.Errorf("%s encoded request is %d bytes, limit is %d: %w",
+			c.name, len(body), c.maxRequestBytes, rerank.ErrRequestTooLarge)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("%s could not create provider request: %w", c.name, rerank.ErrUnavailable)
+	}
+	for key, values := range header {
+		for _, value := range values {
+			httpReq.Header.Add(key, value)
+		}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, c.redactTransportError(err)
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+
+	if httpResp.StatusCode < http.StatusOK || httpResp.StatusCode >= http.StatusMultipleChoices {
+		drainBounded(httpResp.Body, c.maxResponseBytes)
+		return nil, fmt.Errorf("%s endpoint returned status %d: %w",
+			c.name, httpResp.StatusCode, rerank.ErrUnavailable)
+	}
+
+	raw, tooLarge, err := ReadAtMost(httpResp.Body, c.maxResponseBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s could not read provider response: %w", c.name, rerank.ErrUnavailable)
+	}
+	if tooLarge {
+		return nil, fmt.Errorf("%s response body exceeds %d bytes: %w",
+			c.name, c.maxResponseBytes, rerank.ErrResponseTooLarge)
+	}
+	if err := DecodeStrict(raw, out); err != nil {
+		return nil, fmt.Errorf("%s decode response: %w: %w", c.name, err, rerank.ErrInvalidResponse)
+	}
+	return httpResp.Header, nil
+}
+
+// redactTransportError intentionally discards the original transport error.
+// net/url errors can contain a redirect target, proxy URL, userinfo, or query
+// parameters. The stable sentinel is sufficient for callers to classify the
+// failure without serializing protected operational data.
+func (c *Client) redactTransportError(_ error) error {
+	return fmt.Errorf("%s provider transport failed: %w", c.name, rerank.ErrUnavailable)
+}
+
+// drainBounded reads and discards a non-2xx body up to the limit so the
+// connection can be reused, without ever surfacing the body in an error.
+func drainBounded(body io.Reader, limit int64) {
+	_, _, _ = ReadAtMost(body, limit)
+}
+
+// ReadAtMost reads up to limit+1 bytes from r. tooLarge is true only when the
+// body exceeded the limit; a read error remains distinguishable from a limit
+// violation and is classified by the caller as provider unavailability.
+func ReadAtMost(r io.Reader, limit int64) ([]byte, bool, error) {
+	lr := &io.LimitedReader{R: r, N: limit + 1}
+	body, err := io.ReadAll(lr)
+	if err != nil {
+		return nil, false, err
+	}
+	return body, int64(len(body)) > limit, nil
+}
+
+// DecodeStrict decodes exactly one JSON value into out. It rejects unknown
+// fields and every non-whitespace byte after that value without returning
+// provider body content in an error.
+func DecodeStrict(raw []byte, out any) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(out); err != nil {
+		return fmt.Errorf("invalid JSON response")
+	}
+	var extra any
+	if err := dec.Decode(&extra); !errors.Is(err, io.EOF) {
+		return fmt.Errorf("trailing data after rerank response")
+	}
+	return nil
+}
+
+// CloneClientWithRedirectRejection returns an http.Client that rejects every
+// redirect. If injected is nil, a new client is constructed. Otherwise the
+// client is shallow-copied so its Transport, Jar, and Timeout are retained
+// while CheckRedirect is replaced. The caller's client is never mutated in
+// place.
+func CloneClientWithRedirectRejection(injected *http.Client) *http.Client {
+	rejectRedirect := func(_ *http.Request, _ []*http.Request) error {
+		return fmt.Errorf("rerank provider rejects redirects")
+	}
+	if injected == nil {
+		return &http.Client{
+			CheckRedirect: rejectRedirect,
+			Timeout:       0,
+		}
+	}
+	cloned := *injected
+	cloned.CheckRedirect = rejectRedirect
+	return &cloned
+}
@@ -0,0 +1,64 @@
+package rerankhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/rerank"
+	"github.com/go-go-golems/geppetto/pkg/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var allowLocal = security.OutboundURLOptions{AllowHTTP: true, AllowLocalNetworks: true}
+
+func TestReadAtMost_Limits(t *testing.T) {
+	out, tooLarge, err := ReadAtMost(strings.NewReader("hello"), 3)
+	require.NoError(t, err)
+	assert.True(t, tooLarge)
+	assert.Len(t, out, 4) // limit+1
+}
+
+func TestNew_PrefixesErrorsWithName(t *testing.T) {
+	_, err := New(Options{Name: "acme", BaseURL: "https://user:pw@example.com"})
+	require.ErrorIs(t, err, rerank.ErrInvalidRequest)
+	assert.Contains(t, err.Error(), "acme base URL must not contain userinfo")
+	assert.NotContains(t, err.Error(), "pw")
+}
+
+func TestPostJSON_SendsHeadersAndDecodesStrictly(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/prefix/v1/rerank", r.URL.Path)
+		assert.Equal(t, "Bearer k", r.Header.Get("Authorization"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		w.Header().Set("X-Request-Id", "req-1")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := New(Options{Name: "acme", BaseURL: srv.URL + "/prefix", Path: "v1/rerank", OutboundURL: allowLocal})
+	require.NoError(t, err)
+	var out struct {
+		OK bool `json:"ok"`
+	}
+	header, err := c.PostJSON(context.Background(), map[string]string{"q": "x"}, BearerHeader("k"), &out)
+	require.NoError(t, err)
+	assert.True(t, out.OK)
+	assert.Equal(t, "req-1", RequestID(header))
+
+	var strict struct{}
+	_, err = c.PostJSON(context.Background(), map[string]string{}, BearerHeader("k"), &strict)
+	require.ErrorIs(t, err, rerank.ErrInvalidResponse)
+}
+
+func TestInputCost_NilWithoutUsageOrRate(t *testing.T) {
+	rate := 2.0
+	assert.Nil(t, InputCost(nil, &rate))
+	assert.Nil(t, InputCost(&rerank.Usage{TotalTokens: 10}, nil))
+	cost := InputCost(&rerank.Usage{TotalTokens: 500_000}, &rate)
+	require.NotNil(t, cost)
+	assert.InDelta(t, 1.0, *cost, 1e-9)
+}
@@ -0,0 +1,157 @@
+package rerankhttp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/rerank"
+	"github.com/go-go-golems/geppetto/pkg/security"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/ratelimit"
+)
+
+// HostedOptions configures a hosted, API-key rerank provider (Cohere, Jina,
+// Voyage).
+//
+// APIKey and Model are required. BaseURL defaults to the API's hosted URL and
+// may point at a compatible gateway. CostPerMTokens is applied to the reported
+// input tokens; when nil, or when the API reports no tokens, Cost is nil
+// (unknown).
+type HostedOptions struct {
+	BaseURL          string
+	APIKey           string
+	Model            string
+	HTTPClient       *http.Client
+	OutboundURL      security.OutboundURLOptions
+	MaxRequestBytes  int64
+	MaxResponseBytes int64
+	CostPerMTokens   *float64
+}
+
+// Reply is what an API's response mapper extracts from its wire response.
+//
+// Model, when set, must equal the effective model. RequestID, when empty,
+// falls back to the response's request ID header.
+type Reply struct {
+	Items     []Item
+	Model     string
+	Usage     *rerank.Usage
+	RequestID string
+}
+
+// HostedAPI describes one hosted /rerank API: its identity, route, and the
+// mapping between rerank requests and its wire DTOs. Resp is the response
+// wire type decoded by the shared client.
+type HostedAPI[Resp any] struct {
+	Name           string
+	DefaultBaseURL string
+	Path           string
+	// BuildRequest returns the wire request for model, the query and the
+	// document texts of in.
+	BuildRequest func(model string, in rerank.Request, documents []string) any
+	// MapResponse extracts results, model and usage from a decoded response.
+	MapResponse func(resp *Resp) Reply
+}
+
+// HostedProvider is a rerank.Provider for one HostedAPI.
+type HostedProvider[Resp any] struct {
+	api            HostedAPI[Resp]
+	http           *Client
+	apiKey         string
+	model          string
+	costPerMTokens *float64
+}
+
+// NewHosted validates options and constructs a provider for api.
+func NewHosted[Resp any](api HostedAPI[Resp], options HostedOptions) (*HostedProvider[Resp], error) {
+	apiKey := strings.TrimSpace(options.APIKey)
+	if apiKey == "" {
+		return nil, fmt.Errorf("%s API key is required: %w", api.Name, rerank.ErrInvalidRequest)
+	}
+	model := strings.TrimSpace(options.Model)
+	if model == "" {
+		return nil, fmt.Errorf("%s model is required: %w", api.Name, rerank.ErrInvalidRequest)
+	}
+	baseURL := options.BaseURL
+	if strings.TrimSpace(baseURL) == "" {
+		baseURL = api.DefaultBaseURL
+	}
+
+	client, err := New(Options{
+		Name:             api.Name,
+		BaseURL:          baseURL,
+		Path:             api.Path,
+		HTTPClient:       options.HTTPClient,
+		OutboundURL:      options.OutboundURL,
+		MaxRequestBytes:  options.MaxRequestBytes,
+		MaxResponseBytes: options.MaxResponseBytes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &HostedProvider[Resp]{
+		api:            api,
+		http:           client,
+		apiKey:         apiKey,
+		model:          model,
+		costPerMTokens: options.CostPerMTokens,
+	}, nil
+}
+
+// Model returns the provider's configured provider/model identity.
+func (p *HostedProvider[Resp]) Model() rerank.Model {
+	return rerank.Model{Provider: p.api.Name, Name: p.model}
+}
+
+// Rerank scores and reorders documents via the API's /rerank endpoint.
+func (p *HostedProvider[Resp]) Rerank(ctx context.Context, in rerank.Request) (rerank.Response, error) {
+	started := time.Now()
+
+	providerModel := p.Model()
+	if err := rerank.ValidateRequest(in, providerModel); err != nil {
+		return rerank.Response{}, err
+	}
+
+	effectiveModel := rerank.ResolveModel(in, providerModel)
+	documents := DocumentTexts(in)
+
+	ctx = ratelimit.WithTokenEstimate(ctx, ratelimit.EstimateTokens(in.Query)+ratelimit.EstimateTokens(documents...))
+	var wire Resp
+	header, err := p.http.PostJSON(ctx, p.api.BuildRequest(effectiveModel, in, documents), BearerHeader(p.apiKey), &wire)
+	if err != nil {
+		return rerank.Response{}, err
+	}
+	reply := p.api.MapResponse(&wire)
+
+	results, err := rerank.ValidateAndMapResults(in.Documents, in.TopN, ToRawResults(reply.Items))
+	if err != nil {
+		return rerank.Response{}, err
+	}
+	if reply.Model != "" && reply.Model != effectiveModel {
+		return rerank.Response{}, fmt.Errorf("%s response model %q does not match effective model %q: %w",
+			p.api.Name, reply.Model, effectiveModel, rerank.ErrInvalidResponse)
+	}
+
+	var cost *float64
+	if u := reply.Usage; u != nil && (u.InputTokens > 0 || u.TotalTokens > 0) {
+		cost = InputCost(u, p.costPerMTokens)
+	}
+	requestID := reply.RequestID
+	if requestID == "" {
+		requestID = RequestID(header)
+	}
+	durationMs := time.Since(started).Milliseconds()
+
+	return rerank.Response{
+		Provider:   p.api.Name,
+		Model:      effectiveModel,
+		Results:    results,
+		Usage:      reply.Usage,
+		Cost:       cost,
+		RequestID:  requestID,
+		DurationMs: &durationMs,
+	}, nil
+}
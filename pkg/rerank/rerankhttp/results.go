@@ -0,0 +1,87 @@
+package rerankhttp
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-go-golems/geppetto/pkg/rerank"
+)
+
+// Item is the common {index, relevance_score} result shape used by the
+// llama.cpp, Cohere, Jina, Voyage, and OpenAI-compatible rerank APIs. Pointer
+// fields distinguish a missing zero from a valid zero. Document is accepted
+// (some servers echo it even when not requested) but never read.
+type Item struct {
+	Index          *int            `json:"index"`
+	RelevanceScore *float64        `json:"relevance_score"`
+	Document       json.RawMessage `json:"document,omitempty"`
+}
+
+// ToRawResults converts wire items into rerank.RawResult presence-flagged
+// values.
+func ToRawResults(items []Item) []rerank.RawResult {
+	out := make([]rerank.RawResult, 0, len(items))
+	for _, it := range items {
+		out = append(out, RawResult(it.Index, it.RelevanceScore))
+	}
+	return out
+}
+
+// RawResult converts one index/score pair into a presence-flagged
+// rerank.RawResult.
+func RawResult(index *int, score *float64) rerank.RawResult {
+	r := rerank.RawResult{}
+	if index != nil {
+		r.Index = *index
+		r.HasIndex = true
+	}
+	if score != nil {
+		r.Score = *score
+		r.HasScore = true
+	}
+	return r
+}
+
+// DocumentTexts returns the document texts of in, in array order. Caller
+// document IDs never enter provider payloads.
+func DocumentTexts(in rerank.Request) []string {
+	documents := make([]string, len(in.Documents))
+	for i, doc := range in.Documents {
+		documents[i] = doc.Text
+	}
+	return documents
+}
+
+// InputCost computes the input-token cost when a per-million-token rate is
+// configured and the provider reported usage. Returns nil (unknown cost) when
+// either is absent, preserving the nil-vs-zero distinction.
+func InputCost(u *rerank.Usage, costPerMTokens *float64) *float64 {
+	if u == nil || costPerMTokens == nil {
+		return nil
+	}
+	tokens := u.InputTokens
+	if tokens == 0 {
+		tokens = u.TotalTokens
+	}
+	cost := *costPerMTokens * float64(tokens) / 1_000_000
+	return &cost
+}
+
+// BearerHeader returns the Authorization header for apiKey, or nil when the
+// key is empty so self-hosted endpoints can run unauthenticated.
+func BearerHeader(apiKey string) http.Header {
+	if apiKey == "" {
+		return nil
+	}
+	return http.Header{"Authorization": []string{"Bearer " + apiKey}}
+}
+
+// RequestID returns the first non-empty request ID header a provider set.
+func RequestID(header http.Header) string {
+	for _, key := range []string{"X-Request-Id", "Request-Id"} {
+		if id := header.Get(key); id != "" {
+			return id
+		}
+	}
+	return ""
+}
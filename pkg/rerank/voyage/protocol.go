@@ -0,0 +1,27 @@
+package voyage
+
+import "github.com/go-go-golems/geppetto/pkg/rerank/rerankhttp"
+
+// request is the Voyage /v1/rerank request wire DTO. Voyage names the result
+// cardinality top_k. ReturnDocuments is always false so document text is not
+// echoed back.
+type request struct {
+	Model           string   `json:"model"`
+	Query           string   `json:"query"`
+	Documents       []string `json:"documents"`
+	TopK            int      `json:"top_k"`
+	ReturnDocuments bool     `json:"return_documents"`
+}
+
+// response is the Voyage /v1/rerank response wire DTO.
+type response struct {
+	Object string            `json:"object,omitempty"`
+	Model  string            `json:"model,omitempty"`
+	Usage  *usage            `json:"usage,omitempty"`
+	Data   []rerankhttp.Item `json:"data"`
+}
+
+// usage mirrors the Voyage token usage object.
+type usage struct {
+	TotalTokens int `json:"total_tokens"`
+}
@@ -0,0 +1,48 @@
+// Package voyage implements a strict Voyage AI /v1/rerank adapter for the
+// transport-neutral rerank.Provider interface.
+package voyage
+
+import (
+	"github.com/go-go-golems/geppetto/pkg/rerank"
+	"github.com/go-go-golems/geppetto/pkg/rerank/rerankhttp"
+)
+
+const (
+	// ProviderName is the provider identity reported in rerank.Response.
+	ProviderName = "voyage"
+
+	// DefaultBaseURL is the hosted Voyage AI API.
+	DefaultBaseURL = "https://api.voyageai.com"
+
+	// rerankPath is the Voyage reranking route appended to the base URL.
+	rerankPath = "v1/rerank"
+)
+
+// Options configures the Voyage rerank provider.
+type Options = rerankhttp.HostedOptions
+
+// Provider is the Voyage rerank provider.
+type Provider = rerankhttp.HostedProvider[response]
+
+var _ rerank.Provider = (*Provider)(nil)
+
+var api = rerankhttp.HostedAPI[response]{
+	Name:           ProviderName,
+	DefaultBaseURL: DefaultBaseURL,
+	Path:           rerankPath,
+	BuildRequest: func(model string, in rerank.Request, documents []string) any {
+		return request{Model: model, Query: in.Query, Documents: documents, TopK: in.TopN, ReturnDocuments: false}
+	},
+	MapResponse: func(resp *response) rerankhttp.Reply {
+		reply := rerankhttp.Reply{Items: resp.Data, Model: resp.Model}
+		if resp.Usage != nil {
+			reply.Usage = &rerank.Usage{InputTokens: resp.Usage.TotalTokens, TotalTokens: resp.Usage.TotalTokens}
+		}
+		return reply
+	},
+}
+
+// New constructs a Voyage rerank provider.
+func New(options Options) (*Provider, error) {
+	return rerankhttp.NewHosted(api, options)
+}
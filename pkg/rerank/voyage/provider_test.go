@@ -0,0 +1,81 @@
+package voyage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/rerank"
+	"github.com/go-go-golems/geppetto/pkg/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testModel = "rerank-2"
+
+var testDocs = []rerank.Document{
+	{ID: "a", Text: "The Mediterranean diet emphasizes fish and olive oil."},
+	{ID: "b", Text: "Photosynthesis converts light energy into glucose."},
+}
+
+func newTestServer(t *testing.T, cost *float64, handler http.HandlerFunc) *Provider {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	p, err := New(Options{
+		BaseURL:        srv.URL,
+		APIKey:         "VOYAGESECRET",
+		Model:          testModel,
+		OutboundURL:    security.OutboundURLOptions{AllowHTTP: true, AllowLocalNetworks: true},
+		CostPerMTokens: cost,
+	})
+	require.NoError(t, err)
+	return p
+}
+
+func TestNew_RequiresAPIKey(t *testing.T) {
+	_, err := New(Options{Model: testModel})
+	require.ErrorIs(t, err, rerank.ErrInvalidRequest)
+	assert.Contains(t, err.Error(), "voyage API key is required")
+}
+
+func TestRerank_RequestShapeUsageAndCost(t *testing.T) {
+	var got request
+	cost := 0.05
+	p := newTestServer(t, &cost, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/rerank", r.URL.Path)
+		assert.Equal(t, "Bearer VOYAGESECRET", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`{
+			"object": "list",
+			"data": [
+				{"relevance_score": 0.4375, "index": 0},
+				{"relevance_score": 0.0215, "index": 1}
+			],
+			"model": "rerank-2",
+			"usage": {"total_tokens": 200000}
+		}`))
+	})
+
+	resp, err := p.Rerank(context.Background(), rerank.Request{Query: "healthy diet", Documents: testDocs, TopN: 2})
+	require.NoError(t, err)
+	assert.Equal(t, request{Model: testModel, Query: "healthy diet", Documents: []string{testDocs[0].Text, testDocs[1].Text}, TopK: 2}, got)
+
+	assert.Equal(t, "voyage", resp.Provider)
+	require.Len(t, resp.Results, 2)
+	assert.Equal(t, "a", resp.Results[0].DocumentID)
+	require.NotNil(t, resp.Usage)
+	assert.Equal(t, 200000, resp.Usage.InputTokens)
+	require.NotNil(t, resp.Cost)
+	assert.InDelta(t, 0.01, *resp.Cost, 1e-9)
+}
+
+func TestRerank_RejectsWrongCardinality(t *testing.T) {
+	p := newTestServer(t, nil, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"relevance_score":0.4,"index":0}]}`))
+	})
+	_, err := p.Rerank(context.Background(), rerank.Request{Query: "q", Documents: testDocs, TopN: 2})
+	require.ErrorIs(t, err, rerank.ErrInvalidResponse)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings/claude"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings/gemini"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings/ollama"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings/openai"
 	"github.com/go-go-golems/glazed/pkg/cmds/schema"
 )
@@ -84,6 +85,14 @@ func CreateGeppettoSections(opts ...CreateOption) ([]schema.Section, error) {
 		return nil, err
 	}
 
+	ollamaSection, err := ollama.NewValueSection()
+	if err != nil {
+		return nil, err
+	}
+	if err := ollamaSection.InitializeDefaultsFromStruct(ss.Ollama); err != nil {
+		return nil, err
+	}
+
 	embeddingsSection, err := embeddingsconfig.NewEmbeddingsValueSection()
 	if err != nil {
 		return nil, err
@@ -124,6 +133,7 @@ func CreateGeppettoSections(opts ...CreateOption) ([]schema.Section, error) {
 		claudeSection,
 		geminiSection,
 		openaiSection,
+		ollamaSection,
 		embeddingsSection,
 		rerankSection,
 		inferenceSection,
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/security"
+)
+
+// Message batch processing statuses.
+const (
+	MessageBatchInProgress = "in_progress"
+	MessageBatchCanceling  = "canceling"
+	MessageBatchEnded      = "ended"
+)
+
+// Message batch result types.
+const (
+	MessageBatchResultSucceeded = "succeeded"
+	MessageBatchResultErrored   = "errored"
+	MessageBatchResultCanceled  = "canceled"
+	MessageBatchResultExpired   = "expired"
+)
+
+// MessageBatchRequest is one entry of a Message Batches create request.
+type MessageBatchRequest struct {
+	CustomID string         `json:"custom_id"`
+	Params   MessageRequest `json:"params"`
+}
+
+// MessageBatchCreateRequest is the Message Batches create payload.
+type MessageBatchCreateRequest struct {
+	Requests []MessageBatchRequest `json:"requests"`
+}
+
+// MessageBatchRequestCounts counts batch requests by state.
+type MessageBatchRequestCounts struct {
+	Processing int `json:"processing"`
+	Succeeded  int `json:"succeeded"`
+	Errored    int `json:"errored"`
+	Canceled   int `json:"canceled"`
+	Expired    int `json:"expired"`
+}
+
+// MessageBatch is the Message Batches object.
+type MessageBatch struct {
+	ID                string                    `json:"id"`
+	Type              string                    `json:"type"`
+	ProcessingStatus  string                    `json:"processing_status"`
+	RequestCounts     MessageBatchRequestCounts `json:"request_counts"`
+	ResultsURL        string                    `json:"results_url,omitempty"`
+	CreatedAt         string                    `json:"created_at,omitempty"`
+	EndedAt           string                    `json:"ended_at,omitempty"`
+	ExpiresAt         string                    `json:"expires_at,omitempty"`
+	CancelInitiatedAt string                    `json:"cancel_initiated_at,omitempty"`
+}
+
+// MessageBatchResult is one line of a batch results file.
+type MessageBatchResult struct {
+	CustomID string `json:"custom_id"`
+	Result   struct {
+		Type    string           `json:"type"`
+		Message *MessageResponse `json:"message,omitempty"`
+		Error   *ErrorResponse   `json:"error,omitempty"`
+	} `json:"result"`
+}
+
+// CreateMessageBatch submits a Message Batches job.
+func (c *Client) CreateMessageBatch(ctx context.Context, req *MessageBatchCreateRequest) (*MessageBatch, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	var batch MessageBatch
+	if err := c.doBatchRequest(ctx, http.MethodPost, c.batchesURL(""), body, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// GetMessageBatch retrieves a Message Batches job.
+func (c *Client) GetMessageBatch(ctx context.Context, id string) (*MessageBatch, error) {
+	var batch MessageBatch
+	if err := c.doBatchRequest(ctx, http.MethodGet, c.batchesURL(id), nil, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// CancelMessageBatch asks Anthropic to stop a Message Batches job.
+func (c *Client) CancelMessageBatch(ctx context.Context, id string) (*MessageBatch, error) {
+	var batch MessageBatch
+	if err := c.doBatchRequest(ctx, http.MethodPost, c.batchesURL(id)+"/cancel", nil, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// MessageBatchResults downloads the JSONL results of an ended batch. An empty
+// resultsURL falls back to the results endpoint of the batch id.
+func (c *Client) MessageBatchResults(ctx context.Context, id, resultsURL string) ([]MessageBatchResult, error) {
+	if resultsURL == "" {
+		resultsURL = c.batchesURL(id) + "/results"
+	}
+	resp, err := c.sendBatchRequest(ctx, http.MethodGet, resultsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	var ret []MessageBatchResult
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var r MessageBatchResult
+		if err := json.Unmarshal(line, &r); err != nil {
+			return nil, fmt.Errorf("failed to decode message batch result: %w", err)
+		}
+		ret = append(ret, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read message batch results: %w", err)
+	}
+	return ret, nil
+}
+
+func (c *Client) batchesURL(id string) string {
+	url := strings.TrimSuffix(c.BaseURL, "/") + "/v1/messages/batches"
+	if id != "" {
+		url += "/" + id
+	}
+	return url
+}
+
+func (c *Client) doBatchRequest(ctx context.Context, method, url string, body []byte, out any) error {
+	resp, err := c.sendBatchRequest(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(respBody, out)
+}
+
+func (c *Client) sendBatchRequest(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	if err := security.ValidateOutboundURL(url, c.outboundOptions()); err != nil {
+		return nil, fmt.Errorf("invalid claude message batches URL: %w", err)
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	c.setHeaders(httpReq)
+
+	// #nosec G704 -- URL is validated above with ValidateOutboundURL.
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer func(Body io.ReadCloser) {
+			_ = Body.Close()
+		}(resp.Body)
+		var errorResp ErrorResponse
+		respBody, _ := io.ReadAll(resp.Body)
+		if unmarshalErr := json.Unmarshal(respBody, &errorResp); unmarshalErr != nil || errorResp.Error.Message == "" {
+			return nil, fmt.Errorf("claude message batches API error: status %d", resp.StatusCode)
+		}
+		return nil, fmt.Errorf("claude message batches API error: %s", errorResp.Error.Message)
+	}
+	return resp, nil
+}
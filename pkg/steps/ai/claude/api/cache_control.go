@@ -0,0 +1,109 @@
+package api
+
+import "encoding/json"
+
+// CacheControlTypeEphemeral is the only cache_control type the Messages API accepts.
+const CacheControlTypeEphemeral = "ephemeral"
+
+// MaxCacheBreakpoints is the number of cache_control markers Anthropic accepts
+// per request, counted across tools, system and messages.
+const MaxCacheBreakpoints = 4
+
+// CacheControl marks the end of a cacheable prompt prefix.
+type CacheControl struct {
+	Type string `json:"type"`
+	// TTL is "5m" (default when empty) or "1h".
+	TTL string `json:"ttl,omitempty"`
+}
+
+// NewEphemeralCacheControl returns an ephemeral breakpoint with the given TTL.
+func NewEphemeralCacheControl(ttl string) *CacheControl {
+	return &CacheControl{Type: CacheControlTypeEphemeral, TTL: ttl}
+}
+
+// ContentCacheControl returns the breakpoint carried by c, if any.
+func ContentCacheControl(c Content) *CacheControl {
+	switch v := c.(type) {
+	case TextContent:
+		return v.CacheControl
+	case ImageContent:
+		return v.CacheControl
+	case DocumentContent:
+		return v.CacheControl
+	case ToolUseContent:
+		return v.CacheControl
+	case ToolResultContent:
+		return v.CacheControl
+	default:
+		return nil
+	}
+}
+
+// WithCacheControl returns a copy of c carrying cc. Content types that cannot
+// hold a breakpoint (thinking blocks) are returned unchanged with ok=false.
+// Passing a nil cc clears an existing breakpoint.
+func WithCacheControl(c Content, cc *CacheControl) (Content, bool) {
+	switch v := c.(type) {
+	case TextContent:
+		v.CacheControl = cc
+		return v, true
+	case ImageContent:
+		v.CacheControl = cc
+		return v, true
+	case DocumentContent:
+		v.CacheControl = cc
+		return v, true
+	case ToolUseContent:
+		v.CacheControl = cc
+		return v, true
+	case ToolResultContent:
+		v.CacheControl = cc
+		return v, true
+	default:
+		return c, false
+	}
+}
+
+// systemTextBlock is the array form of the "system" field, which is required
+// to attach cache_control to the system prompt.
+type systemTextBlock struct {
+	Type         ContentType   `json:"type"`
+	Text         string        `json:"text"`
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
+}
+
+// marshalSystem renders the system prompt as a plain string, or as a single
+// text block when a cache breakpoint is attached.
+func marshalSystem(system string, cc *CacheControl) (json.RawMessage, error) {
+	if system == "" {
+		return nil, nil
+	}
+	if cc == nil {
+		return json.Marshal(system)
+	}
+	return json.Marshal([]systemTextBlock{{Type: ContentTypeText, Text: system, CacheControl: cc}})
+}
+
+// unmarshalSystem accepts both the string and the text-block array form.
+func unmarshalSystem(raw json.RawMessage) (string, *CacheControl, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil, nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil, nil
+	}
+	var blocks []systemTextBlock
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return "", nil, err
+	}
+	text := ""
+	var cc *CacheControl
+	for _, b := range blocks {
+		text += b.Text
+		if b.CacheControl != nil {
+			cc = b.CacheControl
+		}
+	}
+	return text, cc, nil
+}
@@ -15,6 +15,7 @@ const (
 	ContentTypeToolUse    ContentType = "tool_use"
 	ContentTypeToolResult ContentType = "tool_result"
 	ContentTypeThinking   ContentType = "thinking"
+	ContentTypeDocument   ContentType = "document"
 )
 
 type Content interface {
@@ -27,7 +28,9 @@ type BaseContent struct {
 
 type TextContent struct {
 	BaseContent
-	Text string `json:"text"`
+	Text         string        `json:"text"`
+	Citations    []Citation    `json:"citations,omitempty"`
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
 }
 
 func (t TextContent) Type() ContentType {
@@ -36,7 +39,8 @@ func (t TextContent) Type() ContentType {
 
 type ImageContent struct {
 	BaseContent
-	Source ImageSource `json:"source"`
+	Source       ImageSource   `json:"source"`
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
 }
 
 func (i ImageContent) Type() ContentType {
@@ -50,11 +54,69 @@ type ImageSource struct {
 	Data      string `json:"data"`
 }
 
+// Document source types accepted by the Messages API.
+const (
+	DocumentSourceBase64 = "base64"
+	DocumentSourceText   = "text"
+	DocumentSourceURL    = "url"
+)
+
+// DocumentContent is a PDF or plain-text document attached to a user message.
+type DocumentContent struct {
+	BaseContent
+	Source       DocumentSource   `json:"source"`
+	Title        string           `json:"title,omitempty"`
+	Context      string           `json:"context,omitempty"`
+	Citations    *CitationsConfig `json:"citations,omitempty"`
+	CacheControl *CacheControl    `json:"cache_control,omitempty"`
+}
+
+func (d DocumentContent) Type() ContentType {
+	return ContentTypeDocument
+}
+
+// DocumentSource holds base64 PDF data, plain text, or a PDF URL depending on Type.
+type DocumentSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// CitationsConfig enables citations for a document.
+type CitationsConfig struct {
+	Enabled bool `json:"enabled"`
+}
+
+// Citation locations returned on text content that cites a document.
+const (
+	CitationTypeCharLocation         = "char_location"
+	CitationTypePageLocation         = "page_location"
+	CitationTypeContentBlockLocation = "content_block_location"
+)
+
+// Citation points from a text content block into a request document. Which
+// location fields are set depends on Type: character indices for plain-text
+// documents, page numbers for PDFs, block indices for custom content documents.
+type Citation struct {
+	Type            string `json:"type"`
+	CitedText       string `json:"cited_text"`
+	DocumentIndex   int    `json:"document_index"`
+	DocumentTitle   string `json:"document_title,omitempty"`
+	StartCharIndex  *int   `json:"start_char_index,omitempty"`
+	EndCharIndex    *int   `json:"end_char_index,omitempty"`
+	StartPageNumber *int   `json:"start_page_number,omitempty"`
+	EndPageNumber   *int   `json:"end_page_number,omitempty"`
+	StartBlockIndex *int   `json:"start_block_index,omitempty"`
+	EndBlockIndex   *int   `json:"end_block_index,omitempty"`
+}
+
 type ToolUseContent struct {
 	BaseContent
-	ID    string          `json:"id"`
-	Name  string          `json:"name"`
-	Input json.RawMessage `json:"input"`
+	ID           string          `json:"id"`
+	Name         string          `json:"name"`
+	Input        json.RawMessage `json:"input"`
+	CacheControl *CacheControl   `json:"cache_control,omitempty"`
 }
 
 func (t ToolUseContent) Type() ContentType {
@@ -63,8 +125,9 @@ func (t ToolUseContent) Type() ContentType {
 
 type ToolResultContent struct {
 	BaseContent
-	ToolUseID string `json:"tool_use_id"`
-	Content   string `json:"content"`
+	ToolUseID    string        `json:"tool_use_id"`
+	Content      string        `json:"content"`
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
 }
 
 func (t ToolResultContent) Type() ContentType {
@@ -98,6 +161,21 @@ func NewImageContent(mediaType, base64Data string) Content {
 	}
 }
 
+// NewDocumentContent returns a document content block. Citations are requested
+// when citations is true.
+func NewDocumentContent(source DocumentSource, title, context string, citations bool) Content {
+	d := DocumentContent{
+		BaseContent: BaseContent{Type_: ContentTypeDocument},
+		Source:      source,
+		Title:       title,
+		Context:     context,
+	}
+	if citations {
+		d.Citations = &CitationsConfig{Enabled: true}
+	}
+	return d
+}
+
 func NewToolUseContent(toolID, toolName string, toolInput string) Content {
 	return ToolUseContent{
 		BaseContent: BaseContent{Type_: ContentTypeToolUse},
@@ -144,6 +222,20 @@ func (is ImageSource) MarshalZerologObject(e *zerolog.Event) {
 	e.Str("data", is.Data)
 }
 
+func (dc DocumentContent) MarshalZerologObject(e *zerolog.Event) {
+	e.Object("base", dc.BaseContent)
+	e.Str("source_type", dc.Source.Type)
+	e.Str("media_type", dc.Source.MediaType)
+	if dc.Source.URL != "" {
+		e.Str("url", dc.Source.URL)
+	}
+	e.Int("data_len", len(dc.Source.Data))
+	if dc.Title != "" {
+		e.Str("title", dc.Title)
+	}
+	e.Bool("citations", dc.Citations != nil && dc.Citations.Enabled)
+}
+
 func (tuc ToolUseContent) MarshalZerologObject(e *zerolog.Event) {
 	e.Object("base", tuc.BaseContent)
 	e.Str("id", tuc.ID)
@@ -204,6 +296,12 @@ func UnmarshalContent(data []byte) (Content, error) {
 			return nil, err
 		}
 		return thinking, nil
+	case ContentTypeDocument:
+		var document DocumentContent
+		if err := json.Unmarshal(data, &document); err != nil {
+			return nil, err
+		}
+		return document, nil
 	default:
 		return nil, fmt.Errorf("unknown content type: %s", base.Type_)
 	}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/go-go-golems/geppetto/pkg/inference/engine"
 	"github.com/go-go-golems/geppetto/pkg/security"
 	"github.com/rs/zerolog"
 )
@@ -30,6 +31,41 @@ type MessageRequest struct {
 	TopK          *int           `json:"top_k,omitempty"`
 	TopP          *float64       `json:"top_p,omitempty"`
 	OutputFormat  *OutputFormat  `json:"output_format,omitempty"`
+
+	// SystemCacheControl places a prompt-cache breakpoint on the system prompt.
+	// When set, System is sent in its text-block array form.
+	SystemCacheControl *CacheControl `json:"-"`
+}
+
+func (r MessageRequest) MarshalJSON() ([]byte, error) {
+	type alias MessageRequest
+	system, err := marshalSystem(r.System, r.SystemCacheControl)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		alias
+		System json.RawMessage `json:"system,omitempty"`
+	}{alias: alias(r), System: system})
+}
+
+func (r *MessageRequest) UnmarshalJSON(data []byte) error {
+	type alias MessageRequest
+	var temp struct {
+		alias
+		System json.RawMessage `json:"system,omitempty"`
+	}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	*r = MessageRequest(temp.alias)
+	system, cc, err := unmarshalSystem(temp.System)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal system: %w", err)
+	}
+	r.System = system
+	r.SystemCacheControl = cc
+	return nil
 }
 
 // ThinkingParam configures extended thinking for Claude models.
@@ -49,6 +85,8 @@ type Tool struct {
 	Name        string      `json:"name"`
 	Description string      `json:"description,omitempty"`
 	InputSchema interface{} `json:"input_schema"` // JSON schema for the tool input
+	// CacheControl on the last tool caches all tool definitions.
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
 }
 
 // Message represents a single message in the conversation.
@@ -99,6 +137,20 @@ type MessageCountTokensRequest struct {
 	Metadata *Metadata      `json:"metadata,omitempty"`
 	Thinking *ThinkingParam `json:"thinking,omitempty"`
 	Tools    []Tool         `json:"tools,omitempty"`
+
+	SystemCacheControl *CacheControl `json:"-"`
+}
+
+func (r MessageCountTokensRequest) MarshalJSON() ([]byte, error) {
+	type alias MessageCountTokensRequest
+	system, err := marshalSystem(r.System, r.SystemCacheControl)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		alias
+		System json.RawMessage `json:"system,omitempty"`
+	}{alias: alias(r), System: system})
 }
 
 type MessageCountTokensResponse struct {
@@ -263,9 +315,9 @@ func (c *Client) StreamMessage(ctx context.Context, req *MessageRequest) (<-chan
 		log.Error().Err(err).Str("respBody", truncatedRespBody).Msg("Error reading response body")
 		if unmarshalErr := json.Unmarshal(respBody, &errorResp); unmarshalErr != nil {
 			log.Error().Err(unmarshalErr).Str("respBody", truncatedRespBody).Msg("Error unmarshalling error response")
-			return nil, unmarshalErr
+			return nil, &engine.StatusError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("claude API error: status=%d", resp.StatusCode)}
 		}
-		return nil, fmt.Errorf("claude API error: %s", errorResp.Error.Message)
+		return nil, &engine.StatusError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("claude API error: %s", errorResp.Error.Message)}
 	}
 
 	events := make(chan StreamingEvent)
@@ -33,6 +33,7 @@ const (
 	InputJSONDeltaType StreamingDeltaType = "input_json_delta"
 	ThinkingDeltaType  StreamingDeltaType = "thinking_delta"
 	SignatureDeltaType StreamingDeltaType = "signature_delta"
+	CitationsDeltaType StreamingDeltaType = "citations_delta"
 )
 
 type StreamingEvent struct {
@@ -83,6 +84,7 @@ type ContentBlock struct {
 	Text      string      `json:"text,omitempty"`
 	Thinking  string      `json:"thinking,omitempty"`
 	Signature string      `json:"signature,omitempty"`
+	Citations []Citation  `json:"citations,omitempty"`
 }
 
 type Error struct {
@@ -96,6 +98,7 @@ type Delta struct {
 	PartialJSON  string             `json:"partial_json"`
 	Thinking     string             `json:"thinking,omitempty"`
 	Signature    string             `json:"signature,omitempty"`
+	Citation     *Citation          `json:"citation,omitempty"`
 	StopReason   string             `json:"stop_reason,omitempty"`
 	StopSequence string             `json:"stop_sequence,omitempty"`
 }
@@ -0,0 +1,183 @@
+package claude
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/inference/batch"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/claude/api"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// BatchClient runs Turns through Anthropic's Message Batches API. Requests are
+// built like ClaudeEngine.RunInference builds them, minus streaming, and
+// results are converted with the same block mapping.
+type BatchClient struct {
+	engine *ClaudeEngine
+}
+
+var _ batch.Client = (*BatchClient)(nil)
+
+// NewBatchClient creates a Message Batches client. Engine options configure
+// credentials the same way as for NewClaudeEngine.
+func NewBatchClient(s *settings.InferenceSettings, opts ...EngineOption) (*BatchClient, error) {
+	e, err := NewClaudeEngine(s, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &BatchClient{engine: e}, nil
+}
+
+// Submit builds one Messages request per Turn and creates the batch. Tools
+// of the context registry are attached to every request.
+func (c *BatchClient) Submit(ctx context.Context, reqs []batch.Request) (*batch.Job, error) {
+	if err := batch.ValidateRequests(reqs); err != nil {
+		return nil, err
+	}
+	client, err := c.engine.newAPIClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	create := &api.MessageBatchCreateRequest{Requests: make([]api.MessageBatchRequest, 0, len(reqs))}
+	for _, r := range reqs {
+		req, err := c.engine.buildMessageRequest(ctx, r.Turn)
+		if err != nil {
+			return nil, errors.Wrapf(err, "build request %q", r.CustomID)
+		}
+		req.Stream = false
+		create.Requests = append(create.Requests, api.MessageBatchRequest{CustomID: r.CustomID, Params: *req})
+	}
+	mb, err := client.CreateMessageBatch(ctx, create)
+	if err != nil {
+		return nil, err
+	}
+	return batchJobFromMessageBatch(mb), nil
+}
+
+// Get returns the current state of the batch.
+func (c *BatchClient) Get(ctx context.Context, id string) (*batch.Job, error) {
+	client, err := c.engine.newAPIClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mb, err := client.GetMessageBatch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return batchJobFromMessageBatch(mb), nil
+}
+
+// Cancel asks Anthropic to stop the batch.
+func (c *BatchClient) Cancel(ctx context.Context, id string) (*batch.Job, error) {
+	client, err := c.engine.newAPIClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mb, err := client.CancelMessageBatch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return batchJobFromMessageBatch(mb), nil
+}
+
+// Results downloads the results of an ended batch and appends each message
+// to a clone of its request Turn.
+func (c *BatchClient) Results(ctx context.Context, id string, reqs []batch.Request) ([]batch.Result, error) {
+	client, err := c.engine.newAPIClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mb, err := client.GetMessageBatch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if mb.ProcessingStatus != api.MessageBatchEnded {
+		return nil, batch.ErrNotFinished
+	}
+	lines, err := client.MessageBatchResults(ctx, id, mb.ResultsURL)
+	if err != nil {
+		return nil, err
+	}
+
+	requestTurns := batch.RequestTurns(reqs)
+	byID := make(map[string]batch.Result, len(lines))
+	for _, line := range lines {
+		t, ok := requestTurns[line.CustomID]
+		if !ok {
+			log.Warn().Str("batch_id", id).Str("custom_id", line.CustomID).Msg("Claude batch: result for unknown request")
+			continue
+		}
+		res := batch.Result{CustomID: line.CustomID}
+		switch line.Result.Type {
+		case api.MessageBatchResultSucceeded:
+			if line.Result.Message == nil {
+				res.Err = errors.New("claude batch: succeeded result without message")
+				break
+			}
+			res.Turn = t.Clone()
+			c.engine.appendBatchMessage(res.Turn, line.Result.Message)
+		case api.MessageBatchResultErrored:
+			msg := "unknown error"
+			if line.Result.Error != nil && line.Result.Error.Error.Message != "" {
+				msg = line.Result.Error.Error.Type + ": " + line.Result.Error.Error.Message
+			}
+			res.Err = errors.Errorf("claude batch: request errored: %s", msg)
+		default:
+			res.Err = errors.Errorf("claude batch: request %s", line.Result.Type)
+		}
+		byID[line.CustomID] = res
+	}
+	return batch.Assemble(reqs, byID), nil
+}
+
+// appendBatchMessage appends the blocks of a non-streamed response to t and
+// persists its inference result.
+func (e *ClaudeEngine) appendBatchMessage(t *turns.Turn, response *api.MessageResponse) {
+	metadata := events.EventMetadata{
+		ID: uuid.New(),
+		LLMInferenceData: events.LLMInferenceData{
+			Model: response.Model,
+			Usage: claudeResponseUsageToEventUsage(response.Usage),
+		},
+		TurnID: t.ID,
+	}
+	if sr := strings.TrimSpace(response.StopReason); sr != "" {
+		metadata.StopReason = &sr
+	}
+	cbm := NewContentBlockMerger(metadata)
+	cbm.response = response
+	hasToolCalls := appendResponseBlocks(t, response, cbm)
+	e.persistInferenceResult(t, metadata, hasToolCalls)
+}
+
+func batchJobFromMessageBatch(mb *api.MessageBatch) *batch.Job {
+	counts := mb.RequestCounts
+	job := &batch.Job{
+		ID:             mb.ID,
+		Provider:       "claude",
+		ProviderStatus: mb.ProcessingStatus,
+		Status:         batch.StatusInProgress,
+		Counts: batch.Counts{
+			Total:     counts.Processing + counts.Succeeded + counts.Errored + counts.Canceled + counts.Expired,
+			Succeeded: counts.Succeeded,
+			Failed:    counts.Errored + counts.Canceled + counts.Expired,
+		},
+	}
+	if mb.ProcessingStatus == api.MessageBatchEnded {
+		switch {
+		case counts.Succeeded+counts.Errored > 0:
+			job.Status = batch.StatusCompleted
+		case counts.Canceled > 0:
+			job.Status = batch.StatusCancelled
+		case counts.Expired > 0:
+			job.Status = batch.StatusExpired
+		default:
+			job.Status = batch.StatusCompleted
+		}
+	}
+	return job
+}
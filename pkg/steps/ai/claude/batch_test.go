@@ -0,0 +1,108 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/batch"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/claude/api"
+	aisettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	claudesettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings/claude"
+	ai_types "github.com/go-go-golems/geppetto/pkg/steps/ai/types"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchClientRoundTrip(t *testing.T) {
+	var created api.MessageBatchCreateRequest
+	polls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/messages/batches", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test", r.Header.Get("x-api-key"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+		_, _ = io.WriteString(w, `{"id":"msgbatch_1","type":"message_batch","processing_status":"in_progress","request_counts":{"processing":2}}`)
+	})
+	mux.HandleFunc("GET /v1/messages/batches/msgbatch_1", func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		if polls < 2 {
+			_, _ = io.WriteString(w, `{"id":"msgbatch_1","type":"message_batch","processing_status":"in_progress","request_counts":{"processing":1,"succeeded":1}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"id":"msgbatch_1","type":"message_batch","processing_status":"ended","request_counts":{"succeeded":1,"errored":1},"results_url":"https://api.anthropic.com/v1/messages/batches/msgbatch_1/results"}`)
+	})
+	mux.HandleFunc("GET /v1/messages/batches/msgbatch_1/results", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, strings.Join([]string{
+			`{"custom_id":"weather","result":{"type":"succeeded","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-20250514","content":[{"type":"text","text":"Let me check."},{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"city":"Paris"}}],"stop_reason":"tool_use","usage":{"input_tokens":12,"output_tokens":8}}}}`,
+			`{"custom_id":"broken","result":{"type":"errored","error":{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens too large"}}}}`,
+		}, "\n")+"\n")
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+	targetURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	httpClient := server.Client()
+	httpClient.Transport = &claudeHeaderTransport{
+		base:   httpClient.Transport,
+		target: targetURL,
+		host:   "api.anthropic.com",
+		scheme: "https",
+		header: "X-Test-Transport",
+		value:  "claude-batch",
+	}
+
+	engine := "claude-sonnet-4-20250514"
+	apiType := ai_types.ApiTypeClaude
+	client, err := NewBatchClient(&aisettings.InferenceSettings{
+		Client: &aisettings.ClientSettings{HTTPClient: httpClient},
+		Claude: &claudesettings.Settings{},
+		API: &aisettings.APISettings{
+			APIKeys:  map[string]string{"claude-api-key": "test"},
+			BaseUrls: map[string]string{"claude-base-url": "https://api.anthropic.com"},
+		},
+		Chat: &aisettings.ChatSettings{Engine: &engine, ApiType: &apiType, Stream: true},
+	})
+	require.NoError(t, err)
+
+	reqs := []batch.Request{
+		{CustomID: "weather", Turn: &turns.Turn{ID: "t1", Blocks: []turns.Block{turns.NewUserTextBlock("Weather in Paris?")}}},
+		{CustomID: "broken", Turn: &turns.Turn{ID: "t2", Blocks: []turns.Block{turns.NewUserTextBlock("hi")}}},
+	}
+	results, job, err := batch.Run(context.Background(), client, reqs, batch.WithPollInterval(time.Millisecond))
+	require.NoError(t, err)
+	assert.Equal(t, batch.StatusCompleted, job.Status)
+	assert.Equal(t, batch.Counts{Total: 2, Succeeded: 1, Failed: 1}, job.Counts)
+
+	require.Len(t, created.Requests, 2)
+	assert.Equal(t, "weather", created.Requests[0].CustomID)
+	assert.Equal(t, engine, created.Requests[0].Params.Model)
+	assert.False(t, created.Requests[0].Params.Stream)
+
+	require.Len(t, results, 2)
+	require.NoError(t, results[0].Err)
+	out := results[0].Turn
+	require.Len(t, out.Blocks, 3)
+	assert.Equal(t, turns.BlockKindLLMText, out.Blocks[1].Kind)
+	assert.Equal(t, "Let me check.", out.Blocks[1].Payload[turns.PayloadKeyText])
+	assert.Equal(t, turns.BlockKindToolCall, out.Blocks[2].Kind)
+	assert.Equal(t, "toolu_1", out.Blocks[2].Payload[turns.PayloadKeyID])
+	assert.Equal(t, map[string]any{"city": "Paris"}, out.Blocks[2].Payload[turns.PayloadKeyArgs])
+
+	res, ok, err := turns.KeyTurnMetaInferenceResult.Get(out.Metadata)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "tool_use", res.StopReason)
+	require.NotNil(t, res.Usage)
+	assert.Equal(t, 12, res.Usage.InputTokens)
+
+	require.Error(t, results[1].Err)
+	assert.Contains(t, results[1].Err.Error(), "max_tokens too large")
+	assert.Len(t, reqs[0].Turn.Blocks, 1)
+}
@@ -309,7 +309,7 @@ func (cbm *ContentBlockMerger) Add(event api.StreamingEvent) ([]events.Event, er
 			return []events.Event{events.NewToolCallStartedEvent(cbm.metadata, corr, event.ContentBlock.ID, event.ContentBlock.Name)}, nil
 		case api.ContentTypeThinking:
 			return []events.Event{events.NewReasoningSegmentStartedEvent(cbm.metadata, cbm.contentBlockCorrelation(event.Index, events.SegmentTypeReasoning), "thinking")}, nil
-		case api.ContentTypeImage, api.ContentTypeToolResult:
+		case api.ContentTypeImage, api.ContentTypeToolResult, api.ContentTypeDocument:
 			return []events.Event{}, nil
 		default:
 			return []events.Event{}, nil
@@ -353,6 +353,17 @@ func (cbm *ContentBlockMerger) Add(event api.StreamingEvent) ([]events.Event, er
 		case api.SignatureDeltaType:
 			cb.Signature += event.Delta.Signature
 			return []events.Event{}, nil
+		case api.CitationsDeltaType:
+			if event.Delta.Citation == nil {
+				return []events.Event{}, nil
+			}
+			c := *event.Delta.Citation
+			cb.Citations = append(cb.Citations, c)
+			index, docIndex := event.Index, c.DocumentIndex
+			ev := events.NewCitation(cbm.metadata, c.DocumentTitle, "", nil, nil, nil, &index, nil)
+			ev.CitedText = c.CitedText
+			ev.DocumentIndex = &docIndex
+			return []events.Event{ev}, nil
 		}
 		return []events.Event{}, nil
 
@@ -366,7 +377,11 @@ func (cbm *ContentBlockMerger) Add(event api.StreamingEvent) ([]events.Event, er
 		}
 		switch cb.Type {
 		case api.ContentTypeText:
-			cbm.response.Content = append(cbm.response.Content, api.NewTextContent(cb.Text))
+			cbm.response.Content = append(cbm.response.Content, api.TextContent{
+				BaseContent: api.BaseContent{Type_: api.ContentTypeText},
+				Text:        cb.Text,
+				Citations:   cb.Citations,
+			})
 			return []events.Event{events.NewTextSegmentFinishedEvent(cbm.metadata, cbm.contentBlockCorrelation(event.Index, events.SegmentTypeText), cb.Text, "content_block_stop")}, nil
 
 		case api.ContentTypeToolUse:
@@ -391,7 +406,7 @@ func (cbm *ContentBlockMerger) Add(event api.StreamingEvent) ([]events.Event, er
 			cbm.response.Content = append(cbm.response.Content, api.NewThinkingContent(cb.Thinking, cb.Signature))
 			return []events.Event{events.NewReasoningSegmentFinishedEventWithSource(cbm.metadata, cbm.contentBlockCorrelation(event.Index, events.SegmentTypeReasoning), "thinking", cb.Thinking, "content_block_stop")}, nil
 
-		case api.ContentTypeImage, api.ContentTypeToolResult:
+		case api.ContentTypeImage, api.ContentTypeToolResult, api.ContentTypeDocument:
 			return nil, errors.Errorf("Unsupported content block type: %s", cb.Type)
 		}
 
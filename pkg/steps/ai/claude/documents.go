@@ -0,0 +1,65 @@
+package claude
+
+import (
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/claude/api"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/mediaparts"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+)
+
+// fileContent converts a normalized file part into Claude content: PDFs and
+// plain text become document blocks, inline images image blocks. Other
+// parts, and sources Claude cannot fetch, are rejected.
+func fileContent(p mediaparts.Part) (api.Content, error) {
+	switch p.Kind {
+	case mediaparts.KindPDF:
+		switch {
+		case len(p.Data) > 0:
+			return api.NewDocumentContent(api.DocumentSource{Type: api.DocumentSourceBase64, MediaType: "application/pdf", Data: p.Base64()}, p.Title, p.Context, p.Citations), nil
+		case p.URL != "":
+			return api.NewDocumentContent(api.DocumentSource{Type: api.DocumentSourceURL, URL: p.URL}, p.Title, p.Context, p.Citations), nil
+		}
+	case mediaparts.KindText:
+		text := p.Text
+		if text == "" {
+			text = string(p.Data)
+		}
+		if text != "" {
+			return api.NewDocumentContent(api.DocumentSource{Type: api.DocumentSourceText, MediaType: "text/plain", Data: text}, p.Title, p.Context, p.Citations), nil
+		}
+	case mediaparts.KindImage:
+		if len(p.Data) > 0 {
+			return api.NewImageContent(p.MediaType, p.Base64()), nil
+		}
+	case mediaparts.KindAudio, mediaparts.KindVideo, mediaparts.KindFile:
+	}
+	return nil, mediaparts.Unsupported("claude", p)
+}
+
+// citationsFromContent converts the citations of a response text content into
+// block citations covering [start, end) of the assistant block text.
+func citationsFromContent(cs []api.Citation, start, end int) []turns.Citation {
+	if len(cs) == 0 {
+		return nil
+	}
+	ret := make([]turns.Citation, 0, len(cs))
+	for _, c := range cs {
+		tc := turns.Citation{
+			Start:         start,
+			End:           end,
+			Type:          c.Type,
+			CitedText:     c.CitedText,
+			DocumentIndex: c.DocumentIndex,
+			DocumentTitle: c.DocumentTitle,
+		}
+		switch c.Type {
+		case api.CitationTypeCharLocation:
+			tc.SourceStart, tc.SourceEnd = c.StartCharIndex, c.EndCharIndex
+		case api.CitationTypePageLocation:
+			tc.SourceStart, tc.SourceEnd = c.StartPageNumber, c.EndPageNumber
+		case api.CitationTypeContentBlockLocation:
+			tc.SourceStart, tc.SourceEnd = c.StartBlockIndex, c.EndBlockIndex
+		}
+		ret = append(ret, tc)
+	}
+	return ret
+}
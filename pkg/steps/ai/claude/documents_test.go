@@ -0,0 +1,200 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/claude/api"
+	aisettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	claudesettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings/claude"
+	ai_types "github.com/go-go-golems/geppetto/pkg/steps/ai/types"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMakeMessageRequestFromTurnUserDocuments(t *testing.T) {
+	engine := "claude-sonnet-4-20250514"
+	e := newTestEngine(&aisettings.InferenceSettings{
+		Client: &aisettings.ClientSettings{},
+		Claude: &claudesettings.Settings{},
+		Chat:   &aisettings.ChatSettings{Engine: &engine, Stream: true},
+	})
+	tu := &turns.Turn{Blocks: []turns.Block{
+		turns.NewUserDocumentBlock("summarize", []map[string]any{
+			{"media_type": "application/pdf", "content": []byte("%PDF"), "title": "Report", "citations": true},
+			{"text": "The grass is green.", "title": "Notes", "context": "field notes"},
+			{"url": "https://example.com/paper.pdf"},
+		}),
+	}}
+
+	req, err := e.MakeMessageRequestFromTurn(tu)
+	require.NoError(t, err)
+	require.Len(t, req.Messages, 1)
+	content := req.Messages[0].Content
+	require.Len(t, content, 4)
+
+	pdf, ok := content[0].(api.DocumentContent)
+	require.True(t, ok, "first content = %#v", content[0])
+	assert.Equal(t, api.DocumentSource{Type: api.DocumentSourceBase64, MediaType: "application/pdf", Data: "JVBERg=="}, pdf.Source)
+	assert.Equal(t, "Report", pdf.Title)
+	require.NotNil(t, pdf.Citations)
+	assert.True(t, pdf.Citations.Enabled)
+
+	text, ok := content[1].(api.DocumentContent)
+	require.True(t, ok, "second content = %#v", content[1])
+	assert.Equal(t, api.DocumentSource{Type: api.DocumentSourceText, MediaType: "text/plain", Data: "The grass is green."}, text.Source)
+	assert.Equal(t, "field notes", text.Context)
+	assert.Nil(t, text.Citations)
+
+	remote, ok := content[2].(api.DocumentContent)
+	require.True(t, ok, "third content = %#v", content[2])
+	assert.Equal(t, api.DocumentSource{Type: api.DocumentSourceURL, URL: "https://example.com/paper.pdf"}, remote.Source)
+
+	_, ok = content[3].(api.TextContent)
+	assert.True(t, ok, "prompt text should follow the documents")
+
+	b, err := json.Marshal(pdf)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"document","source":{"type":"base64","media_type":"application/pdf","data":"JVBERg=="},"title":"Report","citations":{"enabled":true}}`, string(b))
+}
+
+func TestMakeMessageRequestFromTurnRejectsUnsupportedDocumentMediaType(t *testing.T) {
+	engine := "claude-sonnet-4-20250514"
+	e := newTestEngine(&aisettings.InferenceSettings{
+		Client: &aisettings.ClientSettings{},
+		Claude: &claudesettings.Settings{},
+		Chat:   &aisettings.ChatSettings{Engine: &engine, Stream: true},
+	})
+	tu := &turns.Turn{Blocks: []turns.Block{
+		turns.NewUserDocumentBlock("read", []map[string]any{
+			{"media_type": "application/zip", "content": []byte("PK")},
+		}),
+	}}
+	_, err := e.MakeMessageRequestFromTurn(tu)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "application/zip")
+}
+
+type citationCollector struct {
+	citations []*events.EventCitation
+}
+
+func (c *citationCollector) PublishEvent(e events.Event) error {
+	if ev, ok := e.(*events.EventCitation); ok {
+		c.citations = append(c.citations, ev)
+	}
+	return nil
+}
+
+func TestClaudeRunInferenceStoresCitationsOnTextBlock(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(strings.Join([]string{
+			"event: message_start",
+			`data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"model":"claude-sonnet-4-20250514","usage":{"input_tokens":1,"output_tokens":0}}}`,
+			"",
+			"event: content_block_start",
+			`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			"",
+			"event: content_block_delta",
+			`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"According to the notes, "}}`,
+			"",
+			"event: content_block_stop",
+			`data: {"type":"content_block_stop","index":0}`,
+			"",
+			"event: content_block_start",
+			`data: {"type":"content_block_start","index":1,"content_block":{"type":"text","text":"","citations":[]}}`,
+			"",
+			"event: content_block_delta",
+			`data: {"type":"content_block_delta","index":1,"delta":{"type":"citations_delta","citation":{"type":"char_location","cited_text":"The grass is green.","document_index":0,"document_title":"Notes","start_char_index":0,"end_char_index":19}}}`,
+			"",
+			"event: content_block_delta",
+			`data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"the grass is green"}}`,
+			"",
+			"event: content_block_stop",
+			`data: {"type":"content_block_stop","index":1}`,
+			"",
+			"event: content_block_start",
+			`data: {"type":"content_block_start","index":2,"content_block":{"type":"text","text":""}}`,
+			"",
+			"event: content_block_delta",
+			`data: {"type":"content_block_delta","index":2,"delta":{"type":"text_delta","text":"."}}`,
+			"",
+			"event: content_block_stop",
+			`data: {"type":"content_block_stop","index":2}`,
+			"",
+			"event: message_delta",
+			`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":8}}`,
+			"",
+			"event: message_stop",
+			`data: {"type":"message_stop"}`,
+			"",
+		}, "\n")))
+	}))
+	defer server.Close()
+	targetURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	httpClient := server.Client()
+	httpClient.Transport = &claudeHeaderTransport{
+		base:   httpClient.Transport,
+		target: targetURL,
+		host:   "api.anthropic.com",
+		scheme: "https",
+		header: "X-Test-Transport",
+		value:  "claude-proxy",
+	}
+	engine := "claude-sonnet-4-20250514"
+	apiType := ai_types.ApiTypeClaude
+	e := newTestEngine(&aisettings.InferenceSettings{
+		Client: &aisettings.ClientSettings{HTTPClient: httpClient},
+		Claude: &claudesettings.Settings{},
+		API: &aisettings.APISettings{
+			APIKeys:  map[string]string{"claude-api-key": "test"},
+			BaseUrls: map[string]string{"claude-base-url": "https://api.anthropic.com"},
+		},
+		Chat: &aisettings.ChatSettings{Engine: &engine, ApiType: &apiType},
+	})
+
+	collector := &citationCollector{}
+	ctx := events.WithEventSinks(context.Background(), collector)
+	out, err := e.RunInference(ctx, &turns.Turn{Blocks: []turns.Block{
+		turns.NewUserDocumentBlock("What color is the grass?", []map[string]any{
+			{"text": "The grass is green.", "title": "Notes", "citations": true},
+		}),
+	}})
+	require.NoError(t, err)
+
+	require.Len(t, out.Blocks, 2)
+	reply := out.Blocks[1]
+	require.Equal(t, turns.BlockKindLLMText, reply.Kind)
+	text := reply.Payload[turns.PayloadKeyText].(string)
+	assert.Equal(t, "According to the notes, the grass is green.", text)
+
+	citations, ok, err := turns.KeyBlockMetaCitations.Get(reply.Metadata)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, citations, 1)
+	c := citations[0]
+	assert.Equal(t, "the grass is green", text[c.Start:c.End])
+	assert.Equal(t, "The grass is green.", c.CitedText)
+	assert.Equal(t, "Notes", c.DocumentTitle)
+	assert.Equal(t, api.CitationTypeCharLocation, c.Type)
+	require.NotNil(t, c.SourceStart)
+	require.NotNil(t, c.SourceEnd)
+	assert.Equal(t, 0, *c.SourceStart)
+	assert.Equal(t, 19, *c.SourceEnd)
+
+	require.Len(t, collector.citations, 1)
+	assert.Equal(t, "Notes", collector.citations[0].Title)
+	assert.Equal(t, "The grass is green.", collector.citations[0].CitedText)
+	require.NotNil(t, collector.citations[0].ContentIndex)
+	assert.Equal(t, 1, *collector.citations[0].ContentIndex)
+}
@@ -13,6 +13,9 @@ import (
 	"github.com/go-go-golems/geppetto/pkg/steps"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/claude/api"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/credentials"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/providerhttp"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/ratelimit"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/retry"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/runtimeattrib"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
 	"github.com/go-go-golems/geppetto/pkg/turns"
@@ -60,8 +63,7 @@ func (e *ClaudeEngine) RunInference(
 ) (*turns.Turn, error) {
 	// Build request messages directly from Turn blocks (no conversation dependency)
 	log.Debug().Int("num_blocks", len(t.Blocks)).Bool("stream", e.settings.Chat.Stream).Msg("Claude RunInference started")
-	clientSettings := e.settings.Client
-	if clientSettings == nil {
+	if e.settings.Client == nil {
 		return nil, steps.ErrMissingClientSettings
 	}
 	anthropicSettings := e.settings.Claude
@@ -69,51 +71,11 @@ func (e *ClaudeEngine) RunInference(
 		return nil, errors.New("no claude settings")
 	}
 
-	apiType_ := e.settings.Chat.ApiType
-	if apiType_ == nil {
-		return nil, errors.New("no chat engine specified")
-	}
-	apiType := *apiType_
-	apiSettings := e.settings.API
-
-	baseURL, ok := apiSettings.BaseUrls[string(apiType)+"-base-url"]
-	if !ok {
-		return nil, errors.Errorf("no base URL for %s", apiType)
-	}
-	apiKey, ok := apiSettings.APIKeys[string(apiType)+"-api-key"]
-	if e.bearerTokenSource != nil {
-		request := credentials.Request{Provider: string(apiType), BaseURL: strings.TrimRight(baseURL, "/")}
-		resolvedToken, resolveErr := e.bearerTokenSource.BearerToken(ctx, request)
-		if resolveErr != nil {
-			return nil, errors.New("resolve Anthropic gateway credential")
-		}
-		apiKey = resolvedToken
-		if strings.TrimSpace(apiKey) == "" {
-			return nil, errors.New("Anthropic gateway credential is empty")
-		}
-		ok = true
-	}
-	if !ok {
-		return nil, errors.Errorf("no API key for %s", apiType)
-	}
-	client := api.NewClient(apiKey, baseURL)
-	bearerAuthorization := e.bearerAuthorization
-	if e.bearerTokenSource != nil && bearerAuthorization == "" {
-		bearerAuthorization = apiKey
-	}
-	if e.oauthBearerMode {
-		client.SetOAuthBearerAuthorization(bearerAuthorization)
-	} else {
-		client.SetBearerAuthorization(bearerAuthorization)
-	}
-	client.SetOutboundURLOptions(settings.OutboundURLOptions(apiSettings, string(apiType)))
-	httpClient, err := settings.EnsureHTTPClient(clientSettings)
+	client, err := e.newAPIClient(ctx)
 	if err != nil {
 		return nil, err
 	}
-	client.SetHTTPClient(httpClient)
-
-	req, err := e.MakeMessageRequestFromTurn(t)
+	req, err := e.buildMessageRequest(ctx, t)
 	if err != nil {
 		return nil, err
 	}
@@ -124,29 +86,6 @@ func (e *ClaudeEngine) RunInference(
 	// message_start event.
 	req.Stream = true
 
-	// Add tools from context if present (no Turn.Data registry).
-	if reg, ok := tools.RegistryFrom(ctx); ok && reg != nil {
-		var claudeTools []api.Tool
-		for _, tool := range reg.ListTools() {
-			claudeTool := api.Tool{
-				Name:        tool.Name,
-				Description: tool.Description,
-				InputSchema: tool.Parameters,
-			}
-			claudeTools = append(claudeTools, claudeTool)
-			log.Trace().
-				Str("tool_name", claudeTool.Name).
-				Str("tool_description", claudeTool.Description).
-				Interface("tool_input_schema", claudeTool.InputSchema).
-				Msg("Converted tool to Claude format")
-		}
-		req.Tools = claudeTools
-		log.Debug().
-			Int("claude_tool_count", len(claudeTools)).
-			Msg("Tools added to Claude request from context")
-	}
-	// Do not force defaults for Temperature/TopP; omit when at API defaults (1.0)
-
 	// Setup metadata and event publishing
 	metadata := events.EventMetadata{
 		ID: uuid.New(),
@@ -184,7 +123,11 @@ func (e *ClaudeEngine) RunInference(
 	// Non-streaming mode removed. We always use streaming.
 
 	// For streaming, we need to collect all events and return the final message
-	eventCh, err := client.StreamMessage(ctx, req)
+	// The provider-call ID is only known after message_start, so retries carry
+	// the run correlation.
+	streamCtx := ratelimit.WithTokenEstimate(ctx, ratelimit.EstimateTurnTokens(t))
+	streamCtx = retry.WithEventReporter(streamCtx, metadata, events.BuildRunCorrelation(metadata.SessionID, metadata.InferenceID, metadata.TurnID), e.publishEvent)
+	eventCh, err := client.StreamMessage(streamCtx, req)
 	if err != nil {
 		log.Error().Err(err).Msg("Claude streaming request failed")
 		e.publishEvent(ctx, events.NewErrorEvent(metadata, err))
@@ -262,19 +205,148 @@ streamingComplete:
 		Str("stop_reason", stopReason).
 		Msg("Claude metadata finalized")
 
-	// Create blocks from content blocks: text -> llm_text, tool_use -> tool_call
+	hasToolCalls := appendResponseBlocks(t, response, completionMerger)
+	e.persistInferenceResult(t, metadata, hasToolCalls)
+
+	// NOTE: Final event is already published by ContentBlockMerger during event processing
+	log.Trace().Msg("Claude RunInference completed (streaming)")
+	return t, nil
+}
+
+// newAPIClient builds an Anthropic API client from the engine settings and
+// credential options.
+func (e *ClaudeEngine) newAPIClient(ctx context.Context) (*api.Client, error) {
+	clientSettings := e.settings.Client
+	if clientSettings == nil {
+		return nil, steps.ErrMissingClientSettings
+	}
+	apiType_ := e.settings.Chat.ApiType
+	if apiType_ == nil {
+		return nil, errors.New("no chat engine specified")
+	}
+	apiType := *apiType_
+	apiSettings := e.settings.API
+
+	baseURL, ok := apiSettings.BaseUrls[string(apiType)+"-base-url"]
+	if !ok {
+		return nil, errors.Errorf("no base URL for %s", apiType)
+	}
+	apiKey, ok := apiSettings.APIKeys[string(apiType)+"-api-key"]
+	if e.bearerTokenSource != nil {
+		request := credentials.Request{Provider: string(apiType), BaseURL: strings.TrimRight(baseURL, "/")}
+		resolvedToken, resolveErr := e.bearerTokenSource.BearerToken(ctx, request)
+		if resolveErr != nil {
+			return nil, errors.New("resolve Anthropic gateway credential")
+		}
+		apiKey = resolvedToken
+		if strings.TrimSpace(apiKey) == "" {
+			return nil, errors.New("Anthropic gateway credential is empty")
+		}
+		ok = true
+	}
+	if !ok {
+		return nil, errors.Errorf("no API key for %s", apiType)
+	}
+	client := api.NewClient(apiKey, baseURL)
+	bearerAuthorization := e.bearerAuthorization
+	if e.bearerTokenSource != nil && bearerAuthorization == "" {
+		bearerAuthorization = apiKey
+	}
+	if e.oauthBearerMode {
+		client.SetOAuthBearerAuthorization(bearerAuthorization)
+	} else {
+		client.SetBearerAuthorization(bearerAuthorization)
+	}
+	client.SetOutboundURLOptions(settings.OutboundURLOptions(apiSettings, string(apiType)))
+	model := ""
+	if e.settings.Chat.Engine != nil {
+		model = *e.settings.Chat.Engine
+	}
+	httpClient, err := providerhttp.Client(clientSettings, ratelimit.NewKey(string(apiType), model, apiKey))
+	if err != nil {
+		return nil, err
+	}
+	client.SetHTTPClient(httpClient)
+	return client, nil
+}
+
+// buildMessageRequest builds the Messages API request for t, including the
+// tools of the context registry and prompt-cache breakpoints.
+func (e *ClaudeEngine) buildMessageRequest(ctx context.Context, t *turns.Turn) (*api.MessageRequest, error) {
+	req, err := e.MakeMessageRequestFromTurn(t)
+	if err != nil {
+		return nil, err
+	}
+	// Add tools from context if present (no Turn.Data registry).
+	if reg, ok := tools.RegistryFrom(ctx); ok && reg != nil {
+		var claudeTools []api.Tool
+		for _, tool := range reg.ListTools() {
+			claudeTool := api.Tool{
+				Name:        tool.Name,
+				Description: tool.Description,
+				InputSchema: tool.Parameters,
+			}
+			claudeTools = append(claudeTools, claudeTool)
+			log.Trace().
+				Str("tool_name", claudeTool.Name).
+				Str("tool_description", claudeTool.Description).
+				Interface("tool_input_schema", claudeTool.InputSchema).
+				Msg("Converted tool to Claude format")
+		}
+		req.Tools = claudeTools
+		log.Debug().
+			Int("claude_tool_count", len(claudeTools)).
+			Msg("Tools added to Claude request from context")
+	}
+	// Prompt-cache breakpoints depend on the final tool list, so they are placed
+	// after tools are attached.
+	var promptCacheCfg *engine.ClaudePromptCacheConfig
+	if claudeCfg := engine.ResolveClaudeInferenceConfig(t); claudeCfg != nil {
+		promptCacheCfg = claudeCfg.PromptCache
+	}
+	applyPromptCachePolicy(req, promptCacheCfg)
+	// Do not force defaults for Temperature/TopP; omit when at API defaults (1.0)
+
+	return req, nil
+}
+
+// appendResponseBlocks appends the blocks of a complete response to t and
+// reports whether it requested tool calls. cbm provides block correlations.
+func appendResponseBlocks(t *turns.Turn, response *api.MessageResponse, cbm *ContentBlockMerger) bool {
+	// Create blocks from content blocks: text -> llm_text, tool_use -> tool_call.
+	// Consecutive text contents form one llm_text block: when citing documents,
+	// Claude splits its answer at every cited span.
 	hasToolCalls := false
+	var text strings.Builder
+	var citations []turns.Citation
+	flushText := func() {
+		if text.Len() == 0 {
+			return
+		}
+		b := turns.NewAssistantTextBlock(text.String())
+		if len(citations) > 0 {
+			if err := turns.KeyBlockMetaCitations.Set(&b.Metadata, citations); err != nil {
+				log.Warn().Err(err).Msg("Claude: failed to store citations on text block")
+			}
+		}
+		turns.AppendBlock(t, b)
+		text.Reset()
+		citations = nil
+	}
 	for i, c := range response.Content {
+		if _, ok := c.(api.TextContent); !ok {
+			flushText()
+		}
 		switch v := c.(type) {
 		case api.TextContent:
-			if s := v.Text; s != "" {
-				turns.AppendBlock(t, turns.NewAssistantTextBlock(s))
-			}
+			start := text.Len()
+			text.WriteString(v.Text)
+			citations = append(citations, citationsFromContent(v.Citations, start, text.Len())...)
 		case api.ToolUseContent:
 			hasToolCalls = true
 			var args any
 			_ = json.Unmarshal(v.Input, &args)
-			corr := completionMerger.contentBlockCorrelation(i, events.SegmentTypeTool)
+			corr := cbm.contentBlockCorrelation(i, events.SegmentTypeTool)
 			corr.ToolCallID = v.ID
 			turns.AppendBlock(t, toolblocks.NewToolCallBlockWithCorrelation(v.ID, v.Name, args, corr))
 		case api.ThinkingContent:
@@ -293,16 +365,17 @@ streamingComplete:
 			})
 		}
 	}
+	flushText()
+	return hasToolCalls
+}
 
+// persistInferenceResult stores the canonical inference result on t.
+func (e *ClaudeEngine) persistInferenceResult(t *turns.Turn, metadata events.EventMetadata, hasToolCalls bool) {
 	result := engine.BuildInferenceResultFromEventMetadata(metadata, "claude", hasToolCalls)
 	settings.ApplyModelInfoCost(&result, e.settings.ModelInfo)
 	if err := engine.PersistInferenceResult(t, result); err != nil {
 		log.Warn().Err(err).Msg("Claude: failed to persist canonical inference_result")
 	}
-
-	// NOTE: Final event is already published by ContentBlockMerger during event processing
-	log.Trace().Msg("Claude RunInference completed (streaming)")
-	return t, nil
 }
 
 func syncClaudeEventMetadata(dst *events.EventMetadata, src events.EventMetadata) {
@@ -10,6 +10,7 @@ import (
 	"github.com/go-go-golems/geppetto/pkg/steps"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/claude/api"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/imageparts"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/mediaparts"
 	"github.com/go-go-golems/geppetto/pkg/turns"
 	"github.com/pkg/errors"
 )
@@ -17,8 +18,9 @@ import (
 const claudePayloadKeySignature = "signature"
 
 type messageProjection struct {
-	System   string
-	Messages []api.Message
+	System             string
+	SystemCacheControl *api.CacheControl
+	Messages           []api.Message
 }
 
 // Removed obsolete messageToClaudeMessage (conversation-based)
@@ -46,6 +48,9 @@ func (e *ClaudeEngine) MakeMessageRequestFromTurn(
 	} else {
 		return nil, errors.New("no engine specified")
 	}
+	if err := mediaparts.CheckTurnInput(s.ModelInfo, t); err != nil {
+		return nil, err
+	}
 
 	projection, err := e.buildMessageProjectionFromTurn(t)
 	if err != nil {
@@ -94,6 +99,8 @@ func (e *ClaudeEngine) MakeMessageRequestFromTurn(
 		Tools:         nil,
 		TopK:          nil,
 		TopP:          topPPtr,
+
+		SystemCacheControl: projection.SystemCacheControl,
 	}
 
 	// Apply provider-native structured output schema when configured.
@@ -186,8 +193,26 @@ func (e *ClaudeEngine) buildMessageProjectionFromTurn(t *turns.Turn) (*messagePr
 	}
 	systemPrompt := ""
 	hasSystemPrompt := false
+	var systemCacheControl *api.CacheControl
+	var cacheCfg *infengine.ClaudePromptCacheConfig
+	if t != nil {
+		claudeCfg, ok, err := infengine.KeyClaudeInferenceConfig.Get(t.Data)
+		if err != nil {
+			return nil, errors.Wrap(err, "get claude inference config")
+		}
+		if ok {
+			cacheCfg = claudeCfg.PromptCache
+		}
+	}
+	if err := cacheCfg.Validate(); err != nil {
+		return nil, err
+	}
 	if t != nil {
 		for _, b := range t.Blocks {
+			cacheControl, err := blockCacheControl(b, cacheCfg)
+			if err != nil {
+				return nil, err
+			}
 			switch b.Kind {
 			case turns.BlockKindSystem:
 				text := ""
@@ -200,9 +225,10 @@ func (e *ClaudeEngine) buildMessageProjectionFromTurn(t *turns.Turn) (*messagePr
 				}
 				if !hasSystemPrompt {
 					systemPrompt = text
+					systemCacheControl = cacheControl
 					hasSystemPrompt = true
 				} else if text != "" {
-					msg := api.Message{Role: RoleUser, Content: []api.Content{api.NewTextContent(text)}}
+					msg := withMessageCacheControl(api.Message{Role: RoleUser, Content: []api.Content{api.NewTextContent(text)}}, cacheControl)
 					if toolPhaseActive {
 						delayedMsgs = append(delayedMsgs, msg)
 					} else {
@@ -214,7 +240,7 @@ func (e *ClaudeEngine) buildMessageProjectionFromTurn(t *turns.Turn) (*messagePr
 					return nil, errors.Wrap(err, "get claude original content (user block)")
 				} else if ok && orig != nil {
 					if arr, ok2 := orig.([]api.Content); ok2 && len(arr) > 0 {
-						msg := api.Message{Role: RoleUser, Content: arr}
+						msg := withMessageCacheControl(api.Message{Role: RoleUser, Content: arr}, cacheControl)
 						if toolPhaseActive {
 							delayedMsgs = append(delayedMsgs, msg)
 						} else {
@@ -232,6 +258,18 @@ func (e *ClaudeEngine) buildMessageProjectionFromTurn(t *turns.Turn) (*messagePr
 					}
 				}
 				parts := []api.Content{}
+				// Documents go before the prompt text, as Anthropic recommends.
+				files, err := mediaparts.FromPayload(b.Payload)
+				if err != nil {
+					return nil, err
+				}
+				for _, f := range files {
+					part, err := fileContent(f)
+					if err != nil {
+						return nil, err
+					}
+					parts = append(parts, part)
+				}
 				if text != "" {
 					parts = append(parts, api.NewTextContent(text))
 				}
@@ -248,7 +286,7 @@ func (e *ClaudeEngine) buildMessageProjectionFromTurn(t *turns.Turn) (*messagePr
 					}
 				}
 				if len(parts) > 0 {
-					msg := api.Message{Role: RoleUser, Content: parts}
+					msg := withMessageCacheControl(api.Message{Role: RoleUser, Content: parts}, cacheControl)
 					if toolPhaseActive {
 						delayedMsgs = append(delayedMsgs, msg)
 					} else {
@@ -260,7 +298,7 @@ func (e *ClaudeEngine) buildMessageProjectionFromTurn(t *turns.Turn) (*messagePr
 					return nil, errors.Wrap(err, "get claude original content (assistant block)")
 				} else if ok && orig != nil {
 					if arr, ok2 := orig.([]api.Content); ok2 && len(arr) > 0 {
-						msg := api.Message{Role: RoleAssistant, Content: arr}
+						msg := withMessageCacheControl(api.Message{Role: RoleAssistant, Content: arr}, cacheControl)
 						if toolPhaseActive {
 							delayedMsgs = append(delayedMsgs, msg)
 						} else {
@@ -278,7 +316,7 @@ func (e *ClaudeEngine) buildMessageProjectionFromTurn(t *turns.Turn) (*messagePr
 					}
 				}
 				if text != "" {
-					msg := api.Message{Role: RoleAssistant, Content: []api.Content{api.NewTextContent(text)}}
+					msg := withMessageCacheControl(api.Message{Role: RoleAssistant, Content: []api.Content{api.NewTextContent(text)}}, cacheControl)
 					if toolPhaseActive {
 						delayedMsgs = append(delayedMsgs, msg)
 					} else {
@@ -301,7 +339,7 @@ func (e *ClaudeEngine) buildMessageProjectionFromTurn(t *turns.Turn) (*messagePr
 				if signature != "" {
 					content = api.NewThinkingContent(text, signature)
 				}
-				msg := api.Message{Role: RoleAssistant, Content: []api.Content{content}}
+				msg := withMessageCacheControl(api.Message{Role: RoleAssistant, Content: []api.Content{content}}, cacheControl)
 				if toolPhaseActive {
 					delayedMsgs = append(delayedMsgs, msg)
 				} else {
@@ -329,19 +367,19 @@ func (e *ClaudeEngine) buildMessageProjectionFromTurn(t *turns.Turn) (*messagePr
 						}
 					}
 				}
-				msgs = append(msgs, api.Message{Role: RoleAssistant, Content: []api.Content{api.NewToolUseContent(toolID, name, argsStr)}})
+				msgs = append(msgs, withMessageCacheControl(api.Message{Role: RoleAssistant, Content: []api.Content{api.NewToolUseContent(toolID, name, argsStr)}}, cacheControl))
 				toolPhaseActive = true
 			case turns.BlockKindToolUse:
 				toolID := ""
 				_ = assignString(&toolID, b.Payload[turns.PayloadKeyID])
 				result := toolUsePayloadToJSONString(b.Payload)
-				msgs = append(msgs, api.Message{Role: RoleUser, Content: []api.Content{api.NewToolResultContent(toolID, result)}})
+				msgs = append(msgs, withMessageCacheControl(api.Message{Role: RoleUser, Content: []api.Content{api.NewToolResultContent(toolID, result)}}, cacheControl))
 				flushDelayed()
 				toolPhaseActive = false
 			case turns.BlockKindOther:
 				if v, ok := b.Payload[turns.PayloadKeyText]; ok {
 					if s, ok2 := v.(string); ok2 && s != "" {
-						msg := api.Message{Role: RoleAssistant, Content: []api.Content{api.NewTextContent(s)}}
+						msg := withMessageCacheControl(api.Message{Role: RoleAssistant, Content: []api.Content{api.NewTextContent(s)}}, cacheControl)
 						if toolPhaseActive {
 							delayedMsgs = append(delayedMsgs, msg)
 						} else {
@@ -355,8 +393,9 @@ func (e *ClaudeEngine) buildMessageProjectionFromTurn(t *turns.Turn) (*messagePr
 
 	flushDelayed()
 	return &messageProjection{
-		System:   systemPrompt,
-		Messages: msgs,
+		System:             systemPrompt,
+		SystemCacheControl: systemCacheControl,
+		Messages:           msgs,
 	}, nil
 }
 
@@ -0,0 +1,208 @@
+package claude
+
+import (
+	"strings"
+
+	infengine "github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/claude/api"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/pkg/errors"
+)
+
+const promptCacheTTLLong = "1h"
+
+// blockCacheControl returns the breakpoint requested for a block, either via
+// turns.KeyBlockMetaCacheControl or via the MinUserBlockChars policy rule.
+func blockCacheControl(b turns.Block, cfg *infengine.ClaudePromptCacheConfig) (*api.CacheControl, error) {
+	if cfg.EffectiveMode() == infengine.ClaudePromptCacheModeOff {
+		return nil, nil
+	}
+	cc, ok, err := turns.KeyBlockMetaCacheControl.Get(b.Metadata)
+	if err != nil {
+		return nil, errors.Wrap(err, "get block cache control")
+	}
+	if ok {
+		return api.NewEphemeralCacheControl(strings.TrimSpace(cc.TTL)), nil
+	}
+	if cfg != nil && cfg.MinUserBlockChars > 0 && b.Kind == turns.BlockKindUser {
+		if text, _ := b.Payload[turns.PayloadKeyText].(string); len(text) >= cfg.MinUserBlockChars {
+			return api.NewEphemeralCacheControl(strings.TrimSpace(cfg.TTL)), nil
+		}
+	}
+	return nil, nil
+}
+
+// withMessageCacheControl attaches cc to the last content of msg that can carry
+// a breakpoint. The content slice is copied so block-owned original content
+// (KeyBlockMetaClaudeOriginalContent) is never mutated.
+func withMessageCacheControl(msg api.Message, cc *api.CacheControl) api.Message {
+	if cc == nil || len(msg.Content) == 0 {
+		return msg
+	}
+	content := append([]api.Content(nil), msg.Content...)
+	for i := len(content) - 1; i >= 0; i-- {
+		if marked, ok := api.WithCacheControl(content[i], cc); ok {
+			content[i] = marked
+			msg.Content = content
+			return msg
+		}
+	}
+	return msg
+}
+
+// applyPromptCachePolicy places policy-driven breakpoints on the request and
+// enforces Anthropic's constraints: at most api.MaxCacheBreakpoints markers,
+// and 1h breakpoints must not follow 5m ones in prompt order (tools, system,
+// messages). It must run after tools are attached to the request.
+func applyPromptCachePolicy(req *api.MessageRequest, cfg *infengine.ClaudePromptCacheConfig) {
+	if req == nil {
+		return
+	}
+	mode := cfg.EffectiveMode()
+	if mode == infengine.ClaudePromptCacheModeOff {
+		stripPromptCache(req)
+		return
+	}
+	ttl := ""
+	cacheTools := mode == infengine.ClaudePromptCacheModeAuto
+	cacheSystem := mode == infengine.ClaudePromptCacheModeAuto
+	if cfg != nil {
+		ttl = strings.TrimSpace(cfg.TTL)
+		cacheTools = cacheTools || cfg.Tools
+		cacheSystem = cacheSystem || cfg.System
+	}
+
+	if cacheTools && len(req.Tools) > 0 {
+		req.Tools[len(req.Tools)-1].CacheControl = api.NewEphemeralCacheControl(ttl)
+	}
+	if cacheSystem && req.System != "" {
+		req.SystemCacheControl = api.NewEphemeralCacheControl(ttl)
+	}
+	if mode == infengine.ClaudePromptCacheModeAuto {
+		markStablePrefix(req.Messages, ttl)
+	}
+	// Tool and system markers count against the limit however they were set,
+	// including system breakpoints requested through block metadata.
+	budget := api.MaxCacheBreakpoints
+	for _, tool := range req.Tools {
+		if tool.CacheControl != nil {
+			budget--
+		}
+	}
+	if req.SystemCacheControl != nil {
+		budget--
+	}
+	trimMessageBreakpoints(req.Messages, budget)
+	normalizePromptCacheTTLOrder(req)
+}
+
+// markStablePrefix marks the last two user-role messages. In a tool loop the
+// newest one (usually a tool_result) writes the cache for the next iteration,
+// while the previous one is where the prior iteration wrote, so it is read.
+func markStablePrefix(msgs []api.Message, ttl string) {
+	marked := 0
+	for i := len(msgs) - 1; i >= 0 && marked < 2; i-- {
+		if msgs[i].Role != RoleUser {
+			continue
+		}
+		if !messageHasCacheControl(msgs[i]) {
+			msgs[i] = withMessageCacheControl(msgs[i], api.NewEphemeralCacheControl(ttl))
+		}
+		marked++
+	}
+}
+
+func messageHasCacheControl(msg api.Message) bool {
+	for _, c := range msg.Content {
+		if api.ContentCacheControl(c) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+type contentRef struct{ msg, content int }
+
+// trimMessageBreakpoints keeps the latest budget message breakpoints. Later
+// breakpoints cover a longer prefix, so dropping the earliest loses the least.
+func trimMessageBreakpoints(msgs []api.Message, budget int) {
+	if budget < 0 {
+		budget = 0
+	}
+	var refs []contentRef
+	for i, msg := range msgs {
+		for j, c := range msg.Content {
+			if api.ContentCacheControl(c) != nil {
+				refs = append(refs, contentRef{msg: i, content: j})
+			}
+		}
+	}
+	if len(refs) <= budget {
+		return
+	}
+	drop := refs[:len(refs)-budget]
+	log.Debug().Int("requested", len(refs)).Int("kept", budget).Msg("Claude prompt cache: dropping earliest message breakpoints over the limit")
+	for _, ref := range drop {
+		setContentCacheControl(msgs, ref, nil)
+	}
+}
+
+// setContentCacheControl replaces one content's breakpoint on a copied slice.
+func setContentCacheControl(msgs []api.Message, ref contentRef, cc *api.CacheControl) {
+	content := append([]api.Content(nil), msgs[ref.msg].Content...)
+	content[ref.content], _ = api.WithCacheControl(content[ref.content], cc)
+	msgs[ref.msg].Content = content
+}
+
+// normalizePromptCacheTTLOrder downgrades 1h breakpoints that appear after a
+// shorter-lived one, which the API rejects.
+func normalizePromptCacheTTLOrder(req *api.MessageRequest) {
+	seenShort := false
+	visit := func(cc *api.CacheControl) *api.CacheControl {
+		if cc == nil {
+			return nil
+		}
+		if cc.TTL == promptCacheTTLLong {
+			if seenShort {
+				return api.NewEphemeralCacheControl("")
+			}
+			return cc
+		}
+		seenShort = true
+		return cc
+	}
+	for i := range req.Tools {
+		req.Tools[i].CacheControl = visit(req.Tools[i].CacheControl)
+	}
+	req.SystemCacheControl = visit(req.SystemCacheControl)
+	for i, msg := range req.Messages {
+		for j, c := range msg.Content {
+			cc := api.ContentCacheControl(c)
+			if cc == nil {
+				continue
+			}
+			if next := visit(cc); next != cc {
+				setContentCacheControl(req.Messages, contentRef{msg: i, content: j}, next)
+			}
+		}
+	}
+}
+
+func stripPromptCache(req *api.MessageRequest) {
+	req.SystemCacheControl = nil
+	for i := range req.Tools {
+		req.Tools[i].CacheControl = nil
+	}
+	stripMessageCacheControl(req.Messages)
+}
+
+// stripMessageCacheControl removes every message-level breakpoint.
+func stripMessageCacheControl(msgs []api.Message) {
+	for i, msg := range msgs {
+		for j, c := range msg.Content {
+			if api.ContentCacheControl(c) != nil {
+				setContentCacheControl(msgs, contentRef{msg: i, content: j}, nil)
+			}
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package claude
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	infengine "github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/claude/api"
+	aisettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	claudesettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings/claude"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPromptCacheTestEngine() *ClaudeEngine {
+	engine := "claude-sonnet-4-20250514"
+	return newTestEngine(&aisettings.InferenceSettings{
+		Client: &aisettings.ClientSettings{},
+		Claude: &claudesettings.Settings{},
+		Chat:   &aisettings.ChatSettings{Engine: &engine},
+	})
+}
+
+func countBreakpoints(req *api.MessageRequest) int {
+	n := 0
+	if req.SystemCacheControl != nil {
+		n++
+	}
+	for _, tool := range req.Tools {
+		if tool.CacheControl != nil {
+			n++
+		}
+	}
+	for _, msg := range req.Messages {
+		if messageHasCacheControl(msg) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestPromptCache_BlockMetadataMarksSystemAndUserBlocks(t *testing.T) {
+	system := turns.NewSystemTextBlock("long system prompt")
+	require.NoError(t, turns.KeyBlockMetaCacheControl.Set(&system.Metadata, turns.CacheControl{TTL: "1h"}))
+	user := turns.NewUserTextBlock("large document")
+	require.NoError(t, turns.KeyBlockMetaCacheControl.Set(&user.Metadata, turns.CacheControl{}))
+	tu := &turns.Turn{Blocks: []turns.Block{system, user, turns.NewUserTextBlock("question")}}
+
+	req, err := newPromptCacheTestEngine().MakeMessageRequestFromTurn(tu)
+	require.NoError(t, err)
+	applyPromptCachePolicy(req, nil)
+
+	require.NotNil(t, req.SystemCacheControl)
+	assert.Equal(t, "1h", req.SystemCacheControl.TTL)
+	require.Len(t, req.Messages, 2)
+	assert.NotNil(t, api.ContentCacheControl(req.Messages[0].Content[0]))
+	assert.Nil(t, api.ContentCacheControl(req.Messages[1].Content[0]))
+
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `"system":[{"type":"text","text":"long system prompt","cache_control":{"type":"ephemeral","ttl":"1h"}}]`)
+	assert.Contains(t, string(body), `"text":"large document","cache_control":{"type":"ephemeral"}`)
+
+	var roundTrip api.MessageRequest
+	require.NoError(t, json.Unmarshal(body, &roundTrip))
+	assert.Equal(t, "long system prompt", roundTrip.System)
+	require.NotNil(t, roundTrip.SystemCacheControl)
+}
+
+func TestPromptCache_SystemWithoutBreakpointStaysString(t *testing.T) {
+	tu := &turns.Turn{Blocks: []turns.Block{turns.NewSystemTextBlock("sys"), turns.NewUserTextBlock("hi")}}
+	req, err := newPromptCacheTestEngine().MakeMessageRequestFromTurn(tu)
+	require.NoError(t, err)
+	applyPromptCachePolicy(req, nil)
+
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `"system":"sys"`)
+	assert.NotContains(t, string(body), "cache_control")
+}
+
+func TestPromptCache_AutoModeMarksToolsSystemAndStablePrefix(t *testing.T) {
+	tu := &turns.Turn{Blocks: []turns.Block{
+		turns.NewSystemTextBlock("agent instructions"),
+		turns.NewUserTextBlock("do the thing"),
+		turns.NewToolCallBlock("call-1", "search", map[string]any{"q": "a"}),
+		turns.NewToolUseBlock("call-1", "result-1"),
+		turns.NewToolCallBlock("call-2", "search", map[string]any{"q": "b"}),
+		turns.NewToolUseBlock("call-2", "result-2"),
+	}}
+	require.NoError(t, infengine.KeyClaudeInferenceConfig.Set(&tu.Data, infengine.ClaudeInferenceConfig{
+		PromptCache: &infengine.ClaudePromptCacheConfig{Mode: infengine.ClaudePromptCacheModeAuto},
+	}))
+
+	req, err := newPromptCacheTestEngine().MakeMessageRequestFromTurn(tu)
+	require.NoError(t, err)
+	req.Tools = []api.Tool{{Name: "search"}, {Name: "fetch"}}
+	applyPromptCachePolicy(req, &infengine.ClaudePromptCacheConfig{Mode: infengine.ClaudePromptCacheModeAuto})
+
+	assert.Nil(t, req.Tools[0].CacheControl)
+	assert.NotNil(t, req.Tools[1].CacheControl)
+	assert.NotNil(t, req.SystemCacheControl)
+	last := len(req.Messages) - 1
+	assert.True(t, messageHasCacheControl(req.Messages[last]), "latest tool result should be a breakpoint")
+	assert.True(t, messageHasCacheControl(req.Messages[last-2]), "previous tool result should be a breakpoint")
+	assert.False(t, messageHasCacheControl(req.Messages[0]))
+	assert.Equal(t, 4, countBreakpoints(req))
+}
+
+func TestPromptCache_LimitKeepsLatestMessageBreakpoints(t *testing.T) {
+	var blocks []turns.Block
+	for i := 0; i < 5; i++ {
+		b := turns.NewUserTextBlock(strings.Repeat("x", 10+i))
+		blocks = append(blocks, b, turns.NewAssistantTextBlock("ok"))
+	}
+	tu := &turns.Turn{Blocks: append([]turns.Block{turns.NewSystemTextBlock("sys")}, blocks...)}
+	cfg := &infengine.ClaudePromptCacheConfig{System: true, MinUserBlockChars: 10}
+	require.NoError(t, infengine.KeyClaudeInferenceConfig.Set(&tu.Data, infengine.ClaudeInferenceConfig{PromptCache: cfg}))
+
+	req, err := newPromptCacheTestEngine().MakeMessageRequestFromTurn(tu)
+	require.NoError(t, err)
+	applyPromptCachePolicy(req, cfg)
+
+	assert.Equal(t, api.MaxCacheBreakpoints, countBreakpoints(req))
+	assert.NotNil(t, req.SystemCacheControl)
+	assert.False(t, messageHasCacheControl(req.Messages[0]))
+	assert.False(t, messageHasCacheControl(req.Messages[2]))
+	assert.True(t, messageHasCacheControl(req.Messages[8]))
+}
+
+func TestPromptCache_LimitCountsSystemMarkerFromBlockMetadata(t *testing.T) {
+	system := turns.NewSystemTextBlock("sys")
+	require.NoError(t, turns.KeyBlockMetaCacheControl.Set(&system.Metadata, turns.CacheControl{}))
+	blocks := []turns.Block{system}
+	for i := 0; i < 4; i++ {
+		b := turns.NewUserTextBlock(strings.Repeat("x", 10+i))
+		require.NoError(t, turns.KeyBlockMetaCacheControl.Set(&b.Metadata, turns.CacheControl{}))
+		blocks = append(blocks, b, turns.NewAssistantTextBlock("ok"))
+	}
+	tu := &turns.Turn{Blocks: blocks}
+	cfg := &infengine.ClaudePromptCacheConfig{Mode: infengine.ClaudePromptCacheModeExplicit}
+
+	req, err := newPromptCacheTestEngine().MakeMessageRequestFromTurn(tu)
+	require.NoError(t, err)
+	applyPromptCachePolicy(req, cfg)
+
+	assert.Equal(t, api.MaxCacheBreakpoints, countBreakpoints(req))
+	assert.NotNil(t, req.SystemCacheControl)
+	assert.False(t, messageHasCacheControl(req.Messages[0]))
+	assert.True(t, messageHasCacheControl(req.Messages[6]))
+}
+
+func TestPromptCache_OffModeStripsBlockMarkers(t *testing.T) {
+	user := turns.NewUserTextBlock("doc")
+	require.NoError(t, turns.KeyBlockMetaCacheControl.Set(&user.Metadata, turns.CacheControl{}))
+	tu := &turns.Turn{Blocks: []turns.Block{user}}
+	cfg := &infengine.ClaudePromptCacheConfig{Mode: infengine.ClaudePromptCacheModeOff}
+	require.NoError(t, infengine.KeyClaudeInferenceConfig.Set(&tu.Data, infengine.ClaudeInferenceConfig{PromptCache: cfg}))
+
+	req, err := newPromptCacheTestEngine().MakeMessageRequestFromTurn(tu)
+	require.NoError(t, err)
+	applyPromptCachePolicy(req, cfg)
+	assert.Equal(t, 0, countBreakpoints(req))
+}
+
+func TestPromptCache_LongTTLAfterShortIsDowngraded(t *testing.T) {
+	user := turns.NewUserTextBlock("doc")
+	require.NoError(t, turns.KeyBlockMetaCacheControl.Set(&user.Metadata, turns.CacheControl{TTL: "1h"}))
+	tu := &turns.Turn{Blocks: []turns.Block{turns.NewSystemTextBlock("sys"), user}}
+	cfg := &infengine.ClaudePromptCacheConfig{System: true}
+
+	req, err := newPromptCacheTestEngine().MakeMessageRequestFromTurn(tu)
+	require.NoError(t, err)
+	applyPromptCachePolicy(req, cfg)
+
+	require.NotNil(t, req.SystemCacheControl)
+	assert.Equal(t, "", req.SystemCacheControl.TTL)
+	cc := api.ContentCacheControl(req.Messages[0].Content[0])
+	require.NotNil(t, cc)
+	assert.Equal(t, "", cc.TTL)
+}
+
+func TestPromptCache_DoesNotMutateOriginalContent(t *testing.T) {
+	orig := []api.Content{api.NewTextContent("stored")}
+	user := turns.NewUserTextBlock("stored")
+	require.NoError(t, turns.KeyBlockMetaClaudeOriginalContent.Set(&user.Metadata, orig))
+	require.NoError(t, turns.KeyBlockMetaCacheControl.Set(&user.Metadata, turns.CacheControl{}))
+
+	req, err := newPromptCacheTestEngine().MakeMessageRequestFromTurn(&turns.Turn{Blocks: []turns.Block{user}})
+	require.NoError(t, err)
+	assert.NotNil(t, api.ContentCacheControl(req.Messages[0].Content[0]))
+	assert.Nil(t, api.ContentCacheControl(orig[0]))
+}
+
+func TestPromptCache_UnknownModeFailsRequest(t *testing.T) {
+	tu := &turns.Turn{Blocks: []turns.Block{turns.NewUserTextBlock("question")}}
+	require.NoError(t, infengine.KeyClaudeInferenceConfig.Set(&tu.Data, infengine.ClaudeInferenceConfig{
+		PromptCache: &infengine.ClaudePromptCacheConfig{Mode: "atuo"},
+	}))
+	_, err := newPromptCacheTestEngine().MakeMessageRequestFromTurn(tu)
+	require.ErrorContains(t, err, `unknown prompt cache mode "atuo"`)
+}
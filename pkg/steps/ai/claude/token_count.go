@@ -127,6 +127,9 @@ func (e *ClaudeEngine) MakeCountTokensRequestFromTurn(ctx context.Context, t *tu
 		return nil, errors.New("no engine specified")
 	}
 
+	// Cache breakpoints do not change token counts; strip them so block markers
+	// cannot push the count request over the breakpoint limit.
+	stripMessageCacheControl(projection.Messages)
 	req := &api.MessageCountTokensRequest{
 		Model:    model,
 		System:   projection.System,
@@ -8,6 +8,7 @@ import (
 
 	"github.com/go-go-golems/geppetto/pkg/events"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/imageparts"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/mediaparts"
 	"github.com/go-go-golems/geppetto/pkg/turns"
 	"github.com/go-go-golems/geppetto/pkg/turns/toolblocks"
 	"github.com/google/uuid"
@@ -266,6 +267,13 @@ func buildModernGeminiContentsFromTurn(t *turns.Turn) ([]*moderngenai.Content, e
 				return nil, err
 			}
 			content.Parts = append(content.Parts, parts...)
+			if b.Kind == turns.BlockKindUser {
+				parts, err := modernGeminiFilePartsFromBlock(b)
+				if err != nil {
+					return nil, err
+				}
+				content.Parts = append(content.Parts, parts...)
+			}
 		case turns.BlockKindLLMText:
 			content.Role = string(moderngenai.RoleModel)
 			if txt, ok := blockText(b); ok {
@@ -348,6 +356,32 @@ func modernGeminiImagePartsFromBlock(b turns.Block) ([]*moderngenai.Part, error)
 	return parts, nil
 }
 
+// modernGeminiFilePartsFromBlock maps file parts to inline data or file
+// URIs. Plain-text documents given as text become text parts; Gemini accepts
+// public URLs (including YouTube) as file URIs.
+func modernGeminiFilePartsFromBlock(b turns.Block) ([]*moderngenai.Part, error) {
+	files, err := mediaparts.FromPayload(b.Payload)
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]*moderngenai.Part, 0, len(files))
+	for _, f := range files {
+		switch {
+		case f.Text != "":
+			parts = append(parts, moderngenai.NewPartFromText(f.LabeledText()))
+		case len(f.Data) > 0:
+			parts = append(parts, &moderngenai.Part{InlineData: &moderngenai.Blob{MIMEType: f.MediaType, Data: f.Data}})
+		case f.FileURI != "":
+			parts = append(parts, moderngenai.NewPartFromURI(f.FileURI, f.MediaType))
+		case f.URL != "":
+			parts = append(parts, moderngenai.NewPartFromURI(f.URL, f.MediaType))
+		default:
+			return nil, mediaparts.Unsupported("gemini", f)
+		}
+	}
+	return parts, nil
+}
+
 func blockText(b turns.Block) (string, bool) {
 	if b.Payload == nil {
 		return "", false
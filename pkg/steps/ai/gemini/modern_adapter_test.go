@@ -227,3 +227,41 @@ func TestModernGeminiContentsRejectsGenericImageURL(t *testing.T) {
 		t.Fatalf("expected generic URL error")
 	}
 }
+
+func TestModernGeminiContentsMapsFileParts(t *testing.T) {
+	turn := &turns.Turn{ID: "turn-files"}
+	turns.AppendBlock(turn, turns.NewUserFileBlock("summarize", []map[string]any{
+		{"media_type": "application/pdf", "content": []byte("PDF")},
+		{"file_uri": "https://generativelanguage.googleapis.com/v1beta/files/abc", "media_type": "audio/mpeg"},
+		{"url": "https://www.youtube.com/watch?v=xyz", "media_type": "video/mp4"},
+	}))
+
+	contents, err := buildModernGeminiContentsFromTurn(turn)
+	if err != nil {
+		t.Fatalf("build contents: %v", err)
+	}
+	if len(contents) != 1 || len(contents[0].Parts) != 4 {
+		t.Fatalf("contents = %#v", contents)
+	}
+	inline := contents[0].Parts[1].InlineData
+	if inline == nil || inline.MIMEType != "application/pdf" || string(inline.Data) != "PDF" {
+		t.Fatalf("inline data = %#v", inline)
+	}
+	audio := contents[0].Parts[2].FileData
+	if audio == nil || audio.MIMEType != "audio/mpeg" {
+		t.Fatalf("audio file data = %#v", audio)
+	}
+	video := contents[0].Parts[3].FileData
+	if video == nil || video.FileURI != "https://www.youtube.com/watch?v=xyz" || video.MIMEType != "video/mp4" {
+		t.Fatalf("video file data = %#v", video)
+	}
+}
+
+func TestModernGeminiContentsRejectsProviderFileIDs(t *testing.T) {
+	turn := &turns.Turn{ID: "turn-file-id"}
+	turns.AppendBlock(turn, turns.NewUserFileBlock("read", []map[string]any{{"file_id": "file-123"}}))
+
+	if _, err := buildModernGeminiContentsFromTurn(turn); err == nil {
+		t.Fatalf("expected error for OpenAI-style file_id")
+	}
+}
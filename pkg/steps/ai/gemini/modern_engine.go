@@ -11,11 +11,14 @@ import (
 	"github.com/go-go-golems/geppetto/pkg/inference/engine"
 	gepsession "github.com/go-go-golems/geppetto/pkg/inference/session"
 	"github.com/go-go-golems/geppetto/pkg/inference/tools"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/mediaparts"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/providerhttp"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/ratelimit"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/retry"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/runtimeattrib"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
 	"github.com/go-go-golems/geppetto/pkg/turns"
 	"github.com/google/uuid"
-	"github.com/invopop/jsonschema"
 	"github.com/pkg/errors"
 	moderngenai "google.golang.org/genai"
 )
@@ -30,7 +33,7 @@ func (e *GeminiEngine) runModernInference(ctx context.Context, t *turns.Turn) (*
 		return nil, errors.Errorf("missing API key %s", string(*apiType)+"-api-key")
 	}
 	baseURL := e.settings.API.BaseUrls[string(*apiType)+"-base-url"]
-	httpClient, err := settings.EnsureHTTPClient(e.settings.Client)
+	httpClient, err := providerhttp.Client(e.settings.Client, ratelimit.NewKey(string(*apiType), *e.settings.Chat.Engine, apiKey))
 	if err != nil {
 		return nil, errors.Wrap(err, "resolve gemini HTTP client")
 	}
@@ -54,6 +57,9 @@ func (e *GeminiEngine) runModernInference(ctx context.Context, t *turns.Turn) (*
 	if err != nil {
 		return nil, err
 	}
+	if err := mediaparts.CheckTurnInput(e.settings.ModelInfo, t); err != nil {
+		return nil, err
+	}
 	contents, err := buildModernGeminiContentsFromTurn(t)
 	if err != nil {
 		return nil, err
@@ -81,7 +87,9 @@ func (e *GeminiEngine) runModernInference(ctx context.Context, t *turns.Turn) (*
 
 	streamState := newModernGeminiStreamState(providerCallCorr)
 	var terminalErr error
-	for resp, err := range client.Models.GenerateContentStream(ctx, modelName, contents, config) {
+	streamCtx := ratelimit.WithTokenEstimate(ctx, ratelimit.EstimateTurnTokens(t))
+	streamCtx = retry.WithEventReporter(streamCtx, metadata, providerCallCorr, e.publishEvent)
+	for resp, err := range client.Models.GenerateContentStream(streamCtx, modelName, contents, config) {
 		if err != nil {
 			terminalErr = err
 			break
@@ -192,6 +200,9 @@ func (e *GeminiEngine) buildModernGenerateContentConfig(ctx context.Context, t *
 			config.ThinkingConfig = thinking
 		}
 	}
+	if err := e.applyModernStructuredOutput(config, t); err != nil {
+		return nil, err
+	}
 	registry, _ := tools.RegistryFrom(ctx)
 	if registry != nil {
 		decls, err := modernGeminiToolDeclarations(registry)
@@ -239,71 +250,24 @@ func modernGeminiToolDeclarations(reg tools.ToolRegistry) ([]*moderngenai.Functi
 		if len(paramNames) > 0 {
 			desc = strings.TrimSpace(desc + " Parameters: " + strings.Join(paramNames, ", "))
 		}
+		params, degraded, err := convertToolParametersToModernGenAI(td.Parameters)
+		for _, w := range degraded {
+			log.Warn().Err(w).Str("tool", td.Name).Msg("Gemini request: tool parameter uses unsupported schema features, sending it untyped")
+		}
+		if err != nil {
+			// Keep the tool callable; the parameter names are in the description.
+			log.Warn().Err(err).Str("tool", td.Name).Msg("Gemini request: tool parameters use unsupported schema features, sending an untyped object")
+			params = &moderngenai.Schema{Type: moderngenai.TypeObject}
+		}
 		out = append(out, &moderngenai.FunctionDeclaration{
 			Name:        td.Name,
 			Description: desc,
-			Parameters:  convertJSONSchemaToModernGenAI(td.Parameters),
+			Parameters:  params,
 		})
 	}
 	return out, nil
 }
 
-func convertJSONSchemaToModernGenAI(s *jsonschema.Schema) *moderngenai.Schema {
-	if s == nil {
-		return nil
-	}
-	gs := &moderngenai.Schema{}
-	switch s.Type {
-	case "string":
-		gs.Type = moderngenai.TypeString
-	case "number":
-		gs.Type = moderngenai.TypeNumber
-	case "integer":
-		gs.Type = moderngenai.TypeInteger
-	case "boolean":
-		gs.Type = moderngenai.TypeBoolean
-	case "array":
-		gs.Type = moderngenai.TypeArray
-	case "object", "":
-		gs.Type = moderngenai.TypeObject
-		props := modernGeminiSchemaProperties(s)
-		if len(props) > 0 {
-			gs.Properties = props
-		}
-	default:
-		gs.Type = moderngenai.TypeObject
-	}
-	return gs
-}
-
-func modernGeminiSchemaProperties(s *jsonschema.Schema) map[string]*moderngenai.Schema {
-	if s == nil || s.Properties == nil {
-		return nil
-	}
-	propsVal := reflect.ValueOf(s.Properties)
-	keysMethod := propsVal.MethodByName("Keys")
-	getMethod := propsVal.MethodByName("Get")
-	if !keysMethod.IsValid() || !getMethod.IsValid() {
-		return nil
-	}
-	keys := keysMethod.Call(nil)
-	if len(keys) != 1 {
-		return nil
-	}
-	ks, ok := keys[0].Interface().([]string)
-	if !ok {
-		return nil
-	}
-	ret := map[string]*moderngenai.Schema{}
-	for _, k := range ks {
-		res := getMethod.Call([]reflect.Value{reflect.ValueOf(k)})
-		if len(res) >= 2 && res[1].IsValid() && res[1].Bool() {
-			ret[k] = &moderngenai.Schema{Type: moderngenai.TypeString}
-		}
-	}
-	return ret
-}
-
 func completeModernGeminiStream(
 	t *turns.Turn,
 	metadata *events.EventMetadata,
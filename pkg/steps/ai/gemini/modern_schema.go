@@ -0,0 +1,405 @@
+package gemini
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/invopop/jsonschema"
+	"github.com/pkg/errors"
+	moderngenai "google.golang.org/genai"
+)
+
+// applyModernStructuredOutput maps the structured output config of the chat
+// settings, or its Turn.Data override, to ResponseMIMEType/ResponseSchema.
+// Invalid configs and schemas Gemini cannot express fail the request when
+// RequireValid is set and are ignored with a warning otherwise.
+func (e *GeminiEngine) applyModernStructuredOutput(config *moderngenai.GenerateContentConfig, t *turns.Turn) error {
+	var (
+		cfg *engine.StructuredOutputConfig
+		err error
+	)
+	requireValid := false
+	if e.settings.Chat != nil && e.settings.Chat.IsStructuredOutputEnabled() {
+		requireValid = e.settings.Chat.StructuredOutputRequireValid
+		cfg, err = e.settings.Chat.StructuredOutputConfig()
+	}
+	if t != nil {
+		if soCfg, ok, getErr := engine.KeyStructuredOutputConfig.Get(t.Data); getErr == nil && ok && soCfg.IsEnabled() {
+			requireValid = soCfg.RequireValid
+			err = soCfg.Validate()
+			cfg = &soCfg
+		}
+	}
+	if err == nil && cfg != nil && cfg.IsEnabled() {
+		var schema *moderngenai.Schema
+		schema, err = structuredOutputSchemaToModernGenAI(cfg.Schema)
+		if err == nil {
+			config.ResponseMIMEType = "application/json"
+			config.ResponseSchema = schema
+			return nil
+		}
+	}
+	if err == nil {
+		return nil
+	}
+	if requireValid {
+		return fmt.Errorf("gemini structured output: %w: %w", engine.ErrStructuredOutputUnsupported, err)
+	}
+	log.Warn().Err(err).Msg("Gemini request: ignoring unsupported structured output configuration")
+	return nil
+}
+
+// structuredOutputSchemaToModernGenAI decodes a structured output JSON schema
+// and converts it with convertJSONSchemaToModernGenAI.
+func structuredOutputSchemaToModernGenAI(schema map[string]any) (*moderngenai.Schema, error) {
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return nil, errors.Wrap(err, "encode schema")
+	}
+	var s jsonschema.Schema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, errors.Wrap(err, "decode schema (type arrays are not supported, use anyOf with a null branch)")
+	}
+	return convertJSONSchemaToModernGenAI(&s)
+}
+
+// convertJSONSchemaToModernGenAI converts a JSON schema to the OpenAPI subset
+// accepted by Gemini. Local $refs into $defs are inlined. Keywords Gemini
+// cannot express (allOf, oneOf, not, conditionals, pattern properties, map
+// schemas via additionalProperties, recursive $refs) return an error naming the
+// offending location; additionalProperties true/false is dropped.
+func convertJSONSchemaToModernGenAI(s *jsonschema.Schema) (*moderngenai.Schema, error) {
+	if s == nil {
+		return nil, nil
+	}
+	c := &modernSchemaConverter{root: s, refs: map[string]bool{}}
+	return c.convert(s, "$")
+}
+
+// convertToolParametersToModernGenAI converts tool parameters like
+// convertJSONSchemaToModernGenAI, but degrades each property Gemini cannot
+// express to an untyped value instead of rejecting the whole schema. The
+// returned warnings name the degraded properties; an error means the root
+// itself is unsupported.
+func convertToolParametersToModernGenAI(s *jsonschema.Schema) (*moderngenai.Schema, []error, error) {
+	if s == nil {
+		return nil, nil, nil
+	}
+	c := &modernSchemaConverter{root: s, refs: map[string]bool{}, lenient: true}
+	out, err := c.convert(s, "$")
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, c.degraded, nil
+}
+
+type modernSchemaConverter struct {
+	root *jsonschema.Schema
+	refs map[string]bool
+	// lenient degrades unsupported properties, collecting the errors in
+	// degraded, instead of failing the conversion.
+	lenient  bool
+	degraded []error
+}
+
+func (c *modernSchemaConverter) convert(s *jsonschema.Schema, path string) (*moderngenai.Schema, error) {
+	if s == nil {
+		return nil, nil
+	}
+	if b, ok := schemaBoolean(s); ok {
+		if !b {
+			return nil, errors.Errorf("%s: false schema is not supported", path)
+		}
+		return nil, nil
+	}
+	if s.Ref != "" {
+		return c.convertRef(s, path)
+	}
+	if err := unsupportedSchemaKeywords(s, path); err != nil {
+		return nil, err
+	}
+
+	gs := &moderngenai.Schema{
+		Title:       s.Title,
+		Description: s.Description,
+		Format:      s.Format,
+		Pattern:     s.Pattern,
+		Default:     s.Default,
+		MinLength:   uint64ToInt64Ptr(s.MinLength),
+		MaxLength:   uint64ToInt64Ptr(s.MaxLength),
+		MinItems:    uint64ToInt64Ptr(s.MinItems),
+		MaxItems:    uint64ToInt64Ptr(s.MaxItems),
+		Minimum:     jsonNumberPtr(s.Minimum),
+		Maximum:     jsonNumberPtr(s.Maximum),
+	}
+	if len(s.Examples) > 0 {
+		gs.Example = s.Examples[0]
+	}
+
+	if len(s.AnyOf) > 0 {
+		return c.convertAnyOf(s, gs, path)
+	}
+
+	switch s.Type {
+	case "string":
+		gs.Type = moderngenai.TypeString
+	case "number":
+		gs.Type = moderngenai.TypeNumber
+	case "integer":
+		gs.Type = moderngenai.TypeInteger
+	case "boolean":
+		gs.Type = moderngenai.TypeBoolean
+	case "array":
+		gs.Type = moderngenai.TypeArray
+	case "object":
+		gs.Type = moderngenai.TypeObject
+	case "":
+		gs.Type = moderngenai.TypeObject
+		if s.Items != nil {
+			gs.Type = moderngenai.TypeArray
+		}
+	case "null":
+		return nil, errors.Errorf("%s: type null is only supported as an anyOf branch", path)
+	default:
+		return nil, errors.Errorf("%s: unsupported type %q", path, s.Type)
+	}
+
+	enum, err := schemaStringEnum(s, path)
+	if err != nil {
+		return nil, err
+	}
+	if len(enum) > 0 {
+		if gs.Type != moderngenai.TypeString && s.Type != "" {
+			return nil, errors.Errorf("%s: enum is only supported on strings", path)
+		}
+		gs.Type = moderngenai.TypeString
+		gs.Enum = enum
+	}
+
+	if gs.Type == moderngenai.TypeArray && s.Items != nil {
+		items, err := c.convert(s.Items, path+"[]")
+		if err != nil {
+			return nil, err
+		}
+		gs.Items = items
+	}
+	if gs.Type == moderngenai.TypeObject {
+		if err := c.convertObject(s, gs, path); err != nil {
+			return nil, err
+		}
+	}
+	return gs, nil
+}
+
+func (c *modernSchemaConverter) convertObject(s *jsonschema.Schema, gs *moderngenai.Schema, path string) error {
+	if s.AdditionalProperties != nil {
+		if _, ok := schemaBoolean(s.AdditionalProperties); !ok {
+			return errors.Errorf("%s: additionalProperties schemas (maps) are not supported", path)
+		}
+	}
+	gs.MinProperties = uint64ToInt64Ptr(s.MinProperties)
+	gs.MaxProperties = uint64ToInt64Ptr(s.MaxProperties)
+	gs.Required = append([]string(nil), s.Required...)
+	if s.Properties == nil || s.Properties.Len() == 0 {
+		return nil
+	}
+	gs.Properties = make(map[string]*moderngenai.Schema, s.Properties.Len())
+	for pair := s.Properties.Oldest(); pair != nil; pair = pair.Next() {
+		prop, err := c.convert(pair.Value, path+"."+pair.Key)
+		if err != nil {
+			if !c.lenient {
+				return err
+			}
+			c.degraded = append(c.degraded, err)
+			prop = degradedModernSchema(pair.Value)
+		}
+		if prop == nil {
+			prop = &moderngenai.Schema{Type: moderngenai.TypeString}
+		}
+		gs.Properties[pair.Key] = prop
+		gs.PropertyOrdering = append(gs.PropertyOrdering, pair.Key)
+	}
+	return nil
+}
+
+// degradedModernSchema is the stand-in for a property Gemini cannot express:
+// its scalar type when it declares one, an untyped object otherwise, and its
+// description.
+func degradedModernSchema(s *jsonschema.Schema) *moderngenai.Schema {
+	gs := &moderngenai.Schema{Type: moderngenai.TypeObject}
+	if s == nil {
+		return gs
+	}
+	gs.Description = s.Description
+	switch s.Type {
+	case "string":
+		gs.Type = moderngenai.TypeString
+	case "number":
+		gs.Type = moderngenai.TypeNumber
+	case "integer":
+		gs.Type = moderngenai.TypeInteger
+	case "boolean":
+		gs.Type = moderngenai.TypeBoolean
+	}
+	return gs
+}
+
+// convertAnyOf maps {"anyOf":[X, {"type":"null"}]} to a nullable X and other
+// anyOf lists to Gemini's anyOf.
+func (c *modernSchemaConverter) convertAnyOf(s *jsonschema.Schema, gs *moderngenai.Schema, path string) (*moderngenai.Schema, error) {
+	var branches []*jsonschema.Schema
+	nullable := false
+	for _, b := range s.AnyOf {
+		if b != nil && b.Type == "null" {
+			nullable = true
+			continue
+		}
+		branches = append(branches, b)
+	}
+	if len(branches) == 1 {
+		inner, err := c.convert(branches[0], path)
+		if err != nil {
+			return nil, err
+		}
+		if inner == nil {
+			inner = &moderngenai.Schema{}
+		}
+		if gs.Description != "" && inner.Description == "" {
+			inner.Description = gs.Description
+		}
+		if nullable {
+			inner.Nullable = &nullable
+		}
+		return inner, nil
+	}
+	for i, b := range branches {
+		inner, err := c.convert(b, path+".anyOf["+strconv.Itoa(i)+"]")
+		if err != nil {
+			return nil, err
+		}
+		if inner != nil {
+			gs.AnyOf = append(gs.AnyOf, inner)
+		}
+	}
+	if nullable {
+		gs.Nullable = &nullable
+	}
+	return gs, nil
+}
+
+func (c *modernSchemaConverter) convertRef(s *jsonschema.Schema, path string) (*moderngenai.Schema, error) {
+	name, ok := strings.CutPrefix(s.Ref, "#/$defs/")
+	if !ok {
+		return nil, errors.Errorf("%s: unsupported $ref %q (only local #/$defs references are supported)", path, s.Ref)
+	}
+	target := c.root.Definitions[name]
+	if target == nil {
+		return nil, errors.Errorf("%s: unresolved $ref %q", path, s.Ref)
+	}
+	if c.refs[name] {
+		return nil, errors.Errorf("%s: recursive $ref %q is not supported", path, s.Ref)
+	}
+	c.refs[name] = true
+	defer delete(c.refs, name)
+	out, err := c.convert(target, path)
+	if err != nil {
+		return nil, err
+	}
+	if out != nil && s.Description != "" {
+		out.Description = s.Description
+	}
+	return out, nil
+}
+
+func unsupportedSchemaKeywords(s *jsonschema.Schema, path string) error {
+	var found []string
+	if len(s.AllOf) > 0 {
+		found = append(found, "allOf")
+	}
+	if len(s.OneOf) > 0 {
+		found = append(found, "oneOf (use anyOf)")
+	}
+	if s.Not != nil {
+		found = append(found, "not")
+	}
+	if s.If != nil || s.Then != nil || s.Else != nil {
+		found = append(found, "if/then/else")
+	}
+	if len(s.DependentSchemas) > 0 || len(s.DependentRequired) > 0 {
+		found = append(found, "dependent schemas")
+	}
+	if len(s.PrefixItems) > 0 {
+		found = append(found, "prefixItems")
+	}
+	if s.Contains != nil {
+		found = append(found, "contains")
+	}
+	if len(s.PatternProperties) > 0 {
+		found = append(found, "patternProperties")
+	}
+	if s.PropertyNames != nil {
+		found = append(found, "propertyNames")
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	return errors.Errorf("%s: unsupported schema keywords: %s", path, strings.Join(found, ", "))
+}
+
+func schemaStringEnum(s *jsonschema.Schema, path string) ([]string, error) {
+	values := s.Enum
+	if s.Const != nil {
+		values = append(values, s.Const)
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		str, ok := v.(string)
+		if !ok {
+			return nil, errors.Errorf("%s: only string enum/const values are supported, got %v", path, v)
+		}
+		out = append(out, str)
+	}
+	return out, nil
+}
+
+// schemaBoolean reports whether s is the boolean schema true or false.
+func schemaBoolean(s *jsonschema.Schema) (bool, bool) {
+	switch {
+	case reflect.DeepEqual(s, jsonschema.TrueSchema):
+		return true, true
+	case reflect.DeepEqual(s, jsonschema.FalseSchema):
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+func uint64ToInt64Ptr(v *uint64) *int64 {
+	if v == nil {
+		return nil
+	}
+	n := int64(*v) // #nosec G115 -- schema bounds are small
+	if n < 0 {
+		n = 0
+	}
+	return &n
+}
+
+func jsonNumberPtr(n json.Number) *float64 {
+	if n == "" {
+		return nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return nil
+	}
+	return &f
+}
@@ -0,0 +1,180 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/inference/tools"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/invopop/jsonschema"
+	moderngenai "google.golang.org/genai"
+)
+
+const personSchemaJSON = `{
+  "type": "object",
+  "properties": {
+    "name": {"type": "string", "description": "Full name"},
+    "age": {"type": "integer", "minimum": 0},
+    "role": {"type": "string", "enum": ["admin", "user"]},
+    "nickname": {"anyOf": [{"type": "string"}, {"type": "null"}]},
+    "address": {"$ref": "#/$defs/address"},
+    "tags": {"type": "array", "items": {"type": "string"}, "maxItems": 3}
+  },
+  "required": ["name", "age"],
+  "additionalProperties": false,
+  "$defs": {
+    "address": {"type": "object", "properties": {"city": {"type": "string"}}, "required": ["city"]}
+  }
+}`
+
+func newStructuredOutputEngine(t *testing.T, schema string, requireValid bool) *GeminiEngine {
+	t.Helper()
+	ss, err := settings.NewInferenceSettings()
+	if err != nil {
+		t.Fatalf("NewInferenceSettings: %v", err)
+	}
+	ss.Chat.StructuredOutputMode = settings.StructuredOutputModeJSONSchema
+	ss.Chat.StructuredOutputName = "person"
+	ss.Chat.StructuredOutputSchema = schema
+	ss.Chat.StructuredOutputRequireValid = requireValid
+	e, err := NewGeminiEngine(ss)
+	if err != nil {
+		t.Fatalf("NewGeminiEngine: %v", err)
+	}
+	return e
+}
+
+func TestBuildModernGenerateContentConfigMapsStructuredOutput(t *testing.T) {
+	e := newStructuredOutputEngine(t, personSchemaJSON, true)
+	cfg, err := e.buildModernGenerateContentConfig(context.Background(), &turns.Turn{})
+	if err != nil {
+		t.Fatalf("build config: %v", err)
+	}
+	if cfg.ResponseMIMEType != "application/json" || cfg.ResponseSchema == nil {
+		t.Fatalf("expected JSON response schema, got %q %+v", cfg.ResponseMIMEType, cfg.ResponseSchema)
+	}
+	rs := cfg.ResponseSchema
+	if rs.Type != moderngenai.TypeObject || strings.Join(rs.Required, ",") != "name,age" {
+		t.Fatalf("unexpected root schema %+v", rs)
+	}
+	if rs.Properties["age"].Type != moderngenai.TypeInteger || rs.Properties["age"].Minimum == nil {
+		t.Fatalf("unexpected age schema %+v", rs.Properties["age"])
+	}
+	if got := rs.Properties["role"].Enum; len(got) != 2 || got[0] != "admin" {
+		t.Fatalf("unexpected role enum %v", got)
+	}
+	if n := rs.Properties["nickname"]; n.Type != moderngenai.TypeString || n.Nullable == nil || !*n.Nullable {
+		t.Fatalf("expected nullable string nickname, got %+v", n)
+	}
+	if a := rs.Properties["address"]; a.Type != moderngenai.TypeObject || a.Properties["city"].Type != moderngenai.TypeString {
+		t.Fatalf("expected inlined $ref address, got %+v", a)
+	}
+	if tags := rs.Properties["tags"]; tags.Items == nil || tags.Items.Type != moderngenai.TypeString || *tags.MaxItems != 3 {
+		t.Fatalf("unexpected tags schema %+v", tags)
+	}
+}
+
+func TestBuildModernGenerateContentConfigTurnOverride(t *testing.T) {
+	e := newStructuredOutputEngine(t, personSchemaJSON, false)
+	turn := &turns.Turn{}
+	if err := engine.KeyStructuredOutputConfig.Set(&turn.Data, engine.StructuredOutputConfig{
+		Mode:   engine.StructuredOutputModeJSONSchema,
+		Name:   "answer",
+		Schema: map[string]any{"type": "object", "properties": map[string]any{"answer": map[string]any{"type": "boolean"}}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := e.buildModernGenerateContentConfig(context.Background(), turn)
+	if err != nil {
+		t.Fatalf("build config: %v", err)
+	}
+	if cfg.ResponseSchema == nil || cfg.ResponseSchema.Properties["answer"].Type != moderngenai.TypeBoolean {
+		t.Fatalf("expected turn override schema, got %+v", cfg.ResponseSchema)
+	}
+}
+
+func TestBuildModernGenerateContentConfigUnsupportedSchema(t *testing.T) {
+	cases := map[string]string{
+		"oneOf":       `{"type":"object","properties":{"v":{"oneOf":[{"type":"string"},{"type":"integer"}]}}}`,
+		"map":         `{"type":"object","additionalProperties":{"type":"string"}}`,
+		"recursive":   `{"$ref":"#/$defs/node","$defs":{"node":{"type":"object","properties":{"next":{"$ref":"#/$defs/node"}}}}}`,
+		"type arrays": `{"type":["string","null"]}`,
+	}
+	for name, schema := range cases {
+		t.Run(name, func(t *testing.T) {
+			e := newStructuredOutputEngine(t, schema, true)
+			if _, err := e.buildModernGenerateContentConfig(context.Background(), &turns.Turn{}); !errors.Is(err, engine.ErrStructuredOutputUnsupported) || !strings.Contains(err.Error(), "gemini structured output") {
+				t.Fatalf("expected structured output error, got %v", err)
+			}
+
+			lenient := newStructuredOutputEngine(t, schema, false)
+			cfg, err := lenient.buildModernGenerateContentConfig(context.Background(), &turns.Turn{})
+			if err != nil || cfg.ResponseSchema != nil {
+				t.Fatalf("expected schema to be ignored without require_valid, got %v %+v", err, cfg)
+			}
+		})
+	}
+}
+
+func TestConvertJSONSchemaRejectsDefinitionsRefs(t *testing.T) {
+	_, err := structuredOutputSchemaToModernGenAI(map[string]any{
+		"type":        "object",
+		"properties":  map[string]any{"a": map[string]any{"$ref": "#/definitions/a"}},
+		"definitions": map[string]any{"a": map[string]any{"type": "string"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "unsupported $ref") {
+		t.Fatalf("expected unsupported $ref error, got %v", err)
+	}
+}
+
+func TestModernGeminiToolDeclarationsDegradeOnlyUnsupportedProperties(t *testing.T) {
+	var params jsonschema.Schema
+	if err := json.Unmarshal([]byte(`{
+  "type": "object",
+  "properties": {
+    "query": {"type": "string", "description": "Search query"},
+    "limit": {"type": "integer"},
+    "filter": {"description": "Filter", "oneOf": [{"type": "string"}, {"type": "integer"}]},
+    "options": {"type": "object", "properties": {"mode": {"type": "string", "not": {"const": "x"}}}}
+  },
+  "required": ["query"]
+}`), &params); err != nil {
+		t.Fatalf("decode schema: %v", err)
+	}
+	reg := tools.NewInMemoryToolRegistry()
+	if err := reg.RegisterTool("search", tools.ToolDefinition{Name: "search", Description: "Search", Parameters: &params}); err != nil {
+		t.Fatalf("RegisterTool: %v", err)
+	}
+
+	decls, err := modernGeminiToolDeclarations(reg)
+	if err != nil || len(decls) != 1 {
+		t.Fatalf("expected one declaration, got %v %v", decls, err)
+	}
+	got := decls[0].Parameters
+	if got == nil || got.Type != moderngenai.TypeObject || len(got.Required) != 1 {
+		t.Fatalf("expected typed root object, got %+v", got)
+	}
+	if got.Properties["query"].Type != moderngenai.TypeString || got.Properties["limit"].Type != moderngenai.TypeInteger {
+		t.Fatalf("supported properties must keep their types, got %+v", got.Properties)
+	}
+	filter := got.Properties["filter"]
+	if filter == nil || filter.Type != moderngenai.TypeObject || filter.Description != "Filter" || len(filter.AnyOf) != 0 {
+		t.Fatalf("expected filter degraded to an untyped object, got %+v", filter)
+	}
+	options := got.Properties["options"]
+	if options == nil || options.Properties["mode"] == nil || options.Properties["mode"].Type != moderngenai.TypeString {
+		t.Fatalf("expected only options.mode degraded, got %+v", options)
+	}
+
+	// A root Gemini cannot express still falls back to an untyped object.
+	params.AllOf = []*jsonschema.Schema{{Type: "object"}}
+	decls, err = modernGeminiToolDeclarations(reg)
+	if err != nil || decls[0].Parameters == nil || len(decls[0].Parameters.Properties) != 0 {
+		t.Fatalf("expected untyped root fallback, got %+v %v", decls, err)
+	}
+}
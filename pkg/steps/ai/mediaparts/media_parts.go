@@ -0,0 +1,555 @@
+// Package mediaparts normalizes the file inputs of user blocks (PDFs, audio,
+// video, plain-text documents and arbitrary files) into provider-neutral
+// parts that engines map to their own content types.
+//
+// Images keep their dedicated turns.PayloadKeyImages shape (see imageparts);
+// everything else is attached under turns.PayloadKeyFiles or
+// turns.PayloadKeyDocuments as maps with the keys:
+//
+//   - one source: "path" (local regular file, at most MaxFileBytes),
+//     "content" ([]byte, base64 or data URL), "url" (remote or data URL),
+//     "file_id" (provider file ID), "file_uri" (Gemini file URI) or "text"
+//     (plain-text body)
+//   - "media_type": required for inline content unless it can be derived
+//     from "path", a data URL or "filename"; "content" strings of text/*
+//     media types are taken literally instead of being base64-decoded
+//   - optional "filename", "title", "context" and "citations" (bool)
+package mediaparts
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/imageparts"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+)
+
+// Kind classifies a part by the input modality it needs.
+type Kind string
+
+const (
+	KindImage Kind = "image"
+	KindPDF   Kind = "pdf"
+	KindAudio Kind = "audio"
+	KindVideo Kind = "video"
+	KindText  Kind = "text"
+	// KindFile is any other file type, e.g. spreadsheets or archives.
+	KindFile Kind = "file"
+)
+
+// MaxFileBytes is the largest local file, referenced by "path", that Normalize
+// reads into memory.
+const MaxFileBytes = 50 << 20
+
+// ErrUnsupportedInput is wrapped by errors reporting a part that the selected
+// model or provider cannot accept.
+var ErrUnsupportedInput = errors.New("unsupported input")
+
+// Part is one normalized file input. Exactly one of Data, Text, URL, FileID or
+// FileURI is set.
+type Part struct {
+	Kind      Kind
+	MediaType string
+	Data      []byte
+	Text      string
+	URL       string
+	FileID    string
+	FileURI   string
+
+	Filename  string
+	Title     string
+	Context   string
+	Citations bool
+}
+
+// readFilePart reads a regular file of at most MaxFileBytes.
+func readFilePart(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file part: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("read file part: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("file part %s is not a regular file", filepath.Base(path))
+	}
+	if info.Size() > MaxFileBytes {
+		return nil, fmt.Errorf("file part %s is %d bytes, over the %d byte limit", filepath.Base(path), info.Size(), MaxFileBytes)
+	}
+	data, err := io.ReadAll(io.LimitReader(f, MaxFileBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read file part: %w", err)
+	}
+	if len(data) > MaxFileBytes {
+		return nil, fmt.Errorf("file part %s exceeds the %d byte limit", filepath.Base(path), MaxFileBytes)
+	}
+	return data, nil
+}
+
+// Normalize converts one file map into a Part. Maps without any source are
+// skipped with ok=false.
+func Normalize(m map[string]any) (Part, bool, error) {
+	if len(m) == 0 {
+		return Part{}, false, nil
+	}
+	p := Part{
+		MediaType: stringValue(m["media_type"]),
+		Filename:  stringValue(m["filename"]),
+		Title:     stringValue(m["title"]),
+		Context:   stringValue(m["context"]),
+	}
+	p.Citations, _ = m["citations"].(bool)
+
+	switch {
+	case stringValue(m["path"]) != "":
+		path := stringValue(m["path"])
+		data, err := readFilePart(path)
+		if err != nil {
+			return Part{}, false, err
+		}
+		if p.Filename == "" {
+			p.Filename = filepath.Base(path)
+		}
+		if p.MediaType == "" {
+			p.MediaType = mediaTypeFromName(path)
+		}
+		if p.MediaType == "" {
+			p.MediaType = http.DetectContentType(data)
+		}
+		p.Data = data
+	case stringValue(m["text"]) != "":
+		p.Text, _ = m["text"].(string)
+		p.MediaType = "text/plain"
+	case stringValue(m["url"]) != "":
+		url := stringValue(m["url"])
+		mediaType, data, ok, err := imageparts.DecodeDataURL(url)
+		if err != nil {
+			return Part{}, false, fmt.Errorf("file part url: %w", err)
+		}
+		if ok {
+			p.MediaType, p.Data = mediaType, data
+		} else {
+			p.URL = url
+			if p.MediaType == "" {
+				p.MediaType = mediaTypeFromName(url)
+			}
+		}
+	case stringValue(m["file_id"]) != "":
+		p.FileID = stringValue(m["file_id"])
+	case stringValue(m["file_uri"]) != "":
+		p.FileURI = stringValue(m["file_uri"])
+	case m["content"] != nil:
+		if s, ok := m["content"].(string); ok && strings.HasPrefix(strings.TrimSpace(s), "data:") {
+			mediaType, data, _, err := imageparts.DecodeDataURL(s)
+			if err != nil {
+				return Part{}, false, fmt.Errorf("file part content: %w", err)
+			}
+			p.MediaType, p.Data = mediaType, data
+			break
+		}
+		if p.MediaType == "" {
+			p.MediaType = mediaTypeFromName(p.Filename)
+		}
+		data, err := contentBytes(m["content"], strings.HasPrefix(p.MediaType, "text/"))
+		if err != nil {
+			return Part{}, false, err
+		}
+		if len(data) == 0 {
+			return Part{}, false, nil
+		}
+		if p.MediaType == "" {
+			return Part{}, false, fmt.Errorf("file part content requires media_type")
+		}
+		p.Data = data
+	default:
+		return Part{}, false, nil
+	}
+
+	if p.MediaType == "" {
+		p.MediaType = mediaTypeFromName(firstNonEmpty(p.Filename, p.FileURI))
+	}
+	p.Kind = KindForMediaType(p.MediaType)
+	return p, true, nil
+}
+
+// Classify returns the Kind and media type of one file map from its metadata
+// alone: "media_type", a data URL header, or the extension of "path", "url",
+// "file_uri" or "filename". Unlike Normalize it never reads files or decodes
+// content, so parts whose type only sniffing would reveal are KindFile. The
+// returned Part carries no Data or Text.
+func Classify(m map[string]any) (Part, bool) {
+	if len(m) == 0 {
+		return Part{}, false
+	}
+	p := Part{
+		MediaType: stringValue(m["media_type"]),
+		Filename:  stringValue(m["filename"]),
+		Title:     stringValue(m["title"]),
+	}
+	switch {
+	case stringValue(m["path"]) != "":
+		path := stringValue(m["path"])
+		if p.Filename == "" {
+			p.Filename = filepath.Base(path)
+		}
+		if p.MediaType == "" {
+			p.MediaType = mediaTypeFromName(path)
+		}
+	case stringValue(m["text"]) != "":
+		p.MediaType = "text/plain"
+	case stringValue(m["url"]) != "":
+		url := stringValue(m["url"])
+		if mediaType, ok := dataURLMediaType(url); ok {
+			p.MediaType = mediaType
+		} else {
+			p.URL = url
+			if p.MediaType == "" {
+				p.MediaType = mediaTypeFromName(url)
+			}
+		}
+	case stringValue(m["file_id"]) != "":
+		p.FileID = stringValue(m["file_id"])
+	case stringValue(m["file_uri"]) != "":
+		p.FileURI = stringValue(m["file_uri"])
+	case m["content"] != nil:
+		if mediaType, ok := dataURLMediaType(stringValue(m["content"])); ok {
+			p.MediaType = mediaType
+		}
+	default:
+		return Part{}, false
+	}
+
+	if p.MediaType == "" {
+		p.MediaType = mediaTypeFromName(firstNonEmpty(p.Filename, p.FileURI))
+	}
+	p.Kind = KindForMediaType(p.MediaType)
+	return p, true
+}
+
+// FromPayload returns the normalized file parts of a block payload, reading
+// turns.PayloadKeyDocuments followed by turns.PayloadKeyFiles. Images are not
+// included.
+func FromPayload(payload map[string]any) ([]Part, error) {
+	var parts []Part
+	for _, key := range []string{turns.PayloadKeyDocuments, turns.PayloadKeyFiles} {
+		for _, m := range Maps(payload[key]) {
+			p, ok, err := Normalize(m)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				parts = append(parts, p)
+			}
+		}
+	}
+	return parts, nil
+}
+
+// ClassifyPayload returns the file parts of a block payload like FromPayload,
+// classified with Classify and without reading any file.
+func ClassifyPayload(payload map[string]any) []Part {
+	var parts []Part
+	for _, key := range []string{turns.PayloadKeyDocuments, turns.PayloadKeyFiles} {
+		for _, m := range Maps(payload[key]) {
+			if p, ok := Classify(m); ok {
+				parts = append(parts, p)
+			}
+		}
+	}
+	return parts
+}
+
+// Maps returns the entries of a payload list value. Turns loaded from YAML
+// carry them as []any.
+func Maps(v any) []map[string]any {
+	switch vv := v.(type) {
+	case []map[string]any:
+		return vv
+	case []any:
+		ret := make([]map[string]any, 0, len(vv))
+		for _, item := range vv {
+			if m, ok := item.(map[string]any); ok {
+				ret = append(ret, m)
+			}
+		}
+		return ret
+	default:
+		return nil
+	}
+}
+
+// KindForMediaType maps a media type to a Kind. Empty and unknown media types
+// are KindFile.
+func KindForMediaType(mediaType string) Kind {
+	mt := strings.ToLower(strings.TrimSpace(mediaType))
+	if i := strings.Index(mt, ";"); i >= 0 {
+		mt = strings.TrimSpace(mt[:i])
+	}
+	switch {
+	case mt == "application/pdf":
+		return KindPDF
+	case strings.HasPrefix(mt, "image/"):
+		return KindImage
+	case strings.HasPrefix(mt, "audio/"):
+		return KindAudio
+	case strings.HasPrefix(mt, "video/"):
+		return KindVideo
+	case strings.HasPrefix(mt, "text/"):
+		return KindText
+	default:
+		return KindFile
+	}
+}
+
+// Modality returns the ModelInfo input modality the part requires.
+func (p Part) Modality() settings.InputModality {
+	switch p.Kind {
+	case KindImage:
+		return settings.InputModalityImage
+	case KindPDF:
+		return settings.InputModalityPDF
+	case KindAudio:
+		return settings.InputModalityAudio
+	case KindVideo:
+		return settings.InputModalityVideo
+	case KindText:
+		return settings.InputModalityText
+	case KindFile:
+		return settings.InputModality(KindFile)
+	}
+	return settings.InputModality(p.Kind)
+}
+
+// Describe returns a short human-readable label for error messages.
+func (p Part) Describe() string {
+	name := firstNonEmpty(p.Filename, p.Title, p.URL, p.FileID, p.FileURI)
+	if name == "" {
+		return fmt.Sprintf("%s part (%s)", p.Kind, p.MediaType)
+	}
+	return fmt.Sprintf("%s part %q (%s)", p.Kind, name, p.MediaType)
+}
+
+// Base64 returns the inline data base64-encoded.
+func (p Part) Base64() string {
+	return base64.StdEncoding.EncodeToString(p.Data)
+}
+
+// DataURL returns the inline data as a base64 data URL, or "" when the part
+// carries no inline data.
+func (p Part) DataURL() string {
+	if len(p.Data) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("data:%s;base64,%s", p.MediaType, p.Base64())
+}
+
+// FilenameOrDefault returns Filename, or a name derived from the kind and
+// media type for providers that require one.
+func (p Part) FilenameOrDefault() string {
+	if p.Filename != "" {
+		return p.Filename
+	}
+	ext := ""
+	if exts, err := mime.ExtensionsByType(p.MediaType); err == nil && len(exts) > 0 {
+		ext = exts[0]
+	}
+	if p.Kind == KindPDF {
+		ext = ".pdf"
+	}
+	return "file" + ext
+}
+
+// TextBody returns the plain-text body of a text part.
+func (p Part) TextBody() string {
+	if p.Text != "" {
+		return p.Text
+	}
+	return string(p.Data)
+}
+
+// LabeledText returns the text body prefixed with the document title or
+// filename, for providers that receive plain-text documents as prompt text.
+func (p Part) LabeledText() string {
+	body := p.TextBody()
+	if body == "" {
+		return ""
+	}
+	if label := firstNonEmpty(p.Title, p.Filename); label != "" {
+		return fmt.Sprintf("Document: %s\n\n%s", label, body)
+	}
+	return body
+}
+
+// AudioFormat returns "wav" or "mp3" for the audio media types OpenAI input
+// audio accepts, and "" otherwise.
+func AudioFormat(mediaType string) string {
+	switch strings.ToLower(strings.TrimSpace(mediaType)) {
+	case "audio/wav", "audio/x-wav", "audio/wave":
+		return "wav"
+	case "audio/mpeg", "audio/mp3":
+		return "mp3"
+	default:
+		return ""
+	}
+}
+
+// Unsupported returns an ErrUnsupportedInput error for a part the provider
+// API cannot carry.
+func Unsupported(provider string, p Part) error {
+	return fmt.Errorf("%w: %s does not accept %s", ErrUnsupportedInput, provider, p.Describe())
+}
+
+// CheckModelInput returns an ErrUnsupportedInput error for the first part
+// whose modality is not listed in info.Input. Models without ModelInfo or
+// without a declared input list accept every part.
+func CheckModelInput(info *settings.ModelInfo, parts ...Part) error {
+	if info == nil || len(info.Input) == 0 {
+		return nil
+	}
+	for _, p := range parts {
+		if !acceptsModality(info, p.Modality()) {
+			name := "model"
+			if info.ID != nil && *info.ID != "" {
+				name = "model " + *info.ID
+			}
+			accepted := make([]string, 0, len(info.Input))
+			for _, in := range info.Input {
+				accepted = append(accepted, string(in))
+			}
+			return fmt.Errorf("%w: %s does not accept %s input (%s); model_info.input is [%s]",
+				ErrUnsupportedInput, name, p.Modality(), p.Describe(), strings.Join(accepted, ", "))
+		}
+	}
+	return nil
+}
+
+// CheckTurnInput applies CheckModelInput to the images and file parts of all
+// user blocks of t. Parts are classified from their metadata (see Classify),
+// so no file is read.
+func CheckTurnInput(info *settings.ModelInfo, t *turns.Turn) error {
+	if t == nil || info == nil || len(info.Input) == 0 {
+		return nil
+	}
+	for _, b := range t.Blocks {
+		if b.Kind != turns.BlockKindUser {
+			continue
+		}
+		for _, img := range Maps(b.Payload[turns.PayloadKeyImages]) {
+			if err := CheckModelInput(info, imagePart(img)); err != nil {
+				return err
+			}
+		}
+		if err := CheckModelInput(info, ClassifyPayload(b.Payload)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// imagePart describes an image map for CheckModelInput.
+func imagePart(m map[string]any) Part {
+	p := Part{Kind: KindImage, MediaType: stringValue(m["media_type"])}
+	url := firstNonEmpty(stringValue(m["url"]), stringValue(m["image_url"]))
+	if mediaType, ok := dataURLMediaType(url); ok {
+		if p.MediaType == "" {
+			p.MediaType = mediaType
+		}
+	} else {
+		p.URL = url
+	}
+	if path := stringValue(m["path"]); path != "" {
+		p.Filename = filepath.Base(path)
+	}
+	return p
+}
+
+func acceptsModality(info *settings.ModelInfo, m settings.InputModality) bool {
+	for _, in := range info.Input {
+		if strings.EqualFold(string(in), string(m)) {
+			return true
+		}
+	}
+	return false
+}
+
+func mediaTypeFromName(name string) string {
+	ext := strings.ToLower(filepath.Ext(strings.SplitN(name, "?", 2)[0]))
+	if ext == "" {
+		return ""
+	}
+	if ext == ".md" {
+		return "text/markdown"
+	}
+	mt := mime.TypeByExtension(ext)
+	if i := strings.Index(mt, ";"); i >= 0 {
+		mt = strings.TrimSpace(mt[:i])
+	}
+	return mt
+}
+
+// dataURLMediaType returns the media type of a data URL without decoding it.
+func dataURLMediaType(value string) (string, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(value), "data:")
+	if !ok {
+		return "", false
+	}
+	meta, _, ok := strings.Cut(rest, ",")
+	if !ok {
+		return "", false
+	}
+	mediaType, _, _ := strings.Cut(meta, ";")
+	return strings.TrimSpace(mediaType), true
+}
+
+func contentBytes(raw any, textual bool) ([]byte, error) {
+	switch v := raw.(type) {
+	case []byte:
+		return append([]byte(nil), v...), nil
+	case string:
+		if textual {
+			return []byte(v), nil
+		}
+		s := strings.TrimSpace(v)
+		if s == "" {
+			return nil, nil
+		}
+		decoded, err := base64.StdEncoding.DecodeString(s)
+		if err == nil {
+			return decoded, nil
+		}
+		return nil, fmt.Errorf("file part content must be bytes, base64 or a data URL: %w", err)
+	default:
+		return nil, fmt.Errorf("unsupported file part content type %T", raw)
+	}
+}
+
+func stringValue(v any) string {
+	switch vv := v.(type) {
+	case string:
+		return strings.TrimSpace(vv)
+	case []byte:
+		return strings.TrimSpace(string(vv))
+	default:
+		return ""
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
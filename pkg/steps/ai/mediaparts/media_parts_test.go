@@ -0,0 +1,220 @@
+package mediaparts
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+)
+
+func TestNormalizePath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.pdf")
+	if err := os.WriteFile(path, []byte("%PDF-1.7"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p, ok, err := Normalize(map[string]any{"path": path})
+	if err != nil || !ok {
+		t.Fatalf("Normalize ok=%v err=%v", ok, err)
+	}
+	if p.Kind != KindPDF || p.MediaType != "application/pdf" || p.Filename != "report.pdf" || string(p.Data) != "%PDF-1.7" {
+		t.Fatalf("part = %#v", p)
+	}
+}
+
+func TestNormalizePathRejectsOversizedAndIrregularFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "huge.pdf")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Truncate(MaxFileBytes + 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Normalize(map[string]any{"path": path}); err == nil || !strings.Contains(err.Error(), "byte limit") {
+		t.Fatalf("expected size limit error, got %v", err)
+	}
+	if _, _, err := Normalize(map[string]any{"path": dir}); err == nil || !strings.Contains(err.Error(), "not a regular file") {
+		t.Fatalf("expected regular file error, got %v", err)
+	}
+}
+
+func TestNormalizeSources(t *testing.T) {
+	cases := []struct {
+		name string
+		in   map[string]any
+		want Part
+	}{
+		{
+			name: "data url",
+			in:   map[string]any{"url": "data:audio/wav;base64," + base64.StdEncoding.EncodeToString([]byte("RIFF"))},
+			want: Part{Kind: KindAudio, MediaType: "audio/wav", Data: []byte("RIFF")},
+		},
+		{
+			name: "remote url",
+			in:   map[string]any{"url": "https://example.com/paper.pdf?dl=1"},
+			want: Part{Kind: KindPDF, MediaType: "application/pdf", URL: "https://example.com/paper.pdf?dl=1"},
+		},
+		{
+			name: "base64 content",
+			in:   map[string]any{"media_type": "video/mp4", "content": base64.StdEncoding.EncodeToString([]byte("MP4"))},
+			want: Part{Kind: KindVideo, MediaType: "video/mp4", Data: []byte("MP4")},
+		},
+		{
+			name: "literal text content",
+			in:   map[string]any{"media_type": "text/csv", "content": "a,b\n1,2"},
+			want: Part{Kind: KindText, MediaType: "text/csv", Data: []byte("a,b\n1,2")},
+		},
+		{
+			name: "text body",
+			in:   map[string]any{"text": "notes", "title": "Notes", "citations": true},
+			want: Part{Kind: KindText, MediaType: "text/plain", Text: "notes", Title: "Notes", Citations: true},
+		},
+		{
+			name: "provider file id",
+			in:   map[string]any{"file_id": "file-123", "media_type": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+			want: Part{Kind: KindFile, MediaType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", FileID: "file-123"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok, err := Normalize(tc.in)
+			if err != nil || !ok {
+				t.Fatalf("Normalize ok=%v err=%v", ok, err)
+			}
+			if got.Kind != tc.want.Kind || got.MediaType != tc.want.MediaType || string(got.Data) != string(tc.want.Data) ||
+				got.Text != tc.want.Text || got.URL != tc.want.URL || got.FileID != tc.want.FileID ||
+				got.Title != tc.want.Title || got.Citations != tc.want.Citations {
+				t.Fatalf("part = %#v, want %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeRejectsInlineContentWithoutMediaType(t *testing.T) {
+	if _, _, err := Normalize(map[string]any{"content": []byte("???")}); err == nil {
+		t.Fatalf("expected error for content without media_type")
+	}
+}
+
+func TestFromPayloadReadsDocumentsAndFilesFromYAMLShapes(t *testing.T) {
+	payload := map[string]any{
+		turns.PayloadKeyDocuments: []any{map[string]any{"text": "a"}},
+		turns.PayloadKeyFiles:     []map[string]any{{"file_uri": "gs://bucket/clip.mp3", "media_type": "audio/mpeg"}},
+	}
+	parts, err := FromPayload(payload)
+	if err != nil {
+		t.Fatalf("FromPayload: %v", err)
+	}
+	if len(parts) != 2 || parts[0].Kind != KindText || parts[1].Kind != KindAudio {
+		t.Fatalf("parts = %#v", parts)
+	}
+}
+
+func TestCheckTurnInput(t *testing.T) {
+	id := "text-only"
+	info := &settings.ModelInfo{ID: &id, Input: []settings.InputModality{settings.InputModalityText, settings.InputModalityImage}}
+	ok := &turns.Turn{Blocks: []turns.Block{
+		turns.NewUserMultimodalBlock("look", []map[string]any{{"url": "https://example.com/a.png"}}),
+		turns.NewUserFileBlock("read", []map[string]any{{"text": "plain"}}),
+	}}
+	if err := CheckTurnInput(info, ok); err != nil {
+		t.Fatalf("CheckTurnInput: %v", err)
+	}
+
+	bad := &turns.Turn{Blocks: []turns.Block{
+		turns.NewUserFileBlock("listen", []map[string]any{{"media_type": "audio/wav", "content": []byte("RIFF"), "filename": "memo.wav"}}),
+	}}
+	err := CheckTurnInput(info, bad)
+	if !errors.Is(err, ErrUnsupportedInput) {
+		t.Fatalf("expected ErrUnsupportedInput, got %v", err)
+	}
+	for _, want := range []string{"model text-only", "audio", "memo.wav", "[text, image]"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q does not mention %q", err, want)
+		}
+	}
+
+	if err := CheckTurnInput(&settings.ModelInfo{}, bad); err != nil {
+		t.Fatalf("undeclared input must accept everything, got %v", err)
+	}
+}
+
+func TestKindForMediaType(t *testing.T) {
+	cases := map[string]Kind{
+		"":                         KindFile,
+		"application/pdf":          KindPDF,
+		"Application/PDF; q=1":     KindPDF,
+		"image/png":                KindImage,
+		"audio/mpeg":               KindAudio,
+		"video/mp4":                KindVideo,
+		"text/markdown":            KindText,
+		"application/octet-stream": KindFile,
+	}
+	for mediaType, want := range cases {
+		if got := KindForMediaType(mediaType); got != want {
+			t.Fatalf("KindForMediaType(%q) = %s, want %s", mediaType, got, want)
+		}
+	}
+}
+
+func TestClassifyUsesMetadataOnly(t *testing.T) {
+	cases := []struct {
+		name string
+		in   map[string]any
+		kind Kind
+		mt   string
+	}{
+		{name: "missing path by extension", in: map[string]any{"path": "/does/not/exist/report.pdf"}, kind: KindPDF, mt: "application/pdf"},
+		{name: "missing path without extension", in: map[string]any{"path": "/does/not/exist/notes"}, kind: KindFile},
+		{name: "explicit media type", in: map[string]any{"path": "/does/not/exist/clip", "media_type": "audio/wav"}, kind: KindAudio, mt: "audio/wav"},
+		{name: "data url header", in: map[string]any{"url": "data:video/mp4;base64,not-decoded"}, kind: KindVideo, mt: "video/mp4"},
+		{name: "data url content", in: map[string]any{"content": "data:application/pdf;base64,not-decoded"}, kind: KindPDF, mt: "application/pdf"},
+		{name: "file uri extension", in: map[string]any{"file_uri": "gs://bucket/talk.mp3"}, kind: KindAudio, mt: "audio/mpeg"},
+		{name: "file id by filename", in: map[string]any{"file_id": "file-1", "filename": "scan.pdf"}, kind: KindPDF, mt: "application/pdf"},
+		{name: "file id without metadata", in: map[string]any{"file_id": "file-1"}, kind: KindFile},
+		{name: "text", in: map[string]any{"text": "hello"}, kind: KindText, mt: "text/plain"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, ok := Classify(tc.in)
+			if !ok || p.Kind != tc.kind || p.MediaType != tc.mt || p.Data != nil {
+				t.Fatalf("Classify() = %#v ok=%v, want kind %s media type %q", p, ok, tc.kind, tc.mt)
+			}
+		})
+	}
+	if _, ok := Classify(map[string]any{"title": "no source"}); ok {
+		t.Fatalf("expected a map without source to be skipped")
+	}
+}
+
+func TestCheckTurnInputChecksEveryImageWithoutReadingFiles(t *testing.T) {
+	info := &settings.ModelInfo{Input: []settings.InputModality{settings.InputModalityText, settings.InputModalityPDF}}
+	pdfOnly := &turns.Turn{Blocks: []turns.Block{
+		turns.NewUserFileBlock("read", []map[string]any{{"path": "/does/not/exist/report.pdf"}}),
+	}}
+	if err := CheckTurnInput(info, pdfOnly); err != nil {
+		t.Fatalf("CheckTurnInput must not read files, got %v", err)
+	}
+
+	withImages := &turns.Turn{Blocks: []turns.Block{
+		turns.NewUserMultimodalBlock("look", []map[string]any{{"url": "https://example.com/a.png"}, {"url": "https://example.com/b.png"}}),
+	}}
+	err := CheckTurnInput(info, withImages)
+	if !errors.Is(err, ErrUnsupportedInput) || !strings.Contains(err.Error(), "a.png") {
+		t.Fatalf("expected the first image to be rejected, got %v", err)
+	}
+
+	info.Input = append(info.Input, settings.InputModalityImage)
+	if err := CheckTurnInput(info, withImages); err != nil {
+		t.Fatalf("CheckTurnInput: %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/security"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/providerhttp"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/ratelimit"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	ai_types "github.com/go-go-golems/geppetto/pkg/steps/ai/types"
+	"github.com/pkg/errors"
+)
+
+// DefaultBaseURL is the endpoint of a locally running Ollama daemon.
+const DefaultBaseURL = "http://localhost:11434"
+
+// maxChatErrorBodyBytes bounds how much of a failed response body is copied
+// into the returned error.
+const maxChatErrorBodyBytes = 64 * 1024
+
+// maxChatFrameBytes caps one NDJSON stream frame. Frames carry a single delta,
+// so this only trips on a broken or hostile server.
+const maxChatFrameBytes = 4 << 20
+
+type chatStreamConfig struct {
+	baseURL    string
+	endpoint   string
+	apiKey     string
+	httpClient *http.Client
+}
+
+type chatStream struct {
+	resp    *http.Response
+	scanner *bufio.Scanner
+}
+
+// resolveChatStreamConfig derives the /api/chat endpoint, optional bearer key
+// and HTTP client from inference settings.
+//
+// Ollama is a self-hosted server that normally listens on plain HTTP on
+// loopback or the LAN, so like the Ollama embeddings provider the endpoint is
+// allowed to use HTTP and local network addresses. When no ollama-base-url is
+// configured the engine targets the local daemon at DefaultBaseURL.
+func resolveChatStreamConfig(apiSettings *settings.APISettings, clientSettings *settings.ClientSettings, model string) (chatStreamConfig, error) {
+	apiType := string(ai_types.ApiTypeOllama)
+	baseURL := ""
+	apiKey := ""
+	if apiSettings != nil {
+		baseURL = strings.TrimSpace(apiSettings.BaseUrls[apiType+"-base-url"])
+		apiKey = strings.TrimSpace(apiSettings.APIKeys[apiType+"-api-key"])
+	}
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	outboundOptions := security.OutboundURLOptions{AllowHTTP: true, AllowLocalNetworks: true}
+	endpoint := strings.TrimRight(baseURL, "/") + "/api/chat"
+	if err := security.ValidateOutboundURL(endpoint, outboundOptions); err != nil {
+		return chatStreamConfig{}, errors.Wrap(err, "invalid ollama chat URL")
+	}
+	httpClient, err := providerhttp.Client(clientSettings, ratelimit.NewKey(apiType, model, apiKey))
+	if err != nil {
+		return chatStreamConfig{}, err
+	}
+	return chatStreamConfig{
+		baseURL:    baseURL,
+		endpoint:   endpoint,
+		apiKey:     apiKey,
+		httpClient: httpClient,
+	}, nil
+}
+
+func openChatStream(ctx context.Context, cfg chatStreamConfig, req *ChatRequest) (*chatStream, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal ollama chat request")
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "create ollama chat request")
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/x-ndjson")
+	if cfg.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+cfg.apiKey)
+	}
+	// #nosec G704 -- endpoint URL is validated in resolveChatStreamConfig.
+	resp, err := cfg.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, chatHTTPError(resp)
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxChatFrameBytes)
+	return &chatStream{resp: resp, scanner: scanner}, nil
+}
+
+func chatHTTPError(resp *http.Response) error {
+	defer func() { _ = resp.Body.Close() }()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, maxChatErrorBodyBytes))
+	var payload struct {
+		Error string `json:"error"`
+	}
+	msg := fmt.Sprintf("ollama chat error: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	switch {
+	case json.Unmarshal(raw, &payload) == nil && strings.TrimSpace(payload.Error) != "":
+		msg = fmt.Sprintf("ollama chat error: status=%d error=%s", resp.StatusCode, strings.TrimSpace(payload.Error))
+	case len(raw) == 0:
+		msg = fmt.Sprintf("ollama chat error: status=%d", resp.StatusCode)
+	}
+	return &engine.StatusError{StatusCode: resp.StatusCode, Message: msg}
+}
+
+// Recv returns the next NDJSON frame. It returns io.EOF once the body is
+// exhausted; mid-stream {"error": "..."} frames and frames over
+// maxChatFrameBytes are surfaced as errors.
+func (s *chatStream) Recv() (*ChatResponse, error) {
+	for {
+		if !s.scanner.Scan() {
+			if err := s.scanner.Err(); err != nil {
+				if errors.Is(err, bufio.ErrTooLong) {
+					return nil, fmt.Errorf("ollama chat stream frame exceeds %d bytes", maxChatFrameBytes)
+				}
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		trimmed := bytes.TrimSpace(s.scanner.Bytes())
+		if len(trimmed) == 0 {
+			continue
+		}
+		var resp ChatResponse
+		if decodeErr := json.Unmarshal(trimmed, &resp); decodeErr != nil {
+			return nil, errors.Wrap(decodeErr, "decode ollama chat stream frame")
+		}
+		if strings.TrimSpace(resp.Error) != "" {
+			return nil, fmt.Errorf("ollama chat stream error: %s", strings.TrimSpace(resp.Error))
+		}
+		return &resp, nil
+	}
+}
+
+func (s *chatStream) Close() error {
+	if s == nil || s.resp == nil || s.resp.Body == nil {
+		return nil
+	}
+	return s.resp.Body.Close()
+}
@@ -0,0 +1,69 @@
+package ollama
+
+// ChatRequest is the Ollama /api/chat request payload.
+type ChatRequest struct {
+	Model    string         `json:"model"`
+	Messages []ChatMessage  `json:"messages"`
+	Tools    []ChatTool     `json:"tools,omitempty"`
+	Format   any            `json:"format,omitempty"`
+	Options  map[string]any `json:"options,omitempty"`
+	Stream   bool           `json:"stream"`
+	// Think is either a bool or one of "low", "medium", "high" for models that
+	// support graded reasoning effort.
+	Think     any    `json:"think,omitempty"`
+	KeepAlive string `json:"keep_alive,omitempty"`
+}
+
+// ChatMessage is a single message in an Ollama chat request or streamed response.
+type ChatMessage struct {
+	Role      string         `json:"role"`
+	Content   string         `json:"content"`
+	Thinking  string         `json:"thinking,omitempty"`
+	Images    []string       `json:"images,omitempty"`
+	ToolCalls []ChatToolCall `json:"tool_calls,omitempty"`
+	ToolName  string         `json:"tool_name,omitempty"`
+}
+
+// ChatToolCall is a function call requested by the model. Older Ollama
+// releases do not send IDs, so the engine synthesizes one when absent.
+type ChatToolCall struct {
+	ID       string               `json:"id,omitempty"`
+	Function ChatToolCallFunction `json:"function"`
+}
+
+type ChatToolCallFunction struct {
+	Index     *int           `json:"index,omitempty"`
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments"`
+}
+
+// ChatTool advertises a function tool to the model.
+type ChatTool struct {
+	Type     string           `json:"type"`
+	Function ChatToolFunction `json:"function"`
+}
+
+type ChatToolFunction struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parameters  any    `json:"parameters,omitempty"`
+}
+
+// ChatResponse is one NDJSON frame of a streamed /api/chat response. The final
+// frame has Done=true and carries the token counts and timings.
+type ChatResponse struct {
+	Model              string      `json:"model"`
+	CreatedAt          string      `json:"created_at,omitempty"`
+	Message            ChatMessage `json:"message"`
+	Done               bool        `json:"done"`
+	DoneReason         string      `json:"done_reason,omitempty"`
+	TotalDuration      int64       `json:"total_duration,omitempty"`
+	LoadDuration       int64       `json:"load_duration,omitempty"`
+	PromptEvalCount    int         `json:"prompt_eval_count,omitempty"`
+	PromptEvalDuration int64       `json:"prompt_eval_duration,omitempty"`
+	EvalCount          int         `json:"eval_count,omitempty"`
+	EvalDuration       int64       `json:"eval_duration,omitempty"`
+	Error              string      `json:"error,omitempty"`
+}
+
+const chatToolTypeFunction = "function"
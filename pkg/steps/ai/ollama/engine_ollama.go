@@ -0,0 +1,263 @@
+package ollama
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/inference/engine"
+	gepsession "github.com/go-go-golems/geppetto/pkg/inference/session"
+	"github.com/go-go-golems/geppetto/pkg/inference/tools"
+	geppettoobs "github.com/go-go-golems/geppetto/pkg/observability"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/ratelimit"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/retry"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/runtimeattrib"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// OllamaEngine implements the Engine interface against the native Ollama
+// /api/chat endpoint. Unlike the OpenAI-compatible shim, the native API exposes
+// thinking output, tool calls, images and hardware/runtime options directly.
+type OllamaEngine struct {
+	settings            *settings.InferenceSettings
+	observer            geppettoobs.Observer
+	observabilityConfig geppettoobs.Config
+}
+
+// EngineOption configures an OllamaEngine.
+type EngineOption func(*OllamaEngine)
+
+// NewOllamaEngine creates a new Ollama inference engine with the given settings.
+func NewOllamaEngine(settings *settings.InferenceSettings, opts ...EngineOption) (*OllamaEngine, error) {
+	ret := &OllamaEngine{settings: settings, observabilityConfig: geppettoobs.DefaultConfig()}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(ret)
+		}
+	}
+	ret.observabilityConfig = ret.observabilityConfig.Normalized()
+	return ret, nil
+}
+
+// RunInference processes a Turn using the Ollama chat API and appends result blocks.
+func (e *OllamaEngine) RunInference(ctx context.Context, t *turns.Turn) (*turns.Turn, error) {
+	if e.settings == nil || e.settings.Chat == nil || e.settings.Chat.Engine == nil {
+		return nil, errors.New("no engine specified")
+	}
+
+	cfg, err := resolveChatStreamConfig(e.settings.API, e.settings.Client, *e.settings.Chat.Engine)
+	if err != nil {
+		return nil, err
+	}
+	req, err := e.MakeChatRequestFromTurn(t)
+	if err != nil {
+		return nil, err
+	}
+	req.Tools = ollamaToolsFromDefinitions(tools.AdvertisedToolDefinitionsFromContext(ctx))
+
+	startTime := time.Now()
+	metadata := e.ollamaEventMetadata(t, req.Model)
+	inferenceScopeID := metadata.InferenceID
+	if inferenceScopeID == "" {
+		inferenceScopeID = metadata.ID.String()
+	}
+	providerCallIndex := 0
+	if idx, ok := gepsession.ProviderCallIndexFromContext(ctx); ok {
+		providerCallIndex = idx
+	}
+	providerCallCorr := ollamaProviderCallCorrelation(metadata, inferenceScopeID, providerCallIndex)
+	e.publishEvent(ctx, events.NewProviderCallStartedEvent(metadata, providerCallCorr))
+	e.publishProviderRecord(ctx, metadata, providerCallCorr, "ollama.chat.request", req)
+
+	streamState := newOllamaStreamState(providerCallCorr)
+	var terminalErr error
+	streamCtx := ratelimit.WithTokenEstimate(ctx, ratelimit.EstimateTurnTokens(t))
+	streamCtx = retry.WithEventReporter(streamCtx, metadata, providerCallCorr, e.publishEvent)
+	stream, err := openChatStream(streamCtx, cfg, req)
+	if err != nil {
+		terminalErr = err
+	} else {
+		defer func() { _ = stream.Close() }()
+		for {
+			resp, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				terminalErr = err
+				break
+			}
+			e.publishProviderRecord(ctx, metadata, providerCallCorr, "ollama.chat.stream.chunk", resp)
+			for _, event := range reduceOllamaChatResponse(metadata, streamState, resp) {
+				e.publishEvent(ctx, event)
+			}
+			if resp.Done {
+				break
+			}
+		}
+	}
+	if terminalErr != nil && ctx.Err() != nil {
+		streamState.finalStopReason = "cancelled"
+		terminalErr = ctx.Err()
+	}
+	if terminalErr != nil {
+		log.Error().Err(terminalErr).Msg("Ollama stream receive failed")
+	}
+
+	result, completionEvents := completeOllamaStream(t, &metadata, streamState, startTime, terminalErr)
+	settings.ApplyModelInfoCost(&result, e.settings.ModelInfo)
+	if err := engine.PersistInferenceResult(t, result); err != nil {
+		log.Warn().Err(err).Msg("Ollama: failed to persist canonical inference_result")
+	}
+	for _, event := range completionEvents {
+		e.publishEvent(ctx, event)
+	}
+	if terminalErr != nil {
+		return t, terminalErr
+	}
+	return t, nil
+}
+
+func (e *OllamaEngine) ollamaEventMetadata(t *turns.Turn, modelName string) events.EventMetadata {
+	metadata := events.EventMetadata{
+		ID: uuid.New(),
+		LLMInferenceData: events.LLMInferenceData{
+			Model:       modelName,
+			Usage:       nil,
+			StopReason:  nil,
+			Temperature: e.settings.Chat.Temperature,
+			TopP:        e.settings.Chat.TopP,
+			MaxTokens:   e.settings.Chat.MaxResponseTokens,
+		},
+	}
+	if t != nil {
+		if sid, ok, err := turns.KeyTurnMetaSessionID.Get(t.Metadata); err == nil && ok {
+			metadata.SessionID = sid
+		}
+		if iid, ok, err := turns.KeyTurnMetaInferenceID.Get(t.Metadata); err == nil && ok {
+			metadata.InferenceID = iid
+		}
+		metadata.TurnID = t.ID
+	}
+	metadata.Extra = map[string]interface{}{}
+	metadata.Extra[events.MetadataSettingsSlug] = e.settings.GetMetadata()
+	runtimeattrib.AddRuntimeAttributionToExtra(metadata.Extra, t)
+	return metadata
+}
+
+func ollamaToolsFromDefinitions(defs []engine.ToolDefinition) []ChatTool {
+	if len(defs) == 0 {
+		return nil
+	}
+	out := make([]ChatTool, 0, len(defs))
+	for _, td := range defs {
+		tool := ChatTool{
+			Type: chatToolTypeFunction,
+			Function: ChatToolFunction{
+				Name:        td.Name,
+				Description: td.Description,
+			},
+		}
+		if td.Parameters != nil {
+			tool.Function.Parameters = td.Parameters
+		} else {
+			tool.Function.Parameters = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+		out = append(out, tool)
+	}
+	return out
+}
+
+func completeOllamaStream(
+	t *turns.Turn,
+	metadata *events.EventMetadata,
+	state *ollamaStreamState,
+	startedAt time.Time,
+	terminalErr error,
+) (engine.InferenceResult, []events.Event) {
+	if metadata == nil {
+		return engine.InferenceResult{}, nil
+	}
+	if state == nil {
+		state = newOllamaStreamState(events.Correlation{})
+	}
+	if terminalErr != nil && strings.TrimSpace(state.finalStopReason) == "" {
+		state.finalStopReason = "error"
+	}
+	out := make([]events.Event, 0, 4)
+	if state.reasoningStarted {
+		out = append(out, events.NewReasoningSegmentFinishedEventWithSource(*metadata, state.reasoningCorr, "provider", state.reasoning, state.finalStopReason))
+	}
+	if state.message != "" && state.textSegmentStarted {
+		out = append(out, events.NewTextSegmentFinishedEvent(*metadata, state.textCorr, state.message, state.finalStopReason))
+	}
+	appendOllamaStateBlocks(t, state)
+
+	durationMs := time.Since(startedAt).Milliseconds()
+	metadata.DurationMs = &durationMs
+	if strings.TrimSpace(state.finalStopReason) != "" {
+		metadata.StopReason = &state.finalStopReason
+	}
+	if state.finalUsage != nil {
+		metadata.Usage = state.finalUsage
+	}
+	if len(state.finalUsageExtra) > 0 {
+		if metadata.Extra == nil {
+			metadata.Extra = map[string]any{}
+		}
+		for k, v := range state.finalUsageExtra {
+			metadata.Extra[k] = v
+		}
+	}
+	hasToolCalls := len(state.pendingCalls) > 0
+	result := engine.BuildInferenceResultFromEventMetadata(*metadata, "ollama", hasToolCalls)
+	if terminalErr != nil && state.finalStopReason != "cancelled" {
+		result.FinishClass = engine.InferenceFinishClassError
+	}
+	if terminalErr != nil {
+		out = append(out, events.NewErrorEvent(*metadata, terminalErr))
+	}
+	out = append(out, events.NewProviderCallFinishedEvent(
+		*metadata,
+		state.providerCallCorr,
+		state.finalStopReason,
+		string(result.FinishClass),
+		metadata.Usage,
+		metadata.DurationMs,
+		hasToolCalls,
+	))
+	return result, out
+}
+
+func ollamaProviderCallCorrelation(metadata events.EventMetadata, inferenceScopeID string, providerCallIndex int) events.Correlation {
+	corr := events.BuildProviderCallCorrelation("ollama", inferenceScopeID, "", providerCallIndex, "")
+	corr.SessionID = metadata.SessionID
+	corr.TurnID = metadata.TurnID
+	return corr
+}
+
+func ollamaSegmentCorrelation(providerCallCorr events.Correlation, providerObjectID string, segmentIndex int, segmentType string) events.Correlation {
+	corr := events.BuildSegmentCorrelation(providerCallCorr, providerObjectID, segmentIndex, segmentType)
+	corr.SessionID = providerCallCorr.SessionID
+	corr.TurnID = providerCallCorr.TurnID
+	return corr
+}
+
+func ollamaToolCorrelation(providerCallCorr events.Correlation, toolCallID string, toolCallIndex int) events.Correlation {
+	corr := ollamaSegmentCorrelation(providerCallCorr, toolCallID, toolCallIndex, events.SegmentTypeTool)
+	corr.ToolCallID = toolCallID
+	return corr
+}
+
+// publishEvent publishes an event to all configured sinks and any sinks carried in context.
+func (e *OllamaEngine) publishEvent(ctx context.Context, event events.Event) {
+	e.publishEventRecord(ctx, event)
+	events.PublishEventToContext(ctx, event)
+}
+
+var _ engine.Engine = (*OllamaEngine)(nil)
@@ -0,0 +1,331 @@
+package ollama
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	ollamasettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings/ollama"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+)
+
+type capturingEventSink struct {
+	mu     sync.Mutex
+	events []events.Event
+}
+
+func (s *capturingEventSink) PublishEvent(event events.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *capturingEventSink) snapshot() []events.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]events.Event, len(s.events))
+	copy(out, s.events)
+	return out
+}
+
+func ptr[T any](v T) *T { return &v }
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func newTestEngine(t *testing.T, handler func(*http.Request, *ChatRequest) (int, string)) *OllamaEngine {
+	t.Helper()
+	client := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/api/chat" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		var req ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		status, body := handler(r, &req)
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": []string{"application/x-ndjson"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    r,
+		}, nil
+	})}
+	eng, err := NewOllamaEngine(&settings.InferenceSettings{
+		API:    &settings.APISettings{APIKeys: map[string]string{}, BaseUrls: map[string]string{}},
+		Client: &settings.ClientSettings{HTTPClient: client},
+		Chat:   &settings.ChatSettings{Engine: ptr("qwen3:8b"), Temperature: ptr(0.2)},
+	})
+	if err != nil {
+		t.Fatalf("NewOllamaEngine: %v", err)
+	}
+	return eng
+}
+
+func TestRunInference_StreamsThinkingTextAndUsage(t *testing.T) {
+	eng := newTestEngine(t, func(_ *http.Request, req *ChatRequest) (int, string) {
+		if req.Model != "qwen3:8b" || !req.Stream {
+			t.Fatalf("unexpected request model/stream: %+v", req)
+		}
+		if got := req.Options["temperature"]; got != 0.2 {
+			t.Fatalf("expected temperature 0.2, got %v", got)
+		}
+		if len(req.Messages) != 2 || req.Messages[0].Role != "system" || req.Messages[1].Role != "user" {
+			t.Fatalf("unexpected messages: %+v", req.Messages)
+		}
+		return http.StatusOK, strings.Join([]string{
+			`{"model":"qwen3:8b","message":{"role":"assistant","content":"","thinking":"Let me "},"done":false}`,
+			`{"model":"qwen3:8b","message":{"role":"assistant","content":"","thinking":"think."},"done":false}`,
+			`{"model":"qwen3:8b","message":{"role":"assistant","content":"Hello"},"done":false}`,
+			`{"model":"qwen3:8b","message":{"role":"assistant","content":" there"},"done":false}`,
+			`{"model":"qwen3:8b","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":12,"eval_count":7,"eval_duration":1000}`,
+		}, "\n") + "\n"
+	})
+
+	sink := &capturingEventSink{}
+	ctx := events.WithEventSinks(context.Background(), sink)
+	turn := &turns.Turn{Blocks: []turns.Block{
+		turns.NewSystemTextBlock("You are a LLM."),
+		turns.NewUserTextBlock("Hello"),
+	}}
+
+	out, err := eng.RunInference(ctx, turn)
+	if err != nil {
+		t.Fatalf("RunInference: %v", err)
+	}
+	if len(out.Blocks) != 4 {
+		t.Fatalf("expected 4 blocks, got %d", len(out.Blocks))
+	}
+	if out.Blocks[2].Kind != turns.BlockKindReasoning || out.Blocks[2].Payload[turns.PayloadKeyText] != "Let me think." {
+		t.Fatalf("unexpected reasoning block: %+v", out.Blocks[2])
+	}
+	if out.Blocks[3].Kind != turns.BlockKindLLMText || out.Blocks[3].Payload[turns.PayloadKeyText] != "Hello there" {
+		t.Fatalf("unexpected text block: %+v", out.Blocks[3])
+	}
+
+	result, ok, err := turns.KeyTurnMetaInferenceResult.Get(out.Metadata)
+	if err != nil || !ok {
+		t.Fatalf("expected persisted inference result, ok=%v err=%v", ok, err)
+	}
+	if result.Provider != "ollama" || result.StopReason != "stop" || result.FinishClass != engine.InferenceFinishClassCompleted {
+		t.Fatalf("unexpected inference result: %+v", result)
+	}
+	if result.Usage == nil || result.Usage.InputTokens != 12 || result.Usage.OutputTokens != 7 {
+		t.Fatalf("unexpected usage: %+v", result.Usage)
+	}
+
+	var reasoningDeltas, textDeltas, finished int
+	for _, event := range sink.snapshot() {
+		if err := events.ValidateCanonicalEvent(event); err != nil {
+			t.Fatalf("event %s should validate: %v", event.Type(), err)
+		}
+		switch event.(type) {
+		case *events.EventReasoningDelta:
+			reasoningDeltas++
+		case *events.EventTextDelta:
+			textDeltas++
+		case *events.EventProviderCallFinished:
+			finished++
+		}
+	}
+	if reasoningDeltas != 2 || textDeltas != 2 || finished != 1 {
+		t.Fatalf("unexpected event counts reasoning=%d text=%d finished=%d", reasoningDeltas, textDeltas, finished)
+	}
+}
+
+func TestRunInference_ToolCallsBecomeBlocks(t *testing.T) {
+	eng := newTestEngine(t, func(_ *http.Request, _ *ChatRequest) (int, string) {
+		return http.StatusOK, strings.Join([]string{
+			`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"get_weather","arguments":{"city":"Paris"}}}]},"done":false}`,
+			`{"message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":5,"eval_count":3}`,
+		}, "\n")
+	})
+
+	out, err := eng.RunInference(context.Background(), &turns.Turn{Blocks: []turns.Block{turns.NewUserTextBlock("weather?")}})
+	if err != nil {
+		t.Fatalf("RunInference: %v", err)
+	}
+	last := out.Blocks[len(out.Blocks)-1]
+	if last.Kind != turns.BlockKindToolCall || last.Payload[turns.PayloadKeyName] != "get_weather" {
+		t.Fatalf("expected tool call block, got %+v", last)
+	}
+	if id, _ := last.Payload[turns.PayloadKeyID].(string); id == "" {
+		t.Fatalf("expected synthesized tool call id")
+	}
+	result, ok, err := turns.KeyTurnMetaInferenceResult.Get(out.Metadata)
+	if err != nil || !ok {
+		t.Fatalf("expected persisted inference result, ok=%v err=%v", ok, err)
+	}
+	if result.FinishClass != engine.InferenceFinishClassToolCallsPending {
+		t.Fatalf("expected tool_calls_pending, got %s", result.FinishClass)
+	}
+}
+
+func TestRunInference_ErrorFrameReturnsError(t *testing.T) {
+	eng := newTestEngine(t, func(_ *http.Request, _ *ChatRequest) (int, string) {
+		return http.StatusOK, `{"message":{"role":"assistant","content":"partial"},"done":false}` + "\n" + `{"error":"model crashed"}` + "\n"
+	})
+
+	sink := &capturingEventSink{}
+	ctx := events.WithEventSinks(context.Background(), sink)
+	_, err := eng.RunInference(ctx, &turns.Turn{Blocks: []turns.Block{turns.NewUserTextBlock("hi")}})
+	if err == nil || !strings.Contains(err.Error(), "model crashed") {
+		t.Fatalf("expected stream error, got %v", err)
+	}
+	var errorEvents int
+	for _, event := range sink.snapshot() {
+		if event.Type() == events.EventTypeError {
+			errorEvents++
+		}
+	}
+	if errorEvents != 1 {
+		t.Fatalf("expected one error event, got %d", errorEvents)
+	}
+}
+
+func TestRunInference_RejectsOversizedFrame(t *testing.T) {
+	eng := newTestEngine(t, func(_ *http.Request, _ *ChatRequest) (int, string) {
+		return http.StatusOK, `{"message":{"role":"assistant","content":"` + strings.Repeat("x", maxChatFrameBytes) + `"},"done":true}` + "\n"
+	})
+	_, err := eng.RunInference(context.Background(), &turns.Turn{Blocks: []turns.Block{turns.NewUserTextBlock("hi")}})
+	if err == nil || !strings.Contains(err.Error(), "frame exceeds") {
+		t.Fatalf("expected oversized frame error, got %v", err)
+	}
+}
+
+func TestRunInference_HTTPErrorIncludesMessage(t *testing.T) {
+	eng := newTestEngine(t, func(_ *http.Request, _ *ChatRequest) (int, string) {
+		return http.StatusNotFound, `{"error":"model \"qwen3:8b\" not found, try pulling it first"}`
+	})
+	_, err := eng.RunInference(context.Background(), &turns.Turn{Blocks: []turns.Block{turns.NewUserTextBlock("hi")}})
+	if err == nil || !strings.Contains(err.Error(), "not found") || !strings.Contains(err.Error(), "status=404") {
+		t.Fatalf("expected 404 error with message, got %v", err)
+	}
+	var statusErr *engine.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected engine.StatusError with 404, got %T", err)
+	}
+}
+
+func TestMakeChatRequestFromTurn_MapsBlocks(t *testing.T) {
+	eng, err := NewOllamaEngine(&settings.InferenceSettings{
+		Chat: &settings.ChatSettings{Engine: ptr("llava"), MaxResponseTokens: ptr(256)},
+	})
+	if err != nil {
+		t.Fatalf("NewOllamaEngine: %v", err)
+	}
+	user := turns.NewUserTextBlock("describe")
+	user.Payload[turns.PayloadKeyImages] = []map[string]any{{"media_type": "image/png", "content": []byte("png-bytes")}}
+	turn := &turns.Turn{Blocks: []turns.Block{
+		user,
+		{Kind: turns.BlockKindReasoning, Role: turns.RoleAssistant, Payload: map[string]any{turns.PayloadKeyText: "hmm"}},
+		turns.NewAssistantTextBlock("calling tool"),
+		turns.NewToolCallBlock("call-1", "lookup", map[string]any{"q": "x"}),
+		turns.NewToolUseBlock("call-1", map[string]any{"answer": 42}),
+	}}
+
+	req, err := eng.MakeChatRequestFromTurn(turn)
+	if err != nil {
+		t.Fatalf("MakeChatRequestFromTurn: %v", err)
+	}
+	if req.Options["num_predict"] != 256 {
+		t.Fatalf("expected num_predict 256, got %v", req.Options["num_predict"])
+	}
+	if len(req.Messages) != 3 {
+		t.Fatalf("expected 3 messages, got %+v", req.Messages)
+	}
+	if got := req.Messages[0].Images; len(got) != 1 || got[0] != base64.StdEncoding.EncodeToString([]byte("png-bytes")) {
+		t.Fatalf("unexpected images: %v", got)
+	}
+	assistant := req.Messages[1]
+	if assistant.Role != "assistant" || assistant.Thinking != "hmm" || assistant.Content != "calling tool" || len(assistant.ToolCalls) != 1 {
+		t.Fatalf("unexpected assistant message: %+v", assistant)
+	}
+	if assistant.ToolCalls[0].Function.Arguments["q"] != "x" {
+		t.Fatalf("unexpected tool call args: %+v", assistant.ToolCalls[0])
+	}
+	tool := req.Messages[2]
+	if tool.Role != "tool" || tool.ToolName != "lookup" || tool.Content != `{"answer":42}` {
+		t.Fatalf("unexpected tool message: %+v", tool)
+	}
+}
+
+func TestMakeChatRequestFromTurn_RejectsRemoteImages(t *testing.T) {
+	eng, _ := NewOllamaEngine(&settings.InferenceSettings{Chat: &settings.ChatSettings{Engine: ptr("llava")}})
+	user := turns.NewUserTextBlock("describe")
+	user.Payload[turns.PayloadKeyImages] = []map[string]any{{"media_type": "image/png", "url": "https://example.com/a.png"}}
+	if _, err := eng.MakeChatRequestFromTurn(&turns.Turn{Blocks: []turns.Block{user}}); err == nil {
+		t.Fatalf("expected error for remote image url")
+	}
+}
+
+func TestThinkFromInferenceConfig(t *testing.T) {
+	if got := thinkFromInferenceConfig(&engine.InferenceConfig{ReasoningEffort: ptr("High")}); got != "high" {
+		t.Fatalf("expected high, got %v", got)
+	}
+	if got := thinkFromInferenceConfig(&engine.InferenceConfig{ThinkingType: ptr("disabled")}); got != false {
+		t.Fatalf("expected false, got %v", got)
+	}
+	if got := thinkFromInferenceConfig(&engine.InferenceConfig{ThinkingBudget: ptr(1024)}); got != true {
+		t.Fatalf("expected true, got %v", got)
+	}
+	if got := thinkFromInferenceConfig(&engine.InferenceConfig{}); got != nil {
+		t.Fatalf("expected nil, got %v", got)
+	}
+}
+
+func TestChatOptionsFromSettings_SendsEverySetValue(t *testing.T) {
+	opts := chatOptionsFromSettings(nil, &ollamasettings.Settings{Temperature: ptr(0.8), NumCtx: ptr(2048)}, nil)
+	if opts["temperature"] != 0.8 || opts["num_ctx"] != 2048 {
+		t.Fatalf("expected explicit values to be sent, got %v", opts)
+	}
+	if _, ok := opts["top_k"]; ok {
+		t.Fatalf("expected unset options to be omitted, got %v", opts)
+	}
+	if got := chatOptionsFromSettings(nil, &ollamasettings.Settings{}, nil); got != nil {
+		t.Fatalf("expected no options, got %v", got)
+	}
+}
+
+func TestResolveChatStreamConfig_DefaultsToLocalDaemon(t *testing.T) {
+	cfg, err := resolveChatStreamConfig(&settings.APISettings{}, nil, "llama3")
+	if err != nil {
+		t.Fatalf("resolveChatStreamConfig: %v", err)
+	}
+	if cfg.endpoint != "http://localhost:11434/api/chat" {
+		t.Fatalf("unexpected endpoint: %s", cfg.endpoint)
+	}
+
+	cfg, err = resolveChatStreamConfig(&settings.APISettings{
+		BaseUrls: map[string]string{"ollama-base-url": "http://192.168.1.20:11434/"},
+		APIKeys:  map[string]string{"ollama-api-key": "secret"},
+	}, nil, "llama3")
+	if err != nil {
+		t.Fatalf("resolveChatStreamConfig: %v", err)
+	}
+	if cfg.endpoint != "http://192.168.1.20:11434/api/chat" || cfg.apiKey != "secret" {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+}
+
+func TestResolveChatStreamConfig_RejectsUnsupportedScheme(t *testing.T) {
+	_, err := resolveChatStreamConfig(&settings.APISettings{
+		BaseUrls: map[string]string{"ollama-base-url": "file:///etc/passwd"},
+	}, nil, "llama3")
+	if err == nil {
+		t.Fatalf("expected non-http(s) base URL to be rejected")
+	}
+}
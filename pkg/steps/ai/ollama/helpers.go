@@ -0,0 +1,393 @@
+package ollama
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	infengine "github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/imageparts"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	ollamasettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings/ollama"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/pkg/errors"
+)
+
+const (
+	roleSystem    = "system"
+	roleUser      = "user"
+	roleAssistant = "assistant"
+	roleTool      = "tool"
+)
+
+// MakeChatRequestFromTurn builds an Ollama /api/chat request from a Turn's
+// blocks and the engine settings. Tools are attached by RunInference from the
+// registry carried in context.
+func (e *OllamaEngine) MakeChatRequestFromTurn(t *turns.Turn) (*ChatRequest, error) {
+	s := e.settings
+	if s == nil || s.Chat == nil {
+		return nil, errors.New("no chat settings")
+	}
+	if s.Chat.Engine == nil || strings.TrimSpace(*s.Chat.Engine) == "" {
+		return nil, errors.New("no engine specified")
+	}
+
+	messages, err := buildChatMessagesFromTurn(t)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &ChatRequest{
+		Model:    strings.TrimSpace(*s.Chat.Engine),
+		Messages: messages,
+		Stream:   true,
+	}
+
+	infCfg := infengine.ResolveInferenceConfig(t, s.Inference)
+	req.Options = chatOptionsFromSettings(s.Chat, s.Ollama, infCfg)
+	req.Think = thinkFromInferenceConfig(infCfg)
+
+	// Apply structured output from chat settings first, then let a per-turn
+	// StructuredOutputConfig override it.
+	if s.Chat.IsStructuredOutputEnabled() {
+		cfg, err := s.Chat.StructuredOutputConfig()
+		if err != nil {
+			if s.Chat.StructuredOutputRequireValid {
+				return nil, err
+			}
+			log.Warn().Err(err).Msg("Ollama request: ignoring invalid structured output configuration")
+		} else if cfg != nil {
+			req.Format = cfg.Schema
+		}
+	}
+	if t != nil {
+		if soCfg, ok, err := infengine.KeyStructuredOutputConfig.Get(t.Data); err == nil && ok && soCfg.IsEnabled() {
+			if err := soCfg.Validate(); err == nil {
+				req.Format = soCfg.Schema
+			}
+		}
+	}
+
+	return req, nil
+}
+
+// chatOptionsFromSettings maps generic chat settings, per-turn overrides and
+// the ollama-chat section into the request "options" object.
+//
+// Generic settings (temperature, top_p, max tokens, stop, seed) take precedence
+// over their ollama-* counterparts, mirroring how the other engines treat the
+// ai-chat section as authoritative. The ollama-chat fields have no section
+// defaults, so every set value is sent and unset ones leave the model's own
+// defaults (including Modelfile parameters) in place.
+func chatOptionsFromSettings(chat *settings.ChatSettings, ollama *ollamasettings.Settings, infCfg *infengine.InferenceConfig) map[string]any {
+	opts := map[string]any{}
+	if ollama != nil {
+		setOption(opts, "temperature", ollama.Temperature)
+		setOption(opts, "top_p", ollama.TopP)
+		setOption(opts, "top_k", ollama.TopK)
+		setOption(opts, "seed", ollama.Seed)
+		if len(ollama.Stop) > 0 {
+			opts["stop"] = append([]string(nil), ollama.Stop...)
+		}
+		setOption(opts, "num_predict", ollama.NumPredict)
+		setOption(opts, "num_ctx", ollama.NumCtx)
+		setOption(opts, "mirostat", ollama.Mirostat)
+		setOption(opts, "mirostat_eta", ollama.MirostatEta)
+		setOption(opts, "mirostat_tau", ollama.MirostatTau)
+		setOption(opts, "repeat_last_n", ollama.RepeatLastN)
+		setOption(opts, "repeat_penalty", ollama.RepeatPenalty)
+		setOption(opts, "tfs_z", ollama.TfsZ)
+		setOption(opts, "num_gqa", ollama.NumGqa)
+		setOption(opts, "num_gpu", ollama.NumGpu)
+		setOption(opts, "num_thread", ollama.NumThread)
+	}
+	if chat != nil {
+		if chat.Temperature != nil {
+			opts["temperature"] = *chat.Temperature
+		}
+		if chat.TopP != nil {
+			opts["top_p"] = *chat.TopP
+		}
+		if chat.MaxResponseTokens != nil && *chat.MaxResponseTokens > 0 {
+			opts["num_predict"] = *chat.MaxResponseTokens
+		}
+		if len(chat.Stop) > 0 {
+			opts["stop"] = append([]string(nil), chat.Stop...)
+		}
+	}
+	if infCfg != nil {
+		if infCfg.Temperature != nil {
+			opts["temperature"] = *infCfg.Temperature
+		}
+		if infCfg.TopP != nil {
+			opts["top_p"] = *infCfg.TopP
+		}
+		if infCfg.MaxResponseTokens != nil && *infCfg.MaxResponseTokens > 0 {
+			opts["num_predict"] = *infCfg.MaxResponseTokens
+		}
+		if infCfg.Stop != nil {
+			opts["stop"] = append([]string(nil), infCfg.Stop...)
+		}
+		if infCfg.Seed != nil {
+			opts["seed"] = *infCfg.Seed
+		}
+	}
+	if len(opts) == 0 {
+		return nil
+	}
+	return opts
+}
+
+func setOption[T any](opts map[string]any, key string, v *T) {
+	if v != nil {
+		opts[key] = *v
+	}
+}
+
+// thinkFromInferenceConfig maps InferenceConfig onto Ollama's "think" field.
+// ReasoningEffort low/medium/high is passed through for models with graded
+// effort; ThinkingType enabled/disabled and a positive ThinkingBudget toggle
+// thinking on or off. Nil leaves the model default in place.
+func thinkFromInferenceConfig(cfg *infengine.InferenceConfig) any {
+	if cfg == nil {
+		return nil
+	}
+	if cfg.ReasoningEffort != nil {
+		switch effort := strings.ToLower(strings.TrimSpace(*cfg.ReasoningEffort)); effort {
+		case "low", "medium", "high":
+			return effort
+		case "none", "off":
+			return false
+		}
+	}
+	if cfg.ThinkingType != nil {
+		switch strings.ToLower(strings.TrimSpace(*cfg.ThinkingType)) {
+		case "enabled", "on", "true":
+			return true
+		case "disabled", "off", "false":
+			return false
+		}
+	}
+	if cfg.ThinkingBudget != nil && *cfg.ThinkingBudget > 0 {
+		return true
+	}
+	return nil
+}
+
+func buildChatMessagesFromTurn(t *turns.Turn) ([]ChatMessage, error) {
+	if t == nil {
+		return nil, nil
+	}
+	idToName := map[string]string{}
+	for _, b := range t.Blocks {
+		if b.Kind == turns.BlockKindToolCall {
+			id, _ := b.Payload[turns.PayloadKeyID].(string)
+			name, _ := b.Payload[turns.PayloadKeyName].(string)
+			if id != "" && name != "" {
+				idToName[id] = name
+			}
+		}
+	}
+
+	msgs := make([]ChatMessage, 0, len(t.Blocks))
+	pendingThinking := ""
+	// lastAssistant reports whether the previous message is an assistant
+	// message produced from the same model response, so text, reasoning and
+	// tool calls of one response collapse into a single Ollama message.
+	lastAssistant := func() *ChatMessage {
+		if len(msgs) == 0 || msgs[len(msgs)-1].Role != roleAssistant {
+			return nil
+		}
+		return &msgs[len(msgs)-1]
+	}
+	appendAssistant := func(msg ChatMessage) {
+		msg.Role = roleAssistant
+		if pendingThinking != "" {
+			msg.Thinking = pendingThinking
+			pendingThinking = ""
+		}
+		msgs = append(msgs, msg)
+	}
+	flushThinking := func() {
+		if pendingThinking != "" {
+			appendAssistant(ChatMessage{})
+		}
+	}
+
+	for _, b := range t.Blocks {
+		switch b.Kind {
+		case turns.BlockKindSystem:
+			flushThinking()
+			if text := blockText(b); text != "" {
+				msgs = append(msgs, ChatMessage{Role: roleSystem, Content: text})
+			}
+		case turns.BlockKindUser:
+			flushThinking()
+			images, err := imagesFromBlock(b)
+			if err != nil {
+				return nil, err
+			}
+			text := blockText(b)
+			if text == "" && len(images) == 0 {
+				continue
+			}
+			msgs = append(msgs, ChatMessage{Role: roleUser, Content: text, Images: images})
+		case turns.BlockKindReasoning:
+			text := blockText(b)
+			if text == "" {
+				continue
+			}
+			if pendingThinking != "" {
+				pendingThinking += "\n"
+			}
+			pendingThinking += text
+		case turns.BlockKindLLMText, turns.BlockKindOther:
+			text := blockText(b)
+			if text == "" {
+				continue
+			}
+			appendAssistant(ChatMessage{Content: text})
+		case turns.BlockKindToolCall:
+			id, _ := b.Payload[turns.PayloadKeyID].(string)
+			name, _ := b.Payload[turns.PayloadKeyName].(string)
+			call := ChatToolCall{
+				ID: id,
+				Function: ChatToolCallFunction{
+					Name:      name,
+					Arguments: toolCallArgsMap(b.Payload[turns.PayloadKeyArgs]),
+				},
+			}
+			if prev := lastAssistant(); prev != nil && pendingThinking == "" {
+				prev.ToolCalls = append(prev.ToolCalls, call)
+				continue
+			}
+			appendAssistant(ChatMessage{ToolCalls: []ChatToolCall{call}})
+		case turns.BlockKindToolUse:
+			flushThinking()
+			id, _ := b.Payload[turns.PayloadKeyID].(string)
+			msgs = append(msgs, ChatMessage{
+				Role:     roleTool,
+				Content:  toolUsePayloadToString(b.Payload),
+				ToolName: idToName[id],
+			})
+		}
+	}
+	flushThinking()
+	return msgs, nil
+}
+
+// imagesFromBlock returns base64-encoded image bytes. Ollama only accepts
+// inline image data, so remote URLs and provider file references are rejected.
+func imagesFromBlock(b turns.Block) ([]string, error) {
+	if b.Payload == nil {
+		return nil, nil
+	}
+	imgs, ok := b.Payload[turns.PayloadKeyImages].([]map[string]any)
+	if !ok || len(imgs) == 0 {
+		return nil, nil
+	}
+	out := make([]string, 0, len(imgs))
+	for _, img := range imgs {
+		part, ok, err := imageparts.NormalizeImageMap(img)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		switch {
+		case len(part.Data) > 0:
+			out = append(out, base64.StdEncoding.EncodeToString(part.Data))
+		case part.URL != "":
+			return nil, fmt.Errorf("ollama image url requires inline content or a data URL")
+		case part.FileID != "", part.FileURI != "":
+			return nil, fmt.Errorf("ollama does not support provider file references for images")
+		}
+	}
+	return out, nil
+}
+
+func blockText(b turns.Block) string {
+	if b.Payload == nil {
+		return ""
+	}
+	switch v := b.Payload[turns.PayloadKeyText].(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case nil:
+		return ""
+	default:
+		bb, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(bb)
+	}
+}
+
+func toolCallArgsMap(raw any) map[string]any {
+	switch v := raw.(type) {
+	case map[string]any:
+		return v
+	case string:
+		var obj map[string]any
+		if json.Unmarshal([]byte(v), &obj) == nil && obj != nil {
+			return obj
+		}
+	case json.RawMessage:
+		var obj map[string]any
+		if json.Unmarshal(v, &obj) == nil && obj != nil {
+			return obj
+		}
+	case nil:
+	default:
+		bb, err := json.Marshal(v)
+		if err == nil {
+			var obj map[string]any
+			if json.Unmarshal(bb, &obj) == nil && obj != nil {
+				return obj
+			}
+		}
+	}
+	return map[string]any{}
+}
+
+func toolUsePayloadToString(payload map[string]any) string {
+	if payload == nil {
+		return ""
+	}
+	result := payload[turns.PayloadKeyResult]
+	errStr, _ := payload[turns.PayloadKeyError].(string)
+	if errStr == "" {
+		return anyToString(result)
+	}
+	out := map[string]any{"error": errStr}
+	if result != nil {
+		out["result"] = result
+	}
+	bb, err := json.Marshal(out)
+	if err != nil {
+		return fmt.Sprintf(`{"error":%q}`, errStr)
+	}
+	return string(bb)
+}
+
+func anyToString(v any) string {
+	switch tv := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return tv
+	case []byte:
+		return string(tv)
+	default:
+		bb, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(bb)
+	}
+}
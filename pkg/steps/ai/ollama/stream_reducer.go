@@ -0,0 +1,163 @@
+package ollama
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/go-go-golems/geppetto/pkg/turns/toolblocks"
+	"github.com/google/uuid"
+)
+
+type ollamaPendingCall struct {
+	id, name string
+	args     map[string]any
+}
+
+type ollamaStreamState struct {
+	providerCallCorr events.Correlation
+
+	message            string
+	textSegmentStarted bool
+	textSequence       int64
+	textCorr           events.Correlation
+
+	reasoning         string
+	reasoningStarted  bool
+	reasoningSequence int64
+	reasoningCorr     events.Correlation
+
+	toolCallIndex int
+	pendingCalls  []ollamaPendingCall
+
+	finalStopReason string
+	finalUsage      *events.Usage
+	finalUsageExtra map[string]any
+}
+
+func newOllamaStreamState(providerCallCorr events.Correlation) *ollamaStreamState {
+	return &ollamaStreamState{providerCallCorr: providerCallCorr}
+}
+
+// reduceOllamaChatResponse folds one NDJSON frame into the stream state and
+// returns the canonical events it produces. Ollama sends content and thinking
+// as incremental deltas, tool calls as complete objects, and token counts only
+// on the final done frame.
+func reduceOllamaChatResponse(metadata events.EventMetadata, state *ollamaStreamState, resp *ChatResponse) []events.Event {
+	if state == nil || resp == nil {
+		return nil
+	}
+
+	var out []events.Event
+	if resp.Message.Thinking != "" {
+		out = append(out, reduceOllamaThinkingDelta(metadata, state, resp.Message.Thinking)...)
+	}
+	if resp.Message.Content != "" {
+		out = append(out, reduceOllamaTextDelta(metadata, state, resp.Message.Content)...)
+	}
+	for _, call := range resp.Message.ToolCalls {
+		out = append(out, reduceOllamaToolCall(metadata, state, call)...)
+	}
+
+	if resp.Done {
+		state.finalStopReason = strings.TrimSpace(resp.DoneReason)
+		if state.finalStopReason == "" {
+			state.finalStopReason = "stop"
+		}
+		if usage, extra, ok := extractOllamaUsage(resp); ok {
+			state.finalUsage = usage
+			state.finalUsageExtra = extra
+		}
+		out = append(out, events.NewProviderCallMetadataUpdatedEvent(metadata, state.providerCallCorr, state.finalStopReason, "", state.finalUsage))
+	}
+	return out
+}
+
+func reduceOllamaThinkingDelta(metadata events.EventMetadata, state *ollamaStreamState, delta string) []events.Event {
+	var out []events.Event
+	if !state.reasoningStarted {
+		state.reasoningStarted = true
+		state.reasoningCorr = ollamaSegmentCorrelation(state.providerCallCorr, "", 0, events.SegmentTypeReasoning)
+		out = append(out, events.NewReasoningSegmentStartedEvent(metadata, state.reasoningCorr, "provider"))
+	}
+	state.reasoning += delta
+	state.reasoningSequence++
+	out = append(out, events.NewReasoningDeltaEventWithSource(metadata, state.reasoningCorr, "provider", delta, state.reasoning, state.reasoningSequence))
+	return out
+}
+
+func reduceOllamaTextDelta(metadata events.EventMetadata, state *ollamaStreamState, delta string) []events.Event {
+	var out []events.Event
+	if !state.textSegmentStarted {
+		state.textSegmentStarted = true
+		state.textCorr = ollamaSegmentCorrelation(state.providerCallCorr, "", 0, events.SegmentTypeText)
+		out = append(out, events.NewTextSegmentStartedEvent(metadata, state.textCorr, "assistant"))
+	}
+	state.message += delta
+	state.textSequence++
+	out = append(out, events.NewTextDeltaEvent(metadata, state.textCorr, delta, state.message, state.textSequence))
+	return out
+}
+
+func reduceOllamaToolCall(metadata events.EventMetadata, state *ollamaStreamState, call ChatToolCall) []events.Event {
+	args := call.Function.Arguments
+	if args == nil {
+		args = map[string]any{}
+	}
+	id := strings.TrimSpace(call.ID)
+	if id == "" {
+		id = uuid.NewString()
+	}
+	name := call.Function.Name
+	state.pendingCalls = append(state.pendingCalls, ollamaPendingCall{id: id, name: name, args: args})
+	inputBytes, _ := json.Marshal(args)
+	toolCorr := ollamaToolCorrelation(state.providerCallCorr, id, state.toolCallIndex)
+	state.toolCallIndex++
+	return []events.Event{
+		events.NewToolCallStartedEvent(metadata, toolCorr, id, name),
+		events.NewToolCallRequestedEvent(metadata, toolCorr, id, name, string(inputBytes)),
+	}
+}
+
+func extractOllamaUsage(resp *ChatResponse) (*events.Usage, map[string]any, bool) {
+	if resp == nil || (resp.PromptEvalCount == 0 && resp.EvalCount == 0) {
+		return nil, nil, false
+	}
+	extra := map[string]any{}
+	if resp.TotalDuration > 0 {
+		extra["total_duration_ns"] = resp.TotalDuration
+	}
+	if resp.LoadDuration > 0 {
+		extra["load_duration_ns"] = resp.LoadDuration
+	}
+	if resp.PromptEvalDuration > 0 {
+		extra["prompt_eval_duration_ns"] = resp.PromptEvalDuration
+	}
+	if resp.EvalDuration > 0 {
+		extra["eval_duration_ns"] = resp.EvalDuration
+	}
+	return &events.Usage{
+		InputTokens:  resp.PromptEvalCount,
+		OutputTokens: resp.EvalCount,
+	}, extra, true
+}
+
+func appendOllamaStateBlocks(t *turns.Turn, state *ollamaStreamState) {
+	if t == nil || state == nil {
+		return
+	}
+	if state.reasoning != "" {
+		turns.AppendBlock(t, turns.Block{
+			Kind:    turns.BlockKindReasoning,
+			Role:    turns.RoleAssistant,
+			Payload: map[string]any{turns.PayloadKeyText: state.reasoning},
+		})
+	}
+	if state.message != "" {
+		turns.AppendBlock(t, turns.NewAssistantTextBlock(state.message))
+	}
+	for i, call := range state.pendingCalls {
+		turns.AppendBlock(t, toolblocks.NewToolCallBlockWithCorrelation(call.id, call.name, call.args, ollamaToolCorrelation(state.providerCallCorr, call.id, i)))
+	}
+}
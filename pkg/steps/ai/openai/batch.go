@@ -0,0 +1,459 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/inference/batch"
+	"github.com/go-go-golems/geppetto/pkg/security"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	batchEndpointChatCompletions = "/v1/chat/completions"
+	batchCompletionWindow        = "24h"
+)
+
+// BatchClient runs Turns through the OpenAI Batch API against the chat
+// completions endpoint. Requests are built like OpenAIEngine.RunInference
+// builds them, minus streaming, and responses are replayed through the chat
+// stream reducer so the resulting blocks match a streamed inference.
+type BatchClient struct {
+	engine *OpenAIEngine
+}
+
+var _ batch.Client = (*BatchClient)(nil)
+
+// NewBatchClient creates a Batch API client. Engine options configure
+// credentials the same way as for NewOpenAIEngine.
+func NewBatchClient(s *settings.InferenceSettings, opts ...EngineOption) (*BatchClient, error) {
+	e, err := NewOpenAIEngine(s, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &BatchClient{engine: e}, nil
+}
+
+// openAIBatch is the Batch API object.
+type openAIBatch struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	OutputFileID  string `json:"output_file_id,omitempty"`
+	ErrorFileID   string `json:"error_file_id,omitempty"`
+	RequestCounts struct {
+		Total     int `json:"total"`
+		Completed int `json:"completed"`
+		Failed    int `json:"failed"`
+	} `json:"request_counts"`
+	Errors *struct {
+		Data []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+			Line    *int   `json:"line,omitempty"`
+		} `json:"data"`
+	} `json:"errors,omitempty"`
+}
+
+type openAIBatchInputLine struct {
+	CustomID string                 `json:"custom_id"`
+	Method   string                 `json:"method"`
+	URL      string                 `json:"url"`
+	Body     *ChatCompletionRequest `json:"body"`
+}
+
+type openAIBatchOutputLine struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int            `json:"status_code"`
+		Body       map[string]any `json:"body"`
+	} `json:"response"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// batchHTTP carries the resolved endpoint and credentials of one call.
+type batchHTTP struct {
+	cfg      chatStreamConfig
+	outbound security.OutboundURLOptions
+}
+
+func (c *BatchClient) resolveHTTP(ctx context.Context) (*batchHTTP, error) {
+	s := c.engine.settings
+	if s.Chat == nil || s.Chat.ApiType == nil {
+		return nil, errors.New("no chat engine specified")
+	}
+	cfg, err := resolveChatStreamConfig(ctx, s.API, s.Client, *s.Chat.ApiType, chatModel(s.Chat), c.engine.bearerTokenSource)
+	if err != nil {
+		return nil, err
+	}
+	return &batchHTTP{cfg: cfg, outbound: settings.OutboundURLOptions(s.API, string(*s.Chat.ApiType))}, nil
+}
+
+// Submit uploads the requests as a JSONL file and creates the batch. Tools
+// advertised in ctx are attached to every request.
+func (c *BatchClient) Submit(ctx context.Context, reqs []batch.Request) (*batch.Job, error) {
+	if err := batch.ValidateRequests(reqs); err != nil {
+		return nil, err
+	}
+	h, err := c.resolveHTTP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var input bytes.Buffer
+	enc := json.NewEncoder(&input)
+	for _, r := range reqs {
+		req, err := c.engine.buildChatRequest(ctx, r.Turn)
+		if err != nil {
+			return nil, errors.Wrapf(err, "build request %q", r.CustomID)
+		}
+		req.Stream = false
+		req.StreamOptions = nil
+		line := openAIBatchInputLine{CustomID: r.CustomID, Method: http.MethodPost, URL: batchEndpointChatCompletions, Body: req}
+		if err := enc.Encode(line); err != nil {
+			return nil, errors.Wrapf(err, "encode request %q", r.CustomID)
+		}
+	}
+	fileID, err := h.uploadBatchFile(ctx, input.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(map[string]any{
+		"input_file_id":     fileID,
+		"endpoint":          batchEndpointChatCompletions,
+		"completion_window": batchCompletionWindow,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal batch request")
+	}
+	var b openAIBatch
+	if err := h.doJSON(ctx, http.MethodPost, "/batches", body, &b); err != nil {
+		return nil, err
+	}
+	return c.job(&b), nil
+}
+
+// Get returns the current state of the batch.
+func (c *BatchClient) Get(ctx context.Context, id string) (*batch.Job, error) {
+	b, err := c.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return c.job(b), nil
+}
+
+// Cancel asks OpenAI to stop the batch.
+func (c *BatchClient) Cancel(ctx context.Context, id string) (*batch.Job, error) {
+	h, err := c.resolveHTTP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var b openAIBatch
+	if err := h.doJSON(ctx, http.MethodPost, "/batches/"+id+"/cancel", nil, &b); err != nil {
+		return nil, err
+	}
+	return c.job(&b), nil
+}
+
+// Results downloads the output and error files of a finished batch and
+// appends each completion to a clone of its request Turn.
+func (c *BatchClient) Results(ctx context.Context, id string, reqs []batch.Request) ([]batch.Result, error) {
+	b, err := c.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	job := c.job(b)
+	if !job.Status.Done() {
+		return nil, batch.ErrNotFinished
+	}
+	if job.Status == batch.StatusFailed {
+		return nil, errors.Errorf("openai batch %s failed: %s", id, job.Error)
+	}
+	h, err := c.resolveHTTP(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	requestTurns := batch.RequestTurns(reqs)
+	byID := make(map[string]batch.Result, len(reqs))
+	for _, fileID := range []string{b.OutputFileID, b.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+		lines, err := h.downloadBatchOutput(ctx, fileID)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range lines {
+			t, ok := requestTurns[line.CustomID]
+			if !ok {
+				log.Warn().Str("batch_id", id).Str("custom_id", line.CustomID).Msg("OpenAI batch: result for unknown request")
+				continue
+			}
+			byID[line.CustomID] = c.engine.batchResult(t, line)
+		}
+	}
+	return batch.Assemble(reqs, byID), nil
+}
+
+func (c *BatchClient) get(ctx context.Context, id string) (*openAIBatch, error) {
+	h, err := c.resolveHTTP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var b openAIBatch
+	if err := h.doJSON(ctx, http.MethodGet, "/batches/"+id, nil, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (c *BatchClient) job(b *openAIBatch) *batch.Job {
+	job := &batch.Job{
+		ID:             b.ID,
+		Provider:       c.engine.inferenceProvider(),
+		ProviderStatus: b.Status,
+		Counts: batch.Counts{
+			Total:     b.RequestCounts.Total,
+			Succeeded: b.RequestCounts.Completed,
+			Failed:    b.RequestCounts.Failed,
+		},
+	}
+	switch b.Status {
+	case "completed":
+		job.Status = batch.StatusCompleted
+	case "failed":
+		job.Status = batch.StatusFailed
+	case "cancelled":
+		job.Status = batch.StatusCancelled
+	case "expired":
+		job.Status = batch.StatusExpired
+	default:
+		// validating, in_progress, finalizing, cancelling
+		job.Status = batch.StatusInProgress
+	}
+	if b.Errors != nil {
+		msgs := make([]string, 0, len(b.Errors.Data))
+		for _, e := range b.Errors.Data {
+			msg := e.Code + ": " + e.Message
+			if e.Line != nil {
+				msg = fmt.Sprintf("line %d: %s", *e.Line, msg)
+			}
+			msgs = append(msgs, msg)
+		}
+		job.Error = strings.Join(msgs, "; ")
+	}
+	return job
+}
+
+// batchResult converts one output line into a Result for a clone of t.
+func (e *OpenAIEngine) batchResult(t *turns.Turn, line openAIBatchOutputLine) batch.Result {
+	res := batch.Result{CustomID: line.CustomID}
+	switch {
+	case line.Error != nil:
+		res.Err = errors.Errorf("openai batch: request failed: %s: %s", line.Error.Code, line.Error.Message)
+	case line.Response == nil:
+		res.Err = errors.New("openai batch: result without response")
+	case line.Response.StatusCode < 200 || line.Response.StatusCode >= 300:
+		msg, _ := stringValue(mapValue(line.Response.Body["error"])["message"])
+		res.Err = errors.Errorf("openai batch: request failed: status=%d %s", line.Response.StatusCode, msg)
+	default:
+		res.Turn = t.Clone()
+		e.appendChatCompletion(res.Turn, line.Response.Body)
+	}
+	return res
+}
+
+// appendChatCompletion appends the blocks of a non-streamed chat completion
+// to t by feeding it to the stream reducer as a single chunk.
+func (e *OpenAIEngine) appendChatCompletion(t *turns.Turn, body map[string]any) {
+	model, _ := stringValue(body["model"])
+	metadata := events.EventMetadata{
+		ID:               uuid.New(),
+		LLMInferenceData: events.LLMInferenceData{Model: model},
+		TurnID:           t.ID,
+	}
+	corr := events.BuildProviderCallCorrelation(e.inferenceProvider(), metadata.ID.String(), "", 0, "")
+	corr.TurnID = t.ID
+	state := newOpenAIChatStreamState(metadata, e.inferenceProvider(), model, corr, 0)
+	state, _ = reduceOpenAIChatStream(state, openAIChatStreamInput{
+		Kind:  openAIChatStreamInputChunk,
+		Chunk: chatStreamEventFromCompletion(body),
+	})
+	terminal := openAIChatTerminal{Kind: openAIChatTerminalEOF}
+	state = state.withTerminalStopReason(terminal)
+	metadata = finalizeOpenAIChatMetadata(metadata, state, time.Now())
+	// Batch requests have no meaningful wall-clock duration.
+	metadata.DurationMs = nil
+	state.Metadata = metadata
+	state, _ = reduceOpenAIChatStream(state, openAIChatStreamInput{Kind: openAIChatStreamInputTerminal, Terminal: terminal})
+
+	toolCallCount := appendOpenAIChatTurnBlocks(t, state, true)
+	e.persistInferenceResult(t, metadata, toolCallCount > 0)
+}
+
+// chatStreamEventFromCompletion reads a chat completion body as one stream
+// chunk whose delta is the complete first-choice message.
+func chatStreamEventFromCompletion(body map[string]any) chatStreamEvent {
+	choice := firstChoice(body)
+	delta := map[string]any{}
+	for k, v := range mapValue(choice["message"]) {
+		delta[k] = v
+	}
+	// Streamed tool calls carry an index; complete messages list them in order.
+	if calls, ok := delta["tool_calls"].([]any); ok {
+		indexed := make([]any, 0, len(calls))
+		for i, call := range calls {
+			m := map[string]any{}
+			for k, v := range mapValue(call) {
+				m[k] = v
+			}
+			if _, ok := m["index"]; !ok {
+				m["index"] = i
+			}
+			indexed = append(indexed, m)
+		}
+		delta["tool_calls"] = indexed
+	}
+	chunk := map[string]any{
+		"choices": []any{map[string]any{
+			"index":         choice["index"],
+			"delta":         delta,
+			"finish_reason": choice["finish_reason"],
+		}},
+	}
+	for _, k := range []string{"id", "model", "usage"} {
+		if v, ok := body[k]; ok {
+			chunk[k] = v
+		}
+	}
+	return normalizeChatStreamEvent(chunk)
+}
+
+func (h *batchHTTP) url(path string) (string, error) {
+	u := strings.TrimRight(h.cfg.baseURL, "/") + path
+	if err := security.ValidateOutboundURL(u, h.outbound); err != nil {
+		return "", errors.Wrap(err, "invalid batch URL")
+	}
+	return u, nil
+}
+
+func (h *batchHTTP) send(ctx context.Context, method, path, contentType string, body []byte) (*http.Response, error) {
+	u, err := h.url(path)
+	if err != nil {
+		return nil, err
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "create batch request")
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if strings.TrimSpace(h.cfg.apiKey) != "" {
+		req.Header.Set("Authorization", "Bearer "+h.cfg.apiKey)
+	}
+	// #nosec G704 -- URL is validated above with ValidateOutboundURL.
+	resp, err := h.cfg.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		raw, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("openai batch API error: %s %s: status=%d body=%s", method, path, resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	return resp, nil
+}
+
+func (h *batchHTTP) doJSON(ctx context.Context, method, path string, body []byte, out any) error {
+	contentType := ""
+	if body != nil {
+		contentType = "application/json"
+	}
+	resp, err := h.send(ctx, method, path, contentType, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return errors.Wrapf(err, "decode %s response", path)
+	}
+	return nil
+}
+
+func (h *batchHTTP) uploadBatchFile(ctx context.Context, content []byte) (string, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	if err := w.WriteField("purpose", "batch"); err != nil {
+		return "", errors.Wrap(err, "write purpose field")
+	}
+	part, err := w.CreateFormFile("file", "batch.jsonl")
+	if err != nil {
+		return "", errors.Wrap(err, "create file field")
+	}
+	if _, err := part.Write(content); err != nil {
+		return "", errors.Wrap(err, "write batch file")
+	}
+	if err := w.Close(); err != nil {
+		return "", errors.Wrap(err, "close multipart body")
+	}
+	resp, err := h.send(ctx, http.MethodPost, "/files", w.FormDataContentType(), body.Bytes())
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var file struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&file); err != nil {
+		return "", errors.Wrap(err, "decode file upload response")
+	}
+	if file.ID == "" {
+		return "", errors.New("file upload response has no id")
+	}
+	return file.ID, nil
+}
+
+func (h *batchHTTP) downloadBatchOutput(ctx context.Context, fileID string) ([]openAIBatchOutputLine, error) {
+	resp, err := h.send(ctx, http.MethodGet, "/files/"+fileID+"/content", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var ret []openAIBatchOutputLine
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		var line openAIBatchOutputLine
+		if err := json.Unmarshal(raw, &line); err != nil {
+			return nil, errors.Wrapf(err, "decode batch output of file %s", fileID)
+		}
+		ret = append(ret, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "read batch output of file %s", fileID)
+	}
+	return ret, nil
+}
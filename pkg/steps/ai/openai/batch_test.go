@@ -0,0 +1,138 @@
+package openai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/batch"
+	aisettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	aisettingsopenai "github.com/go-go-golems/geppetto/pkg/steps/ai/settings/openai"
+	ai_types "github.com/go-go-golems/geppetto/pkg/steps/ai/types"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+)
+
+func TestBatchClientRoundTrip(t *testing.T) {
+	var uploaded []openAIBatchInputLine
+	polls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/files", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("purpose") != "batch" {
+			t.Errorf("purpose = %q", r.FormValue("purpose"))
+		}
+		f, _, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("form file: %v", err)
+			return
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var line openAIBatchInputLine
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				t.Errorf("decode input line: %v", err)
+			}
+			uploaded = append(uploaded, line)
+		}
+		_, _ = io.WriteString(w, `{"id":"file-in"}`)
+	})
+	mux.HandleFunc("POST /v1/batches", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["input_file_id"] != "file-in" || body["endpoint"] != "/v1/chat/completions" {
+			t.Errorf("create body = %v", body)
+		}
+		_, _ = io.WriteString(w, `{"id":"batch_1","status":"validating","request_counts":{"total":0,"completed":0,"failed":0}}`)
+	})
+	mux.HandleFunc("GET /v1/batches/batch_1", func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		if polls < 2 {
+			_, _ = io.WriteString(w, `{"id":"batch_1","status":"in_progress","request_counts":{"total":3,"completed":1,"failed":0}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"id":"batch_1","status":"completed","output_file_id":"file-out","error_file_id":"file-err","request_counts":{"total":3,"completed":2,"failed":1}}`)
+	})
+	mux.HandleFunc("GET /v1/files/file-out/content", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, strings.Join([]string{
+			`{"id":"r1","custom_id":"greet","response":{"status_code":200,"body":{"id":"chatcmpl-1","model":"gpt-4o-mini","choices":[{"index":0,"message":{"role":"assistant","content":"Hello!"},"finish_reason":"stop"}],"usage":{"prompt_tokens":7,"completion_tokens":2}}},"error":null}`,
+			`{"id":"r2","custom_id":"lookup","response":{"status_code":200,"body":{"id":"chatcmpl-2","model":"gpt-4o-mini","choices":[{"index":0,"message":{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"search","arguments":"{\"q\":\"go\"}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":9,"completion_tokens":5}}},"error":null}`,
+		}, "\n")+"\n")
+	})
+	mux.HandleFunc("GET /v1/files/file-err/content", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"id":"r3","custom_id":"broken","response":{"status_code":400,"body":{"error":{"message":"bad request"}}},"error":null}`+"\n")
+	})
+
+	model := "gpt-4o-mini"
+	apiType := ai_types.ApiTypeOpenAI
+	client, err := NewBatchClient(&aisettings.InferenceSettings{
+		API: &aisettings.APISettings{
+			APIKeys:  map[string]string{"openai-api-key": "test"},
+			BaseUrls: map[string]string{"openai-base-url": "https://example.test/v1"},
+		},
+		Client: &aisettings.ClientSettings{HTTPClient: &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			if r.Header.Get("Authorization") != "Bearer test" {
+				t.Errorf("authorization = %q", r.Header.Get("Authorization"))
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, r)
+			return rec.Result(), nil
+		})}},
+		OpenAI: &aisettingsopenai.Settings{},
+		Chat:   &aisettings.ChatSettings{ApiType: &apiType, Engine: &model},
+	})
+	if err != nil {
+		t.Fatalf("NewBatchClient: %v", err)
+	}
+
+	reqs := []batch.Request{
+		{CustomID: "greet", Turn: &turns.Turn{ID: "t1", Blocks: []turns.Block{turns.NewUserTextBlock("Say hello")}}},
+		{CustomID: "lookup", Turn: &turns.Turn{ID: "t2", Blocks: []turns.Block{turns.NewUserTextBlock("Search for go")}}},
+		{CustomID: "broken", Turn: &turns.Turn{ID: "t3", Blocks: []turns.Block{turns.NewUserTextBlock("???")}}},
+	}
+	results, job, err := batch.Run(context.Background(), client, reqs, batch.WithPollInterval(time.Millisecond))
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if job.Status != batch.StatusCompleted || job.Counts.Failed != 1 {
+		t.Fatalf("job = %#v", job)
+	}
+
+	if len(uploaded) != 3 || uploaded[0].CustomID != "greet" || uploaded[0].URL != "/v1/chat/completions" || uploaded[0].Body.Stream || uploaded[0].Body.Model != model {
+		t.Fatalf("uploaded = %#v", uploaded)
+	}
+
+	greet := results[0].Turn
+	if results[0].Err != nil || greet == nil || len(greet.Blocks) != 2 {
+		t.Fatalf("greet result = %#v", results[0])
+	}
+	if greet.Blocks[1].Kind != turns.BlockKindLLMText || greet.Blocks[1].Payload[turns.PayloadKeyText] != "Hello!" {
+		t.Fatalf("greet reply = %#v", greet.Blocks[1])
+	}
+	res, ok, err := turns.KeyTurnMetaInferenceResult.Get(greet.Metadata)
+	if err != nil || !ok {
+		t.Fatalf("inference result ok=%v err=%v", ok, err)
+	}
+	if res.StopReason != "stop" || res.Usage == nil || res.Usage.InputTokens != 7 || res.Usage.OutputTokens != 2 {
+		t.Fatalf("inference result = %#v", res)
+	}
+
+	lookup := results[1].Turn
+	if results[1].Err != nil || lookup == nil || len(lookup.Blocks) != 2 || lookup.Blocks[1].Kind != turns.BlockKindToolCall {
+		t.Fatalf("lookup result = %#v", results[1])
+	}
+	if lookup.Blocks[1].Payload[turns.PayloadKeyID] != "call_1" || lookup.Blocks[1].Payload[turns.PayloadKeyName] != "search" {
+		t.Fatalf("tool call block = %#v", lookup.Blocks[1])
+	}
+
+	if results[2].Err == nil || !strings.Contains(results[2].Err.Error(), "bad request") {
+		t.Fatalf("broken result = %#v", results[2])
+	}
+	if len(reqs[0].Turn.Blocks) != 1 {
+		t.Fatalf("request turn was mutated")
+	}
+}
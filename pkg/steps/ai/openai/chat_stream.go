@@ -11,8 +11,11 @@ import (
 	"net/url"
 	"strings"
 
+	"github.com/go-go-golems/geppetto/pkg/inference/engine"
 	"github.com/go-go-golems/geppetto/pkg/security"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/credentials"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/providerhttp"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/ratelimit"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
 	ai_types "github.com/go-go-golems/geppetto/pkg/steps/ai/types"
 	"github.com/pkg/errors"
@@ -59,6 +62,7 @@ func resolveChatStreamConfig(
 	apiSettings *settings.APISettings,
 	clientSettings *settings.ClientSettings,
 	apiType ai_types.ApiType,
+	model string,
 	bearerTokenSource credentials.BearerTokenSource,
 ) (chatStreamConfig, error) {
 	baseURL, ok := apiSettings.BaseUrls[string(apiType)+"-base-url"]
@@ -69,12 +73,12 @@ func resolveChatStreamConfig(
 	if err := security.ValidateOutboundURL(endpoint, settings.OutboundURLOptions(apiSettings, string(apiType))); err != nil {
 		return chatStreamConfig{}, errors.Wrap(err, "invalid chat completion URL")
 	}
-	httpClient, err := settings.EnsureHTTPClient(clientSettings)
+	credentialRequest := credentials.Request{Provider: string(apiType), BaseURL: baseURL}
+	apiKey, err := resolveBearerToken(ctx, apiSettings, apiType, baseURL, bearerTokenSource)
 	if err != nil {
 		return chatStreamConfig{}, err
 	}
-	credentialRequest := credentials.Request{Provider: string(apiType), BaseURL: baseURL}
-	apiKey, err := resolveBearerToken(ctx, apiSettings, apiType, baseURL, bearerTokenSource)
+	httpClient, err := providerhttp.Client(clientSettings, ratelimit.NewKey(string(apiType), model, apiKey))
 	if err != nil {
 		return chatStreamConfig{}, err
 	}
@@ -179,10 +183,11 @@ func chatCompletionHTTPError(resp *http.Response, cfg chatStreamConfig) error {
 			Str("endpoint", cfg.endpoint).
 			Msg("OpenAI-compatible chat completions request failed with suspicious base URL")
 	}
+	msg := fmt.Sprintf("chat completions error: status=%d body=%s%s", resp.StatusCode, strings.TrimSpace(string(raw)), hint)
 	if len(raw) == 0 {
-		return fmt.Errorf("chat completions error: status=%d%s", resp.StatusCode, hint)
+		msg = fmt.Sprintf("chat completions error: status=%d%s", resp.StatusCode, hint)
 	}
-	return fmt.Errorf("chat completions error: status=%d body=%s%s", resp.StatusCode, strings.TrimSpace(string(raw)), hint)
+	return &engine.StatusError{StatusCode: resp.StatusCode, Message: msg}
 }
 
 func chatCompletionEndpointHint(statusCode int, cfg chatStreamConfig) string {
@@ -429,3 +434,11 @@ func intValue(v any) (int, bool) {
 		return 0, false
 	}
 }
+
+// chatModel returns the configured chat model, or "" when none is set.
+func chatModel(chat *settings.ChatSettings) string {
+	if chat == nil || chat.Engine == nil {
+		return ""
+	}
+	return *chat.Engine
+}
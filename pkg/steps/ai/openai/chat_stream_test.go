@@ -327,7 +327,7 @@ func TestResolveChatStreamConfigRejectsLocalHTTPByDefault(t *testing.T) {
 	apiSettings.APIKeys["openai-api-key"] = "test-key"
 	apiSettings.BaseUrls["openai-base-url"] = "http://127.0.0.1:9999/v1"
 
-	_, err := resolveChatStreamConfig(context.Background(), apiSettings, nil, ai_types.ApiTypeOpenAI, nil)
+	_, err := resolveChatStreamConfig(context.Background(), apiSettings, nil, ai_types.ApiTypeOpenAI, "", nil)
 	if err == nil {
 		t.Fatal("expected local HTTP base URL to be rejected by default")
 	}
@@ -343,7 +343,7 @@ func TestResolveChatStreamConfigAllowsLocalHTTPWhenProfileOptsIn(t *testing.T) {
 	apiSettings.AllowHTTP["openai"] = true
 	apiSettings.AllowLocalNetworks["openai"] = true
 
-	cfg, err := resolveChatStreamConfig(context.Background(), apiSettings, nil, ai_types.ApiTypeOpenAI, nil)
+	cfg, err := resolveChatStreamConfig(context.Background(), apiSettings, nil, ai_types.ApiTypeOpenAI, "", nil)
 	if err != nil {
 		t.Fatalf("resolveChatStreamConfig: %v", err)
 	}
@@ -6,6 +6,8 @@ const (
 	chatToolTypeFunction             = "function"
 	chatMessagePartTypeText          = "text"
 	chatMessagePartTypeImageURL      = "image_url"
+	chatMessagePartTypeFile          = "file"
+	chatMessagePartTypeInputAudio    = "input_audio"
 	chatImageURLDetailAuto           = "auto"
 	chatResponseFormatTypeJSONSchema = "json_schema"
 )
@@ -70,9 +72,25 @@ func (m ChatCompletionMessage) MarshalJSON() ([]byte, error) {
 }
 
 type ChatMessagePart struct {
-	Type     string               `json:"type"`
-	Text     string               `json:"text,omitempty"`
-	ImageURL *ChatMessageImageURL `json:"image_url,omitempty"`
+	Type       string                 `json:"type"`
+	Text       string                 `json:"text,omitempty"`
+	ImageURL   *ChatMessageImageURL   `json:"image_url,omitempty"`
+	File       *ChatMessageFile       `json:"file,omitempty"`
+	InputAudio *ChatMessageInputAudio `json:"input_audio,omitempty"`
+}
+
+// ChatMessageFile is either an uploaded file ID or inline file data as a
+// base64 data URL with a filename.
+type ChatMessageFile struct {
+	FileID   string `json:"file_id,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	FileData string `json:"file_data,omitempty"`
+}
+
+// ChatMessageInputAudio carries base64 audio; Format is "wav" or "mp3".
+type ChatMessageInputAudio struct {
+	Data   string `json:"data"`
+	Format string `json:"format"`
 }
 
 type ChatMessageImageURL struct {
@@ -19,6 +19,8 @@ import (
 
 	geppettoobs "github.com/go-go-golems/geppetto/pkg/observability"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/credentials"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/ratelimit"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/retry"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/runtimeattrib"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
 	"github.com/pkg/errors"
@@ -66,12 +68,12 @@ func (e *OpenAIEngine) RunInference(
 
 	// Chat engine no longer routes to Responses; factory selects the correct engine
 
-	streamCfg, err := resolveChatStreamConfig(ctx, e.settings.API, e.settings.Client, *e.settings.Chat.ApiType, e.bearerTokenSource)
+	streamCfg, err := resolveChatStreamConfig(ctx, e.settings.API, e.settings.Client, *e.settings.Chat.ApiType, chatModel(e.settings.Chat), e.bearerTokenSource)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := e.MakeCompletionRequestFromTurn(t)
+	req, err := e.buildChatRequest(ctx, t)
 	if err != nil {
 		return nil, err
 	}
@@ -83,6 +85,102 @@ func (e *OpenAIEngine) RunInference(
 		req.StreamOptions = &ChatStreamOptions{IncludeUsage: true}
 	}
 
+	// Setup metadata and event publishing
+	metadata := events.EventMetadata{
+		ID: uuid.New(),
+		LLMInferenceData: events.LLMInferenceData{
+			Model:       req.Model,
+			Usage:       nil,
+			StopReason:  nil,
+			Temperature: e.settings.Chat.Temperature,
+			TopP:        e.settings.Chat.TopP,
+			MaxTokens:   e.settings.Chat.MaxResponseTokens,
+		},
+	}
+	log.Debug().
+		Str("event_id", metadata.ID.String()).
+		Str("model", metadata.Model).
+		Interface("temperature", metadata.Temperature).
+		Interface("top_p", metadata.TopP).
+		Interface("max_tokens", metadata.MaxTokens).
+		Msg("LLMInferenceData initialized")
+	// Propagate Turn correlation identifiers when present
+	if t != nil {
+		if sid, ok, err := turns.KeyTurnMetaSessionID.Get(t.Metadata); err == nil && ok {
+			metadata.SessionID = sid
+		}
+		if iid, ok, err := turns.KeyTurnMetaInferenceID.Get(t.Metadata); err == nil && ok {
+			metadata.InferenceID = iid
+		}
+		metadata.TurnID = t.ID
+	}
+	// Step metadata removed; settings metadata moved to EventMetadata.Extra
+	if metadata.Extra == nil {
+		metadata.Extra = map[string]interface{}{}
+	}
+	metadata.Extra[events.MetadataSettingsSlug] = e.settings.GetMetadata()
+	runtimeattrib.AddRuntimeAttributionToExtra(metadata.Extra, t)
+
+	// Publish provider-call start event.
+	log.Debug().Str("event_id", metadata.ID.String()).Msg("OpenAI publishing provider call start event")
+	inferenceScopeID := metadata.InferenceID
+	if inferenceScopeID == "" {
+		inferenceScopeID = metadata.ID.String()
+	}
+	providerCallIndex := 0
+	if idx, ok := gepsession.ProviderCallIndexFromContext(ctx); ok {
+		providerCallIndex = idx
+	}
+	providerCallCorr := events.BuildProviderCallCorrelation(e.inferenceProvider(), inferenceScopeID, "", providerCallIndex, "")
+	providerCallCorr.SessionID = metadata.SessionID
+	providerCallCorr.TurnID = metadata.TurnID
+	e.publishEvent(ctx, events.NewProviderCallStartedEvent(metadata, providerCallCorr))
+
+	// Always use streaming mode
+	log.Debug().Msg("OpenAI using streaming mode")
+	streamCtx := ratelimit.WithTokenEstimate(ctx, ratelimit.EstimateTurnTokens(t))
+	streamCtx = retry.WithEventReporter(streamCtx, metadata, providerCallCorr, e.publishEvent)
+	stream, err := openChatCompletionStream(streamCtx, streamCfg, req)
+	if err != nil {
+		log.Error().Err(err).Msg("OpenAI streaming request failed")
+		// set duration up to error
+		d := time.Since(startTime).Milliseconds()
+		dm := int64(d)
+		metadata.DurationMs = &dm
+		e.publishEvent(ctx, events.NewErrorEvent(metadata, err))
+		return nil, err
+	}
+	defer func() {
+		if err := stream.Close(); err != nil {
+			stdlog.Printf("Failed to close stream: %v", err)
+		}
+	}()
+
+	state := newOpenAIChatStreamState(metadata, e.inferenceProvider(), req.Model, providerCallCorr, providerCallIndex)
+	state, terminal, runErr := e.consumeOpenAIChatStream(ctx, stream, state, metadata, req.Model)
+	state, metadata = e.completeOpenAIChatStream(ctx, t, state, metadata, req.Model, startTime, terminal)
+
+	log.Debug().
+		Str("event_id", metadata.ID.String()).
+		Str("model", metadata.Model).
+		Interface("temperature", metadata.Temperature).
+		Interface("top_p", metadata.TopP).
+		Interface("max_tokens", metadata.MaxTokens).
+		Interface("usage", metadata.Usage).
+		Str("stop_reason", stopReasonString(state.StopReason)).
+		Msg("OpenAI publishing final event (streaming)")
+
+	log.Debug().Err(runErr).Msg("OpenAI RunInference completed (streaming)")
+	return t, runErr
+}
+
+// buildChatRequest builds the chat completion request for t, including the
+// tools advertised in ctx.
+func (e *OpenAIEngine) buildChatRequest(ctx context.Context, t *turns.Turn) (*ChatCompletionRequest, error) {
+	req, err := e.MakeCompletionRequestFromTurn(t)
+	if err != nil {
+		return nil, err
+	}
 	// Debug: confirm adjacency constraints before sending
 	if req != nil {
 		// Check that any assistant message with tool_calls is followed by tool messages
@@ -181,91 +279,7 @@ func (e *OpenAIEngine) RunInference(
 			Msg("Tools added to OpenAI request")
 	}
 
-	// Setup metadata and event publishing
-	metadata := events.EventMetadata{
-		ID: uuid.New(),
-		LLMInferenceData: events.LLMInferenceData{
-			Model:       req.Model,
-			Usage:       nil,
-			StopReason:  nil,
-			Temperature: e.settings.Chat.Temperature,
-			TopP:        e.settings.Chat.TopP,
-			MaxTokens:   e.settings.Chat.MaxResponseTokens,
-		},
-	}
-	log.Debug().
-		Str("event_id", metadata.ID.String()).
-		Str("model", metadata.Model).
-		Interface("temperature", metadata.Temperature).
-		Interface("top_p", metadata.TopP).
-		Interface("max_tokens", metadata.MaxTokens).
-		Msg("LLMInferenceData initialized")
-	// Propagate Turn correlation identifiers when present
-	if t != nil {
-		if sid, ok, err := turns.KeyTurnMetaSessionID.Get(t.Metadata); err == nil && ok {
-			metadata.SessionID = sid
-		}
-		if iid, ok, err := turns.KeyTurnMetaInferenceID.Get(t.Metadata); err == nil && ok {
-			metadata.InferenceID = iid
-		}
-		metadata.TurnID = t.ID
-	}
-	// Step metadata removed; settings metadata moved to EventMetadata.Extra
-	if metadata.Extra == nil {
-		metadata.Extra = map[string]interface{}{}
-	}
-	metadata.Extra[events.MetadataSettingsSlug] = e.settings.GetMetadata()
-	runtimeattrib.AddRuntimeAttributionToExtra(metadata.Extra, t)
-
-	// Publish provider-call start event.
-	log.Debug().Str("event_id", metadata.ID.String()).Msg("OpenAI publishing provider call start event")
-	inferenceScopeID := metadata.InferenceID
-	if inferenceScopeID == "" {
-		inferenceScopeID = metadata.ID.String()
-	}
-	providerCallIndex := 0
-	if idx, ok := gepsession.ProviderCallIndexFromContext(ctx); ok {
-		providerCallIndex = idx
-	}
-	providerCallCorr := events.BuildProviderCallCorrelation(e.inferenceProvider(), inferenceScopeID, "", providerCallIndex, "")
-	providerCallCorr.SessionID = metadata.SessionID
-	providerCallCorr.TurnID = metadata.TurnID
-	e.publishEvent(ctx, events.NewProviderCallStartedEvent(metadata, providerCallCorr))
-
-	// Always use streaming mode
-	log.Debug().Msg("OpenAI using streaming mode")
-	stream, err := openChatCompletionStream(ctx, streamCfg, req)
-	if err != nil {
-		log.Error().Err(err).Msg("OpenAI streaming request failed")
-		// set duration up to error
-		d := time.Since(startTime).Milliseconds()
-		dm := int64(d)
-		metadata.DurationMs = &dm
-		e.publishEvent(ctx, events.NewErrorEvent(metadata, err))
-		return nil, err
-	}
-	defer func() {
-		if err := stream.Close(); err != nil {
-			stdlog.Printf("Failed to close stream: %v", err)
-		}
-	}()
-
-	state := newOpenAIChatStreamState(metadata, e.inferenceProvider(), req.Model, providerCallCorr, providerCallIndex)
-	state, terminal, runErr := e.consumeOpenAIChatStream(ctx, stream, state, metadata, req.Model)
-	state, metadata = e.completeOpenAIChatStream(ctx, t, state, metadata, req.Model, startTime, terminal)
-
-	log.Debug().
-		Str("event_id", metadata.ID.String()).
-		Str("model", metadata.Model).
-		Interface("temperature", metadata.Temperature).
-		Interface("top_p", metadata.TopP).
-		Interface("max_tokens", metadata.MaxTokens).
-		Interface("usage", metadata.Usage).
-		Str("stop_reason", stopReasonString(state.StopReason)).
-		Msg("OpenAI publishing final event (streaming)")
-
-	log.Debug().Err(runErr).Msg("OpenAI RunInference completed (streaming)")
-	return t, runErr
+	return req, nil
 }
 
 func (e *OpenAIEngine) consumeOpenAIChatStream(
@@ -336,13 +350,18 @@ func (e *OpenAIEngine) completeOpenAIChatStream(
 		Str("terminal", string(terminal.Kind)).
 		Msg("OpenAI streaming complete, preparing messages")
 
-	result := engine.BuildInferenceResultFromEventMetadata(metadata, "openai", includeToolCalls && toolCallCount > 0)
+	e.persistInferenceResult(t, metadata, includeToolCalls && toolCallCount > 0)
+
+	return state, metadata
+}
+
+// persistInferenceResult stores the canonical inference result on t.
+func (e *OpenAIEngine) persistInferenceResult(t *turns.Turn, metadata events.EventMetadata, hasToolCalls bool) {
+	result := engine.BuildInferenceResultFromEventMetadata(metadata, "openai", hasToolCalls)
 	settings.ApplyModelInfoCost(&result, e.settings.ModelInfo)
 	if err := engine.PersistInferenceResult(t, result); err != nil {
 		log.Warn().Err(err).Msg("OpenAI: failed to persist canonical inference_result")
 	}
-
-	return state, metadata
 }
 
 func appendOpenAIChatTurnBlocks(t *turns.Turn, state openAIChatStreamState, includeToolCalls bool) int {
@@ -8,6 +8,7 @@ import (
 	infengine "github.com/go-go-golems/geppetto/pkg/inference/engine"
 	"github.com/go-go-golems/geppetto/pkg/steps"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/imageparts"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/mediaparts"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
 	"github.com/go-go-golems/geppetto/pkg/turns"
 	"github.com/pkg/errors"
@@ -136,6 +137,9 @@ func (e *OpenAIEngine) MakeCompletionRequestFromTurn(
 	} else {
 		return nil, errors.New("no engine specified")
 	}
+	if err := mediaparts.CheckTurnInput(settings.ModelInfo, t); err != nil {
+		return nil, err
+	}
 
 	var msgs_ []ChatCompletionMessage
 
@@ -213,7 +217,15 @@ func (e *OpenAIEngine) MakeCompletionRequestFromTurn(
 						text = strings.TrimSpace(string(bb))
 					}
 				}
-				if text == "" {
+				var files []mediaparts.Part
+				if b.Kind == turns.BlockKindUser {
+					var err error
+					files, err = mediaparts.FromPayload(b.Payload)
+					if err != nil {
+						return nil, err
+					}
+				}
+				if text == "" && len(files) == 0 {
 					log.Debug().Str("role", b.Role).Msg("OpenAI request: skipping empty text block")
 					continue
 				}
@@ -236,8 +248,12 @@ func (e *OpenAIEngine) MakeCompletionRequestFromTurn(
 				}
 				// Check for images array in payload to construct MultiContent
 				var msg ChatCompletionMessage
-				if imgs, ok := b.Payload[turns.PayloadKeyImages].([]map[string]any); ok && len(imgs) > 0 {
-					parts := []ChatMessagePart{{Type: chatMessagePartTypeText, Text: text}}
+				imgs, _ := b.Payload[turns.PayloadKeyImages].([]map[string]any)
+				if len(imgs) > 0 || len(files) > 0 {
+					parts := []ChatMessagePart{}
+					if text != "" {
+						parts = append(parts, ChatMessagePart{Type: chatMessagePartTypeText, Text: text})
+					}
 					for _, img := range imgs {
 						part, ok, err := imageparts.NormalizeImageMap(img)
 						if err != nil {
@@ -261,6 +277,13 @@ func (e *OpenAIEngine) MakeCompletionRequestFromTurn(
 							},
 						})
 					}
+					for _, f := range files {
+						part, err := chatFilePart(f)
+						if err != nil {
+							return nil, err
+						}
+						parts = append(parts, part)
+					}
 					msg = ChatCompletionMessage{Role: role, MultiContent: parts}
 				} else {
 					msg = ChatCompletionMessage{Role: role, Content: text}
@@ -702,3 +725,36 @@ func anyToJSONString(v any) string {
 // TODO(manuel, 2024-06-25) We actually need a processor like the content block merger here, where we merge tool use blocks with previous messages to properly create openai messages
 // So we would need to get a message block, then slurp up all the following tool use messages, and then finally emit the message block
 // Removed obsolete messageToOpenAIMessage (conversation-based)
+
+// chatFilePart maps a file part to a Chat Completions content part: documents
+// and other files as "file", WAV/MP3 audio as "input_audio", plain text as
+// text. Remote URLs are only accepted for images.
+func chatFilePart(p mediaparts.Part) (ChatMessagePart, error) {
+	switch p.Kind {
+	case mediaparts.KindPDF, mediaparts.KindFile:
+		switch {
+		case p.FileID != "":
+			return ChatMessagePart{Type: chatMessagePartTypeFile, File: &ChatMessageFile{FileID: p.FileID}}, nil
+		case len(p.Data) > 0:
+			return ChatMessagePart{Type: chatMessagePartTypeFile, File: &ChatMessageFile{Filename: p.FilenameOrDefault(), FileData: p.DataURL()}}, nil
+		}
+	case mediaparts.KindAudio:
+		if format := mediaparts.AudioFormat(p.MediaType); format != "" && len(p.Data) > 0 {
+			return ChatMessagePart{Type: chatMessagePartTypeInputAudio, InputAudio: &ChatMessageInputAudio{Data: p.Base64(), Format: format}}, nil
+		}
+	case mediaparts.KindText:
+		if text := p.LabeledText(); text != "" {
+			return ChatMessagePart{Type: chatMessagePartTypeText, Text: text}, nil
+		}
+	case mediaparts.KindImage:
+		url := p.URL
+		if url == "" {
+			url = p.DataURL()
+		}
+		if url != "" {
+			return ChatMessagePart{Type: chatMessagePartTypeImageURL, ImageURL: &ChatMessageImageURL{URL: url, Detail: chatImageURLDetailAuto}}, nil
+		}
+	case mediaparts.KindVideo:
+	}
+	return ChatMessagePart{}, mediaparts.Unsupported("openai chat completions", p)
+}
@@ -1,9 +1,11 @@
 package openai
 
 import (
+	"errors"
 	"testing"
 
 	infengine "github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/mediaparts"
 	aisettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
 	aisettingsopenai "github.com/go-go-golems/geppetto/pkg/steps/ai/settings/openai"
 	"github.com/go-go-golems/geppetto/pkg/turns"
@@ -373,3 +375,63 @@ func TestMakeCompletionRequestFromTurnUserImageContent(t *testing.T) {
 		t.Fatalf("image URL = %#v", img.ImageURL)
 	}
 }
+
+func TestMakeCompletionRequestFromTurnUserFileParts(t *testing.T) {
+	engine := "gpt-4o-audio-preview"
+	st := &aisettings.InferenceSettings{
+		Client: &aisettings.ClientSettings{},
+		OpenAI: &aisettingsopenai.Settings{},
+		Chat:   &aisettings.ChatSettings{Engine: &engine},
+	}
+	tu := &turns.Turn{Blocks: []turns.Block{
+		turns.NewUserFileBlock("", []map[string]any{
+			{"media_type": "application/pdf", "content": []byte("PDF"), "filename": "a.pdf"},
+			{"file_id": "file-123"},
+			{"media_type": "audio/wav", "content": []byte("RIFF")},
+		}),
+	}}
+
+	req, err := newTestEngine(st).MakeCompletionRequestFromTurn(tu)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(req.Messages) != 1 || len(req.Messages[0].MultiContent) != 3 {
+		t.Fatalf("messages = %#v", req.Messages)
+	}
+	parts := req.Messages[0].MultiContent
+	if parts[0].Type != chatMessagePartTypeFile || parts[0].File == nil || parts[0].File.Filename != "a.pdf" || parts[0].File.FileData != "data:application/pdf;base64,UERG" {
+		t.Fatalf("pdf part = %#v", parts[0])
+	}
+	if parts[1].Type != chatMessagePartTypeFile || parts[1].File == nil || parts[1].File.FileID != "file-123" {
+		t.Fatalf("file id part = %#v", parts[1])
+	}
+	if parts[2].Type != chatMessagePartTypeInputAudio || parts[2].InputAudio == nil || parts[2].InputAudio.Format != "wav" || parts[2].InputAudio.Data != "UklGRg==" {
+		t.Fatalf("audio part = %#v", parts[2])
+	}
+}
+
+func TestMakeCompletionRequestFromTurnRejectsFilesOutsideModelInput(t *testing.T) {
+	engine := "gpt-4o-mini"
+	st := &aisettings.InferenceSettings{
+		Client:    &aisettings.ClientSettings{},
+		OpenAI:    &aisettingsopenai.Settings{},
+		Chat:      &aisettings.ChatSettings{Engine: &engine},
+		ModelInfo: &aisettings.ModelInfo{Input: []aisettings.InputModality{aisettings.InputModalityText, aisettings.InputModalityImage}},
+	}
+	tu := &turns.Turn{Blocks: []turns.Block{
+		turns.NewUserFileBlock("summarize", []map[string]any{{"media_type": "application/pdf", "content": []byte("PDF")}}),
+	}}
+	_, err := newTestEngine(st).MakeCompletionRequestFromTurn(tu)
+	if !errors.Is(err, mediaparts.ErrUnsupportedInput) {
+		t.Fatalf("expected ErrUnsupportedInput, got %v", err)
+	}
+
+	st.ModelInfo = nil
+	tu = &turns.Turn{Blocks: []turns.Block{
+		turns.NewUserFileBlock("watch", []map[string]any{{"media_type": "video/mp4", "content": []byte("MP4")}}),
+	}}
+	_, err = newTestEngine(st).MakeCompletionRequestFromTurn(tu)
+	if !errors.Is(err, mediaparts.ErrUnsupportedInput) {
+		t.Fatalf("expected video to be rejected by chat completions, got %v", err)
+	}
+}
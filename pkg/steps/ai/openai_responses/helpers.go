@@ -7,6 +7,7 @@ import (
 
 	"github.com/go-go-golems/geppetto/pkg/inference/engine"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/imageparts"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/mediaparts"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
 	"github.com/go-go-golems/geppetto/pkg/turns"
 )
@@ -82,6 +83,10 @@ type responsesContentPart struct {
 	ImageURL string `json:"image_url,omitempty"`
 	FileID   string `json:"file_id,omitempty"`
 	Detail   string `json:"detail,omitempty"`
+	// For input_file content type
+	Filename string `json:"filename,omitempty"`
+	FileData string `json:"file_data,omitempty"`
+	FileURL  string `json:"file_url,omitempty"`
 	// For function_call content type
 	CallID    string `json:"call_id,omitempty"`
 	Name      string `json:"name,omitempty"`
@@ -185,7 +190,14 @@ func (e *Engine) buildResponsesRequest(t *turns.Turn) (responsesRequest, error)
 	if s != nil && s.Chat != nil && s.Chat.Engine != nil {
 		req.Model = *s.Chat.Engine
 	}
-	req.Input = buildInputItemsFromTurn(t)
+	if err := checkResponsesFileInput(s, t); err != nil {
+		return responsesRequest{}, err
+	}
+	input, err := buildInputItemsFromTurn(t)
+	if err != nil {
+		return responsesRequest{}, err
+	}
+	req.Input = input
 	if s != nil && s.Chat != nil {
 		if s.Chat.MaxResponseTokens != nil {
 			req.MaxOutputTokens = s.Chat.MaxResponseTokens
@@ -428,10 +440,10 @@ func reasoningSummaryEntriesFromPayload(payload map[string]any) []any {
 	return nil
 }
 
-func buildInputItemsFromTurn(t *turns.Turn) []responsesInput {
+func buildInputItemsFromTurn(t *turns.Turn) ([]responsesInput, error) {
 	var items []responsesInput
 	if t == nil {
-		return items
+		return items, nil
 	}
 	roleFor := func(kind turns.BlockKind) string {
 		switch kind {
@@ -448,12 +460,16 @@ func buildInputItemsFromTurn(t *turns.Turn) []responsesInput {
 	}
 
 	// Helpers
-	appendMessage := func(b turns.Block) {
+	appendMessage := func(b turns.Block) error {
 		role := roleFor(b.Kind)
-		parts := buildResponsesMessageParts(role, b.Payload)
+		parts, err := buildResponsesMessageParts(role, b.Payload)
+		if err != nil {
+			return err
+		}
 		if len(parts) > 0 {
 			items = append(items, responsesInput{Role: role, Content: parts})
 		}
+		return nil
 	}
 	appendFunctionCall := func(b turns.Block) {
 		name, _ := b.Payload[turns.PayloadKeyName].(string)
@@ -577,17 +593,19 @@ func buildInputItemsFromTurn(t *turns.Turn) []responsesInput {
 		case turns.BlockKindToolUse:
 			appendFunctionCallOutput(b)
 		case turns.BlockKindUser, turns.BlockKindLLMText, turns.BlockKindSystem, turns.BlockKindOther:
-			appendMessage(b)
+			if err := appendMessage(b); err != nil {
+				return nil, err
+			}
 		}
 	}
 
-	return items
+	return items, nil
 }
 
-func buildResponsesMessageParts(role string, payload map[string]any) []responsesContentPart {
+func buildResponsesMessageParts(role string, payload map[string]any) ([]responsesContentPart, error) {
 	var parts []responsesContentPart
 	if payload == nil {
-		return parts
+		return parts, nil
 	}
 	if v, ok := payload[turns.PayloadKeyText]; ok && v != nil {
 		if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
@@ -599,7 +617,7 @@ func buildResponsesMessageParts(role string, payload map[string]any) []responses
 		}
 	}
 	if role == "assistant" {
-		return parts
+		return parts, nil
 	}
 	if imgs, ok := payload[turns.PayloadKeyImages].([]map[string]any); ok && len(imgs) > 0 {
 		for _, img := range imgs {
@@ -608,7 +626,67 @@ func buildResponsesMessageParts(role string, payload map[string]any) []responses
 			}
 		}
 	}
-	return parts
+	if role != "user" {
+		return parts, nil
+	}
+	// Files are read here, once; parts the Responses API cannot accept fail
+	// the request rather than being dropped.
+	files, err := mediaparts.FromPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		part, err := responsesFilePart(f)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+// checkResponsesFileInput rejects turns with file parts that the model (per
+// ModelInfo.Input) cannot accept, without reading any file.
+func checkResponsesFileInput(s *settings.InferenceSettings, t *turns.Turn) error {
+	if t == nil {
+		return nil
+	}
+	var info *settings.ModelInfo
+	if s != nil {
+		info = s.ModelInfo
+	}
+	return mediaparts.CheckTurnInput(info, t)
+}
+
+// responsesFilePart maps a file part to input_file (documents and other
+// files), input_image or input_text (plain-text documents).
+func responsesFilePart(p mediaparts.Part) (responsesContentPart, error) {
+	switch p.Kind {
+	case mediaparts.KindPDF, mediaparts.KindFile:
+		switch {
+		case p.FileID != "":
+			return responsesContentPart{Type: "input_file", FileID: p.FileID}, nil
+		case p.URL != "":
+			return responsesContentPart{Type: "input_file", FileURL: p.URL}, nil
+		case len(p.Data) > 0:
+			return responsesContentPart{Type: "input_file", Filename: p.FilenameOrDefault(), FileData: p.DataURL()}, nil
+		}
+	case mediaparts.KindText:
+		if text := p.LabeledText(); text != "" {
+			return responsesContentPart{Type: "input_text", Text: text}, nil
+		}
+	case mediaparts.KindImage:
+		switch {
+		case p.FileID != "":
+			return responsesContentPart{Type: "input_image", FileID: p.FileID, Detail: "auto"}, nil
+		case p.URL != "":
+			return responsesContentPart{Type: "input_image", ImageURL: p.URL, Detail: "auto"}, nil
+		case len(p.Data) > 0:
+			return responsesContentPart{Type: "input_image", ImageURL: p.DataURL(), Detail: "auto"}, nil
+		}
+	case mediaparts.KindAudio, mediaparts.KindVideo:
+	}
+	return responsesContentPart{}, mediaparts.Unsupported("openai responses", p)
 }
 
 func responsesImagePartFromMap(img map[string]any) (responsesContentPart, bool) {
@@ -2,9 +2,13 @@ package openai_responses
 
 import (
 	"encoding/base64"
+	"errors"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	infengine "github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/mediaparts"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
 	"github.com/go-go-golems/geppetto/pkg/turns"
 )
@@ -17,13 +21,22 @@ func typesOf(parts []responsesContentPart) []string {
 	return ts
 }
 
+func mustBuildInputItems(t *testing.T, turn *turns.Turn) []responsesInput {
+	t.Helper()
+	items, err := buildInputItemsFromTurn(turn)
+	if err != nil {
+		t.Fatalf("buildInputItemsFromTurn: %v", err)
+	}
+	return items
+}
+
 func TestBuildInputItemsFromTurn_PlainChat(t *testing.T) {
 	turn := &turns.Turn{Blocks: []turns.Block{
 		turns.NewSystemTextBlock("You are a LLM."),
 		turns.NewUserTextBlock("Hello"),
 	}}
 
-	got := buildInputItemsFromTurn(turn)
+	got := mustBuildInputItems(t, turn)
 	if len(got) != 2 {
 		t.Fatalf("expected 2 items, got %d", len(got))
 	}
@@ -49,7 +62,7 @@ func TestBuildInputItemsFromTurn_UserMessageWithImageURL(t *testing.T) {
 		}}),
 	}}
 
-	got := buildInputItemsFromTurn(turn)
+	got := mustBuildInputItems(t, turn)
 	if len(got) != 1 {
 		t.Fatalf("expected 1 item, got %d", len(got))
 	}
@@ -82,7 +95,7 @@ func TestBuildInputItemsFromTurn_UserMessageWithInlineImageBytes(t *testing.T) {
 		}}),
 	}}
 
-	got := buildInputItemsFromTurn(turn)
+	got := mustBuildInputItems(t, turn)
 	if len(got) != 1 {
 		t.Fatalf("expected 1 item, got %d", len(got))
 	}
@@ -112,7 +125,7 @@ func TestBuildInputItemsFromTurn_UserMessageWithMixedTextAndMultipleImages(t *te
 		}),
 	}}
 
-	got := buildInputItemsFromTurn(turn)
+	got := mustBuildInputItems(t, turn)
 	if len(got) != 1 {
 		t.Fatalf("expected 1 item, got %d", len(got))
 	}
@@ -144,7 +157,7 @@ func TestBuildInputItemsFromTurn_ReasoningWithAssistantFollower(t *testing.T) {
 		as,
 	}}
 
-	got := buildInputItemsFromTurn(turn)
+	got := mustBuildInputItems(t, turn)
 	if len(got) != 4 {
 		t.Fatalf("expected 4 items, got %d", len(got))
 	}
@@ -183,7 +196,7 @@ func TestBuildInputItemsFromTurn_MultiTurnReasoningThenUser(t *testing.T) {
 		turns.NewUserTextBlock("Follow-up"),
 	}}
 
-	got := buildInputItemsFromTurn(turn)
+	got := mustBuildInputItems(t, turn)
 	if len(got) != 5 {
 		t.Fatalf("expected 5 items, got %d", len(got))
 	}
@@ -214,7 +227,7 @@ func TestBuildInputItemsFromTurn_OmitsSyntheticReasoningUUID(t *testing.T) {
 		turns.NewAssistantTextBlock("Answer"),
 	}}
 
-	got := buildInputItemsFromTurn(turn)
+	got := mustBuildInputItems(t, turn)
 	if len(got) != 3 {
 		t.Fatalf("expected 3 items, got %d", len(got))
 	}
@@ -239,7 +252,7 @@ func TestBuildInputItemsFromTurn_OmitsPlaintextOnlyReasoningInputContent(t *test
 		turns.NewAssistantTextBlock("Answer"),
 	}}
 
-	got := buildInputItemsFromTurn(turn)
+	got := mustBuildInputItems(t, turn)
 	if len(got) != 2 {
 		t.Fatalf("expected plaintext-only reasoning to be omitted from replay, got %d items: %#v", len(got), got)
 	}
@@ -256,7 +269,7 @@ func TestBuildInputItemsFromTurn_OmitsTrulyEmptyReasoning(t *testing.T) {
 		turns.NewAssistantTextBlock("Answer"),
 	}}
 
-	got := buildInputItemsFromTurn(turn)
+	got := mustBuildInputItems(t, turn)
 	if len(got) != 2 {
 		t.Fatalf("expected truly empty reasoning to be omitted, got %d items: %#v", len(got), got)
 	}
@@ -282,7 +295,7 @@ func TestBuildInputItemsFromTurn_ReplaysReasoningSummaryPayload(t *testing.T) {
 		as,
 	}}
 
-	got := buildInputItemsFromTurn(turn)
+	got := mustBuildInputItems(t, turn)
 	if len(got) != 4 {
 		t.Fatalf("expected 4 items, got %d", len(got))
 	}
@@ -315,7 +328,7 @@ func TestBuildInputItemsFromTurn_PreservesAssistantContextWithReasoningFollower(
 		turns.NewAssistantTextBlock("A3 follower"),
 	}}
 
-	got := buildInputItemsFromTurn(turn)
+	got := mustBuildInputItems(t, turn)
 
 	var assistantRoleTexts []string
 	for _, item := range got {
@@ -394,7 +407,7 @@ func TestBuildInputItemsFromTurn_PreservesReasoningForOlderFunctionCallChains(t
 		turns.NewUserTextBlock("follow-up"),
 	}}
 
-	got := buildInputItemsFromTurn(turn)
+	got := mustBuildInputItems(t, turn)
 
 	oldCallIdx := -1
 	for i, item := range got {
@@ -568,3 +581,59 @@ func TestBuildResponsesRequestInferenceEmptyStopClearsChatStop(t *testing.T) {
 		t.Fatalf("expected stop override to clear chat stop, got %v", req.StopSequences)
 	}
 }
+
+func TestBuildResponsesRequestMapsFilePartsToInputFile(t *testing.T) {
+	model := "gpt-4.1"
+	e := newTestEngine(&settings.InferenceSettings{Chat: &settings.ChatSettings{Engine: &model}})
+	turn := &turns.Turn{Blocks: []turns.Block{
+		turns.NewUserFileBlock("Summarize", []map[string]any{
+			{"media_type": "application/pdf", "content": []byte("PDF")},
+			{"url": "https://example.com/data.xlsx", "media_type": "application/vnd.ms-excel"},
+			{"text": "meeting notes", "title": "Notes"},
+		}),
+	}}
+
+	req, err := e.buildResponsesRequest(turn)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(req.Input) != 1 {
+		t.Fatalf("expected 1 input item, got %#v", req.Input)
+	}
+	parts := req.Input[0].Content
+	if got := typesOf(parts); len(got) != 4 || got[1] != "input_file" || got[2] != "input_file" || got[3] != "input_text" {
+		t.Fatalf("part types = %v", got)
+	}
+	if parts[1].Filename != "file.pdf" || parts[1].FileData != "data:application/pdf;base64,UERG" {
+		t.Fatalf("inline file part = %#v", parts[1])
+	}
+	if parts[2].FileURL != "https://example.com/data.xlsx" {
+		t.Fatalf("file url part = %#v", parts[2])
+	}
+	if parts[3].Text != "Document: Notes\n\nmeeting notes" {
+		t.Fatalf("text document part = %#v", parts[3])
+	}
+}
+
+func TestBuildResponsesRequestRejectsAudioParts(t *testing.T) {
+	model := "gpt-4.1"
+	e := newTestEngine(&settings.InferenceSettings{Chat: &settings.ChatSettings{Engine: &model}})
+	turn := &turns.Turn{Blocks: []turns.Block{
+		turns.NewUserFileBlock("Transcribe", []map[string]any{{"media_type": "audio/mpeg", "content": []byte("ID3")}}),
+	}}
+	_, err := e.buildResponsesRequest(turn)
+	if !errors.Is(err, mediaparts.ErrUnsupportedInput) {
+		t.Fatalf("expected ErrUnsupportedInput, got %v", err)
+	}
+}
+
+func TestBuildResponsesRequestReturnsFileReadErrors(t *testing.T) {
+	model := "gpt-4.1"
+	e := newTestEngine(&settings.InferenceSettings{Chat: &settings.ChatSettings{Engine: &model}})
+	turn := &turns.Turn{Blocks: []turns.Block{
+		turns.NewUserFileBlock("Summarize", []map[string]any{{"path": filepath.Join(t.TempDir(), "missing.pdf")}}),
+	}}
+	if _, err := e.buildResponsesRequest(turn); err == nil || !strings.Contains(err.Error(), "read file part") {
+		t.Fatalf("expected file read error, got %v", err)
+	}
+}
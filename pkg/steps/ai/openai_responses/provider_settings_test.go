@@ -8,8 +8,10 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/credentials"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/retry"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
 	aitransport "github.com/go-go-golems/geppetto/pkg/steps/ai/transport"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/types"
@@ -128,6 +130,44 @@ func TestOpenResponsesStreamRetriesOneProvider401WithReplacementBearer(t *testin
 	}
 }
 
+func TestOpenResponsesStreamRetriesOverloadedWithSameBearer(t *testing.T) {
+	var requests int
+	client := &http.Client{Transport: roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		requests++
+		if got := request.Header.Get("Authorization"); got != "Bearer steady-token" {
+			t.Fatalf("attempt %d Authorization = %q", requests, got)
+		}
+		status, body := retry.StatusOverloaded, `{"error":{"type":"overloaded_error"}}`
+		if requests == 3 {
+			status, body = http.StatusOK, "data: [DONE]\\n\\n"
+		}
+		return &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body)), Request: request}, nil
+	})}
+
+	credentialRequest := credentials.Request{Provider: "open-responses", BaseURL: "https://provider.example.test/v1"}
+	requestTransport := testResponsesRequestTransport(t, "https://provider.example.test/v1/responses", "steady-token", nil, credentialRequest)
+	chain, err := aitransport.NewChain(retry.NewMiddleware(retry.Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond}), &responsesBearerMiddleware{
+		api:               &settings.APISettings{APIKeys: map[string]string{"open-responses-api-key": "steady-token"}},
+		apiType:           types.ApiTypeOpenResponses,
+		credentialRequest: credentialRequest,
+	})
+	if err != nil {
+		t.Fatalf("NewChain: %v", err)
+	}
+	requestTransport.chain = chain
+
+	var retries []retry.Attempt
+	ctx := retry.WithReporter(context.Background(), func(_ context.Context, a retry.Attempt) { retries = append(retries, a) })
+	response, err := openResponsesStream(ctx, client, requestTransport, []byte(`{"model":"test"}`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if requests != 3 || len(retries) != 2 || retries[1].Number != 3 || retries[1].Class != retry.ClassOverloaded {
+		t.Fatalf("requests=%d retries=%#v", requests, retries)
+	}
+}
+
 func TestOpenResponsesRequestRedactsMiddlewareCredentialsFromDebugTap(t *testing.T) {
 	const secret = "Bearer middleware-secret"
 	source := bearerTokenSourceFunc(func(context.Context, credentials.Request) (string, error) {
@@ -10,6 +10,8 @@ import (
 
 	"github.com/go-go-golems/geppetto/pkg/security"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/credentials"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/providerhttp"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/ratelimit"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
 	aitransport "github.com/go-go-golems/geppetto/pkg/steps/ai/transport"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/types"
@@ -116,6 +118,12 @@ func (e *Engine) newRequestTransport(api *settings.APISettings) (responsesReques
 		}, middlewares...)
 	}
 
+	clientMiddlewares, err := e.clientMiddlewares(config.Provider, api)
+	if err != nil {
+		return responsesRequestTransport{}, fmt.Errorf("build Responses client middleware: %w", err)
+	}
+	middlewares = append(clientMiddlewares, middlewares...)
+
 	chain, err := aitransport.NewChain(middlewares...)
 	if err != nil {
 		return responsesRequestTransport{}, fmt.Errorf("build Responses middleware chain: %w", err)
@@ -123,6 +131,24 @@ func (e *Engine) newRequestTransport(api *settings.APISettings) (responsesReques
 	return responsesRequestTransport{request: request, chain: chain, headerRules: rules}, nil
 }
 
+// clientMiddlewares returns the retry and rate-limit middleware configured by
+// the client settings. They run first so retries wait for their backoff and
+// requests wait for budget before credentials are resolved.
+func (e *Engine) clientMiddlewares(provider string, api *settings.APISettings) ([]aitransport.Middleware, error) {
+	if e.settings == nil {
+		return nil, nil
+	}
+	model := ""
+	if e.settings.Chat != nil && e.settings.Chat.Engine != nil {
+		model = *e.settings.Chat.Engine
+	}
+	apiKey := ""
+	if api != nil {
+		apiKey = api.APIKeys[string(responsesAPIType(e.settings))+"-api-key"]
+	}
+	return providerhttp.Middlewares(e.settings.Client, ratelimit.NewKey(provider, model, apiKey))
+}
+
 func appendResponsesHeaderRule(rules []aitransport.HeaderRule, rule aitransport.HeaderRule) ([]aitransport.HeaderRule, error) {
 	canonicalName := http.CanonicalHeaderKey(strings.TrimSpace(rule.Name))
 	for _, existing := range rules {
@@ -150,11 +176,19 @@ type responsesBearerAttempt struct {
 }
 
 func (m *responsesBearerMiddleware) BeforeRequest(ctx context.Context, _ aitransport.RequestContext, previous aitransport.Attempt, headers aitransport.HeaderWriter) (aitransport.Attempt, error) {
-	if prior, ok := previous.(*responsesBearerAttempt); ok && strings.TrimSpace(prior.replacement) != "" {
-		if err := headers.Set("Authorization", "Bearer "+prior.replacement); err != nil {
+	if prior, ok := previous.(*responsesBearerAttempt); ok {
+		// A replay requested by another middleware keeps the prior credential.
+		token := prior.token
+		if strings.TrimSpace(prior.replacement) != "" {
+			token = prior.replacement
+		}
+		if strings.TrimSpace(token) == "" {
+			return &responsesBearerAttempt{}, nil
+		}
+		if err := headers.Set("Authorization", "Bearer "+token); err != nil {
 			return nil, err
 		}
-		return &responsesBearerAttempt{token: prior.replacement}, nil
+		return &responsesBearerAttempt{token: token}, nil
 	}
 	if previous != nil {
 		return nil, errors.New("responses bearer middleware received incompatible prior attempt")
@@ -13,12 +13,21 @@ import (
 	"github.com/go-go-golems/geppetto/pkg/events"
 	"github.com/go-go-golems/geppetto/pkg/inference/engine"
 	gepsession "github.com/go-go-golems/geppetto/pkg/inference/session"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/ratelimit"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/retry"
 	aitransport "github.com/go-go-golems/geppetto/pkg/steps/ai/transport"
 	"github.com/go-go-golems/geppetto/pkg/turns"
 )
 
 func (e *Engine) runStreamingInference(ctx context.Context, t *turns.Turn, httpClient *http.Client, requestTransport responsesRequestTransport, body []byte, metadata events.EventMetadata, tap engine.DebugTap, startTime time.Time, reqBody responsesRequest) (*turns.Turn, error) {
-	resp, err := openResponsesStream(ctx, httpClient, requestTransport, body, tap)
+	providerCallIndex := 0
+	if idx, ok := gepsession.ProviderCallIndexFromContext(ctx); ok {
+		providerCallIndex = idx
+	}
+	providerCallCorr := newResponsesProviderCallCorrelation(metadata, reqBody, providerCallIndex)
+	streamCtx := ratelimit.WithTokenEstimate(ctx, ratelimit.EstimateTurnTokens(t))
+	streamCtx = retry.WithEventReporter(streamCtx, metadata, providerCallCorr, e.publishEvent)
+	resp, err := openResponsesStream(streamCtx, httpClient, requestTransport, body, tap)
 	if err != nil {
 		return nil, err
 	}
@@ -26,11 +35,6 @@ func (e *Engine) runStreamingInference(ctx context.Context, t *turns.Turn, httpC
 	reader := bufio.NewReader(resp.Body)
 	var eventName string
 	var dataBuf strings.Builder
-	providerCallIndex := 0
-	if idx, ok := gepsession.ProviderCallIndexFromContext(ctx); ok {
-		providerCallIndex = idx
-	}
-	providerCallCorr := newResponsesProviderCallCorrelation(metadata, reqBody, providerCallIndex)
 	e.publishEvent(ctx, events.NewProviderCallStartedEvent(metadata, providerCallCorr))
 	streamState := newResponsesStreamState(reqBody, providerCallCorr, tap)
 	log.Trace().Msg("Responses: starting SSE read loop")
@@ -147,21 +151,25 @@ func (e *Engine) completeResponsesStream(ctx context.Context, t *turns.Turn, met
 
 func openResponsesStream(ctx context.Context, httpClient *http.Client, requestTransport responsesRequestTransport, body []byte, tap engine.DebugTap) (*http.Response, error) {
 	var previousAttempt aitransport.AttemptState
+	maxReplays := requestTransport.chain.MaxReplays()
 	for attempt := 0; ; attempt++ {
 		resp, attempts, err := openResponsesRequest(ctx, httpClient, requestTransport, body, previousAttempt, tap)
 		if err != nil {
 			return nil, err
 		}
-		decision, err := requestTransport.chain.AfterResponse(ctx, requestTransport.request, attempts, aitransport.ResponseMetadata{StatusCode: resp.StatusCode, RetryEligible: attempt == 0})
+		decision, err := requestTransport.chain.AfterResponse(ctx, requestTransport.request, attempts, aitransport.ResponseMetadata{StatusCode: resp.StatusCode, Header: resp.Header.Clone(), RetryEligible: attempt < maxReplays})
 		if err != nil {
 			_ = resp.Body.Close()
+			requestTransport.chain.Complete(ctx, requestTransport.request, attempts)
 			return nil, err
 		}
-		if decision == aitransport.Retry && attempt == 0 {
+		if decision == aitransport.Retry && attempt < maxReplays {
 			_ = resp.Body.Close()
+			requestTransport.chain.Complete(ctx, requestTransport.request, attempts)
 			previousAttempt = attempts
 			continue
 		}
+		resp.Body = requestTransport.chain.CompleteOnClose(ctx, requestTransport.request, attempts, resp.Body)
 		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			return resp, nil
 		}
@@ -193,6 +201,7 @@ func openResponsesRequest(ctx context.Context, httpClient *http.Client, requestT
 	// #nosec G704 -- URL is validated above with transport.ResolveAndValidate.
 	resp, err := httpClient.Do(req)
 	if err != nil {
+		requestTransport.chain.Complete(ctx, requestTransport.request, attempts)
 		log.Debug().Err(err).Msg("Responses: HTTP request failed")
 		if tap != nil {
 			tap.OnProviderObject("http.error", map[string]any{"error": err.Error()})
@@ -211,7 +220,7 @@ func responsesHTTPError(resp *http.Response, tap engine.DebugTap) error {
 	if tap != nil {
 		tap.OnHTTPResponse(resp, mustMarshalJSON(m))
 	}
-	return fmt.Errorf("responses api error: status=%d body=%v", resp.StatusCode, m)
+	return &engine.StatusError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("responses api error: status=%d body=%v", resp.StatusCode, m)}
 }
 
 func consumeResponsesSSE(
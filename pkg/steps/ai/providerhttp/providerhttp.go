@@ -0,0 +1,65 @@
+// Package providerhttp builds the HTTP clients and transport middleware that
+// every engine, embeddings provider and reranker uses to reach a provider. It
+// combines the retry policy and the process-wide rate-limit governor
+// configured in settings.ClientSettings, in that order, so a retry waits for
+// its backoff before it queues for rate-limit budget.
+package providerhttp
+
+import (
+	"net/http"
+
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/ratelimit"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/retry"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	aitransport "github.com/go-go-golems/geppetto/pkg/steps/ai/transport"
+)
+
+// Middlewares returns the retry and rate-limit middleware configured by cs,
+// for engines that own a transport.Chain. key selects the shared governor.
+func Middlewares(cs *settings.ClientSettings, key ratelimit.Key) ([]aitransport.Middleware, error) {
+	policy, err := retry.PolicyFromClientSettings(cs)
+	if err != nil {
+		return nil, err
+	}
+	var middlewares []aitransport.Middleware
+	if policy.Enabled() {
+		middlewares = append(middlewares, retry.NewMiddleware(policy))
+	}
+	return append(middlewares, ratelimit.ChainMiddlewares(cs, key)...), nil
+}
+
+// Client returns the HTTP client for cs (see settings.EnsureHTTPClient) with
+// the middleware of Middlewares installed. Without retry or rate-limit
+// settings the plain client is returned.
+func Client(cs *settings.ClientSettings, key ratelimit.Key) (*http.Client, error) {
+	client, err := settings.EnsureHTTPClient(cs)
+	if err != nil {
+		return nil, err
+	}
+	middlewares, err := Middlewares(cs, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(middlewares) == 0 {
+		return client, nil
+	}
+	return Wrap(client, key.Provider, middlewares...)
+}
+
+// Wrap returns a copy of client whose requests pass through middlewares.
+func Wrap(client *http.Client, provider string, middlewares ...aitransport.Middleware) (*http.Client, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	chain, err := aitransport.NewChain(middlewares...)
+	if err != nil {
+		return nil, err
+	}
+	rt, err := aitransport.NewRoundTripper(client.Transport, provider, chain)
+	if err != nil {
+		return nil, err
+	}
+	wrapped := *client
+	wrapped.Transport = rt
+	return &wrapped, nil
+}
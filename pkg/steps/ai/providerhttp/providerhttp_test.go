@@ -0,0 +1,112 @@
+package providerhttp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/ratelimit"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/retry"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+)
+
+func TestClientRetriesTransientStatusesAndReportsAttempts(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"input":"hi"}` {
+			t.Errorf("body = %q", body)
+		}
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(retry.StatusOverloaded)
+		case 2:
+			w.Header().Set("Retry-After-Ms", "5")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			_, _ = io.WriteString(w, "ok")
+		}
+	}))
+	defer server.Close()
+
+	attempts, backoff, one := 3, 1, 1
+	cs := &settings.ClientSettings{RetryMaxAttempts: &attempts, RetryInitialBackoffMs: &backoff, MaxConcurrentRequests: &one, HTTPClient: server.Client()}
+	client, err := Client(cs, ratelimit.NewKey("test-provider", "retry-model", "secret"))
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+
+	var published []events.Event
+	corr := events.BuildRunCorrelation("session-1", "run-1", "turn-1")
+	ctx := retry.WithEventReporter(context.Background(), events.EventMetadata{InferenceID: "run-1"}, corr, func(_ context.Context, e events.Event) {
+		published = append(published, e)
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/v1/messages", strings.NewReader(`{"input":"hi"}`))
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(b) != "ok" || calls.Load() != 3 {
+		t.Fatalf("status=%d body=%q calls=%d", resp.StatusCode, b, calls.Load())
+	}
+
+	if len(published) != 2 {
+		t.Fatalf("published %d retry events, want 2", len(published))
+	}
+	first, ok := published[0].(*events.EventProviderCallRetry)
+	if !ok || first.Attempt != 2 || first.MaxAttempts != 3 || first.StatusCode != retry.StatusOverloaded || first.ErrorClass != string(retry.ClassOverloaded) {
+		t.Fatalf("first retry event = %#v", published[0])
+	}
+	second := published[1].(*events.EventProviderCallRetry)
+	if second.Attempt != 3 || second.ErrorClass != string(retry.ClassRateLimited) || second.DelayMs < 5 {
+		t.Fatalf("second retry event = %#v", second)
+	}
+	if err := events.ValidateCanonicalEvent(second); err != nil {
+		t.Fatalf("retry event is not canonical: %v", err)
+	}
+}
+
+func TestClientReturnsFailureWhenAttemptsAreExhausted(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	attempts, backoff := 2, 1
+	cs := &settings.ClientSettings{RetryMaxAttempts: &attempts, RetryInitialBackoffMs: &backoff, RetryOn: []string{"server_error"}, HTTPClient: server.Client()}
+	client, err := Client(cs, ratelimit.NewKey("test-provider", "m", ""))
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+	resp, err := client.Get(server.URL + "/v1/models")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || calls.Load() != 2 {
+		t.Fatalf("status=%d calls=%d", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestClientWithoutRetryOrLimitsIsUnwrapped(t *testing.T) {
+	base := &http.Client{}
+	client, err := Client(&settings.ClientSettings{HTTPClient: base}, ratelimit.NewKey("p", "m", ""))
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+	if client != base {
+		t.Fatalf("client without retry or limits should be returned unchanged")
+	}
+}
@@ -163,8 +163,12 @@ func (m *credentialMiddleware) BeforeRequest(ctx context.Context, _ aitransport.
 }
 
 func (m *credentialMiddleware) credentialForAttempt(ctx context.Context, previous any) (Credential, error) {
-	if prior, ok := previous.(*attempt); ok && prior.replacement != nil {
-		return *prior.replacement, nil
+	if prior, ok := previous.(*attempt); ok {
+		if prior.replacement != nil {
+			return *prior.replacement, nil
+		}
+		// A replay requested by another middleware keeps the prior credential.
+		return prior.credential, nil
 	}
 	if previous != nil {
 		return Credential{}, errors.New("codex middleware received incompatible prior attempt")
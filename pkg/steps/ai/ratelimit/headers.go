@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTooManyRequestsBackoff is the hold applied after a 429 response that
+// carries no Retry-After or reset hint.
+const DefaultTooManyRequestsBackoff = time.Second
+
+// feedback is the rate-limit information of one response. Remaining counts
+// are -1 when the provider did not report them.
+type feedback struct {
+	backoff           time.Duration
+	remainingRequests int
+	remainingTokens   int
+}
+
+// parseFeedback reads Retry-After, retry-after-ms, the OpenAI-style
+// x-ratelimit-{remaining,reset}-{requests,tokens} headers and the Anthropic
+// anthropic-ratelimit-{requests,tokens}-{remaining,reset} headers.
+func parseFeedback(statusCode int, header http.Header, now time.Time) feedback {
+	fb := feedback{remainingRequests: -1, remainingTokens: -1}
+	if header == nil {
+		header = http.Header{}
+	}
+
+	retryAfter, hasRetryAfter := RetryAfter(header, now)
+	if hasRetryAfter {
+		fb.backoff = retryAfter
+	}
+
+	for _, family := range []struct {
+		remaining, reset string
+		target           *int
+	}{
+		{"X-Ratelimit-Remaining-Requests", "X-Ratelimit-Reset-Requests", &fb.remainingRequests},
+		{"Anthropic-Ratelimit-Requests-Remaining", "Anthropic-Ratelimit-Requests-Reset", &fb.remainingRequests},
+		{"X-Ratelimit-Remaining-Tokens", "X-Ratelimit-Reset-Tokens", &fb.remainingTokens},
+		{"Anthropic-Ratelimit-Tokens-Remaining", "Anthropic-Ratelimit-Tokens-Reset", &fb.remainingTokens},
+		{"Anthropic-Ratelimit-Input-Tokens-Remaining", "Anthropic-Ratelimit-Input-Tokens-Reset", &fb.remainingTokens},
+	} {
+		raw := strings.TrimSpace(header.Get(family.remaining))
+		if raw == "" {
+			continue
+		}
+		remaining, err := strconv.Atoi(raw)
+		if err != nil || remaining < 0 {
+			continue
+		}
+		if *family.target < 0 || remaining < *family.target {
+			*family.target = remaining
+		}
+		if remaining > 0 || hasRetryAfter {
+			continue
+		}
+		if reset, ok := parseReset(header.Get(family.reset), now); ok && reset > fb.backoff {
+			fb.backoff = reset
+		}
+	}
+
+	if statusCode == http.StatusTooManyRequests && fb.backoff == 0 {
+		fb.backoff = DefaultTooManyRequestsBackoff
+	}
+	return fb
+}
+
+// RetryAfter returns the delay requested by a retry-after-ms or Retry-After
+// header. Retry-After may be a number of seconds or an HTTP date.
+func RetryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	if raw := strings.TrimSpace(header.Get("Retry-After-Ms")); raw != "" {
+		if ms, err := strconv.ParseFloat(raw, 64); err == nil && ms >= 0 {
+			return time.Duration(ms * float64(time.Millisecond)), true
+		}
+	}
+	raw := strings.TrimSpace(header.Get("Retry-After"))
+	if raw == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseFloat(raw, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	if at, err := http.ParseTime(raw); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// parseReset accepts a Go duration ("6m0s", "20ms"), a number of seconds, or
+// an RFC 3339 timestamp.
+func parseReset(raw string, now time.Time) (time.Duration, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, false
+	}
+	if d, err := time.ParseDuration(raw); err == nil {
+		return max(d, 0), true
+	}
+	if seconds, err := strconv.ParseFloat(raw, 64); err == nil {
+		return max(time.Duration(seconds*float64(time.Second)), 0), true
+	}
+	if at, err := time.Parse(time.RFC3339, raw); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,153 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	aitransport "github.com/go-go-golems/geppetto/pkg/steps/ai/transport"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+)
+
+// Middleware admits each request through a Governor. BeforeRequest waits for
+// budget, AfterResponse feeds the provider's rate-limit headers back, and
+// Complete frees the concurrency slot when the response body is closed. It
+// never replays a request itself: a 429 is only retried when a retry policy
+// middleware is enabled, and that replay then waits for the backoff here.
+type Middleware struct {
+	governor *Governor
+}
+
+var (
+	_ aitransport.Middleware = (*Middleware)(nil)
+	_ aitransport.Completer  = (*Middleware)(nil)
+)
+
+// NewMiddleware returns a transport middleware backed by g.
+func NewMiddleware(g *Governor) *Middleware {
+	return &Middleware{governor: g}
+}
+
+// BeforeRequest waits for budget. The token cost is taken from
+// WithTokenEstimate on ctx.
+func (m *Middleware) BeforeRequest(ctx context.Context, _ aitransport.RequestContext, _ aitransport.Attempt, _ aitransport.HeaderWriter) (aitransport.Attempt, error) {
+	permit, err := m.governor.Acquire(ctx, TokenEstimate(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("wait for %s rate limit: %w", m.governor.Key().Provider, err)
+	}
+	return permit, nil
+}
+
+// AfterResponse records provider feedback.
+func (m *Middleware) AfterResponse(_ context.Context, _ aitransport.RequestContext, _ aitransport.Attempt, response aitransport.ResponseMetadata) (aitransport.ResponseDecision, error) {
+	m.governor.Observe(response.StatusCode, response.Header)
+	return aitransport.Continue, nil
+}
+
+// Complete releases the attempt's permit.
+func (m *Middleware) Complete(_ context.Context, _ aitransport.RequestContext, attempt aitransport.Attempt) {
+	if permit, ok := attempt.(*Permit); ok {
+		permit.Release()
+	}
+}
+
+type tokenEstimateKey struct{}
+
+// WithTokenEstimate records the estimated token cost of the requests made with
+// ctx, used against TokensPerMinute.
+func WithTokenEstimate(ctx context.Context, tokens int) context.Context {
+	return context.WithValue(ctx, tokenEstimateKey{}, tokens)
+}
+
+// TokenEstimate returns the estimate stored by WithTokenEstimate, or zero.
+func TokenEstimate(ctx context.Context) int {
+	tokens, _ := ctx.Value(tokenEstimateKey{}).(int)
+	return tokens
+}
+
+// EstimateTokens approximates the token count of texts at four characters per
+// token. Provider x-ratelimit-remaining-tokens headers correct the estimate.
+func EstimateTokens(texts ...string) int {
+	chars := 0
+	for _, text := range texts {
+		chars += utf8.RuneCountInString(text)
+	}
+	return (chars + 3) / 4
+}
+
+// EstimateTurnTokens estimates the prompt size of t from its text payloads.
+func EstimateTurnTokens(t *turns.Turn) int {
+	if t == nil {
+		return 0
+	}
+	texts := make([]string, 0, len(t.Blocks))
+	for _, b := range t.Blocks {
+		if text, ok := b.Payload[turns.PayloadKeyText].(string); ok {
+			texts = append(texts, text)
+		}
+	}
+	return EstimateTokens(texts...)
+}
+
+// LimitsFromClientSettings reads the rate-limit fields of cs.
+func LimitsFromClientSettings(cs *settings.ClientSettings) Limits {
+	if cs == nil {
+		return Limits{}
+	}
+	value := func(v *int) int {
+		if v == nil || *v < 0 {
+			return 0
+		}
+		return *v
+	}
+	return Limits{
+		RequestsPerMinute: value(cs.RequestsPerMinute),
+		TokensPerMinute:   value(cs.TokensPerMinute),
+		MaxConcurrent:     value(cs.MaxConcurrentRequests),
+	}
+}
+
+// HTTPClient returns the HTTP client for cs (see settings.EnsureHTTPClient)
+// governed by the process-wide governor for key. Without limits in cs the
+// plain client is returned.
+func HTTPClient(cs *settings.ClientSettings, key Key) (*http.Client, error) {
+	client, err := settings.EnsureHTTPClient(cs)
+	if err != nil {
+		return nil, err
+	}
+	limits := LimitsFromClientSettings(cs)
+	if limits.IsZero() {
+		return client, nil
+	}
+	return WrapHTTPClient(client, Default().Governor(key, limits))
+}
+
+// WrapHTTPClient returns a copy of client whose requests pass through g.
+func WrapHTTPClient(client *http.Client, g *Governor) (*http.Client, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	chain, err := aitransport.NewChain(NewMiddleware(g))
+	if err != nil {
+		return nil, err
+	}
+	rt, err := aitransport.NewRoundTripper(client.Transport, g.Key().Provider, chain)
+	if err != nil {
+		return nil, err
+	}
+	wrapped := *client
+	wrapped.Transport = rt
+	return &wrapped, nil
+}
+
+// ChainMiddlewares returns the rate-limit middleware for key when cs sets
+// limits, for engines that own a transport.Chain.
+func ChainMiddlewares(cs *settings.ClientSettings, key Key) []aitransport.Middleware {
+	limits := LimitsFromClientSettings(cs)
+	if limits.IsZero() {
+		return nil
+	}
+	return []aitransport.Middleware{NewMiddleware(Default().Governor(key, limits))}
+}
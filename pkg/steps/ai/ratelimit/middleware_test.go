@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+)
+
+func TestHTTPClientLeavesTooManyRequestsToRetryPolicy(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"input":"hi"}` {
+			t.Errorf("body = %q", body)
+		}
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After-Ms", "10")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	one := 1
+	cs := &settings.ClientSettings{MaxConcurrentRequests: &one, HTTPClient: server.Client()}
+	key := NewKey("test-provider", "replay-model", "secret")
+	client, err := HTTPClient(cs, key)
+	if err != nil {
+		t.Fatalf("HTTPClient: %v", err)
+	}
+	resp, err := client.Post(server.URL+"/v1/embeddings", "application/json", strings.NewReader(`{"input":"hi"}`))
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || calls.Load() != 1 {
+		t.Fatalf("status=%d body=%q calls=%d", resp.StatusCode, b, calls.Load())
+	}
+
+	g, ok := Default().Lookup(key)
+	if !ok {
+		t.Fatalf("governor was not registered")
+	}
+	g.mu.Lock()
+	inflight := g.inflight
+	g.mu.Unlock()
+	if inflight != 0 {
+		t.Fatalf("permits leaked: inflight=%d", inflight)
+	}
+}
+
+func TestHTTPClientWithoutLimitsIsUnwrapped(t *testing.T) {
+	base := &http.Client{}
+	client, err := HTTPClient(&settings.ClientSettings{HTTPClient: base}, NewKey("p", "m", ""))
+	if err != nil {
+		t.Fatalf("HTTPClient: %v", err)
+	}
+	if client != base {
+		t.Fatalf("client without limits should be returned unchanged")
+	}
+}
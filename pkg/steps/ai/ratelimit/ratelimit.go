@@ -0,0 +1,274 @@
+// Package ratelimit provides a process-wide request governor for provider
+// calls. Every chat engine, embeddings provider and reranker built from the
+// same provider, model and API key shares one Governor, which enforces
+// requests per minute, tokens per minute and a concurrency cap, and backs off
+// when a provider answers with Retry-After or exhausted x-ratelimit-* headers.
+//
+// The governor is installed as a transport.Middleware, either directly in an
+// engine's middleware chain or around a plain http.Client with HTTPClient.
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Limits bounds the traffic of one Key. Zero fields are unlimited.
+type Limits struct {
+	RequestsPerMinute int
+	TokensPerMinute   int
+	MaxConcurrent     int
+}
+
+// IsZero reports whether l limits nothing.
+func (l Limits) IsZero() bool {
+	return l.RequestsPerMinute <= 0 && l.TokensPerMinute <= 0 && l.MaxConcurrent <= 0
+}
+
+// Key identifies the provider quota a request counts against. Credential is a
+// fingerprint of the API key, never the key itself.
+type Key struct {
+	Provider   string
+	Model      string
+	Credential string
+}
+
+// NewKey builds a Key, replacing apiKey by a short SHA-256 fingerprint.
+func NewKey(provider, model, apiKey string) Key {
+	k := Key{Provider: provider, Model: model}
+	if apiKey != "" {
+		sum := sha256.Sum256([]byte(apiKey))
+		k.Credential = hex.EncodeToString(sum[:8])
+	}
+	return k
+}
+
+// Governor meters the requests of one Key. It is safe for concurrent use.
+type Governor struct {
+	key Key
+	now func() time.Time
+
+	mu           sync.Mutex
+	limits       Limits
+	requests     bucket
+	tokens       bucket
+	inflight     int
+	blockedUntil time.Time
+	changed      chan struct{}
+}
+
+// NewGovernor returns a standalone governor. Most callers use
+// Registry.Governor to share one per key across the process instead.
+func NewGovernor(key Key, limits Limits) *Governor {
+	return newGovernor(key, limits, time.Now)
+}
+
+func newGovernor(key Key, limits Limits, now func() time.Time) *Governor {
+	g := &Governor{key: key, now: now, changed: make(chan struct{})}
+	g.setLimitsLocked(limits)
+	return g
+}
+
+// Key returns the key the governor meters.
+func (g *Governor) Key() Key { return g.key }
+
+// Limits returns the current limits.
+func (g *Governor) Limits() Limits {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.limits
+}
+
+// SetLimits replaces the limits. Requests already admitted are unaffected;
+// waiting requests are re-evaluated against the new limits.
+func (g *Governor) SetLimits(limits Limits) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.limits == limits {
+		return
+	}
+	g.setLimitsLocked(limits)
+	g.broadcastLocked()
+}
+
+func (g *Governor) setLimitsLocked(limits Limits) {
+	now := g.now()
+	g.limits = limits
+	g.requests.resize(float64(limits.RequestsPerMinute), now)
+	g.tokens.resize(float64(limits.TokensPerMinute), now)
+}
+
+// Permit is one admitted request. Release must be called once the request is
+// over; further calls are no-ops.
+type Permit struct {
+	g    *Governor
+	once sync.Once
+}
+
+// Release frees the permit's concurrency slot.
+func (p *Permit) Release() {
+	if p == nil {
+		return
+	}
+	p.once.Do(func() {
+		p.g.mu.Lock()
+		defer p.g.mu.Unlock()
+		p.g.inflight--
+		p.g.broadcastLocked()
+	})
+}
+
+// Acquire blocks until a request estimated at tokens tokens may start, or ctx
+// is done. Estimates larger than the per-minute token budget wait for a full
+// budget rather than forever.
+func (g *Governor) Acquire(ctx context.Context, tokens int) (*Permit, error) {
+	for {
+		g.mu.Lock()
+		wait, ok := g.tryAcquireLocked(tokens)
+		changed := g.changed
+		g.mu.Unlock()
+		if ok {
+			return &Permit{g: g}, nil
+		}
+		if wait > 0 {
+			log.Debug().Str("provider", g.key.Provider).Str("model", g.key.Model).Dur("wait", wait).Msg("rate limit: waiting for budget")
+		}
+		if err := waitFor(ctx, changed, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// tryAcquireLocked admits a request or returns how long to wait. A zero wait
+// with ok=false means waiting for a permit release.
+func (g *Governor) tryAcquireLocked(tokens int) (time.Duration, bool) {
+	now := g.now()
+	if now.Before(g.blockedUntil) {
+		return g.blockedUntil.Sub(now), false
+	}
+	if g.limits.MaxConcurrent > 0 && g.inflight >= g.limits.MaxConcurrent {
+		return 0, false
+	}
+	wait := max(g.requests.wait(1, now), g.tokens.wait(float64(tokens), now))
+	if wait > 0 {
+		return wait, false
+	}
+	g.requests.take(1)
+	g.tokens.take(float64(tokens))
+	g.inflight++
+	return 0, true
+}
+
+// Observe applies provider rate-limit feedback from a response. It returns how
+// long new requests are held back, which is zero when the provider reported
+// spare budget.
+func (g *Governor) Observe(statusCode int, header http.Header) time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	fb := parseFeedback(statusCode, header, now)
+	if fb.remainingRequests >= 0 {
+		g.requests.clamp(float64(fb.remainingRequests), now)
+	}
+	if fb.remainingTokens >= 0 {
+		g.tokens.clamp(float64(fb.remainingTokens), now)
+	}
+	if until := now.Add(fb.backoff); until.After(g.blockedUntil) {
+		g.blockedUntil = until
+		log.Debug().Str("provider", g.key.Provider).Str("model", g.key.Model).Int("status", statusCode).Dur("backoff", fb.backoff).Msg("rate limit: provider requested backoff")
+		g.broadcastLocked()
+	}
+	if now.Before(g.blockedUntil) {
+		return g.blockedUntil.Sub(now)
+	}
+	return 0
+}
+
+func (g *Governor) broadcastLocked() {
+	close(g.changed)
+	g.changed = make(chan struct{})
+}
+
+func waitFor(ctx context.Context, changed <-chan struct{}, wait time.Duration) error {
+	var timeout <-chan time.Time
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-changed:
+	case <-timeout:
+	}
+	return nil
+}
+
+// bucket is a token bucket refilled continuously to capacity once a minute.
+// A zero capacity disables it. The level may go negative when estimates turn
+// out low, which delays later requests accordingly.
+type bucket struct {
+	capacity float64
+	level    float64
+	updated  time.Time
+}
+
+func (b *bucket) resize(capacity float64, now time.Time) {
+	if capacity <= 0 {
+		*b = bucket{}
+		return
+	}
+	if b.capacity == 0 {
+		b.level = capacity
+	} else {
+		b.refill(now)
+		b.level = math.Min(b.level, capacity)
+	}
+	b.capacity = capacity
+	b.updated = now
+}
+
+func (b *bucket) refill(now time.Time) {
+	if b.capacity == 0 {
+		return
+	}
+	elapsed := now.Sub(b.updated)
+	if elapsed > 0 {
+		b.level = math.Min(b.capacity, b.level+b.capacity*elapsed.Minutes())
+		b.updated = now
+	}
+}
+
+func (b *bucket) wait(n float64, now time.Time) time.Duration {
+	if b.capacity == 0 || n <= 0 {
+		return 0
+	}
+	b.refill(now)
+	need := math.Min(n, b.capacity)
+	if b.level >= need {
+		return 0
+	}
+	return time.Duration((need - b.level) / b.capacity * float64(time.Minute))
+}
+
+func (b *bucket) take(n float64) {
+	if b.capacity == 0 || n <= 0 {
+		return
+	}
+	b.level -= n
+}
+
+// clamp lowers the level to a provider-reported remaining budget.
+func (b *bucket) clamp(remaining float64, now time.Time) {
+	if b.capacity == 0 {
+		return
+	}
+	b.refill(now)
+	b.level = math.Min(b.level, remaining)
+}
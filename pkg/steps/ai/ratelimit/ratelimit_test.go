@@ -0,0 +1,146 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newTestGovernor(limits Limits) (*Governor, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	return newGovernor(NewKey("openai", "gpt-4o-mini", "sk-test"), limits, clock.now), clock
+}
+
+func TestGovernorRequestsPerMinute(t *testing.T) {
+	g, clock := newTestGovernor(Limits{RequestsPerMinute: 2})
+	for range 2 {
+		if _, ok := g.tryAcquireLocked(0); !ok {
+			t.Fatalf("request within budget was not admitted")
+		}
+	}
+	wait, ok := g.tryAcquireLocked(0)
+	if ok || wait != 30*time.Second {
+		t.Fatalf("third request: ok=%v wait=%s", ok, wait)
+	}
+	clock.t = clock.t.Add(30 * time.Second)
+	if _, ok := g.tryAcquireLocked(0); !ok {
+		t.Fatalf("request after refill was not admitted")
+	}
+}
+
+func TestGovernorTokensPerMinuteClampsLargeEstimates(t *testing.T) {
+	g, clock := newTestGovernor(Limits{TokensPerMinute: 1000})
+	if _, ok := g.tryAcquireLocked(600); !ok {
+		t.Fatalf("first request not admitted")
+	}
+	wait, ok := g.tryAcquireLocked(600)
+	if ok || wait != 12*time.Second {
+		t.Fatalf("second request: ok=%v wait=%s", ok, wait)
+	}
+	clock.t = clock.t.Add(time.Minute)
+	if _, ok := g.tryAcquireLocked(5000); !ok {
+		t.Fatalf("oversized estimate should run with a full budget")
+	}
+}
+
+func TestGovernorMaxConcurrentWaitsForRelease(t *testing.T) {
+	g := NewGovernor(NewKey("claude", "claude-sonnet", "k"), Limits{MaxConcurrent: 1})
+	first, err := g.Acquire(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := g.Acquire(ctx, 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline while slot is held, got %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		p, err := g.Acquire(context.Background(), 0)
+		if err == nil {
+			p.Release()
+		}
+		done <- err
+	}()
+	first.Release()
+	first.Release()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("second Acquire: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("waiter was not woken by Release")
+	}
+}
+
+func TestGovernorObserveHonorsProviderHeaders(t *testing.T) {
+	g, clock := newTestGovernor(Limits{RequestsPerMinute: 100})
+	h := http.Header{}
+	h.Set("Retry-After", "7")
+	if got := g.Observe(http.StatusTooManyRequests, h); got != 7*time.Second {
+		t.Fatalf("Retry-After backoff = %s", got)
+	}
+	if wait, ok := g.tryAcquireLocked(0); ok || wait != 7*time.Second {
+		t.Fatalf("request during backoff: ok=%v wait=%s", ok, wait)
+	}
+	clock.t = clock.t.Add(7 * time.Second)
+
+	h = http.Header{}
+	h.Set("X-Ratelimit-Remaining-Requests", "0")
+	h.Set("X-Ratelimit-Reset-Requests", "1m2s")
+	if got := g.Observe(http.StatusOK, h); got != 62*time.Second {
+		t.Fatalf("reset backoff = %s", got)
+	}
+	clock.t = clock.t.Add(62 * time.Second)
+
+	h = http.Header{}
+	h.Set("Anthropic-Ratelimit-Tokens-Remaining", "0")
+	h.Set("Anthropic-Ratelimit-Tokens-Reset", clock.t.Add(3*time.Second).Format(time.RFC3339))
+	if got := g.Observe(http.StatusOK, h); got != 3*time.Second {
+		t.Fatalf("anthropic reset backoff = %s", got)
+	}
+}
+
+func TestParseFeedbackClampsRemainingBudget(t *testing.T) {
+	now := time.Now()
+	h := http.Header{}
+	h.Set("X-Ratelimit-Remaining-Requests", "3")
+	h.Set("X-Ratelimit-Remaining-Tokens", "1200")
+	h.Set("Anthropic-Ratelimit-Input-Tokens-Remaining", "900")
+	fb := parseFeedback(http.StatusOK, h, now)
+	if fb.backoff != 0 || fb.remainingRequests != 3 || fb.remainingTokens != 900 {
+		t.Fatalf("feedback = %#v", fb)
+	}
+	if fb := parseFeedback(http.StatusTooManyRequests, http.Header{}, now); fb.backoff != DefaultTooManyRequestsBackoff {
+		t.Fatalf("bare 429 backoff = %s", fb.backoff)
+	}
+	h = http.Header{}
+	h.Set("Retry-After", now.Add(90*time.Second).UTC().Format(http.TimeFormat))
+	if d, ok := RetryAfter(h, now); !ok || d < 89*time.Second || d > 90*time.Second {
+		t.Fatalf("HTTP-date Retry-After = %s %v", d, ok)
+	}
+}
+
+func TestRegistrySharesGovernorPerKey(t *testing.T) {
+	r := NewRegistry()
+	a := r.Governor(NewKey("openai", "m", "k1"), Limits{RequestsPerMinute: 10})
+	b := r.Governor(NewKey("openai", "m", "k1"), Limits{RequestsPerMinute: 20})
+	c := r.Governor(NewKey("openai", "m", "k2"), Limits{RequestsPerMinute: 10})
+	if a != b || a == c {
+		t.Fatalf("registry did not share governors by key")
+	}
+	if a.Limits().RequestsPerMinute != 20 {
+		t.Fatalf("limits were not updated: %#v", a.Limits())
+	}
+	if NewKey("openai", "m", "k1").Credential == "k1" {
+		t.Fatalf("key stores the raw API key")
+	}
+}
@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"sync"
+)
+
+// Registry holds one Governor per Key.
+type Registry struct {
+	mu        sync.Mutex
+	governors map[Key]*Governor
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{governors: map[Key]*Governor{}}
+}
+
+var defaultRegistry = NewRegistry()
+
+// Default returns the process-wide registry used by HTTPClient.
+func Default() *Registry { return defaultRegistry }
+
+// Governor returns the governor for key, creating it with limits on first use.
+// Later calls with different limits update the shared governor, so the most
+// recently configured client wins.
+func (r *Registry) Governor(key Key, limits Limits) *Governor {
+	r.mu.Lock()
+	g, ok := r.governors[key]
+	if !ok {
+		g = NewGovernor(key, limits)
+		r.governors[key] = g
+	}
+	r.mu.Unlock()
+	if ok {
+		g.SetLimits(limits)
+	}
+	return g
+}
+
+// Lookup returns the governor for key if one was created.
+func (r *Registry) Lookup(key Key) (*Governor, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g, ok := r.governors[key]
+	return g, ok
+}
@@ -0,0 +1,155 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	aitransport "github.com/go-go-golems/geppetto/pkg/steps/ai/transport"
+)
+
+// Middleware applies a Policy. AfterResponse classifies a pre-stream failure
+// and asks the core for a replay; the following BeforeRequest reports the
+// attempt and waits for the backoff before the request is sent again.
+type Middleware struct {
+	policy Policy
+	now    func() time.Time
+	random func() float64
+}
+
+var (
+	_ aitransport.Middleware = (*Middleware)(nil)
+	_ aitransport.Replayer   = (*Middleware)(nil)
+)
+
+// NewMiddleware returns a transport middleware applying policy.
+func NewMiddleware(policy Policy) *Middleware {
+	// #nosec G404 -- backoff jitter does not need a cryptographic source.
+	return &Middleware{policy: policy, now: time.Now, random: rand.Float64}
+}
+
+// attempt is the middleware's state for one request attempt. class, statusCode
+// and delay are set when AfterResponse scheduled a retry.
+type attempt struct {
+	number     int
+	class      Class
+	statusCode int
+	delay      time.Duration
+}
+
+// MaxReplays implements transport.Replayer.
+func (m *Middleware) MaxReplays() int {
+	return max(m.policy.MaxAttempts-1, 0)
+}
+
+// BeforeRequest reports and waits for a retry scheduled by the previous
+// attempt. Replays requested by other middleware, such as a credential
+// refresh, are counted but not delayed.
+func (m *Middleware) BeforeRequest(ctx context.Context, request aitransport.RequestContext, previous aitransport.Attempt, _ aitransport.HeaderWriter) (aitransport.Attempt, error) {
+	prior, ok := previous.(*attempt)
+	if !ok {
+		return &attempt{number: 1}, nil
+	}
+	next := &attempt{number: prior.number + 1}
+	if prior.class == "" {
+		return next, nil
+	}
+	report := Attempt{
+		Provider:    request.Provider(),
+		Operation:   request.Operation(),
+		Number:      next.number,
+		MaxAttempts: m.policy.MaxAttempts,
+		StatusCode:  prior.statusCode,
+		Class:       prior.class,
+		Delay:       prior.delay,
+	}
+	log.Debug().
+		Str("provider", report.Provider).
+		Int("attempt", report.Number).
+		Int("status", report.StatusCode).
+		Str("class", string(report.Class)).
+		Dur("delay", report.Delay).
+		Msg("retry: replaying provider request")
+	if reporter := ReporterFromContext(ctx); reporter != nil {
+		reporter(ctx, report)
+	}
+	if err := sleep(ctx, prior.delay); err != nil {
+		return nil, fmt.Errorf("wait before retrying %s request: %w", request.Provider(), err)
+	}
+	return next, nil
+}
+
+// AfterResponse requests a replay for a retryable status while attempts remain.
+func (m *Middleware) AfterResponse(_ context.Context, _ aitransport.RequestContext, current aitransport.Attempt, response aitransport.ResponseMetadata) (aitransport.ResponseDecision, error) {
+	state, ok := current.(*attempt)
+	if !ok || !response.RetryEligible || response.StreamStarted || state.number >= m.policy.MaxAttempts {
+		return aitransport.Continue, nil
+	}
+	class, ok := ClassifyStatus(response.StatusCode)
+	if !ok || !m.policy.Retries(class) {
+		return aitransport.Continue, nil
+	}
+	delay, ok := m.policy.Delay(state.number, response.Header, m.now(), m.random())
+	if !ok {
+		return aitransport.Continue, nil
+	}
+	state.class, state.statusCode, state.delay = class, response.StatusCode, delay
+	return aitransport.Retry, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Attempt describes a replay scheduled by the policy. Number is the 1-based
+// number of the attempt about to run; StatusCode and Class describe the
+// failure of the previous one.
+type Attempt struct {
+	Provider    string
+	Operation   string
+	Number      int
+	MaxAttempts int
+	StatusCode  int
+	Class       Class
+	Delay       time.Duration
+}
+
+// Reporter receives every scheduled replay before its delay starts.
+type Reporter func(context.Context, Attempt)
+
+type reporterKey struct{}
+
+// WithReporter returns a context whose provider requests report replays to r.
+func WithReporter(ctx context.Context, r Reporter) context.Context {
+	return context.WithValue(ctx, reporterKey{}, r)
+}
+
+// ReporterFromContext returns the reporter installed by WithReporter, or nil.
+func ReporterFromContext(ctx context.Context) Reporter {
+	r, _ := ctx.Value(reporterKey{}).(Reporter)
+	return r
+}
+
+// WithEventReporter reports replays as provider-call-retry events carrying
+// metadata and corr, delivered through publish. Engines pass their event
+// publisher, so the events also produce observability records.
+func WithEventReporter(ctx context.Context, metadata events.EventMetadata, corr events.Correlation, publish func(context.Context, events.Event)) context.Context {
+	if publish == nil {
+		return ctx
+	}
+	return WithReporter(ctx, func(ctx context.Context, a Attempt) {
+		publish(ctx, events.NewProviderCallRetryEvent(metadata, corr, a.Number, a.MaxAttempts, a.StatusCode, string(a.Class), a.Delay.Milliseconds()))
+	})
+}
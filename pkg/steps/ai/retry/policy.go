@@ -0,0 +1,180 @@
+// Package retry replays provider requests that fail with a transient error
+// before their response stream starts. A Policy bounds the number of attempts,
+// spaces them with exponential backoff and jitter, honors Retry-After, and
+// selects which error classes are retried.
+//
+// The policy is installed as a transport.Middleware, so every engine applies
+// it the same way: engines that own their request loop add it to their chain,
+// the others reach the provider through an http.Client wrapped with
+// transport.NewRoundTripper. Each replay is reported through the Reporter on
+// the request context, which engines connect to a provider-call-retry event.
+package retry
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/ratelimit"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+)
+
+// Class groups transient provider failures. The values match the error
+// classes used by the fallback engine.
+type Class string
+
+const (
+	// ClassRateLimited is a 429 Too Many Requests.
+	ClassRateLimited Class = "rate_limited"
+	// ClassOverloaded is a 529 (Anthropic overloaded_error) or a 503.
+	ClassOverloaded Class = "overloaded"
+	// ClassServerError is any other 5xx status.
+	ClassServerError Class = "server_error"
+	// ClassTimeout is a 408 Request Timeout or a 504 Gateway Timeout.
+	ClassTimeout Class = "timeout"
+)
+
+// StatusOverloaded is the non-standard status Anthropic returns when the API
+// is temporarily overloaded.
+const StatusOverloaded = 529
+
+// Classes lists every retryable class.
+var Classes = []Class{ClassRateLimited, ClassOverloaded, ClassServerError, ClassTimeout}
+
+// ClassifyStatus returns the retry class of an HTTP status, or false when the
+// status is not transient.
+func ClassifyStatus(statusCode int) (Class, bool) {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return ClassRateLimited, true
+	case statusCode == StatusOverloaded, statusCode == http.StatusServiceUnavailable:
+		return ClassOverloaded, true
+	case statusCode == http.StatusRequestTimeout, statusCode == http.StatusGatewayTimeout:
+		return ClassTimeout, true
+	case statusCode >= 500 && statusCode <= 599:
+		return ClassServerError, true
+	default:
+		return "", false
+	}
+}
+
+const (
+	DefaultInitialBackoff = 500 * time.Millisecond
+	DefaultMaxBackoff     = 30 * time.Second
+	DefaultMultiplier     = 2.0
+	DefaultJitter         = 0.2
+)
+
+// Policy configures retries. MaxAttempts counts the initial request, so values
+// of one or less disable retries. An empty Classes retries every class.
+type Policy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	// MaxBackoff caps one delay. A Retry-After longer than MaxBackoff is not
+	// waited for and the failure is returned instead.
+	MaxBackoff time.Duration
+	Multiplier float64
+	// Jitter spreads each delay uniformly by ±Jitter of its value.
+	Jitter  float64
+	Classes []Class
+}
+
+// Enabled reports whether p allows any retry.
+func (p Policy) Enabled() bool { return p.MaxAttempts > 1 }
+
+// Retries reports whether p retries failures of class c.
+func (p Policy) Retries(c Class) bool {
+	if len(p.Classes) == 0 {
+		return true
+	}
+	for _, class := range p.Classes {
+		if class == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Backoff returns the delay after the failed attempt with 1-based number
+// failed. random is a uniform sample in [0, 1) used for jitter.
+func (p Policy) Backoff(failed int, random float64) time.Duration {
+	initial, maxBackoff := p.InitialBackoff, p.MaxBackoff
+	if initial <= 0 {
+		initial = DefaultInitialBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = DefaultMultiplier
+	}
+	delay := float64(initial) * math.Pow(multiplier, float64(max(failed-1, 0)))
+	if p.Jitter > 0 {
+		delay *= 1 + p.Jitter*(2*random-1)
+	}
+	return time.Duration(min(delay, float64(maxBackoff)))
+}
+
+// Delay returns the wait before replaying after the failed attempt with 1-based
+// number failed and response header header. A Retry-After hint raises the
+// backoff; one beyond MaxBackoff returns false.
+func (p Policy) Delay(failed int, header http.Header, now time.Time, random float64) (time.Duration, bool) {
+	delay := p.Backoff(failed, random)
+	if header == nil {
+		return delay, true
+	}
+	retryAfter, ok := ratelimit.RetryAfter(header, now)
+	if !ok {
+		return delay, true
+	}
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+	if retryAfter > maxBackoff {
+		return 0, false
+	}
+	return max(delay, retryAfter), true
+}
+
+// PolicyFromClientSettings reads the retry fields of cs. Unknown classes in
+// RetryOn are an error.
+func PolicyFromClientSettings(cs *settings.ClientSettings) (Policy, error) {
+	policy := Policy{
+		MaxAttempts:    1,
+		InitialBackoff: DefaultInitialBackoff,
+		MaxBackoff:     DefaultMaxBackoff,
+		Multiplier:     DefaultMultiplier,
+		Jitter:         DefaultJitter,
+	}
+	if cs == nil {
+		return policy, nil
+	}
+	if cs.RetryMaxAttempts != nil && *cs.RetryMaxAttempts > 1 {
+		policy.MaxAttempts = *cs.RetryMaxAttempts
+	}
+	if cs.RetryInitialBackoffMs != nil && *cs.RetryInitialBackoffMs > 0 {
+		policy.InitialBackoff = time.Duration(*cs.RetryInitialBackoffMs) * time.Millisecond
+	}
+	if cs.RetryMaxBackoffMs != nil && *cs.RetryMaxBackoffMs > 0 {
+		policy.MaxBackoff = time.Duration(*cs.RetryMaxBackoffMs) * time.Millisecond
+	}
+	for _, raw := range cs.RetryOn {
+		class := Class(strings.TrimSpace(raw))
+		if class == "" {
+			continue
+		}
+		known := false
+		for _, c := range Classes {
+			known = known || c == class
+		}
+		if !known {
+			return Policy{}, fmt.Errorf("unknown retry class %q (expected one of rate_limited, overloaded, server_error, timeout)", raw)
+		}
+		policy.Classes = append(policy.Classes, class)
+	}
+	return policy, nil
+}
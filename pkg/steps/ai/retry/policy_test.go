@@ -0,0 +1,89 @@
+package retry
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+)
+
+func TestClassifyStatus(t *testing.T) {
+	for status, want := range map[int]Class{
+		http.StatusTooManyRequests:     ClassRateLimited,
+		StatusOverloaded:               ClassOverloaded,
+		http.StatusServiceUnavailable:  ClassOverloaded,
+		http.StatusInternalServerError: ClassServerError,
+		http.StatusBadGateway:          ClassServerError,
+		http.StatusGatewayTimeout:      ClassTimeout,
+		http.StatusRequestTimeout:      ClassTimeout,
+	} {
+		if got, ok := ClassifyStatus(status); !ok || got != want {
+			t.Fatalf("ClassifyStatus(%d) = %q %v, want %q", status, got, ok, want)
+		}
+	}
+	for _, status := range []int{http.StatusOK, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound} {
+		if got, ok := ClassifyStatus(status); ok {
+			t.Fatalf("ClassifyStatus(%d) = %q, want not retryable", status, got)
+		}
+	}
+}
+
+func TestPolicyBackoffGrowsWithJitterAndCap(t *testing.T) {
+	p := Policy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2, Jitter: 0.5}
+	if got := p.Backoff(1, 0.5); got != 100*time.Millisecond {
+		t.Fatalf("first backoff = %s", got)
+	}
+	if got := p.Backoff(3, 0.5); got != 400*time.Millisecond {
+		t.Fatalf("third backoff = %s", got)
+	}
+	if lo, hi := p.Backoff(2, 0), p.Backoff(2, 0.999); lo != 100*time.Millisecond || hi < 299*time.Millisecond || hi > 300*time.Millisecond {
+		t.Fatalf("jitter range = [%s, %s]", lo, hi)
+	}
+	if got := p.Backoff(10, 0.999); got != time.Second {
+		t.Fatalf("capped backoff = %s", got)
+	}
+}
+
+func TestPolicyDelayHonorsRetryAfter(t *testing.T) {
+	p := Policy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 5 * time.Second, Multiplier: 2}
+	now := time.Now()
+	h := http.Header{}
+	h.Set("Retry-After", "2")
+	if d, ok := p.Delay(1, h, now, 0); !ok || d != 2*time.Second {
+		t.Fatalf("Retry-After delay = %s %v", d, ok)
+	}
+	h.Set("Retry-After", "60")
+	if _, ok := p.Delay(1, h, now, 0); ok {
+		t.Fatalf("Retry-After beyond MaxBackoff should not be waited for")
+	}
+	if d, ok := p.Delay(2, http.Header{}, now, 0); !ok || d != 200*time.Millisecond {
+		t.Fatalf("delay without hint = %s %v", d, ok)
+	}
+}
+
+func TestPolicyFromClientSettings(t *testing.T) {
+	p, err := PolicyFromClientSettings(nil)
+	if err != nil || p.Enabled() {
+		t.Fatalf("nil settings: %#v %v", p, err)
+	}
+	attempts, initial, maxBackoff := 4, 250, 2000
+	p, err = PolicyFromClientSettings(&settings.ClientSettings{
+		RetryMaxAttempts:      &attempts,
+		RetryInitialBackoffMs: &initial,
+		RetryMaxBackoffMs:     &maxBackoff,
+		RetryOn:               []string{"overloaded", " rate_limited "},
+	})
+	if err != nil {
+		t.Fatalf("PolicyFromClientSettings: %v", err)
+	}
+	if !p.Enabled() || p.MaxAttempts != 4 || p.InitialBackoff != 250*time.Millisecond || p.MaxBackoff != 2*time.Second {
+		t.Fatalf("policy = %#v", p)
+	}
+	if !p.Retries(ClassRateLimited) || p.Retries(ClassServerError) {
+		t.Fatalf("classes = %v", p.Classes)
+	}
+	if _, err := PolicyFromClientSettings(&settings.ClientSettings{RetryOn: []string{"bad_request"}}); err == nil {
+		t.Fatalf("unknown class accepted")
+	}
+}
@@ -2,6 +2,7 @@ package settings
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/go-go-golems/geppetto/pkg/turns"
 )
@@ -134,15 +135,33 @@ func (m *ModelInfo) ComputeCost(usage *turns.InferenceUsage) *float64 {
 	return &total
 }
 
+// ApplyModelInfoCost sets result.Cost from info's pricing and result.Usage.
+// Anthropic reports input_tokens without the cache read/write buckets, so for
+// Claude results those buckets are added back before pricing.
 func ApplyModelInfoCost(result *turns.InferenceResult, info *ModelInfo) {
 	if result == nil || info == nil || result.Usage == nil {
 		return
 	}
-	if cost := info.ComputeCost(result.Usage); cost != nil {
+	usage := result.Usage
+	if inputExcludesCacheTokens(result.Provider) {
+		normalized := *usage
+		normalized.InputTokens += usage.CacheReadInputTokens + usage.CacheCreationInputTokens
+		usage = &normalized
+	}
+	if cost := info.ComputeCost(usage); cost != nil {
 		result.Cost = cost
 	}
 }
 
+func inputExcludesCacheTokens(provider string) bool {
+	switch strings.ToLower(strings.TrimSpace(provider)) {
+	case "claude", "anthropic":
+		return true
+	default:
+		return false
+	}
+}
+
 func MergeModelInfo(base, overlay *ModelInfo) *ModelInfo {
 	if base == nil {
 		return overlay.Clone()
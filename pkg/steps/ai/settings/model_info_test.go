@@ -159,3 +159,27 @@ func TestModelInfoComputeCost_UsesCachedTokensFallback(t *testing.T) {
 		t.Fatalf("cost = %f, want %f", *cost, want)
 	}
 }
+
+func TestApplyModelInfoCost_ClaudeInputExcludesCacheBuckets(t *testing.T) {
+	mi := &ModelInfo{Cost: &ModelCost{Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75}}
+	result := turns.InferenceResult{
+		Provider: "claude",
+		Usage: &turns.InferenceUsage{
+			InputTokens:              1_000,
+			OutputTokens:             500,
+			CacheReadInputTokens:     20_000,
+			CacheCreationInputTokens: 2_000,
+		},
+	}
+	ApplyModelInfoCost(&result, mi)
+	if result.Cost == nil {
+		t.Fatal("expected cost")
+	}
+	want := 3*1000.0/1_000_000 + 15*500.0/1_000_000 + 0.3*20000.0/1_000_000 + 3.75*2000.0/1_000_000
+	if math.Abs(*result.Cost-want) > 1e-12 {
+		t.Fatalf("cost = %f, want %f", *result.Cost, want)
+	}
+	if result.Usage.InputTokens != 1_000 {
+		t.Fatalf("usage must not be modified, got %d input tokens", result.Usage.InputTokens)
+	}
+}
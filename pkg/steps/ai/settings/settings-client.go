@@ -19,7 +19,20 @@ type ClientSettings struct {
 	UserAgent            *string        `yaml:"user_agent,omitempty" glazed:"user-agent"`
 	ProxyURL             *string        `yaml:"proxy_url,omitempty" glazed:"proxy-url"`
 	ProxyFromEnvironment *bool          `yaml:"proxy_from_environment,omitempty" glazed:"proxy-from-environment"`
-	HTTPClient           *http.Client   `yaml:"-" json:"-"`
+	// RequestsPerMinute, TokensPerMinute and MaxConcurrentRequests configure the
+	// process-wide rate limiter shared by every client with the same provider,
+	// model and API key. Unset or zero values leave that dimension unlimited.
+	RequestsPerMinute     *int `yaml:"requests_per_minute,omitempty" glazed:"requests-per-minute"`
+	TokensPerMinute       *int `yaml:"tokens_per_minute,omitempty" glazed:"tokens-per-minute"`
+	MaxConcurrentRequests *int `yaml:"max_concurrent_requests,omitempty" glazed:"max-concurrent-requests"`
+	// RetryMaxAttempts, RetryInitialBackoffMs, RetryMaxBackoffMs and RetryOn
+	// configure the retry policy applied to provider requests that fail with a
+	// transient status before streaming starts. Unset or 1 attempt disables it.
+	RetryMaxAttempts      *int         `yaml:"retry_max_attempts,omitempty" glazed:"retry-max-attempts"`
+	RetryInitialBackoffMs *int         `yaml:"retry_initial_backoff_ms,omitempty" glazed:"retry-initial-backoff-ms"`
+	RetryMaxBackoffMs     *int         `yaml:"retry_max_backoff_ms,omitempty" glazed:"retry-max-backoff-ms"`
+	RetryOn               []string     `yaml:"retry_on,omitempty" glazed:"retry-on"`
+	HTTPClient            *http.Client `yaml:"-" json:"-"`
 }
 
 //go:embed "flags/client.yaml"
@@ -41,11 +54,18 @@ func NewClientValueSection(options ...schema.SectionOption) (*ClientValueSection
 // UnmarshalYAML overrides YAML parsing to convert time.duration from int
 func (cs *ClientSettings) UnmarshalYAML(value *yaml.Node) error {
 	aux := &struct {
-		Timeout              *int    `yaml:"timeout,omitempty"`
-		Organization         *string `yaml:"organization,omitempty"`
-		UserAgent            *string `yaml:"user_agent,omitempty"`
-		ProxyURL             *string `yaml:"proxy_url,omitempty"`
-		ProxyFromEnvironment *bool   `yaml:"proxy_from_environment,omitempty"`
+		Timeout               *int     `yaml:"timeout,omitempty"`
+		Organization          *string  `yaml:"organization,omitempty"`
+		UserAgent             *string  `yaml:"user_agent,omitempty"`
+		ProxyURL              *string  `yaml:"proxy_url,omitempty"`
+		ProxyFromEnvironment  *bool    `yaml:"proxy_from_environment,omitempty"`
+		RequestsPerMinute     *int     `yaml:"requests_per_minute,omitempty"`
+		TokensPerMinute       *int     `yaml:"tokens_per_minute,omitempty"`
+		MaxConcurrentRequests *int     `yaml:"max_concurrent_requests,omitempty"`
+		RetryMaxAttempts      *int     `yaml:"retry_max_attempts,omitempty"`
+		RetryInitialBackoffMs *int     `yaml:"retry_initial_backoff_ms,omitempty"`
+		RetryMaxBackoffMs     *int     `yaml:"retry_max_backoff_ms,omitempty"`
+		RetryOn               []string `yaml:"retry_on,omitempty"`
 	}{}
 	if err := value.Decode(aux); err != nil {
 		return err
@@ -54,6 +74,13 @@ func (cs *ClientSettings) UnmarshalYAML(value *yaml.Node) error {
 	cs.UserAgent = aux.UserAgent
 	cs.ProxyURL = aux.ProxyURL
 	cs.ProxyFromEnvironment = aux.ProxyFromEnvironment
+	cs.RequestsPerMinute = aux.RequestsPerMinute
+	cs.TokensPerMinute = aux.TokensPerMinute
+	cs.MaxConcurrentRequests = aux.MaxConcurrentRequests
+	cs.RetryMaxAttempts = aux.RetryMaxAttempts
+	cs.RetryInitialBackoffMs = aux.RetryInitialBackoffMs
+	cs.RetryMaxBackoffMs = aux.RetryMaxBackoffMs
+	cs.RetryOn = aux.RetryOn
 	if aux.Timeout != nil {
 		t := time.Duration(*aux.Timeout) * time.Second
 		cs.Timeout = &t
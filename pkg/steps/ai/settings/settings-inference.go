@@ -346,6 +346,9 @@ func (ss *InferenceSettings) GetMetadata() map[string]interface{} {
 		if ss.Embeddings.Dimensions != 0 {
 			metadata["embeddings-dimensions"] = ss.Embeddings.Dimensions
 		}
+		if ss.Embeddings.InputType != "" {
+			metadata["embeddings-input-type"] = ss.Embeddings.InputType
+		}
 	}
 
 	if ss.Rerank != nil {
@@ -361,6 +364,18 @@ func (ss *InferenceSettings) GetMetadata() map[string]interface{} {
 		if ss.Rerank.MaxResponseBytes != 0 {
 			metadata["rerank-max-response-bytes"] = ss.Rerank.MaxResponseBytes
 		}
+		if ss.Rerank.LLMMode != "" {
+			metadata["rerank-llm-mode"] = ss.Rerank.LLMMode
+		}
+		if ss.Rerank.LLMBatchSize != 0 {
+			metadata["rerank-llm-batch-size"] = ss.Rerank.LLMBatchSize
+		}
+		if ss.Rerank.LLMMaxConcurrency != 0 {
+			metadata["rerank-llm-max-concurrency"] = ss.Rerank.LLMMaxConcurrency
+		}
+		if ss.Rerank.LLMNormalization != "" {
+			metadata["rerank-llm-normalization"] = ss.Rerank.LLMNormalization
+		}
 	}
 
 	return metadata
@@ -433,6 +448,11 @@ func (ss *InferenceSettings) UpdateFromParsedValues(parsedValues *values.Values)
 		return err
 	}
 
+	err = parsedValues.DecodeSectionInto(ollama.OllamaChatSlug, ss.Ollama)
+	if err != nil {
+		return err
+	}
+
 	err = parsedValues.DecodeSectionInto(config.EmbeddingsSlug, ss.Embeddings)
 	if err != nil {
 		return err
@@ -456,6 +476,7 @@ func (ss *InferenceSettings) UpdateFromParsedValues(parsedValues *values.Values)
 		openai.OpenAiChatSlug,
 		claude.ClaudeChatSlug,
 		gemini.GeminiChatSlug,
+		ollama.OllamaChatSlug,
 		config.EmbeddingsSlug,
 		rerankconfig.RerankSlug,
 	}
@@ -573,6 +594,18 @@ func (ss *InferenceSettings) GetSummary(verbose bool) string {
 		if ss.Client.UserAgent != nil {
 			fmt.Fprintf(&summary, "  - User Agent: %s\n", *ss.Client.UserAgent)
 		}
+		if ss.Client.RequestsPerMinute != nil && *ss.Client.RequestsPerMinute > 0 {
+			fmt.Fprintf(&summary, "  - Requests/min: %d\n", *ss.Client.RequestsPerMinute)
+		}
+		if ss.Client.TokensPerMinute != nil && *ss.Client.TokensPerMinute > 0 {
+			fmt.Fprintf(&summary, "  - Tokens/min: %d\n", *ss.Client.TokensPerMinute)
+		}
+		if ss.Client.MaxConcurrentRequests != nil && *ss.Client.MaxConcurrentRequests > 0 {
+			fmt.Fprintf(&summary, "  - Max concurrent requests: %d\n", *ss.Client.MaxConcurrentRequests)
+		}
+		if ss.Client.RetryMaxAttempts != nil && *ss.Client.RetryMaxAttempts > 1 {
+			fmt.Fprintf(&summary, "  - Retry attempts: %d\n", *ss.Client.RetryMaxAttempts)
+		}
 	}
 
 	// Claude Settings
@@ -654,6 +687,9 @@ func (ss *InferenceSettings) GetSummary(verbose bool) string {
 		if ss.Embeddings.Dimensions != 0 {
 			fmt.Fprintf(&summary, "  - Dimensions: %d\n", ss.Embeddings.Dimensions)
 		}
+		if ss.Embeddings.InputType != "" {
+			fmt.Fprintf(&summary, "  - Input Type: %s\n", ss.Embeddings.InputType)
+		}
 
 		// Embeddings Cache Settings
 		if ss.Embeddings.CacheType != "" {
@@ -685,6 +721,18 @@ func (ss *InferenceSettings) GetSummary(verbose bool) string {
 		if ss.Rerank.MaxResponseBytes != 0 {
 			fmt.Fprintf(&summary, "  - Max Response Bytes: %d\n", ss.Rerank.MaxResponseBytes)
 		}
+		if ss.Rerank.LLMMode != "" {
+			fmt.Fprintf(&summary, "  - LLM Mode: %s\n", ss.Rerank.LLMMode)
+		}
+		if ss.Rerank.LLMBatchSize != 0 {
+			fmt.Fprintf(&summary, "  - LLM Batch Size: %d\n", ss.Rerank.LLMBatchSize)
+		}
+		if ss.Rerank.LLMMaxConcurrency != 0 {
+			fmt.Fprintf(&summary, "  - LLM Max Concurrency: %d\n", ss.Rerank.LLMMaxConcurrency)
+		}
+		if ss.Rerank.LLMNormalization != "" {
+			fmt.Fprintf(&summary, "  - LLM Normalization: %s\n", ss.Rerank.LLMNormalization)
+		}
 	}
 
 	return summary.String()
@@ -0,0 +1,100 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// RoundTripper runs a middleware chain around every request of an
+// http.Client. Engines that reach providers through an SDK or a generic client
+// use it to share middleware with engines that own their request loop. The
+// request context's operation is the last element of the request path.
+//
+// A Retry decision replays the request up to Chain.MaxReplays times, and only
+// when its body can be recreated through http.Request.GetBody.
+type RoundTripper struct {
+	base     http.RoundTripper
+	provider string
+	chain    *Chain
+	rules    []HeaderRule
+}
+
+var _ http.RoundTripper = (*RoundTripper)(nil)
+
+// NewRoundTripper wraps base, or http.DefaultTransport when base is nil.
+// rules declare the headers middleware may set.
+func NewRoundTripper(base http.RoundTripper, provider string, chain *Chain, rules ...HeaderRule) (*RoundTripper, error) {
+	if strings.TrimSpace(provider) == "" {
+		return nil, errors.New("transport provider is required")
+	}
+	if chain == nil {
+		return nil, errors.New("nil transport middleware chain")
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &RoundTripper{
+		base:     base,
+		provider: provider,
+		chain:    chain,
+		rules:    append([]HeaderRule(nil), rules...),
+	}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	request := RequestContext{provider: t.provider, operation: path.Base(req.URL.Path), url: *req.URL}
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	maxReplays := t.chain.MaxReplays()
+
+	var previous AttemptState
+	for attempt := 0; ; attempt++ {
+		outgoing := req.Clone(ctx)
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("replay request body: %w", err)
+			}
+			outgoing.Body = body
+		}
+		headers, err := NewHeaderSet(outgoing.Header, t.rules...)
+		if err != nil {
+			return nil, err
+		}
+		attempts, err := t.chain.BeforeRequest(ctx, request, previous, headers)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := t.base.RoundTrip(outgoing)
+		if err != nil {
+			t.chain.Complete(ctx, request, attempts)
+			return nil, err
+		}
+		retryEligible := attempt < maxReplays && replayable
+		decision, err := t.chain.AfterResponse(ctx, request, attempts, ResponseMetadata{
+			StatusCode:    resp.StatusCode,
+			Header:        resp.Header.Clone(),
+			RetryEligible: retryEligible,
+		})
+		if err != nil {
+			_ = resp.Body.Close()
+			t.chain.Complete(ctx, request, attempts)
+			return nil, err
+		}
+		if decision == Retry && retryEligible {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			_ = resp.Body.Close()
+			t.chain.Complete(ctx, request, attempts)
+			previous = attempts
+			continue
+		}
+		resp.Body = t.chain.CompleteOnClose(ctx, request, attempts, resp.Body)
+		return resp, nil
+	}
+}
@@ -0,0 +1,138 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+type completingMiddleware struct {
+	middlewareFunc
+	completed []Attempt
+}
+
+func (m *completingMiddleware) Complete(_ context.Context, _ RequestContext, attempt Attempt) {
+	m.completed = append(m.completed, attempt)
+}
+
+func TestRoundTripper_ReplaysOnceAndCompletesEveryAttempt(t *testing.T) {
+	var bodies []string
+	var statuses []int
+	base := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		rec := httptest.NewRecorder()
+		if len(bodies) == 1 {
+			rec.Header().Set("Retry-After", "0")
+			rec.WriteHeader(http.StatusTooManyRequests)
+		} else {
+			if got := r.Header.Get("X-Attempt"); got != "2" {
+				t.Errorf("replay header = %q", got)
+			}
+			_, _ = io.WriteString(rec, "ok")
+		}
+		return rec.Result(), nil
+	})
+	middleware := &completingMiddleware{middlewareFunc: middlewareFunc{
+		before: func(_ context.Context, request RequestContext, previous Attempt, headers HeaderWriter) (Attempt, error) {
+			if request.Provider() != "test" || request.Operation() != "embeddings" {
+				t.Errorf("request context = %q %q", request.Provider(), request.Operation())
+			}
+			n := 1
+			if previous != nil {
+				n = previous.(int) + 1
+			}
+			return n, headers.Set("X-Attempt", strconv.Itoa(n))
+		},
+		after: func(_ context.Context, _ RequestContext, _ Attempt, response ResponseMetadata) (ResponseDecision, error) {
+			statuses = append(statuses, response.StatusCode)
+			if response.StatusCode == http.StatusTooManyRequests && response.Header.Get("Retry-After") == "0" && response.RetryEligible {
+				return Retry, nil
+			}
+			return Continue, nil
+		},
+	}}
+	chain, err := NewChain(middleware)
+	if err != nil {
+		t.Fatalf("NewChain: %v", err)
+	}
+	rt, err := NewRoundTripper(base, "test", chain, HeaderRule{Name: "X-Attempt"})
+	if err != nil {
+		t.Fatalf("NewRoundTripper: %v", err)
+	}
+	client := &http.Client{Transport: rt}
+	resp, err := client.Post("https://example.test/v1/embeddings", "application/json", strings.NewReader(`{"input":"hi"}`))
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	if len(middleware.completed) != 1 {
+		t.Fatalf("completed before close = %v", middleware.completed)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	_ = resp.Body.Close()
+	if string(b) != "ok" || resp.StatusCode != http.StatusOK {
+		t.Fatalf("response = %d %q", resp.StatusCode, b)
+	}
+	if len(bodies) != 2 || bodies[1] != `{"input":"hi"}` {
+		t.Fatalf("bodies = %q", bodies)
+	}
+	if len(statuses) != 2 || len(middleware.completed) != 2 || middleware.completed[1] != 2 {
+		t.Fatalf("statuses = %v completed = %v", statuses, middleware.completed)
+	}
+}
+
+type replayingMiddleware struct {
+	middlewareFunc
+	replays int
+}
+
+func (m replayingMiddleware) MaxReplays() int { return m.replays }
+
+func TestRoundTripper_ReplayerRaisesReplayBudget(t *testing.T) {
+	calls := 0
+	base := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		rec := httptest.NewRecorder()
+		rec.WriteHeader(http.StatusServiceUnavailable)
+		return rec.Result(), nil
+	})
+	var eligible []bool
+	middleware := replayingMiddleware{replays: 3, middlewareFunc: middlewareFunc{
+		before: func(context.Context, RequestContext, Attempt, HeaderWriter) (Attempt, error) { return nil, nil },
+		after: func(_ context.Context, _ RequestContext, _ Attempt, response ResponseMetadata) (ResponseDecision, error) {
+			eligible = append(eligible, response.RetryEligible)
+			return Retry, nil
+		},
+	}}
+	chain, err := NewChain(middleware)
+	if err != nil {
+		t.Fatalf("NewChain: %v", err)
+	}
+	if chain.MaxReplays() != 3 {
+		t.Fatalf("MaxReplays = %d", chain.MaxReplays())
+	}
+	rt, err := NewRoundTripper(base, "test", chain)
+	if err != nil {
+		t.Fatalf("NewRoundTripper: %v", err)
+	}
+	resp, err := (&http.Client{Transport: rt}).Get("https://example.test/v1/models")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || calls != 4 {
+		t.Fatalf("status = %d calls = %d", resp.StatusCode, calls)
+	}
+	if len(eligible) != 4 || !eligible[2] || eligible[3] {
+		t.Fatalf("retry eligibility = %v", eligible)
+	}
+}
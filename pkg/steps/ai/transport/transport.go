@@ -8,9 +8,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 )
 
 // RouteRequest is the non-secret input supplied to a trusted provider route
@@ -205,21 +207,26 @@ type AttemptState struct {
 }
 
 // ResponseMetadata is the bounded, body-free response information available to
-// middleware before the engine starts decoding output.
+// middleware before the engine starts decoding output. Header is a copy of the
+// response headers, so middleware can read provider hints such as Retry-After
+// or x-ratelimit-* without touching the live response.
 type ResponseMetadata struct {
 	StatusCode    int
+	Header        http.Header
 	StreamStarted bool
 	RetryEligible bool
 }
 
 // ResponseDecision directs the engine's response handling. The engine, not
 // middleware, enforces whether a retry is eligible and closes any response body.
+// RetryEligible is true while the chain's replay budget (see Chain.MaxReplays)
+// is not exhausted.
 type ResponseDecision uint8
 
 const (
 	// Continue tells the core to decode or report the current response.
 	Continue ResponseDecision = iota
-	// Retry requests one core-governed pre-stream replay.
+	// Retry requests a core-governed pre-stream replay.
 	Retry
 )
 
@@ -233,9 +240,24 @@ type Middleware interface {
 	AfterResponse(context.Context, RequestContext, Attempt, ResponseMetadata) (ResponseDecision, error)
 }
 
+// Completer is implemented by middleware that holds a resource for the lifetime
+// of a request, such as a concurrency slot. Complete runs exactly once per
+// BeforeRequest, after the response body is closed, the attempt is replayed, or
+// the request failed.
+type Completer interface {
+	Complete(context.Context, RequestContext, Attempt)
+}
+
+// Replayer is implemented by middleware that may request more than one replay
+// of the same request, such as a retry policy. MaxReplays is the number of
+// replays it needs after the initial attempt.
+type Replayer interface {
+	MaxReplays() int
+}
+
 // Chain applies request middleware in registration order and response
-// middleware in reverse order, like nested middleware. It leaves retry bounds
-// to the engine core.
+// middleware in reverse order, like nested middleware. The engine core bounds
+// replays with MaxReplays.
 type Chain struct {
 	middlewares []Middleware
 }
@@ -254,9 +276,25 @@ func NewChain(middlewares ...Middleware) (*Chain, error) {
 	return chain, nil
 }
 
+// MaxReplays returns how many replays of one request the core may perform: one,
+// or the largest budget declared by a Replayer middleware.
+func (c *Chain) MaxReplays() int {
+	replays := 1
+	if c == nil {
+		return replays
+	}
+	for _, middleware := range c.middlewares {
+		if replayer, ok := middleware.(Replayer); ok {
+			replays = max(replays, replayer.MaxReplays())
+		}
+	}
+	return replays
+}
+
 // BeforeRequest applies middleware in registration order and returns opaque
 // values for the matching AfterResponse call. previous must be empty for an
-// initial request or have exactly one attempt per middleware for a replay.
+// initial request or have exactly one attempt per middleware for a replay. When
+// a middleware fails, the ones before it are completed.
 func (c *Chain) BeforeRequest(ctx context.Context, request RequestContext, previous AttemptState, headers HeaderWriter) (AttemptState, error) {
 	if c == nil {
 		return AttemptState{}, errors.New("nil transport middleware chain")
@@ -275,6 +313,7 @@ func (c *Chain) BeforeRequest(ctx context.Context, request RequestContext, previ
 		}
 		attempt, err := middleware.BeforeRequest(ctx, request, prior, headers)
 		if err != nil {
+			c.completePartial(ctx, request, attempts)
 			return AttemptState{}, err
 		}
 		attempts = append(attempts, attempt)
@@ -307,3 +346,40 @@ func (c *Chain) AfterResponse(ctx context.Context, request RequestContext, attem
 	}
 	return decision, nil
 }
+
+// Complete notifies every Completer middleware that the attempt is over. It is
+// safe to call with an empty state when BeforeRequest failed.
+func (c *Chain) Complete(ctx context.Context, request RequestContext, attempts AttemptState) {
+	if c == nil || len(attempts.attempts) != len(c.middlewares) {
+		return
+	}
+	c.completePartial(ctx, request, attempts.attempts)
+}
+
+// completePartial completes the leading middlewares that produced attempts,
+// in reverse order.
+func (c *Chain) completePartial(ctx context.Context, request RequestContext, attempts []Attempt) {
+	for i := len(attempts) - 1; i >= 0; i-- {
+		if completer, ok := c.middlewares[i].(Completer); ok {
+			completer.Complete(ctx, request, attempts[i])
+		}
+	}
+}
+
+// CompleteOnClose wraps a response body so Complete runs when the engine closes
+// it. Engines use it for streams whose lifetime extends past AfterResponse.
+func (c *Chain) CompleteOnClose(ctx context.Context, request RequestContext, attempts AttemptState, body io.ReadCloser) io.ReadCloser {
+	return &completingBody{ReadCloser: body, complete: func() { c.Complete(ctx, request, attempts) }}
+}
+
+type completingBody struct {
+	io.ReadCloser
+	once     sync.Once
+	complete func()
+}
+
+func (b *completingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.complete)
+	return err
+}
@@ -10,8 +10,8 @@ const (
 	ApiTypeFireworks       ApiType = "fireworks"
 	ApiTypeClaude          ApiType = "claude"
 	ApiTypeGemini          ApiType = "gemini"
+	ApiTypeOllama          ApiType = "ollama"
 	// not implemented from here on down
-	ApiTypeOllama     ApiType = "ollama"
 	ApiTypeMistral    ApiType = "mistral"
 	ApiTypePerplexity ApiType = "perplexity"
 	// Cohere has connectors
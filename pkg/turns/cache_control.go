@@ -0,0 +1,11 @@
+package turns
+
+// CacheControl marks a block as a prompt-cache breakpoint for providers with
+// explicit prompt caching (currently Anthropic Claude). The cached prefix ends
+// at, and includes, the marked block.
+//
+// Providers without explicit caching ignore the marker.
+type CacheControl struct {
+	// TTL is the requested cache lifetime, e.g. "5m" (provider default) or "1h".
+	TTL string `json:"ttl,omitempty" yaml:"ttl,omitempty" mapstructure:"ttl,omitempty"`
+}
@@ -0,0 +1,26 @@
+package turns
+
+// Citation links a span of an llm_text block to the source document it was
+// drawn from. Engines that return citations (currently Anthropic Claude for
+// documents sent with citations enabled) store them under
+// KeyBlockMetaCitations.
+type Citation struct {
+	// Start and End are byte offsets of the cited span in the block text.
+	Start int `json:"start" yaml:"start" mapstructure:"start"`
+	End   int `json:"end" yaml:"end" mapstructure:"end"`
+
+	// Type is the provider location type, e.g. "char_location" or "page_location".
+	Type      string `json:"type,omitempty" yaml:"type,omitempty" mapstructure:"type,omitempty"`
+	CitedText string `json:"cited_text,omitempty" yaml:"cited_text,omitempty" mapstructure:"cited_text,omitempty"`
+	// DocumentIndex is the position of the document among the documents of
+	// the request, counted across all user blocks.
+	DocumentIndex int    `json:"document_index" yaml:"document_index" mapstructure:"document_index"`
+	DocumentTitle string `json:"document_title,omitempty" yaml:"document_title,omitempty" mapstructure:"document_title,omitempty"`
+	URL           string `json:"url,omitempty" yaml:"url,omitempty" mapstructure:"url,omitempty"`
+
+	// Location inside the document, in the unit given by Type: characters for
+	// text documents, 1-based pages for PDFs, content blocks otherwise. End
+	// values are exclusive.
+	SourceStart *int `json:"source_start,omitempty" yaml:"source_start,omitempty" mapstructure:"source_start,omitempty"`
+	SourceEnd   *int `json:"source_end,omitempty" yaml:"source_end,omitempty" mapstructure:"source_end,omitempty"`
+}
@@ -39,6 +39,48 @@ func NewUserMultimodalBlock(text string, images []map[string]any) Block {
 	}
 }
 
+// NewUserDocumentBlock creates a user block with text and attached documents.
+// documents is a slice of maps with keys:
+//   - "media_type" (string), e.g. "application/pdf" or "text/plain"
+//   - one of "content" ([]byte, base64 string or data URL), "text" (plain-text
+//     document body) or "url" (string)
+//   - optional "title" and "context" (string)
+//   - optional "citations" (bool) to ask the provider for citations into the document
+func NewUserDocumentBlock(text string, documents []map[string]any) Block {
+	payload := map[string]any{PayloadKeyText: text}
+	if len(documents) > 0 {
+		payload[PayloadKeyDocuments] = documents
+	}
+	return Block{
+		ID:      uuid.NewString(),
+		Kind:    BlockKindUser,
+		Role:    RoleUser,
+		Payload: payload,
+	}
+}
+
+// NewUserFileBlock creates a user block with text and attached files such as
+// PDFs, audio clips or spreadsheets. files is a slice of maps with keys:
+//   - one of "path" (local file), "content" ([]byte, base64 string or data
+//     URL), "url", "file_id" (provider file ID) or "file_uri" (Gemini)
+//   - "media_type" (string), derived from "path" or a data URL when omitted
+//   - optional "filename" (string)
+//
+// Engines reject files whose media type the model or provider cannot accept;
+// see pkg/steps/ai/mediaparts.
+func NewUserFileBlock(text string, files []map[string]any) Block {
+	payload := map[string]any{PayloadKeyText: text}
+	if len(files) > 0 {
+		payload[PayloadKeyFiles] = files
+	}
+	return Block{
+		ID:      uuid.NewString(),
+		Kind:    BlockKindUser,
+		Role:    RoleUser,
+		Payload: payload,
+	}
+}
+
 // NewAssistantTextBlock returns a Block representing assistant LLM text output.
 func NewAssistantTextBlock(text string) Block {
 	return Block{
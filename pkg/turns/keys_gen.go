@@ -14,6 +14,8 @@ const (
 	PayloadKeyResult           = "result"
 	PayloadKeyError            = "error"
 	PayloadKeyImages           = "images"
+	PayloadKeyDocuments        = "documents"
+	PayloadKeyFiles            = "files"
 	PayloadKeyEncryptedContent = "encrypted_content"
 	PayloadKeySummary          = "summary"
 	PayloadKeyItemID           = "item_id"
@@ -30,6 +32,7 @@ const (
 	ToolConfigValueKey             = "tool_config"
 	ToolDefinitionsValueKey        = "tool_definitions"
 	StructuredOutputConfigValueKey = "structured_output_config"
+	StructuredOutputResultValueKey = "structured_output_result"
 	InferenceConfigValueKey        = "inference_config"
 	ClaudeInferenceConfigValueKey  = "claude_inference_config"
 	OpenAIInferenceConfigValueKey  = "openai_inference_config"
@@ -55,6 +58,8 @@ const (
 	BlockMetaAgentModeTagValueKey          = "agentmode_tag"
 	BlockMetaAgentModeValueKey             = "agentmode"
 	BlockMetaInferenceResultValueKey       = "inference_result"
+	BlockMetaCacheControlValueKey          = "cache_control"
+	BlockMetaCitationsValueKey             = "citations"
 )
 
 // Typed keys for Turn.Data owned by turns package.
@@ -85,4 +90,6 @@ var (
 	KeyBlockMetaAgentModeTag          = BlockMetaK[string](GeppettoNamespaceKey, BlockMetaAgentModeTagValueKey, 1)
 	KeyBlockMetaAgentMode             = BlockMetaK[string](GeppettoNamespaceKey, BlockMetaAgentModeValueKey, 1)
 	KeyBlockMetaInferenceResult       = BlockMetaK[InferenceResult](GeppettoNamespaceKey, BlockMetaInferenceResultValueKey, 1)
+	KeyBlockMetaCacheControl          = BlockMetaK[CacheControl](GeppettoNamespaceKey, BlockMetaCacheControlValueKey, 1)
+	KeyBlockMetaCitations             = BlockMetaK[[]Citation](GeppettoNamespaceKey, BlockMetaCitationsValueKey, 1)
 )